@@ -103,6 +103,8 @@ type EvalRequest struct {
 	Purpose       string                 `protobuf:"bytes,2,opt,name=purpose,proto3" json:"purpose,omitempty"`
 	TraceId       string                 `protobuf:"bytes,3,opt,name=trace_id,json=traceId,proto3" json:"trace_id,omitempty"`
 	AgentId       string                 `protobuf:"bytes,4,opt,name=agent_id,json=agentId,proto3" json:"agent_id,omitempty"`
+	ConfirmToken  string                 `protobuf:"bytes,5,opt,name=confirm_token,json=confirmToken,proto3" json:"confirm_token,omitempty"` // from a previous response; required to proceed with approved irreversible actions
+	DryRun        bool                   `protobuf:"varint,6,opt,name=dry_run,json=dryRun,proto3" json:"dry_run,omitempty"`                  // evaluate against a copy of the trace; nothing is recorded, alerted or consumed
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -165,6 +167,20 @@ func (x *EvalRequest) GetAgentId() string {
 	return ""
 }
 
+func (x *EvalRequest) GetConfirmToken() string {
+	if x != nil {
+		return x.ConfirmToken
+	}
+	return ""
+}
+
+func (x *EvalRequest) GetDryRun() bool {
+	if x != nil {
+		return x.DryRun
+	}
+	return false
+}
+
 type EvalResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Decision      string                 `protobuf:"bytes,1,opt,name=decision,proto3" json:"decision,omitempty"`
@@ -173,6 +189,8 @@ type EvalResponse struct {
 	PolicyId      string                 `protobuf:"bytes,4,opt,name=policy_id,json=policyId,proto3" json:"policy_id,omitempty"`
 	ApprovalKey   string                 `protobuf:"bytes,5,opt,name=approval_key,json=approvalKey,proto3" json:"approval_key,omitempty"`
 	TraceId       string                 `protobuf:"bytes,6,opt,name=trace_id,json=traceId,proto3" json:"trace_id,omitempty"`
+	Fingerprint   string                 `protobuf:"bytes,7,opt,name=fingerprint,proto3" json:"fingerprint,omitempty"`                       // stable action digest for cross-system correlation
+	ConfirmToken  string                 `protobuf:"bytes,8,opt,name=confirm_token,json=confirmToken,proto3" json:"confirm_token,omitempty"` // issued for approved irreversible actions; present it on the retry
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -249,10 +267,25 @@ func (x *EvalResponse) GetTraceId() string {
 	return ""
 }
 
+func (x *EvalResponse) GetFingerprint() string {
+	if x != nil {
+		return x.Fingerprint
+	}
+	return ""
+}
+
+func (x *EvalResponse) GetConfirmToken() string {
+	if x != nil {
+		return x.ConfirmToken
+	}
+	return ""
+}
+
 type ApproveRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
 	Duration      string                 `protobuf:"bytes,2,opt,name=duration,proto3" json:"duration,omitempty"`
+	Reason        string                 `protobuf:"bytes,3,opt,name=reason,proto3" json:"reason,omitempty"` // justification; required for require_reason keys
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -301,6 +334,13 @@ func (x *ApproveRequest) GetDuration() string {
 	return ""
 }
 
+func (x *ApproveRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
 type ApproveResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Key           string                 `protobuf:"bytes,1,opt,name=key,proto3" json:"key,omitempty"`
@@ -605,6 +645,222 @@ func (x *ListPendingResponse) GetApprovals() []*PendingApproval {
 	return nil
 }
 
+type ResetTraceRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TraceId       string                 `protobuf:"bytes,1,opt,name=trace_id,json=traceId,proto3" json:"trace_id,omitempty"`
+	Scope         string                 `protobuf:"bytes,2,opt,name=scope,proto3" json:"scope,omitempty"` // "all" (default): zones, seen sources and counters; "action_count": only the max_actions_per_trace counter
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResetTraceRequest) Reset() {
+	*x = ResetTraceRequest{}
+	mi := &file_api_proto_chainwatch_v1_chainwatch_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResetTraceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResetTraceRequest) ProtoMessage() {}
+
+func (x *ResetTraceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_chainwatch_v1_chainwatch_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResetTraceRequest.ProtoReflect.Descriptor instead.
+func (*ResetTraceRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_chainwatch_v1_chainwatch_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ResetTraceRequest) GetTraceId() string {
+	if x != nil {
+		return x.TraceId
+	}
+	return ""
+}
+
+func (x *ResetTraceRequest) GetScope() string {
+	if x != nil {
+		return x.Scope
+	}
+	return ""
+}
+
+type ResetTraceResponse struct {
+	state               protoimpl.MessageState `protogen:"open.v1"`
+	TraceId             string                 `protobuf:"bytes,1,opt,name=trace_id,json=traceId,proto3" json:"trace_id,omitempty"`
+	Found               bool                   `protobuf:"varint,2,opt,name=found,proto3" json:"found,omitempty"`
+	PreviousActionCount int32                  `protobuf:"varint,3,opt,name=previous_action_count,json=previousActionCount,proto3" json:"previous_action_count,omitempty"`
+	unknownFields       protoimpl.UnknownFields
+	sizeCache           protoimpl.SizeCache
+}
+
+func (x *ResetTraceResponse) Reset() {
+	*x = ResetTraceResponse{}
+	mi := &file_api_proto_chainwatch_v1_chainwatch_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResetTraceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResetTraceResponse) ProtoMessage() {}
+
+func (x *ResetTraceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_chainwatch_v1_chainwatch_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResetTraceResponse.ProtoReflect.Descriptor instead.
+func (*ResetTraceResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_chainwatch_v1_chainwatch_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ResetTraceResponse) GetTraceId() string {
+	if x != nil {
+		return x.TraceId
+	}
+	return ""
+}
+
+func (x *ResetTraceResponse) GetFound() bool {
+	if x != nil {
+		return x.Found
+	}
+	return false
+}
+
+func (x *ResetTraceResponse) GetPreviousActionCount() int32 {
+	if x != nil {
+		return x.PreviousActionCount
+	}
+	return 0
+}
+
+type ReloadProfileRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Profile       string                 `protobuf:"bytes,1,opt,name=profile,proto3" json:"profile,omitempty"` // profile name or comma-separated stack; empty re-applies the current one
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReloadProfileRequest) Reset() {
+	*x = ReloadProfileRequest{}
+	mi := &file_api_proto_chainwatch_v1_chainwatch_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReloadProfileRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReloadProfileRequest) ProtoMessage() {}
+
+func (x *ReloadProfileRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_chainwatch_v1_chainwatch_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReloadProfileRequest.ProtoReflect.Descriptor instead.
+func (*ReloadProfileRequest) Descriptor() ([]byte, []int) {
+	return file_api_proto_chainwatch_v1_chainwatch_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ReloadProfileRequest) GetProfile() string {
+	if x != nil {
+		return x.Profile
+	}
+	return ""
+}
+
+type ReloadProfileResponse struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	Profile         string                 `protobuf:"bytes,1,opt,name=profile,proto3" json:"profile,omitempty"`
+	PreviousProfile string                 `protobuf:"bytes,2,opt,name=previous_profile,json=previousProfile,proto3" json:"previous_profile,omitempty"`
+	PolicyHash      string                 `protobuf:"bytes,3,opt,name=policy_hash,json=policyHash,proto3" json:"policy_hash,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *ReloadProfileResponse) Reset() {
+	*x = ReloadProfileResponse{}
+	mi := &file_api_proto_chainwatch_v1_chainwatch_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReloadProfileResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReloadProfileResponse) ProtoMessage() {}
+
+func (x *ReloadProfileResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_api_proto_chainwatch_v1_chainwatch_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReloadProfileResponse.ProtoReflect.Descriptor instead.
+func (*ReloadProfileResponse) Descriptor() ([]byte, []int) {
+	return file_api_proto_chainwatch_v1_chainwatch_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ReloadProfileResponse) GetProfile() string {
+	if x != nil {
+		return x.Profile
+	}
+	return ""
+}
+
+func (x *ReloadProfileResponse) GetPreviousProfile() string {
+	if x != nil {
+		return x.PreviousProfile
+	}
+	return ""
+}
+
+func (x *ReloadProfileResponse) GetPolicyHash() string {
+	if x != nil {
+		return x.PolicyHash
+	}
+	return ""
+}
+
 var File_api_proto_chainwatch_v1_chainwatch_proto protoreflect.FileDescriptor
 
 const file_api_proto_chainwatch_v1_chainwatch_proto_rawDesc = "" +
@@ -621,22 +877,27 @@ const file_api_proto_chainwatch_v1_chainwatch_proto_rawDesc = "" +
 	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\x1a7\n" +
 	"\tMetaEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x8c\x01\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xca\x01\n" +
 	"\vEvalRequest\x12-\n" +
 	"\x06action\x18\x01 \x01(\v2\x15.chainwatch.v1.ActionR\x06action\x12\x18\n" +
 	"\apurpose\x18\x02 \x01(\tR\apurpose\x12\x19\n" +
 	"\btrace_id\x18\x03 \x01(\tR\atraceId\x12\x19\n" +
-	"\bagent_id\x18\x04 \x01(\tR\aagentId\"\xb1\x01\n" +
+	"\bagent_id\x18\x04 \x01(\tR\aagentId\x12#\n" +
+	"\rconfirm_token\x18\x05 \x01(\tR\fconfirmToken\x12\x17\n" +
+	"\adry_run\x18\x06 \x01(\bR\x06dryRun\"\xf8\x01\n" +
 	"\fEvalResponse\x12\x1a\n" +
 	"\bdecision\x18\x01 \x01(\tR\bdecision\x12\x16\n" +
 	"\x06reason\x18\x02 \x01(\tR\x06reason\x12\x12\n" +
 	"\x04tier\x18\x03 \x01(\x05R\x04tier\x12\x1b\n" +
 	"\tpolicy_id\x18\x04 \x01(\tR\bpolicyId\x12!\n" +
 	"\fapproval_key\x18\x05 \x01(\tR\vapprovalKey\x12\x19\n" +
-	"\btrace_id\x18\x06 \x01(\tR\atraceId\">\n" +
+	"\btrace_id\x18\x06 \x01(\tR\atraceId\x12 \n" +
+	"\vfingerprint\x18\a \x01(\tR\vfingerprint\x12#\n" +
+	"\rconfirm_token\x18\b \x01(\tR\fconfirmToken\"V\n" +
 	"\x0eApproveRequest\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x1a\n" +
-	"\bduration\x18\x02 \x01(\tR\bduration\";\n" +
+	"\bduration\x18\x02 \x01(\tR\bduration\x12\x16\n" +
+	"\x06reason\x18\x03 \x01(\tR\x06reason\";\n" +
 	"\x0fApproveResponse\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x16\n" +
 	"\x06status\x18\x02 \x01(\tR\x06status\"\x1f\n" +
@@ -654,12 +915,29 @@ const file_api_proto_chainwatch_v1_chainwatch_proto_rawDesc = "" +
 	"\n" +
 	"created_at\x18\x05 \x01(\tR\tcreatedAt\"S\n" +
 	"\x13ListPendingResponse\x12<\n" +
-	"\tapprovals\x18\x01 \x03(\v2\x1e.chainwatch.v1.PendingApprovalR\tapprovals2\xb9\x02\n" +
+	"\tapprovals\x18\x01 \x03(\v2\x1e.chainwatch.v1.PendingApprovalR\tapprovals\"D\n" +
+	"\x11ResetTraceRequest\x12\x19\n" +
+	"\btrace_id\x18\x01 \x01(\tR\atraceId\x12\x14\n" +
+	"\x05scope\x18\x02 \x01(\tR\x05scope\"y\n" +
+	"\x12ResetTraceResponse\x12\x19\n" +
+	"\btrace_id\x18\x01 \x01(\tR\atraceId\x12\x14\n" +
+	"\x05found\x18\x02 \x01(\bR\x05found\x122\n" +
+	"\x15previous_action_count\x18\x03 \x01(\x05R\x13previousActionCount\"0\n" +
+	"\x14ReloadProfileRequest\x12\x18\n" +
+	"\aprofile\x18\x01 \x01(\tR\aprofile\"}\n" +
+	"\x15ReloadProfileResponse\x12\x18\n" +
+	"\aprofile\x18\x01 \x01(\tR\aprofile\x12)\n" +
+	"\x10previous_profile\x18\x02 \x01(\tR\x0fpreviousProfile\x12\x1f\n" +
+	"\vpolicy_hash\x18\x03 \x01(\tR\n" +
+	"policyHash2\xe8\x03\n" +
 	"\x11ChainwatchService\x12C\n" +
 	"\bEvaluate\x12\x1a.chainwatch.v1.EvalRequest\x1a\x1b.chainwatch.v1.EvalResponse\x12H\n" +
 	"\aApprove\x12\x1d.chainwatch.v1.ApproveRequest\x1a\x1e.chainwatch.v1.ApproveResponse\x12?\n" +
 	"\x04Deny\x12\x1a.chainwatch.v1.DenyRequest\x1a\x1b.chainwatch.v1.DenyResponse\x12T\n" +
-	"\vListPending\x12!.chainwatch.v1.ListPendingRequest\x1a\".chainwatch.v1.ListPendingResponseBEZCgithub.com/ppiankov/chainwatch/api/proto/chainwatch/v1;chainwatchv1b\x06proto3"
+	"\vListPending\x12!.chainwatch.v1.ListPendingRequest\x1a\".chainwatch.v1.ListPendingResponse\x12Q\n" +
+	"\n" +
+	"ResetTrace\x12 .chainwatch.v1.ResetTraceRequest\x1a!.chainwatch.v1.ResetTraceResponse\x12Z\n" +
+	"\rReloadProfile\x12#.chainwatch.v1.ReloadProfileRequest\x1a$.chainwatch.v1.ReloadProfileResponseBEZCgithub.com/ppiankov/chainwatch/api/proto/chainwatch/v1;chainwatchv1b\x06proto3"
 
 var (
 	file_api_proto_chainwatch_v1_chainwatch_proto_rawDescOnce sync.Once
@@ -673,36 +951,44 @@ func file_api_proto_chainwatch_v1_chainwatch_proto_rawDescGZIP() []byte {
 	return file_api_proto_chainwatch_v1_chainwatch_proto_rawDescData
 }
 
-var file_api_proto_chainwatch_v1_chainwatch_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_api_proto_chainwatch_v1_chainwatch_proto_msgTypes = make([]protoimpl.MessageInfo, 16)
 var file_api_proto_chainwatch_v1_chainwatch_proto_goTypes = []any{
-	(*Action)(nil),              // 0: chainwatch.v1.Action
-	(*EvalRequest)(nil),         // 1: chainwatch.v1.EvalRequest
-	(*EvalResponse)(nil),        // 2: chainwatch.v1.EvalResponse
-	(*ApproveRequest)(nil),      // 3: chainwatch.v1.ApproveRequest
-	(*ApproveResponse)(nil),     // 4: chainwatch.v1.ApproveResponse
-	(*DenyRequest)(nil),         // 5: chainwatch.v1.DenyRequest
-	(*DenyResponse)(nil),        // 6: chainwatch.v1.DenyResponse
-	(*ListPendingRequest)(nil),  // 7: chainwatch.v1.ListPendingRequest
-	(*PendingApproval)(nil),     // 8: chainwatch.v1.PendingApproval
-	(*ListPendingResponse)(nil), // 9: chainwatch.v1.ListPendingResponse
-	nil,                         // 10: chainwatch.v1.Action.ParamsEntry
-	nil,                         // 11: chainwatch.v1.Action.MetaEntry
+	(*Action)(nil),                // 0: chainwatch.v1.Action
+	(*EvalRequest)(nil),           // 1: chainwatch.v1.EvalRequest
+	(*EvalResponse)(nil),          // 2: chainwatch.v1.EvalResponse
+	(*ApproveRequest)(nil),        // 3: chainwatch.v1.ApproveRequest
+	(*ApproveResponse)(nil),       // 4: chainwatch.v1.ApproveResponse
+	(*DenyRequest)(nil),           // 5: chainwatch.v1.DenyRequest
+	(*DenyResponse)(nil),          // 6: chainwatch.v1.DenyResponse
+	(*ListPendingRequest)(nil),    // 7: chainwatch.v1.ListPendingRequest
+	(*PendingApproval)(nil),       // 8: chainwatch.v1.PendingApproval
+	(*ListPendingResponse)(nil),   // 9: chainwatch.v1.ListPendingResponse
+	(*ResetTraceRequest)(nil),     // 10: chainwatch.v1.ResetTraceRequest
+	(*ResetTraceResponse)(nil),    // 11: chainwatch.v1.ResetTraceResponse
+	(*ReloadProfileRequest)(nil),  // 12: chainwatch.v1.ReloadProfileRequest
+	(*ReloadProfileResponse)(nil), // 13: chainwatch.v1.ReloadProfileResponse
+	nil,                           // 14: chainwatch.v1.Action.ParamsEntry
+	nil,                           // 15: chainwatch.v1.Action.MetaEntry
 }
 var file_api_proto_chainwatch_v1_chainwatch_proto_depIdxs = []int32{
-	10, // 0: chainwatch.v1.Action.params:type_name -> chainwatch.v1.Action.ParamsEntry
-	11, // 1: chainwatch.v1.Action.meta:type_name -> chainwatch.v1.Action.MetaEntry
+	14, // 0: chainwatch.v1.Action.params:type_name -> chainwatch.v1.Action.ParamsEntry
+	15, // 1: chainwatch.v1.Action.meta:type_name -> chainwatch.v1.Action.MetaEntry
 	0,  // 2: chainwatch.v1.EvalRequest.action:type_name -> chainwatch.v1.Action
 	8,  // 3: chainwatch.v1.ListPendingResponse.approvals:type_name -> chainwatch.v1.PendingApproval
 	1,  // 4: chainwatch.v1.ChainwatchService.Evaluate:input_type -> chainwatch.v1.EvalRequest
 	3,  // 5: chainwatch.v1.ChainwatchService.Approve:input_type -> chainwatch.v1.ApproveRequest
 	5,  // 6: chainwatch.v1.ChainwatchService.Deny:input_type -> chainwatch.v1.DenyRequest
 	7,  // 7: chainwatch.v1.ChainwatchService.ListPending:input_type -> chainwatch.v1.ListPendingRequest
-	2,  // 8: chainwatch.v1.ChainwatchService.Evaluate:output_type -> chainwatch.v1.EvalResponse
-	4,  // 9: chainwatch.v1.ChainwatchService.Approve:output_type -> chainwatch.v1.ApproveResponse
-	6,  // 10: chainwatch.v1.ChainwatchService.Deny:output_type -> chainwatch.v1.DenyResponse
-	9,  // 11: chainwatch.v1.ChainwatchService.ListPending:output_type -> chainwatch.v1.ListPendingResponse
-	8,  // [8:12] is the sub-list for method output_type
-	4,  // [4:8] is the sub-list for method input_type
+	10, // 8: chainwatch.v1.ChainwatchService.ResetTrace:input_type -> chainwatch.v1.ResetTraceRequest
+	12, // 9: chainwatch.v1.ChainwatchService.ReloadProfile:input_type -> chainwatch.v1.ReloadProfileRequest
+	2,  // 10: chainwatch.v1.ChainwatchService.Evaluate:output_type -> chainwatch.v1.EvalResponse
+	4,  // 11: chainwatch.v1.ChainwatchService.Approve:output_type -> chainwatch.v1.ApproveResponse
+	6,  // 12: chainwatch.v1.ChainwatchService.Deny:output_type -> chainwatch.v1.DenyResponse
+	9,  // 13: chainwatch.v1.ChainwatchService.ListPending:output_type -> chainwatch.v1.ListPendingResponse
+	11, // 14: chainwatch.v1.ChainwatchService.ResetTrace:output_type -> chainwatch.v1.ResetTraceResponse
+	13, // 15: chainwatch.v1.ChainwatchService.ReloadProfile:output_type -> chainwatch.v1.ReloadProfileResponse
+	10, // [10:16] is the sub-list for method output_type
+	4,  // [4:10] is the sub-list for method input_type
 	4,  // [4:4] is the sub-list for extension type_name
 	4,  // [4:4] is the sub-list for extension extendee
 	0,  // [0:4] is the sub-list for field type_name
@@ -719,7 +1005,7 @@ func file_api_proto_chainwatch_v1_chainwatch_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_api_proto_chainwatch_v1_chainwatch_proto_rawDesc), len(file_api_proto_chainwatch_v1_chainwatch_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   12,
+			NumMessages:   16,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
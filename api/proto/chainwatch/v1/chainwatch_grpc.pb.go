@@ -19,10 +19,12 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	ChainwatchService_Evaluate_FullMethodName    = "/chainwatch.v1.ChainwatchService/Evaluate"
-	ChainwatchService_Approve_FullMethodName     = "/chainwatch.v1.ChainwatchService/Approve"
-	ChainwatchService_Deny_FullMethodName        = "/chainwatch.v1.ChainwatchService/Deny"
-	ChainwatchService_ListPending_FullMethodName = "/chainwatch.v1.ChainwatchService/ListPending"
+	ChainwatchService_Evaluate_FullMethodName      = "/chainwatch.v1.ChainwatchService/Evaluate"
+	ChainwatchService_Approve_FullMethodName       = "/chainwatch.v1.ChainwatchService/Approve"
+	ChainwatchService_Deny_FullMethodName          = "/chainwatch.v1.ChainwatchService/Deny"
+	ChainwatchService_ListPending_FullMethodName   = "/chainwatch.v1.ChainwatchService/ListPending"
+	ChainwatchService_ResetTrace_FullMethodName    = "/chainwatch.v1.ChainwatchService/ResetTrace"
+	ChainwatchService_ReloadProfile_FullMethodName = "/chainwatch.v1.ChainwatchService/ReloadProfile"
 )
 
 // ChainwatchServiceClient is the client API for ChainwatchService service.
@@ -33,6 +35,8 @@ type ChainwatchServiceClient interface {
 	Approve(ctx context.Context, in *ApproveRequest, opts ...grpc.CallOption) (*ApproveResponse, error)
 	Deny(ctx context.Context, in *DenyRequest, opts ...grpc.CallOption) (*DenyResponse, error)
 	ListPending(ctx context.Context, in *ListPendingRequest, opts ...grpc.CallOption) (*ListPendingResponse, error)
+	ResetTrace(ctx context.Context, in *ResetTraceRequest, opts ...grpc.CallOption) (*ResetTraceResponse, error)
+	ReloadProfile(ctx context.Context, in *ReloadProfileRequest, opts ...grpc.CallOption) (*ReloadProfileResponse, error)
 }
 
 type chainwatchServiceClient struct {
@@ -83,6 +87,26 @@ func (c *chainwatchServiceClient) ListPending(ctx context.Context, in *ListPendi
 	return out, nil
 }
 
+func (c *chainwatchServiceClient) ResetTrace(ctx context.Context, in *ResetTraceRequest, opts ...grpc.CallOption) (*ResetTraceResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ResetTraceResponse)
+	err := c.cc.Invoke(ctx, ChainwatchService_ResetTrace_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *chainwatchServiceClient) ReloadProfile(ctx context.Context, in *ReloadProfileRequest, opts ...grpc.CallOption) (*ReloadProfileResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReloadProfileResponse)
+	err := c.cc.Invoke(ctx, ChainwatchService_ReloadProfile_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ChainwatchServiceServer is the server API for ChainwatchService service.
 // All implementations must embed UnimplementedChainwatchServiceServer
 // for forward compatibility.
@@ -91,6 +115,8 @@ type ChainwatchServiceServer interface {
 	Approve(context.Context, *ApproveRequest) (*ApproveResponse, error)
 	Deny(context.Context, *DenyRequest) (*DenyResponse, error)
 	ListPending(context.Context, *ListPendingRequest) (*ListPendingResponse, error)
+	ResetTrace(context.Context, *ResetTraceRequest) (*ResetTraceResponse, error)
+	ReloadProfile(context.Context, *ReloadProfileRequest) (*ReloadProfileResponse, error)
 	mustEmbedUnimplementedChainwatchServiceServer()
 }
 
@@ -113,6 +139,12 @@ func (UnimplementedChainwatchServiceServer) Deny(context.Context, *DenyRequest)
 func (UnimplementedChainwatchServiceServer) ListPending(context.Context, *ListPendingRequest) (*ListPendingResponse, error) {
 	return nil, status.Error(codes.Unimplemented, "method ListPending not implemented")
 }
+func (UnimplementedChainwatchServiceServer) ResetTrace(context.Context, *ResetTraceRequest) (*ResetTraceResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ResetTrace not implemented")
+}
+func (UnimplementedChainwatchServiceServer) ReloadProfile(context.Context, *ReloadProfileRequest) (*ReloadProfileResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ReloadProfile not implemented")
+}
 func (UnimplementedChainwatchServiceServer) mustEmbedUnimplementedChainwatchServiceServer() {}
 func (UnimplementedChainwatchServiceServer) testEmbeddedByValue()                           {}
 
@@ -206,6 +238,42 @@ func _ChainwatchService_ListPending_Handler(srv interface{}, ctx context.Context
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ChainwatchService_ResetTrace_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ResetTraceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChainwatchServiceServer).ResetTrace(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ChainwatchService_ResetTrace_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChainwatchServiceServer).ResetTrace(ctx, req.(*ResetTraceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ChainwatchService_ReloadProfile_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReloadProfileRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ChainwatchServiceServer).ReloadProfile(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ChainwatchService_ReloadProfile_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ChainwatchServiceServer).ReloadProfile(ctx, req.(*ReloadProfileRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ChainwatchService_ServiceDesc is the grpc.ServiceDesc for ChainwatchService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -229,6 +297,14 @@ var ChainwatchService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ListPending",
 			Handler:    _ChainwatchService_ListPending_Handler,
 		},
+		{
+			MethodName: "ResetTrace",
+			Handler:    _ChainwatchService_ResetTrace_Handler,
+		},
+		{
+			MethodName: "ReloadProfile",
+			Handler:    _ChainwatchService_ReloadProfile_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "api/proto/chainwatch/v1/chainwatch.proto",
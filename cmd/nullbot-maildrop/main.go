@@ -5,6 +5,12 @@
 //
 //	nullbot: |/usr/local/bin/nullbot-maildrop
 //
+// With --dry-run the email is validated (allowlist, rate limit without
+// consuming it) and the job that would be created is printed as JSON;
+// nothing is written to the inbox. Exit status is 1 if it would be rejected:
+//
+//	nullbot-maildrop --dry-run < test.eml
+//
 // Environment variables:
 //
 //	NULLBOT_INBOX      inbox directory (default: /home/nullbot/inbox)
@@ -13,6 +19,8 @@
 package main
 
 import (
+	"encoding/json"
+	"flag"
 	"fmt"
 	"io"
 	"os"
@@ -23,6 +31,9 @@ import (
 )
 
 func main() {
+	dryRun := flag.Bool("dry-run", false, "validate the email and print the job that would be created without writing it")
+	flag.Parse()
+
 	cfg := maildrop.Config{
 		InboxDir:      envOrDefault("NULLBOT_INBOX", "/home/nullbot/inbox"),
 		AllowlistFile: envOrDefault("NULLBOT_ALLOWLIST", "/home/nullbot/config/allowlist.txt"),
@@ -42,6 +53,16 @@ func main() {
 		os.Exit(1)
 	}
 
+	if *dryRun {
+		v := maildrop.Validate(cfg, raw)
+		out, _ := json.MarshalIndent(v, "", "  ")
+		fmt.Println(string(out))
+		if !v.Allowed {
+			os.Exit(1)
+		}
+		return
+	}
+
 	if err := maildrop.ProcessEmail(cfg, raw); err != nil {
 		fmt.Fprintf(os.Stderr, "nullbot-maildrop: %v\n", err)
 		os.Exit(1)
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// defaultCheckpointDir holds per-mission checkpoint files.
+const defaultCheckpointDir = "/tmp/nullbot-missions"
+
+// maxCheckpointOutput bounds the step output kept in a checkpoint.
+const maxCheckpointOutput = 4096
+
+// stepRecord is the checkpointed result of one executed plan step.
+type stepRecord struct {
+	Index       int    `json:"index"`
+	Cmd         string `json:"cmd"`
+	Decision    string `json:"decision"` // allowed, blocked, or failed
+	ExitCode    int    `json:"exit_code"`
+	ApprovalKey string `json:"approval_key,omitempty"`
+	Approved    bool   `json:"approved,omitempty"`
+	Output      string `json:"output,omitempty"`
+	FinishedAt  string `json:"finished_at"`
+}
+
+// checkpoint is the on-disk state of a mission, written after every step.
+// The plan is stored so a resumed run executes exactly what was planned
+// instead of asking the LLM again.
+type checkpoint struct {
+	MissionID string       `json:"mission_id"`
+	Goal      string       `json:"goal"`
+	LLMSource string       `json:"llm_source"`
+	Steps     []step       `json:"steps"`
+	NextStep  int          `json:"next_step"` // index of the first step not yet completed
+	Completed []stepRecord `json:"completed"`
+	Failed    *stepRecord  `json:"failed,omitempty"`
+	Done      bool         `json:"done"`
+	UpdatedAt string       `json:"updated_at"`
+
+	path string
+}
+
+// missionID derives a stable identifier from the mission text so that
+// re-running the same mission finds its checkpoint.
+func missionID(mission string) string {
+	sum := sha256.Sum256([]byte(mission))
+	return hex.EncodeToString(sum[:8])
+}
+
+// checkpointPath returns the checkpoint file for a mission.
+func checkpointPath(dir, mission string) string {
+	return filepath.Join(dir, "mission-"+missionID(mission)+".json")
+}
+
+// newCheckpoint starts a fresh checkpoint for a planned mission.
+func newCheckpoint(dir, mission string, p *plan, llmSource string) *checkpoint {
+	return &checkpoint{
+		MissionID: missionID(mission),
+		Goal:      p.Goal,
+		LLMSource: llmSource,
+		Steps:     p.Steps,
+		path:      checkpointPath(dir, mission),
+	}
+}
+
+// loadCheckpoint reads the checkpoint for a mission.
+func loadCheckpoint(dir, mission string) (*checkpoint, error) {
+	path := checkpointPath(dir, mission)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("no checkpoint for this mission at %s", path)
+		}
+		return nil, fmt.Errorf("read checkpoint: %w", err)
+	}
+	var cp checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("parse checkpoint %s: %w", path, err)
+	}
+	if cp.NextStep < 0 || cp.NextStep > len(cp.Steps) {
+		return nil, fmt.Errorf("checkpoint %s: next_step %d out of range", path, cp.NextStep)
+	}
+	cp.path = path
+	return &cp, nil
+}
+
+// plan returns the checkpointed plan.
+func (c *checkpoint) plan() *plan {
+	return &plan{Goal: c.Goal, Steps: c.Steps}
+}
+
+// record marks a step finished. Allowed and blocked steps are complete and
+// advance NextStep; a failed step is kept as Failed so resume retries it.
+func (c *checkpoint) record(rec stepRecord) error {
+	rec.FinishedAt = time.Now().UTC().Format(time.RFC3339)
+	if len(rec.Output) > maxCheckpointOutput {
+		rec.Output = rec.Output[:maxCheckpointOutput]
+	}
+	if rec.Decision == "failed" {
+		c.Failed = &rec
+	} else {
+		c.Completed = append(c.Completed, rec)
+		c.NextStep = rec.Index + 1
+		c.Failed = nil
+	}
+	c.Done = c.NextStep >= len(c.Steps)
+	return c.save()
+}
+
+// save writes the checkpoint atomically. Commands may carry detokenized
+// sensitive values, so the file is private to the owner.
+func (c *checkpoint) save() error {
+	c.UpdatedAt = time.Now().UTC().Format(time.RFC3339)
+	if err := os.MkdirAll(filepath.Dir(c.path), 0700); err != nil {
+		return fmt.Errorf("create checkpoint dir: %w", err)
+	}
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal checkpoint: %w", err)
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("write checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return fmt.Errorf("write checkpoint: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeFlakyMock writes a fake chainwatch that logs every executed command
+// and fails the command "flaky" until the marker file exists.
+func writeFlakyMock(t *testing.T, dir string) (chainwatch, runLog, marker string) {
+	t.Helper()
+	chainwatch = filepath.Join(dir, "chainwatch")
+	runLog = filepath.Join(dir, "ran.log")
+	marker = filepath.Join(dir, "fixed")
+	writeExecutable(t, chainwatch, "#!/bin/sh\n"+
+		"[ \"$1\" = exec ] || exit 0\n"+
+		"cmd=\"$9\"\n"+
+		"echo \"$cmd\" >> "+runLog+"\n"+
+		"if [ \"$cmd\" = flaky ] && [ ! -f "+marker+" ]; then echo boom; exit 3; fi\n"+
+		"echo \"ran $cmd\"\n")
+	return chainwatch, runLog, marker
+}
+
+func noPause(t *testing.T) {
+	t.Helper()
+	orig := pause
+	pause = func(time.Duration) {}
+	t.Cleanup(func() { pause = orig })
+}
+
+func executedCommands(t *testing.T, runLog string) []string {
+	t.Helper()
+	data, err := os.ReadFile(runLog)
+	if err != nil {
+		t.Fatalf("read run log: %v", err)
+	}
+	return strings.Fields(string(data))
+}
+
+func TestResumeSkipsCompletedSteps(t *testing.T) {
+	noPause(t)
+	dir := t.TempDir()
+	chainwatch, runLog, marker := writeFlakyMock(t, dir)
+	cfg := config{profile: "clawbot", checkpointDir: filepath.Join(dir, "missions")}
+	mission := "tidy up"
+	p := &plan{Goal: "tidy", Steps: []step{{Cmd: "first"}, {Cmd: "flaky"}, {Cmd: "third"}}}
+
+	cp := newCheckpoint(cfg.checkpointDir, mission, p, "live")
+	if _, _, err := executeSteps(cfg, chainwatch, filepath.Join(dir, "audit.jsonl"), cp, nil); err == nil {
+		t.Fatal("expected first run to stop at the failing step")
+	}
+
+	saved, err := loadCheckpoint(cfg.checkpointDir, mission)
+	if err != nil {
+		t.Fatalf("loadCheckpoint: %v", err)
+	}
+	if saved.NextStep != 1 || len(saved.Completed) != 1 || saved.Failed == nil || saved.Failed.Index != 1 {
+		t.Fatalf("checkpoint after failure = next %d, completed %d, failed %+v", saved.NextStep, len(saved.Completed), saved.Failed)
+	}
+	if saved.Done {
+		t.Fatal("mission must not be done after a failed step")
+	}
+
+	// Fix the failure and resume.
+	os.WriteFile(marker, nil, 0600)
+	allowed, _, err := executeSteps(cfg, chainwatch, filepath.Join(dir, "audit.jsonl"), saved, nil)
+	if err != nil {
+		t.Fatalf("resumed run: %v", err)
+	}
+	if allowed != 3 {
+		t.Errorf("allowed = %d, want 3 (1 from first run + 2 resumed)", allowed)
+	}
+
+	got := executedCommands(t, runLog)
+	want := []string{"first", "flaky", "flaky", "third"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Fatalf("executed %v, want %v (first must not re-run)", got, want)
+	}
+
+	final, err := loadCheckpoint(cfg.checkpointDir, mission)
+	if err != nil {
+		t.Fatalf("loadCheckpoint: %v", err)
+	}
+	if !final.Done || final.Failed != nil || len(final.Completed) != 3 {
+		t.Errorf("final checkpoint = done %v, failed %+v, completed %d", final.Done, final.Failed, len(final.Completed))
+	}
+}
+
+func TestRunMissionResumeCompletedMissionIsNoop(t *testing.T) {
+	noPause(t)
+	dir := t.TempDir()
+	chainwatch, runLog, _ := writeFlakyMock(t, dir)
+	t.Setenv("CHAINWATCH_BIN", chainwatch)
+	cfg := config{profile: "clawbot", checkpointDir: filepath.Join(dir, "missions"), resume: true}
+
+	cp := newCheckpoint(cfg.checkpointDir, "done mission", &plan{Steps: []step{{Cmd: "first"}}}, "live")
+	if err := cp.record(stepRecord{Index: 0, Cmd: "first", Decision: "allowed"}); err != nil {
+		t.Fatalf("record: %v", err)
+	}
+
+	if err := runMission(cfg, "done mission"); err != nil {
+		t.Fatalf("runMission: %v", err)
+	}
+	if _, err := os.Stat(runLog); !os.IsNotExist(err) {
+		t.Error("resuming a completed mission must not execute any step")
+	}
+}
+
+func TestLoadCheckpointMissing(t *testing.T) {
+	if _, err := loadCheckpoint(t.TempDir(), "never ran"); err == nil {
+		t.Fatal("expected error for mission without checkpoint")
+	}
+}
+
+func TestCheckpointFilePrivate(t *testing.T) {
+	dir := t.TempDir()
+	cp := newCheckpoint(dir, "m", &plan{Steps: []step{{Cmd: "x"}}}, "live")
+	if err := cp.save(); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	info, err := os.Stat(cp.path)
+	if err != nil {
+		t.Fatalf("stat: %v", err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("checkpoint mode = %o, want 600", info.Mode().Perm())
+	}
+}
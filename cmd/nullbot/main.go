@@ -5,6 +5,7 @@
 package main
 
 import (
+	"bufio"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -17,12 +18,15 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/ppiankov/chainwatch/internal/alert"
 	"github.com/ppiankov/chainwatch/internal/daemon"
 	"github.com/ppiankov/chainwatch/internal/integrity"
 	"github.com/ppiankov/chainwatch/internal/inventory"
 	"github.com/ppiankov/chainwatch/internal/observe"
+	"github.com/ppiankov/chainwatch/internal/policy"
 	"github.com/ppiankov/chainwatch/internal/profile"
 	"github.com/ppiankov/chainwatch/internal/redact"
+	"github.com/ppiankov/chainwatch/internal/server"
 	"github.com/ppiankov/chainwatch/internal/systemd"
 	"github.com/ppiankov/chainwatch/internal/wo"
 	"github.com/ppiankov/neurorouter"
@@ -48,6 +52,8 @@ const (
 	defaultProfile                   = "clawbot"
 	defaultMaxSteps                  = 8
 	defaultObserveScopeFromInventory = "/var/lib/clickhouse"
+	defaultObserveFollowInterval     = 5 * time.Minute
+	alertFlushTimeout                = 10 * time.Second
 
 	// defaultMission is the sysadmin brief used in CI and when no args given with GROQ_API_KEY set.
 	defaultMission = `You are a Linux system administration agent. Your task:
@@ -85,6 +91,12 @@ type config struct {
 	llmRateLimit  int // requests per minute; 0 = unlimited
 	llmFallbacks  []observe.LLMProvider
 	llmPool       []observe.LLMProvider
+
+	approveInteractive bool // prompt on require_approval blocks and retry
+
+	checkpointDir string // per-mission checkpoint files; empty uses defaultCheckpointDir
+	resume        bool   // continue from the last completed step of a checkpointed mission
+	auditLog      string // --audit-log; empty falls back to AUDIT_LOG, then the command default
 }
 
 // step is a single command proposed by the LLM.
@@ -262,6 +274,12 @@ func readKeyFile(path string) string {
 	return strings.TrimSpace(string(data))
 }
 
+// resolveAuditLog returns the audit log passed to chainwatch exec.
+// Resolution order: flag → AUDIT_LOG → fallback.
+func resolveAuditLog(flagAuditLog, fallback string) string {
+	return firstNonEmpty(flagAuditLog, os.Getenv("AUDIT_LOG"), fallback)
+}
+
 func firstNonEmpty(vals ...string) string {
 	for _, v := range vals {
 		if v != "" {
@@ -337,10 +355,7 @@ func runMission(cfg config, mission string) error {
 	if chainwatch == "" {
 		chainwatch = "chainwatch"
 	}
-	auditLog := os.Getenv("AUDIT_LOG")
-	if auditLog == "" {
-		auditLog = "/tmp/nullbot-audit.jsonl"
-	}
+	auditLog := resolveAuditLog(cfg.auditLog, "/tmp/nullbot-audit.jsonl")
 
 	// --- Phase 0: Verify chainwatch ---
 	fmt.Printf("%s%s=== CHAINWATCH ===%s\n", bold, cyan, reset)
@@ -349,6 +364,117 @@ func runMission(cfg config, mission string) error {
 	fmt.Println()
 	time.Sleep(500 * time.Millisecond)
 
+	checkpointDir := cfg.checkpointDir
+	if checkpointDir == "" {
+		checkpointDir = defaultCheckpointDir
+	}
+
+	var (
+		p         *plan
+		llmSource string
+		cp        *checkpoint
+	)
+
+	if cfg.resume {
+		loaded, err := loadCheckpoint(checkpointDir, mission)
+		if err != nil {
+			return fmt.Errorf("resume: %w", err)
+		}
+		if loaded.Done {
+			fmt.Printf("%sMission %s already complete (%d steps) — nothing to resume.%s\n", dim, loaded.MissionID, len(loaded.Steps), reset)
+			return nil
+		}
+		cp = loaded
+		p = cp.plan()
+		llmSource = cp.LLMSource + " (resumed)"
+		fmt.Printf("%s%s=== RESUMING MISSION %s ===%s\n\n", bold, cyan, cp.MissionID, reset)
+		fmt.Printf("%sCheckpoint: %s%s\n", dim, cp.path, reset)
+		fmt.Printf("%sCompleted: %d/%d steps — continuing at step %d%s\n", dim, len(cp.Completed), len(cp.Steps), cp.NextStep+1, reset)
+	} else {
+		planned, source, err := planMission(cfg, mission)
+		if err != nil {
+			return err
+		}
+		p, llmSource = planned, source
+	}
+
+	fmt.Printf("\n%sGoal:%s %s\n", bold, reset, p.Goal)
+	fmt.Printf("%sSource: %s | Steps: %d%s\n\n", dim, llmSource, len(p.Steps), reset)
+	time.Sleep(800 * time.Millisecond)
+
+	// Show the raw plan.
+	fmt.Printf("%s%s=== LLM PROPOSED PLAN ===%s\n\n", bold, yellow, reset)
+	for i, s := range p.Steps {
+		fmt.Printf("  %d. %s%-40s%s %s(%s)%s\n", i+1, bold, s.Cmd, reset, dim, s.Why, reset)
+	}
+	fmt.Println()
+	time.Sleep(1 * time.Second)
+
+	if cfg.dryRun {
+		fmt.Printf("%s%sDry run — no commands executed.%s\n", bold, yellow, reset)
+		return nil
+	}
+
+	if cp == nil {
+		cp = newCheckpoint(checkpointDir, mission, p, llmSource)
+		if err := cp.save(); err != nil {
+			return err
+		}
+	}
+
+	// --- Phase 2: Configure guardrail ---
+	fmt.Printf("%s%sGuardrail active%s\n", bold, green, reset)
+	fmt.Printf("%sProfile:     %s%s\n", dim, cfg.profile, reset)
+	fmt.Printf("%sEnforcement: every command routed through chainwatch exec%s\n", dim, reset)
+	fmt.Printf("%sAudit log:   %s%s\n", dim, auditLog, reset)
+	fmt.Printf("%sCheckpoint:  %s%s\n\n", dim, cp.path, reset)
+	time.Sleep(800 * time.Millisecond)
+
+	// Interactive approval only when a human can answer the prompt.
+	var approver approveFunc
+	if cfg.approveInteractive {
+		if stdinIsTerminal() {
+			stdin := bufio.NewReader(os.Stdin)
+			approver = func(key, command string) bool {
+				return promptApproval(stdin, os.Stdout, key, command)
+			}
+		} else {
+			fmt.Printf("%s--approve-interactive ignored: stdin is not a terminal%s\n\n", dim, reset)
+		}
+	}
+
+	// --- Phase 3: Execute each step through chainwatch ---
+	fmt.Printf("%s%s=== EXECUTING ===%s\n\n", bold, cyan, reset)
+	allowed, blocked, err := executeSteps(cfg, chainwatch, auditLog, cp, approver)
+	if err != nil {
+		return err
+	}
+
+	// --- Phase 4: Results ---
+	fmt.Printf("%s=== RESULTS ===%s\n\n", bold, reset)
+	fmt.Printf("  Tasks: %d  |  %sAllowed: %d%s  |  %sBlocked: %d%s\n", len(p.Steps), green, allowed, reset, red, blocked, reset)
+	fmt.Printf("  %sLLM source: %s%s\n\n", dim, llmSource, reset)
+	time.Sleep(1 * time.Second)
+
+	fmt.Printf("%sVerifying audit chain integrity...%s\n", cyan, reset)
+	verify := exec.Command(chainwatch, "audit", "verify", auditLog)
+	verify.Stdout = os.Stdout
+	verify.Stderr = os.Stderr
+	_ = verify.Run()
+	fmt.Println()
+	time.Sleep(1 * time.Second)
+
+	fmt.Printf("%s%sField test complete. LLM proposed; chainwatch enforced.%s\n", bold, green, reset)
+	time.Sleep(3 * time.Second)
+
+	// Signal the driver (CI VHS recording).
+	_ = os.WriteFile("/tmp/release-demo-done", []byte("done"), 0644)
+	return nil
+}
+
+// planMission asks the LLM for a plan (falling back to the built-in plan)
+// and restores redacted values in the result.
+func planMission(cfg config, mission string) (*plan, string, error) {
 	// --- Phase 1: LLM generates the plan ---
 	fmt.Printf("%s%s=== AGENT PLANNING ===%s\n\n", bold, cyan, reset)
 	time.Sleep(300 * time.Millisecond)
@@ -407,12 +533,18 @@ func runMission(cfg config, mission string) error {
 		for _, s := range p.Steps {
 			allCmds += " " + s.Cmd + " " + s.Why
 		}
-		if leaks := redact.CheckLeaks(allCmds, tokenMap); len(leaks) > 0 {
+		report := redact.VerifyFidelity(allCmds, tokenMap, redact.FidelityOptions{})
+		if len(report.Leaks) > 0 {
 			fmt.Printf("\n  %sLEAK DETECTED%s — LLM response contains literal sensitive data:%s\n", bold, red, reset)
-			for _, leak := range leaks {
+			for _, leak := range report.Leaks {
 				fmt.Printf("    %s• %s%s\n", red, leak, reset)
 			}
-			return fmt.Errorf("redaction leak: LLM exposed %d sensitive values", len(leaks))
+			return nil, "", fmt.Errorf("redaction leak: LLM exposed %d sensitive values", len(report.Leaks))
+		}
+		// Unmapped tokens would survive detokening and run literally.
+		if err := report.Err(); err != nil {
+			fmt.Printf("\n  %sUNSAFE PLAN%s — %v\n", bold+red, reset, err)
+			return nil, "", err
 		}
 
 		// Detoken: restore real values in commands before execution.
@@ -423,92 +555,81 @@ func runMission(cfg config, mission string) error {
 		}
 	}
 
-	fmt.Printf("\n%sGoal:%s %s\n", bold, reset, p.Goal)
-	fmt.Printf("%sSource: %s | Steps: %d%s\n\n", dim, llmSource, len(p.Steps), reset)
-	time.Sleep(800 * time.Millisecond)
+	return p, llmSource, nil
+}
 
-	// Show the raw plan.
-	fmt.Printf("%s%s=== LLM PROPOSED PLAN ===%s\n\n", bold, yellow, reset)
-	for i, s := range p.Steps {
-		fmt.Printf("  %d. %s%-40s%s %s(%s)%s\n", i+1, bold, s.Cmd, reset, dim, s.Why, reset)
+// executeSteps runs the remaining plan steps through chainwatch, writing the
+// checkpoint after each one. Steps completed by an earlier run are skipped.
+// A step that fails (non-zero exit other than a policy block) stops the
+// mission so `--resume` can retry from that point.
+func executeSteps(cfg config, chainwatch, auditLog string, cp *checkpoint, approver approveFunc) (allowed, blocked int, err error) {
+	for _, rec := range cp.Completed {
+		switch rec.Decision {
+		case "allowed":
+			allowed++
+		case "blocked":
+			blocked++
+		}
 	}
-	fmt.Println()
-	time.Sleep(1 * time.Second)
-
-	if cfg.dryRun {
-		fmt.Printf("%s%sDry run — no commands executed.%s\n", bold, yellow, reset)
-		return nil
+	for i := 0; i < cp.NextStep; i++ {
+		fmt.Printf("%s[%d/%d] skipped (completed in earlier run)%s\n", dim, i+1, len(cp.Steps), reset)
+	}
+	if cp.NextStep > 0 {
+		fmt.Println()
 	}
 
-	// --- Phase 2: Configure guardrail ---
-	fmt.Printf("%s%sGuardrail active%s\n", bold, green, reset)
-	fmt.Printf("%sProfile:     %s%s\n", dim, cfg.profile, reset)
-	fmt.Printf("%sEnforcement: every command routed through chainwatch exec%s\n", dim, reset)
-	fmt.Printf("%sAudit log:   %s%s\n\n", dim, auditLog, reset)
-	time.Sleep(800 * time.Millisecond)
-
-	// --- Phase 3: Execute each step through chainwatch ---
-	fmt.Printf("%s%s=== EXECUTING ===%s\n\n", bold, cyan, reset)
-	var allowed, blocked int
-
-	for i, s := range p.Steps {
+	for i := cp.NextStep; i < len(cp.Steps); i++ {
+		s := cp.Steps[i]
 		num := i + 1
-		fmt.Printf("%s[%d/%d]%s %s\n", bold, num, len(p.Steps), reset, s.Why)
+		fmt.Printf("%s[%d/%d]%s %s\n", bold, num, len(cp.Steps), reset, s.Why)
 		fmt.Printf("  %s$ %s%s\n", dim, s.Cmd, reset)
-		time.Sleep(300 * time.Millisecond)
+		pause(300 * time.Millisecond)
 
-		args := []string{"exec", "--profile", cfg.profile, "--audit-log", auditLog, "--"}
-		args = append(args, "sh", "-c", s.Cmd)
+		outcome := execStep(chainwatch, cfg.profile, auditLog, s.Cmd, approver)
+		if outcome.approved {
+			fmt.Printf("  %sAPPROVED%s %s (retried)\n", green, reset, outcome.approvalKey)
+		}
 
-		cmd := exec.Command(chainwatch, args...)
-		out, err := cmd.CombinedOutput()
+		rec := stepRecord{
+			Index:       i,
+			Cmd:         s.Cmd,
+			ExitCode:    outcome.exitCode,
+			ApprovalKey: outcome.approvalKey,
+			Approved:    outcome.approved,
+			Output:      strings.TrimSpace(string(outcome.output)),
+		}
 
-		exitCode := 0
-		if err != nil {
-			if exitErr, ok := err.(*exec.ExitError); ok {
-				exitCode = exitErr.ExitCode()
+		switch outcome.exitCode {
+		case exitBlocked:
+			if outcome.approvalKey != "" {
+				fmt.Printf("  %sBLOCKED%s by chainwatch (approval required: %s)\n", red, reset, outcome.approvalKey)
 			} else {
-				exitCode = 1
+				fmt.Printf("  %sBLOCKED%s by chainwatch\n", red, reset)
 			}
-		}
-
-		switch exitCode {
-		case 77:
-			fmt.Printf("  %sBLOCKED%s by chainwatch\n", red, reset)
+			rec.Decision = "blocked"
 			blocked++
 		case 0:
-			output := strings.TrimSpace(string(out))
-			lines := strings.SplitN(output, "\n", 3)
+			lines := strings.SplitN(rec.Output, "\n", 3)
 			short := strings.Join(lines[:min(len(lines), 2)], " ")
 			fmt.Printf("  %sOK%s %s\n", green, reset, short)
+			rec.Decision = "allowed"
 			allowed++
 		default:
-			fmt.Printf("  %sERROR%s exit=%d\n", red, reset, exitCode)
+			fmt.Printf("  %sERROR%s exit=%d\n", red, reset, outcome.exitCode)
+			rec.Decision = "failed"
+		}
+
+		if err := cp.record(rec); err != nil {
+			return allowed, blocked, err
+		}
+		if rec.Decision == "failed" {
+			fmt.Printf("\n  %sMission stopped at step %d. Re-run with --resume to continue from here.%s\n\n", yellow, num, reset)
+			return allowed, blocked, fmt.Errorf("step %d failed (exit %d); checkpoint saved to %s", num, outcome.exitCode, cp.path)
 		}
 		fmt.Println()
-		time.Sleep(800 * time.Millisecond)
+		pause(800 * time.Millisecond)
 	}
-
-	// --- Phase 4: Results ---
-	fmt.Printf("%s=== RESULTS ===%s\n\n", bold, reset)
-	fmt.Printf("  Tasks: %d  |  %sAllowed: %d%s  |  %sBlocked: %d%s\n", len(p.Steps), green, allowed, reset, red, blocked, reset)
-	fmt.Printf("  %sLLM source: %s%s\n\n", dim, llmSource, reset)
-	time.Sleep(1 * time.Second)
-
-	fmt.Printf("%sVerifying audit chain integrity...%s\n", cyan, reset)
-	verify := exec.Command(chainwatch, "audit", "verify", auditLog)
-	verify.Stdout = os.Stdout
-	verify.Stderr = os.Stderr
-	_ = verify.Run()
-	fmt.Println()
-	time.Sleep(1 * time.Second)
-
-	fmt.Printf("%s%sField test complete. LLM proposed; chainwatch enforced.%s\n", bold, green, reset)
-	time.Sleep(3 * time.Second)
-
-	// Signal the driver (CI VHS recording).
-	_ = os.WriteFile("/tmp/release-demo-done", []byte("done"), 0644)
-	return nil
+	return allowed, blocked, nil
 }
 
 func runShow(name string, args ...string) {
@@ -609,6 +730,85 @@ func runObserveWithInventory(
 	return result, nil
 }
 
+// followObserve re-runs investigate every interval until interrupted and
+// reports only observations absent from the previous cycle. Newly
+// appeared critical findings go to the chainwatch policy's alert channels.
+func followObserve(
+	investigate func() (*observe.RunResult, string, []wo.Observation, error),
+	scope, policyPath string,
+	interval time.Duration,
+	logf func(format string, args ...any),
+) error {
+	pcfg, err := policy.LoadConfig(policyPath)
+	if err != nil {
+		return err
+	}
+	dispatcher := alert.NewDispatcher(pcfg.Alerts)
+	defer func() {
+		ctx, cancel := context.WithTimeout(context.Background(), alertFlushTimeout)
+		defer cancel()
+		if err := dispatcher.Flush(ctx); err != nil {
+			logf("%sWARNING: alerts still pending at exit: %v%s\n", yellow, err, reset)
+		}
+	}()
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	logf("%sFollowing every %s; only new observations are reported.%s\n\n", dim, interval, reset)
+	return observe.Follow(ctx, observe.FollowConfig{
+		Scope:    scope,
+		Interval: interval,
+		Cycle:    followCycle(investigate),
+		Report: func(cycle int, fresh []wo.Observation, err error) {
+			stamp := time.Now().UTC().Format(time.RFC3339)
+			if err != nil {
+				logf("%s=== CYCLE %d (%s) FAILED ===%s %v\n\n", red, cycle, stamp, reset, err)
+				return
+			}
+			logf("%s=== CYCLE %d (%s) ===%s\n", bold, cycle, stamp, reset)
+			logf("  New observations: %d\n", len(fresh))
+			for _, obs := range fresh {
+				color := dim
+				switch obs.Severity {
+				case wo.SeverityCritical:
+					color = red
+				case wo.SeverityHigh:
+					color = yellow
+				}
+				logf("    %s[%s]%s %s: %s\n", color, obs.Severity, reset, obs.Type, obs.Detail)
+			}
+			logf("\n")
+		},
+		Alert: func(obs wo.Observation) {
+			if dispatcher == nil {
+				return
+			}
+			dispatcher.Dispatch(alert.AlertEvent{
+				Timestamp: time.Now().UTC().Format("2006-01-02T15:04:05.000Z"),
+				Tool:      "nullbot",
+				Resource:  scope,
+				Reason:    fmt.Sprintf("%s: %s", obs.Type, obs.Detail),
+				Tier:      3,
+				Type:      observe.EventNewCriticalFinding,
+			})
+		},
+	})
+}
+
+// followCycle adapts investigate to a Follow cycle. A cycle that collects
+// no evidence fails, which keeps the previous baseline so its findings are
+// not reported as new once evidence comes back.
+func followCycle(investigate func() (*observe.RunResult, string, []wo.Observation, error)) func(context.Context) ([]wo.Observation, error) {
+	return func(context.Context) ([]wo.Observation, error) {
+		_, evidence, observations, err := investigate()
+		if err == nil && evidence == "" {
+			err = fmt.Errorf("no evidence collected (all steps blocked or empty)")
+		}
+		return observations, err
+	}
+}
+
 func resolveRunbookTypes(cmd *cobra.Command, observeTypes, observeType string, hasInventory bool) []string {
 	if observeTypes != "" {
 		var runbookTypes []string
@@ -636,6 +836,12 @@ func main() {
 		flagProfile  string
 		flagMaxSteps int
 		flagDryRun   bool
+
+		flagApproveInteractive bool
+		flagCheckpointDir      string
+		flagResume             bool
+		flagAuditLog           string
+		flagPolicy             string
 	)
 
 	rootCmd := &cobra.Command{
@@ -656,10 +862,15 @@ func main() {
 		Short: "plan and execute a mission through chainwatch",
 		Long: `Sends a mission brief to the configured LLM backend, receives a command
 plan, and executes each command through chainwatch exec for policy enforcement.
+Progress is checkpointed after every step; --resume continues a failed or
+interrupted mission from the last completed step without re-running it.
 
 Examples:
   nullbot run "check disk usage and clean temp files"
   nullbot run --dry-run "audit system security"
+  nullbot run --approve-interactive "rotate nginx logs"
+  nullbot run --resume "rotate nginx logs"
+  nullbot run --audit-log /var/log/nullbot/rotate.jsonl "rotate nginx logs"
   GROQ_API_KEY=xxx nullbot run "check system health"
   nullbot run --api-url http://localhost:11434/v1/chat/completions "free disk space"`,
 		Args: cobra.MaximumNArgs(1),
@@ -682,6 +893,10 @@ Rules:
 			}
 
 			cfg := resolveConfig(flagURL, flagModel, flagProfile, flagMaxSteps, flagDryRun)
+			cfg.approveInteractive = flagApproveInteractive
+			cfg.checkpointDir = flagCheckpointDir
+			cfg.resume = flagResume
+			cfg.auditLog = flagAuditLog
 			return runMission(cfg, mission)
 		},
 	}
@@ -691,6 +906,10 @@ Rules:
 	runCmd.Flags().StringVar(&flagProfile, "profile", defaultProfile, "chainwatch profile (env: NULLBOT_PROFILE)")
 	runCmd.Flags().IntVar(&flagMaxSteps, "max-steps", defaultMaxSteps, "maximum commands in plan")
 	runCmd.Flags().BoolVar(&flagDryRun, "dry-run", false, "show plan without executing")
+	runCmd.Flags().BoolVar(&flagApproveInteractive, "approve-interactive", false, "prompt to approve require_approval steps and retry (terminal only)")
+	runCmd.Flags().StringVar(&flagCheckpointDir, "checkpoint-dir", defaultCheckpointDir, "directory for per-mission checkpoint files")
+	runCmd.Flags().BoolVar(&flagResume, "resume", false, "continue the mission from its last completed step")
+	runCmd.Flags().StringVar(&flagAuditLog, "audit-log", "", "chainwatch audit log for this run (env: AUDIT_LOG, default /tmp/nullbot-audit.jsonl)")
 
 	var (
 		observeScope       string
@@ -705,6 +924,16 @@ Rules:
 		observeNoDedup     bool
 		observeDedupWindow time.Duration
 		observeQuery       string
+
+		observeTarget string
+
+		observeMaxStepEvidence int
+		observeMaxEvidence     int
+
+		observeClassifyCacheTTL time.Duration
+
+		observeFollow   bool
+		observeInterval time.Duration
 	)
 
 	observeCmd := &cobra.Command{
@@ -720,7 +949,9 @@ Examples:
   nullbot observe --scope /var/www/site --types kubernetes,prometheus --classify
   nullbot observe --scope /var/lib/clickhouse --type clickhouse --cluster
   nullbot observe --inventory inventory.yaml
-  nullbot observe --scope /var/www/site --classify`,
+  nullbot observe --scope /var/www/site --classify
+  nullbot observe --scope /var/log --type linux --target ops@web-1
+  nullbot observe --scope /var/www/site --follow --interval 5m`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			outputFormat, formatErr := normalizeObserveFormat(observeFormat)
 			if formatErr != nil {
@@ -739,15 +970,22 @@ Examples:
 			if outputFormat == observeFormatWO {
 				observeClassify = true
 			}
+			if observeFollow {
+				if outputFormat == observeFormatWO || observeOutput != "" || observeDiagnostic {
+					return fmt.Errorf("--follow cannot be combined with --format wo, --output, or --diagnostic")
+				}
+				if observeInterval <= 0 {
+					return fmt.Errorf("--interval must be positive")
+				}
+				// Follow mode diffs classified observations between cycles.
+				observeClassify = true
+			}
 
 			chainwatch := os.Getenv("CHAINWATCH_BIN")
 			if chainwatch == "" {
 				chainwatch = "chainwatch"
 			}
-			auditLog := os.Getenv("AUDIT_LOG")
-			if auditLog == "" {
-				auditLog = "/tmp/nullbot-observe.jsonl"
-			}
+			auditLog := resolveAuditLog(flagAuditLog, "/tmp/nullbot-observe.jsonl")
 
 			var inv *inventory.Inventory
 			if observeInventory != "" {
@@ -758,6 +996,15 @@ Examples:
 				inv = loaded
 			}
 
+			if observeTarget != "" {
+				if inv != nil {
+					return fmt.Errorf("--target cannot be combined with --inventory")
+				}
+				if err := observe.ValidateTarget(observeTarget); err != nil {
+					return err
+				}
+			}
+
 			if observeScope == "" {
 				if inv != nil {
 					observeScope = defaultObserveScopeFromInventory
@@ -786,6 +1033,8 @@ Examples:
 				Cluster:    observeCluster,
 				Chainwatch: chainwatch,
 				AuditLog:   auditLog,
+				Policy:     flagPolicy,
+				Target:     observeTarget,
 			}
 			if observeQuery != "" {
 				runnerCfg.Params = map[string]string{"QUERY": observeQuery}
@@ -795,6 +1044,9 @@ Examples:
 
 			logf("%s%s=== OBSERVE MODE ===%s\n\n", bold, cyan, reset)
 			logf("%sScope:   %s%s\n", dim, observeScope, reset)
+			if observeTarget != "" {
+				logf("%sTarget:  %s (over ssh)%s\n", dim, observeTarget, reset)
+			}
 			if inv != nil {
 				logf("%sInventory: %s%s\n", dim, inv.Path(), reset)
 				logf("%sClusters: %d%s\n", dim, len(inv.Clusters()), reset)
@@ -907,134 +1159,160 @@ Examples:
 				logf("  %s\n\n", diagPath)
 			}
 
-			// Execute runbook(s).
-			logf("%sRunning investigation...%s\n\n", dim, reset)
-			var result *observe.RunResult
-			var err error
-			if inv != nil {
-				result, err = runObserveWithInventory(runnerCfg, runbookTypes, inv)
-			} else if multiMode {
-				result, err = observe.RunMulti(runnerCfg, runbookTypes)
-			} else {
-				result, err = observe.Run(runnerCfg, observe.GetRunbook(runbookTypes[0]))
-			}
-			if err != nil {
-				return fmt.Errorf("observe failed: %w", err)
-			}
-
-			// Display step results.
-			for i, sr := range result.Steps {
-				stepContext := ""
-				if sr.Cluster != "" || sr.Host != "" {
-					var contextParts []string
-					if sr.Cluster != "" {
-						contextParts = append(contextParts, sr.Cluster)
-					}
-					if sr.Host != "" {
-						contextParts = append(contextParts, sr.Host)
-					}
-					stepContext = fmt.Sprintf(" [%s]", strings.Join(contextParts, "/"))
-				}
-				logf("%s[%d/%d]%s %s%s\n", bold, i+1, len(result.Steps), reset, sr.Purpose, stepContext)
-				if sr.Blocked {
-					logf("  %sBLOCKED%s by chainwatch\n", red, reset)
-				} else if sr.ExitCode != 0 {
-					logf("  %sERROR%s exit=%d\n", red, reset, sr.ExitCode)
-				} else if sr.Output == "" {
-					logf("  %s(no output)%s\n", dim, reset)
+			// investigate runs the runbook(s) once, prints step results, and
+			// classifies the evidence when --classify is set.
+			investigate := func() (*observe.RunResult, string, []wo.Observation, error) {
+				// Execute runbook(s).
+				logf("%sRunning investigation...%s\n\n", dim, reset)
+				var result *observe.RunResult
+				var err error
+				if inv != nil {
+					result, err = runObserveWithInventory(runnerCfg, runbookTypes, inv)
+				} else if multiMode {
+					result, err = observe.RunMulti(runnerCfg, runbookTypes)
 				} else {
-					lines := strings.SplitN(sr.Output, "\n", 4)
-					for _, line := range lines[:min(len(lines), 3)] {
-						logf("  %s%s%s\n", dim, line, reset)
+					result, err = observe.Run(runnerCfg, observe.GetRunbook(runbookTypes[0]))
+				}
+				if err != nil {
+					return nil, "", nil, fmt.Errorf("observe failed: %w", err)
+				}
+
+				// Display step results.
+				for i, sr := range result.Steps {
+					stepContext := ""
+					if sr.Cluster != "" || sr.Host != "" {
+						var contextParts []string
+						if sr.Cluster != "" {
+							contextParts = append(contextParts, sr.Cluster)
+						}
+						if sr.Host != "" {
+							contextParts = append(contextParts, sr.Host)
+						}
+						stepContext = fmt.Sprintf(" [%s]", strings.Join(contextParts, "/"))
 					}
-					if len(lines) > 3 {
-						logf("  %s... (%d more lines)%s\n", dim, strings.Count(sr.Output, "\n")-2, reset)
+					logf("%s[%d/%d]%s %s%s\n", bold, i+1, len(result.Steps), reset, sr.Purpose, stepContext)
+					if sr.Skipped {
+						logf("  %sSKIPPED%s %s\n", dim, reset, sr.SkipReason)
+					} else if sr.Blocked {
+						logf("  %sBLOCKED%s by chainwatch\n", red, reset)
+					} else if sr.ExitCode != 0 {
+						logf("  %sERROR%s exit=%d\n", red, reset, sr.ExitCode)
+					} else if sr.Output == "" {
+						logf("  %s(no output)%s\n", dim, reset)
+					} else {
+						lines := strings.SplitN(sr.Output, "\n", 4)
+						for _, line := range lines[:min(len(lines), 3)] {
+							logf("  %s%s%s\n", dim, line, reset)
+						}
+						if len(lines) > 3 {
+							logf("  %s... (%d more lines)%s\n", dim, strings.Count(sr.Output, "\n")-2, reset)
+						}
 					}
+					logln()
 				}
-				logln()
-			}
 
-			// Collect evidence for classification.
-			evidence := observe.CollectEvidence(result)
+				// Collect evidence for classification.
+				evidence := observe.CollectEvidenceWithLimits(result, observe.EvidenceLimits{
+					MaxStepBytes:  observeMaxStepEvidence,
+					MaxTotalBytes: observeMaxEvidence,
+				})
 
-			if diagFile != nil {
-				if _, err := fmt.Fprintf(diagFile, "=== COLLECTED: RAW EVIDENCE ===\n%s\n=== END COLLECTED ===\n\n", evidence); err != nil {
-					return fmt.Errorf("write diagnostic raw evidence: %w", err)
+				if diagFile != nil {
+					if _, err := fmt.Fprintf(diagFile, "=== COLLECTED: RAW EVIDENCE ===\n%s\n=== END COLLECTED ===\n\n", evidence); err != nil {
+						return nil, "", nil, fmt.Errorf("write diagnostic raw evidence: %w", err)
+					}
 				}
-			}
 
-			if evidence == "" {
-				if outputFormat != observeFormatWO {
-					logf("%sNo evidence collected (all steps blocked or empty).%s\n", yellow, reset)
-					return nil
-				}
-			}
+				// Classify with LLM if requested.
+				var observations []wo.Observation
+				if observeClassify && evidence != "" {
+					logf("%sClassifying findings with %s...%s ", dim, cfg.model, reset)
 
-			// Classify with LLM if requested.
-			var observations []wo.Observation
-			if observeClassify && evidence != "" {
-				logf("%sClassifying findings with %s...%s ", dim, cfg.model, reset)
-
-				// Resolve sensitivity: strictest across all runbooks.
-				sensitivity := ""
-				for _, rbType := range runbookTypes {
-					if rb := observe.GetRunbook(rbType); rb != nil && rb.Sensitivity == "local" {
-						sensitivity = "local"
-						break
+					// Resolve sensitivity: strictest across all runbooks.
+					sensitivity := ""
+					for _, rbType := range runbookTypes {
+						if rb := observe.GetRunbook(rbType); rb != nil && rb.Sensitivity == "local" {
+							sensitivity = "local"
+							break
+						}
 					}
-				}
-
-				classifyCfg := observe.ClassifierConfig{
-					APIURL:           cfg.apiURL,
-					APIKey:           cfg.apiKey,
-					Model:            cfg.model,
-					Pool:             cfg.llmPool,
-					Sensitivity:      sensitivity,
-					DiagnosticWriter: diagFile, // nil when --diagnostic not used
-				}
 
-				// Redact evidence if cloud mode.
-				classifyEvidence := evidence
-				var tokenMap *redact.TokenMap
-				if cfg.redactMode == redact.ModeCloud {
-					tokenMap = redact.NewTokenMap(fmt.Sprintf("observe-%d", time.Now().UnixNano()))
-					classifyEvidence = redact.RedactWithConfig(evidence, tokenMap, cfg.redactCfg, cfg.extraPatterns)
-					if tokenMap.Len() > 0 {
-						classifyEvidence = tokenMap.Legend() + "\n" + classifyEvidence
+					classifyCfg := observe.ClassifierConfig{
+						APIURL:           cfg.apiURL,
+						APIKey:           cfg.apiKey,
+						Model:            cfg.model,
+						Pool:             cfg.llmPool,
+						Sensitivity:      sensitivity,
+						DiagnosticWriter: diagFile, // nil when --diagnostic not used
+					}
+					if observeClassifyCacheTTL > 0 {
+						classifyCfg.CachePath = observe.CacheDir(resolveObserveStateDir())
+						classifyCfg.CacheTTL = observeClassifyCacheTTL
 					}
-				}
 
-				if diagFile != nil {
-					if _, err := fmt.Fprintf(diagFile, "=== SENT: REDACTED EVIDENCE ===\n%s\n=== END SENT ===\n\n", classifyEvidence); err != nil {
-						return fmt.Errorf("write diagnostic redacted evidence: %w", err)
+					// Redact evidence if cloud mode.
+					classifyEvidence := evidence
+					var tokenMap *redact.TokenMap
+					if cfg.redactMode == redact.ModeCloud {
+						tokenMap = redact.NewTokenMap(fmt.Sprintf("observe-%d", time.Now().UnixNano()))
+						classifyEvidence = redact.RedactWithConfig(evidence, tokenMap, cfg.redactCfg, cfg.extraPatterns)
+						if tokenMap.Len() > 0 {
+							classifyEvidence = tokenMap.Legend() + "\n" + classifyEvidence
+						}
 					}
-				}
 
-				obs, err := observe.Classify(classifyCfg, classifyEvidence)
-				if err != nil {
-					logf("%sFAILED%s (%v)\n", red, reset, err)
-					logf("%sEvidence collected but classification failed. Use --output to save raw results.%s\n", yellow, reset)
-				} else {
-					// Post-validation: check for leaks and de-redact.
-					if tokenMap != nil && tokenMap.Len() > 0 {
-						var allDetails string
-						for _, o := range obs {
-							allDetails += " " + o.Detail
+					if diagFile != nil {
+						if _, err := fmt.Fprintf(diagFile, "=== SENT: REDACTED EVIDENCE ===\n%s\n=== END SENT ===\n\n", classifyEvidence); err != nil {
+							return nil, "", nil, fmt.Errorf("write diagnostic redacted evidence: %w", err)
 						}
-						if leaks := redact.CheckLeaks(allDetails, tokenMap); len(leaks) > 0 {
-							logf("%sFAILED%s (LLM leaked %d sensitive values)\n", red, reset, len(leaks))
-							return fmt.Errorf("classification leak: LLM exposed %d sensitive values", len(leaks))
+					}
+
+					obs, err := observe.Classify(classifyCfg, classifyEvidence)
+					if err != nil {
+						logf("%sFAILED%s (%v)\n", red, reset, err)
+						logf("%sEvidence collected but classification failed. Use --output to save raw results.%s\n", yellow, reset)
+						if observeFollow {
+							// An empty cycle would make every finding look new next time.
+							return nil, "", nil, fmt.Errorf("classify: %w", err)
 						}
-						for i := range obs {
-							obs[i].Detail = redact.Detoken(obs[i].Detail, tokenMap)
+					} else {
+						// Post-validation: check for leaks and de-redact.
+						if tokenMap != nil && tokenMap.Len() > 0 {
+							var allDetails string
+							for _, o := range obs {
+								allDetails += " " + o.Detail
+							}
+							if leaks := redact.CheckLeaks(allDetails, tokenMap); len(leaks) > 0 {
+								logf("%sFAILED%s (LLM leaked %d sensitive values)\n", red, reset, len(leaks))
+								return nil, "", nil, fmt.Errorf("classification leak: LLM exposed %d sensitive values", len(leaks))
+							}
+							for i := range obs {
+								obs[i].Detail = redact.Detoken(obs[i].Detail, tokenMap)
+							}
 						}
+						observations = obs
+						logf("%sOK%s (%d observations)\n", green, reset, len(obs))
+					}
+
+					if diagFile != nil {
+						logf("%sDiagnostic written to: %s%s\n", dim, diagFile.Name(), reset)
 					}
-					observations = obs
-					logf("%sOK%s (%d observations)\n", green, reset, len(obs))
 				}
+				return result, evidence, observations, nil
+			}
 
-				if diagFile != nil {
-					logf("%sDiagnostic written to: %s%s\n", dim, diagFile.Name(), reset)
+			if observeFollow {
+				return followObserve(investigate, observeScope, flagPolicy, observeInterval, logf)
+			}
+
+			result, evidence, observations, err := investigate()
+			if err != nil {
+				return err
+			}
+			if evidence == "" {
+				if outputFormat != observeFormatWO {
+					logf("%sNo evidence collected (all steps blocked or empty).%s\n", yellow, reset)
+					return nil
 				}
 			}
 
@@ -1106,14 +1384,17 @@ Examples:
 			// Summary.
 			logf("\n%s=== SUMMARY ===%s\n", bold, reset)
 			total := len(result.Steps)
-			blocked := 0
+			blocked, skipped := 0, 0
 			for _, sr := range result.Steps {
 				if sr.Blocked {
 					blocked++
 				}
+				if sr.Skipped {
+					skipped++
+				}
 			}
-			logf("  Steps: %d  |  %sCompleted: %d%s  |  %sBlocked: %d%s\n",
-				total, green, total-blocked, reset, red, blocked, reset)
+			logf("  Steps: %d  |  %sCompleted: %d%s  |  %sBlocked: %d%s  |  Skipped: %d\n",
+				total, green, total-blocked-skipped, reset, red, blocked, reset, skipped)
 			if len(observations) > 0 {
 				logf("  Observations: %d\n", len(observations))
 				for _, obs := range observations {
@@ -1148,12 +1429,23 @@ Examples:
 	observeCmd.Flags().BoolVar(&observeNoDedup, "no-dedup", false, "bypass WO deduplication for this run")
 	observeCmd.Flags().DurationVar(&observeDedupWindow, "dedup-window", defaultObserveDedupWindow, "time window to suppress recurrences after closure")
 	observeCmd.Flags().StringVar(&observeQuery, "query", "", "email address or search term for trace runbooks")
+	observeCmd.Flags().IntVar(&observeMaxStepEvidence, "max-step-evidence", observe.DefaultMaxStepEvidence, "max bytes of one step's output sent to the classifier (-1 = unlimited)")
+	observeCmd.Flags().IntVar(&observeMaxEvidence, "max-evidence", observe.DefaultMaxTotalEvidence, "max bytes of total evidence sent to the classifier (-1 = unlimited)")
+	observeCmd.Flags().StringVar(&observeTarget, "target", "", "investigate a remote host (user@host) over ssh; --scope is a path on that host")
+	observeCmd.Flags().BoolVar(&observeFollow, "follow", false, "re-run every --interval and report only observations new since the previous cycle (implies --classify)")
+	observeCmd.Flags().DurationVar(&observeInterval, "interval", defaultObserveFollowInterval, "time between --follow cycles")
+	observeCmd.Flags().DurationVar(&observeClassifyCacheTTL, "classify-cache-ttl", 0, "reuse the classification of identical evidence for this long (0 = always call the LLM)")
+	observeCmd.Flags().StringVar(&flagAuditLog, "audit-log", "", "chainwatch audit log for this run (env: AUDIT_LOG, default /tmp/nullbot-observe.jsonl)")
+	observeCmd.Flags().StringVar(&flagPolicy, "policy", "", "chainwatch policy YAML for enforcement and --follow alerts (default: ~/.chainwatch/policy.yaml)")
 
 	var (
 		daemonInbox    string
 		daemonOutbox   string
 		daemonState    string
 		daemonPollMode bool
+
+		daemonExpiryReminder   time.Duration
+		daemonClassifyCacheTTL time.Duration
 	)
 
 	daemonCmd := &cobra.Command{
@@ -1175,10 +1467,7 @@ Examples:
 			if chainwatch == "" {
 				chainwatch = "chainwatch"
 			}
-			auditLog := os.Getenv("AUDIT_LOG")
-			if auditLog == "" {
-				auditLog = "/tmp/nullbot-daemon.jsonl"
-			}
+			auditLog := resolveAuditLog(flagAuditLog, "/tmp/nullbot-daemon.jsonl")
 
 			dcfg := daemon.Config{
 				Dirs: daemon.DirConfig{
@@ -1197,6 +1486,10 @@ Examples:
 				LLMRateLimit:  cfg.llmRateLimit,
 				LLMFallbacks:  cfg.llmFallbacks,
 				LLMPool:       cfg.llmPool,
+
+				Policy:           flagPolicy,
+				ExpiryReminder:   daemonExpiryReminder,
+				ClassifyCacheTTL: daemonClassifyCacheTTL,
 			}
 
 			d, err := daemon.New(dcfg)
@@ -1207,6 +1500,14 @@ Examples:
 			ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 			defer cancel()
 
+			// Policy edits and SIGHUP swap the alert channels without a restart.
+			reloader, err := server.NewReloader(d, []string{flagPolicy})
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "warning: hot-reload disabled: %v\n", err)
+			} else {
+				go reloader.Run(ctx)
+			}
+
 			fmt.Printf("%s%s=== NULLBOT DAEMON ===%s\n\n", bold, cyan, reset)
 			fmt.Printf("%sInbox:   %s%s\n", dim, daemonInbox, reset)
 			fmt.Printf("%sOutbox:  %s%s\n", dim, daemonOutbox, reset)
@@ -1227,8 +1528,12 @@ Examples:
 	daemonCmd.Flags().StringVar(&daemonOutbox, "outbox", "/home/nullbot/outbox", "outbox directory for results")
 	daemonCmd.Flags().StringVar(&daemonState, "state", "/home/nullbot/state", "state directory for processing")
 	daemonCmd.Flags().BoolVar(&daemonPollMode, "poll", false, "use polling instead of inotify")
+	daemonCmd.Flags().StringVar(&flagPolicy, "policy", "", "chainwatch policy YAML for enforcement and expiry alerts (default: ~/.chainwatch/policy.yaml)")
+	daemonCmd.Flags().DurationVar(&daemonExpiryReminder, "expiry-reminder", time.Hour, "alert this long before a pending work order expires")
+	daemonCmd.Flags().DurationVar(&daemonClassifyCacheTTL, "classify-cache-ttl", 0, "reuse the classification of identical evidence for this long (0 = always call the LLM)")
 	daemonCmd.Flags().StringVar(&flagURL, "api-url", "", "LLM API endpoint (env: NULLBOT_API_URL)")
 	daemonCmd.Flags().StringVar(&flagModel, "model", "", "LLM model name (env: NULLBOT_MODEL)")
+	daemonCmd.Flags().StringVar(&flagAuditLog, "audit-log", "", "chainwatch audit log (env: AUDIT_LOG, default /tmp/nullbot-daemon.jsonl)")
 
 	// Shared flags for approval commands.
 	var approvalOutbox, approvalState string
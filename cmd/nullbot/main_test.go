@@ -1,12 +1,15 @@
 package main
 
 import (
+	"context"
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/ppiankov/chainwatch/internal/inventory"
 	"github.com/ppiankov/chainwatch/internal/observe"
+	"github.com/ppiankov/chainwatch/internal/wo"
 	"github.com/spf13/cobra"
 )
 
@@ -135,3 +138,31 @@ func writeExecutable(t *testing.T, path, content string) {
 		t.Fatalf("write executable %s: %v", path, err)
 	}
 }
+
+func TestFollowCycleKeepsBaselineOnEmptyEvidence(t *testing.T) {
+	critical := wo.Observation{Type: wo.SuspiciousCode, Severity: wo.SeverityCritical, Detail: "webshell"}
+	evidence := []string{"found", "", "found"}
+	cycle := 0
+	investigate := func() (*observe.RunResult, string, []wo.Observation, error) {
+		ev := evidence[cycle]
+		cycle++
+		if ev == "" {
+			return nil, "", nil, nil
+		}
+		return nil, ev, []wo.Observation{critical}, nil
+	}
+
+	var alerts int
+	if err := observe.Follow(context.Background(), observe.FollowConfig{
+		Scope:    "/srv/app",
+		Interval: time.Millisecond,
+		Cycles:   len(evidence),
+		Cycle:    followCycle(investigate),
+		Alert:    func(wo.Observation) { alerts++ },
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if alerts != 1 {
+		t.Errorf("expected the finding alerted once across an empty cycle, got %d", alerts)
+	}
+}
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// exitBlocked is the chainwatch exec exit code for a policy block.
+const exitBlocked = 77
+
+// stepOutcome is the result of routing one plan step through chainwatch exec.
+type stepOutcome struct {
+	output      []byte
+	exitCode    int
+	approvalKey string // set when chainwatch blocked the step pending approval
+	approved    bool   // operator approved and the step was retried
+}
+
+// pause paces step output for readable demos; tests replace it.
+var pause = time.Sleep
+
+// approveFunc asks the operator whether to approve a blocked step.
+type approveFunc func(key, command string) bool
+
+// execStep runs one shell command through chainwatch exec.
+// When approve is non-nil and the step is blocked with require_approval,
+// the operator is asked to confirm; on yes the approval is granted via
+// `chainwatch approve <key>` and the step is retried once.
+func execStep(chainwatch, profile, auditLog, command string, approve approveFunc) stepOutcome {
+	args := []string{"exec", "--profile", profile, "--audit-log", auditLog, "--"}
+	args = append(args, "sh", "-c", command)
+
+	out := runChainwatch(chainwatch, args)
+	if out.exitCode != exitBlocked {
+		return out
+	}
+
+	out.approvalKey = approvalKeyFromBlock(out.output)
+	if out.approvalKey == "" || approve == nil || !approve(out.approvalKey, command) {
+		return out
+	}
+
+	grant := runChainwatch(chainwatch, []string{"approve", out.approvalKey})
+	if grant.exitCode != 0 {
+		out.output = append(out.output, grant.output...)
+		return out
+	}
+
+	retry := runChainwatch(chainwatch, args)
+	retry.approvalKey = out.approvalKey
+	retry.approved = true
+	return retry
+}
+
+func runChainwatch(chainwatch string, args []string) stepOutcome {
+	cmd := exec.Command(chainwatch, args...)
+	out, err := cmd.CombinedOutput()
+
+	exitCode := 0
+	if err != nil {
+		if exitErr, ok := err.(*exec.ExitError); ok {
+			exitCode = exitErr.ExitCode()
+		} else {
+			exitCode = 1
+		}
+	}
+	return stepOutcome{output: out, exitCode: exitCode}
+}
+
+// approvalKeyFromBlock extracts the approval key from chainwatch exec's
+// block report. Returns "" unless the decision is require_approval.
+func approvalKeyFromBlock(output []byte) string {
+	idx := bytes.IndexByte(output, '{')
+	if idx < 0 {
+		return ""
+	}
+
+	var report struct {
+		Decision    string `json:"decision"`
+		ApprovalKey string `json:"approval_key"`
+	}
+	if err := json.NewDecoder(bytes.NewReader(output[idx:])).Decode(&report); err != nil {
+		return ""
+	}
+	if report.Decision != "require_approval" {
+		return ""
+	}
+	return report.ApprovalKey
+}
+
+// promptApproval asks a y/n question on w and reads the answer from r.
+// Anything other than y/yes is a no (fail-closed).
+func promptApproval(r *bufio.Reader, w io.Writer, key, command string) bool {
+	fmt.Fprintf(w, "  %sAPPROVAL REQUIRED%s %s\n", yellow, reset, key)
+	fmt.Fprintf(w, "  Approve %q and retry? [y/N] ", command)
+
+	line, err := r.ReadString('\n')
+	if err != nil && line == "" {
+		fmt.Fprintln(w)
+		return false
+	}
+	answer := strings.ToLower(strings.TrimSpace(line))
+	return answer == "y" || answer == "yes"
+}
+
+// stdinIsTerminal reports whether stdin is attached to an interactive terminal.
+func stdinIsTerminal() bool {
+	info, err := os.Stdin.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeApprovalMock writes a fake chainwatch that blocks exec with
+// require_approval until `chainwatch approve` has been called.
+func writeApprovalMock(t *testing.T, dir string) string {
+	t.Helper()
+	path := filepath.Join(dir, "chainwatch")
+	marker := filepath.Join(dir, "approved")
+	writeExecutable(t, path, "#!/bin/sh\n"+
+		"case \"$1\" in\n"+
+		"approve)\n"+
+		"  echo \"$2\" > "+marker+"\n"+
+		"  echo \"Approved $2\"\n"+
+		"  exit 0 ;;\n"+
+		"exec)\n"+
+		"  if [ -f "+marker+" ]; then echo step-ran; exit 0; fi\n"+
+		"  cat >&2 <<'JSON'\n"+
+		"{\n"+
+		"  \"blocked\": true,\n"+
+		"  \"command\": \"sh -c rm /var/log/app.log\",\n"+
+		"  \"decision\": \"require_approval\",\n"+
+		"  \"reason\": \"tier 2 (guarded) in guarded mode\",\n"+
+		"  \"approval_key\": \"tier_2_action\"\n"+
+		"}\n"+
+		"JSON\n"+
+		"  exit 77 ;;\n"+
+		"esac\n")
+	return path
+}
+
+func TestExecStepApproveAndRetry(t *testing.T) {
+	dir := t.TempDir()
+	chainwatch := writeApprovalMock(t, dir)
+
+	var askedKey string
+	outcome := execStep(chainwatch, "clawbot", filepath.Join(dir, "audit.jsonl"), "rm /var/log/app.log",
+		func(key, command string) bool {
+			askedKey = key
+			return true
+		})
+
+	if askedKey != "tier_2_action" {
+		t.Fatalf("approver asked for key %q, want tier_2_action", askedKey)
+	}
+	if !outcome.approved {
+		t.Fatal("expected step to be approved and retried")
+	}
+	if outcome.exitCode != 0 {
+		t.Fatalf("retry exit code = %d, want 0 (output: %s)", outcome.exitCode, outcome.output)
+	}
+	if !strings.Contains(string(outcome.output), "step-ran") {
+		t.Fatalf("expected retried step output, got %q", outcome.output)
+	}
+	granted, err := os.ReadFile(filepath.Join(dir, "approved"))
+	if err != nil || strings.TrimSpace(string(granted)) != "tier_2_action" {
+		t.Fatalf("expected chainwatch approve tier_2_action, got %q (%v)", granted, err)
+	}
+}
+
+func TestExecStepDeclinedStaysBlocked(t *testing.T) {
+	dir := t.TempDir()
+	chainwatch := writeApprovalMock(t, dir)
+
+	outcome := execStep(chainwatch, "clawbot", filepath.Join(dir, "audit.jsonl"), "rm /var/log/app.log",
+		func(key, command string) bool { return false })
+
+	if outcome.exitCode != exitBlocked || outcome.approved {
+		t.Fatalf("expected blocked and not approved, got exit=%d approved=%v", outcome.exitCode, outcome.approved)
+	}
+	if outcome.approvalKey != "tier_2_action" {
+		t.Fatalf("approvalKey = %q, want tier_2_action", outcome.approvalKey)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "approved")); err == nil {
+		t.Fatal("declined step must not call chainwatch approve")
+	}
+}
+
+func TestExecStepNonInteractiveKeepsBlock(t *testing.T) {
+	dir := t.TempDir()
+	chainwatch := writeApprovalMock(t, dir)
+
+	outcome := execStep(chainwatch, "clawbot", filepath.Join(dir, "audit.jsonl"), "rm /var/log/app.log", nil)
+
+	if outcome.exitCode != exitBlocked || outcome.approved {
+		t.Fatalf("expected blocked without approver, got exit=%d approved=%v", outcome.exitCode, outcome.approved)
+	}
+}
+
+func TestResolveAuditLogPrecedence(t *testing.T) {
+	t.Setenv("AUDIT_LOG", "")
+	if got := resolveAuditLog("", "/tmp/default.jsonl"); got != "/tmp/default.jsonl" {
+		t.Errorf("default: got %q", got)
+	}
+	t.Setenv("AUDIT_LOG", "/tmp/env.jsonl")
+	if got := resolveAuditLog("", "/tmp/default.jsonl"); got != "/tmp/env.jsonl" {
+		t.Errorf("env over default: got %q", got)
+	}
+	if got := resolveAuditLog("/tmp/flag.jsonl", "/tmp/default.jsonl"); got != "/tmp/flag.jsonl" {
+		t.Errorf("flag over env: got %q", got)
+	}
+}
+
+func TestExecStepPassesAuditLogFlag(t *testing.T) {
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "args")
+	chainwatch := filepath.Join(dir, "chainwatch")
+	writeExecutable(t, chainwatch, "#!/bin/sh\nprintf '%s\\n' \"$@\" > "+argsFile+"\n")
+
+	t.Setenv("AUDIT_LOG", filepath.Join(dir, "env.jsonl"))
+	flagLog := filepath.Join(dir, "mission.jsonl")
+	execStep(chainwatch, "clawbot", resolveAuditLog(flagLog, "/tmp/nullbot-audit.jsonl"), "uptime", nil)
+
+	data, err := os.ReadFile(argsFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	args := strings.Split(strings.TrimSpace(string(data)), "\n")
+	want := []string{"exec", "--profile", "clawbot", "--audit-log", flagLog, "--", "sh", "-c", "uptime"}
+	if strings.Join(args, " ") != strings.Join(want, " ") {
+		t.Fatalf("chainwatch invoked with %q, want %q", args, want)
+	}
+}
+
+func TestApprovalKeyFromBlockIgnoresDeny(t *testing.T) {
+	deny := []byte(`{"blocked": true, "decision": "deny", "reason": "denylisted"}`)
+	if key := approvalKeyFromBlock(deny); key != "" {
+		t.Fatalf("expected no key for deny, got %q", key)
+	}
+	if key := approvalKeyFromBlock([]byte("not json")); key != "" {
+		t.Fatalf("expected no key for garbage, got %q", key)
+	}
+}
+
+func TestPromptApproval(t *testing.T) {
+	tests := []struct {
+		input string
+		want  bool
+	}{
+		{"y\n", true},
+		{"YES\n", true},
+		{"n\n", false},
+		{"\n", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		var out bytes.Buffer
+		got := promptApproval(bufio.NewReader(strings.NewReader(tt.input)), &out, "k", "rm x")
+		if got != tt.want {
+			t.Errorf("promptApproval(%q) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+}
@@ -8,6 +8,8 @@ import (
 	"sync/atomic"
 	"testing"
 	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 var (
@@ -325,3 +327,79 @@ type roundTripFunc func(req *http.Request) (*http.Response, error)
 func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
 	return f(req)
 }
+
+func TestDispatcherRuleAlertForceBypassesEventsFilter(t *testing.T) {
+	denyOnly := &testAlerter{name: "webhook"}
+	d := &Dispatcher{routes: []route{{channel: channelWebhook, events: []string{"deny"}, alerter: denyOnly}}}
+
+	d.Dispatch(AlertEvent{Decision: "allow", Tool: "file_read", Resource: "/hr/salary.csv"})
+	time.Sleep(50 * time.Millisecond)
+	if got := denyOnly.sent.Load(); got != 0 {
+		t.Fatalf("expected allow to be filtered without override, got %d sends", got)
+	}
+
+	d.Dispatch(AlertEvent{
+		Decision: "allow", Tool: "file_read", Resource: "/hr/salary.csv",
+		Rule: RuleAlert{Mode: RuleAlertForce},
+	})
+	time.Sleep(50 * time.Millisecond)
+	if got := denyOnly.sent.Load(); got != 1 {
+		t.Fatalf("expected forced alert to dispatch once, got %d", got)
+	}
+}
+
+func TestDispatcherRuleAlertSuppress(t *testing.T) {
+	a := &testAlerter{name: "webhook"}
+	d := &Dispatcher{routes: []route{{channel: channelWebhook, events: []string{"deny"}, alerter: a}}}
+
+	d.Dispatch(AlertEvent{Decision: "deny", Rule: RuleAlert{Mode: RuleAlertSuppress}})
+	time.Sleep(50 * time.Millisecond)
+	if got := a.sent.Load(); got != 0 {
+		t.Fatalf("expected suppressed alert not to dispatch, got %d", got)
+	}
+}
+
+func TestDispatcherRuleAlertChannelOverride(t *testing.T) {
+	webhook := &testAlerter{name: "webhook"}
+	telegram := &testAlerter{name: "telegram"}
+	d := &Dispatcher{routes: []route{
+		{channel: channelWebhook, events: []string{"deny"}, alerter: webhook},
+		{channel: channelTelegram, events: []string{"deny"}, alerter: telegram},
+	}}
+
+	d.Dispatch(AlertEvent{Decision: "deny", Rule: RuleAlert{Channels: []string{"Telegram"}}})
+	time.Sleep(50 * time.Millisecond)
+	if got := webhook.sent.Load(); got != 0 {
+		t.Fatalf("expected webhook skipped by channel override, got %d", got)
+	}
+	if got := telegram.sent.Load(); got != 1 {
+		t.Fatalf("expected telegram dispatch once, got %d", got)
+	}
+}
+
+func TestRuleAlertUnmarshalYAML(t *testing.T) {
+	var scalar struct {
+		Alert RuleAlert `yaml:"alert"`
+	}
+	if err := yaml.Unmarshal([]byte("alert: Force\n"), &scalar); err != nil {
+		t.Fatalf("scalar form: %v", err)
+	}
+	if scalar.Alert.Mode != RuleAlertForce {
+		t.Errorf("expected mode force, got %q", scalar.Alert.Mode)
+	}
+
+	var mapping struct {
+		Alert RuleAlert `yaml:"alert"`
+	}
+	doc := "alert:\n  mode: suppress\n  channels: [email]\n"
+	if err := yaml.Unmarshal([]byte(doc), &mapping); err != nil {
+		t.Fatalf("mapping form: %v", err)
+	}
+	if mapping.Alert.Mode != RuleAlertSuppress || len(mapping.Alert.Channels) != 1 {
+		t.Errorf("unexpected mapping result: %+v", mapping.Alert)
+	}
+
+	if err := yaml.Unmarshal([]byte("alert: loud\n"), &scalar); err == nil {
+		t.Error("expected error for unknown mode")
+	}
+}
@@ -19,6 +19,15 @@ type AlertConfig struct {
 
 	Telegram TelegramConfig `yaml:"telegram" json:"telegram"`
 	Email    EmailConfig    `yaml:"email"    json:"email"`
+
+	// Retry bounds delivery attempts. Alerts that still fail are written
+	// to SpoolDir (when set) and redelivered after the channel recovers;
+	// otherwise they are dropped and counted in Stats. SpoolMax caps the
+	// spooled alerts per channel (default 1000); when full, the oldest is
+	// evicted.
+	Retry    RetryConfig `yaml:"retry"     json:"retry"`
+	SpoolDir string      `yaml:"spool_dir" json:"spool_dir"`
+	SpoolMax int         `yaml:"spool_max" json:"spool_max"`
 }
 
 // TelegramConfig configures Telegram Bot API delivery.
@@ -60,4 +69,8 @@ type AlertEvent struct {
 	Tier       int    `json:"tier"`
 	PolicyHash string `json:"policy_hash"`
 	Type       string `json:"type,omitempty"` // "break_glass_used" etc.
+
+	// Rule carries the per-rule routing override of the matched policy rule.
+	// It affects dispatch only and is not part of the delivered payload.
+	Rule RuleAlert `json:"-"`
 }
@@ -1,15 +1,24 @@
 package alert
 
-import "context"
+import (
+	"context"
+	"strings"
+	"sync"
+
+	"github.com/ppiankov/chainwatch/internal/redact"
+)
 
 // Dispatcher fans out alert events to matching webhook configurations.
 type Dispatcher struct {
-	routes []route
+	routes   []route
+	inflight sync.WaitGroup
 }
 
 type route struct {
+	channel string
 	events  []string
 	alerter Alerter
+	spool   *spool // nil when spooling is disabled
 }
 
 // NewDispatcher creates a Dispatcher from alert channel configurations.
@@ -30,10 +39,17 @@ func NewDispatcher(configs []AlertConfig) *Dispatcher {
 		if alerter == nil {
 			continue
 		}
-		routes = append(routes, route{
+		r := route{
+			channel: channel,
 			events:  cfg.Events,
 			alerter: alerter,
-		})
+			spool:   newSpool(cfg),
+		}
+		if r.spool != nil && len(r.spool.pending()) > 0 {
+			// Alerts spooled by a previous run; the channel may be back.
+			go r.spool.flush(context.Background(), r.alerter)
+		}
+		routes = append(routes, r)
 	}
 
 	if len(routes) == 0 {
@@ -44,15 +60,95 @@ func NewDispatcher(configs []AlertConfig) *Dispatcher {
 
 // Dispatch sends the event to all channels whose Events list matches.
 // Matching is based on event.Decision or event.Type (for break_glass_used).
+// A per-rule override (event.Rule) can suppress the event, force it past
+// the events filter, or restrict it to specific channels.
+// Secrets in the resource and reason are redacted before any channel sees
+// them; the audit log keeps the original.
 // Fires goroutines — does not block the caller.
 func (d *Dispatcher) Dispatch(event AlertEvent) {
+	if event.Rule.Mode == RuleAlertSuppress {
+		return
+	}
+	event = scrubSecrets(event)
 	for _, route := range d.routes {
-		if matches(route.events, event) {
-			go func(alerter Alerter) {
-				_ = alerter.Send(context.Background(), event)
-			}(route.alerter)
+		if selects(route, event) {
+			d.inflight.Add(1)
+			go func() {
+				defer d.inflight.Done()
+				deliver(route, event)
+			}()
+		}
+	}
+}
+
+// Flush waits until every dispatched alert has been delivered, spooled or
+// dropped, or until ctx ends. Short-lived callers use it before exiting so
+// in-flight alerts are not lost. Safe on a nil Dispatcher.
+func (d *Dispatcher) Flush(ctx context.Context) error {
+	if d == nil {
+		return nil
+	}
+	done := make(chan struct{})
+	go func() {
+		d.inflight.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// deliver sends the event on one route. After a success, alerts spooled
+// during an earlier outage are redelivered. A transient failure is spooled
+// if the route has a spool; anything else is dropped.
+func deliver(r route, event AlertEvent) {
+	ctx := context.Background()
+	err := r.alerter.Send(ctx, event)
+	if err == nil {
+		stats.delivered.Add(1)
+		if r.spool != nil {
+			r.spool.flush(ctx, r.alerter)
 		}
+		return
 	}
+	if r.spool != nil && !isPermanent(err) {
+		if r.spool.put(event) == nil {
+			stats.spooled.Add(1)
+			return
+		}
+	}
+	stats.dropped.Add(1)
+}
+
+// scrubSecrets redacts credentials (API keys, tokens, PEM blocks,
+// connection strings, env assignments) from the free-text fields of an
+// alert, using the same patterns as command output scanning.
+func scrubSecrets(event AlertEvent) AlertEvent {
+	event.Resource, _ = redact.ScanOutputFull(event.Resource)
+	event.Reason, _ = redact.ScanOutputFull(event.Reason)
+	return event
+}
+
+func selects(r route, event AlertEvent) bool {
+	if len(event.Rule.Channels) > 0 && !containsChannel(event.Rule.Channels, r.channel) {
+		return false
+	}
+	if event.Rule.Mode == RuleAlertForce {
+		return true
+	}
+	return matches(r.events, event)
+}
+
+func containsChannel(channels []string, channel string) bool {
+	for _, c := range channels {
+		if strings.EqualFold(strings.TrimSpace(c), channel) {
+			return true
+		}
+	}
+	return false
 }
 
 func matches(events []string, event AlertEvent) bool {
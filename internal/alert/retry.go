@@ -0,0 +1,109 @@
+package alert
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	defaultInitialBackoff = 1 * time.Second
+	defaultMaxBackoff     = 30 * time.Second
+)
+
+// RetryConfig bounds delivery retries for an alert channel. Zero values
+// use the defaults: 3 attempts, 1s initial backoff doubling up to 30s.
+type RetryConfig struct {
+	MaxAttempts    int           `yaml:"max_attempts"    json:"max_attempts"`
+	InitialBackoff time.Duration `yaml:"initial_backoff" json:"initial_backoff"`
+	MaxBackoff     time.Duration `yaml:"max_backoff"     json:"max_backoff"`
+}
+
+func (r RetryConfig) attempts() int {
+	if r.MaxAttempts <= 0 {
+		return maxRetries
+	}
+	return r.MaxAttempts
+}
+
+// backoff returns the wait before retry n (1-based): InitialBackoff
+// doubled n-1 times, capped at MaxBackoff.
+func (r RetryConfig) backoff(n int) time.Duration {
+	d := r.InitialBackoff
+	if d <= 0 {
+		d = defaultInitialBackoff
+	}
+	limit := r.MaxBackoff
+	if limit <= 0 {
+		limit = defaultMaxBackoff
+	}
+	for i := 1; i < n && d < limit; i++ {
+		d *= 2
+	}
+	return min(d, limit)
+}
+
+// permanentError marks a delivery the endpoint rejected outright. Retrying
+// or spooling it will not help.
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// isPermanent reports whether err is a rejection that should not be retried.
+func isPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+// postJSON posts body to url, retrying transport errors and 5xx responses
+// with exponential backoff. 4xx responses are permanent errors. label
+// names the channel in error messages.
+func postJSON(ctx context.Context, retry RetryConfig, label, url string, body []byte, headers map[string]string) error {
+	attempts := retry.attempts()
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(retry.backoff(attempt)):
+			}
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+		if err != nil {
+			return &permanentError{fmt.Errorf("create %s request: %w", label, err)}
+		}
+		req.Header.Set("Content-Type", "application/json")
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		_ = resp.Body.Close()
+
+		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+			return nil
+		}
+		if resp.StatusCode >= 400 && resp.StatusCode < 500 {
+			return &permanentError{fmt.Errorf("%s rejected: HTTP %d", label, resp.StatusCode)}
+		}
+		// 5xx — retry
+		lastErr = fmt.Errorf("%s server error: HTTP %d", label, resp.StatusCode)
+	}
+
+	if lastErr == nil {
+		lastErr = fmt.Errorf("no %s attempts completed", label)
+	}
+	return fmt.Errorf("%s failed after %d attempts: %w", label, attempts, lastErr)
+}
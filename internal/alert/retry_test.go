@@ -0,0 +1,190 @@
+package alert
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryBackoffExponential(t *testing.T) {
+	r := RetryConfig{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	want := []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond, 800 * time.Millisecond, time.Second, time.Second}
+	for i, w := range want {
+		if got := r.backoff(i + 1); got != w {
+			t.Errorf("backoff(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+	if got := (RetryConfig{}).attempts(); got != maxRetries {
+		t.Errorf("default attempts = %d, want %d", got, maxRetries)
+	}
+}
+
+func TestRetryMaxAttempts(t *testing.T) {
+	var attempts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	cfg := AlertConfig{URL: srv.URL, Retry: RetryConfig{MaxAttempts: 5, InitialBackoff: time.Millisecond}}
+	if err := Send(cfg, AlertEvent{Decision: "deny"}); err == nil {
+		t.Fatal("expected error after exhausting retries")
+	}
+	if attempts.Load() != 5 {
+		t.Errorf("expected 5 attempts, got %d", attempts.Load())
+	}
+}
+
+// flakyServer fails every request until restored, recording the trace IDs
+// it accepts.
+type flakyServer struct {
+	mu        sync.Mutex
+	up        bool
+	delivered []string
+}
+
+func (f *flakyServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.up {
+		w.WriteHeader(http.StatusServiceUnavailable)
+		return
+	}
+	var event AlertEvent
+	_ = json.NewDecoder(r.Body).Decode(&event)
+	f.delivered = append(f.delivered, event.TraceID)
+	w.WriteHeader(http.StatusOK)
+}
+
+func (f *flakyServer) restore() {
+	f.mu.Lock()
+	f.up = true
+	f.mu.Unlock()
+}
+
+func (f *flakyServer) traces() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]string(nil), f.delivered...)
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestSpooledAlertDeliveredAfterRecovery(t *testing.T) {
+	flaky := &flakyServer{}
+	srv := httptest.NewServer(flaky)
+	defer srv.Close()
+
+	cfg := AlertConfig{
+		URL:      srv.URL,
+		Format:   "generic",
+		Events:   []string{"deny"},
+		Retry:    RetryConfig{MaxAttempts: 2, InitialBackoff: time.Millisecond},
+		SpoolDir: t.TempDir(),
+	}
+	d := NewDispatcher([]AlertConfig{cfg})
+	sp := newSpool(cfg)
+	before := Stats()
+
+	d.Dispatch(AlertEvent{TraceID: "t-outage", Decision: "deny"})
+	waitFor(t, func() bool { return len(sp.pending()) == 1 })
+	if got := Stats().Spooled - before.Spooled; got != 1 {
+		t.Errorf("expected 1 spooled alert, got %d", got)
+	}
+
+	flaky.restore()
+	d.Dispatch(AlertEvent{TraceID: "t-recovered", Decision: "deny"})
+	waitFor(t, func() bool { return len(flaky.traces()) == 2 })
+
+	got := flaky.traces()
+	if got[0] != "t-recovered" || got[1] != "t-outage" {
+		t.Errorf("expected live alert then spooled alert, got %v", got)
+	}
+	if len(sp.pending()) != 0 {
+		t.Errorf("expected empty spool after redelivery, got %v", sp.pending())
+	}
+	if got := Stats().Redelivered - before.Redelivered; got != 1 {
+		t.Errorf("expected 1 redelivered alert, got %d", got)
+	}
+}
+
+func TestSpoolFlushedOnStartup(t *testing.T) {
+	flaky := &flakyServer{up: true}
+	srv := httptest.NewServer(flaky)
+	defer srv.Close()
+
+	cfg := AlertConfig{URL: srv.URL, Events: []string{"deny"}, SpoolDir: t.TempDir()}
+	if err := newSpool(cfg).put(AlertEvent{TraceID: "t-previous-run", Decision: "deny"}); err != nil {
+		t.Fatal(err)
+	}
+
+	NewDispatcher([]AlertConfig{cfg})
+	waitFor(t, func() bool { return len(flaky.traces()) == 1 })
+	if got := flaky.traces()[0]; got != "t-previous-run" {
+		t.Errorf("expected spooled alert redelivered, got %q", got)
+	}
+}
+
+func TestDroppedAfterRetryCounted(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	before := Stats().Dropped
+	d := NewDispatcher([]AlertConfig{{
+		URL:    srv.URL,
+		Events: []string{"deny"},
+		Retry:  RetryConfig{MaxAttempts: 2, InitialBackoff: time.Millisecond},
+	}})
+	d.Dispatch(AlertEvent{Decision: "deny"})
+	waitFor(t, func() bool { return Stats().Dropped-before == 1 })
+}
+
+func TestSpoolEvictsOldestWhenFull(t *testing.T) {
+	cfg := AlertConfig{URL: "http://127.0.0.1:1", SpoolDir: t.TempDir(), SpoolMax: 2}
+	sp := newSpool(cfg)
+	before := Stats().Evicted
+
+	for _, trace := range []string{"t-1", "t-2", "t-3"} {
+		if err := sp.put(AlertEvent{TraceID: trace, Decision: "deny"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	files := sp.pending()
+	if len(files) != 2 {
+		t.Fatalf("expected spool capped at 2, got %d", len(files))
+	}
+	data, err := os.ReadFile(files[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "t-2") {
+		t.Errorf("expected oldest alert evicted, first spooled is %s", data)
+	}
+	if got := Stats().Evicted - before; got != 1 {
+		t.Errorf("expected 1 evicted alert, got %d", got)
+	}
+
+	status := SpoolStatuses([]AlertConfig{cfg, {URL: "http://unspooled"}})
+	if len(status) != 1 || status[0].Pending != 2 || status[0].Max != 2 {
+		t.Errorf("unexpected spool status %+v", status)
+	}
+}
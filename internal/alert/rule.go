@@ -0,0 +1,64 @@
+package alert
+
+import (
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Per-rule alert modes.
+const (
+	// RuleAlertForce dispatches to every channel regardless of its events filter.
+	RuleAlertForce = "force"
+	// RuleAlertSuppress never dispatches events produced by the rule.
+	RuleAlertSuppress = "suppress"
+)
+
+// RuleAlert overrides alert routing for decisions produced by a single policy rule.
+//
+// In YAML it is either a bare mode:
+//
+//	alert: force
+//
+// or a mapping with an optional channel override:
+//
+//	alert:
+//	  mode: force
+//	  channels: [telegram]
+type RuleAlert struct {
+	Mode     string   `yaml:"mode" json:"mode,omitempty"`         // "", "force", "suppress"
+	Channels []string `yaml:"channels" json:"channels,omitempty"` // restrict delivery to these channels
+}
+
+// UnmarshalYAML accepts both the scalar and mapping forms.
+func (ra *RuleAlert) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		ra.Mode = node.Value
+		ra.Channels = nil
+	} else {
+		type plain RuleAlert
+		var p plain
+		if err := node.Decode(&p); err != nil {
+			return err
+		}
+		*ra = RuleAlert(p)
+	}
+	ra.Mode = strings.ToLower(strings.TrimSpace(ra.Mode))
+	return ra.Validate()
+}
+
+// Validate checks the mode is known.
+func (ra RuleAlert) Validate() error {
+	switch ra.Mode {
+	case "", RuleAlertForce, RuleAlertSuppress:
+		return nil
+	default:
+		return fmt.Errorf("unknown alert mode %q (valid: %s, %s)", ra.Mode, RuleAlertForce, RuleAlertSuppress)
+	}
+}
+
+// IsZero reports whether the override leaves default routing unchanged.
+func (ra RuleAlert) IsZero() bool {
+	return ra.Mode == "" && len(ra.Channels) == 0
+}
@@ -0,0 +1,194 @@
+package alert
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// defaultSpoolMax caps spooled alerts per channel when SpoolMax is unset.
+const defaultSpoolMax = 1000
+
+// DeliveryStats counts alert delivery outcomes since process start.
+type DeliveryStats struct {
+	Delivered   int64 `json:"delivered"`
+	Spooled     int64 `json:"spooled"`
+	Redelivered int64 `json:"redelivered"`
+	Dropped     int64 `json:"dropped"` // failed after retries and not spooled
+	Evicted     int64 `json:"evicted"` // oldest spooled alerts removed by spool_max
+}
+
+var stats struct {
+	delivered, spooled, redelivered, dropped, evicted atomic.Int64
+}
+
+// Stats returns the process-wide alert delivery counters.
+func Stats() DeliveryStats {
+	return DeliveryStats{
+		Delivered:   stats.delivered.Load(),
+		Spooled:     stats.spooled.Load(),
+		Redelivered: stats.redelivered.Load(),
+		Dropped:     stats.dropped.Load(),
+		Evicted:     stats.evicted.Load(),
+	}
+}
+
+// SpoolStatus is the on-disk backlog of one channel's spool.
+type SpoolStatus struct {
+	Channel string `json:"channel"`
+	Dir     string `json:"dir"`
+	Pending int    `json:"pending"`
+	Max     int    `json:"max"`
+}
+
+// SpoolStatuses reports the spooled alerts waiting for each configured
+// channel that has a spool. The spool is on disk, so this sees alerts
+// left by any process using the same configs.
+func SpoolStatuses(configs []AlertConfig) []SpoolStatus {
+	var out []SpoolStatus
+	for _, cfg := range configs {
+		s := newSpool(cfg)
+		if s == nil {
+			continue
+		}
+		out = append(out, SpoolStatus{
+			Channel: cfg.ChannelName(),
+			Dir:     s.dir,
+			Pending: len(s.pending()),
+			Max:     s.max,
+		})
+	}
+	return out
+}
+
+// spoolLocks serializes flushes per spool directory, so dispatchers rebuilt
+// on reload never redeliver the same file twice.
+var spoolLocks sync.Map // dir -> *sync.Mutex
+
+// spool persists alerts that exhausted their retries, one JSON file each,
+// keeping at most max files.
+type spool struct {
+	dir string
+	max int
+}
+
+// newSpool returns the spool for a channel config, or nil if spooling is
+// disabled. Each destination gets its own subdirectory.
+func newSpool(cfg AlertConfig) *spool {
+	if strings.TrimSpace(cfg.SpoolDir) == "" {
+		return nil
+	}
+	limit := cfg.SpoolMax
+	if limit <= 0 {
+		limit = defaultSpoolMax
+	}
+	return &spool{dir: filepath.Join(cfg.SpoolDir, routeKey(cfg)), max: limit}
+}
+
+// routeKey identifies a destination without putting secrets in the path.
+func routeKey(cfg AlertConfig) string {
+	channel := cfg.ChannelName()
+	h := sha256.New()
+	fmt.Fprintf(h, "%s\x00%s\x00%s\x00%s\x00%s\x00%s",
+		channel, cfg.URL, cfg.Telegram.APIURL, cfg.Telegram.ChatID,
+		cfg.Email.SMTPHost, strings.Join(cfg.Email.To, ","))
+	return channel + "-" + hex.EncodeToString(h.Sum(nil))[:12]
+}
+
+func (s *spool) lock() *sync.Mutex {
+	mu, _ := spoolLocks.LoadOrStore(s.dir, &sync.Mutex{})
+	return mu.(*sync.Mutex)
+}
+
+// put writes the event to the spool. Files are named by time so flush
+// redelivers in order. A full spool evicts its oldest alerts, so a long
+// outage keeps the most recent ones within the cap.
+func (s *spool) put(event AlertEvent) error {
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return fmt.Errorf("create alert spool: %w", err)
+	}
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("marshal spooled alert: %w", err)
+	}
+	f, err := os.CreateTemp(s.dir, fmt.Sprintf("%020d-*.tmp", time.Now().UnixNano()))
+	if err != nil {
+		return fmt.Errorf("create spooled alert: %w", err)
+	}
+	tmp := f.Name()
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		_ = os.Remove(tmp)
+		return fmt.Errorf("write spooled alert: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("write spooled alert: %w", err)
+	}
+	if err := os.Rename(tmp, strings.TrimSuffix(tmp, ".tmp")+".json"); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("write spooled alert: %w", err)
+	}
+	s.evict()
+	return nil
+}
+
+// evict removes the oldest spooled alerts beyond the cap.
+func (s *spool) evict() {
+	files := s.pending()
+	for _, path := range files[:max(len(files)-s.max, 0)] {
+		if os.Remove(path) == nil {
+			stats.evicted.Add(1)
+		}
+	}
+}
+
+// pending lists spooled alert files, oldest first.
+func (s *spool) pending() []string {
+	files, _ := filepath.Glob(filepath.Join(s.dir, "*.json"))
+	sort.Strings(files)
+	return files
+}
+
+// flush redelivers spooled alerts in order, stopping at the first failure
+// so the rest wait for the next recovery. A flush already running for the
+// same spool is not duplicated. Unreadable files and permanent rejections
+// are dropped.
+func (s *spool) flush(ctx context.Context, alerter Alerter) {
+	mu := s.lock()
+	if !mu.TryLock() {
+		return
+	}
+	defer mu.Unlock()
+
+	for _, path := range s.pending() {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var event AlertEvent
+		if err := json.Unmarshal(data, &event); err != nil {
+			_ = os.Remove(path)
+			stats.dropped.Add(1)
+			continue
+		}
+		if err := alerter.Send(ctx, event); err != nil {
+			if !isPermanent(err) {
+				return
+			}
+			stats.dropped.Add(1)
+		} else {
+			stats.redelivered.Add(1)
+		}
+		_ = os.Remove(path)
+	}
+}
@@ -1,13 +1,10 @@
 package alert
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
-	"net/http"
 	"strings"
-	"time"
 )
 
 const defaultTelegramAPIURL = "https://api.telegram.org"
@@ -55,42 +52,7 @@ func (a *TelegramAlerter) Send(ctx context.Context, event AlertEvent) error {
 	}
 
 	endpoint := fmt.Sprintf("%s/bot%s/sendMessage", strings.TrimRight(apiURL, "/"), a.cfg.Telegram.BotToken)
-	var lastErr error
-	for attempt := 0; attempt < maxRetries; attempt++ {
-		if attempt > 0 {
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			case <-time.After(time.Duration(attempt) * time.Second):
-			}
-		}
-
-		req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
-		if err != nil {
-			return fmt.Errorf("create telegram request: %w", err)
-		}
-		req.Header.Set("Content-Type", "application/json")
-
-		resp, err := httpClient.Do(req)
-		if err != nil {
-			lastErr = err
-			continue
-		}
-		_ = resp.Body.Close()
-
-		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-			return nil
-		}
-		if resp.StatusCode >= 400 && resp.StatusCode < 500 {
-			return fmt.Errorf("telegram rejected: HTTP %d", resp.StatusCode)
-		}
-		lastErr = fmt.Errorf("telegram server error: HTTP %d", resp.StatusCode)
-	}
-
-	if lastErr == nil {
-		lastErr = fmt.Errorf("no telegram attempts completed")
-	}
-	return fmt.Errorf("telegram failed after %d attempts: %w", maxRetries, lastErr)
+	return postJSON(ctx, a.cfg.Retry, "telegram", endpoint, body, nil)
 }
 
 func (a *TelegramAlerter) apiURL() string {
@@ -1,11 +1,9 @@
 package alert
 
 import (
-	"bytes"
 	"context"
 	"fmt"
 	"net/http"
-	"time"
 )
 
 var httpClient = &http.Client{Timeout: requestTimeout}
@@ -37,7 +35,8 @@ func (a *WebhookAlerter) Name() string {
 	return channelWebhook
 }
 
-// Send posts an alert event to a webhook endpoint with retry on 5xx.
+// Send posts an alert event to a webhook endpoint, retrying transport
+// errors and 5xx responses per the channel's retry config.
 func (a *WebhookAlerter) Send(ctx context.Context, event AlertEvent) error {
 	if shouldRedactWebhook(a.cfg.URL) {
 		event = redactEvent(event)
@@ -48,46 +47,7 @@ func (a *WebhookAlerter) Send(ctx context.Context, event AlertEvent) error {
 		return fmt.Errorf("format payload: %w", err)
 	}
 
-	var lastErr error
-	for attempt := 0; attempt < maxRetries; attempt++ {
-		if attempt > 0 {
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			case <-time.After(time.Duration(attempt) * time.Second):
-			}
-		}
-
-		req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.cfg.URL, bytes.NewReader(body))
-		if err != nil {
-			return fmt.Errorf("create request: %w", err)
-		}
-		req.Header.Set("Content-Type", "application/json")
-		for k, v := range a.cfg.Headers {
-			req.Header.Set(k, v)
-		}
-
-		resp, err := httpClient.Do(req)
-		if err != nil {
-			lastErr = err
-			continue
-		}
-		_ = resp.Body.Close()
-
-		if resp.StatusCode >= 200 && resp.StatusCode < 300 {
-			return nil
-		}
-		if resp.StatusCode >= 400 && resp.StatusCode < 500 {
-			return fmt.Errorf("webhook rejected: HTTP %d", resp.StatusCode)
-		}
-		// 5xx — retry
-		lastErr = fmt.Errorf("webhook server error: HTTP %d", resp.StatusCode)
-	}
-
-	if lastErr == nil {
-		lastErr = fmt.Errorf("no webhook attempts completed")
-	}
-	return fmt.Errorf("webhook failed after %d attempts: %w", maxRetries, lastErr)
+	return postJSON(ctx, a.cfg.Retry, "webhook", a.cfg.URL, body, a.cfg.Headers)
 }
 
 // Send posts an alert event to a webhook endpoint with retry on 5xx.
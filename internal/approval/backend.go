@@ -0,0 +1,159 @@
+package approval
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ErrNotFound is returned by a Backend when a key has no record.
+var ErrNotFound = errors.New("not found")
+
+// Backend persists approval records. Records are opaque JSON documents
+// addressed by approval key; the Store owns their semantics.
+type Backend interface {
+	// Get returns the record for key, or ErrNotFound.
+	Get(key string) ([]byte, error)
+	// Put stores the record for key, replacing any existing one.
+	Put(key string, data []byte) error
+	// Create stores the record only if key has none. It reports whether
+	// the record was stored.
+	Create(key string, data []byte) (bool, error)
+	// Keys returns every key with a record.
+	Keys() ([]string, error)
+	// Clear removes every record.
+	Clear() error
+	// Lock serializes read-modify-write cycles on key across every
+	// process using the backend. The returned func releases the lock.
+	Lock(key string) (func(), error)
+	// Shared reports whether other processes use the same records.
+	Shared() bool
+}
+
+// Backend types accepted by BackendConfig.Type.
+const (
+	BackendFile  = "file"
+	BackendRedis = "redis"
+)
+
+// BackendConfig selects where approvals are stored. The default file
+// backend keeps one JSON file per key in the approval directory; redis
+// shares approvals between chainwatch replicas.
+type BackendConfig struct {
+	Type        string        `yaml:"type" json:"type"`                                     // file (default) | redis
+	Addr        string        `yaml:"addr,omitempty" json:"addr,omitempty"`                 // redis host:port
+	PasswordEnv string        `yaml:"password_env,omitempty" json:"password_env,omitempty"` // env var holding the redis password
+	DB          int           `yaml:"db,omitempty" json:"db,omitempty"`                     // redis logical database
+	Prefix      string        `yaml:"prefix,omitempty" json:"prefix,omitempty"`             // key prefix (default "chainwatch:approval:")
+	Timeout     time.Duration `yaml:"timeout,omitempty" json:"timeout,omitempty"`           // dial and I/O timeout (default 5s)
+	TLS         bool          `yaml:"tls,omitempty" json:"tls,omitempty"`                   // connect to redis over TLS
+	CAFile      string        `yaml:"ca_file,omitempty" json:"ca_file,omitempty"`           // PEM CA bundle for the redis server (implies tls; default: system roots)
+}
+
+// Open creates a Store on the backend described by cfg. The file backend
+// uses dir; other backends ignore it.
+func Open(dir string, cfg BackendConfig) (*Store, error) {
+	switch cfg.Type {
+	case "", BackendFile:
+		return NewStore(dir)
+	case BackendRedis:
+		if cfg.Addr == "" {
+			return nil, fmt.Errorf("redis approval store requires addr")
+		}
+		password := ""
+		if cfg.PasswordEnv != "" {
+			password = os.Getenv(cfg.PasswordEnv)
+		}
+		backend, err := newRedisBackend(cfg, password)
+		if err != nil {
+			return nil, err
+		}
+		return NewStoreWithBackend(backend), nil
+	default:
+		return nil, fmt.Errorf("unknown approval store type %q (want %s or %s)", cfg.Type, BackendFile, BackendRedis)
+	}
+}
+
+// fileBackend stores each record as <dir>/<key>.json. Writes go through a
+// temp file and rename so readers never see a partial record.
+type fileBackend struct {
+	dir string
+}
+
+func (b *fileBackend) path(key string) string {
+	return filepath.Join(b.dir, key+".json")
+}
+
+func (b *fileBackend) Get(key string) ([]byte, error) {
+	data, err := os.ReadFile(b.path(key))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+func (b *fileBackend) Put(key string, data []byte) error {
+	path := b.path(key)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+func (b *fileBackend) Create(key string, data []byte) (bool, error) {
+	if _, err := os.Stat(b.path(key)); err == nil {
+		return false, nil
+	}
+	return true, b.Put(key, data)
+}
+
+func (b *fileBackend) Keys() ([]string, error) {
+	entries, err := os.ReadDir(b.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var keys []string
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		keys = append(keys, strings.TrimSuffix(e.Name(), ".json"))
+	}
+	return keys, nil
+}
+
+func (b *fileBackend) Clear() error {
+	entries, err := os.ReadDir(b.dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	var errs []error
+	for _, e := range entries {
+		if e.IsDir() {
+			continue
+		}
+		if err := os.Remove(filepath.Join(b.dir, e.Name())); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Lock is a no-op: the Store mutex serializes writers within a process,
+// and the file store has never coordinated between processes.
+func (b *fileBackend) Lock(string) (func(), error) {
+	return func() {}, nil
+}
+
+func (b *fileBackend) Shared() bool { return false }
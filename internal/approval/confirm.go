@@ -0,0 +1,149 @@
+package approval
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ppiankov/chainwatch/internal/model"
+)
+
+// ErrConfirmRejected is returned when a confirm token is missing, expired,
+// or presented for an action other than the one it was issued for.
+var ErrConfirmRejected = errors.New("confirm token rejected")
+
+// IssueConfirm issues a confirm token for the approved key, bound to the
+// action fingerprint. The action must target the resource the approval was
+// requested for, so an approval cannot be redirected at another resource.
+// Issuing again replaces any earlier token. The token is returned once;
+// only its hash is stored.
+func (s *Store) IssueConfirm(key, resource, fingerprint string, ttl time.Duration) (string, error) {
+	if err := validateKey(key); err != nil {
+		return "", fmt.Errorf("invalid approval key: %w", err)
+	}
+
+	unlock, err := s.lockKey(key)
+	if err != nil {
+		return "", err
+	}
+	defer unlock()
+
+	a, err := s.read(key)
+	if err != nil {
+		return "", fmt.Errorf("approval %q not found: %w", key, err)
+	}
+	if a.Status != StatusApproved {
+		return "", fmt.Errorf("approval %q is %s", key, a.Status)
+	}
+	if a.Resource != "" && a.Resource != resource {
+		return "", fmt.Errorf("%w: approval %q was granted for %q, not %q", ErrConfirmRejected, key, a.Resource, resource)
+	}
+
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate confirm token: %w", err)
+	}
+	token := "cf-" + hex.EncodeToString(b)
+
+	exp := time.Now().UTC().Add(ttl)
+	a.ConfirmHash = hashConfirm(token)
+	a.ConfirmFingerprint = fingerprint
+	a.ConfirmExpiresAt = &exp
+	if err := s.write(key, *a); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// Confirm redeems a confirm token for the action with fingerprint. On
+// success the token is spent and the approval consumed as by Consume.
+func (s *Store) Confirm(key, fingerprint, token string) error {
+	if err := validateKey(key); err != nil {
+		return fmt.Errorf("invalid approval key: %w", err)
+	}
+
+	unlock, err := s.lockKey(key)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	a, err := s.read(key)
+	if err != nil {
+		return fmt.Errorf("approval %q not found: %w", key, err)
+	}
+
+	now := time.Now().UTC()
+	switch {
+	case a.Status != StatusApproved:
+		return fmt.Errorf("%w: approval %q is %s", ErrConfirmRejected, key, a.Status)
+	case a.ConfirmHash == "":
+		return fmt.Errorf("%w: no token issued for %q", ErrConfirmRejected, key)
+	case subtle.ConstantTimeCompare([]byte(hashConfirm(token)), []byte(a.ConfirmHash)) != 1:
+		return fmt.Errorf("%w: token does not belong to %q", ErrConfirmRejected, key)
+	case a.ConfirmExpiresAt != nil && now.After(*a.ConfirmExpiresAt):
+		return fmt.Errorf("%w: token expired", ErrConfirmRejected)
+	case a.ConfirmFingerprint != fingerprint:
+		return fmt.Errorf("%w: token was issued for a different action", ErrConfirmRejected)
+	}
+
+	a.clearConfirm()
+	if a.TraceID == "" {
+		a.Status = StatusConsumed
+		a.ResolvedAt = &now
+	}
+	return s.write(key, *a)
+}
+
+// ConfirmApproved applies the confirm step to a require_approval result
+// whose key is approved. With a valid token for this exact action the
+// result becomes allow. Without a token, a fresh one is issued and returned
+// in result.ConfirmToken while the action stays blocked; a wrong token
+// blocks without issuing one.
+func (s *Store) ConfirmApproved(result model.PolicyResult, action *model.Action, token string, ttl time.Duration) model.PolicyResult {
+	fingerprint := action.Fingerprint()
+	blocked := result
+	blocked.PolicyID = "approval.confirm"
+
+	if token != "" {
+		if err := s.Confirm(result.ApprovalKey, fingerprint, token); err != nil {
+			blocked.Reason = fmt.Sprintf("%v (%s)", err, result.Reason)
+			return blocked
+		}
+		result.Decision = model.Allow
+		result.Reason = "approved and confirmed: " + result.Reason
+		return result
+	}
+
+	issued, err := s.IssueConfirm(result.ApprovalKey, action.Resource, fingerprint, ttl)
+	if err != nil {
+		blocked.Reason = fmt.Sprintf("%v (%s)", err, result.Reason)
+		return blocked
+	}
+	blocked.ConfirmToken = issued
+	blocked.Reason = fmt.Sprintf("approved, irreversible: retry this exact action with confirm token within %s (%s)", ttl, result.Reason)
+	return blocked
+}
+
+// ConfirmUnsupported blocks an approved result that needs a confirm token
+// at an enforcement point with no way for the agent to present one.
+func ConfirmUnsupported(result model.PolicyResult) model.PolicyResult {
+	result.PolicyID = "approval.confirm"
+	result.Reason = fmt.Sprintf("%v: irreversible action needs a confirm token, which this enforcement point cannot accept (%s)", ErrConfirmRejected, result.Reason)
+	return result
+}
+
+func (a *Approval) clearConfirm() {
+	a.ConfirmHash = ""
+	a.ConfirmFingerprint = ""
+	a.ConfirmExpiresAt = nil
+}
+
+func hashConfirm(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
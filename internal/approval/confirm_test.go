@@ -0,0 +1,127 @@
+package approval
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ppiankov/chainwatch/internal/model"
+)
+
+func confirmAction(resource string) *model.Action {
+	return &model.Action{
+		Tool:      "command",
+		Resource:  resource,
+		Operation: "execute",
+		RawMeta:   map[string]any{"sensitivity": "high", "egress": "internal"},
+	}
+}
+
+func approvedResult(t *testing.T, s *Store, key, resource string) model.PolicyResult {
+	t.Helper()
+	if err := s.Request(key, "irreversible", "rule.drop", resource, ""); err != nil {
+		t.Fatalf("Request: %v", err)
+	}
+	if err := s.Approve(key, 0, ""); err != nil {
+		t.Fatalf("Approve: %v", err)
+	}
+	return model.PolicyResult{
+		Decision:    model.RequireApproval,
+		Reason:      "irreversible",
+		Tier:        3,
+		PolicyID:    "rule.drop",
+		ApprovalKey: key,
+	}
+}
+
+func TestConfirmTokenForExactAction(t *testing.T) {
+	s := newTestStore(t)
+	action := confirmAction("dropdb prod")
+	result := approvedResult(t, s, "drop_prod", action.Resource)
+
+	// First retry after approval: still blocked, token issued.
+	first := s.ConfirmApproved(result, action, "", time.Minute)
+	if first.Decision != model.RequireApproval || first.ConfirmToken == "" {
+		t.Fatalf("expected blocked result with confirm token, got %s token=%q", first.Decision, first.ConfirmToken)
+	}
+	if first.PolicyID != "approval.confirm" {
+		t.Errorf("expected policy_id approval.confirm, got %s", first.PolicyID)
+	}
+
+	a, _ := s.read("drop_prod")
+	if a.ConfirmHash == "" || strings.Contains(a.ConfirmHash, first.ConfirmToken) {
+		t.Errorf("expected only a hash of the token on disk, got %q", a.ConfirmHash)
+	}
+
+	// Retry with the token: allowed and approval consumed.
+	second := s.ConfirmApproved(result, confirmAction("dropdb prod"), first.ConfirmToken, time.Minute)
+	if second.Decision != model.Allow {
+		t.Fatalf("expected allow with confirm token, got %s: %s", second.Decision, second.Reason)
+	}
+	if status, _ := s.Check("drop_prod"); status != StatusConsumed {
+		t.Errorf("expected consumed after confirm, got %s", status)
+	}
+
+	// The token is single-use.
+	if err := s.Confirm("drop_prod", action.Fingerprint(), first.ConfirmToken); !errors.Is(err, ErrConfirmRejected) {
+		t.Errorf("expected replayed token rejected, got %v", err)
+	}
+}
+
+func TestConfirmTokenRejectedForDifferentResource(t *testing.T) {
+	s := newTestStore(t)
+	action := confirmAction("dropdb staging")
+	result := approvedResult(t, s, "drop_db", action.Resource)
+
+	issued := s.ConfirmApproved(result, action, "", time.Minute)
+	if issued.ConfirmToken == "" {
+		t.Fatalf("expected token, got %s: %s", issued.Decision, issued.Reason)
+	}
+
+	// Replaying the approval and its token against another resource fails.
+	other := confirmAction("dropdb prod")
+	replay := s.ConfirmApproved(result, other, issued.ConfirmToken, time.Minute)
+	if replay.Decision == model.Allow {
+		t.Fatal("confirm token must not allow a different resource")
+	}
+	if !strings.Contains(replay.Reason, "different action") {
+		t.Errorf("unexpected reason: %s", replay.Reason)
+	}
+
+	// Nor can a fresh token be obtained for the other resource.
+	fresh := s.ConfirmApproved(result, other, "", time.Minute)
+	if fresh.Decision == model.Allow || fresh.ConfirmToken != "" {
+		t.Fatalf("expected no token for a resource the approval was not granted for, got %q", fresh.ConfirmToken)
+	}
+
+	// The approval is untouched and still confirms the original action.
+	ok := s.ConfirmApproved(result, action, issued.ConfirmToken, time.Minute)
+	if ok.Decision != model.Allow {
+		t.Errorf("expected original action confirmed, got %s: %s", ok.Decision, ok.Reason)
+	}
+}
+
+func TestConfirmTokenExpires(t *testing.T) {
+	s := newTestStore(t)
+	action := confirmAction("rm -rf /srv/data")
+	approvedResult(t, s, "wipe", action.Resource)
+
+	token, err := s.IssueConfirm("wipe", action.Resource, action.Fingerprint(), -time.Second)
+	if err != nil {
+		t.Fatalf("IssueConfirm: %v", err)
+	}
+	if err := s.Confirm("wipe", action.Fingerprint(), token); !errors.Is(err, ErrConfirmRejected) {
+		t.Errorf("expected expired token rejected, got %v", err)
+	}
+}
+
+func TestConfirmRequiresApproval(t *testing.T) {
+	s := newTestStore(t)
+	if err := s.Request("pending_key", "r", "p", "x", ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.IssueConfirm("pending_key", "x", "sha256:x", time.Minute); err == nil {
+		t.Error("expected no token for an unapproved key")
+	}
+}
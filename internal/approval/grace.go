@@ -0,0 +1,78 @@
+package approval
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/ppiankov/chainwatch/internal/model"
+)
+
+// GraceWindow is an open approval grace window of one rule. It covers only
+// the trace and agent whose use of the approval opened it.
+type GraceWindow struct {
+	Until   time.Time `json:"until"`
+	TraceID string    `json:"trace_id,omitempty"`
+	AgentID string    `json:"agent_id,omitempty"`
+}
+
+// OpenGrace records that the approval of result.ApprovalKey was just used
+// by agentID in traceID and opens the grace window of the rule that
+// required it: until result.ApprovalGrace elapses, later actions of the
+// same agent and trace matching the same rule (same PolicyID) are allowed
+// by Grace. The window is scoped to the rule, not the key, so other rules
+// sharing the key still prompt. No-op when the rule has no grace window.
+func (s *Store) OpenGrace(result model.PolicyResult, traceID, agentID string) error {
+	if result.ApprovalGrace <= 0 || result.PolicyID == "" {
+		return nil
+	}
+	if err := validateKey(result.ApprovalKey); err != nil {
+		return fmt.Errorf("invalid approval key: %w", err)
+	}
+
+	unlock, err := s.lockKey(result.ApprovalKey)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	a, err := s.read(result.ApprovalKey)
+	if err != nil {
+		return fmt.Errorf("approval %q not found: %w", result.ApprovalKey, err)
+	}
+	if a.Grace == nil {
+		a.Grace = make(map[string]GraceWindow)
+	}
+	a.Grace[result.PolicyID] = GraceWindow{
+		Until:   time.Now().UTC().Add(result.ApprovalGrace),
+		TraceID: traceID,
+		AgentID: agentID,
+	}
+	return s.write(result.ApprovalKey, *a)
+}
+
+// Grace reports whether a require_approval result of agentID in traceID
+// falls in an open grace window of its rule. If so it returns the result
+// converted to allow, with the approval that opened the window named in
+// the reason. Callers check the throttle first: a throttled key is denied
+// even inside a window.
+func (s *Store) Grace(result model.PolicyResult, traceID, agentID string) (model.PolicyResult, bool) {
+	if result.ApprovalGrace <= 0 || result.PolicyID == "" || validateKey(result.ApprovalKey) != nil {
+		return result, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	a, err := s.read(result.ApprovalKey)
+	if err != nil {
+		return result, false
+	}
+	w, ok := a.Grace[result.PolicyID]
+	if !ok || !time.Now().UTC().Before(w.Until) || w.TraceID != traceID || w.AgentID != agentID {
+		return result, false
+	}
+	result.Decision = model.Allow
+	result.Reason = fmt.Sprintf("approval grace window (approved key %q, until %s): %s",
+		a.Key, w.Until.Format(time.RFC3339), result.Reason)
+	return result, true
+}
@@ -0,0 +1,112 @@
+package approval
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ppiankov/chainwatch/internal/model"
+)
+
+func graceResult(policyID string, grace time.Duration) model.PolicyResult {
+	return model.PolicyResult{
+		Decision:      model.RequireApproval,
+		Reason:        "deploys need approval",
+		ApprovalKey:   "deploy",
+		PolicyID:      policyID,
+		ApprovalGrace: grace,
+	}
+}
+
+func TestGraceAllowsSameRuleWithinWindow(t *testing.T) {
+	s := newTestStore(t)
+	s.Request("deploy", "deploys need approval", "purpose.ops.deploy", "deploy step1", "")
+	if err := s.Approve("deploy", 0, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Consume("deploy"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.OpenGrace(graceResult("purpose.ops.deploy", time.Minute), "trace-1", "agent-a"); err != nil {
+		t.Fatalf("OpenGrace: %v", err)
+	}
+
+	got, ok := s.Grace(graceResult("purpose.ops.deploy", time.Minute), "trace-1", "agent-a")
+	if !ok || got.Decision != model.Allow {
+		t.Fatalf("expected same-rule action allowed in grace window, got %v %+v", ok, got)
+	}
+	if got.PolicyID != "purpose.ops.deploy" {
+		t.Errorf("expected policy ID preserved, got %s", got.PolicyID)
+	}
+
+	if _, ok := s.Grace(graceResult("purpose.ops.migrate", time.Minute), "trace-1", "agent-a"); ok {
+		t.Error("grace window must not cover a different rule")
+	}
+}
+
+func TestGraceExpires(t *testing.T) {
+	s := newTestStore(t)
+	s.Request("deploy", "r", "purpose.ops.deploy", "deploy", "")
+	s.Approve("deploy", 0, "")
+	if err := s.OpenGrace(graceResult("purpose.ops.deploy", 20*time.Millisecond), "trace-1", "agent-a"); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(40 * time.Millisecond)
+	if _, ok := s.Grace(graceResult("purpose.ops.deploy", time.Minute), "trace-1", "agent-a"); ok {
+		t.Error("expected grace window to have expired")
+	}
+}
+
+func TestGraceClosedByDeny(t *testing.T) {
+	s := newTestStore(t)
+	s.Request("deploy", "r", "purpose.ops.deploy", "deploy", "")
+	s.Approve("deploy", 0, "")
+	s.OpenGrace(graceResult("purpose.ops.deploy", time.Minute), "trace-1", "agent-a")
+	if err := s.Deny("deploy"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.Grace(graceResult("purpose.ops.deploy", time.Minute), "trace-1", "agent-a"); ok {
+		t.Error("expected deny to close the grace window")
+	}
+}
+
+func TestOpenGraceNoopWithoutWindow(t *testing.T) {
+	s := newTestStore(t)
+	s.Request("deploy", "r", "purpose.ops.deploy", "deploy", "")
+	if err := s.OpenGrace(graceResult("purpose.ops.deploy", 0), "trace-1", "agent-a"); err != nil {
+		t.Fatal(err)
+	}
+	a, _ := s.read("deploy")
+	if len(a.Grace) != 0 {
+		t.Error("expected no grace window for a rule without approval_grace")
+	}
+}
+
+func TestGraceScopedToTraceAndAgent(t *testing.T) {
+	s := newTestStore(t)
+	s.Request("deploy", "r", "purpose.ops.deploy", "deploy", "")
+	s.Approve("deploy", 0, "")
+	if err := s.OpenGrace(graceResult("purpose.ops.deploy", time.Minute), "trace-1", "agent-a"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := s.Grace(graceResult("purpose.ops.deploy", time.Minute), "trace-2", "agent-a"); ok {
+		t.Error("grace window must not cover another trace")
+	}
+	if _, ok := s.Grace(graceResult("purpose.ops.deploy", time.Minute), "trace-1", "agent-b"); ok {
+		t.Error("grace window must not cover another agent")
+	}
+}
+
+func TestGraceWindowsPerRuleOnSharedKey(t *testing.T) {
+	s := newTestStore(t)
+	s.Request("deploy", "r", "purpose.ops.deploy", "deploy", "")
+	s.Approve("deploy", 0, "")
+	s.OpenGrace(graceResult("purpose.ops.deploy", time.Minute), "trace-1", "agent-a")
+	s.OpenGrace(graceResult("purpose.ops.migrate", time.Minute), "trace-1", "agent-a")
+
+	for _, id := range []string{"purpose.ops.deploy", "purpose.ops.migrate"} {
+		if _, ok := s.Grace(graceResult(id, time.Minute), "trace-1", "agent-a"); !ok {
+			t.Errorf("expected open grace window for %s", id)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package approval
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	defaultRedisPrefix  = "chainwatch:approval:"
+	defaultRedisTimeout = 5 * time.Second
+
+	// redisLockTTL bounds how long a crashed holder can block a key.
+	redisLockTTL = 10 * time.Second
+	// redisLockRetry is the wait between lock attempts.
+	redisLockRetry = 20 * time.Millisecond
+)
+
+// redisUnlock deletes a lock only if this holder still owns it.
+var redisUnlock = redis.NewScript(`if redis.call("get", KEYS[1]) == ARGV[1] then return redis.call("del", KEYS[1]) else return 0 end`)
+
+// redisBackend stores approvals in Redis so every replica sees the same
+// approvals.
+type redisBackend struct {
+	client  *redis.Client
+	prefix  string
+	timeout time.Duration
+}
+
+func newRedisBackend(cfg BackendConfig, password string) (*redisBackend, error) {
+	b := &redisBackend{
+		prefix:  cfg.Prefix,
+		timeout: cfg.Timeout,
+	}
+	if b.prefix == "" {
+		b.prefix = defaultRedisPrefix
+	}
+	if b.timeout <= 0 {
+		b.timeout = defaultRedisTimeout
+	}
+
+	opts := &redis.Options{
+		Addr:         cfg.Addr,
+		Password:     password,
+		DB:           cfg.DB,
+		DialTimeout:  b.timeout,
+		ReadTimeout:  b.timeout,
+		WriteTimeout: b.timeout,
+	}
+	if cfg.TLS || cfg.CAFile != "" {
+		tlsCfg, err := redisTLSConfig(cfg)
+		if err != nil {
+			return nil, err
+		}
+		opts.TLSConfig = tlsCfg
+	}
+	b.client = redis.NewClient(opts)
+	return b, nil
+}
+
+// redisTLSConfig verifies the server against CAFile, or the system roots
+// when none is set.
+func redisTLSConfig(cfg BackendConfig) (*tls.Config, error) {
+	tlsCfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if cfg.CAFile == "" {
+		return tlsCfg, nil
+	}
+	pem, err := os.ReadFile(cfg.CAFile)
+	if err != nil {
+		return nil, fmt.Errorf("redis ca_file: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("redis ca_file %s: no certificates found", cfg.CAFile)
+	}
+	tlsCfg.RootCAs = pool
+	return tlsCfg, nil
+}
+
+func (b *redisBackend) recordKey(key string) string { return b.prefix + key }
+func (b *redisBackend) lockKey(key string) string   { return b.prefix + "lock:" + key }
+
+func (b *redisBackend) ctx() (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.Background(), b.timeout)
+}
+
+func (b *redisBackend) Get(key string) ([]byte, error) {
+	ctx, cancel := b.ctx()
+	defer cancel()
+	data, err := b.client.Get(ctx, b.recordKey(key)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+func (b *redisBackend) Put(key string, data []byte) error {
+	ctx, cancel := b.ctx()
+	defer cancel()
+	return b.client.Set(ctx, b.recordKey(key), data, 0).Err()
+}
+
+func (b *redisBackend) Create(key string, data []byte) (bool, error) {
+	ctx, cancel := b.ctx()
+	defer cancel()
+	return b.client.SetNX(ctx, b.recordKey(key), data, 0).Result()
+}
+
+func (b *redisBackend) Keys() ([]string, error) {
+	ctx, cancel := b.ctx()
+	defer cancel()
+	var keys []string
+	iter := b.client.Scan(ctx, 0, b.prefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		key := strings.TrimPrefix(iter.Val(), b.prefix)
+		// Approval keys cannot contain ':', so this skips lock keys.
+		if key != "" && !strings.Contains(key, ":") {
+			keys = append(keys, key)
+		}
+	}
+	return keys, iter.Err()
+}
+
+func (b *redisBackend) Clear() error {
+	keys, err := b.Keys()
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, key := range keys {
+		ctx, cancel := b.ctx()
+		errs = append(errs, b.client.Del(ctx, b.recordKey(key)).Err())
+		cancel()
+	}
+	return errors.Join(errs...)
+}
+
+// Lock takes a per-key lock with SET NX PX, retrying until the timeout.
+// The lock expires on its own if the holder dies.
+func (b *redisBackend) Lock(key string) (func(), error) {
+	tok := make([]byte, 16)
+	if _, err := rand.Read(tok); err != nil {
+		return nil, err
+	}
+	token := hex.EncodeToString(tok)
+	lockKey := b.lockKey(key)
+
+	ctx, cancel := b.ctx()
+	defer cancel()
+	for {
+		ok, err := b.client.SetNX(ctx, lockKey, token, redisLockTTL).Result()
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			return func() {
+				ctx, cancel := b.ctx()
+				defer cancel()
+				redisUnlock.Run(ctx, b.client, []string{lockKey}, token)
+			}, nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("timed out waiting for lock on %q", key)
+		case <-time.After(redisLockRetry):
+		}
+	}
+}
+
+func (b *redisBackend) Shared() bool { return true }
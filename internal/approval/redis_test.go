@@ -0,0 +1,221 @@
+package approval
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+)
+
+func openRedisStore(t *testing.T, m *miniredis.Miniredis, cfg BackendConfig) *Store {
+	t.Helper()
+	cfg.Type = BackendRedis
+	cfg.Addr = m.Addr()
+	s, err := Open("", cfg)
+	if err != nil {
+		t.Fatalf("open redis store: %v", err)
+	}
+	return s
+}
+
+func TestRedisStoreSharedBetweenInstances(t *testing.T) {
+	m := miniredis.RunT(t)
+	a := openRedisStore(t, m, BackendConfig{})
+	b := openRedisStore(t, m, BackendConfig{})
+
+	if err := a.Request("deploy_prod", "deploy", "rule.deploy", "kubectl apply", "agent-a"); err != nil {
+		t.Fatal(err)
+	}
+	// A second request from another replica must not reset the first.
+	if err := b.Request("deploy_prod", "other", "rule.other", "x", "agent-b"); err != nil {
+		t.Fatal(err)
+	}
+
+	list, err := b.List()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 1 || list[0].Key != "deploy_prod" || list[0].RequestedBy != "agent-a" {
+		t.Fatalf("expected replica b to list a's request, got %+v", list)
+	}
+
+	if err := b.Approve("deploy_prod", 0, ""); err != nil {
+		t.Fatal(err)
+	}
+	if status, _ := a.Check("deploy_prod"); status != StatusApproved {
+		t.Fatalf("expected approval visible on replica a, got %s", status)
+	}
+
+	if err := a.Consume("deploy_prod"); err != nil {
+		t.Fatal(err)
+	}
+	if status, _ := b.Check("deploy_prod"); status != StatusConsumed {
+		t.Fatalf("expected consumption visible on replica b, got %s", status)
+	}
+	if err := b.Consume("deploy_prod"); err == nil {
+		t.Fatal("expected second consume on another replica to fail")
+	}
+
+	// Startup cleanup of one replica must not wipe shared approvals.
+	if err := a.Cleanup(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := b.Check("deploy_prod"); err != nil {
+		t.Fatalf("expected shared approval to survive cleanup: %v", err)
+	}
+}
+
+func TestRedisStoreAuthAndPrefix(t *testing.T) {
+	t.Setenv("TEST_REDIS_PASSWORD", "s3cret")
+	m := miniredis.RunT(t)
+	m.RequireAuth("s3cret")
+	s := openRedisStore(t, m, BackendConfig{PasswordEnv: "TEST_REDIS_PASSWORD", DB: 2, Prefix: "cw:"})
+
+	if err := s.Request("k1", "r", "p", "res", ""); err != nil {
+		t.Fatal(err)
+	}
+	if !m.DB(2).Exists("cw:k1") {
+		t.Error("expected record stored under prefix cw: in db 2")
+	}
+
+	bad := openRedisStore(t, m, BackendConfig{Prefix: "cw:", DB: 2})
+	if _, err := bad.List(); err == nil {
+		t.Error("expected error without password")
+	}
+}
+
+func TestRedisLockReleased(t *testing.T) {
+	m := miniredis.RunT(t)
+	b, err := newRedisBackend(BackendConfig{Addr: m.Addr()}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unlock, err := b.Lock("k1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.Exists(b.lockKey("k1")) {
+		t.Fatal("expected lock key to be set")
+	}
+	unlock()
+	if m.Exists(b.lockKey("k1")) {
+		t.Fatal("expected lock key to be released")
+	}
+
+	keys, err := b.Keys()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 0 {
+		t.Errorf("expected lock keys not listed, got %v", keys)
+	}
+}
+
+func TestOpenUnknownBackend(t *testing.T) {
+	if _, err := Open(t.TempDir(), BackendConfig{Type: "etcd"}); err == nil {
+		t.Error("expected error for unknown backend type")
+	}
+	if _, err := Open("", BackendConfig{Type: BackendRedis}); err == nil {
+		t.Error("expected error for redis without addr")
+	}
+	s, err := Open(t.TempDir(), BackendConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.backend.Shared() {
+		t.Error("expected default backend to be the local file store")
+	}
+}
+
+func TestRedisLockWaitDoesNotBlockOtherKeys(t *testing.T) {
+	m := miniredis.RunT(t)
+	s := openRedisStore(t, m, BackendConfig{Timeout: 500 * time.Millisecond})
+	if err := s.Request("held", "r", "p", "res", ""); err != nil {
+		t.Fatal(err)
+	}
+	// Another replica holds the lock on "held" for the whole test.
+	m.Set(defaultRedisPrefix+"lock:held", "other-replica")
+
+	done := make(chan error, 1)
+	go func() { done <- s.Approve("held", 0, "") }()
+	time.Sleep(50 * time.Millisecond)
+
+	start := time.Now()
+	if err := s.Request("other", "r", "p", "res", ""); err != nil {
+		t.Fatal(err)
+	}
+	if status, err := s.Check("held"); err != nil || status != StatusPending {
+		t.Fatalf("expected lock-free read of held key, got %s %v", status, err)
+	}
+	if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+		t.Errorf("other keys waited %s on a held lock", elapsed)
+	}
+	if err := <-done; err == nil {
+		t.Error("expected approve of the held key to time out")
+	}
+}
+
+func TestRedisStoreTLS(t *testing.T) {
+	serverCfg, caFile := testTLS(t)
+	m, err := miniredis.RunTLS(serverCfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(m.Close)
+
+	s := openRedisStore(t, m, BackendConfig{CAFile: caFile})
+	if err := s.Request("k1", "r", "p", "res", ""); err != nil {
+		t.Fatalf("request over TLS: %v", err)
+	}
+	if !m.Exists(defaultRedisPrefix + "k1") {
+		t.Error("expected record stored over TLS")
+	}
+
+	plain := openRedisStore(t, m, BackendConfig{})
+	if _, err := plain.List(); err == nil {
+		t.Error("expected plaintext client to fail against a TLS server")
+	}
+}
+
+// testTLS returns a server config with a self-signed certificate for
+// 127.0.0.1 and the path of its PEM file, used as the client CA.
+func testTLS(t *testing.T) (*tls.Config, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "redis-test"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cert := tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+	return &tls.Config{Certificates: []tls.Certificate{cert}}, caFile
+}
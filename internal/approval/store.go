@@ -10,6 +10,9 @@ import (
 	"strings"
 	"sync"
 	"time"
+
+	"github.com/ppiankov/chainwatch/internal/alert"
+	"github.com/ppiankov/chainwatch/internal/model"
 )
 
 // validKey matches alphanumeric, dash, underscore, and dot characters only.
@@ -38,8 +41,24 @@ const (
 	StatusDenied   Status = "denied"
 	StatusConsumed Status = "consumed"
 	StatusExpired  Status = "expired"
+
+	// StatusThrottled is reported instead of re-prompting when the key
+	// has been approved MaxApprovals times within the throttle window.
+	StatusThrottled Status = "throttled"
 )
 
+// maxGrantHistory bounds the grant timestamps kept per approval.
+const maxGrantHistory = 100
+
+// Throttle limits approval fatigue: once a key has been approved
+// MaxApprovals times within Window, further matching actions are
+// auto-denied instead of prompting again until the window slides past
+// the oldest grant. A zero MaxApprovals disables the throttle.
+type Throttle struct {
+	MaxApprovals int           `yaml:"max_approvals" json:"max_approvals"`
+	Window       time.Duration `yaml:"window" json:"window"`
+}
+
 // Approval represents a single approval request and its state.
 type Approval struct {
 	Key         string     `json:"key"`
@@ -52,12 +71,95 @@ type Approval struct {
 	CreatedAt   time.Time  `json:"created_at"`
 	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
 	ResolvedAt  *time.Time `json:"resolved_at,omitempty"`
+
+	// TraceID scopes an approval to one trace. A trace-scoped approval
+	// covers every matching action in that trace and is never consumed.
+	TraceID string `json:"trace_id,omitempty"`
+
+	// Grants records when the key was approved, for the throttle.
+	Grants []time.Time `json:"grants,omitempty"`
+
+	// RequireReason is set when the key belongs to a require_reason rule.
+	// Such approvals are rejected without a Justification.
+	RequireReason bool   `json:"require_reason,omitempty"`
+	Justification string `json:"justification,omitempty"`
+
+	// Confirm token state for irreversible actions (see IssueConfirm).
+	// Only a hash of the token is stored.
+	ConfirmHash        string     `json:"confirm_hash,omitempty"`
+	ConfirmFingerprint string     `json:"confirm_fingerprint,omitempty"`
+	ConfirmExpiresAt   *time.Time `json:"confirm_expires_at,omitempty"`
+
+	// Grace windows opened when the approval was used (see OpenGrace),
+	// by policy ID of the rule they cover.
+	Grace map[string]GraceWindow `json:"grace,omitempty"`
 }
 
-// Store manages approval files on disk.
+// ErrReasonRequired is returned when approving a require_reason key
+// without a justification.
+var ErrReasonRequired = errors.New("approval requires a reason")
+
+// ApproveOptions describes an approval grant.
+type ApproveOptions struct {
+	Duration      time.Duration // > 0 sets an expiration; 0 is one-time use
+	ApprovedBy    string        // empty for human/CLI
+	TraceID       string        // scopes the approval to one trace
+	Justification string        // why the operator approved; see RequireReason
+}
+
+// Store manages approval records on a Backend, by default files on disk.
 type Store struct {
-	dir string
-	mu  sync.Mutex
+	backend    Backend
+	mu         sync.Mutex
+	throttle   Throttle
+	reasonKeys map[string]bool
+}
+
+// ThrottleDeny converts a require_approval result whose key reported
+// StatusThrottled into the auto-deny, forcing an alert so the operator
+// learns the pattern is unusually frequent.
+func (s *Store) ThrottleDeny(result model.PolicyResult) model.PolicyResult {
+	s.mu.Lock()
+	t := s.throttle
+	s.mu.Unlock()
+
+	result.Decision = model.Deny
+	result.Reason = fmt.Sprintf("approval throttled: %q was approved %d times within %s; auto-denied until the window resets (%s)",
+		result.ApprovalKey, t.MaxApprovals, t.Window, result.Reason)
+	result.PolicyID = "approval.throttle"
+	result.AlertMode = alert.RuleAlertForce
+	result.AlertChannels = nil
+	return result
+}
+
+// SetThrottle configures the approval throttle. A zero Throttle disables it.
+func (s *Store) SetThrottle(t Throttle) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.throttle = t
+}
+
+// SetReasonRequired marks approval keys whose approvals must carry a
+// justification. Requests for these keys record the requirement, so it is
+// enforced by any process that approves them.
+func (s *Store) SetReasonRequired(keys []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reasonKeys = make(map[string]bool, len(keys))
+	for _, k := range keys {
+		s.reasonKeys[k] = true
+	}
+}
+
+// recentGrants counts grants of a within the throttle window ending at now.
+func (s *Store) recentGrants(a *Approval, now time.Time) int {
+	n := 0
+	for _, g := range a.Grants {
+		if now.Sub(g) < s.throttle.Window {
+			n++
+		}
+	}
+	return n
 }
 
 // NewStore creates a Store backed by the given directory.
@@ -65,7 +167,12 @@ func NewStore(dir string) (*Store, error) {
 	if err := os.MkdirAll(dir, 0755); err != nil {
 		return nil, fmt.Errorf("cannot create approval directory: %w", err)
 	}
-	return &Store{dir: dir}, nil
+	return NewStoreWithBackend(&fileBackend{dir: dir}), nil
+}
+
+// NewStoreWithBackend creates a Store on backend.
+func NewStoreWithBackend(backend Backend) *Store {
+	return &Store{backend: backend}
 }
 
 // DefaultDir returns the default approval store directory.
@@ -77,7 +184,17 @@ func DefaultDir() string {
 	return filepath.Join(home, ".chainwatch", "pending")
 }
 
-// Request creates a pending approval file. No-op if file already exists.
+// DefaultAuditLog returns where operator approvals are audited when no
+// audit log is given.
+func DefaultAuditLog() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return filepath.Join(os.TempDir(), "chainwatch-approvals.jsonl")
+	}
+	return filepath.Join(home, ".chainwatch", "approvals.jsonl")
+}
+
+// Request creates a pending approval. No-op if the key already exists.
 // requestedBy identifies the agent that created this request (empty for human/legacy).
 func (s *Store) Request(key, reason, policyID, resource, requestedBy string) error {
 	if err := validateKey(key); err != nil {
@@ -87,11 +204,6 @@ func (s *Store) Request(key, reason, policyID, resource, requestedBy string) err
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	path := s.path(key)
-	if _, err := os.Stat(path); err == nil {
-		return nil // already exists
-	}
-
 	a := Approval{
 		Key:         key,
 		Status:      StatusPending,
@@ -100,9 +212,16 @@ func (s *Store) Request(key, reason, policyID, resource, requestedBy string) err
 		Resource:    resource,
 		RequestedBy: requestedBy,
 		CreatedAt:   time.Now().UTC(),
+
+		RequireReason: s.reasonKeys[key],
 	}
 
-	return s.writeAtomic(path, a)
+	data, err := json.MarshalIndent(a, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = s.backend.Create(key, data) // no-op if already exists
+	return err
 }
 
 // Approve marks an approval as approved. If duration > 0, sets expiration.
@@ -110,12 +229,32 @@ func (s *Store) Request(key, reason, policyID, resource, requestedBy string) err
 // approvedBy identifies who is approving (empty for human/CLI).
 // Anti-circular: an agent cannot approve its own request.
 func (s *Store) Approve(key string, duration time.Duration, approvedBy string) error {
+	return s.ApproveWith(key, ApproveOptions{Duration: duration, ApprovedBy: approvedBy})
+}
+
+// ApproveForTrace approves key for every matching action in traceID.
+// Unlike one-time approvals it is not consumed on use; unlike duration
+// approvals it does not apply to other traces. If duration > 0 the
+// approval also expires after that period.
+func (s *Store) ApproveForTrace(key, traceID string, duration time.Duration, approvedBy string) error {
+	if traceID == "" {
+		return fmt.Errorf("trace-scoped approval requires a trace ID")
+	}
+	return s.ApproveWith(key, ApproveOptions{Duration: duration, ApprovedBy: approvedBy, TraceID: traceID})
+}
+
+// ApproveWith approves key as described by opts. Keys of require_reason
+// rules fail with ErrReasonRequired unless opts.Justification is set.
+func (s *Store) ApproveWith(key string, opts ApproveOptions) error {
 	if err := validateKey(key); err != nil {
 		return fmt.Errorf("invalid approval key: %w", err)
 	}
 
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	unlock, err := s.lockKey(key)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
 	a, err := s.read(key)
 	if err != nil {
@@ -123,20 +262,32 @@ func (s *Store) Approve(key string, duration time.Duration, approvedBy string) e
 	}
 
 	// Anti-circular: agent cannot approve its own request.
-	if a.RequestedBy != "" && approvedBy != "" && a.RequestedBy == approvedBy {
-		return fmt.Errorf("agent %q cannot approve its own request", approvedBy)
+	if a.RequestedBy != "" && opts.ApprovedBy != "" && a.RequestedBy == opts.ApprovedBy {
+		return fmt.Errorf("agent %q cannot approve its own request", opts.ApprovedBy)
+	}
+
+	justification := strings.TrimSpace(opts.Justification)
+	if (a.RequireReason || s.reasonKeys[key]) && justification == "" {
+		return fmt.Errorf("%w: %q is covered by a require_reason rule", ErrReasonRequired, key)
 	}
 
 	a.Status = StatusApproved
-	a.ApprovedBy = approvedBy
+	a.ApprovedBy = opts.ApprovedBy
+	a.clearConfirm()
+	a.TraceID = opts.TraceID
+	a.Justification = justification
 	now := time.Now().UTC()
 	a.ResolvedAt = &now
-	if duration > 0 {
-		exp := now.Add(duration)
+	a.Grants = append(a.Grants, now)
+	if len(a.Grants) > maxGrantHistory {
+		a.Grants = a.Grants[len(a.Grants)-maxGrantHistory:]
+	}
+	if opts.Duration > 0 {
+		exp := now.Add(opts.Duration)
 		a.ExpiresAt = &exp
 	}
 
-	return s.writeAtomic(s.path(key), *a)
+	return s.write(key, *a)
 }
 
 // Deny marks an approval as denied.
@@ -145,8 +296,11 @@ func (s *Store) Deny(key string) error {
 		return fmt.Errorf("invalid approval key: %w", err)
 	}
 
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	unlock, err := s.lockKey(key)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
 	a, err := s.read(key)
 	if err != nil {
@@ -156,43 +310,97 @@ func (s *Store) Deny(key string) error {
 	a.Status = StatusDenied
 	now := time.Now().UTC()
 	a.ResolvedAt = &now
+	a.Grace = nil
 
-	return s.writeAtomic(s.path(key), *a)
+	return s.write(key, *a)
 }
 
 // Check returns the current status of an approval.
 // Returns StatusExpired if the approval has passed its deadline.
+// Trace-scoped approvals report StatusPending; use CheckTrace.
 func (s *Store) Check(key string) (Status, error) {
+	return s.CheckTrace(key, "")
+}
+
+// CheckTrace returns the status of an approval for an action in traceID.
+// An approval scoped to a different trace reports StatusPending: it exists
+// but does not cover this trace. When a throttle is set and the key has
+// used up its approvals for the window, anything short of a live approval
+// or an explicit denial reports StatusThrottled. It is a read: the
+// distributed lock is only taken to record an expiry.
+func (s *Store) CheckTrace(key, traceID string) (Status, error) {
+	return s.checkTrace(key, traceID, true)
+}
+
+// PeekTrace reports what CheckTrace would, without recording an expiry,
+// for callers that must not change the store (dry runs).
+func (s *Store) PeekTrace(key, traceID string) (Status, error) {
+	return s.checkTrace(key, traceID, false)
+}
+
+func (s *Store) checkTrace(key, traceID string, recordExpiry bool) (Status, error) {
 	if err := validateKey(key); err != nil {
 		return "", fmt.Errorf("invalid approval key: %w", err)
 	}
 
-	s.mu.Lock()
-	defer s.mu.Unlock()
-
 	a, err := s.read(key)
 	if err != nil {
 		return "", fmt.Errorf("approval %q not found", key)
 	}
 
+	status := a.Status
+	now := time.Now().UTC()
+
 	// Check expiration for approved entries
-	if a.Status == StatusApproved && a.ExpiresAt != nil && time.Now().UTC().After(*a.ExpiresAt) {
-		a.Status = StatusExpired
-		s.writeAtomic(s.path(key), *a)
-		return StatusExpired, nil
+	if status == StatusApproved && a.ExpiresAt != nil && now.After(*a.ExpiresAt) {
+		if recordExpiry {
+			s.expire(key, now)
+		}
+		status = StatusExpired
+	} else if status == StatusApproved && a.TraceID != "" && a.TraceID != traceID {
+		status = StatusPending
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if status != StatusApproved && status != StatusDenied &&
+		s.throttle.MaxApprovals > 0 && s.recentGrants(a, now) >= s.throttle.MaxApprovals {
+		return StatusThrottled, nil
+	}
+
+	return status, nil
+}
+
+// expire records that the approval of key lapsed at now, unless it was
+// re-approved since it was read. Best effort: CheckTrace reports the
+// expiry either way.
+func (s *Store) expire(key string, now time.Time) {
+	unlock, err := s.lockKey(key)
+	if err != nil {
+		return
 	}
+	defer unlock()
 
-	return a.Status, nil
+	a, err := s.read(key)
+	if err != nil || a.Status != StatusApproved || a.ExpiresAt == nil || !now.After(*a.ExpiresAt) {
+		return
+	}
+	a.Status = StatusExpired
+	s.write(key, *a)
 }
 
-// Consume marks a one-time approval as consumed.
+// Consume marks a one-time approval as consumed. Trace-scoped approvals
+// are left approved so they cover the rest of the trace.
 func (s *Store) Consume(key string) error {
 	if err := validateKey(key); err != nil {
 		return fmt.Errorf("invalid approval key: %w", err)
 	}
 
-	s.mu.Lock()
-	defer s.mu.Unlock()
+	unlock, err := s.lockKey(key)
+	if err != nil {
+		return err
+	}
+	defer unlock()
 
 	a, err := s.read(key)
 	if err != nil {
@@ -202,12 +410,15 @@ func (s *Store) Consume(key string) error {
 	if a.Status == StatusConsumed {
 		return fmt.Errorf("approval %q already consumed", key)
 	}
+	if a.TraceID != "" {
+		return nil
+	}
 
 	a.Status = StatusConsumed
 	now := time.Now().UTC()
 	a.ResolvedAt = &now
 
-	return s.writeAtomic(s.path(key), *a)
+	return s.write(key, *a)
 }
 
 // List returns all approvals in the store.
@@ -215,20 +426,13 @@ func (s *Store) List() ([]Approval, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	entries, err := os.ReadDir(s.dir)
+	keys, err := s.backend.Keys()
 	if err != nil {
-		if os.IsNotExist(err) {
-			return nil, nil
-		}
 		return nil, err
 	}
 
 	var approvals []Approval
-	for _, e := range entries {
-		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
-			continue
-		}
-		key := strings.TrimSuffix(e.Name(), ".json")
+	for _, key := range keys {
 		a, err := s.read(key)
 		if err != nil {
 			continue
@@ -239,38 +443,36 @@ func (s *Store) List() ([]Approval, error) {
 	return approvals, nil
 }
 
-// Cleanup removes all approval files in the store.
+// Cleanup removes all approvals in the store. A shared backend is left
+// intact: its approvals still belong to the other replicas.
 func (s *Store) Cleanup() error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	entries, err := os.ReadDir(s.dir)
-	if err != nil {
-		if os.IsNotExist(err) {
-			return nil
-		}
-		return err
+	if s.backend.Shared() {
+		return nil
 	}
-
-	var errs []error
-	for _, e := range entries {
-		if e.IsDir() {
-			continue
-		}
-		if err := os.Remove(filepath.Join(s.dir, e.Name())); err != nil {
-			errs = append(errs, err)
-		}
-	}
-
-	return errors.Join(errs...)
+	return s.backend.Clear()
 }
 
-func (s *Store) path(key string) string {
-	return filepath.Join(s.dir, key+".json")
+// lockKey takes the backend and in-process locks for a read-modify-write
+// of key. The backend lock may poll, so it is taken first: waiting on one
+// key must not stall the store for every other key. The returned func
+// releases both.
+func (s *Store) lockKey(key string) (func(), error) {
+	unlock, err := s.backend.Lock(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to lock approval %q: %w", key, err)
+	}
+	s.mu.Lock()
+	return func() {
+		s.mu.Unlock()
+		unlock()
+	}, nil
 }
 
 func (s *Store) read(key string) (*Approval, error) {
-	data, err := os.ReadFile(s.path(key))
+	data, err := s.backend.Get(key)
 	if err != nil {
 		return nil, err
 	}
@@ -283,16 +485,10 @@ func (s *Store) read(key string) (*Approval, error) {
 	return &a, nil
 }
 
-func (s *Store) writeAtomic(path string, a Approval) error {
+func (s *Store) write(key string, a Approval) error {
 	data, err := json.MarshalIndent(a, "", "  ")
 	if err != nil {
 		return err
 	}
-
-	tmp := path + ".tmp"
-	if err := os.WriteFile(tmp, data, 0644); err != nil {
-		return err
-	}
-
-	return os.Rename(tmp, path)
+	return s.backend.Put(key, data)
 }
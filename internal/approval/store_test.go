@@ -1,9 +1,12 @@
 package approval
 
 import (
+	"errors"
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/ppiankov/chainwatch/internal/model"
 )
 
 func newTestStore(t *testing.T) *Store {
@@ -334,3 +337,143 @@ func TestApprovalTracksApprovedBy(t *testing.T) {
 		t.Errorf("expected approvedBy=agent-beta, got %s", a.ApprovedBy)
 	}
 }
+
+func TestApproveForTrace(t *testing.T) {
+	s := newTestStore(t)
+	s.Request("key1", "test", "p1", "/r1", "")
+
+	if err := s.ApproveForTrace("key1", "t-1", 0, ""); err != nil {
+		t.Fatalf("ApproveForTrace failed: %v", err)
+	}
+
+	// Repeated use within the same trace is allowed
+	for i := 0; i < 3; i++ {
+		status, _ := s.CheckTrace("key1", "t-1")
+		if status != StatusApproved {
+			t.Fatalf("iteration %d: expected approved for same trace, got %s", i, status)
+		}
+		if err := s.Consume("key1"); err != nil {
+			t.Fatalf("Consume failed: %v", err)
+		}
+	}
+
+	if status, _ := s.CheckTrace("key1", "t-2"); status != StatusPending {
+		t.Errorf("expected pending for different trace, got %s", status)
+	}
+	if status, _ := s.Check("key1"); status != StatusPending {
+		t.Errorf("expected pending without trace, got %s", status)
+	}
+
+	a, _ := s.read("key1")
+	if a.TraceID != "t-1" {
+		t.Errorf("expected trace_id=t-1, got %s", a.TraceID)
+	}
+}
+
+func TestApproveForTraceEmpty(t *testing.T) {
+	s := newTestStore(t)
+	s.Request("key1", "test", "p1", "/r1", "")
+	if err := s.ApproveForTrace("key1", "", 0, ""); err == nil {
+		t.Error("expected error for empty trace ID")
+	}
+}
+
+func TestThrottleDeniesAfterMaxApprovals(t *testing.T) {
+	s := newTestStore(t)
+	s.SetThrottle(Throttle{MaxApprovals: 2, Window: time.Hour})
+	s.Request("key1", "test", "p1", "/r1", "")
+
+	for i := 0; i < 2; i++ {
+		if err := s.Approve("key1", 0, ""); err != nil {
+			t.Fatalf("approve %d: %v", i, err)
+		}
+		if status, _ := s.Check("key1"); status != StatusApproved {
+			t.Fatalf("approval %d: expected approved, got %s", i, status)
+		}
+		s.Consume("key1")
+	}
+
+	// Third approval-requiring action within the window is throttled
+	if status, _ := s.Check("key1"); status != StatusThrottled {
+		t.Fatalf("expected throttled after 2 approvals, got %s", status)
+	}
+
+	result := s.ThrottleDeny(model.PolicyResult{Decision: model.RequireApproval, ApprovalKey: "key1", Reason: "needs approval"})
+	if result.Decision != model.Deny || result.PolicyID != "approval.throttle" || result.AlertMode != "force" {
+		t.Errorf("unexpected throttle result: %+v", result)
+	}
+}
+
+func TestThrottleResetsAfterWindow(t *testing.T) {
+	s := newTestStore(t)
+	s.SetThrottle(Throttle{MaxApprovals: 1, Window: time.Hour})
+	s.Request("key1", "test", "p1", "/r1", "")
+	s.Approve("key1", 0, "")
+	s.Consume("key1")
+
+	if status, _ := s.Check("key1"); status != StatusThrottled {
+		t.Fatalf("expected throttled, got %s", status)
+	}
+
+	// Age the grant past the window
+	a, _ := s.read("key1")
+	a.Grants = []time.Time{time.Now().UTC().Add(-2 * time.Hour)}
+	s.write("key1", *a)
+
+	if status, _ := s.Check("key1"); status != StatusConsumed {
+		t.Errorf("expected throttle to reset, got %s", status)
+	}
+}
+
+func TestThrottleDisabledByDefault(t *testing.T) {
+	s := newTestStore(t)
+	s.Request("key1", "test", "p1", "/r1", "")
+	for i := 0; i < 5; i++ {
+		s.Approve("key1", 0, "")
+		s.Consume("key1")
+	}
+	if status, _ := s.Check("key1"); status != StatusConsumed {
+		t.Errorf("expected consumed without throttle, got %s", status)
+	}
+}
+
+func TestApproveRequireReason(t *testing.T) {
+	s := newTestStore(t)
+	s.SetReasonRequired([]string{"prod_deploy"})
+	if err := s.Request("prod_deploy", "deploy to prod", "rule.deploy", "kubectl apply", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// A store without the policy (e.g. the approve CLI) still enforces it.
+	other := NewStoreWithBackend(s.backend)
+	if err := other.Approve("prod_deploy", 0, ""); !errors.Is(err, ErrReasonRequired) {
+		t.Fatalf("expected ErrReasonRequired, got %v", err)
+	}
+	err := other.ApproveWith("prod_deploy", ApproveOptions{Justification: "   "})
+	if !errors.Is(err, ErrReasonRequired) {
+		t.Fatalf("expected blank reason rejected, got %v", err)
+	}
+	if status, _ := other.Check("prod_deploy"); status != StatusPending {
+		t.Fatalf("expected still pending after rejected approval, got %s", status)
+	}
+
+	if err := other.ApproveWith("prod_deploy", ApproveOptions{Justification: " INC-42 hotfix "}); err != nil {
+		t.Fatalf("approve with reason: %v", err)
+	}
+	a, err := other.read("prod_deploy")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if a.Status != StatusApproved || a.Justification != "INC-42 hotfix" {
+		t.Errorf("expected approved with justification, got %s %q", a.Status, a.Justification)
+	}
+}
+
+func TestApproveWithoutRequireReason(t *testing.T) {
+	s := newTestStore(t)
+	s.SetReasonRequired([]string{"other_key"})
+	s.Request("plain_key", "r", "p", "res", "")
+	if err := s.Approve("plain_key", 0, ""); err != nil {
+		t.Fatalf("expected approval without reason for unlisted key, got %v", err)
+	}
+}
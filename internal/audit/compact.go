@@ -0,0 +1,320 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CompactionSummaryType is the entry type of the trailing summary line
+// written by Compact.
+const CompactionSummaryType = "compaction_summary"
+
+// CompactionSummary commits a compacted log to the original it came from.
+// Kept lines are copied verbatim, so the literal hash chain is broken
+// wherever entries were dropped; the summary replaces it with Merkle roots
+// over the kept and dropped lines plus the original chain tail.
+type CompactionSummary struct {
+	Keep           []string `json:"keep"`
+	SourceLines    int      `json:"source_lines"`
+	SourceTailHash string   `json:"source_tail_hash"`
+	Kept           int      `json:"kept"`
+	KeptRoot       string   `json:"kept_root"`
+	Dropped        int      `json:"dropped"`
+	DroppedRoot    string   `json:"dropped_root"`
+}
+
+// CompactFilter selects the entries Compact retains. An entry is kept if
+// it matches any clause.
+type CompactFilter struct {
+	exprs   []string
+	clauses []filterClause
+}
+
+type filterClause struct {
+	field  string
+	value  string
+	negate bool
+}
+
+// compactFields maps filter field names to entry accessors.
+var compactFields = map[string]func(AuditEntry) string{
+	"decision":   func(e AuditEntry) string { return e.Decision },
+	"type":       func(e AuditEntry) string { return e.Type },
+	"tier":       func(e AuditEntry) string { return strconv.Itoa(e.Tier) },
+	"tool":       func(e AuditEntry) string { return e.Action.Tool },
+	"resource":   func(e AuditEntry) string { return e.Action.Resource },
+	"trace_id":   func(e AuditEntry) string { return e.TraceID },
+	"agent_id":   func(e AuditEntry) string { return e.AgentID },
+	"session_id": func(e AuditEntry) string { return e.SessionID },
+}
+
+// ParseCompactFilter parses keep expressions of the form "field=value" or
+// "field!=value", e.g. "decision!=allow".
+func ParseCompactFilter(exprs []string) (*CompactFilter, error) {
+	if len(exprs) == 0 {
+		return nil, fmt.Errorf("audit: at least one keep expression is required")
+	}
+	f := &CompactFilter{exprs: exprs}
+	for _, expr := range exprs {
+		var c filterClause
+		field, value, ok := strings.Cut(expr, "!=")
+		if ok {
+			c.negate = true
+		} else if field, value, ok = strings.Cut(expr, "="); !ok {
+			return nil, fmt.Errorf("audit: invalid keep expression %q: want field=value or field!=value", expr)
+		}
+		c.field = strings.TrimSpace(field)
+		c.value = strings.TrimSpace(value)
+		if _, known := compactFields[c.field]; !known {
+			return nil, fmt.Errorf("audit: unknown keep field %q", c.field)
+		}
+		f.clauses = append(f.clauses, c)
+	}
+	return f, nil
+}
+
+// Match reports whether the entry should be retained.
+func (f *CompactFilter) Match(entry AuditEntry) bool {
+	for _, c := range f.clauses {
+		if (compactFields[c.field](entry) == c.value) != c.negate {
+			return true
+		}
+	}
+	return false
+}
+
+// Compact reads the audit log at src and writes dst containing the entries
+// that match filter, followed by a compaction_summary entry, and returns
+// that summary. The source chain must verify; compacting a tampered log
+// would launder it. The written log is checked with VerifyCompacted and
+// removed if it does not verify.
+func Compact(src, dst string, filter *CompactFilter) (*CompactionSummary, error) {
+	if v := Verify(src); !v.Valid {
+		return nil, fmt.Errorf("audit: source log does not verify (line %d): %s", v.ErrorLine, v.Error)
+	}
+
+	sum, kept, err := partition(src, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	prevHash := GenesisHash
+	for _, line := range kept {
+		buf.Write(line)
+		buf.WriteByte('\n')
+		prevHash = HashLine(line)
+	}
+
+	summary := AuditEntry{
+		Timestamp:  time.Now().UTC().Format(TimestampFormat),
+		Type:       CompactionSummaryType,
+		Reason:     fmt.Sprintf("compacted %d entries: kept %d, dropped %d", sum.SourceLines, sum.Kept, sum.Dropped),
+		PrevHash:   prevHash,
+		Compaction: sum,
+	}
+	line, err := json.Marshal(summary)
+	if err != nil {
+		return nil, fmt.Errorf("audit: marshal compaction summary: %w", err)
+	}
+	buf.Write(line)
+	buf.WriteByte('\n')
+
+	if err := os.WriteFile(dst, buf.Bytes(), 0600); err != nil {
+		return nil, fmt.Errorf("audit: write compacted log: %w", err)
+	}
+	if v := VerifyCompacted(dst); !v.Valid {
+		os.Remove(dst)
+		return nil, fmt.Errorf("audit: compacted log does not verify (line %d): %s", v.ErrorLine, v.Error)
+	}
+	return sum, nil
+}
+
+// VerifyCompaction checks a compacted log against the original it was
+// produced from: the original chain must verify, and re-running the
+// recorded keep filter must reproduce the same kept lines, dropped root,
+// and source tail.
+func VerifyCompaction(original, compacted string) VerifyResult {
+	if v := Verify(original); !v.Valid {
+		return VerifyResult{Error: "original: " + v.Error, ErrorLine: v.ErrorLine}
+	}
+
+	kept, summary, res := readCompacted(compacted)
+	if !res.Valid {
+		return res
+	}
+
+	filter, err := ParseCompactFilter(summary.Keep)
+	if err != nil {
+		return VerifyResult{Error: err.Error(), ErrorLine: len(kept) + 1}
+	}
+	want, wantKept, err := partition(original, filter)
+	if err != nil {
+		return VerifyResult{Error: err.Error()}
+	}
+
+	switch {
+	case want.SourceTailHash != summary.SourceTailHash:
+		return VerifyResult{Error: fmt.Sprintf("source tail mismatch: original %s, summary %s", want.SourceTailHash, summary.SourceTailHash), ErrorLine: len(kept) + 1}
+	case want.SourceLines != summary.SourceLines:
+		return VerifyResult{Error: fmt.Sprintf("source line count mismatch: original %d, summary %d", want.SourceLines, summary.SourceLines), ErrorLine: len(kept) + 1}
+	case want.DroppedRoot != summary.DroppedRoot || want.Dropped != summary.Dropped:
+		return VerifyResult{Error: "dropped entries do not match summary", ErrorLine: len(kept) + 1}
+	case len(wantKept) != len(kept):
+		return VerifyResult{Error: fmt.Sprintf("kept entry count mismatch: original yields %d, compacted has %d", len(wantKept), len(kept))}
+	}
+	for i := range kept {
+		if !bytes.Equal(kept[i], wantKept[i]) {
+			return VerifyResult{Error: "kept entry differs from original", ErrorLine: i + 1}
+		}
+	}
+	return VerifyResult{Valid: true, Lines: len(kept) + 1}
+}
+
+// VerifyCompacted checks a compacted log on its own: the trailing summary
+// must chain to the last kept line and its kept root must cover every
+// kept line. Dropped entries can only be checked with VerifyCompaction.
+func VerifyCompacted(path string) VerifyResult {
+	_, _, res := readCompacted(path)
+	return res
+}
+
+// IsCompacted reports whether the log at path ends in a compaction
+// summary, i.e. was written by Compact.
+func IsCompacted(path string) bool {
+	lines, err := readLines(path)
+	if err != nil || len(lines) == 0 {
+		return false
+	}
+	var entry AuditEntry
+	if err := json.Unmarshal(lines[len(lines)-1], &entry); err != nil {
+		return false
+	}
+	return entry.Type == CompactionSummaryType
+}
+
+// readCompacted splits a compacted log into kept lines and its summary,
+// validating the summary's commitments to the kept lines.
+func readCompacted(path string) ([][]byte, *CompactionSummary, VerifyResult) {
+	lines, err := readLines(path)
+	if err != nil {
+		return nil, nil, VerifyResult{Error: err.Error()}
+	}
+	if len(lines) == 0 {
+		return nil, nil, VerifyResult{Error: "compacted log is empty"}
+	}
+
+	last := len(lines)
+	var entry AuditEntry
+	if err := json.Unmarshal(lines[last-1], &entry); err != nil {
+		return nil, nil, VerifyResult{Error: fmt.Sprintf("parse error: %v", err), ErrorLine: last}
+	}
+	if entry.Type != CompactionSummaryType || entry.Compaction == nil {
+		return nil, nil, VerifyResult{Error: "last entry is not a compaction summary", ErrorLine: last}
+	}
+	summary := entry.Compaction
+	kept := lines[:last-1]
+
+	expectedPrev := GenesisHash
+	if len(kept) > 0 {
+		expectedPrev = HashLine(kept[len(kept)-1])
+	}
+	if entry.PrevHash != expectedPrev {
+		return nil, nil, VerifyResult{Error: fmt.Sprintf("hash mismatch: expected %s, got %s", expectedPrev, entry.PrevHash), ErrorLine: last}
+	}
+	if summary.Kept != len(kept) || summary.KeptRoot != merkleRoot(kept) {
+		return nil, nil, VerifyResult{Error: "kept entries do not match summary", ErrorLine: last}
+	}
+	return kept, summary, VerifyResult{Valid: true, Lines: last}
+}
+
+// partition splits the log at path by filter, returning the summary
+// commitments and the kept lines in order.
+func partition(path string, filter *CompactFilter) (*CompactionSummary, [][]byte, error) {
+	lines, err := readLines(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var kept, dropped [][]byte
+	for i, line := range lines {
+		var entry AuditEntry
+		if err := json.Unmarshal(line, &entry); err != nil {
+			return nil, nil, fmt.Errorf("audit: parse line %d: %w", i+1, err)
+		}
+		if filter.Match(entry) {
+			kept = append(kept, line)
+		} else {
+			dropped = append(dropped, line)
+		}
+	}
+
+	tail := GenesisHash
+	if len(lines) > 0 {
+		tail = HashLine(lines[len(lines)-1])
+	}
+	return &CompactionSummary{
+		Keep:           filter.exprs,
+		SourceLines:    len(lines),
+		SourceTailHash: tail,
+		Kept:           len(kept),
+		KeptRoot:       merkleRoot(kept),
+		Dropped:        len(dropped),
+		DroppedRoot:    merkleRoot(dropped),
+	}, kept, nil
+}
+
+func readLines(path string) ([][]byte, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("audit: open: %w", err)
+	}
+	defer f.Close()
+
+	var lines [][]byte
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	for scanner.Scan() {
+		line := make([]byte, len(scanner.Bytes()))
+		copy(line, scanner.Bytes())
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("audit: scan: %w", err)
+	}
+	return lines, nil
+}
+
+// merkleRoot computes an RFC 6962 style Merkle tree hash over lines, with
+// domain-separated leaf (0x00) and node (0x01) hashes.
+func merkleRoot(lines [][]byte) string {
+	h := merkleHash(lines)
+	return "sha256:" + hex.EncodeToString(h[:])
+}
+
+func merkleHash(lines [][]byte) [sha256.Size]byte {
+	switch len(lines) {
+	case 0:
+		return sha256.Sum256(nil)
+	case 1:
+		return sha256.Sum256(append([]byte{0x00}, lines[0]...))
+	}
+	k := 1
+	for k*2 < len(lines) {
+		k *= 2
+	}
+	left, right := merkleHash(lines[:k]), merkleHash(lines[k:])
+	node := make([]byte, 0, 1+2*sha256.Size)
+	node = append(node, 0x01)
+	node = append(node, left[:]...)
+	node = append(node, right[:]...)
+	return sha256.Sum256(node)
+}
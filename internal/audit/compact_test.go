@@ -0,0 +1,155 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeCompactSource(t *testing.T) string {
+	t.Helper()
+	l, path := newTestLog(t)
+	for _, d := range []string{"allow", "allow", "deny", "allow", "require_approval", "allow", "deny", "allow"} {
+		if err := l.Record(testEntry(d)); err != nil {
+			t.Fatalf("record: %v", err)
+		}
+	}
+	l.Close()
+	return path
+}
+
+func readEntries(t *testing.T, path string) []AuditEntry {
+	t.Helper()
+	f, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	defer f.Close()
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			t.Fatalf("parse: %v", err)
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func compactTestLog(t *testing.T, src string, keep ...string) string {
+	t.Helper()
+	filter, err := ParseCompactFilter(keep)
+	if err != nil {
+		t.Fatalf("ParseCompactFilter: %v", err)
+	}
+	dst := filepath.Join(t.TempDir(), "compacted.jsonl")
+	if _, err := Compact(src, dst, filter); err != nil {
+		t.Fatalf("Compact: %v", err)
+	}
+	return dst
+}
+
+func TestCompactPreservesDenials(t *testing.T) {
+	src := writeCompactSource(t)
+	dst := compactTestLog(t, src, "decision!=allow")
+
+	entries := readEntries(t, dst)
+	if len(entries) != 4 {
+		t.Fatalf("expected 3 kept entries + summary, got %d", len(entries))
+	}
+	for _, e := range entries[:3] {
+		if e.Decision == "allow" {
+			t.Errorf("allow entry should have been dropped: %+v", e)
+		}
+	}
+
+	sum := entries[3]
+	if sum.Type != CompactionSummaryType || sum.Compaction == nil {
+		t.Fatalf("expected trailing compaction summary, got %+v", sum)
+	}
+	if sum.Compaction.SourceLines != 8 || sum.Compaction.Kept != 3 || sum.Compaction.Dropped != 5 {
+		t.Errorf("unexpected summary counts: %+v", sum.Compaction)
+	}
+}
+
+func TestCompactSummaryVerifiesAgainstOriginal(t *testing.T) {
+	src := writeCompactSource(t)
+	dst := compactTestLog(t, src, "decision!=allow")
+
+	if r := VerifyCompacted(dst); !r.Valid {
+		t.Fatalf("compacted log should self-verify: %s", r.Error)
+	}
+	if r := VerifyCompaction(src, dst); !r.Valid {
+		t.Fatalf("compacted log should verify against original: line %d: %s", r.ErrorLine, r.Error)
+	}
+	if r := Verify(dst); r.Valid {
+		t.Error("compaction is expected to break the literal hash chain")
+	}
+	if !IsCompacted(dst) || IsCompacted(src) {
+		t.Error("IsCompacted should recognise only the compacted log")
+	}
+}
+
+func TestVerifyCompactionDetectsForeignOriginal(t *testing.T) {
+	src := writeCompactSource(t)
+	dst := compactTestLog(t, src, "decision!=allow")
+
+	// A different original with the same kept entries but other dropped
+	// entries must not verify against the summary.
+	other, otherPath := newTestLog(t)
+	for _, d := range []string{"allow", "deny", "deny", "require_approval", "deny"} {
+		other.Record(testEntry(d))
+	}
+	other.Close()
+
+	if r := VerifyCompaction(otherPath, dst); r.Valid {
+		t.Fatal("summary must not verify against a different original")
+	}
+}
+
+func TestVerifyCompactedDetectsTamperedKeptEntry(t *testing.T) {
+	src := writeCompactSource(t)
+	dst := compactTestLog(t, src, "decision!=allow")
+
+	data, _ := os.ReadFile(dst)
+	tampered := strings.Replace(string(data), `"decision":"deny"`, `"decision":"allow"`, 1)
+	os.WriteFile(dst, []byte(tampered), 0600)
+
+	if r := VerifyCompacted(dst); r.Valid {
+		t.Fatal("tampered kept entry should fail verification")
+	}
+}
+
+func TestCompactRefusesTamperedSource(t *testing.T) {
+	src := writeCompactSource(t)
+	data, _ := os.ReadFile(src)
+	os.WriteFile(src, []byte(strings.Replace(string(data), `"decision":"deny"`, `"decision":"allow"`, 1)), 0600)
+
+	filter, _ := ParseCompactFilter([]string{"decision!=allow"})
+	if _, err := Compact(src, filepath.Join(t.TempDir(), "out.jsonl"), filter); err == nil {
+		t.Fatal("expected Compact to refuse a source log that does not verify")
+	}
+}
+
+func TestParseCompactFilter(t *testing.T) {
+	f, err := ParseCompactFilter([]string{"decision=deny", "type=break_glass"})
+	if err != nil {
+		t.Fatalf("ParseCompactFilter: %v", err)
+	}
+	if !f.Match(AuditEntry{Decision: "deny"}) || !f.Match(AuditEntry{Type: "break_glass"}) {
+		t.Error("expected entries matching any clause to be kept")
+	}
+	if f.Match(AuditEntry{Decision: "allow"}) {
+		t.Error("allow entry should not match")
+	}
+
+	for _, bad := range []string{"decision", "color=red"} {
+		if _, err := ParseCompactFilter([]string{bad}); err == nil {
+			t.Errorf("ParseCompactFilter(%q) should fail", bad)
+		}
+	}
+}
@@ -0,0 +1,278 @@
+package audit
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Audit sink formats. The hash-chained JSONL log is always the canonical
+// on-disk record; these formats are for SIEM-facing sinks.
+const (
+	SinkJSON = "json" // chainwatch AuditEntry JSON, one per line
+	SinkCEF  = "cef"  // ArcSight Common Event Format
+	SinkECS  = "ecs"  // Elastic Common Schema JSON
+)
+
+// CEF header fields identifying chainwatch as the device.
+const (
+	cefVendor  = "chainwatch"
+	cefProduct = "chainwatch"
+	cefVersion = "1"
+)
+
+// Encoder renders an AuditEntry as a single line (without trailing newline).
+type Encoder interface {
+	Encode(e AuditEntry) ([]byte, error)
+}
+
+// NewEncoder returns the encoder for a sink format. Empty means SinkJSON.
+func NewEncoder(format string) (Encoder, error) {
+	switch strings.ToLower(format) {
+	case "", SinkJSON:
+		return JSONEncoder{}, nil
+	case SinkCEF:
+		return CEFEncoder{}, nil
+	case SinkECS:
+		return ECSEncoder{}, nil
+	default:
+		return nil, fmt.Errorf("audit: unknown sink format %q (want json, cef, or ecs)", format)
+	}
+}
+
+// JSONEncoder emits the entry exactly as it appears in the canonical log.
+type JSONEncoder struct{}
+
+// Encode implements Encoder.
+func (JSONEncoder) Encode(e AuditEntry) ([]byte, error) {
+	return json.Marshal(e)
+}
+
+// CEFEncoder emits ArcSight Common Event Format:
+//
+//	CEF:0|chainwatch|chainwatch|1|<signature>|<name>|<severity>|<extensions>
+type CEFEncoder struct{}
+
+// Encode implements Encoder.
+func (CEFEncoder) Encode(e AuditEntry) ([]byte, error) {
+	signature := e.Decision
+	if e.Type != "" {
+		signature = e.Type
+	}
+	name := strings.TrimSpace(e.Action.Tool + " " + e.Decision)
+
+	var ext []string
+	add := func(key, value string) {
+		if value != "" {
+			ext = append(ext, key+"="+cefExtEscape(value))
+		}
+	}
+	if ts, err := time.Parse(TimestampFormat, e.Timestamp); err == nil {
+		add("rt", strconv.FormatInt(ts.UnixMilli(), 10))
+	}
+	add("act", e.Decision)
+	add("outcome", outcome(e.Decision))
+	add("reason", e.Reason)
+	add("suser", e.AgentID)
+	add("request", e.Action.Resource)
+	add("cat", e.Type)
+	add("cs1Label", "tool")
+	add("cs1", e.Action.Tool)
+	add("cs2Label", "traceId")
+	add("cs2", e.TraceID)
+	add("cs3Label", "policyHash")
+	add("cs3", e.PolicyHash)
+	add("cs4Label", "sessionId")
+	add("cs4", e.SessionID)
+	if e.Action.Fingerprint != "" {
+		add("cs5Label", "actionFingerprint")
+		add("cs5", e.Action.Fingerprint)
+	}
+	add("cn1Label", "tier")
+	ext = append(ext, "cn1="+strconv.Itoa(e.Tier))
+
+	line := fmt.Sprintf("CEF:0|%s|%s|%s|%s|%s|%d|%s",
+		cefHeaderEscape(cefVendor), cefHeaderEscape(cefProduct), cefHeaderEscape(cefVersion),
+		cefHeaderEscape(signature), cefHeaderEscape(name), cefSeverity(e.Tier), strings.Join(ext, " "))
+	return []byte(line), nil
+}
+
+// cefSeverity maps a tier to the CEF 0-10 scale:
+// 0-3 low, 4-6 medium, 7-8 high, 9-10 very high.
+func cefSeverity(tier int) int {
+	switch {
+	case tier <= 0:
+		return 1
+	case tier == 1:
+		return 3
+	case tier == 2:
+		return 6
+	default:
+		return 9
+	}
+}
+
+func cefHeaderEscape(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
+
+func cefExtEscape(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, "=", `\=`)
+	return strings.NewReplacer("\r", `\r`, "\n", `\n`).Replace(s)
+}
+
+// ECSEncoder emits Elastic Common Schema JSON. Chainwatch-specific fields
+// without an ECS equivalent live under the "chainwatch" object.
+type ECSEncoder struct{}
+
+type ecsDoc struct {
+	Timestamp  string            `json:"@timestamp"`
+	ECS        ecsVersion        `json:"ecs"`
+	Event      ecsEvent          `json:"event"`
+	Log        ecsLog            `json:"log"`
+	Message    string            `json:"message"`
+	Rule       *ecsRule          `json:"rule,omitempty"`
+	Trace      *ecsID            `json:"trace,omitempty"`
+	User       *ecsUser          `json:"user,omitempty"`
+	Labels     map[string]string `json:"labels,omitempty"`
+	Chainwatch ecsChainwatch     `json:"chainwatch"`
+}
+
+type ecsVersion struct {
+	Version string `json:"version"`
+}
+
+type ecsEvent struct {
+	Kind     string   `json:"kind"`
+	Category []string `json:"category"`
+	Type     []string `json:"type"`
+	Action   string   `json:"action"`
+	Outcome  string   `json:"outcome"`
+	Severity int      `json:"severity"`
+	Reason   string   `json:"reason,omitempty"`
+	Provider string   `json:"provider"`
+}
+
+type ecsLog struct {
+	Level string `json:"level"`
+}
+
+type ecsRule struct {
+	Ruleset string `json:"ruleset"`
+	Hash    string `json:"hash"`
+}
+
+type ecsID struct {
+	ID string `json:"id"`
+}
+
+type ecsUser struct {
+	ID string `json:"id"`
+}
+
+type ecsChainwatch struct {
+	Tool      string `json:"tool"`
+	Resource  string `json:"resource"`
+	Decision  string `json:"decision"`
+	Tier      int    `json:"tier"`
+	Type      string `json:"type,omitempty"`
+	SessionID string `json:"session_id,omitempty"`
+	PrevHash  string `json:"prev_hash,omitempty"`
+
+	Fingerprint string `json:"fingerprint,omitempty"`
+}
+
+// Encode implements Encoder.
+func (ECSEncoder) Encode(e AuditEntry) ([]byte, error) {
+	doc := ecsDoc{
+		Timestamp: e.Timestamp,
+		ECS:       ecsVersion{Version: "8.11.0"},
+		Event: ecsEvent{
+			Kind:     "event",
+			Category: []string{ecsCategory(e.Action.Tool)},
+			Type:     []string{ecsType(e.Decision)},
+			Action:   e.Decision,
+			Outcome:  outcome(e.Decision),
+			Severity: cefSeverity(e.Tier),
+			Reason:   e.Reason,
+			Provider: "chainwatch",
+		},
+		Log:     ecsLog{Level: ecsLevel(e.Tier)},
+		Message: strings.TrimSpace(fmt.Sprintf("%s %s: %s", e.Action.Tool, e.Decision, e.Action.Resource)),
+		Labels:  e.Action.Labels,
+		Chainwatch: ecsChainwatch{
+			Tool:      e.Action.Tool,
+			Resource:  e.Action.Resource,
+			Decision:  e.Decision,
+			Tier:      e.Tier,
+			Type:      e.Type,
+			SessionID: e.SessionID,
+			PrevHash:  e.PrevHash,
+
+			Fingerprint: e.Action.Fingerprint,
+		},
+	}
+	if e.PolicyHash != "" {
+		doc.Rule = &ecsRule{Ruleset: "chainwatch", Hash: e.PolicyHash}
+	}
+	if e.TraceID != "" {
+		doc.Trace = &ecsID{ID: e.TraceID}
+	}
+	if e.AgentID != "" {
+		doc.User = &ecsUser{ID: e.AgentID}
+	}
+	return json.Marshal(doc)
+}
+
+// outcome maps a decision to the CEF/ECS outcome vocabulary. Blocked
+// actions are failures from the caller's point of view.
+func outcome(decision string) string {
+	switch decision {
+	case "allow", "allow_with_redaction", "redacted", "truncated":
+		return "success"
+	case "deny", "require_approval", "quarantine", "timeout":
+		return "failure"
+	default:
+		return "unknown"
+	}
+}
+
+func ecsType(decision string) string {
+	switch outcome(decision) {
+	case "success":
+		return "allowed"
+	case "failure":
+		return "denied"
+	default:
+		return "info"
+	}
+}
+
+func ecsCategory(tool string) string {
+	switch {
+	case tool == "command":
+		return "process"
+	case strings.HasPrefix(tool, "http"), tool == "browser", tool == "upstream_tls":
+		return "network"
+	case strings.HasPrefix(tool, "file"):
+		return "file"
+	default:
+		return "api"
+	}
+}
+
+func ecsLevel(tier int) string {
+	switch {
+	case tier <= 1:
+		return "info"
+	case tier == 2:
+		return "warning"
+	default:
+		return "critical"
+	}
+}
@@ -0,0 +1,187 @@
+package audit
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func denialEntry() AuditEntry {
+	return AuditEntry{
+		Timestamp:  "2026-03-01T12:00:00.000Z",
+		TraceID:    "t-deny",
+		AgentID:    "clawbot-prod",
+		Action:     AuditAction{Tool: "command", Resource: "rm -rf /var|data", Labels: map[string]string{"env": "prod"}},
+		Decision:   "deny",
+		Reason:     "denylisted: rm -rf",
+		Tier:       3,
+		PolicyHash: "sha256:abc123",
+		PrevHash:   GenesisHash,
+	}
+}
+
+func TestCEFEncoderDenial(t *testing.T) {
+	line, err := CEFEncoder{}.Encode(denialEntry())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := string(line)
+
+	header := strings.SplitN(s, "|", 8)
+	if len(header) != 8 {
+		t.Fatalf("expected 8 CEF header fields, got %d: %s", len(header), s)
+	}
+	if header[0] != "CEF:0" || header[1] != "chainwatch" || header[4] != "deny" || header[5] != "command deny" {
+		t.Errorf("unexpected CEF header: %q", header[:7])
+	}
+	if header[6] != "9" {
+		t.Errorf("expected tier 3 severity 9, got %s", header[6])
+	}
+
+	for _, want := range []string{
+		"rt=1772366400000",
+		"act=deny",
+		"outcome=failure",
+		"reason=denylisted: rm -rf",
+		"suser=clawbot-prod",
+		`request=rm -rf /var|data`,
+		"cs1Label=tool cs1=command",
+		"cs2Label=traceId cs2=t-deny",
+		"cn1Label=tier cn1=3",
+	} {
+		if !strings.Contains(s, want) {
+			t.Errorf("expected %q in CEF line: %s", want, s)
+		}
+	}
+}
+
+func TestCEFEscaping(t *testing.T) {
+	e := denialEntry()
+	e.Decision = "a|b"
+	e.Reason = "key=value\nnext"
+	line, _ := CEFEncoder{}.Encode(e)
+	s := string(line)
+	if !strings.Contains(s, `|a\|b|`) {
+		t.Errorf("expected escaped pipe in header: %s", s)
+	}
+	if !strings.Contains(s, `reason=key\=value\nnext`) {
+		t.Errorf("expected escaped extension value: %s", s)
+	}
+}
+
+func TestECSEncoderDenial(t *testing.T) {
+	line, err := ECSEncoder{}.Encode(denialEntry())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(line, &doc); err != nil {
+		t.Fatalf("ECS output is not JSON: %v", err)
+	}
+
+	if doc["@timestamp"] != "2026-03-01T12:00:00.000Z" {
+		t.Errorf("unexpected @timestamp: %v", doc["@timestamp"])
+	}
+	event := doc["event"].(map[string]any)
+	if event["outcome"] != "failure" || event["action"] != "deny" || event["severity"] != float64(9) {
+		t.Errorf("unexpected event fields: %v", event)
+	}
+	if types := event["type"].([]any); types[0] != "denied" {
+		t.Errorf("expected event.type denied, got %v", types)
+	}
+	if cats := event["category"].([]any); cats[0] != "process" {
+		t.Errorf("expected event.category process, got %v", cats)
+	}
+	if lvl := doc["log"].(map[string]any)["level"]; lvl != "critical" {
+		t.Errorf("expected log.level critical, got %v", lvl)
+	}
+	if id := doc["trace"].(map[string]any)["id"]; id != "t-deny" {
+		t.Errorf("expected trace.id, got %v", id)
+	}
+	if id := doc["user"].(map[string]any)["id"]; id != "clawbot-prod" {
+		t.Errorf("expected user.id, got %v", id)
+	}
+	if env := doc["labels"].(map[string]any)["env"]; env != "prod" {
+		t.Errorf("expected labels.env, got %v", env)
+	}
+	cw := doc["chainwatch"].(map[string]any)
+	if cw["resource"] != "rm -rf /var|data" || cw["tier"] != float64(3) {
+		t.Errorf("unexpected chainwatch fields: %v", cw)
+	}
+}
+
+func TestOutcomeMapping(t *testing.T) {
+	tests := map[string]string{
+		"allow":            "success",
+		"deny":             "failure",
+		"require_approval": "failure",
+		"redacted":         "success",
+		"something_else":   "unknown",
+	}
+	for decision, want := range tests {
+		if got := outcome(decision); got != want {
+			t.Errorf("outcome(%q) = %q, want %q", decision, got, want)
+		}
+	}
+}
+
+func TestNewEncoderUnknownFormat(t *testing.T) {
+	if _, err := NewEncoder("syslog"); err == nil {
+		t.Error("expected error for unknown format")
+	}
+	if enc, err := NewEncoder(""); err != nil || enc == nil {
+		t.Errorf("expected default JSON encoder, got %v, %v", enc, err)
+	}
+}
+
+func TestOpenWithSinks(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "audit.jsonl")
+	cefPath := filepath.Join(dir, "sinks", "audit.cef")
+	ecsPath := filepath.Join(dir, "sinks", "audit.ecs.json")
+
+	l, err := OpenWithSinks(path, []SinkConfig{
+		{Format: "cef", Path: cefPath},
+		{Format: "ecs", Path: ecsPath},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 3; i++ {
+		if err := l.Record(denialEntry()); err != nil {
+			t.Fatalf("record %d: %v", i, err)
+		}
+	}
+	l.Close()
+
+	if r := Verify(path); !r.Valid {
+		t.Fatalf("canonical chain invalid: %+v", r)
+	}
+
+	cef, err := os.ReadFile(cefPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cefLines := strings.Split(strings.TrimSpace(string(cef)), "\n")
+	if len(cefLines) != 3 || !strings.HasPrefix(cefLines[0], "CEF:0|") {
+		t.Errorf("expected 3 CEF lines, got %q", cef)
+	}
+
+	ecs, err := os.ReadFile(ecsPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(strings.Split(strings.TrimSpace(string(ecs)), "\n")); n != 3 {
+		t.Errorf("expected 3 ECS lines, got %d", n)
+	}
+}
+
+func TestOpenWithSinksBadFormat(t *testing.T) {
+	dir := t.TempDir()
+	_, err := OpenWithSinks(filepath.Join(dir, "audit.jsonl"), []SinkConfig{{Format: "xml", Path: filepath.Join(dir, "out")}})
+	if err == nil {
+		t.Fatal("expected error for unknown sink format")
+	}
+}
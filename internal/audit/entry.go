@@ -2,8 +2,17 @@ package audit
 
 // AuditAction is the flattened action recorded in each audit entry.
 type AuditAction struct {
-	Tool     string `json:"tool"`
-	Resource string `json:"resource"`
+	Tool     string            `json:"tool"`
+	Resource string            `json:"resource"`
+	Labels   map[string]string `json:"labels,omitempty"` // string map: json.Marshal sorts keys
+
+	// RawResource is the resource as received when it was percent- or
+	// hex-encoded; Resource is the decoded form that was evaluated.
+	RawResource string `json:"raw_resource,omitempty"`
+
+	// Fingerprint is model.Action.Fingerprint, for correlating the same
+	// action across instances and external logs.
+	Fingerprint string `json:"fingerprint,omitempty"`
 }
 
 // AuditEntry is one line in the hash-chained JSONL audit log.
@@ -27,4 +36,10 @@ type AuditEntry struct {
 	OriginalDecision string `json:"original_decision,omitempty"`
 	OverriddenTo     string `json:"overridden_to,omitempty"`
 	ExpiresAt        string `json:"expires_at,omitempty"`
+
+	// Justification is the operator's reason on approval events.
+	Justification string `json:"justification,omitempty"`
+
+	// Compaction summary — only present on the trailing entry written by Compact.
+	Compaction *CompactionSummary `json:"compaction,omitempty"`
 }
@@ -34,8 +34,11 @@ func FormatTimeline(result *ReplayResult) string {
 		resource := truncate(e.Action.Resource, 40)
 
 		tag := ""
-		if e.Type == "break_glass_used" {
+		switch e.Type {
+		case "break_glass_used":
 			tag = "  [break-glass]"
+		case "break_glass_revoked":
+			tag = "  [break-glass revoked]"
 		}
 
 		b.WriteString(fmt.Sprintf("%-10s %-3s %-18s %-13s %-40s%s\n",
@@ -22,6 +22,7 @@ type Log struct {
 	path     string
 	file     *os.File
 	prevHash string
+	sinks    []sink
 	mu       sync.Mutex
 }
 
@@ -94,13 +95,17 @@ func (l *Log) Record(entry AuditEntry) error {
 	}
 
 	l.prevHash = HashLine(line)
-	return nil
+	return l.writeSinks(entry)
 }
 
-// Close flushes and closes the underlying file.
+// Close flushes and closes the underlying file and any sinks.
 func (l *Log) Close() error {
 	l.mu.Lock()
 	defer l.mu.Unlock()
+	for _, s := range l.sinks {
+		s.file.Close()
+	}
+	l.sinks = nil
 	return l.file.Close()
 }
 
@@ -0,0 +1,76 @@
+package audit
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// SinkConfig configures a secondary audit output (policy.yaml audit_sinks).
+// Every entry recorded in the canonical log is also written to each sink in
+// the sink's format.
+type SinkConfig struct {
+	Format string `yaml:"format"` // json | cef | ecs
+	Path   string `yaml:"path"`   // file to append to
+}
+
+type sink struct {
+	path string
+	file *os.File
+	enc  Encoder
+}
+
+// OpenWithSinks opens the canonical audit log at path and attaches each
+// configured sink.
+func OpenWithSinks(path string, sinks []SinkConfig) (*Log, error) {
+	l, err := Open(path)
+	if err != nil {
+		return nil, err
+	}
+	for _, sc := range sinks {
+		if err := l.AddSink(sc); err != nil {
+			l.Close()
+			return nil, err
+		}
+	}
+	return l, nil
+}
+
+// AddSink attaches a secondary output. Sink write failures are reported by
+// Record but never break the hash chain of the canonical log.
+func (l *Log) AddSink(cfg SinkConfig) error {
+	if cfg.Path == "" {
+		return fmt.Errorf("audit: sink path is required")
+	}
+	enc, err := NewEncoder(cfg.Format)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(cfg.Path), 0700); err != nil {
+		return fmt.Errorf("audit: create sink directory: %w", err)
+	}
+	f, err := os.OpenFile(cfg.Path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("audit: open sink: %w", err)
+	}
+
+	l.mu.Lock()
+	l.sinks = append(l.sinks, sink{path: cfg.Path, file: f, enc: enc})
+	l.mu.Unlock()
+	return nil
+}
+
+// writeSinks emits e to every sink. Caller must hold l.mu.
+func (l *Log) writeSinks(e AuditEntry) error {
+	var firstErr error
+	for _, s := range l.sinks {
+		line, err := s.enc.Encode(e)
+		if err == nil {
+			_, err = s.file.Write(append(line, '\n'))
+		}
+		if err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("audit: sink %s: %w", s.path, err)
+		}
+	}
+	return firstErr
+}
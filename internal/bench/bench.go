@@ -0,0 +1,207 @@
+// Package bench measures policy evaluation cost for a loaded config, so
+// operators embedding chainwatch in hot paths can size their policy and
+// denylist.
+package bench
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/ppiankov/chainwatch/internal/denylist"
+	"github.com/ppiankov/chainwatch/internal/model"
+	"github.com/ppiankov/chainwatch/internal/policy"
+)
+
+// DefaultIterations is the number of evaluations per run when Options
+// leaves it unset.
+const DefaultIterations = 10000
+
+// MixEntry is one action of the evaluated mix.
+type MixEntry struct {
+	Tool      string         `yaml:"tool" json:"tool"`
+	Resource  string         `yaml:"resource" json:"resource"`
+	Operation string         `yaml:"operation" json:"operation"`
+	Meta      map[string]any `yaml:"meta,omitempty" json:"meta,omitempty"` // result_meta: sensitivity, tags, egress, ...
+}
+
+// DefaultMix covers the common evaluation paths: known-safe commands,
+// plain and sensitive file reads, outbound HTTP, and a denylist hit.
+var DefaultMix = []MixEntry{
+	{Tool: "command", Resource: "ls -la", Operation: "execute"},
+	{Tool: "command", Resource: "git status", Operation: "execute"},
+	{Tool: "file_read", Resource: "/data/reports/q3.csv", Operation: "read"},
+	{Tool: "file_read", Resource: "/data/hr/salary.csv", Operation: "read",
+		Meta: map[string]any{"sensitivity": "high"}},
+	{Tool: "http", Resource: "https://api.example.com/v1/status", Operation: "GET",
+		Meta: map[string]any{"egress": "external"}},
+	{Tool: "http", Resource: "https://api.example.com/v1/upload", Operation: "POST",
+		Meta: map[string]any{"egress": "external"}},
+	{Tool: "command", Resource: "rm -rf /", Operation: "execute"},
+}
+
+// LoadMix reads a YAML list of MixEntry from path.
+func LoadMix(path string) ([]MixEntry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read mix: %w", err)
+	}
+	var mix []MixEntry
+	if err := yaml.Unmarshal(data, &mix); err != nil {
+		return nil, fmt.Errorf("parse mix %s: %w", path, err)
+	}
+	if len(mix) == 0 {
+		return nil, fmt.Errorf("mix %s has no actions", path)
+	}
+	return mix, nil
+}
+
+// Options configures a benchmark run.
+type Options struct {
+	Policy     *policy.PolicyConfig // nil uses policy.DefaultConfig
+	Denylist   *denylist.Denylist   // nil uses denylist.NewDefault; padded in place by RunSizes
+	Mix        []MixEntry           // nil uses DefaultMix
+	Iterations int                  // evaluations per run; 0 uses DefaultIterations
+	Purpose    string               // "" uses "general"
+	AgentID    string
+}
+
+// Result is the measured cost of one run.
+type Result struct {
+	DenylistPatterns int           `json:"denylist_patterns"`
+	Rules            int           `json:"rules"`
+	Evaluations      int           `json:"evaluations"`
+	Elapsed          time.Duration `json:"elapsed_ns"`
+	OpsPerSec        float64       `json:"ops_per_sec"`
+	P50              time.Duration `json:"p50_ns"`
+	P99              time.Duration `json:"p99_ns"`
+	AllocsPerOp      float64       `json:"allocs_per_op"`
+	BytesPerOp       float64       `json:"bytes_per_op"`
+}
+
+// Run evaluates the mix round-robin for opts.Iterations evaluations and
+// reports latency percentiles, throughput and allocations. Each evaluation
+// gets a fresh trace state, so escalation from earlier actions in the mix
+// never changes the path later ones take. States are built before timing
+// starts and are not counted in the allocations. A warm-up pass over the
+// mix runs first.
+func Run(opts Options) Result {
+	opts = opts.withDefaults()
+	actions := make([]*model.Action, len(opts.Mix))
+	for i, m := range opts.Mix {
+		actions[i] = &model.Action{Tool: m.Tool, Resource: m.Resource, Operation: m.Operation, RawMeta: m.Meta}
+	}
+
+	for _, a := range actions {
+		policy.Evaluate(a, model.NewTraceState("bench"), opts.Purpose, opts.AgentID, opts.Denylist, opts.Policy)
+	}
+
+	states := make([]*model.TraceState, opts.Iterations)
+	for i := range states {
+		states[i] = model.NewTraceState("bench")
+	}
+	latencies := make([]time.Duration, opts.Iterations)
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	for i := range latencies {
+		t := time.Now()
+		policy.Evaluate(actions[i%len(actions)], states[i], opts.Purpose, opts.AgentID, opts.Denylist, opts.Policy)
+		latencies[i] = time.Since(t)
+	}
+	elapsed := time.Since(start)
+	runtime.ReadMemStats(&after)
+
+	sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+	n := float64(opts.Iterations)
+	return Result{
+		DenylistPatterns: patternCount(opts.Denylist),
+		Rules:            len(opts.Policy.Rules),
+		Evaluations:      opts.Iterations,
+		Elapsed:          elapsed,
+		OpsPerSec:        n / elapsed.Seconds(),
+		P50:              percentile(latencies, 50),
+		P99:              percentile(latencies, 99),
+		AllocsPerOp:      float64(after.Mallocs-before.Mallocs) / n,
+		BytesPerOp:       float64(after.TotalAlloc-before.TotalAlloc) / n,
+	}
+}
+
+// RunSizes runs the benchmark once per denylist size, padding opts.Denylist
+// with synthetic patterns that never match the mix until it holds that many
+// extra patterns. Sizes are run in ascending order; a size of 0 measures the
+// denylist as loaded.
+func RunSizes(opts Options, sizes []int) []Result {
+	opts = opts.withDefaults()
+	sizes = append([]int(nil), sizes...)
+	sort.Ints(sizes)
+
+	var results []Result
+	added := 0
+	for _, size := range sizes {
+		for ; added < size; added++ {
+			padDenylist(opts.Denylist, added)
+		}
+		results = append(results, Run(opts))
+	}
+	return results
+}
+
+func (o Options) withDefaults() Options {
+	if o.Policy == nil {
+		o.Policy = policy.DefaultConfig()
+	}
+	if o.Denylist == nil {
+		o.Denylist = denylist.NewDefault()
+	}
+	if len(o.Mix) == 0 {
+		o.Mix = DefaultMix
+	}
+	if o.Iterations <= 0 {
+		o.Iterations = DefaultIterations
+	}
+	if o.Purpose == "" {
+		o.Purpose = "general"
+	}
+	return o
+}
+
+// padDenylist adds the i-th synthetic pattern, rotating between URL, file
+// and command patterns so every matcher grows.
+func padDenylist(dl *denylist.Denylist, i int) {
+	switch i % 3 {
+	case 0:
+		dl.AddPattern("urls", fmt.Sprintf("https://bench-blocked-%d.invalid", i))
+	case 1:
+		dl.AddPattern("files", fmt.Sprintf("/bench/blocked/%d/**", i))
+	default:
+		dl.AddPattern("commands", fmt.Sprintf("bench-blocked-command-%d", i))
+	}
+}
+
+func patternCount(dl *denylist.Denylist) int {
+	n := 0
+	for _, v := range dl.ToMap() {
+		if list, ok := v.([]string); ok {
+			n += len(list)
+		}
+	}
+	return n
+}
+
+// percentile returns the p-th percentile of sorted latencies.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	i := (len(sorted)*p+99)/100 - 1
+	if i < 0 {
+		i = 0
+	}
+	return sorted[i]
+}
@@ -0,0 +1,68 @@
+package bench
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRunProducesThroughput(t *testing.T) {
+	r := Run(Options{Iterations: 500})
+	if r.Evaluations != 500 {
+		t.Errorf("expected 500 evaluations, got %d", r.Evaluations)
+	}
+	if r.OpsPerSec <= 0 || r.Elapsed <= 0 {
+		t.Errorf("expected non-zero throughput, got %.0f ops/sec over %s", r.OpsPerSec, r.Elapsed)
+	}
+	if r.P50 <= 0 || r.P99 < r.P50 {
+		t.Errorf("expected 0 < p50 <= p99, got p50=%s p99=%s", r.P50, r.P99)
+	}
+	if r.AllocsPerOp <= 0 {
+		t.Errorf("expected allocations to be measured, got %.1f", r.AllocsPerOp)
+	}
+}
+
+func TestRunSizesGrowsDenylist(t *testing.T) {
+	results := RunSizes(Options{Iterations: 100}, []int{30, 0})
+	if len(results) != 2 {
+		t.Fatalf("expected 2 results, got %d", len(results))
+	}
+	if got := results[1].DenylistPatterns - results[0].DenylistPatterns; got != 30 {
+		t.Errorf("expected 30 extra patterns in the second run, got %d", got)
+	}
+	for _, r := range results {
+		if r.OpsPerSec <= 0 {
+			t.Errorf("expected non-zero throughput at %d patterns", r.DenylistPatterns)
+		}
+	}
+
+	if out := FormatText(results); !strings.Contains(out, "P99") || strings.Count(out, "\n") != 3 {
+		t.Errorf("unexpected text report:\n%s", out)
+	}
+}
+
+func TestLoadMix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mix.yaml")
+	if err := os.WriteFile(path, []byte("- tool: command\n  resource: ls\n  operation: execute\n- tool: file_read\n  resource: /etc/hosts\n  meta:\n    sensitivity: high\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	mix, err := LoadMix(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(mix) != 2 || mix[1].Meta["sensitivity"] != "high" {
+		t.Errorf("unexpected mix %+v", mix)
+	}
+	if r := Run(Options{Mix: mix, Iterations: 10}); r.Evaluations != 10 {
+		t.Errorf("expected custom mix to run, got %d evaluations", r.Evaluations)
+	}
+
+	if err := os.WriteFile(path, []byte("[]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadMix(path); err == nil {
+		t.Error("expected error for empty mix")
+	}
+}
@@ -0,0 +1,28 @@
+package bench
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// FormatText renders results as a table, one row per run.
+func FormatText(results []Result) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-10s %-6s %-8s %12s %10s %10s %10s %10s\n",
+		"DENYLIST", "RULES", "EVALS", "OPS/SEC", "P50", "P99", "ALLOCS/OP", "B/OP")
+	for _, r := range results {
+		fmt.Fprintf(&b, "%-10d %-6d %-8d %12.0f %10s %10s %10.1f %10.0f\n",
+			r.DenylistPatterns, r.Rules, r.Evaluations, r.OpsPerSec, r.P50, r.P99, r.AllocsPerOp, r.BytesPerOp)
+	}
+	return b.String()
+}
+
+// FormatJSON renders results as indented JSON.
+func FormatJSON(results []Result) (string, error) {
+	data, err := json.MarshalIndent(results, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshal bench results: %w", err)
+	}
+	return string(data), nil
+}
@@ -90,3 +90,16 @@ func TestCheckAndConsumeIsOneShot(t *testing.T) {
 		t.Error("expected nil on second call (token already consumed)")
 	}
 }
+
+func TestCheckAndConsumeRevokedToken(t *testing.T) {
+	store, _ := NewStore(t.TempDir())
+	token, _ := store.Create("minted by mistake", DefaultDuration)
+	if err := store.Revoke(token.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	action := &model.Action{Tool: "command", Resource: "sudo restart"}
+	if got := CheckAndConsume(store, 2, action); got != nil {
+		t.Errorf("revoked token %s overrode a tier 2 decision", got.ID)
+	}
+}
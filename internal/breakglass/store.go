@@ -56,6 +56,20 @@ func (t *Token) IsActive() bool {
 	return time.Now().UTC().Before(t.ExpiresAt)
 }
 
+// Status reports the token state: "active", "used", "revoked", or "expired".
+func (t *Token) Status() string {
+	switch {
+	case t.UsedAt != nil:
+		return "used"
+	case t.RevokedAt != nil:
+		return "revoked"
+	case !t.IsActive():
+		return "expired"
+	default:
+		return "active"
+	}
+}
+
 // Store manages break-glass token files on disk.
 type Store struct {
 	dir string
@@ -163,7 +177,9 @@ func (s *Store) Consume(id string) error {
 	return s.writeAtomic(s.path(id), token)
 }
 
-// Revoke marks a token as revoked.
+// Revoke marks a token as revoked. A revoked token is never honored by
+// CheckAndConsume. Revoking a token that was already used is a no-op:
+// there is nothing left to invalidate.
 func (s *Store) Revoke(id string) error {
 	if err := validateID(id); err != nil {
 		return fmt.Errorf("invalid token id: %w", err)
@@ -176,6 +192,9 @@ func (s *Store) Revoke(id string) error {
 	if err != nil {
 		return fmt.Errorf("token %q not found: %w", id, err)
 	}
+	if token.UsedAt != nil {
+		return nil
+	}
 
 	now := time.Now().UTC()
 	token.RevokedAt = &now
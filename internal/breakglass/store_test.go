@@ -276,3 +276,21 @@ func TestTokenIsActiveFalseWhenRevoked(t *testing.T) {
 		t.Error("revoked token should not be active")
 	}
 }
+
+func TestRevokeUsedTokenIsNoop(t *testing.T) {
+	store, _ := NewStore(t.TempDir())
+	token, _ := store.Create("test", DefaultDuration)
+	if err := store.Consume(token.ID); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Revoke(token.ID); err != nil {
+		t.Fatalf("expected revoking a used token to be a no-op, got %v", err)
+	}
+	got, err := store.read(token.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.RevokedAt != nil || got.Status() != "used" {
+		t.Errorf("expected used token left unchanged, got status %s", got.Status())
+	}
+}
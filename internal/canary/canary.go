@@ -0,0 +1,131 @@
+// Package canary detects exfiltration of planted canary tokens.
+//
+// Operators register fake credentials (canaries) that no legitimate task
+// should ever send anywhere. Reading a canary is harmless; transmitting one
+// to an external destination is a strong exfiltration signal.
+package canary
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// MinValueLen is the shortest accepted canary value. Shorter values would
+// match ordinary traffic by accident.
+const MinValueLen = 8
+
+// Token is a registered canary value.
+type Token struct {
+	Name  string `yaml:"name"`
+	Value string `yaml:"value"`
+}
+
+// Validate checks that every token has a name and a value of at least
+// MinValueLen bytes.
+func Validate(tokens []Token) error {
+	for i, t := range tokens {
+		if t.Name == "" {
+			return fmt.Errorf("canaries[%d]: name is required", i)
+		}
+		if len(t.Value) < MinValueLen {
+			return fmt.Errorf("canaries[%d] %q: value must be at least %d characters", i, t.Name, MinValueLen)
+		}
+	}
+	return nil
+}
+
+// Find reports the first registered token contained in data. Besides the
+// raw value, URL-escaped and base64-encoded forms of the value are matched,
+// as is a value hidden anywhere inside a base64-encoded run of data.
+func Find(tokens []Token, data string) (Token, bool) {
+	if data == "" {
+		return Token{}, false
+	}
+	var decoded []string
+	decodedDone := false
+	for _, t := range tokens {
+		if t.Value == "" {
+			continue
+		}
+		for _, form := range encodings(t.Value) {
+			if strings.Contains(data, form) {
+				return t, true
+			}
+		}
+		if !decodedDone {
+			decoded = decodeBase64Runs(data)
+			decodedDone = true
+		}
+		for _, d := range decoded {
+			if strings.Contains(d, t.Value) {
+				return t, true
+			}
+		}
+	}
+	return Token{}, false
+}
+
+// base64Run matches candidate base64 runs long enough to hide a canary.
+var base64Run = regexp.MustCompile(`[A-Za-z0-9+/]{12,}={0,2}`)
+
+// decodeBase64Runs decodes every base64 run in data. A canary encoded as
+// part of a larger payload does not appear as its own encoding, because
+// base64 output depends on the value's offset in the input.
+func decodeBase64Runs(data string) []string {
+	var out []string
+	for _, run := range base64Run.FindAllString(data, -1) {
+		b, err := base64.StdEncoding.DecodeString(run)
+		if err != nil {
+			b, err = base64.RawStdEncoding.DecodeString(strings.TrimRight(run, "="))
+			if err != nil {
+				continue
+			}
+		}
+		out = append(out, string(b))
+	}
+	return out
+}
+
+// FindParams searches every string nested in params, in deterministic key order.
+func FindParams(tokens []Token, params map[string]any) (Token, bool) {
+	return findValue(tokens, params)
+}
+
+func findValue(tokens []Token, v any) (Token, bool) {
+	switch x := v.(type) {
+	case string:
+		return Find(tokens, x)
+	case map[string]any:
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if t, ok := findValue(tokens, x[k]); ok {
+				return t, true
+			}
+		}
+	case []any:
+		for _, item := range x {
+			if t, ok := findValue(tokens, item); ok {
+				return t, true
+			}
+		}
+	}
+	return Token{}, false
+}
+
+// encodings returns the forms of a value looked for in outbound data.
+func encodings(value string) []string {
+	forms := []string{value}
+	if q := url.QueryEscape(value); q != value {
+		forms = append(forms, q)
+	}
+	forms = append(forms, strings.TrimRight(base64.StdEncoding.EncodeToString([]byte(value)), "="))
+	return forms
+}
@@ -0,0 +1,63 @@
+package canary
+
+import (
+	"encoding/base64"
+	"net/url"
+	"testing"
+)
+
+func TestFindEncodedForms(t *testing.T) {
+	tokens := []Token{{Name: "fake-db", Value: "canary pass/word+1"}}
+
+	for _, data := range []string{
+		"password=canary pass/word+1",
+		"q=" + url.QueryEscape("canary pass/word+1"),
+		"blob " + base64.StdEncoding.EncodeToString([]byte("canary pass/word+1")),
+	} {
+		if tok, ok := Find(tokens, data); !ok || tok.Name != "fake-db" {
+			t.Errorf("expected canary found in %q", data)
+		}
+	}
+	if _, ok := Find(tokens, "nothing to see"); ok {
+		t.Error("expected no match")
+	}
+}
+
+func TestFindCanaryInsideLargerBase64Payload(t *testing.T) {
+	tokens := []Token{{Name: "fake-aws", Value: "AKIACANARYTOKEN00001"}}
+
+	// The canary's offset in the encoded payload is not a multiple of 3,
+	// so its own base64 encoding does not appear in the output.
+	data := base64.StdEncoding.EncodeToString([]byte(`{"key":"AKIACANARYTOKEN00001"}`))
+	if tok, ok := Find(tokens, "payload="+data); !ok || tok.Name != "fake-aws" {
+		t.Errorf("expected canary found inside base64 payload %q", data)
+	}
+
+	benign := base64.StdEncoding.EncodeToString([]byte(`{"k":"ordinary value"}`))
+	if _, ok := Find(tokens, "payload="+benign); ok {
+		t.Error("benign base64 payload should not match")
+	}
+}
+
+func TestFindParamsNested(t *testing.T) {
+	tokens := []Token{{Name: "fake-key", Value: "AKIACANARYTOKEN00001"}}
+	params := map[string]any{
+		"headers": map[string]any{"x": "y"},
+		"items":   []any{"a", map[string]any{"secret": "AKIACANARYTOKEN00001"}},
+	}
+	if _, ok := FindParams(tokens, params); !ok {
+		t.Error("expected canary found in nested params")
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate([]Token{{Name: "ok", Value: "long-enough"}}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if err := Validate([]Token{{Name: "short", Value: "abc"}}); err == nil {
+		t.Error("expected error for short value")
+	}
+	if err := Validate([]Token{{Value: "long-enough"}}); err == nil {
+		t.Error("expected error for missing name")
+	}
+}
@@ -1,45 +1,112 @@
 package cli
 
 import (
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
 
 	"github.com/ppiankov/chainwatch/internal/approval"
+	"github.com/ppiankov/chainwatch/internal/audit"
+	"github.com/ppiankov/chainwatch/internal/policy"
 )
 
-var approveDuration time.Duration
+var (
+	approveDuration time.Duration
+	approveTrace    string
+	approveReason   string
+	approveAuditLog string
+	approvePolicy   string
+)
 
 func init() {
 	rootCmd.AddCommand(approveCmd)
 	approveCmd.Flags().DurationVar(&approveDuration, "duration", 0, "Validity period (e.g., 5m, 1h). Default: one-time use")
+	approveCmd.Flags().StringVar(&approveTrace, "trace", "", "Approve every matching action in this trace ID instead of one action")
+	approveCmd.Flags().StringVar(&approveReason, "reason", "", "Justification for the approval (required for require_reason rules)")
+	approveCmd.Flags().StringVar(&approveAuditLog, "audit-log", "", "Path to audit log JSONL file to record the approval in (default: ~/.chainwatch/approvals.jsonl)")
+	approveCmd.Flags().StringVar(&approvePolicy, "policy", "", "Path to policy YAML whose approval_store to use (default: ~/.chainwatch/policy.yaml)")
 }
 
 var approveCmd = &cobra.Command{
 	Use:   "approve <key>",
 	Short: "Grant approval for a require_approval action",
-	Long:  "Approves a pending approval request. Without --duration, approval is one-time (consumed on first use).\nWith --duration, approval is valid for the specified period and can be reused.",
+	Long:  "Approves a pending approval request. Without --duration, approval is one-time (consumed on first use).\nWith --duration, approval is valid for the specified period and can be reused.\nWith --trace, approval covers every matching action in that trace and is never consumed;\nother traces still require their own approval.\nKeys of rules with require_reason must be approved with --reason.",
 	Args:  cobra.ExactArgs(1),
 	RunE:  runApprove,
 }
 
+// openApprovalStore opens the approval store configured in the policy at
+// policyPath (default policy when empty), so approvals land where the
+// guards running with that policy look for them.
+func openApprovalStore(policyPath string) (*approval.Store, error) {
+	cfg, err := policy.LoadConfig(policyPath)
+	if err != nil {
+		return nil, err
+	}
+	return approval.Open(approval.DefaultDir(), cfg.ApprovalStore)
+}
+
 func runApprove(cmd *cobra.Command, args []string) error {
 	key := args[0]
 
-	store, err := approval.NewStore(approval.DefaultDir())
+	store, err := openApprovalStore(approvePolicy)
 	if err != nil {
 		return fmt.Errorf("failed to open approval store: %w", err)
 	}
 
-	if err := store.Approve(key, approveDuration, ""); err != nil {
+	// Every approval is audited, so the log is opened before granting it.
+	auditPath := approveAuditLog
+	if auditPath == "" {
+		auditPath = approval.DefaultAuditLog()
+	}
+	auditLog, err := audit.Open(auditPath)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer auditLog.Close()
+
+	err = store.ApproveWith(key, approval.ApproveOptions{
+		Duration:      approveDuration,
+		TraceID:       approveTrace,
+		Justification: approveReason,
+	})
+	if errors.Is(err, approval.ErrReasonRequired) {
+		return fmt.Errorf("%w (use --reason)", err)
+	}
+	if err != nil {
+		return err
+	}
+
+	if err := recordApproveAudit(auditLog, key, strings.TrimSpace(approveReason)); err != nil {
 		return err
 	}
 
-	if approveDuration > 0 {
+	switch {
+	case approveTrace != "" && approveDuration > 0:
+		fmt.Printf("Approved %q for trace %s for %s\n", key, approveTrace, approveDuration)
+	case approveTrace != "":
+		fmt.Printf("Approved %q for trace %s\n", key, approveTrace)
+	case approveDuration > 0:
 		fmt.Printf("Approved %q for %s\n", key, approveDuration)
-	} else {
+	default:
 		fmt.Printf("Approved %q (one-time use)\n", key)
 	}
 	return nil
 }
+
+// recordApproveAudit appends the approval and its justification to the
+// audit log.
+func recordApproveAudit(log *audit.Log, key, justification string) error {
+	return log.Record(audit.AuditEntry{
+		Timestamp:     time.Now().UTC().Format(audit.TimestampFormat),
+		TraceID:       approveTrace,
+		Action:        audit.AuditAction{Tool: "approval", Resource: key},
+		Decision:      "approved",
+		Reason:        "approved via CLI",
+		Type:          "approval_approved",
+		Justification: justification,
+	})
+}
@@ -11,13 +11,24 @@ import (
 	"github.com/ppiankov/chainwatch/internal/audit"
 )
 
-var tailLines int
+var (
+	tailLines      int
+	compactKeep    []string
+	compactOutput  string
+	verifyOriginal string
+)
 
 func init() {
 	rootCmd.AddCommand(auditCmd)
 	auditCmd.AddCommand(auditVerifyCmd)
 	auditCmd.AddCommand(auditTailCmd)
+	auditCmd.AddCommand(auditCompactCmd)
 	auditTailCmd.Flags().IntVarP(&tailLines, "lines", "n", 10, "Number of recent entries to show")
+	auditVerifyCmd.Flags().StringVar(&verifyOriginal, "original", "", "Verify a compacted log against the original it was produced from")
+	auditCompactCmd.Flags().StringArrayVar(&compactKeep, "keep", nil, "Keep entries matching field=value or field!=value (repeatable; any match keeps)")
+	auditCompactCmd.Flags().StringVarP(&compactOutput, "output", "o", "", "Path for the compacted log")
+	_ = auditCompactCmd.MarkFlagRequired("keep")
+	_ = auditCompactCmd.MarkFlagRequired("output")
 }
 
 var auditCmd = &cobra.Command{
@@ -29,9 +40,11 @@ var auditCmd = &cobra.Command{
 var auditVerifyCmd = &cobra.Command{
 	Use:   "verify <path>",
 	Short: "Verify hash chain integrity of an audit log",
-	Long:  "Walks the JSONL audit log and validates that every entry's prev_hash\nmatches the SHA-256 of the previous entry. Exits 0 if valid, 1 if tampered.",
-	Args:  cobra.ExactArgs(1),
-	RunE:  runAuditVerify,
+	Long: "Walks the JSONL audit log and validates that every entry's prev_hash\nmatches the SHA-256 of the previous entry. Exits 0 if valid, 1 if tampered.\n" +
+		"A compacted log is checked against its compaction summary; pass --original\n" +
+		"to also check the dropped entries.",
+	Args: cobra.ExactArgs(1),
+	RunE: runAuditVerify,
 }
 
 var auditTailCmd = &cobra.Command{
@@ -42,8 +55,26 @@ var auditTailCmd = &cobra.Command{
 	RunE:  runAuditTail,
 }
 
+var auditCompactCmd = &cobra.Command{
+	Use:   "compact <path>",
+	Short: "Write a smaller audit log keeping only matching entries",
+	Long: "Copies entries matching --keep to --output and appends a compaction_summary\n" +
+		"entry with Merkle roots over the kept and dropped entries and the original\n" +
+		"chain tail. Compaction breaks the literal hash chain; verify the result with\n" +
+		"`chainwatch audit verify <compacted> --original <log>`.",
+	Args: cobra.ExactArgs(1),
+	RunE: runAuditCompact,
+}
+
 func runAuditVerify(cmd *cobra.Command, args []string) error {
-	result := audit.Verify(args[0])
+	var result audit.VerifyResult
+	if verifyOriginal != "" {
+		result = audit.VerifyCompaction(verifyOriginal, args[0])
+	} else if audit.IsCompacted(args[0]) {
+		result = audit.VerifyCompacted(args[0])
+	} else {
+		result = audit.Verify(args[0])
+	}
 	if result.Valid {
 		fmt.Printf("OK: %d entries verified\n", result.Lines)
 		return nil
@@ -87,3 +118,18 @@ func runAuditTail(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+func runAuditCompact(cmd *cobra.Command, args []string) error {
+	filter, err := audit.ParseCompactFilter(compactKeep)
+	if err != nil {
+		return err
+	}
+	summary, err := audit.Compact(args[0], compactOutput, filter)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Compacted %d entries: kept %d, dropped %d\n", summary.SourceLines, summary.Kept, summary.Dropped)
+	fmt.Printf("Dropped root: %s\n", summary.DroppedRoot)
+	fmt.Printf("Source tail:  %s\n", summary.SourceTailHash)
+	return nil
+}
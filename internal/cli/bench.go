@@ -0,0 +1,87 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ppiankov/chainwatch/internal/bench"
+	"github.com/ppiankov/chainwatch/internal/denylist"
+	"github.com/ppiankov/chainwatch/internal/policy"
+)
+
+var (
+	benchPolicy     string
+	benchDenylist   string
+	benchMix        string
+	benchIterations int
+	benchSizes      []int
+	benchPurpose    string
+	benchAgent      string
+	benchFormat     string
+)
+
+func init() {
+	rootCmd.AddCommand(benchCmd)
+	benchCmd.Flags().StringVar(&benchPolicy, "policy", "", "Path to policy YAML (default: ~/.chainwatch/policy.yaml)")
+	benchCmd.Flags().StringVar(&benchDenylist, "denylist", "", "Path to denylist YAML (default: ~/.chainwatch/denylist.yaml)")
+	benchCmd.Flags().StringVar(&benchMix, "mix", "", "YAML list of actions to evaluate (tool, resource, operation, meta); default is a built-in mix")
+	benchCmd.Flags().IntVarP(&benchIterations, "iterations", "n", bench.DefaultIterations, "Evaluations per run")
+	benchCmd.Flags().IntSliceVar(&benchSizes, "denylist-sizes", nil, "Extra synthetic denylist patterns per run, e.g. 0,100,1000")
+	benchCmd.Flags().StringVar(&benchPurpose, "purpose", "general", "Purpose for every evaluation")
+	benchCmd.Flags().StringVar(&benchAgent, "agent", "", "Agent ID for every evaluation (optional)")
+	benchCmd.Flags().StringVarP(&benchFormat, "format", "f", "text", "Output format (text|json)")
+}
+
+var benchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "Measure policy evaluation latency and allocations for a config",
+	Long: "Evaluates a mix of actions against the loaded policy and denylist and\n" +
+		"reports throughput, p50/p99 latency and allocations per evaluation.\n\n" +
+		"Use --denylist-sizes to see how cost grows as the denylist grows.",
+	Example: "  chainwatch bench\n" +
+		"  chainwatch bench --policy policy.yaml --denylist-sizes 0,100,1000\n" +
+		"  chainwatch bench --mix actions.yaml -n 100000 -f json",
+	RunE: runBench,
+}
+
+func runBench(cmd *cobra.Command, args []string) error {
+	cfg, err := policy.LoadConfig(benchPolicy)
+	if err != nil {
+		return fmt.Errorf("load policy: %w", err)
+	}
+	dl, err := denylist.Load(benchDenylist)
+	if err != nil {
+		return fmt.Errorf("load denylist: %w", err)
+	}
+	opts := bench.Options{
+		Policy:     cfg,
+		Denylist:   dl,
+		Iterations: benchIterations,
+		Purpose:    benchPurpose,
+		AgentID:    benchAgent,
+	}
+	if benchMix != "" {
+		if opts.Mix, err = bench.LoadMix(benchMix); err != nil {
+			return err
+		}
+	}
+
+	sizes := benchSizes
+	if len(sizes) == 0 {
+		sizes = []int{0}
+	}
+	results := bench.RunSizes(opts, sizes)
+
+	switch benchFormat {
+	case "json":
+		out, err := bench.FormatJSON(results)
+		if err != nil {
+			return err
+		}
+		fmt.Println(out)
+	default:
+		fmt.Print(bench.FormatText(results))
+	}
+	return nil
+}
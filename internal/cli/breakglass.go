@@ -85,14 +85,7 @@ func runBreakGlassList(cmd *cobra.Command, args []string) error {
 
 	fmt.Printf("%-20s %-10s %-30s %-25s\n", "ID", "STATUS", "REASON", "EXPIRES")
 	for _, t := range tokens {
-		status := "active"
-		if t.UsedAt != nil {
-			status = "used"
-		} else if t.RevokedAt != nil {
-			status = "revoked"
-		} else if !t.IsActive() {
-			status = "expired"
-		}
+		status := t.Status()
 
 		reason := t.Reason
 		if len(reason) > 28 {
@@ -5,16 +5,23 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/ppiankov/chainwatch/internal/client"
+	"github.com/ppiankov/chainwatch/internal/model"
 	"github.com/ppiankov/chainwatch/internal/policy"
 )
 
-var budgetPolicyPath string
+var (
+	budgetPolicyPath string
+	budgetRemote     string
+)
 
 func init() {
 	rootCmd.AddCommand(budgetCmd)
 	budgetCmd.AddCommand(budgetStatusCmd)
+	budgetCmd.AddCommand(budgetResetTraceCmd)
 
 	budgetStatusCmd.Flags().StringVar(&budgetPolicyPath, "policy", "", "path to policy.yaml (default: ~/.chainwatch/policy.yaml)")
+	budgetResetTraceCmd.Flags().StringVar(&budgetRemote, "remote", "localhost:50051", "Policy server address")
 }
 
 var budgetCmd = &cobra.Command{
@@ -29,12 +36,43 @@ var budgetStatusCmd = &cobra.Command{
 	RunE:  runBudgetStatus,
 }
 
+var budgetResetTraceCmd = &cobra.Command{
+	Use:   "reset-trace <trace-id>",
+	Short: "Reset the action budget of a trace on a policy server",
+	Long: "Clears the max_actions_per_trace counter for a trace held by a running\n" +
+		"chainwatch serve instance, so evaluation resumes after trace_budget_exhausted.",
+	Args: cobra.ExactArgs(1),
+	RunE: runBudgetResetTrace,
+}
+
+func runBudgetResetTrace(cmd *cobra.Command, args []string) error {
+	c, err := client.New(budgetRemote)
+	if err != nil {
+		return fmt.Errorf("failed to connect to remote server: %w", err)
+	}
+	defer c.Close()
+
+	found, prev, err := c.ResetTrace(args[0], model.ResetScopeActionCount, "")
+	if err != nil {
+		return fmt.Errorf("reset failed: %w", err)
+	}
+	if !found {
+		return fmt.Errorf("trace %q not found on %s", args[0], budgetRemote)
+	}
+	fmt.Printf("Reset trace %s (%d actions counted before reset)\n", args[0], prev)
+	return nil
+}
+
 func runBudgetStatus(cmd *cobra.Command, args []string) error {
 	cfg, err := policy.LoadConfig(budgetPolicyPath)
 	if err != nil {
 		return fmt.Errorf("failed to load policy: %w", err)
 	}
 
+	if cfg.MaxActionsPerTrace > 0 {
+		fmt.Printf("Trace action budget: max_actions_per_trace = %d\n\n", cfg.MaxActionsPerTrace)
+	}
+
 	if len(cfg.Budgets) == 0 {
 		fmt.Println("No budgets configured.")
 		fmt.Println()
@@ -45,16 +45,22 @@ func runCheck(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("no scenario files match pattern: %s", checkScenario)
 	}
 
+	return runScenarioFiles(matches, checkPolicy, checkDenylist, checkFormat)
+}
+
+// runScenarioFiles evaluates scenario files, prints the report in format,
+// and exits 1 if any case fails.
+func runScenarioFiles(paths []string, policyPath, denylistPath, format string) error {
 	var results []*scenario.RunResult
-	for _, path := range matches {
-		r, err := scenario.LoadAndRun(path, checkPolicy, checkDenylist)
+	for _, path := range paths {
+		r, err := scenario.LoadAndRun(path, policyPath, denylistPath)
 		if err != nil {
 			return fmt.Errorf("%s: %w", path, err)
 		}
 		results = append(results, r)
 	}
 
-	switch checkFormat {
+	switch format {
 	case "json":
 		out, err := scenario.FormatJSON(results)
 		if err != nil {
@@ -97,6 +97,7 @@ func runSOCDemo(cmd *cobra.Command, args []string) error {
 			"reason":       result.Reason,
 			"policy_id":    result.PolicyID,
 			"approval_key": result.ApprovalKey,
+			"tier":         result.Tier,
 		}, "")
 
 		// Try enforcement
@@ -4,12 +4,13 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
-
-	"github.com/ppiankov/chainwatch/internal/approval"
 )
 
+var denyPolicy string
+
 func init() {
 	rootCmd.AddCommand(denyCmd)
+	denyCmd.Flags().StringVar(&denyPolicy, "policy", "", "Path to policy YAML whose approval_store to use (default: ~/.chainwatch/policy.yaml)")
 }
 
 var denyCmd = &cobra.Command{
@@ -23,7 +24,7 @@ var denyCmd = &cobra.Command{
 func runDeny(cmd *cobra.Command, args []string) error {
 	key := args[0]
 
-	store, err := approval.NewStore(approval.DefaultDir())
+	store, err := openApprovalStore(denyPolicy)
 	if err != nil {
 		return fmt.Errorf("failed to open approval store: %w", err)
 	}
@@ -2,33 +2,78 @@ package cli
 
 import (
 	"fmt"
+	"io"
+	"net"
+	"net/http"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 
+	"github.com/ppiankov/chainwatch/internal/approval"
+	"github.com/ppiankov/chainwatch/internal/breakglass"
+	"github.com/ppiankov/chainwatch/internal/denylist"
+	"github.com/ppiankov/chainwatch/internal/policy"
 	"github.com/ppiankov/chainwatch/internal/profile"
 )
 
+var (
+	doctorPolicy   string
+	doctorDenylist string
+	doctorProfile  string
+	doctorAuditLog string
+	doctorUpstream string
+	doctorTimeout  time.Duration
+)
+
 func init() {
 	rootCmd.AddCommand(doctorCmd)
+	doctorCmd.Flags().StringVar(&doctorPolicy, "policy", "", "Path to policy YAML (default: ~/.chainwatch/policy.yaml)")
+	doctorCmd.Flags().StringVar(&doctorDenylist, "denylist", "", "Path to denylist YAML (default: ~/.chainwatch/denylist.yaml)")
+	doctorCmd.Flags().StringVar(&doctorProfile, "profile", "", "Safety profile to load and validate")
+	doctorCmd.Flags().StringVar(&doctorAuditLog, "audit-log", "", "Audit log path whose directory must be writable")
+	doctorCmd.Flags().StringVar(&doctorUpstream, "upstream", "", "Upstream URL or host:port to probe for reachability")
+	doctorCmd.Flags().DurationVar(&doctorTimeout, "timeout", 5*time.Second, "Timeout for the upstream reachability probe")
 }
 
 var doctorCmd = &cobra.Command{
 	Use:   "doctor",
 	Short: "Check system readiness and diagnose configuration issues",
-	RunE:  runDoctor,
+	Long: "Validates the local chainwatch setup and prints a pass/warn/fail report.\n\n" +
+		"Checks that policy and denylist files parse, the selected profile exists and is valid,\n" +
+		"approval/break-glass/audit directories are writable, and (with --upstream) that the\n" +
+		"upstream endpoint is reachable. Exits non-zero if any check fails.",
+	RunE: runDoctor,
 }
 
+type checkStatus int
+
+const (
+	checkPass checkStatus = iota
+	checkWarn
+	checkFail
+)
+
 type checkResult struct {
 	label  string
-	ok     bool
+	status checkStatus
 	detail string
 	fix    string
 }
 
 func runDoctor(cmd *cobra.Command, args []string) error {
+	checks := collectDoctorChecks()
+	if printDoctorReport(os.Stdout, checks) {
+		return fmt.Errorf("doctor found issues")
+	}
+	return nil
+}
+
+// collectDoctorChecks runs every diagnostic and returns the results in report order.
+func collectDoctorChecks() []checkResult {
 	var checks []checkResult
 
 	// 1. Binary location and version.
@@ -36,13 +81,13 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 	if execPath != "" {
 		checks = append(checks, checkResult{
 			label:  "chainwatch binary",
-			ok:     true,
+			status: checkPass,
 			detail: fmt.Sprintf("%s (v%s)", execPath, version),
 		})
 	} else {
 		checks = append(checks, checkResult{
 			label:  "chainwatch binary",
-			ok:     false,
+			status: checkFail,
 			detail: "cannot determine executable path",
 		})
 	}
@@ -58,121 +103,225 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 		if info, err := os.Stat(configDir); err == nil && info.IsDir() {
 			checks = append(checks, checkResult{
 				label:  "config directory",
-				ok:     true,
+				status: checkPass,
 				detail: configDir,
 			})
 		} else {
 			checks = append(checks, checkResult{
 				label:  "config directory",
-				ok:     false,
-				detail: "missing",
+				status: checkWarn,
+				detail: "missing (built-in defaults in use)",
 				fix:    "chainwatch init",
 			})
 		}
 	} else {
 		checks = append(checks, checkResult{
 			label:  "config directory",
-			ok:     false,
+			status: checkWarn,
 			detail: "cannot determine home directory",
 		})
 	}
 
-	// 3. policy.yaml.
-	if configDir != "" {
-		policyPath := filepath.Join(configDir, "policy.yaml")
-		if _, err := os.Stat(policyPath); err == nil {
-			checks = append(checks, checkResult{
-				label:  "policy.yaml",
-				ok:     true,
-				detail: "exists",
-			})
-		} else {
-			checks = append(checks, checkResult{
-				label:  "policy.yaml",
-				ok:     false,
-				detail: "missing",
-				fix:    "chainwatch init",
-			})
+	// 3. policy.yaml — must parse if present.
+	checks = append(checks, checkConfigFile("policy.yaml", doctorPolicy, configDir, func(path string) (string, error) {
+		cfg, err := policy.LoadConfig(path)
+		if err != nil {
+			return "", err
 		}
-	}
+		return fmt.Sprintf("%s (mode %s, %d rules)", path, cfg.EnforcementMode, len(cfg.Rules)), nil
+	}))
 
-	// 4. denylist.yaml.
-	if configDir != "" {
-		denylistPath := filepath.Join(configDir, "denylist.yaml")
-		if _, err := os.Stat(denylistPath); err == nil {
-			checks = append(checks, checkResult{
-				label:  "denylist.yaml",
-				ok:     true,
-				detail: "exists",
-			})
-		} else {
-			checks = append(checks, checkResult{
-				label:  "denylist.yaml",
-				ok:     false,
-				detail: "missing",
-				fix:    "chainwatch init",
-			})
+	// 4. denylist.yaml — must parse if present.
+	checks = append(checks, checkConfigFile("denylist.yaml", doctorDenylist, configDir, func(path string) (string, error) {
+		if _, err := denylist.Load(path); err != nil {
+			return "", fmt.Errorf("failed to parse denylist: %w", err)
 		}
-	}
+		return path, nil
+	}))
 
 	// 5. Profiles.
-	profiles := profile.List()
-	if len(profiles) > 0 {
-		checks = append(checks, checkResult{
-			label:  "profiles",
-			ok:     true,
-			detail: fmt.Sprintf("%d available", len(profiles)),
-		})
-	} else {
-		checks = append(checks, checkResult{
-			label:  "profiles",
-			ok:     false,
-			detail: "none found",
-			fix:    "chainwatch init --profile <name>",
-		})
+	checks = append(checks, checkProfile(doctorProfile))
+
+	// 6. State directories — stores create them on first use, so the
+	// nearest existing ancestor must be writable.
+	checks = append(checks, checkWritableDir("approval dir", approval.DefaultDir()))
+	checks = append(checks, checkWritableDir("breakglass dir", breakglass.DefaultDir()))
+	if doctorAuditLog != "" {
+		checks = append(checks, checkWritableDir("audit log dir", filepath.Dir(doctorAuditLog)))
 	}
 
-	// 6. systemd (Linux only).
+	// 7. Upstream reachability (optional).
+	if doctorUpstream != "" {
+		checks = append(checks, checkUpstream(doctorUpstream, doctorTimeout))
+	}
+
+	// 8. systemd (Linux only, optional).
 	if runtime.GOOS == "linux" {
 		unitPath := "/etc/systemd/system/chainwatch-guarded@.service"
 		if _, err := os.Stat(unitPath); err == nil {
 			checks = append(checks, checkResult{
 				label:  "guarded@ template",
-				ok:     true,
+				status: checkPass,
 				detail: "installed",
 			})
 		} else {
 			checks = append(checks, checkResult{
 				label:  "guarded@ template",
-				ok:     false,
+				status: checkWarn,
 				detail: "not installed",
 				fix:    "sudo chainwatch init --install-systemd",
 			})
 		}
 	}
 
-	// Print results.
-	hasFailures := false
+	return checks
+}
+
+// checkConfigFile resolves an explicit or default config path and parses it.
+// A missing default file is a warning (loaders fall back to built-in defaults);
+// a missing explicit file or a parse error is a failure.
+func checkConfigFile(label, explicit, configDir string, load func(path string) (string, error)) checkResult {
+	path := explicit
+	if path == "" {
+		if configDir == "" {
+			return checkResult{label: label, status: checkWarn, detail: "no config directory, using built-in defaults"}
+		}
+		path = filepath.Join(configDir, label)
+	}
+
+	if _, err := os.Stat(path); err != nil {
+		if explicit != "" {
+			return checkResult{label: label, status: checkFail, detail: fmt.Sprintf("%s: %v", path, err)}
+		}
+		return checkResult{
+			label:  label,
+			status: checkWarn,
+			detail: "missing, using built-in defaults",
+			fix:    "chainwatch init",
+		}
+	}
+
+	detail, err := load(path)
+	if err != nil {
+		return checkResult{label: label, status: checkFail, detail: err.Error()}
+	}
+	return checkResult{label: label, status: checkPass, detail: detail}
+}
+
+// checkProfile validates a named profile, or reports available profiles when none is selected.
+func checkProfile(name string) checkResult {
+	if name == "" {
+		profiles := profile.List()
+		if len(profiles) == 0 {
+			return checkResult{
+				label:  "profiles",
+				status: checkFail,
+				detail: "none found",
+				fix:    "chainwatch init --profile <name>",
+			}
+		}
+		return checkResult{
+			label:  "profiles",
+			status: checkPass,
+			detail: fmt.Sprintf("%d available", len(profiles)),
+		}
+	}
+
+	label := "profile " + name
+	prof, err := profile.Load(name)
+	if err != nil {
+		return checkResult{label: label, status: checkFail, detail: err.Error(), fix: "chainwatch profile list"}
+	}
+	if err := profile.Validate(prof); err != nil {
+		return checkResult{label: label, status: checkFail, detail: err.Error()}
+	}
+	return checkResult{label: label, status: checkPass, detail: "valid"}
+}
+
+// checkWritableDir probes dir (or its nearest existing ancestor) with a temp file.
+func checkWritableDir(label, dir string) checkResult {
+	probe := dir
+	for {
+		info, err := os.Stat(probe)
+		if err == nil {
+			if !info.IsDir() {
+				return checkResult{label: label, status: checkFail, detail: probe + " is not a directory"}
+			}
+			break
+		}
+		parent := filepath.Dir(probe)
+		if parent == probe {
+			return checkResult{label: label, status: checkFail, detail: dir + ": no existing parent directory"}
+		}
+		probe = parent
+	}
+
+	f, err := os.CreateTemp(probe, ".chainwatch-doctor-*")
+	if err != nil {
+		return checkResult{label: label, status: checkFail, detail: fmt.Sprintf("%s not writable: %v", probe, err)}
+	}
+	name := f.Name()
+	_ = f.Close()
+	_ = os.Remove(name)
+
+	if probe != dir {
+		return checkResult{label: label, status: checkPass, detail: dir + " (will be created)"}
+	}
+	return checkResult{label: label, status: checkPass, detail: dir}
+}
+
+// checkUpstream probes an HTTP(S) URL with a GET, or a bare host:port with a TCP dial.
+// Any HTTP response counts as reachable.
+func checkUpstream(target string, timeout time.Duration) checkResult {
+	const label = "upstream"
+
+	if !strings.Contains(target, "://") {
+		conn, err := net.DialTimeout("tcp", target, timeout)
+		if err != nil {
+			return checkResult{label: label, status: checkFail, detail: fmt.Sprintf("%s unreachable: %v", target, err)}
+		}
+		_ = conn.Close()
+		return checkResult{label: label, status: checkPass, detail: target + " reachable"}
+	}
+
+	client := &http.Client{Timeout: timeout}
+	resp, err := client.Get(target)
+	if err != nil {
+		return checkResult{label: label, status: checkFail, detail: fmt.Sprintf("%s unreachable: %v", target, err)}
+	}
+	_ = resp.Body.Close()
+	return checkResult{label: label, status: checkPass, detail: fmt.Sprintf("%s reachable (HTTP %d)", target, resp.StatusCode)}
+}
+
+// printDoctorReport writes the report and returns true if any check failed.
+func printDoctorReport(w io.Writer, checks []checkResult) bool {
+	var failures, warnings int
 	for _, c := range checks {
 		mark := "\u2713" // ✓
-		if !c.ok {
+		switch c.status {
+		case checkWarn:
+			mark = "!"
+			warnings++
+		case checkFail:
 			mark = "\u2717" // ✗
-			hasFailures = true
+			failures++
 		}
 		line := fmt.Sprintf("%s %-20s %s", mark, c.label+":", c.detail)
-		if !c.ok && c.fix != "" {
+		if c.status != checkPass && c.fix != "" {
 			line += fmt.Sprintf("  ->  %s", c.fix)
 		}
-		fmt.Println(line)
+		fmt.Fprintln(w, line)
 	}
 
-	if hasFailures {
-		fmt.Println()
-		fmt.Println("Some checks failed. Run the suggested commands to fix.")
-		return fmt.Errorf("doctor found issues")
+	fmt.Fprintln(w)
+	switch {
+	case failures > 0:
+		fmt.Fprintf(w, "%d check(s) failed, %d warning(s). Run the suggested commands to fix.\n", failures, warnings)
+		return true
+	case warnings > 0:
+		fmt.Fprintf(w, "All checks passed with %d warning(s).\n", warnings)
+	default:
+		fmt.Fprintln(w, "All checks passed.")
 	}
-
-	fmt.Println()
-	fmt.Println("All checks passed.")
-	return nil
+	return false
 }
@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func resetDoctorFlags(t *testing.T) {
+	t.Helper()
+	doctorPolicy = ""
+	doctorDenylist = ""
+	doctorProfile = ""
+	doctorAuditLog = ""
+	doctorUpstream = ""
+	doctorTimeout = 2 * time.Second
+}
+
+func setupDoctorHome(t *testing.T) string {
+	t.Helper()
+	tmpDir := t.TempDir()
+	t.Setenv("HOME", tmpDir)
+
+	initMode = "user"
+	initProfile = ""
+	initInstallSystemd = false
+	initForce = false
+	if err := runInit(nil, nil); err != nil {
+		t.Fatalf("runInit failed: %v", err)
+	}
+	return filepath.Join(tmpDir, ".chainwatch")
+}
+
+func findCheck(checks []checkResult, label string) (checkResult, bool) {
+	for _, c := range checks {
+		if c.label == label {
+			return c, true
+		}
+	}
+	return checkResult{}, false
+}
+
+func TestDoctorHealthyConfig(t *testing.T) {
+	configDir := setupDoctorHome(t)
+	resetDoctorFlags(t)
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer upstream.Close()
+
+	doctorProfile = "coding-agent"
+	doctorAuditLog = filepath.Join(configDir, "audit", "audit.jsonl")
+	doctorUpstream = upstream.URL
+
+	checks := collectDoctorChecks()
+	for _, c := range checks {
+		if c.status == checkFail {
+			t.Errorf("unexpected failure: %s: %s", c.label, c.detail)
+		}
+	}
+	for _, label := range []string{"policy.yaml", "denylist.yaml", "profile coding-agent", "approval dir", "breakglass dir", "audit log dir", "upstream"} {
+		c, ok := findCheck(checks, label)
+		if !ok {
+			t.Errorf("missing check %q", label)
+			continue
+		}
+		if c.status != checkPass {
+			t.Errorf("%s: expected pass, got status %d (%s)", label, c.status, c.detail)
+		}
+	}
+
+	var out bytes.Buffer
+	if printDoctorReport(&out, checks) {
+		t.Errorf("expected report without failures, got:\n%s", out.String())
+	}
+}
+
+func TestDoctorInvalidPolicy(t *testing.T) {
+	configDir := setupDoctorHome(t)
+	resetDoctorFlags(t)
+
+	if err := os.WriteFile(filepath.Join(configDir, "policy.yaml"), []byte("rules: [\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	checks := collectDoctorChecks()
+	c, ok := findCheck(checks, "policy.yaml")
+	if !ok {
+		t.Fatal("missing policy.yaml check")
+	}
+	if c.status != checkFail {
+		t.Errorf("expected invalid policy to fail, got status %d (%s)", c.status, c.detail)
+	}
+
+	var out bytes.Buffer
+	if !printDoctorReport(&out, checks) {
+		t.Error("expected report to signal failure")
+	}
+	if !strings.Contains(out.String(), "failed to parse policy config") {
+		t.Errorf("expected parse error in report, got:\n%s", out.String())
+	}
+
+	if err := runDoctor(nil, nil); err == nil {
+		t.Error("expected runDoctor to return an error")
+	}
+}
+
+func TestDoctorMissingPolicyWarns(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	resetDoctorFlags(t)
+
+	c, _ := findCheck(collectDoctorChecks(), "policy.yaml")
+	if c.status != checkWarn {
+		t.Errorf("expected missing default policy to warn, got status %d (%s)", c.status, c.detail)
+	}
+
+	doctorPolicy = filepath.Join(t.TempDir(), "nope.yaml")
+	c, _ = findCheck(collectDoctorChecks(), "policy.yaml")
+	if c.status != checkFail {
+		t.Errorf("expected missing explicit policy to fail, got status %d (%s)", c.status, c.detail)
+	}
+}
+
+func TestDoctorUnknownProfileFails(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	resetDoctorFlags(t)
+	doctorProfile = "no-such-profile"
+
+	c, ok := findCheck(collectDoctorChecks(), "profile no-such-profile")
+	if !ok || c.status != checkFail {
+		t.Errorf("expected unknown profile to fail, got %+v", c)
+	}
+}
@@ -5,11 +5,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"os/signal"
 	"strings"
 	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -21,46 +23,77 @@ import (
 var (
 	execDenylist string
 	execPolicy   string
-	execProfile  string
+	execProfile  []string
 	execPurpose  string
 	execVerbose  bool
 	execDryRun   bool
 	execAuditLog string
 	execRemote   string
 	execAgent    string
+
+	execRedactPlaceholder string
+	execRequirePolicy     bool
+
+	execTimeout time.Duration
+
+	execStdinFile string
+
+	execEnvPassthrough []string
+	execTracePath      string
+
+	execConfirmToken string
 )
 
 func init() {
 	rootCmd.AddCommand(execCmd)
 	execCmd.Flags().StringVar(&execDenylist, "denylist", "", "Path to denylist YAML")
 	execCmd.Flags().StringVar(&execPolicy, "policy", "", "Path to policy YAML (default: ~/.chainwatch/policy.yaml)")
-	execCmd.Flags().StringVar(&execProfile, "profile", "", "Safety profile to apply (e.g., clawbot)")
+	execCmd.Flags().BoolVar(&execRequirePolicy, "require-policy", false, "Fail if the policy file is missing or empty instead of using defaults")
+	execCmd.Flags().StringSliceVar(&execProfile, "profile", nil, "Safety profile to apply (e.g., clawbot); repeat or comma-separate to stack, e.g. clawbot,vm-cloud")
 	execCmd.Flags().StringVar(&execPurpose, "purpose", "general", "Purpose identifier for policy evaluation")
 	execCmd.Flags().BoolVarP(&execVerbose, "verbose", "v", false, "Print trace summary after execution")
 	execCmd.Flags().BoolVar(&execDryRun, "dry-run", false, "Check policy without executing")
 	execCmd.Flags().StringVar(&execAuditLog, "audit-log", "", "Path to audit log JSONL file")
 	execCmd.Flags().StringVar(&execRemote, "remote", "", "Remote policy server address (e.g., localhost:50051)")
 	execCmd.Flags().StringVar(&execAgent, "agent", "", "Agent identity for scoped policy enforcement")
+	execCmd.Flags().DurationVar(&execTimeout, "timeout", 0, fmt.Sprintf("Kill the command after this duration (e.g., 30s) and exit %d; 0 disables", cmdguard.TimeoutExitCode))
+	execCmd.Flags().StringVar(&execStdinFile, "stdin-from-file", "", fmt.Sprintf("Feed the command's stdin from this file instead of the terminal (max %d bytes)", cmdguard.DefaultMaxStdinBytes))
+	execCmd.Flags().StringSliceVar(&execEnvPassthrough, "env-passthrough", nil, "Env vars to keep for the command even if a sensitive prefix would strip them (e.g. AWS_REGION); secret-like names are always stripped")
+	execCmd.Flags().StringVar(&execTracePath, "trace-path", "", "Append the session trace as JSONL (one line per action) to this file on exit")
+	execCmd.Flags().StringVar(&execConfirmToken, "confirm-token", "", "Confirm token from an earlier blocked run; required to run an approved irreversible command under confirm_irreversible")
+	execCmd.Flags().StringVar(&execRedactPlaceholder, "redact-placeholder", cmdguard.DefaultRedactPlaceholder, "Replacement for secrets in command output; {category} expands to the secret type")
 }
 
 var execCmd = &cobra.Command{
 	Use:   "exec [flags] -- <command> [args...]",
 	Short: "Execute a command through chainwatch policy enforcement",
-	Long:  "Evaluates the command against denylist and policy before execution.\nBlocked commands are not executed. Exit code 77 indicates policy block.",
+	Long:  "Evaluates the command against denylist and policy before execution.\nBlocked commands are not executed. Exit code 77 indicates policy block;\nexit code 124 indicates the --timeout was reached.",
 	Args:  cobra.MinimumNArgs(1),
 	RunE:  runExec,
 }
 
 func runExec(cmd *cobra.Command, args []string) error {
+	// Open stdin up front so an unreadable or oversize file fails before
+	// the command is evaluated.
+	var stdin io.Reader = os.Stdin
+	if execStdinFile != "" {
+		f, err := cmdguard.OpenStdinFile(execStdinFile, cmdguard.DefaultMaxStdinBytes)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		stdin = f
+	}
+
 	// Remote mode: policy evaluation via gRPC, execution local
 	if execRemote != "" {
-		return runExecRemote(args)
+		return runExecRemote(args, stdin)
 	}
 
-	return runExecLocal(args)
+	return runExecLocal(args, stdin)
 }
 
-func runExecRemote(args []string) error {
+func runExecRemote(args []string, stdin io.Reader) error {
 	name := args[0]
 	cmdArgs := args[1:]
 
@@ -86,14 +119,14 @@ func runExecRemote(args []string) error {
 	if execDryRun {
 		out, _ := json.MarshalIndent(result, "", "  ")
 		fmt.Println(string(out))
-		if result.Decision == model.Deny || result.Decision == model.RequireApproval {
+		if result.Decision == model.Deny || result.Decision == model.RequireApproval || result.Decision == model.Quarantine {
 			os.Exit(77)
 		}
 		return nil
 	}
 
 	// Blocked by remote policy
-	if result.Decision == model.Deny || result.Decision == model.RequireApproval {
+	if result.Decision == model.Deny || result.Decision == model.RequireApproval || result.Decision == model.Quarantine {
 		resp := map[string]any{
 			"blocked":  true,
 			"command":  strings.Join(args, " "),
@@ -103,6 +136,9 @@ func runExecRemote(args []string) error {
 		if result.PolicyID != "" {
 			resp["policy_id"] = result.PolicyID
 		}
+		if result.ApprovalKey != "" {
+			resp["approval_key"] = result.ApprovalKey
+		}
 		out, _ := json.MarshalIndent(resp, "", "  ")
 		fmt.Fprintln(os.Stderr, string(out))
 
@@ -115,6 +151,12 @@ func runExecRemote(args []string) error {
 	// Allowed: execute locally
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
+	runCtx := ctx
+	if execTimeout > 0 {
+		var timeoutCancel context.CancelFunc
+		runCtx, timeoutCancel = context.WithTimeout(ctx, execTimeout)
+		defer timeoutCancel()
+	}
 
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
@@ -123,12 +165,16 @@ func runExecRemote(args []string) error {
 		cancel()
 	}()
 
-	execCmd := exec.CommandContext(ctx, name, cmdArgs...)
-	execCmd.Stdin = os.Stdin
+	execCmd := exec.CommandContext(runCtx, name, cmdArgs...)
+	execCmd.Stdin = stdin
 	execCmd.Stdout = os.Stdout
 	execCmd.Stderr = os.Stderr
 
 	if err := execCmd.Run(); err != nil {
+		if ctx.Err() == nil && runCtx.Err() == context.DeadlineExceeded {
+			fmt.Fprintf(os.Stderr, "chainwatch: command timed out after %s\n", execTimeout)
+			os.Exit(cmdguard.TimeoutExitCode)
+		}
 		var exitErr *exec.ExitError
 		if errors.As(err, &exitErr) {
 			os.Exit(exitErr.ExitCode())
@@ -138,22 +184,35 @@ func runExecRemote(args []string) error {
 	return nil
 }
 
-func runExecLocal(args []string) error {
+func runExecLocal(args []string, stdin io.Reader) error {
 	cfg := cmdguard.Config{
-		DenylistPath: execDenylist,
-		PolicyPath:   execPolicy,
-		ProfileName:  execProfile,
-		Purpose:      execPurpose,
-		AgentID:      execAgent,
-		Actor:        map[string]any{"cli": "chainwatch exec"},
-		AuditLogPath: execAuditLog,
+		DenylistPath:      execDenylist,
+		PolicyPath:        execPolicy,
+		RequirePolicyFile: execRequirePolicy,
+		ProfileName:       strings.Join(execProfile, ","),
+		Purpose:           execPurpose,
+		AgentID:           execAgent,
+		Actor:             map[string]any{"cli": "chainwatch exec"},
+		AuditLogPath:      execAuditLog,
+		RedactPlaceholder: execRedactPlaceholder,
+
+		CommandTimeout: execTimeout,
+		EnvPassthrough: execEnvPassthrough,
+		TracePath:      execTracePath,
 	}
 
 	guard, err := cmdguard.NewGuard(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to create guard: %w", err)
 	}
-	defer guard.Close()
+	// os.Exit skips deferred calls, so exit paths close the guard first
+	// to flush the audit log and trace file.
+	closeGuard := func() {
+		if err := guard.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
+	}
+	defer closeGuard()
 
 	name := args[0]
 	cmdArgs := args[1:]
@@ -163,7 +222,8 @@ func runExecLocal(args []string) error {
 		result := guard.Check(name, cmdArgs)
 		out, _ := json.MarshalIndent(result, "", "  ")
 		fmt.Println(string(out))
-		if result.Decision == "deny" || result.Decision == "require_approval" {
+		if dryRunBlocked(result.Decision) {
+			closeGuard()
 			os.Exit(77)
 		}
 		return nil
@@ -180,7 +240,7 @@ func runExecLocal(args []string) error {
 		cancel()
 	}()
 
-	result, err := guard.Run(ctx, name, cmdArgs, os.Stdin)
+	result, err := guard.Run(cmdguard.WithConfirmToken(ctx, execConfirmToken), name, cmdArgs, stdin)
 	if err != nil {
 		var blocked *cmdguard.BlockedError
 		if errors.As(err, &blocked) {
@@ -193,16 +253,28 @@ func runExecLocal(args []string) error {
 			if blocked.PolicyID != "" {
 				resp["policy_id"] = blocked.PolicyID
 			}
+			if blocked.ApprovalKey != "" {
+				resp["approval_key"] = blocked.ApprovalKey
+			}
+			if blocked.Suggestion != "" {
+				resp["suggestion"] = blocked.Suggestion
+			}
+			if blocked.ConfirmToken != "" {
+				resp["confirm_token"] = blocked.ConfirmToken
+			}
 			out, _ := json.MarshalIndent(resp, "", "  ")
 			fmt.Fprintln(os.Stderr, string(out))
 
-			if blocked.Decision == model.RequireApproval && blocked.ApprovalKey != "" {
+			if blocked.ConfirmToken != "" {
+				fmt.Fprintf(os.Stderr, "\nTo confirm, rerun with: --confirm-token %s\n", blocked.ConfirmToken)
+			} else if blocked.Decision == model.RequireApproval && blocked.ApprovalKey != "" {
 				fmt.Fprintf(os.Stderr, "\nTo approve, run: chainwatch approve %s\n", blocked.ApprovalKey)
 			}
 
 			if execVerbose {
 				printExecTrace(guard)
 			}
+			closeGuard()
 			os.Exit(77)
 		}
 		return err
@@ -218,7 +290,14 @@ func runExecLocal(args []string) error {
 		printExecTrace(guard)
 	}
 
+	if result.TimedOut {
+		fmt.Fprintf(os.Stderr, "chainwatch: command timed out after %s\n", execTimeout)
+	}
+	if result.EgressDetected {
+		fmt.Fprintln(os.Stderr, "chainwatch: command output shows an upload to an external host (recorded as output_egress)")
+	}
 	if result.ExitCode != 0 {
+		closeGuard()
 		os.Exit(result.ExitCode)
 	}
 	return nil
@@ -230,3 +309,13 @@ func printExecTrace(guard *cmdguard.Guard) {
 	fmt.Fprintln(os.Stderr, "\nTrace summary:")
 	fmt.Fprintln(os.Stderr, string(out))
 }
+
+// dryRunBlocked reports whether a --dry-run decision would block the real
+// run. Quarantine blocks: exec cannot contain a command's effects.
+func dryRunBlocked(decision model.Decision) bool {
+	switch decision {
+	case model.Deny, model.RequireApproval, model.Quarantine:
+		return true
+	}
+	return false
+}
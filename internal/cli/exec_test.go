@@ -0,0 +1,25 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/ppiankov/chainwatch/internal/model"
+)
+
+func TestDryRunBlocked(t *testing.T) {
+	tests := []struct {
+		decision model.Decision
+		want     bool
+	}{
+		{model.Allow, false},
+		{model.AllowWithRedaction, false},
+		{model.Deny, true},
+		{model.RequireApproval, true},
+		{model.Quarantine, true},
+	}
+	for _, tt := range tests {
+		if got := dryRunBlocked(tt.decision); got != tt.want {
+			t.Errorf("dryRunBlocked(%s) = %v, want %v", tt.decision, got, tt.want)
+		}
+	}
+}
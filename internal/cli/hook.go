@@ -140,7 +140,7 @@ func runHook(cmd *cobra.Command, args []string) error {
 	result := policy.Evaluate(action, state, "claude-code", "", dl, cfg)
 
 	switch result.Decision {
-	case model.Deny:
+	case model.Deny, model.Quarantine:
 		if hookVerbose {
 			fmt.Fprintf(os.Stderr, "chainwatch: DENY %s %q — %s\n", action.Tool, action.Resource, result.Reason)
 		}
@@ -246,13 +246,13 @@ func loadHookDenylist() (*denylist.Denylist, error) {
 				return nil, err
 			}
 			for _, u := range preset.URLs {
-				dl.AddPattern("urls", u)
+				dl.AddPattern("urls", u.Pattern)
 			}
 			for _, f := range preset.Files {
-				dl.AddPattern("files", f)
+				dl.AddPattern("files", f.Pattern)
 			}
 			for _, c := range preset.Commands {
-				dl.AddPattern("commands", c)
+				dl.AddPattern("commands", c.Pattern)
 			}
 		}
 	}
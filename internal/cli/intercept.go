@@ -11,6 +11,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/ppiankov/chainwatch/internal/intercept"
+	"github.com/ppiankov/chainwatch/internal/server"
 )
 
 var (
@@ -22,6 +23,30 @@ var (
 	interceptPurpose  string
 	interceptAuditLog string
 	interceptAgent    string
+	interceptResPaths map[string]string
+	interceptPins     []string
+	interceptAgentHdr string
+	interceptToolRes  bool
+
+	interceptRequirePolicy bool
+	interceptMaxStreams    int
+	interceptMaxSSELine    int
+	interceptInsecureHosts []string
+
+	interceptShadow        string
+	interceptShadowHeaders map[string]string
+
+	interceptDisableTools       bool
+	interceptDisableToolsAgents []string
+	interceptGeoIPDBs           []string
+
+	interceptUnknownFormat string
+	interceptTracePath     string
+
+	interceptStripReqHeaders  []string
+	interceptStripRespHeaders []string
+	interceptMaxHeaderBytes   int
+	interceptScanHeaders      bool
 )
 
 func init() {
@@ -30,16 +55,36 @@ func init() {
 	interceptCmd.Flags().StringVar(&interceptUpstream, "upstream", "https://api.anthropic.com", "Upstream LLM API URL")
 	interceptCmd.Flags().StringVar(&interceptDenylist, "denylist", "", "Path to denylist YAML (default: ~/.chainwatch/denylist.yaml)")
 	interceptCmd.Flags().StringVar(&interceptPolicy, "policy", "", "Path to policy YAML (default: ~/.chainwatch/policy.yaml)")
+	interceptCmd.Flags().BoolVar(&interceptRequirePolicy, "require-policy", false, "Fail if the policy file is missing or empty instead of using defaults")
 	interceptCmd.Flags().StringVar(&interceptProfile, "profile", "", "Safety profile to apply (e.g., clawbot)")
 	interceptCmd.Flags().StringVar(&interceptPurpose, "purpose", "general", "Purpose identifier for policy evaluation")
 	interceptCmd.Flags().StringVar(&interceptAuditLog, "audit-log", "", "Path to audit log JSONL file")
 	interceptCmd.Flags().StringVar(&interceptAgent, "agent", "", "Agent identity for scoped policy enforcement")
+	interceptCmd.Flags().StringVar(&interceptAgentHdr, "agent-header", "", "Request header carrying a per-request agent identity, e.g. X-Agent-ID (cannot be combined with --agent)")
+	interceptCmd.Flags().StringToStringVar(&interceptResPaths, "resource-path", nil, "Per-tool resource JSONPath, e.g. fetch_record=$.request.endpoint (repeatable)")
+	interceptCmd.Flags().BoolVar(&interceptToolRes, "tool-results", false, "Also attach a synthetic role=tool message per blocked OpenAI tool call (choices[0].tool_messages); blocked calls are still removed")
+	interceptCmd.Flags().IntVar(&interceptMaxStreams, "max-streams", 0, "Maximum concurrent streaming responses; excess get 503 (0 = unlimited)")
+	interceptCmd.Flags().IntVar(&interceptMaxSSELine, "max-sse-line", intercept.DefaultMaxSSELineSize, "Maximum size in bytes of a single SSE line; longer lines end the stream with an error event")
+	interceptCmd.Flags().StringSliceVar(&interceptInsecureHosts, "insecure-skip-verify-host", nil, "Upstream host whose TLS certificate is not verified, e.g. a self-signed internal gateway (repeatable; all other hosts stay verified)")
+	interceptCmd.Flags().StringVar(&interceptShadow, "shadow-upstream", "", "Mirror each request to a second upstream and audit its tool calls for comparison; its responses never reach the agent")
+	interceptCmd.Flags().StringToStringVar(&interceptShadowHeaders, "shadow-header", nil, "Header set on mirrored requests, e.g. Authorization='Bearer sk-...' (repeatable)")
+	interceptCmd.Flags().BoolVar(&interceptDisableTools, "disable-tools", false, "Kill switch: rewrite requests to tool_choice \"none\" so the model cannot call tools")
+	interceptCmd.Flags().StringSliceVar(&interceptDisableToolsAgents, "disable-tools-agent", nil, "Limit --disable-tools to this agent ID (repeatable; default all agents)")
+	interceptCmd.Flags().StringSliceVar(&interceptGeoIPDBs, "geoip-db", nil, "MaxMind DB (GeoLite2 Country/City/ASN) tagging tool call destinations with dest_country/dest_asn labels (repeatable)")
+	interceptCmd.Flags().StringVar(&interceptTracePath, "trace-path", "", "Append the session trace as JSONL (one line per tool call) to this file on shutdown")
+	interceptCmd.Flags().StringSliceVar(&interceptStripReqHeaders, "strip-request-header", nil, "Request header removed before forwarding upstream (repeatable)")
+	interceptCmd.Flags().StringSliceVar(&interceptStripRespHeaders, "strip-response-header", nil, "Response header removed before returning to the agent (repeatable)")
+	interceptCmd.Flags().IntVar(&interceptMaxHeaderBytes, "max-header-bytes", 0, "Reject requests whose headers exceed this many bytes with 431 (0 disables)")
+	interceptCmd.Flags().BoolVar(&interceptScanHeaders, "scan-headers", false, "Block requests whose header values carry a registered canary")
+	interceptCmd.Flags().StringVar(&interceptUnknownFormat, "unknown-format", intercept.UnknownFormatPassthrough, "Handling of responses in an unrecognized format that carry tool-call-like content: passthrough, log (audit), or block (fail closed)")
+	interceptCmd.Flags().StringSliceVar(&interceptPins, "upstream-pin", nil, "SHA-256 SPKI pin for the upstream certificate, sha256/<base64> (repeatable)")
+	interceptCmd.MarkFlagsMutuallyExclusive("agent", "agent-header")
 }
 
 var interceptCmd = &cobra.Command{
 	Use:   "intercept",
 	Short: "Start reverse proxy intercepting LLM tool-call responses",
-	Long:  "Reverse proxy between agent and LLM API that inspects tool_use/function_call blocks\nin LLM responses before the agent acts on them.\nUsage: ANTHROPIC_BASE_URL=http://localhost:9999 python agent.py",
+	Long:  "Reverse proxy between agent and LLM API that inspects tool_use/function_call blocks\nin LLM responses before the agent acts on them.\nWebSocket upgrades (OpenAI Realtime) are relayed with per-message function call enforcement.\nUsage: ANTHROPIC_BASE_URL=http://localhost:9999 python agent.py",
 	RunE:  runIntercept,
 }
 
@@ -54,17 +99,55 @@ func runIntercept(cmd *cobra.Command, args []string) error {
 		AgentID:      interceptAgent,
 		Actor:        map[string]any{"intercept": "chainwatch", "port": interceptPort},
 		AuditLogPath: interceptAuditLog,
+		AgentHeader:  interceptAgentHdr,
+		ToolResults:  interceptToolRes,
+
+		ResourcePaths:    interceptResPaths,
+		UpstreamCertPins: interceptPins,
+
+		RequirePolicyFile:    interceptRequirePolicy,
+		MaxConcurrentStreams: interceptMaxStreams,
+		MaxSSELineSize:       interceptMaxSSELine,
+
+		InsecureSkipVerifyHosts: interceptInsecureHosts,
+
+		ShadowUpstream: interceptShadow,
+		ShadowHeaders:  interceptShadowHeaders,
+
+		DisableTools:       interceptDisableTools,
+		DisableToolsAgents: interceptDisableToolsAgents,
+		GeoIPDBs:           interceptGeoIPDBs,
+
+		UnknownFormatPolicy: interceptUnknownFormat,
+		TracePath:           interceptTracePath,
+
+		StripRequestHeaders:  interceptStripReqHeaders,
+		StripResponseHeaders: interceptStripRespHeaders,
+		MaxHeaderBytes:       interceptMaxHeaderBytes,
+		ScanHeaderValues:     interceptScanHeaders,
 	}
 
 	srv, err := intercept.NewServer(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to create intercept server: %w", err)
 	}
-	defer srv.Close()
+	defer func() {
+		if err := srv.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
+	}()
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Reload policy, denylist, and profile on file change or SIGHUP
+	reloader, err := server.NewReloader(srv, []string{interceptPolicy, interceptDenylist})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: hot-reload disabled: %v\n", err)
+	} else {
+		go reloader.Run(ctx)
+	}
+
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
 
@@ -76,6 +159,15 @@ func runIntercept(cmd *cobra.Command, args []string) error {
 
 	fmt.Printf("chainwatch interceptor listening on :%d\n", interceptPort)
 	fmt.Printf("Upstream: %s\n", interceptUpstream)
+	if interceptShadow != "" {
+		fmt.Printf("Shadow upstream: %s\n", interceptShadow)
+	}
+	if interceptDisableTools {
+		fmt.Fprintln(os.Stderr, "WARNING: tool use disabled (tool_choice forced to none)")
+	}
+	for _, h := range interceptInsecureHosts {
+		fmt.Fprintf(os.Stderr, "WARNING: TLS certificate verification disabled for host %s\n", h)
+	}
 	fmt.Printf("Set ANTHROPIC_BASE_URL=http://localhost:%d to route agent traffic\n", interceptPort)
 	fmt.Println("Press Ctrl+C to stop")
 	fmt.Println()
@@ -87,6 +179,7 @@ func runIntercept(cmd *cobra.Command, args []string) error {
 	summary := srv.TraceSummary()
 	out, _ := json.MarshalIndent(summary, "", "  ")
 	fmt.Println(string(out))
+	printAlertStats(os.Stdout)
 
 	return err
 }
@@ -0,0 +1,76 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ppiankov/chainwatch/internal/denylist"
+	"github.com/ppiankov/chainwatch/internal/learn"
+)
+
+var (
+	learnAuditLog string
+	learnTrace    string
+	learnDenylist string
+	learnFormat   string
+)
+
+func init() {
+	rootCmd.AddCommand(learnCmd)
+	learnCmd.AddCommand(learnReportCmd)
+
+	learnReportCmd.Flags().StringVar(&learnAuditLog, "audit-log", "", "Path to the audit log recorded during the learning session (required)")
+	learnReportCmd.Flags().StringVar(&learnTrace, "trace", "", "Limit the report to one trace ID")
+	learnReportCmd.Flags().StringVar(&learnDenylist, "denylist", "", "Path to denylist YAML; attempts it already blocks are not suggested (default: ~/.chainwatch/denylist.yaml)")
+	learnReportCmd.Flags().StringVarP(&learnFormat, "format", "f", "text", "Output format (text|yaml|json)")
+	_ = learnReportCmd.MarkFlagRequired("audit-log")
+}
+
+var learnCmd = &cobra.Command{
+	Use:   "learn",
+	Short: "Bootstrap a denylist from observed agent behavior",
+	Long: "Learning mode: run the agent under an advisory policy (enforcement_mode: advisory)\n" +
+		"with --audit-log, so every attempt is recorded but nothing outside the denylist\n" +
+		"is blocked. Then run 'chainwatch learn report' to get suggested denylist entries\n" +
+		"for the risky attempts.",
+}
+
+var learnReportCmd = &cobra.Command{
+	Use:   "report",
+	Short: "Suggest denylist entries from a learning session's audit log",
+	Long: "Collects the distinct (tool, resource) pairs the agent attempted and proposes\n" +
+		"blocks for the risky ones: destructive or privilege-changing commands, sensitive\n" +
+		"files, external hosts, and anything policy placed at tier 3 or above. Attempts\n" +
+		"the current denylist already blocks are left out. --format yaml prints a\n" +
+		"denylist.yaml fragment to review and merge.",
+	RunE: runLearnReport,
+}
+
+func runLearnReport(cmd *cobra.Command, args []string) error {
+	attempts, err := learn.ReadAttempts(learnAuditLog, learnTrace)
+	if err != nil {
+		return err
+	}
+	dl, err := denylist.Load(learnDenylist)
+	if err != nil {
+		return fmt.Errorf("failed to load denylist: %w", err)
+	}
+
+	report := learn.Suggest(attempts, dl)
+	switch learnFormat {
+	case "yaml":
+		fmt.Print(learn.FormatYAML(report))
+	case "json":
+		out, err := learn.FormatJSON(report)
+		if err != nil {
+			return err
+		}
+		fmt.Println(out)
+	case "text":
+		fmt.Print(learn.FormatText(report))
+	default:
+		return fmt.Errorf("unknown format %q (want text, yaml or json)", learnFormat)
+	}
+	return nil
+}
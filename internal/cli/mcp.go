@@ -7,6 +7,7 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/spf13/cobra"
 
@@ -20,6 +21,13 @@ var (
 	mcpPurpose  string
 	mcpAuditLog string
 	mcpAgent    string
+	mcpCacheTTL time.Duration
+	mcpQuarDir  string
+
+	mcpMaxOutput int
+	mcpListen    string
+
+	mcpEnvPassthrough []string
 )
 
 func init() {
@@ -30,12 +38,17 @@ func init() {
 	mcpCmd.Flags().StringVar(&mcpPurpose, "purpose", "general", "Purpose identifier for policy evaluation")
 	mcpCmd.Flags().StringVar(&mcpAuditLog, "audit-log", "", "Path to audit log JSONL file")
 	mcpCmd.Flags().StringVar(&mcpAgent, "agent", "", "Agent identity for scoped policy enforcement")
+	mcpCmd.Flags().DurationVar(&mcpCacheTTL, "decision-cache-ttl", 0, "Cache identical exec decisions within the trace for this long (0 = disabled)")
+	mcpCmd.Flags().StringVar(&mcpQuarDir, "quarantine-dir", "", "Directory receiving file writes with a quarantine decision (empty = quarantine denies)")
+	mcpCmd.Flags().StringVar(&mcpListen, "listen", "", "Serve MCP sessions on a socket instead of stdio, e.g. unix:///run/chainwatch-mcp.sock")
+	mcpCmd.Flags().StringSliceVar(&mcpEnvPassthrough, "env-passthrough", nil, "Env vars to keep for chainwatch_exec commands even if a sensitive prefix would strip them; secret-like names are always stripped")
+	mcpCmd.Flags().IntVar(&mcpMaxOutput, "max-output-bytes", 0, "Truncate chainwatch_exec stdout/stderr in responses to this many bytes (0 = no cap)")
 }
 
 var mcpCmd = &cobra.Command{
 	Use:   "mcp",
 	Short: "Start MCP tool server for agent integration",
-	Long:  "Runs chainwatch as an MCP (Model Context Protocol) server over stdio.\nExposes policy-enforced tools: exec, http, check, approve, pending.",
+	Long:  "Runs chainwatch as an MCP (Model Context Protocol) server over stdio.\nExposes policy-enforced tools: exec, http, write, check, approve, pending.",
 	RunE:  runMCP,
 }
 
@@ -47,6 +60,12 @@ func runMCP(cmd *cobra.Command, args []string) error {
 		Purpose:      mcpPurpose,
 		AgentID:      mcpAgent,
 		AuditLogPath: mcpAuditLog,
+
+		DecisionCacheTTL: mcpCacheTTL,
+		QuarantineDir:    mcpQuarDir,
+		MaxOutputBytes:   mcpMaxOutput,
+		Listen:           mcpListen,
+		EnvPassthrough:   mcpEnvPassthrough,
 	}
 
 	srv, err := chainmcp.New(cfg)
@@ -67,7 +86,11 @@ func runMCP(cmd *cobra.Command, args []string) error {
 		cancel()
 	}()
 
-	fmt.Fprintln(os.Stderr, "chainwatch MCP server running on stdio")
+	if mcpListen != "" {
+		fmt.Fprintf(os.Stderr, "chainwatch MCP server listening on %s\n", mcpListen)
+	} else {
+		fmt.Fprintln(os.Stderr, "chainwatch MCP server running on stdio")
+	}
 	if mcpProfile != "" {
 		fmt.Fprintf(os.Stderr, "Profile: %s\n", mcpProfile)
 	}
@@ -81,6 +104,7 @@ func runMCP(cmd *cobra.Command, args []string) error {
 	summary := srv.TraceSummary()
 	out, _ := json.MarshalIndent(summary, "", "  ")
 	fmt.Fprintln(os.Stderr, string(out))
+	printAlertStats(os.Stderr)
 
 	return err
 }
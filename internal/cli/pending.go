@@ -4,12 +4,13 @@ import (
 	"fmt"
 
 	"github.com/spf13/cobra"
-
-	"github.com/ppiankov/chainwatch/internal/approval"
 )
 
+var pendingPolicy string
+
 func init() {
 	rootCmd.AddCommand(pendingCmd)
+	pendingCmd.Flags().StringVar(&pendingPolicy, "policy", "", "Path to policy YAML whose approval_store to use (default: ~/.chainwatch/policy.yaml)")
 }
 
 var pendingCmd = &cobra.Command{
@@ -20,7 +21,7 @@ var pendingCmd = &cobra.Command{
 }
 
 func runPending(cmd *cobra.Command, args []string) error {
-	store, err := approval.NewStore(approval.DefaultDir())
+	store, err := openApprovalStore(pendingPolicy)
 	if err != nil {
 		return fmt.Errorf("failed to open approval store: %w", err)
 	}
@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ppiankov/chainwatch/internal/denylist"
+	"github.com/ppiankov/chainwatch/internal/policy"
+	"github.com/ppiankov/chainwatch/internal/policytree"
+	"github.com/ppiankov/chainwatch/internal/profile"
+)
+
+var (
+	policyTestPolicy   string
+	policyTestDenylist string
+	policyTestFormat   string
+)
+
+var (
+	exportTreePolicy   string
+	exportTreeDenylist string
+	exportTreeProfile  []string
+	exportTreeFormat   string
+)
+
+func init() {
+	rootCmd.AddCommand(policyCmd)
+	policyCmd.AddCommand(policyTestCmd)
+	policyTestCmd.Flags().StringVar(&policyTestPolicy, "policy", "", "Path to policy YAML (default: ~/.chainwatch/policy.yaml)")
+	policyTestCmd.Flags().StringVar(&policyTestDenylist, "denylist", "", "Path to denylist YAML (default: ~/.chainwatch/denylist.yaml)")
+	policyTestCmd.Flags().StringVarP(&policyTestFormat, "format", "f", "text", "Output format (text|json)")
+
+	policyCmd.AddCommand(policyExportTreeCmd)
+	policyExportTreeCmd.Flags().StringVar(&exportTreePolicy, "policy", "", "Path to policy YAML (default: ~/.chainwatch/policy.yaml)")
+	policyExportTreeCmd.Flags().StringVar(&exportTreeDenylist, "denylist", "", "Path to denylist YAML (default: ~/.chainwatch/denylist.yaml)")
+	policyExportTreeCmd.Flags().StringSliceVar(&exportTreeProfile, "profile", nil, "Safety profile(s) to apply; repeat or comma-separate to stack")
+	policyExportTreeCmd.Flags().StringVarP(&exportTreeFormat, "format", "f", "markdown", "Output format (markdown|json)")
+}
+
+var policyCmd = &cobra.Command{
+	Use:   "policy",
+	Short: "Policy maintenance commands",
+}
+
+var policyTestCmd = &cobra.Command{
+	Use:   "test <cases.yaml> [more.yaml...]",
+	Short: "Run policy regression test cases",
+	Long: "Evaluates each test case against the policy and denylist and reports\n" +
+		"pass/fail. A case names an action and its expected decision, and may\n" +
+		"also assert expect_tier and expect_approval_key:\n\n" +
+		"  name: payroll guardrails\n" +
+		"  cases:\n" +
+		"    - action: {tool: file_read, resource: /hr/salary.csv}\n" +
+		"      purpose: SOC_efficiency\n" +
+		"      expect: require_approval\n" +
+		"      expect_approval_key: soc_salary_access\n" +
+		"    - action: {tool: command, resource: rm -rf /}\n" +
+		"      expect: deny\n" +
+		"      expect_tier: 3\n\n" +
+		"Exit code 0 if all cases pass, 1 if any fail.",
+	Args: cobra.MinimumNArgs(1),
+	RunE: runPolicyTest,
+}
+
+var policyExportTreeCmd = &cobra.Command{
+	Use:   "export-tree",
+	Short: "Export the effective policy decision tree",
+	Long: "Renders every condition under which an action is denied or held for approval —\n" +
+		"denylist patterns, zone triggers and escalations, self-target patterns, rules,\n" +
+		"tier decisions, and thresholds — generated from the loaded policy, denylist,\n" +
+		"and profiles. Use it to document enforcement for compliance review.",
+	Args: cobra.NoArgs,
+	RunE: runPolicyExportTree,
+}
+
+func runPolicyTest(cmd *cobra.Command, args []string) error {
+	return runScenarioFiles(args, policyTestPolicy, policyTestDenylist, policyTestFormat)
+}
+
+func runPolicyExportTree(cmd *cobra.Command, args []string) error {
+	out, err := exportPolicyTree(exportTreePolicy, exportTreeDenylist, exportTreeProfile, exportTreeFormat)
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(cmd.OutOrStdout(), out)
+	return nil
+}
+
+// exportPolicyTree loads the policy, denylist, and profiles and renders the
+// decision tree in format.
+func exportPolicyTree(policyPath, denylistPath string, profiles []string, format string) (string, error) {
+	cfg, hash, err := policy.LoadConfigWithHash(policyPath)
+	if err != nil {
+		return "", err
+	}
+	dl, err := denylist.Load(denylistPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to load denylist: %w", err)
+	}
+	names := profile.SplitNames(strings.Join(profiles, ","))
+	if len(names) > 0 {
+		cfg, err = profile.ApplyStack(names, dl, cfg)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	tree := policytree.Build(cfg, dl)
+	tree.PolicyPath = policyPath
+	tree.PolicyHash = hash
+	tree.Profiles = names
+
+	switch format {
+	case "json":
+		out, err := policytree.FormatJSON(tree)
+		if err != nil {
+			return "", err
+		}
+		return out + "\n", nil
+	case "markdown", "md":
+		return policytree.FormatMarkdown(tree), nil
+	default:
+		return "", fmt.Errorf("unknown format %q (want markdown or json)", format)
+	}
+}
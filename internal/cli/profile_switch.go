@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ppiankov/chainwatch/internal/client"
+)
+
+var (
+	profileSwitchRemote   string
+	profileSwitchOperator string
+)
+
+func init() {
+	profileCmd.AddCommand(profileSwitchCmd)
+	profileSwitchCmd.Flags().StringVar(&profileSwitchRemote, "remote", "localhost:50051", "Policy server address")
+	profileSwitchCmd.Flags().StringVar(&profileSwitchOperator, "operator", os.Getenv("USER"), "Operator identity recorded in the audit log")
+}
+
+var profileSwitchCmd = &cobra.Command{
+	Use:   "switch <name>",
+	Short: "Switch the active profile of a running policy server",
+	Long: "Re-reads policy and denylist on a running chainwatch serve instance and\n" +
+		"applies the named profile (or comma-separated stack) in place of the\n" +
+		"current one, without a restart. Traces keep their accumulated state.\n" +
+		"The switch is recorded in the server's audit log with the operator identity.",
+	Args: cobra.ExactArgs(1),
+	RunE: runProfileSwitch,
+}
+
+func runProfileSwitch(cmd *cobra.Command, args []string) error {
+	c, err := client.New(profileSwitchRemote)
+	if err != nil {
+		return fmt.Errorf("failed to connect to remote server: %w", err)
+	}
+	defer c.Close()
+
+	prev, err := c.ReloadProfile(args[0], profileSwitchOperator)
+	if err != nil {
+		return fmt.Errorf("profile switch failed: %w", err)
+	}
+	if prev == "" {
+		prev = "(none)"
+	}
+	fmt.Printf("Switched %s from profile %s to %s\n", profileSwitchRemote, prev, args[0])
+	return nil
+}
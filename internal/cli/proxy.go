@@ -11,6 +11,7 @@ import (
 	"github.com/spf13/cobra"
 
 	"github.com/ppiankov/chainwatch/internal/proxy"
+	"github.com/ppiankov/chainwatch/internal/server"
 )
 
 var (
@@ -21,6 +22,18 @@ var (
 	proxyPurpose  string
 	proxyAuditLog string
 	proxyAgent    string
+	proxyAgentHdr string
+
+	proxyRequirePolicy bool
+	proxyInsecureHosts []string
+	proxyBlockStatus   map[string]int
+	proxyGeoIPDBs      []string
+	proxyTracePath     string
+
+	proxyStripReqHeaders  []string
+	proxyStripRespHeaders []string
+	proxyMaxHeaderBytes   int
+	proxyScanHeaders      bool
 )
 
 func init() {
@@ -28,10 +41,21 @@ func init() {
 	proxyCmd.Flags().IntVar(&proxyPort, "port", 8888, "Port to listen on")
 	proxyCmd.Flags().StringVar(&proxyDenylist, "denylist", "", "Path to denylist YAML (default: ~/.chainwatch/denylist.yaml)")
 	proxyCmd.Flags().StringVar(&proxyPolicy, "policy", "", "Path to policy YAML (default: ~/.chainwatch/policy.yaml)")
+	proxyCmd.Flags().BoolVar(&proxyRequirePolicy, "require-policy", false, "Fail if the policy file is missing or empty instead of using defaults")
 	proxyCmd.Flags().StringVar(&proxyProfile, "profile", "", "Safety profile to apply (e.g., clawbot)")
 	proxyCmd.Flags().StringVar(&proxyPurpose, "purpose", "general", "Purpose identifier for policy evaluation")
 	proxyCmd.Flags().StringVar(&proxyAuditLog, "audit-log", "", "Path to audit log JSONL file")
 	proxyCmd.Flags().StringVar(&proxyAgent, "agent", "", "Agent identity for scoped policy enforcement")
+	proxyCmd.Flags().StringSliceVar(&proxyInsecureHosts, "insecure-skip-verify-host", nil, "Host whose TLS certificate is not verified on absolute-form https:// requests (repeatable; all other hosts stay verified)")
+	proxyCmd.Flags().StringToIntVar(&proxyBlockStatus, "block-status", nil, "HTTP status per blocked decision, e.g. require_approval=403 (classes: deny=403, require_approval=428, rate_limited=429, quarantine=403)")
+	proxyCmd.Flags().StringSliceVar(&proxyGeoIPDBs, "geoip-db", nil, "MaxMind DB (GeoLite2 Country/City/ASN) tagging destinations with dest_country/dest_asn labels (repeatable)")
+	proxyCmd.Flags().StringVar(&proxyTracePath, "trace-path", "", "Append the session trace as JSONL (one line per request) to this file on shutdown")
+	proxyCmd.Flags().StringSliceVar(&proxyStripReqHeaders, "strip-request-header", nil, "Request header removed before forwarding (repeatable)")
+	proxyCmd.Flags().StringSliceVar(&proxyStripRespHeaders, "strip-response-header", nil, "Response header removed before returning to the client (repeatable)")
+	proxyCmd.Flags().IntVar(&proxyMaxHeaderBytes, "max-header-bytes", 0, "Reject requests whose headers exceed this many bytes with 431 (0 disables)")
+	proxyCmd.Flags().BoolVar(&proxyScanHeaders, "scan-headers", false, "Scan request header values for canaries like body content")
+	proxyCmd.Flags().StringVar(&proxyAgentHdr, "agent-header", "", "Request header carrying a per-request agent identity, e.g. X-Agent-ID (cannot be combined with --agent)")
+	proxyCmd.MarkFlagsMutuallyExclusive("agent", "agent-header")
 }
 
 var proxyCmd = &cobra.Command{
@@ -51,17 +75,40 @@ func runProxy(cmd *cobra.Command, args []string) error {
 		AgentID:      proxyAgent,
 		Actor:        map[string]any{"proxy": "chainwatch", "port": proxyPort},
 		AuditLogPath: proxyAuditLog,
+		AgentHeader:  proxyAgentHdr,
+
+		RequirePolicyFile:       proxyRequirePolicy,
+		InsecureSkipVerifyHosts: proxyInsecureHosts,
+		BlockStatus:             proxyBlockStatus,
+		GeoIPDBs:                proxyGeoIPDBs,
+		TracePath:               proxyTracePath,
+		StripRequestHeaders:     proxyStripReqHeaders,
+		StripResponseHeaders:    proxyStripRespHeaders,
+		MaxHeaderBytes:          proxyMaxHeaderBytes,
+		ScanHeaderValues:        proxyScanHeaders,
 	}
 
 	srv, err := proxy.NewServer(cfg)
 	if err != nil {
 		return fmt.Errorf("failed to create proxy server: %w", err)
 	}
-	defer srv.Close()
+	defer func() {
+		if err := srv.Close(); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+		}
+	}()
 
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	// Reload policy, denylist, and profile on file change or SIGHUP
+	reloader, err := server.NewReloader(srv, []string{proxyPolicy, proxyDenylist})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: hot-reload disabled: %v\n", err)
+	} else {
+		go reloader.Run(ctx)
+	}
+
 	// Handle graceful shutdown
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, syscall.SIGINT, syscall.SIGTERM)
@@ -74,6 +121,9 @@ func runProxy(cmd *cobra.Command, args []string) error {
 
 	fmt.Printf("chainwatch proxy listening on :%d\n", proxyPort)
 	fmt.Printf("Set HTTP_PROXY=http://localhost:%d to route agent traffic\n", proxyPort)
+	for _, h := range proxyInsecureHosts {
+		fmt.Fprintf(os.Stderr, "WARNING: TLS certificate verification disabled for host %s\n", h)
+	}
 	fmt.Println("Press Ctrl+C to stop")
 	fmt.Println()
 
@@ -85,6 +135,7 @@ func runProxy(cmd *cobra.Command, args []string) error {
 	summary := srv.TraceSummary()
 	out, _ := json.MarshalIndent(summary, "", "  ")
 	fmt.Println(string(out))
+	printAlertStats(os.Stdout)
 
 	return err
 }
@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ppiankov/chainwatch/internal/configschema"
+)
+
+func init() {
+	rootCmd.AddCommand(schemaCmd)
+}
+
+var schemaCmd = &cobra.Command{
+	Use:       "schema <policy|profile>",
+	Short:     "Emit JSON Schema for policy or profile configs",
+	Long:      "Prints a JSON Schema derived from the config structs.\nPoint your editor's YAML language server at it for validation and autocomplete.",
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: configschema.Names(),
+	RunE:      runSchema,
+}
+
+func runSchema(cmd *cobra.Command, args []string) error {
+	s := configschema.ByName(args[0])
+	if s == nil {
+		return fmt.Errorf("unknown schema %q (available: %s)", args[0], strings.Join(configschema.Names(), ", "))
+	}
+
+	out, err := configschema.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to render schema: %w", err)
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), string(out))
+	return nil
+}
@@ -18,6 +18,7 @@ var (
 	servePolicy   string
 	serveProfile  string
 	serveAuditLog string
+	serveListen   string
 )
 
 func init() {
@@ -27,6 +28,7 @@ func init() {
 	serveCmd.Flags().StringVar(&servePolicy, "policy", "", "Path to policy YAML")
 	serveCmd.Flags().StringVar(&serveProfile, "profile", "", "Safety profile to apply (e.g., clawbot)")
 	serveCmd.Flags().StringVar(&serveAuditLog, "audit-log", "", "Path to audit log JSONL file")
+	serveCmd.Flags().StringVar(&serveListen, "listen", "", "Listen address, e.g. unix:///run/chainwatch.sock or 127.0.0.1:50051 (overrides --port)")
 }
 
 var serveCmd = &cobra.Command{
@@ -43,6 +45,7 @@ func runServe(cmd *cobra.Command, args []string) error {
 		DenylistPath: serveDenylist,
 		ProfileName:  serveProfile,
 		AuditLogPath: serveAuditLog,
+		Listen:       serveListen,
 	}
 
 	srv, err := server.New(cfg)
@@ -75,7 +78,11 @@ func runServe(cmd *cobra.Command, args []string) error {
 		srv.GracefulStop()
 	}()
 
-	fmt.Fprintf(os.Stderr, "chainwatch policy server listening on :%d\n", servePort)
+	if serveListen != "" {
+		fmt.Fprintf(os.Stderr, "chainwatch policy server listening on %s\n", serveListen)
+	} else {
+		fmt.Fprintf(os.Stderr, "chainwatch policy server listening on :%d\n", servePort)
+	}
 	if serveProfile != "" {
 		fmt.Fprintf(os.Stderr, "Profile: %s\n", serveProfile)
 	}
@@ -84,5 +91,7 @@ func runServe(cmd *cobra.Command, args []string) error {
 	}
 	fmt.Fprintln(os.Stderr)
 
-	return srv.Serve()
+	err = srv.Serve()
+	printAlertStats(os.Stderr)
+	return err
 }
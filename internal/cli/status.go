@@ -0,0 +1,246 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ppiankov/chainwatch/internal/alert"
+	"github.com/ppiankov/chainwatch/internal/approval"
+	"github.com/ppiankov/chainwatch/internal/audit"
+	"github.com/ppiankov/chainwatch/internal/breakglass"
+	"github.com/ppiankov/chainwatch/internal/denylist"
+	"github.com/ppiankov/chainwatch/internal/policy"
+	"github.com/ppiankov/chainwatch/internal/profile"
+)
+
+var (
+	statusPolicy   string
+	statusProfile  []string
+	statusAuditLog string
+	statusWindow   time.Duration
+	statusJSON     bool
+)
+
+func init() {
+	rootCmd.AddCommand(statusCmd)
+	statusCmd.Flags().StringVar(&statusPolicy, "policy", "", "Path to policy YAML (default: ~/.chainwatch/policy.yaml)")
+	statusCmd.Flags().StringSliceVar(&statusProfile, "profile", nil, "Active safety profile(s); repeat or comma-separate to stack")
+	statusCmd.Flags().StringVar(&statusAuditLog, "audit-log", "", "Path to audit log JSONL file")
+	statusCmd.Flags().DurationVar(&statusWindow, "window", time.Hour, "Time window for the recent denial rate")
+	statusCmd.Flags().BoolVar(&statusJSON, "json", false, "Print status as JSON")
+}
+
+var statusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show live enforcement state at a glance",
+	Long: "Summarizes the enforcement state an operator cares about: loaded policy hash,\n" +
+		"enforcement mode, active profiles, pending approvals, unused break-glass tokens,\n" +
+		"spooled alerts awaiting redelivery, audit log size, and the denial rate over\n" +
+		"--window. Use doctor to validate config.",
+	RunE: runStatus,
+}
+
+// statusReport is the status summary; it is also the --json output.
+type statusReport struct {
+	PolicyHash      string              `json:"policy_hash"`
+	EnforcementMode string              `json:"enforcement_mode"`
+	Profiles        []string            `json:"profiles"`
+	PendingApproval int                 `json:"pending_approvals"`
+	BreakGlass      []statusToken       `json:"break_glass_tokens"`
+	AlertSpools     []alert.SpoolStatus `json:"alert_spools"`
+	AuditLog        *auditLogStatus     `json:"audit_log,omitempty"`
+}
+
+// statusToken is an unused break-glass token.
+type statusToken struct {
+	ID        string    `json:"id"`
+	Reason    string    `json:"reason"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// auditLogStatus summarizes the audit log and its recent decisions.
+type auditLogStatus struct {
+	Path       string  `json:"path"`
+	SizeBytes  int64   `json:"size_bytes"`
+	Window     string  `json:"window"`
+	Decisions  int     `json:"decisions"`
+	Denials    int     `json:"denials"`
+	DenialRate float64 `json:"denial_rate"`
+}
+
+func runStatus(cmd *cobra.Command, args []string) error {
+	report, err := collectStatus(time.Now().UTC())
+	if err != nil {
+		return err
+	}
+	if statusJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	printStatusReport(os.Stdout, report)
+	return nil
+}
+
+// collectStatus reads the policy, profiles, stores, and audit log.
+func collectStatus(now time.Time) (*statusReport, error) {
+	cfg, hash, err := policy.LoadConfigWithHash(statusPolicy)
+	if err != nil {
+		return nil, err
+	}
+	names := profile.SplitNames(strings.Join(statusProfile, ","))
+	if len(names) > 0 {
+		cfg, err = profile.ApplyStack(names, denylist.NewDefault(), cfg)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	report := &statusReport{
+		PolicyHash:      hash,
+		EnforcementMode: cfg.EnforcementMode,
+		Profiles:        names,
+		BreakGlass:      []statusToken{},
+		AlertSpools:     alert.SpoolStatuses(cfg.Alerts),
+	}
+	if report.AlertSpools == nil {
+		report.AlertSpools = []alert.SpoolStatus{}
+	}
+	if report.Profiles == nil {
+		report.Profiles = []string{}
+	}
+
+	approvals, err := approval.Open(approval.DefaultDir(), cfg.ApprovalStore)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open approval store: %w", err)
+	}
+	list, err := approvals.List()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list approvals: %w", err)
+	}
+	for _, a := range list {
+		if a.Status == approval.StatusPending {
+			report.PendingApproval++
+		}
+	}
+
+	tokens, err := breakglass.NewStore(breakglass.DefaultDir())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breakglass store: %w", err)
+	}
+	all, err := tokens.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, t := range all {
+		if t.IsActive() {
+			report.BreakGlass = append(report.BreakGlass, statusToken{ID: t.ID, Reason: t.Reason, ExpiresAt: t.ExpiresAt})
+		}
+	}
+
+	if statusAuditLog != "" {
+		report.AuditLog, err = summarizeAuditLog(statusAuditLog, now.Add(-statusWindow))
+		if err != nil {
+			return nil, err
+		}
+		report.AuditLog.Window = statusWindow.String()
+	}
+	return report, nil
+}
+
+// summarizeAuditLog counts policy decisions recorded since the given time.
+// Event entries (break-glass, approvals, shadow calls) carry a type and are
+// not decisions, so they do not count toward the rate.
+func summarizeAuditLog(path string, since time.Time) (*auditLogStatus, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open audit log: %w", err)
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("stat audit log: %w", err)
+	}
+	summary := &auditLogStatus{Path: path, SizeBytes: info.Size()}
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry audit.AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue // skip malformed lines
+		}
+		if entry.Type != "" || entry.Decision == "" {
+			continue
+		}
+		ts, err := time.Parse(audit.TimestampFormat, entry.Timestamp)
+		if err != nil || ts.Before(since) {
+			continue
+		}
+		summary.Decisions++
+		if strings.EqualFold(entry.Decision, "deny") {
+			summary.Denials++
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read audit log: %w", err)
+	}
+	if summary.Decisions > 0 {
+		summary.DenialRate = float64(summary.Denials) / float64(summary.Decisions)
+	}
+	return summary, nil
+}
+
+// printAlertStats prints this process's alert delivery counters when any
+// alert was sent, so an operator sees drops and evictions on shutdown.
+func printAlertStats(w io.Writer) {
+	stats := alert.Stats()
+	if stats == (alert.DeliveryStats{}) {
+		return
+	}
+	fmt.Fprintf(w, "Alerts: %d delivered, %d spooled, %d redelivered, %d dropped, %d evicted from spool\n",
+		stats.Delivered, stats.Spooled, stats.Redelivered, stats.Dropped, stats.Evicted)
+}
+
+func printStatusReport(w io.Writer, r *statusReport) {
+	profiles := "none"
+	if len(r.Profiles) > 0 {
+		profiles = strings.Join(r.Profiles, ", ")
+	}
+
+	fmt.Fprintf(w, "%-20s %s\n", "Policy hash:", r.PolicyHash)
+	fmt.Fprintf(w, "%-20s %s\n", "Enforcement mode:", r.EnforcementMode)
+	fmt.Fprintf(w, "%-20s %s\n", "Profiles:", profiles)
+	fmt.Fprintf(w, "%-20s %d\n", "Pending approvals:", r.PendingApproval)
+	fmt.Fprintf(w, "%-20s %d\n", "Break-glass tokens:", len(r.BreakGlass))
+	for _, t := range r.BreakGlass {
+		fmt.Fprintf(w, "  %-18s expires %s  %s\n", t.ID, t.ExpiresAt.Format(time.RFC3339), t.Reason)
+	}
+	if len(r.AlertSpools) > 0 {
+		pending := 0
+		for _, s := range r.AlertSpools {
+			pending += s.Pending
+		}
+		fmt.Fprintf(w, "%-20s %d\n", "Spooled alerts:", pending)
+		for _, s := range r.AlertSpools {
+			fmt.Fprintf(w, "  %-18s %d of max %d  %s\n", s.Channel, s.Pending, s.Max, s.Dir)
+		}
+	}
+
+	if r.AuditLog == nil {
+		fmt.Fprintf(w, "%-20s %s\n", "Audit log:", "not specified (use --audit-log)")
+		return
+	}
+	a := r.AuditLog
+	fmt.Fprintf(w, "%-20s %s (%d bytes)\n", "Audit log:", a.Path, a.SizeBytes)
+	fmt.Fprintf(w, "%-20s %.1f%% (%d of %d decisions in last %s)\n",
+		"Denial rate:", a.DenialRate*100, a.Denials, a.Decisions, a.Window)
+}
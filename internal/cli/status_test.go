@@ -0,0 +1,160 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ppiankov/chainwatch/internal/approval"
+	"github.com/ppiankov/chainwatch/internal/audit"
+	"github.com/ppiankov/chainwatch/internal/breakglass"
+)
+
+func resetStatusFlags(t *testing.T) {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+	statusPolicy = ""
+	statusProfile = nil
+	statusAuditLog = ""
+	statusWindow = time.Hour
+	statusJSON = false
+}
+
+func TestStatusReflectsApprovalAndBreakGlass(t *testing.T) {
+	resetStatusFlags(t)
+
+	approvals, err := approval.NewStore(approval.DefaultDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := approvals.Request("deploy-prod", "needs review", "rule.deploy", "kubectl apply", "agent-1"); err != nil {
+		t.Fatal(err)
+	}
+	tokens, err := breakglass.NewStore(breakglass.DefaultDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	token, err := tokens.Create("incident 42", 10*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := collectStatus(time.Now().UTC())
+	if err != nil {
+		t.Fatalf("collectStatus failed: %v", err)
+	}
+	if report.PendingApproval != 1 {
+		t.Errorf("expected 1 pending approval, got %d", report.PendingApproval)
+	}
+	if len(report.BreakGlass) != 1 || report.BreakGlass[0].ID != token.ID {
+		t.Errorf("expected break-glass token %s, got %+v", token.ID, report.BreakGlass)
+	}
+	if !strings.HasPrefix(report.PolicyHash, "sha256:") {
+		t.Errorf("expected sha256 policy hash, got %q", report.PolicyHash)
+	}
+	if report.EnforcementMode != "guarded" {
+		t.Errorf("expected default guarded mode, got %q", report.EnforcementMode)
+	}
+
+	var buf bytes.Buffer
+	printStatusReport(&buf, report)
+	out := buf.String()
+	for _, want := range []string{"Pending approvals:   1", "Break-glass tokens:  1", token.ID} {
+		if !strings.Contains(out, want) {
+			t.Errorf("report missing %q:\n%s", want, out)
+		}
+	}
+
+	// A used token no longer counts.
+	if err := tokens.Consume(token.ID); err != nil {
+		t.Fatal(err)
+	}
+	report, err = collectStatus(time.Now().UTC())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.BreakGlass) != 0 {
+		t.Errorf("expected no unused tokens after consume, got %+v", report.BreakGlass)
+	}
+}
+
+func TestStatusDenialRate(t *testing.T) {
+	resetStatusFlags(t)
+
+	now := time.Now().UTC()
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	entries := []audit.AuditEntry{
+		{Timestamp: now.Add(-2 * time.Hour).Format(audit.TimestampFormat), Decision: "deny"},
+		{Timestamp: now.Add(-time.Minute).Format(audit.TimestampFormat), Decision: "allow"},
+		{Timestamp: now.Add(-time.Minute).Format(audit.TimestampFormat), Decision: "deny"},
+		{Timestamp: now.Add(-time.Minute).Format(audit.TimestampFormat), Decision: "allow"},
+		{Timestamp: now.Add(-time.Minute).Format(audit.TimestampFormat), Decision: "allow"},
+		{Timestamp: now.Add(-time.Minute).Format(audit.TimestampFormat), Type: "break_glass_used", Decision: "allow"},
+	}
+	var lines []byte
+	for _, e := range entries {
+		b, _ := json.Marshal(e)
+		lines = append(append(lines, b...), '\n')
+	}
+	if err := os.WriteFile(path, lines, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	statusAuditLog = path
+	report, err := collectStatus(now)
+	if err != nil {
+		t.Fatalf("collectStatus failed: %v", err)
+	}
+	a := report.AuditLog
+	if a == nil {
+		t.Fatal("expected audit log summary")
+	}
+	if a.SizeBytes != int64(len(lines)) {
+		t.Errorf("expected size %d, got %d", len(lines), a.SizeBytes)
+	}
+	if a.Decisions != 4 || a.Denials != 1 {
+		t.Errorf("expected 1 of 4 decisions denied, got %d of %d", a.Denials, a.Decisions)
+	}
+	if a.DenialRate != 0.25 {
+		t.Errorf("expected denial rate 0.25, got %v", a.DenialRate)
+	}
+}
+
+func TestStatusReportsAlertSpool(t *testing.T) {
+	resetStatusFlags(t)
+	spoolDir := t.TempDir()
+	statusPolicy = filepath.Join(t.TempDir(), "policy.yaml")
+	policyYAML := "alerts:\n  - url: http://127.0.0.1:1\n    spool_dir: " + spoolDir + "\n    spool_max: 5\n"
+	if err := os.WriteFile(statusPolicy, []byte(policyYAML), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err := collectStatus(time.Now().UTC())
+	if err != nil {
+		t.Fatalf("collectStatus failed: %v", err)
+	}
+	if len(report.AlertSpools) != 1 || report.AlertSpools[0].Pending != 0 || report.AlertSpools[0].Max != 5 {
+		t.Fatalf("expected one empty spool capped at 5, got %+v", report.AlertSpools)
+	}
+	// A spooled alert left behind by a proxy that could not deliver it.
+	if err := os.MkdirAll(report.AlertSpools[0].Dir, 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(report.AlertSpools[0].Dir, "00000000000000000001-x.json"), []byte("{}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	report, err = collectStatus(time.Now().UTC())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	printStatusReport(&buf, report)
+	if out := buf.String(); !strings.Contains(out, "Spooled alerts:      1") || !strings.Contains(out, "1 of max 5") {
+		t.Errorf("report missing spool backlog:\n%s", out)
+	}
+}
@@ -0,0 +1,58 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"github.com/ppiankov/chainwatch/internal/client"
+	"github.com/ppiankov/chainwatch/internal/model"
+)
+
+var (
+	traceRemote   string
+	traceOperator string
+)
+
+func init() {
+	rootCmd.AddCommand(traceCmd)
+	traceCmd.AddCommand(traceResetCmd)
+
+	traceResetCmd.Flags().StringVar(&traceRemote, "remote", "localhost:50051", "Policy server address")
+	traceResetCmd.Flags().StringVar(&traceOperator, "operator", os.Getenv("USER"), "Operator identity recorded in the audit log")
+}
+
+var traceCmd = &cobra.Command{
+	Use:   "trace",
+	Short: "Manage traces on a policy server",
+}
+
+var traceResetCmd = &cobra.Command{
+	Use:   "reset <trace-id>",
+	Short: "Reset the accumulated risk of a trace on a policy server",
+	Long: "Clears the accumulated state of a trace held by a running chainwatch serve\n" +
+		"instance: zones entered, seen sources, rate-limit counters and action count.\n" +
+		"The next action in the trace evaluates from the base tier. The reset is\n" +
+		"recorded in the server's audit log with the operator identity.",
+	Args: cobra.ExactArgs(1),
+	RunE: runTraceReset,
+}
+
+func runTraceReset(cmd *cobra.Command, args []string) error {
+	c, err := client.New(traceRemote)
+	if err != nil {
+		return fmt.Errorf("failed to connect to remote server: %w", err)
+	}
+	defer c.Close()
+
+	found, prev, err := c.ResetTrace(args[0], model.ResetScopeAll, traceOperator)
+	if err != nil {
+		return fmt.Errorf("reset failed: %w", err)
+	}
+	if !found {
+		return fmt.Errorf("trace %q not found on %s", args[0], traceRemote)
+	}
+	fmt.Printf("Reset trace %s (%d actions counted before reset)\n", args[0], prev)
+	return nil
+}
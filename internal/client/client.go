@@ -7,9 +7,11 @@ import (
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 
 	pb "github.com/ppiankov/chainwatch/api/proto/chainwatch/v1"
 	"github.com/ppiankov/chainwatch/internal/approval"
+	"github.com/ppiankov/chainwatch/internal/identity"
 	"github.com/ppiankov/chainwatch/internal/model"
 )
 
@@ -62,11 +64,12 @@ func (c *Client) Evaluate(action *model.Action, purpose string, agentID string)
 }
 
 // Approve grants approval for a pending action via the remote server.
-func (c *Client) Approve(key string, duration time.Duration) error {
+// reason is the justification, required for require_reason keys.
+func (c *Client) Approve(key string, duration time.Duration, reason string) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	req := &pb.ApproveRequest{Key: key}
+	req := &pb.ApproveRequest{Key: key, Reason: reason}
 	if duration > 0 {
 		req.Duration = duration.String()
 	}
@@ -109,6 +112,43 @@ func (c *Client) ListPending() ([]approval.Approval, error) {
 	return result, nil
 }
 
+// ResetTrace clears the state of a trace on the remote server: everything
+// for model.ResetScopeAll, or only the action budget counter for
+// model.ResetScopeActionCount. A non-empty operator is sent as the caller
+// identity recorded in the audit log. Returns whether the trace was known
+// and its action count before the reset.
+func (c *Client) ResetTrace(traceID, scope, operator string) (bool, int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if operator != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, identity.DefaultAgentHeader, operator)
+	}
+
+	resp, err := c.client.ResetTrace(ctx, &pb.ResetTraceRequest{TraceId: traceID, Scope: scope})
+	if err != nil {
+		return false, 0, err
+	}
+	return resp.Found, int(resp.PreviousActionCount), nil
+}
+
+// ReloadProfile switches the remote server to the named profile stack, or
+// re-applies its current one when name is empty. A non-empty operator is
+// sent as the caller identity recorded in the audit log. Returns the
+// previously active profile.
+func (c *Client) ReloadProfile(name, operator string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if operator != "" {
+		ctx = metadata.AppendToOutgoingContext(ctx, identity.DefaultAgentHeader, operator)
+	}
+
+	resp, err := c.client.ReloadProfile(ctx, &pb.ReloadProfileRequest{Profile: name})
+	if err != nil {
+		return "", err
+	}
+	return resp.PreviousProfile, nil
+}
+
 // Close closes the gRPC connection.
 func (c *Client) Close() error {
 	return c.conn.Close()
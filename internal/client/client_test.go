@@ -174,7 +174,7 @@ rules:
 	}
 
 	// Approve via client
-	if err := c.Approve("salary_client_test", 5*time.Minute); err != nil {
+	if err := c.Approve("salary_client_test", 5*time.Minute, ""); err != nil {
 		t.Fatalf("Approve: %v", err)
 	}
 
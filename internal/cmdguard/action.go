@@ -1,14 +1,19 @@
 package cmdguard
 
 import (
+	"path"
 	"strings"
 
 	"github.com/ppiankov/chainwatch/internal/model"
 )
 
 // buildActionFromCommand maps a command invocation to a chainwatch Action.
+// Transfer and delete commands also record their file operands in Params
+// as sources and destination, or targets.
 // The tool name "command" activates denylist.isCommandTool() routing.
-func buildActionFromCommand(name string, args []string) *model.Action {
+// passthrough names env vars the operator keeps for commands; references to
+// them are not credential references unless they are hard secrets.
+func buildActionFromCommand(name string, args []string, passthrough []string) *model.Action {
 	var fullCommand string
 	if len(args) > 0 {
 		fullCommand = name + " " + strings.Join(args, " ")
@@ -16,17 +21,29 @@ func buildActionFromCommand(name string, args []string) *model.Action {
 		fullCommand = name
 	}
 
-	sensitivity, tags := classifyCommandSensitivity(fullCommand)
+	sensitivity, tags := classifyCommandSensitivity(fullCommand, passthrough)
 	egress := model.EgressInternal
 	if isNetworkCommand(fullCommand) {
 		egress = model.EgressExternal
 	}
 
+	params := map[string]any{"name": name, "args": args}
+	if argv := stripCommandWrappers(append([]string{name}, args...)); len(argv) > 0 {
+		sources, destination, targets := FileOperands(path.Base(argv[0]), argv[1:])
+		if destination != "" {
+			params["sources"] = sources
+			params["destination"] = destination
+		}
+		if len(targets) > 0 {
+			params["targets"] = targets
+		}
+	}
+
 	return &model.Action{
 		Tool:      "command",
 		Resource:  fullCommand,
 		Operation: "execute",
-		Params:    map[string]any{"name": name, "args": args},
+		Params:    params,
 		RawMeta: map[string]any{
 			"sensitivity": string(sensitivity),
 			"tags":        toAnySlice(tags),
@@ -35,11 +52,12 @@ func buildActionFromCommand(name string, args []string) *model.Action {
 			"egress":      string(egress),
 			"destination": "",
 		},
+		Labels: CredentialReferenceLabels(fullCommand, passthrough...),
 	}
 }
 
 // classifyCommandSensitivity returns sensitivity level and tags for a command.
-func classifyCommandSensitivity(cmd string) (model.Sensitivity, []string) {
+func classifyCommandSensitivity(cmd string, passthrough []string) (model.Sensitivity, []string) {
 	lower := strings.ToLower(cmd)
 
 	// Destructive patterns
@@ -50,6 +68,11 @@ func classifyCommandSensitivity(cmd string) (model.Sensitivity, []string) {
 		}
 	}
 
+	// References to sensitive env vars, e.g. echo $GROQ_API_KEY
+	if len(SensitiveEnvRefs(cmd, passthrough...)) > 0 {
+		return model.SensHigh, []string{TagCredentialReference}
+	}
+
 	// Credential patterns
 	credential := []string{"sudo", "passwd", "ssh-keygen", "chpasswd"}
 	for _, p := range credential {
@@ -71,6 +94,11 @@ func classifyCommandSensitivity(cmd string) (model.Sensitivity, []string) {
 		}
 	}
 
+	// System logs: operational data that may carry hostnames and user IDs
+	if strings.Contains(lower, "/var/log/") {
+		return model.SensMedium, []string{"system_log"}
+	}
+
 	return model.SensLow, nil
 }
 
@@ -0,0 +1,22 @@
+package cmdguard
+
+import "context"
+
+type confirmTokenKey struct{}
+
+// WithConfirmToken returns a context that presents token to Run. An
+// approved irreversible command under confirm_irreversible is blocked once
+// with a confirm token on its BlockedError; the retry must carry that
+// token to execute.
+func WithConfirmToken(ctx context.Context, token string) context.Context {
+	if token == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, confirmTokenKey{}, token)
+}
+
+// confirmTokenFrom returns the confirm token carried by ctx, if any.
+func confirmTokenFrom(ctx context.Context) string {
+	token, _ := ctx.Value(confirmTokenKey{}).(string)
+	return token
+}
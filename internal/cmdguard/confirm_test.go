@@ -0,0 +1,67 @@
+package cmdguard
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ppiankov/chainwatch/internal/model"
+)
+
+func TestConfirmTokenForApprovedIrreversibleCommand(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	policyYAML := `min_tier: 3
+confirm_irreversible:
+  enabled: true
+  ttl: 1m
+rules:
+  - purpose: "*"
+    resource_pattern: "*prod-db*"
+    decision: require_approval
+    approval_key: drop_prod
+`
+	policyPath := filepath.Join(dir, "policy.yaml")
+	if err := os.WriteFile(policyPath, []byte(policyYAML), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	g, err := NewGuard(Config{Purpose: "test", PolicyPath: policyPath, Actor: map[string]any{"test": true}})
+	if err != nil {
+		t.Fatalf("failed to create guard: %v", err)
+	}
+	defer g.Close()
+	ctx := context.Background()
+
+	_, err = g.Run(ctx, "echo", []string{"drop", "prod-db"}, nil)
+	if blocked := requireBlocked(t, err); blocked.Decision != model.RequireApproval || blocked.ConfirmToken != "" {
+		t.Fatalf("expected plain require_approval, got %s token=%q", blocked.Decision, blocked.ConfirmToken)
+	}
+	if err := g.approvals.Approve("drop_prod", 0, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// Approved, but the first retry only yields a confirm token.
+	_, err = g.Run(ctx, "echo", []string{"drop", "prod-db"}, nil)
+	first := requireBlocked(t, err)
+	if first.ConfirmToken == "" || first.PolicyID != "approval.confirm" {
+		t.Fatalf("expected confirm token, got %s (%s) token=%q", first.Decision, first.PolicyID, first.ConfirmToken)
+	}
+
+	// The token is bound to the exact action.
+	if _, err := g.Run(WithConfirmToken(ctx, first.ConfirmToken), "echo", []string{"drop", "prod-db", "--force"}, nil); err == nil {
+		t.Fatal("confirm token accepted for a different action")
+	}
+
+	res, err := g.Run(WithConfirmToken(ctx, first.ConfirmToken), "echo", []string{"drop", "prod-db"}, nil)
+	if err != nil {
+		t.Fatalf("expected confirmed command to run, got %v", err)
+	}
+	if res.Stdout != "drop prod-db\n" {
+		t.Errorf("unexpected stdout %q", res.Stdout)
+	}
+
+	if _, err := g.Run(WithConfirmToken(ctx, first.ConfirmToken), "echo", []string{"drop", "prod-db"}, nil); err == nil {
+		t.Fatal("confirm token reused after the approval was consumed")
+	}
+}
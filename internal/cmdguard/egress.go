@@ -0,0 +1,224 @@
+package cmdguard
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ppiankov/chainwatch/internal/alert"
+	"github.com/ppiankov/chainwatch/internal/audit"
+	"github.com/ppiankov/chainwatch/internal/model"
+)
+
+var (
+	// curl -v request line and headers.
+	curlRequestLine   = regexp.MustCompile(`^> ([A-Z]+) \S+ HTTP/`)
+	curlHostHeader    = regexp.MustCompile(`(?i)^> Host: (\S+)`)
+	curlContentLength = regexp.MustCompile(`(?i)^> Content-Length: (\d+)`)
+	curlUploadSent    = regexp.MustCompile(`upload completely sent off: (\d+)`)
+	curlConnected     = regexp.MustCompile(`^\* Connected to (\S+)`)
+
+	// wget progress: "--2024-01-01 10:00:00--  https://host/path" and
+	// "Connecting to host (host)|1.2.3.4|:443... connected."
+	wgetFetch   = regexp.MustCompile(`^--\S+ \S+--\s+(\S+)`)
+	wgetConnect = regexp.MustCompile(`^Connecting to ([^\s|(]+)`)
+
+	outputURL = regexp.MustCompile(`\bhttps?://[^\s"'<>|]+`)
+)
+
+// outputEgress is what command output reveals about data the command sent.
+type outputEgress struct {
+	Hosts     []string // external hosts the command contacted
+	Method    string   // upload request method, if any
+	BytesSent int64    // largest upload size reported
+}
+
+// detected reports whether the output shows an upload to an external host
+// of at least minBytes.
+func (e outputEgress) detected(minBytes int64) bool {
+	if len(e.Hosts) == 0 {
+		return false
+	}
+	if e.Method == "" && e.BytesSent == 0 {
+		return false
+	}
+	return e.BytesSent >= minBytes
+}
+
+// detectOutputEgress scans stdout and stderr for signs the command sent
+// data out: curl -v request lines and upload counts, the curl progress
+// meter, and wget connection lines. URLs and hosts on loopback or private
+// addresses are ignored.
+func detectOutputEgress(stdout, stderr string) outputEgress {
+	var e outputEgress
+	hosts := make(map[string]bool)
+	addHost := func(h string) {
+		if h = externalOutputHost(h); h != "" {
+			hosts[h] = true
+		}
+	}
+	addBytes := func(s string) {
+		if n, err := strconv.ParseInt(s, 10, 64); err == nil && n > e.BytesSent {
+			e.BytesSent = n
+		}
+	}
+
+	progress := false
+	for _, line := range strings.FieldsFunc(stdout+"\n"+stderr, func(r rune) bool { return r == '\n' || r == '\r' }) {
+		line = strings.TrimSpace(line)
+		if m := curlRequestLine.FindStringSubmatch(line); m != nil && isUploadMethod(m[1]) {
+			e.Method = m[1]
+		}
+		if m := curlHostHeader.FindStringSubmatch(line); m != nil {
+			addHost(m[1])
+		}
+		if m := curlContentLength.FindStringSubmatch(line); m != nil {
+			addBytes(m[1])
+		}
+		if m := curlUploadSent.FindStringSubmatch(line); m != nil {
+			addBytes(m[1])
+		}
+		if m := curlConnected.FindStringSubmatch(line); m != nil {
+			addHost(m[1])
+		}
+		if m := wgetConnect.FindStringSubmatch(line); m != nil {
+			addHost(m[1])
+		}
+		if m := wgetFetch.FindStringSubmatch(line); m != nil {
+			addHost(m[1])
+		}
+		if strings.Contains(line, "% Total") && strings.Contains(line, "Xferd") {
+			progress = true
+			continue
+		}
+		if progress {
+			if n, ok := progressUploaded(line); ok && n > e.BytesSent {
+				e.BytesSent = n
+			}
+		}
+		for _, u := range outputURL.FindAllString(line, -1) {
+			addHost(u)
+		}
+	}
+
+	for h := range hosts {
+		e.Hosts = append(e.Hosts, h)
+	}
+	sort.Strings(e.Hosts)
+	return e
+}
+
+// progressUploaded reads the uploaded byte count from a curl progress meter
+// row: "% Total  % Received % Xferd ..." puts it in the sixth column.
+func progressUploaded(line string) (int64, bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 6 {
+		return 0, false
+	}
+	return parseSize(fields[5])
+}
+
+// parseSize parses a curl size such as 512, 1536k or 2.5M.
+func parseSize(s string) (int64, bool) {
+	mult := 1.0
+	switch {
+	case strings.HasSuffix(s, "k"):
+		mult, s = 1<<10, strings.TrimSuffix(s, "k")
+	case strings.HasSuffix(s, "M"):
+		mult, s = 1<<20, strings.TrimSuffix(s, "M")
+	case strings.HasSuffix(s, "G"):
+		mult, s = 1<<30, strings.TrimSuffix(s, "G")
+	}
+	f, err := strconv.ParseFloat(s, 64)
+	if err != nil || f < 0 {
+		return 0, false
+	}
+	return int64(f * mult), true
+}
+
+func isUploadMethod(method string) bool {
+	return method == "POST" || method == "PUT" || method == "PATCH"
+}
+
+// externalOutputHost returns the lowercased host of a URL or host[:port]
+// string, or "" when it is local, private or not a host.
+func externalOutputHost(s string) string {
+	host := s
+	if strings.Contains(s, "://") {
+		u, err := url.Parse(s)
+		if err != nil {
+			return ""
+		}
+		host = u.Hostname()
+	} else if h, _, err := net.SplitHostPort(s); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.ToLower(strings.Trim(host, "[]")), ".")
+	if host == "" || host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ""
+	}
+	if ip := net.ParseIP(host); ip != nil {
+		if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsUnspecified() {
+			return ""
+		}
+		return host
+	}
+	if !strings.Contains(host, ".") {
+		return ""
+	}
+	return host
+}
+
+// checkOutputEgress re-examines a finished command's output when
+// output_egress is enabled. An upload to an external host is recorded as
+// a tier 3 output_egress audit entry and force-alerted: the command has
+// already run, so this closes the detection gap rather than blocking.
+func (g *Guard) checkOutputEgress(action *model.Action, stdout, stderr string) bool {
+	cfg := g.policyCfg.OutputEgress
+	if cfg == nil || !cfg.Enabled {
+		return false
+	}
+	e := detectOutputEgress(stdout, stderr)
+	if !e.detected(cfg.MinBytes) {
+		return false
+	}
+
+	method := e.Method
+	if method == "" {
+		method = "upload"
+	}
+	reason := fmt.Sprintf("command output shows %s of %d bytes to %s after execution",
+		method, e.BytesSent, strings.Join(e.Hosts, ", "))
+	ts := time.Now().UTC().Format("2006-01-02T15:04:05.000Z")
+
+	if g.auditLog != nil {
+		g.auditLog.Record(audit.AuditEntry{
+			Timestamp:  ts,
+			TraceID:    g.tracer.State.TraceID,
+			Action:     audit.AuditAction{Tool: "output_egress", Resource: action.Resource},
+			Decision:   "egress_detected",
+			Reason:     reason,
+			Tier:       3,
+			PolicyHash: g.policyHash,
+		})
+	}
+	if g.dispatcher != nil {
+		g.dispatcher.Dispatch(alert.AlertEvent{
+			Timestamp:  ts,
+			TraceID:    g.tracer.State.TraceID,
+			Tool:       "output_egress",
+			Resource:   action.Resource,
+			Decision:   "egress_detected",
+			Reason:     reason,
+			Tier:       3,
+			PolicyHash: g.policyHash,
+			Rule:       alert.RuleAlert{Mode: alert.RuleAlertForce},
+		})
+	}
+	return true
+}
@@ -0,0 +1,123 @@
+package cmdguard
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const curlVerbosePost = `* Connected to exfil.example.com (203.0.113.7) port 443
+> POST /collect HTTP/1.1
+> Host: exfil.example.com
+> User-Agent: curl/8.5.0
+> Content-Length: 2048
+* upload completely sent off: 2048 bytes
+< HTTP/1.1 200 OK
+`
+
+func TestDetectOutputEgress(t *testing.T) {
+	tests := []struct {
+		name     string
+		stdout   string
+		stderr   string
+		minBytes int64
+		want     bool
+		host     string
+	}{
+		{name: "curl verbose post", stderr: curlVerbosePost, want: true, host: "exfil.example.com"},
+		{name: "below min bytes", stderr: curlVerbosePost, minBytes: 4096, want: false},
+		{
+			name: "curl progress meter upload",
+			stdout: "uploading to https://drop.example.net/up\n" +
+				"  % Total    % Received % Xferd  Average Speed   Time    Time     Time  Current\n" +
+				"                                 Dload  Upload   Total   Spent    Left  Speed\n" +
+				"100 1536k  100    12  100 1536k     10  1300k  0:00:01  0:00:01 --:--:-- 1300k\n",
+			minBytes: 1 << 20,
+			want:     true,
+			host:     "drop.example.net",
+		},
+		{
+			name:   "wget download only",
+			stderr: "--2026-01-01 10:00:00--  https://mirror.example.org/pkg.tar.gz\nConnecting to mirror.example.org (mirror.example.org)|198.51.100.4|:443... connected.\n",
+			want:   false,
+		},
+		{
+			name:   "upload to private host",
+			stderr: strings.ReplaceAll(strings.ReplaceAll(curlVerbosePost, "exfil.example.com", "10.0.0.5"), "203.0.113.7", "10.0.0.5"),
+			want:   false,
+		},
+		{name: "url in plain output", stdout: "see https://docs.example.com/install for details\n", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := detectOutputEgress(tt.stdout, tt.stderr)
+			if got := e.detected(tt.minBytes); got != tt.want {
+				t.Fatalf("detected = %v, want %v (%+v)", got, tt.want, e)
+			}
+			if tt.host != "" && (len(e.Hosts) != 1 || e.Hosts[0] != tt.host) {
+				t.Errorf("expected host %s, got %v", tt.host, e.Hosts)
+			}
+		})
+	}
+}
+
+func newEgressGuard(t *testing.T, policyYAML string) (*Guard, string) {
+	t.Helper()
+	dir := t.TempDir()
+	policyPath := filepath.Join(dir, "policy.yaml")
+	if err := os.WriteFile(policyPath, []byte(policyYAML), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	auditPath := filepath.Join(dir, "audit.jsonl")
+	g, err := NewGuard(Config{Purpose: "test", PolicyPath: policyPath, AuditLogPath: auditPath, Actor: map[string]any{"test": true}})
+	if err != nil {
+		t.Fatalf("failed to create guard: %v", err)
+	}
+	t.Cleanup(func() { g.Close() })
+	return g, auditPath
+}
+
+func TestRunRecordsEgressSeenInOutput(t *testing.T) {
+	g, auditPath := newEgressGuard(t, "output_egress:\n  enabled: true\n  min_bytes: 1024\n")
+
+	// The command looks benign before execution; only its stderr reveals
+	// the external POST.
+	result, err := g.Run(context.Background(), "sh", []string{"-c", "cat >&2 <<'EOF'\n" + curlVerbosePost + "EOF"}, nil)
+	if err != nil {
+		t.Fatalf("expected command to run, got %v", err)
+	}
+	if !result.EgressDetected {
+		t.Fatal("expected egress detected from output")
+	}
+
+	data, err := os.ReadFile(auditPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	log := string(data)
+	if !strings.Contains(log, `"tool":"output_egress"`) || !strings.Contains(log, `"decision":"egress_detected"`) {
+		t.Fatalf("expected output_egress audit entry, got %s", log)
+	}
+	if !strings.Contains(log, "POST of 2048 bytes to exfil.example.com") || !strings.Contains(log, `"tier":3`) {
+		t.Errorf("expected tier 3 entry naming the upload, got %s", log)
+	}
+}
+
+func TestRunOutputEgressDisabledByDefault(t *testing.T) {
+	g, auditPath := newEgressGuard(t, "enforcement_mode: guarded\n")
+
+	result, err := g.Run(context.Background(), "sh", []string{"-c", "cat >&2 <<'EOF'\n" + curlVerbosePost + "EOF"}, nil)
+	if err != nil {
+		t.Fatalf("expected command to run, got %v", err)
+	}
+	if result.EgressDetected {
+		t.Error("expected no egress check without output_egress")
+	}
+	data, _ := os.ReadFile(auditPath)
+	if strings.Contains(string(data), "output_egress") {
+		t.Errorf("expected no output_egress entry, got %s", data)
+	}
+}
@@ -0,0 +1,66 @@
+package cmdguard
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// TagCredentialReference marks a command that references a sensitive env
+// var by name, e.g. `echo $GROQ_API_KEY`. Stripping the var from the
+// subprocess environment hides the value; this tag exposes the intent.
+const TagCredentialReference = "credential_reference"
+
+// envRefPattern matches $NAME and ${NAME...} references.
+var envRefPattern = regexp.MustCompile(`\$\{?([A-Za-z_][A-Za-z0-9_]*)`)
+
+// apiKeyEnvSuffixes mark env var names that hold API keys without a hard
+// secret marker, e.g. OPENAI_KEY or STRIPE_APIKEY.
+var apiKeyEnvSuffixes = []string{"_KEY", "_APIKEY"}
+
+// SensitiveEnvRefs returns the sensitive env var names a command
+// references, in order of first appearance. A name is sensitive when it is
+// a hard secret or ends in an API-key suffix. Names in passthrough
+// (case-insensitive) are the operator's declared non-secrets and are not
+// reported unless they are hard secrets, matching sanitizeEnv.
+func SensitiveEnvRefs(cmd string, passthrough ...string) []string {
+	var refs []string
+	seen := map[string]bool{}
+	for _, m := range envRefPattern.FindAllStringSubmatch(cmd, -1) {
+		name := m[1]
+		if seen[name] || !isSensitiveEnvName(strings.ToUpper(name), passthrough) {
+			continue
+		}
+		seen[name] = true
+		refs = append(refs, name)
+	}
+	return refs
+}
+
+// CredentialReferenceLabels returns action labels naming the sensitive env
+// vars a command references, so policy rules can select them with
+// `labels: {credential_reference: "*"}`. Returns nil when there are none.
+func CredentialReferenceLabels(cmd string, passthrough ...string) map[string]string {
+	refs := SensitiveEnvRefs(cmd, passthrough...)
+	if len(refs) == 0 {
+		return nil
+	}
+	return map[string]string{TagCredentialReference: strings.Join(refs, ",")}
+}
+
+// isSensitiveEnvName reports whether an upper-cased env var name is a hard
+// secret, or has an API-key suffix and is not in passthrough.
+func isSensitiveEnvName(upper string, passthrough []string) bool {
+	if isHardSecretEnv(upper) {
+		return true
+	}
+	if slices.ContainsFunc(passthrough, func(name string) bool { return strings.EqualFold(name, upper) }) {
+		return false
+	}
+	for _, suffix := range apiKeyEnvSuffixes {
+		if strings.HasSuffix(upper, suffix) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package cmdguard
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestSensitiveEnvRefs(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		want []string
+	}{
+		{"echo $GROQ_API_KEY", []string{"GROQ_API_KEY"}},
+		{"echo ${db_password:-none} $DB_PASSWORD", []string{"db_password", "DB_PASSWORD"}},
+		{"echo $AWS_SECRET_ACCESS_KEY $AWS_SECRET_ACCESS_KEY", []string{"AWS_SECRET_ACCESS_KEY"}},
+		{"echo $HOME $PATH ${USER}", nil},
+		{"echo GROQ_API_KEY", nil},
+		{"echo $OPENAI_KEY $STRIPE_APIKEY", []string{"OPENAI_KEY", "STRIPE_APIKEY"}},
+		// Prefix-stripped names that are not secrets are not references.
+		{"echo $AWS_REGION $CHAINWATCH_MODE $NULLBOT_MODEL", nil},
+	}
+	for _, tt := range tests {
+		if got := SensitiveEnvRefs(tt.cmd); !slices.Equal(got, tt.want) {
+			t.Errorf("SensitiveEnvRefs(%q) = %v, want %v", tt.cmd, got, tt.want)
+		}
+	}
+}
+
+func TestSensitiveEnvRefsPassthrough(t *testing.T) {
+	passthrough := []string{"deploy_key", "GROQ_API_KEY"}
+	got := SensitiveEnvRefs("echo $DEPLOY_KEY $GROQ_API_KEY", passthrough...)
+	// Passthrough clears API-key suffix names but never hard secrets.
+	if want := []string{"GROQ_API_KEY"}; !slices.Equal(got, want) {
+		t.Errorf("SensitiveEnvRefs with passthrough = %v, want %v", got, want)
+	}
+}
+
+func TestCredentialReferenceRuleBlocksSecretEcho(t *testing.T) {
+	policyYAML := `rules:
+  - purpose: "*"
+    resource_pattern: "*"
+    labels:
+      credential_reference: "*"
+    decision: deny
+    reason: command references a secret env var
+`
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(path, []byte(policyYAML), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	g, err := NewGuard(Config{Purpose: "test", PolicyPath: path, Actor: map[string]any{"test": true}})
+	if err != nil {
+		t.Fatalf("failed to create guard: %v", err)
+	}
+
+	_, err = g.Run(context.Background(), "echo", []string{"$STRIPE_SECRET_KEY"}, nil)
+	blocked := requireBlocked(t, err)
+	if blocked.Reason != "command references a secret env var" {
+		t.Errorf("expected credential reference rule to match, got %q", blocked.Reason)
+	}
+
+	if _, err := g.Run(context.Background(), "echo", []string{"$HOME"}, nil); err != nil {
+		t.Errorf("expected echo $HOME to be allowed, got %v", err)
+	}
+}
@@ -0,0 +1,166 @@
+package cmdguard
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/ppiankov/chainwatch/internal/audit"
+	"github.com/ppiankov/chainwatch/internal/model"
+)
+
+// WriteResult describes the outcome of a guarded file write.
+type WriteResult struct {
+	Path         string         `json:"path"`                    // where the data actually landed
+	IntendedPath string         `json:"intended_path,omitempty"` // set when quarantined
+	Bytes        int            `json:"bytes"`
+	Quarantined  bool           `json:"quarantined,omitempty"`
+	Decision     model.Decision `json:"decision"`
+}
+
+// WriteFile evaluates policy for a file_write and performs it if allowed.
+// A quarantine decision redirects the write into Config.QuarantineDir,
+// preserving the intended path's structure underneath it. Without a
+// quarantine dir, quarantine fails closed.
+func (g *Guard) WriteFile(path string, data []byte) (*WriteResult, error) {
+	return g.WriteFileConfirmed(path, data, "")
+}
+
+// WriteFileConfirmed is WriteFile presenting a confirm token from an
+// earlier BlockedError, for approved irreversible writes under
+// confirm_irreversible.
+func (g *Guard) WriteFileConfirmed(path string, data []byte, confirmToken string) (*WriteResult, error) {
+	action := buildActionFromFileWrite(path, len(data))
+
+	result, err := g.authorize(action, confirmToken)
+	if err != nil {
+		return nil, err
+	}
+
+	target := path
+	if result.Decision == model.Quarantine {
+		if g.cfg.QuarantineDir == "" {
+			return nil, &BlockedError{
+				Command:  action.Resource,
+				Decision: result.Decision,
+				Reason:   "quarantine requested but no quarantine directory configured: " + result.Reason,
+				PolicyID: result.PolicyID,
+			}
+		}
+		target = quarantinePath(g.cfg.QuarantineDir, path)
+		if err := os.MkdirAll(filepath.Dir(target), 0o700); err != nil {
+			return nil, fmt.Errorf("failed to create quarantine directory: %w", err)
+		}
+	}
+
+	if err := os.WriteFile(target, data, 0o600); err != nil {
+		return nil, err
+	}
+
+	wr := &WriteResult{Path: target, Bytes: len(data), Decision: result.Decision}
+	if result.Decision == model.Quarantine {
+		wr.IntendedPath = path
+		wr.Quarantined = true
+		if g.auditLog != nil {
+			g.auditLog.Record(audit.AuditEntry{
+				Timestamp:  time.Now().UTC().Format("2006-01-02T15:04:05.000Z"),
+				TraceID:    g.tracer.State.TraceID,
+				Action:     audit.AuditAction{Tool: action.Tool, Resource: path},
+				Decision:   string(model.Quarantine),
+				Reason:     fmt.Sprintf("write redirected: intended=%s actual=%s", path, target),
+				Tier:       result.Tier,
+				PolicyHash: g.policyHash,
+				Type:       "quarantine_redirect",
+			})
+		}
+	}
+	return wr, nil
+}
+
+// stdinFile limits reads from an opened stdin file to the checked size.
+type stdinFile struct {
+	io.Reader
+	f *os.File
+}
+
+func (s *stdinFile) Close() error { return s.f.Close() }
+
+// OpenStdinFile opens path for use as a command's stdin. Anything other
+// than a regular file, or a file larger than limit bytes, is rejected
+// before the command is evaluated or run. Reads stay capped at limit even
+// if the file grows after the check. Zero limit means DefaultMaxStdinBytes.
+func OpenStdinFile(path string, limit int64) (io.ReadCloser, error) {
+	if limit <= 0 {
+		limit = DefaultMaxStdinBytes
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("stdin file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("stdin file: %w", err)
+	}
+	if !info.Mode().IsRegular() {
+		f.Close()
+		return nil, fmt.Errorf("stdin file %s: not a regular file", path)
+	}
+	if info.Size() > limit {
+		f.Close()
+		return nil, fmt.Errorf("stdin file %s: %d bytes exceeds limit of %d", path, info.Size(), limit)
+	}
+	return &stdinFile{Reader: io.LimitReader(f, limit), f: f}, nil
+}
+
+// quarantinePath maps an intended path into dir. The path is cleaned as if
+// absolute, so "../" segments cannot escape the quarantine directory.
+func quarantinePath(dir, path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = path
+	}
+	rel := filepath.Clean(string(filepath.Separator) + abs)
+	if vol := filepath.VolumeName(rel); vol != "" {
+		rel = strings.TrimPrefix(rel, vol)
+	}
+	return filepath.Join(dir, rel)
+}
+
+// SensitiveFilePatterns are path substrings of files holding credentials or
+// personal data. File actions on them are high sensitivity.
+var SensitiveFilePatterns = []string{".ssh/", ".aws/", ".env", "credentials", "secret", "password", "salary"}
+
+// buildActionFromFileWrite maps a file write to a chainwatch Action.
+func buildActionFromFileWrite(path string, size int) *model.Action {
+	resource, rawResource := model.DecodeResource(path)
+	sensitivity := model.SensLow
+	var tags []string
+	lower := strings.ToLower(resource)
+	for _, p := range SensitiveFilePatterns {
+		if strings.Contains(lower, p) {
+			sensitivity = model.SensHigh
+			tags = []string{"sensitive_file"}
+			break
+		}
+	}
+
+	return &model.Action{
+		Tool:      "file_write",
+		Resource:  resource,
+		Operation: "write",
+		Params:    map[string]any{"path": path, "bytes": size},
+		RawMeta: map[string]any{
+			"sensitivity": string(sensitivity),
+			"tags":        toAnySlice(tags),
+			"bytes":       size,
+			"rows":        0,
+			"egress":      string(model.EgressInternal),
+			"destination": "",
+		},
+		RawResource: rawResource,
+	}
+}
@@ -0,0 +1,174 @@
+package cmdguard
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ppiankov/chainwatch/internal/model"
+)
+
+func newQuarantineGuard(t *testing.T, quarantineDir string) *Guard {
+	t.Helper()
+	policyYAML := `enforcement_mode: guarded
+rules:
+  - purpose: "*"
+    resource_pattern: "*quarantine-me*"
+    decision: quarantine
+`
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(path, []byte(policyYAML), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	g, err := NewGuard(Config{
+		Purpose:       "test",
+		PolicyPath:    path,
+		Actor:         map[string]any{"test": true},
+		QuarantineDir: quarantineDir,
+	})
+	if err != nil {
+		t.Fatalf("failed to create guard: %v", err)
+	}
+	return g
+}
+
+func TestWriteFileAllowed(t *testing.T) {
+	g := newQuarantineGuard(t, t.TempDir())
+	target := filepath.Join(t.TempDir(), "notes.txt")
+
+	res, err := g.WriteFile(target, []byte("hello"))
+	if err != nil {
+		t.Fatalf("expected allow, got %v", err)
+	}
+	if res.Quarantined || res.Path != target {
+		t.Errorf("expected direct write to %s, got %+v", target, res)
+	}
+	if data, _ := os.ReadFile(target); string(data) != "hello" {
+		t.Errorf("expected target written, got %q", data)
+	}
+}
+
+func TestWriteFileQuarantined(t *testing.T) {
+	qdir := t.TempDir()
+	g := newQuarantineGuard(t, qdir)
+	target := filepath.Join(t.TempDir(), "quarantine-me", "out.txt")
+
+	res, err := g.WriteFile(target, []byte("payload"))
+	if err != nil {
+		t.Fatalf("expected quarantined write, got %v", err)
+	}
+	if !res.Quarantined || res.Decision != model.Quarantine {
+		t.Fatalf("expected quarantined result, got %+v", res)
+	}
+	if res.IntendedPath != target {
+		t.Errorf("expected intended path %s, got %s", target, res.IntendedPath)
+	}
+	if !strings.HasPrefix(res.Path, qdir+string(filepath.Separator)) {
+		t.Errorf("expected write under %s, got %s", qdir, res.Path)
+	}
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Errorf("target must not be written, stat err=%v", err)
+	}
+	if data, _ := os.ReadFile(res.Path); string(data) != "payload" {
+		t.Errorf("expected quarantined content, got %q", data)
+	}
+}
+
+func TestWriteFileQuarantineWithoutDirFailsClosed(t *testing.T) {
+	g := newQuarantineGuard(t, "")
+	target := filepath.Join(t.TempDir(), "quarantine-me.txt")
+
+	_, err := g.WriteFile(target, []byte("x"))
+	blocked := requireBlocked(t, err)
+	if blocked.Decision != model.Quarantine {
+		t.Errorf("expected quarantine decision, got %s", blocked.Decision)
+	}
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Errorf("target must not be written, stat err=%v", err)
+	}
+}
+
+func TestRunQuarantineFailsClosed(t *testing.T) {
+	g := newQuarantineGuard(t, t.TempDir())
+	_, err := g.Run(context.Background(), "echo", []string{"quarantine-me"}, nil)
+	requireBlocked(t, err)
+}
+
+func TestQuarantinePathStaysInsideDir(t *testing.T) {
+	dir := filepath.Join(string(filepath.Separator), "q")
+	for _, p := range []string{"/etc/passwd", "../../etc/passwd", "/a/../../b"} {
+		got := quarantinePath(dir, p)
+		if !strings.HasPrefix(got, dir+string(filepath.Separator)) {
+			t.Errorf("quarantinePath(%q) = %q escapes %q", p, got, dir)
+		}
+	}
+}
+
+func TestStdinFromFileReachesCommand(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "patch.diff")
+	content := "--- a/x\n+++ b/x\n@@ -1 +1 @@\n-old\n+new\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	stdin, err := OpenStdinFile(path, 0)
+	if err != nil {
+		t.Fatalf("open stdin file: %v", err)
+	}
+	defer stdin.Close()
+
+	g := newTestGuard(t)
+	result, err := g.Run(context.Background(), "cat", nil, stdin)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Stdout != content {
+		t.Errorf("expected stdout %q, got %q", content, result.Stdout)
+	}
+}
+
+func TestStdinFromFileRejectsOversize(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "big.bin")
+	if err := os.WriteFile(path, make([]byte, 1025), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := OpenStdinFile(path, 1024); err == nil || !strings.Contains(err.Error(), "exceeds limit") {
+		t.Errorf("expected oversize file to be rejected, got %v", err)
+	}
+	if _, err := OpenStdinFile(dir, 1024); err == nil {
+		t.Error("expected directory to be rejected")
+	}
+	if f, err := OpenStdinFile(path, 2048); err != nil {
+		t.Errorf("expected file under limit to open, got %v", err)
+	} else {
+		f.Close()
+	}
+}
+
+func TestWriteFileEncodedPathDecodedWithRawInAudit(t *testing.T) {
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	g, err := NewGuard(Config{Purpose: "test", AuditLogPath: auditPath})
+	if err != nil {
+		t.Fatalf("failed to create guard: %v", err)
+	}
+	defer g.Close()
+
+	raw := "/tmp/..%2f..%2fproject%2f.env"
+	_, err = g.WriteFile(raw, []byte("TOKEN=x"))
+	blocked := requireBlocked(t, err)
+	if blocked.Command != "/tmp/../../project/.env" {
+		t.Errorf("expected decoded path to be evaluated, got %q", blocked.Command)
+	}
+
+	data, err := os.ReadFile(auditPath)
+	if err != nil {
+		t.Fatalf("read audit log: %v", err)
+	}
+	if !strings.Contains(string(data), `"raw_resource":"`+raw+`"`) {
+		t.Errorf("expected raw path preserved in audit, got %s", data)
+	}
+}
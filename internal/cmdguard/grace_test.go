@@ -0,0 +1,107 @@
+package cmdguard
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ppiankov/chainwatch/internal/model"
+)
+
+func TestApprovalGraceAllowsSameRuleOnly(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	policyYAML := `rules:
+  - purpose: "*"
+    resource_pattern: "*deploy*"
+    decision: require_approval
+    approval_key: deploy
+    approval_grace: 1m
+  - purpose: "*"
+    resource_pattern: "*migrate*"
+    decision: require_approval
+    approval_key: migrate
+`
+	policyPath := filepath.Join(dir, "policy.yaml")
+	if err := os.WriteFile(policyPath, []byte(policyYAML), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	auditPath := filepath.Join(dir, "audit.jsonl")
+	g, err := NewGuard(Config{Purpose: "test", PolicyPath: policyPath, AuditLogPath: auditPath, Actor: map[string]any{"test": true}})
+	if err != nil {
+		t.Fatalf("failed to create guard: %v", err)
+	}
+	ctx := context.Background()
+
+	_, err = g.Run(ctx, "echo", []string{"deploy", "build"}, nil)
+	if blocked := requireBlocked(t, err); blocked.Decision != model.RequireApproval {
+		t.Fatalf("expected require_approval before approval, got %s", blocked.Decision)
+	}
+	if err := g.approvals.Approve("deploy", 0, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Run(ctx, "echo", []string{"deploy", "build"}, nil); err != nil {
+		t.Fatalf("expected approved action to run, got %v", err)
+	}
+
+	// The one-time approval is consumed; the grace window covers the rule.
+	if _, err := g.Run(ctx, "echo", []string{"deploy", "restart"}, nil); err != nil {
+		t.Fatalf("expected same-rule action allowed in grace window, got %v", err)
+	}
+
+	_, err = g.Run(ctx, "echo", []string{"migrate", "db"}, nil)
+	if blocked := requireBlocked(t, err); blocked.Decision != model.RequireApproval {
+		t.Fatalf("expected other rule to still require approval, got %s", blocked.Decision)
+	}
+
+	g.Close()
+	data, err := os.ReadFile(auditPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := strings.Count(string(data), `"type":"approval_grace"`); n != 1 {
+		t.Errorf("expected one approval_grace audit entry, got %d:\n%s", n, data)
+	}
+}
+
+func TestApprovalThrottleWinsOverGrace(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	policyYAML := `approval_throttle:
+  max_approvals: 1
+  window: 1h
+rules:
+  - purpose: "*"
+    resource_pattern: "*deploy*"
+    decision: require_approval
+    approval_key: deploy
+    approval_grace: 1m
+`
+	policyPath := filepath.Join(dir, "policy.yaml")
+	if err := os.WriteFile(policyPath, []byte(policyYAML), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	g, err := NewGuard(Config{Purpose: "test", PolicyPath: policyPath, Actor: map[string]any{"test": true}})
+	if err != nil {
+		t.Fatalf("failed to create guard: %v", err)
+	}
+	defer g.Close()
+	ctx := context.Background()
+
+	_, err = g.Run(ctx, "echo", []string{"deploy", "build"}, nil)
+	requireBlocked(t, err)
+	if err := g.approvals.Approve("deploy", 0, ""); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := g.Run(ctx, "echo", []string{"deploy", "build"}, nil); err != nil {
+		t.Fatalf("expected approved action to run, got %v", err)
+	}
+
+	// The grace window is open, but the key has used up its approvals.
+	_, err = g.Run(ctx, "echo", []string{"deploy", "restart"}, nil)
+	if blocked := requireBlocked(t, err); blocked.PolicyID != "approval.throttle" {
+		t.Fatalf("expected throttle deny inside grace window, got %s (%s)", blocked.PolicyID, blocked.Reason)
+	}
+}
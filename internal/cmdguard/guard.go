@@ -7,6 +7,7 @@ import (
 	"io"
 	"os"
 	"os/exec"
+	"slices"
 	"strings"
 	"sync"
 	"syscall"
@@ -16,6 +17,7 @@ import (
 	"github.com/ppiankov/chainwatch/internal/approval"
 	"github.com/ppiankov/chainwatch/internal/audit"
 	"github.com/ppiankov/chainwatch/internal/breakglass"
+	"github.com/ppiankov/chainwatch/internal/decisionhook"
 	"github.com/ppiankov/chainwatch/internal/denylist"
 	"github.com/ppiankov/chainwatch/internal/model"
 	"github.com/ppiankov/chainwatch/internal/policy"
@@ -27,17 +29,60 @@ import (
 type Config struct {
 	DenylistPath string
 	PolicyPath   string
-	ProfileName  string
+	ProfileName  string // one profile or a comma-separated stack, applied in order
 	Purpose      string
 	AgentID      string
 	Actor        map[string]any
 	AuditLogPath string
+
+	// DecisionCacheTTL enables the per-trace decision cache for repeated
+	// identical actions. Zero disables caching.
+	DecisionCacheTTL time.Duration
+
+	// QuarantineDir receives file writes whose policy decision is quarantine.
+	// Empty means quarantine fails closed.
+	QuarantineDir string
+
+	// RedactPlaceholder replaces secrets found in command output. A
+	// "{category}" token expands to the secret type. Empty means
+	// DefaultRedactPlaceholder.
+	RedactPlaceholder string
+
+	// RequirePolicyFile makes a missing or empty policy path a startup
+	// error instead of falling back to the default policy.
+	RequirePolicyFile bool
+
+	// CommandTimeout bounds how long an allowed command may run. A command
+	// still running at the deadline is killed and its Result reports
+	// TimedOut with TimeoutExitCode. Zero means no limit.
+	CommandTimeout time.Duration
+
+	// EnvPassthrough names env vars kept in the subprocess environment even
+	// when a sensitive prefix would strip them (e.g. AWS_REGION). Names that
+	// look like secrets are stripped regardless.
+	EnvPassthrough []string
+
+	// TracePath, when set, receives the session's trace as JSONL (one line
+	// per recorded action), appended on Close.
+	TracePath string
 }
 
+// TimeoutExitCode is the exit code reported for a command killed by
+// CommandTimeout. It matches timeout(1) and is distinct from the policy
+// block exit code 77.
+const TimeoutExitCode = 124
+
+// timeoutWaitDelay bounds how long Run waits for output pipes after a
+// timed-out command is killed, since grandchildren may still hold them open.
+const timeoutWaitDelay = time.Second
+
 // DefaultMaxOutputBytes is the default maximum bytes captured per stream.
 // 4 MB is generous for command output while preventing OOM on unbounded commands.
 const DefaultMaxOutputBytes = 4 << 20 // 4 MB
 
+// DefaultMaxStdinBytes caps stdin supplied from a file (OpenStdinFile).
+const DefaultMaxStdinBytes = 4 << 20 // 4 MB
+
 // Result captures subprocess execution outcome.
 type Result struct {
 	Stdout          string         `json:"stdout"`
@@ -46,6 +91,8 @@ type Result struct {
 	Decision        model.Decision `json:"decision"`
 	StdoutTruncated bool           `json:"stdout_truncated,omitempty"`
 	StderrTruncated bool           `json:"stderr_truncated,omitempty"`
+	TimedOut        bool           `json:"timed_out,omitempty"`
+	EgressDetected  bool           `json:"egress_detected,omitempty"` // output showed an external upload (see output_egress)
 }
 
 // limitedWriter caps how much data is written to an underlying buffer.
@@ -90,6 +137,12 @@ type BlockedError struct {
 	Reason      string
 	PolicyID    string
 	ApprovalKey string
+	Suggestion  string // safer alternative from the matched deny rule
+
+	// ConfirmToken is issued when an approved irreversible action needs
+	// confirmation; retrying with it (WithConfirmToken, WriteFileConfirmed)
+	// lets the action proceed.
+	ConfirmToken string
 }
 
 func (e *BlockedError) Error() string {
@@ -107,6 +160,9 @@ type Guard struct {
 	tracer     *tracer.TraceAccumulator
 	auditLog   *audit.Log
 	policyHash string
+	cache      *policy.DecisionCache
+	hook       *decisionhook.Hook
+	enrich     *decisionhook.Enrichment
 	mu         sync.Mutex
 }
 
@@ -117,25 +173,29 @@ func NewGuard(cfg Config) (*Guard, error) {
 		return nil, fmt.Errorf("failed to load denylist: %w", err)
 	}
 
+	if cfg.RequirePolicyFile {
+		if err := policy.RequireConfigFile(cfg.PolicyPath); err != nil {
+			return nil, err
+		}
+	}
+
 	policyCfg, policyHash, err := policy.LoadConfigWithHash(cfg.PolicyPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load policy config: %w", err)
 	}
 
-	if cfg.ProfileName != "" {
-		prof, err := profile.Load(cfg.ProfileName)
-		if err != nil {
-			return nil, fmt.Errorf("failed to load profile %q: %w", cfg.ProfileName, err)
-		}
-		profile.ApplyToDenylist(prof, dl)
-		policyCfg = profile.ApplyToPolicy(prof, policyCfg)
+	policyCfg, err = profile.ApplyStack(profile.SplitNames(cfg.ProfileName), dl, policyCfg)
+	if err != nil {
+		return nil, err
 	}
 
-	approvalStore, err := approval.NewStore(approval.DefaultDir())
+	approvalStore, err := approval.Open(approval.DefaultDir(), policyCfg.ApprovalStore)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create approval store: %w", err)
 	}
 	approvalStore.Cleanup()
+	approvalStore.SetThrottle(policyCfg.ApprovalThrottle)
+	approvalStore.SetReasonRequired(policyCfg.ReasonRequiredKeys())
 
 	if cfg.Actor == nil {
 		cfg.Actor = map[string]any{"guard": "chainwatch"}
@@ -146,7 +206,7 @@ func NewGuard(cfg Config) (*Guard, error) {
 
 	var auditLog *audit.Log
 	if cfg.AuditLogPath != "" {
-		auditLog, err = audit.Open(cfg.AuditLogPath)
+		auditLog, err = audit.OpenWithSinks(cfg.AuditLogPath, policyCfg.AuditSinks)
 		if err != nil {
 			return nil, fmt.Errorf("failed to open audit log: %w", err)
 		}
@@ -164,105 +224,46 @@ func NewGuard(cfg Config) (*Guard, error) {
 		tracer:     tracer.NewAccumulator(tracer.NewTraceID()),
 		auditLog:   auditLog,
 		policyHash: policyHash,
+		cache:      policy.NewDecisionCache(cfg.DecisionCacheTTL),
+		hook:       decisionhook.New(policyCfg.DecisionHook),
+		enrich:     decisionhook.NewEnrichment(policyCfg.EnrichmentHook),
 	}, nil
 }
 
 // Run evaluates policy for the command, executes if allowed, and records trace.
 func (g *Guard) Run(ctx context.Context, name string, args []string, stdin io.Reader) (*Result, error) {
-	action := buildActionFromCommand(name, args)
-
-	g.mu.Lock()
-	result := policy.Evaluate(action, g.tracer.State, g.cfg.Purpose, g.cfg.AgentID, g.dl, g.policyCfg)
-	g.tracer.RecordAction(g.cfg.Actor, g.cfg.Purpose, action, map[string]any{
-		"result":       string(result.Decision),
-		"reason":       result.Reason,
-		"policy_id":    result.PolicyID,
-		"approval_key": result.ApprovalKey,
-	}, "")
-	g.mu.Unlock()
+	action := buildActionFromCommand(name, args, g.cfg.EnvPassthrough)
 
-	if g.auditLog != nil {
-		g.auditLog.Record(audit.AuditEntry{
-			Timestamp:  time.Now().UTC().Format("2006-01-02T15:04:05.000Z"),
-			TraceID:    g.tracer.State.TraceID,
-			Action:     audit.AuditAction{Tool: action.Tool, Resource: action.Resource},
-			Decision:   string(result.Decision),
-			Reason:     result.Reason,
-			Tier:       result.Tier,
-			PolicyHash: g.policyHash,
-		})
-	}
-	g.dispatchAlert(action, result)
-
-	// Break-glass override (CW-23.2)
-	if result.Tier >= 2 && g.bgStore != nil {
-		if token := breakglass.CheckAndConsume(g.bgStore, result.Tier, action); token != nil {
-			originalDecision := result.Decision
-			result.Decision = model.Allow
-			result.Reason = fmt.Sprintf("break-glass override (token=%s, original=%s): %s",
-				token.ID, originalDecision, token.Reason)
-			result.PolicyID = "breakglass.override"
-			if g.auditLog != nil {
-				g.auditLog.Record(audit.AuditEntry{
-					Timestamp:        time.Now().UTC().Format("2006-01-02T15:04:05.000Z"),
-					TraceID:          g.tracer.State.TraceID,
-					Action:           audit.AuditAction{Tool: action.Tool, Resource: action.Resource},
-					Decision:         "allow",
-					Reason:           result.Reason,
-					Tier:             result.Tier,
-					PolicyHash:       g.policyHash,
-					Type:             "break_glass_used",
-					TokenID:          token.ID,
-					OriginalDecision: string(originalDecision),
-					OverriddenTo:     "allow",
-					ExpiresAt:        token.ExpiresAt.Format(time.RFC3339),
-				})
-			}
-			g.dispatchBreakGlass(action, result)
-		}
+	result, err := g.authorize(action, confirmTokenFrom(ctx))
+	if err != nil {
+		return nil, err
 	}
 
-	if result.Decision == model.Deny {
+	// Command side effects cannot be contained — quarantine fails closed.
+	if result.Decision == model.Quarantine {
 		return nil, &BlockedError{
-			Command:     action.Resource,
-			Decision:    result.Decision,
-			Reason:      result.Reason,
-			PolicyID:    result.PolicyID,
-			ApprovalKey: result.ApprovalKey,
-		}
-	}
-
-	if result.Decision == model.RequireApproval && result.ApprovalKey != "" {
-		status, _ := g.approvals.Check(result.ApprovalKey)
-		if status == approval.StatusApproved {
-			g.approvals.Consume(result.ApprovalKey)
-			// fall through to execute
-		} else {
-			if status != approval.StatusPending && status != approval.StatusDenied {
-				g.approvals.Request(result.ApprovalKey, result.Reason, result.PolicyID, action.Resource, g.cfg.AgentID)
-			}
-			return nil, &BlockedError{
-				Command:  action.Resource,
-				Decision: result.Decision,
-				Reason:   result.Reason,
-				PolicyID: result.PolicyID,
-			}
-		}
-	} else if result.Decision == model.RequireApproval {
-		return nil, &BlockedError{
-			Command:     action.Resource,
-			Decision:    result.Decision,
-			Reason:      result.Reason,
-			PolicyID:    result.PolicyID,
-			ApprovalKey: result.ApprovalKey,
+			Command:  action.Resource,
+			Decision: result.Decision,
+			Reason:   "quarantine is not supported for command execution: " + result.Reason,
+			PolicyID: result.PolicyID,
 		}
 	}
 
 	// Execute the command with sanitized environment.
 	// Sensitive env vars (API keys, tokens) are stripped so spawned
 	// processes cannot exfiltrate credentials via shell builtins.
-	cmd := exec.CommandContext(ctx, name, args...)
-	cmd.Env = sanitizeEnv(os.Environ())
+	runCtx := ctx
+	if g.cfg.CommandTimeout > 0 {
+		var cancel context.CancelFunc
+		runCtx, cancel = context.WithTimeout(ctx, g.cfg.CommandTimeout)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(runCtx, name, args...)
+	if g.cfg.CommandTimeout > 0 {
+		cmd.WaitDelay = timeoutWaitDelay
+	}
+	cmd.Env = sanitizeEnv(os.Environ(), g.cfg.EnvPassthrough)
 	stdout := newLimitedWriter(DefaultMaxOutputBytes)
 	stderr := newLimitedWriter(DefaultMaxOutputBytes)
 	cmd.Stdout = stdout
@@ -271,9 +272,10 @@ func (g *Guard) Run(ctx context.Context, name string, args []string, stdin io.Re
 		cmd.Stdin = stdin
 	}
 
-	err := cmd.Run()
+	err = cmd.Run()
 	exitCode := 0
-	if err != nil {
+	timedOut := g.cfg.CommandTimeout > 0 && ctx.Err() == nil && runCtx.Err() == context.DeadlineExceeded
+	if err != nil && !timedOut {
 		if exitErr, ok := err.(*exec.ExitError); ok {
 			if status, ok := exitErr.Sys().(syscall.WaitStatus); ok {
 				exitCode = status.ExitStatus()
@@ -282,6 +284,20 @@ func (g *Guard) Run(ctx context.Context, name string, args []string, stdin io.Re
 			return nil, err
 		}
 	}
+	if timedOut {
+		exitCode = TimeoutExitCode
+		if g.auditLog != nil {
+			g.auditLog.Record(audit.AuditEntry{
+				Timestamp:  time.Now().UTC().Format("2006-01-02T15:04:05.000Z"),
+				TraceID:    g.tracer.State.TraceID,
+				Action:     audit.AuditAction{Tool: "command_timeout", Resource: action.Resource},
+				Decision:   "timeout",
+				Reason:     fmt.Sprintf("command exceeded %s timeout", g.cfg.CommandTimeout),
+				Tier:       result.Tier,
+				PolicyHash: g.policyHash,
+			})
+		}
+	}
 
 	// Append truncation marker so operators know evidence is incomplete.
 	outStr := stdout.String()
@@ -293,9 +309,12 @@ func (g *Guard) Run(ctx context.Context, name string, args []string, stdin io.Re
 		errStr += "\n[TRUNCATED]"
 	}
 
+	egressDetected := g.checkOutputEgress(action, outStr, errStr)
+
 	// Scan output for leaked secrets and redact before returning.
-	cleanOut, nOut := ScanOutputFull(outStr)
-	cleanErr, nErr := ScanOutputFull(errStr)
+	redactor := Redactor{Placeholder: g.cfg.RedactPlaceholder}
+	cleanOut, nOut := redactor.ScanOutputFull(outStr)
+	cleanErr, nErr := redactor.ScanOutputFull(errStr)
 	if nOut+nErr > 0 && g.auditLog != nil {
 		g.auditLog.Record(audit.AuditEntry{
 			Timestamp:  time.Now().UTC().Format("2006-01-02T15:04:05.000Z"),
@@ -327,9 +346,154 @@ func (g *Guard) Run(ctx context.Context, name string, args []string, stdin io.Re
 		Decision:        result.Decision,
 		StdoutTruncated: stdout.truncated,
 		StderrTruncated: stderr.truncated,
+		TimedOut:        timedOut,
+		EgressDetected:  egressDetected,
 	}, nil
 }
 
+// authorize evaluates policy for an action, records trace/audit/alerts, applies
+// break-glass and approval state, and returns a BlockedError if execution must not proceed.
+// confirmToken is redeemed when an approved irreversible action needs confirmation.
+func (g *Guard) authorize(action *model.Action, confirmToken string) (model.PolicyResult, error) {
+	result := g.evaluate(action)
+	needConfirm := g.recordDecision(action, result)
+
+	if g.auditLog != nil {
+		g.auditLog.Record(audit.AuditEntry{
+			Timestamp:  time.Now().UTC().Format("2006-01-02T15:04:05.000Z"),
+			TraceID:    g.tracer.State.TraceID,
+			Action:     audit.AuditAction{Tool: action.Tool, Resource: action.Resource, RawResource: action.RawResource, Labels: action.Labels, Fingerprint: action.Fingerprint()},
+			Decision:   string(result.Decision),
+			Reason:     result.Reason,
+			Tier:       result.Tier,
+			PolicyHash: g.policyHash,
+		})
+	}
+	g.dispatchAlert(action, result)
+
+	// Break-glass override (CW-23.2)
+	if result.Tier >= 2 && g.bgStore != nil {
+		if token := breakglass.CheckAndConsume(g.bgStore, result.Tier, action); token != nil {
+			originalDecision := result.Decision
+			result.Decision = model.Allow
+			result.Reason = fmt.Sprintf("break-glass override (token=%s, original=%s): %s",
+				token.ID, originalDecision, token.Reason)
+			result.PolicyID = "breakglass.override"
+			if g.auditLog != nil {
+				g.auditLog.Record(audit.AuditEntry{
+					Timestamp:        time.Now().UTC().Format("2006-01-02T15:04:05.000Z"),
+					TraceID:          g.tracer.State.TraceID,
+					Action:           audit.AuditAction{Tool: action.Tool, Resource: action.Resource, RawResource: action.RawResource, Labels: action.Labels, Fingerprint: action.Fingerprint()},
+					Decision:         "allow",
+					Reason:           result.Reason,
+					Tier:             result.Tier,
+					PolicyHash:       g.policyHash,
+					Type:             "break_glass_used",
+					TokenID:          token.ID,
+					OriginalDecision: string(originalDecision),
+					OverriddenTo:     "allow",
+					ExpiresAt:        token.ExpiresAt.Format(time.RFC3339),
+				})
+			}
+			g.dispatchBreakGlass(action, result)
+		}
+	}
+
+	if result.Decision == model.Deny {
+		return result, &BlockedError{
+			Command:     action.Resource,
+			Decision:    result.Decision,
+			Reason:      result.Reason,
+			PolicyID:    result.PolicyID,
+			ApprovalKey: result.ApprovalKey,
+			Suggestion:  result.Suggestion,
+		}
+	}
+
+	if result.Decision == model.RequireApproval && result.ApprovalKey != "" {
+		traceID := g.traceID()
+		status, _ := g.approvals.CheckTrace(result.ApprovalKey, traceID)
+		if status == approval.StatusApproved && needConfirm {
+			result = g.approvals.ConfirmApproved(result, action, confirmToken, g.policyCfg.ConfirmTTL())
+			if result.Decision != model.Allow {
+				return result, &BlockedError{
+					Command:      action.Resource,
+					Decision:     result.Decision,
+					Reason:       result.Reason,
+					PolicyID:     result.PolicyID,
+					ApprovalKey:  result.ApprovalKey,
+					ConfirmToken: result.ConfirmToken,
+				}
+			}
+			// confirmed: fall through to execute
+		} else if status == approval.StatusApproved {
+			g.approvals.Consume(result.ApprovalKey)
+			if err := g.approvals.OpenGrace(result, traceID, g.cfg.AgentID); err != nil {
+				fmt.Fprintf(os.Stderr, "approval grace not opened: %v\n", err)
+			}
+			// fall through to execute
+		} else if status == approval.StatusThrottled {
+			result = g.approvals.ThrottleDeny(result)
+			if g.auditLog != nil {
+				g.auditLog.Record(audit.AuditEntry{
+					Timestamp:  time.Now().UTC().Format("2006-01-02T15:04:05.000Z"),
+					TraceID:    traceID,
+					Action:     audit.AuditAction{Tool: action.Tool, Resource: action.Resource, RawResource: action.RawResource, Labels: action.Labels, Fingerprint: action.Fingerprint()},
+					Decision:   string(result.Decision),
+					Reason:     result.Reason,
+					Tier:       result.Tier,
+					PolicyHash: g.policyHash,
+					Type:       "approval_throttled",
+				})
+			}
+			g.dispatchAlert(action, result)
+			return result, &BlockedError{
+				Command:     action.Resource,
+				Decision:    result.Decision,
+				Reason:      result.Reason,
+				PolicyID:    result.PolicyID,
+				ApprovalKey: result.ApprovalKey,
+			}
+		} else if graced, ok := g.approvals.Grace(result, traceID, g.cfg.AgentID); ok && !needConfirm {
+			result = graced
+			if g.auditLog != nil {
+				g.auditLog.Record(audit.AuditEntry{
+					Timestamp:  time.Now().UTC().Format("2006-01-02T15:04:05.000Z"),
+					TraceID:    traceID,
+					Action:     audit.AuditAction{Tool: action.Tool, Resource: action.Resource, RawResource: action.RawResource, Labels: action.Labels, Fingerprint: action.Fingerprint()},
+					Decision:   string(result.Decision),
+					Reason:     result.Reason,
+					Tier:       result.Tier,
+					PolicyHash: g.policyHash,
+					Type:       "approval_grace",
+				})
+			}
+			// fall through to execute
+		} else {
+			if status != approval.StatusPending && status != approval.StatusDenied {
+				g.approvals.Request(result.ApprovalKey, result.Reason, result.PolicyID, action.Resource, g.cfg.AgentID)
+			}
+			return result, &BlockedError{
+				Command:     action.Resource,
+				Decision:    result.Decision,
+				Reason:      result.Reason,
+				PolicyID:    result.PolicyID,
+				ApprovalKey: result.ApprovalKey,
+			}
+		}
+	} else if result.Decision == model.RequireApproval {
+		return result, &BlockedError{
+			Command:     action.Resource,
+			Decision:    result.Decision,
+			Reason:      result.Reason,
+			PolicyID:    result.PolicyID,
+			ApprovalKey: result.ApprovalKey,
+		}
+	}
+
+	return result, nil
+}
+
 func (g *Guard) dispatchAlert(action *model.Action, result model.PolicyResult) {
 	if g.dispatcher != nil {
 		g.dispatcher.Dispatch(alert.AlertEvent{
@@ -341,6 +505,7 @@ func (g *Guard) dispatchAlert(action *model.Action, result model.PolicyResult) {
 			Reason:     result.Reason,
 			Tier:       result.Tier,
 			PolicyHash: g.policyHash,
+			Rule:       alert.RuleAlert{Mode: result.AlertMode, Channels: result.AlertChannels},
 		})
 	}
 }
@@ -385,9 +550,41 @@ var sensitiveEnvExact = []string{
 	"API_SECRET",
 }
 
+// hardSecretEnvMarkers mark env var names as secrets that passthrough
+// cannot preserve.
+var hardSecretEnvMarkers = []string{
+	"SECRET",
+	"TOKEN",
+	"PASSWORD",
+	"PASSWD",
+	"API_KEY",
+	"PRIVATE_KEY",
+	"CREDENTIAL",
+}
+
+// isHardSecretEnv reports whether an upper-cased env var name is an exact
+// sensitive name or contains a hard secret marker.
+func isHardSecretEnv(upper string) bool {
+	if slices.Contains(sensitiveEnvExact, upper) {
+		return true
+	}
+	for _, marker := range hardSecretEnvMarkers {
+		if strings.Contains(upper, marker) {
+			return true
+		}
+	}
+	return false
+}
+
 // sanitizeEnv filters sensitive environment variables from the list.
-// Returns a new slice with matching entries removed.
-func sanitizeEnv(environ []string) []string {
+// Names in passthrough (case-insensitive) skip the prefix rules unless
+// they look like hard secrets. Returns a new slice with matching entries
+// removed.
+func sanitizeEnv(environ []string, passthrough []string) []string {
+	keep := make(map[string]bool, len(passthrough))
+	for _, name := range passthrough {
+		keep[strings.ToUpper(name)] = true
+	}
 	clean := make([]string, 0, len(environ))
 	for _, entry := range environ {
 		name, _, ok := strings.Cut(entry, "=")
@@ -396,6 +593,12 @@ func sanitizeEnv(environ []string) []string {
 			continue
 		}
 		upper := strings.ToUpper(name)
+		if keep[upper] {
+			if !isHardSecretEnv(upper) {
+				clean = append(clean, entry)
+			}
+			continue
+		}
 		skip := false
 		for _, prefix := range sensitiveEnvPrefixes {
 			if strings.HasPrefix(upper, prefix) {
@@ -420,19 +623,68 @@ func sanitizeEnv(environ []string) []string {
 
 // Check evaluates policy without executing. Dry-run mode.
 func (g *Guard) Check(name string, args []string) model.PolicyResult {
-	action := buildActionFromCommand(name, args)
+	action := buildActionFromCommand(name, args, g.cfg.EnvPassthrough)
+	return g.evaluate(action)
+}
+
+// evaluate enriches the action, runs local policy, then the external
+// decision hook if configured. A panic in any step denies the action.
+func (g *Guard) evaluate(action *model.Action) (result model.PolicyResult) {
+	defer policy.RecoverEvaluation(&result)
+	// Enrichment calls out over HTTP, so it runs before the trace lock is
+	// taken.
+	traceID := g.traceID()
+	if denied, ok := g.enrich.Apply(context.Background(), action, traceID, g.cfg.Purpose, g.cfg.AgentID); !ok {
+		return denied
+	}
+	func() {
+		g.mu.Lock()
+		defer g.mu.Unlock()
+		result = g.cache.Evaluate(action, g.tracer.State, g.cfg.Purpose, g.cfg.AgentID, g.dl, g.policyCfg)
+		result = g.evaluateStages(action, result)
+		result = g.evaluateOperands(action, result)
+	}()
+	// The decision hook also calls out, so it runs after the lock is released.
+	return g.hook.Decide(context.Background(), action, traceID, g.cfg.Purpose, g.cfg.AgentID, result)
+}
 
+// traceID returns the current trace's ID.
+func (g *Guard) traceID() string {
 	g.mu.Lock()
 	defer g.mu.Unlock()
-	return policy.Evaluate(action, g.tracer.State, g.cfg.Purpose, g.cfg.AgentID, g.dl, g.policyCfg)
+	return g.tracer.State.TraceID
 }
 
-// Close closes the audit log if configured.
+// recordDecision appends the decision to the trace and reports whether an
+// approval of it also needs a confirm token.
+func (g *Guard) recordDecision(action *model.Action, result model.PolicyResult) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	g.tracer.RecordAction(g.cfg.Actor, g.cfg.Purpose, action, map[string]any{
+		"result":       string(result.Decision),
+		"reason":       result.Reason,
+		"policy_id":    result.PolicyID,
+		"approval_key": result.ApprovalKey,
+		"tier":         result.Tier,
+	}, "")
+	return g.policyCfg.ConfirmRequired(result, g.tracer.State)
+}
+
+// Close appends the trace to TracePath and closes the audit log, if
+// configured.
 func (g *Guard) Close() error {
+	var traceErr error
+	if g.cfg.TracePath != "" {
+		g.mu.Lock()
+		traceErr = g.tracer.AppendJSONL(g.cfg.TracePath)
+		g.mu.Unlock()
+	}
 	if g.auditLog != nil {
-		return g.auditLog.Close()
+		if err := g.auditLog.Close(); err != nil {
+			return err
+		}
 	}
-	return nil
+	return traceErr
 }
 
 // TraceSummary exports the trace for debugging/audit.
@@ -1,12 +1,21 @@
 package cmdguard
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/ppiankov/chainwatch/internal/alert"
 	"github.com/ppiankov/chainwatch/internal/model"
+	"github.com/ppiankov/chainwatch/internal/tracer"
 )
 
 func newTestGuard(t *testing.T) *Guard {
@@ -163,7 +172,7 @@ func TestCheckDryRun(t *testing.T) {
 }
 
 func TestBuildActionFromCommand(t *testing.T) {
-	action := buildActionFromCommand("curl", []string{"https://example.com"})
+	action := buildActionFromCommand("curl", []string{"https://example.com"}, nil)
 
 	if action.Tool != "command" {
 		t.Errorf("expected tool=command, got %s", action.Tool)
@@ -180,6 +189,28 @@ func TestBuildActionFromCommand(t *testing.T) {
 	}
 }
 
+func TestClassifyCommandSensitivityMedium(t *testing.T) {
+	tests := []struct {
+		cmd  string
+		want model.Sensitivity
+		tag  string
+	}{
+		{"tail -n 100 /var/log/syslog", model.SensMedium, "system_log"},
+		{"git push origin feature/x", model.SensMedium, "vcs_write"},
+		{"sudo tail /var/log/auth.log", model.SensHigh, "credential"},
+		{"ls -la", model.SensLow, ""},
+	}
+	for _, tt := range tests {
+		got, tags := classifyCommandSensitivity(tt.cmd, nil)
+		if got != tt.want {
+			t.Errorf("classifyCommandSensitivity(%q) = %s, want %s", tt.cmd, got, tt.want)
+		}
+		if tt.tag != "" && (len(tags) == 0 || tags[0] != tt.tag) {
+			t.Errorf("classifyCommandSensitivity(%q) tags = %v, want %s", tt.cmd, tags, tt.tag)
+		}
+	}
+}
+
 func TestContextCancellation(t *testing.T) {
 	g := newTestGuard(t)
 	ctx, cancel := context.WithCancel(context.Background())
@@ -197,6 +228,62 @@ func TestContextCancellation(t *testing.T) {
 	}
 }
 
+func TestCommandTimeout(t *testing.T) {
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	g, err := NewGuard(Config{Purpose: "test", AuditLogPath: auditPath, CommandTimeout: 100 * time.Millisecond})
+	if err != nil {
+		t.Fatalf("failed to create guard: %v", err)
+	}
+	defer g.Close()
+
+	start := time.Now()
+	result, err := g.Run(context.Background(), "sh", []string{"-c", "sleep 10"}, nil)
+	elapsed := time.Since(start)
+
+	if err != nil {
+		t.Fatalf("expected timed-out result, not error: %v", err)
+	}
+	if !result.TimedOut {
+		t.Error("expected TimedOut")
+	}
+	if result.ExitCode != TimeoutExitCode {
+		t.Errorf("expected exit code %d, got %d", TimeoutExitCode, result.ExitCode)
+	}
+	if elapsed > 5*time.Second {
+		t.Errorf("expected kill near the deadline, took %v", elapsed)
+	}
+
+	data, err := os.ReadFile(auditPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"decision":"timeout"`) {
+		t.Errorf("expected timeout audit entry, got %s", data)
+	}
+
+	// Commands that finish in time are unaffected.
+	result, err = g.Run(context.Background(), "echo", []string{"fast"}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.TimedOut || result.ExitCode != 0 {
+		t.Errorf("expected normal exit, got timed_out=%v exit=%d", result.TimedOut, result.ExitCode)
+	}
+}
+
+func TestNoTimeoutWaitsForOutput(t *testing.T) {
+	g := newTestGuard(t)
+	// A background child keeps stdout open past the parent's exit. With no
+	// CommandTimeout its output must still be collected in full.
+	result, err := g.Run(context.Background(), "sh", []string{"-c", "echo first; (sleep 1.5; echo second) &"}, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Stdout != "first\nsecond\n" {
+		t.Errorf("expected output from background child, got %q", result.Stdout)
+	}
+}
+
 func TestStdinPassthrough(t *testing.T) {
 	g := newTestGuard(t)
 	input := "hello from stdin"
@@ -297,6 +384,9 @@ func TestCommandSensitivity(t *testing.T) {
 	}{
 		{"rm -rf /", "high", "destructive"},
 		{"sudo su", "high", "credential"},
+		{"echo $GROQ_API_KEY", "high", "credential_reference"},
+		{`curl -H "Authorization: Bearer ${GITHUB_TOKEN}" https://api.github.com`, "high", "credential_reference"},
+		{"echo $HOME", "low", ""},
 		{"curl https://example.com", "medium", "network"},
 		{"git push origin main", "medium", "vcs_write"},
 		{"echo hello", "low", ""},
@@ -304,7 +394,7 @@ func TestCommandSensitivity(t *testing.T) {
 	}
 
 	for _, tt := range tests {
-		sens, tags := classifyCommandSensitivity(tt.cmd)
+		sens, tags := classifyCommandSensitivity(tt.cmd, nil)
 		if string(sens) != tt.wantSens {
 			t.Errorf("classifyCommandSensitivity(%q) sens = %s, want %s", tt.cmd, sens, tt.wantSens)
 		}
@@ -321,3 +411,264 @@ func TestCommandSensitivity(t *testing.T) {
 		}
 	}
 }
+
+func newAlertingGuard(t *testing.T) (*Guard, *atomic.Int32) {
+	t.Helper()
+	var hits atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	policyYAML := `enforcement_mode: guarded
+rules:
+  - purpose: "*"
+    resource_pattern: "echo salary*"
+    decision: allow
+    alert: force
+  - purpose: "*"
+    resource_pattern: "echo noisy*"
+    decision: deny
+    alert: suppress
+alerts:
+  - url: ` + srv.URL + `
+    format: generic
+    events: [deny]
+`
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(path, []byte(policyYAML), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	g, err := NewGuard(Config{Purpose: "test", PolicyPath: path, Actor: map[string]any{"test": true}})
+	if err != nil {
+		t.Fatalf("failed to create guard: %v", err)
+	}
+	return g, &hits
+}
+
+func TestRuleAlertForceDispatchesBelowThreshold(t *testing.T) {
+	g, hits := newAlertingGuard(t)
+
+	if _, err := g.Run(context.Background(), "echo", []string{"salary", "report"}, nil); err != nil {
+		t.Fatalf("expected allow, got %v", err)
+	}
+	time.Sleep(200 * time.Millisecond)
+	if got := hits.Load(); got != 1 {
+		t.Fatalf("expected forced alert on allowed command, got %d", got)
+	}
+}
+
+func TestRuleAlertSuppressSkipsDispatch(t *testing.T) {
+	g, hits := newAlertingGuard(t)
+
+	_, err := g.Run(context.Background(), "echo", []string{"noisy"}, nil)
+	requireBlocked(t, err)
+	time.Sleep(200 * time.Millisecond)
+	if got := hits.Load(); got != 0 {
+		t.Fatalf("expected suppressed deny not to alert, got %d", got)
+	}
+}
+
+func TestDenylistWarnEntryAllowsAndAlerts(t *testing.T) {
+	alerted := make(chan alert.AlertEvent, 4)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var ev alert.AlertEvent
+		json.NewDecoder(r.Body).Decode(&ev)
+		alerted <- ev
+	}))
+	t.Cleanup(srv.Close)
+
+	dir := t.TempDir()
+	policyPath := filepath.Join(dir, "policy.yaml")
+	policyYAML := "enforcement_mode: guarded\nalerts:\n  - url: " + srv.URL + "\n    format: generic\n    events: [deny]\n"
+	if err := os.WriteFile(policyPath, []byte(policyYAML), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	denylistPath := filepath.Join(dir, "denylist.yaml")
+	denylistYAML := "commands:\n  - \"echo blocked\"\n  - pattern: \"echo staged\"\n    action: warn\n"
+	if err := os.WriteFile(denylistPath, []byte(denylistYAML), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	g, err := NewGuard(Config{Purpose: "test", PolicyPath: policyPath, DenylistPath: denylistPath, Actor: map[string]any{"test": true}})
+	if err != nil {
+		t.Fatalf("failed to create guard: %v", err)
+	}
+
+	if _, err := g.Run(context.Background(), "echo", []string{"staged", "entry"}, nil); err != nil {
+		t.Fatalf("expected warn entry to allow, got %v", err)
+	}
+	select {
+	case ev := <-alerted:
+		if ev.Decision != "allow" || !strings.Contains(ev.Reason, "denylist warn") {
+			t.Errorf("expected allow alert mentioning denylist warn, got %+v", ev)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected alert for warn entry")
+	}
+
+	if _, err := g.Run(context.Background(), "echo", []string{"blocked", "entry"}, nil); err == nil {
+		t.Fatal("expected normal denylist entry to block")
+	}
+}
+
+func TestNewGuardRequirePolicyFile(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "policy.yaml")
+
+	if _, err := NewGuard(Config{Purpose: "test", PolicyPath: missing, RequirePolicyFile: true}); err == nil {
+		t.Fatal("expected error for missing policy file in strict mode")
+	}
+
+	g, err := NewGuard(Config{Purpose: "test", PolicyPath: missing})
+	if err != nil {
+		t.Fatalf("expected defaults for missing policy file in lenient mode, got %v", err)
+	}
+	if g.policyCfg.EnforcementMode != "guarded" {
+		t.Errorf("expected default enforcement mode, got %q", g.policyCfg.EnforcementMode)
+	}
+}
+
+func TestEnrichmentHookLabelMatchedByRule(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req map[string]any
+		json.NewDecoder(r.Body).Decode(&req)
+		known := "true"
+		if strings.Contains(req["resource"].(string), "unknown-host") {
+			known = "false"
+		}
+		json.NewEncoder(w).Encode(map[string]any{"labels": map[string]string{"cmdb_known": known}})
+	}))
+	t.Cleanup(srv.Close)
+
+	policyYAML := `rules:
+  - purpose: "*"
+    resource_pattern: "*"
+    decision: deny
+    reason: destination not in CMDB
+    labels:
+      cmdb_known: "false"
+enrichment_hook:
+  url: ` + srv.URL + "\n"
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(path, []byte(policyYAML), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	g, err := NewGuard(Config{Purpose: "test", PolicyPath: path, Actor: map[string]any{"test": true}})
+	if err != nil {
+		t.Fatalf("failed to create guard: %v", err)
+	}
+
+	if _, err := g.Run(context.Background(), "echo", []string{"known-host"}, nil); err != nil {
+		t.Fatalf("expected CMDB-known target to be allowed, got %v", err)
+	}
+	_, err = g.Run(context.Background(), "echo", []string{"unknown-host"}, nil)
+	blocked := requireBlocked(t, err)
+	if !strings.Contains(blocked.Reason, "not in CMDB") {
+		t.Errorf("expected CMDB rule to match, got %s", blocked.Reason)
+	}
+}
+
+func TestEnrichmentHookRunsOutsideTraceLock(t *testing.T) {
+	var g *Guard
+	lockFree := make(chan bool, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		free := g.mu.TryLock()
+		if free {
+			g.mu.Unlock()
+		}
+		lockFree <- free
+		json.NewEncoder(w).Encode(map[string]any{"labels": map[string]string{"cmdb_known": "true"}})
+	}))
+	t.Cleanup(srv.Close)
+
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(path, []byte("enrichment_hook:\n  url: "+srv.URL+"\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var err error
+	g, err = NewGuard(Config{Purpose: "test", PolicyPath: path})
+	if err != nil {
+		t.Fatalf("failed to create guard: %v", err)
+	}
+
+	g.Check("echo", []string{"hello"})
+	if !<-lockFree {
+		t.Error("enrichment hook called while the trace lock was held")
+	}
+}
+
+func TestDecisionHookRunsOutsideTraceLock(t *testing.T) {
+	var g *Guard
+	lockFree := make(chan bool, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		free := g.mu.TryLock()
+		if free {
+			g.mu.Unlock()
+		}
+		lockFree <- free
+		json.NewEncoder(w).Encode(map[string]any{"decision": "allow"})
+	}))
+	t.Cleanup(srv.Close)
+
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(path, []byte("decision_hook:\n  url: "+srv.URL+"\n  min_tier: 1\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	var err error
+	g, err = NewGuard(Config{Purpose: "test", PolicyPath: path})
+	if err != nil {
+		t.Fatalf("failed to create guard: %v", err)
+	}
+
+	g.Check("sudo", []string{"ls"})
+	select {
+	case free := <-lockFree:
+		if !free {
+			t.Error("decision hook called while the trace lock was held")
+		}
+	default:
+		t.Fatal("expected the decision hook to be consulted")
+	}
+}
+
+func TestCloseAppendsTraceToTracePath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trace.jsonl")
+	g, err := NewGuard(Config{Purpose: "test", Actor: map[string]any{"test": true}, TracePath: path})
+	if err != nil {
+		t.Fatalf("failed to create guard: %v", err)
+	}
+	_, err = g.Run(context.Background(), "rm", []string{"-rf", "/"}, nil)
+	requireBlocked(t, err)
+	if err := g.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("trace file not written: %v", err)
+	}
+	var line tracer.ExportedAction
+	if err := json.Unmarshal(bytes.TrimSpace(data), &line); err != nil {
+		t.Fatalf("trace line does not parse: %v", err)
+	}
+	if line.Decision["result"] != "deny" || line.Action["resource"] != "rm -rf /" {
+		t.Errorf("unexpected trace line: %+v", line)
+	}
+}
+
+func TestEvaluatePanicDeniesAndReleasesLock(t *testing.T) {
+	g := newTestGuard(t)
+	state := g.tracer.State
+	g.tracer.State = nil // every evaluation step dereferences the trace state
+	result := g.Check("ls", nil)
+	g.tracer.State = state
+
+	if result.Decision != model.Deny || result.PolicyID != "evaluation.panic" {
+		t.Fatalf("expected evaluation.panic deny, got %s (%s)", result.Decision, result.PolicyID)
+	}
+	if !g.mu.TryLock() {
+		t.Fatal("panic left the trace lock held")
+	}
+	g.mu.Unlock()
+}
@@ -0,0 +1,129 @@
+package cmdguard
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/ppiankov/chainwatch/internal/model"
+	"github.com/ppiankov/chainwatch/internal/policy"
+)
+
+// transferCommands copy or move their source operands onto the last operand.
+var transferCommands = map[string]bool{
+	"mv": true, "cp": true, "install": true, "ln": true,
+}
+
+// deleteCommands remove their file operands.
+var deleteCommands = map[string]bool{
+	"rm": true, "rmdir": true, "unlink": true, "shred": true,
+}
+
+// transferValueFlags take a separate value that is not a file operand.
+var transferValueFlags = map[string]bool{
+	"-S": true, "--suffix": true, "-m": true, "--mode": true,
+	"-o": true, "--owner": true, "-g": true, "--group": true,
+}
+
+// FileOperands splits the operands of a transfer or delete command into
+// the sources it reads, the destination it writes and the targets it
+// deletes. "mv a b dir" has sources a and b and destination dir; -t and
+// --target-directory name the destination explicitly.
+func FileOperands(base string, operands []string) (sources []string, destination string, targets []string) {
+	if !transferCommands[base] && !deleteCommands[base] {
+		return nil, "", nil
+	}
+
+	var positional []string
+	flagsDone := false
+	for i := 0; i < len(operands); i++ {
+		op := operands[i]
+		switch {
+		case flagsDone || !strings.HasPrefix(op, "-") || op == "-":
+			if op != "" {
+				positional = append(positional, op)
+			}
+		case op == "--":
+			flagsDone = true
+		case op == "-t" || op == "--target-directory":
+			if i+1 < len(operands) {
+				i++
+				destination = operands[i]
+			}
+		case strings.HasPrefix(op, "--target-directory="):
+			destination = strings.TrimPrefix(op, "--target-directory=")
+		case transferValueFlags[op]:
+			i++
+		}
+	}
+
+	if deleteCommands[base] {
+		return nil, "", positional
+	}
+	if destination == "" {
+		if len(positional) < 2 {
+			return nil, "", nil
+		}
+		destination = positional[len(positional)-1]
+		positional = positional[:len(positional)-1]
+	}
+	return positional, destination, nil
+}
+
+// fileOperand is an action implied by one operand of a transfer or delete
+// command, with the operand's role for reasons.
+type fileOperand struct {
+	role   string // source, destination or target
+	action *model.Action
+}
+
+// operandActions maps the file operands of a transfer or delete command
+// to a file_read per source, a file_write for the destination and a
+// file_delete per target, so each operand is judged on its own risk.
+// Each file printed by a reader such as cat is a file_read source too.
+func operandActions(base string, operands []string) []fileOperand {
+	var ops []fileOperand
+	if fileReadCommands[base] {
+		for _, op := range operands {
+			if !strings.HasPrefix(op, "-") && model.LooksLikePath(op) {
+				ops = append(ops, fileOperand{"source", buildActionFromFileRead(op)})
+			}
+		}
+		return ops
+	}
+	sources, destination, targets := FileOperands(base, operands)
+	for _, src := range sources {
+		ops = append(ops, fileOperand{"source", buildActionFromFileRead(src)})
+	}
+	if destination != "" {
+		ops = append(ops, fileOperand{"destination", buildActionFromFileOp(destination, "file_write", "write")})
+	}
+	for _, target := range targets {
+		ops = append(ops, fileOperand{"target", buildActionFromFileOp(target, "file_delete", "delete")})
+	}
+	return ops
+}
+
+// evaluateOperands evaluates each source, destination and delete target of
+// a command action and returns the most restrictive of result and the
+// operand results. Operands are evaluated against copies of the trace
+// state so the command is only accounted once. Callers must hold g.mu.
+func (g *Guard) evaluateOperands(action *model.Action, result model.PolicyResult) model.PolicyResult {
+	if action.Tool != "command" {
+		return result
+	}
+	name, _ := action.Params["name"].(string)
+	args, _ := action.Params["args"].([]string)
+	argv := stripCommandWrappers(append([]string{name}, args...))
+	if len(argv) == 0 {
+		return result
+	}
+	for _, op := range operandActions(path.Base(argv[0]), argv[1:]) {
+		opResult := policy.Evaluate(op.action, g.tracer.State.Clone(), g.cfg.Purpose, g.cfg.AgentID, g.dl, g.policyCfg)
+		if opResult.Decision.MoreRestrictive(result.Decision) {
+			opResult.Reason = fmt.Sprintf("%s %q: %s", op.role, op.action.Resource, opResult.Reason)
+			result = opResult
+		}
+	}
+	return result
+}
@@ -0,0 +1,95 @@
+package cmdguard
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/ppiankov/chainwatch/internal/model"
+)
+
+func TestFileOperands(t *testing.T) {
+	tests := []struct {
+		base    string
+		args    []string
+		sources []string
+		dest    string
+		targets []string
+	}{
+		{"mv", []string{"~/.ssh/id_rsa", "/tmp/x"}, []string{"~/.ssh/id_rsa"}, "/tmp/x", nil},
+		{"cp", []string{"-r", "a", "b", "dir/"}, []string{"a", "b"}, "dir/", nil},
+		{"cp", []string{"-t", "/etc/cron.d", "job"}, []string{"job"}, "/etc/cron.d", nil},
+		{"install", []string{"-m", "0755", "bin/tool", "/usr/local/bin/tool"}, []string{"bin/tool"}, "/usr/local/bin/tool", nil},
+		{"mv", []string{"--", "-odd", "/tmp/y"}, []string{"-odd"}, "/tmp/y", nil},
+		{"rm", []string{"-f", "/a", "/b"}, nil, "", []string{"/a", "/b"}},
+		{"mv", []string{"only"}, nil, "", nil},
+		{"cat", []string{"/etc/hosts"}, nil, "", nil},
+	}
+	for _, tt := range tests {
+		sources, dest, targets := FileOperands(tt.base, tt.args)
+		if !reflect.DeepEqual(sources, tt.sources) || dest != tt.dest || !reflect.DeepEqual(targets, tt.targets) {
+			t.Errorf("FileOperands(%s %q) = %q, %q, %q", tt.base, tt.args, sources, dest, targets)
+		}
+	}
+}
+
+func TestBuildActionRecordsSourceAndDestination(t *testing.T) {
+	action := buildActionFromCommand("sudo", []string{"mv", "/tmp/x", "/etc/cron.d/y"}, nil)
+	if got := action.Params["sources"]; !reflect.DeepEqual(got, []string{"/tmp/x"}) {
+		t.Errorf("sources = %v", got)
+	}
+	if got := action.Params["destination"]; got != "/etc/cron.d/y" {
+		t.Errorf("destination = %v", got)
+	}
+}
+
+func TestMoveFlaggedOnSensitiveSource(t *testing.T) {
+	g := newTestGuard(t)
+	result := g.Check("mv", []string{"~/.ssh/id_rsa", "/tmp/x"})
+	if result.Decision != model.Deny {
+		t.Fatalf("expected deny, got %s (%s)", result.Decision, result.Reason)
+	}
+	if !strings.HasPrefix(result.Reason, `source "~/.ssh/id_rsa"`) {
+		t.Errorf("expected reason to name the source, got %q", result.Reason)
+	}
+}
+
+// newProtectedGuard returns a guard whose policy enables the built-in
+// protected paths.
+func newProtectedGuard(t *testing.T) *Guard {
+	t.Helper()
+	policyPath := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(policyPath, []byte("default_protected_paths: true\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	g, err := NewGuard(Config{Purpose: "test", PolicyPath: policyPath, Actor: map[string]any{"test": true}})
+	if err != nil {
+		t.Fatalf("failed to create guard: %v", err)
+	}
+	return g
+}
+
+func TestMoveFlaggedOnProtectedDestination(t *testing.T) {
+	g := newProtectedGuard(t)
+	result := g.Check("mv", []string{"/tmp/x", "/etc/cron.d/y"})
+	if result.Decision != model.RequireApproval {
+		t.Fatalf("expected require_approval, got %s (%s)", result.Decision, result.Reason)
+	}
+	if !strings.HasPrefix(result.Reason, `destination "/etc/cron.d/y"`) || !strings.Contains(result.Reason, "protected path /etc/") {
+		t.Errorf("expected reason to name the protected destination, got %q", result.Reason)
+	}
+
+	if result := g.Check("mv", []string{"/tmp/x", "/tmp/y"}); result.Decision != model.Allow {
+		t.Errorf("expected move between temp files allowed, got %s (%s)", result.Decision, result.Reason)
+	}
+}
+
+func TestPipelineMoveFlaggedOnDestination(t *testing.T) {
+	g := newProtectedGuard(t)
+	result := g.Check("sh", []string{"-c", "echo '* * * * * root sh /tmp/x' > /tmp/job && mv /tmp/job /etc/cron.d/job"})
+	if result.Decision == model.Allow {
+		t.Fatalf("expected pipeline move onto /etc/cron.d to be flagged, got %s (%s)", result.Decision, result.Reason)
+	}
+}
@@ -0,0 +1,306 @@
+package cmdguard
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/ppiankov/chainwatch/internal/model"
+	"github.com/ppiankov/chainwatch/internal/policy"
+)
+
+// maxPipelineStages bounds how many stages of a shell script are evaluated,
+// so a pathological script cannot stall the guard.
+const maxPipelineStages = 32
+
+// shellInterpreters run the script passed with -c.
+var shellInterpreters = map[string]bool{
+	"sh": true, "bash": true, "zsh": true, "dash": true, "ksh": true,
+}
+
+// fileReadCommands print or encode the files named by their operands.
+var fileReadCommands = map[string]bool{
+	"cat": true, "head": true, "tail": true, "less": true, "more": true,
+	"tac": true, "nl": true, "base64": true, "xxd": true, "od": true,
+	"hexdump": true, "strings": true,
+}
+
+// httpCommands send requests to the URLs among their operands.
+var httpCommands = map[string]bool{
+	"curl": true, "wget": true,
+}
+
+// pipelineStage is one simple command of a shell script.
+type pipelineStage struct {
+	args []string // command name followed by its arguments
+}
+
+// shellScript returns the script of a "sh -c <script>" invocation.
+func shellScript(name string, args []string) (string, bool) {
+	if !shellInterpreters[path.Base(name)] {
+		return "", false
+	}
+	for i, arg := range args {
+		if !strings.HasPrefix(arg, "-") || strings.HasPrefix(arg, "--") {
+			return "", false
+		}
+		if strings.Contains(arg, "c") {
+			if i+1 < len(args) {
+				return args[i+1], true
+			}
+			return "", false
+		}
+	}
+	return "", false
+}
+
+// parsePipeline splits a shell script into stages on |, ||, &&, ; and &,
+// honoring single and double quotes and backslash escapes. It is not a
+// full shell parser: substitutions and redirections stay in the arguments.
+func parsePipeline(script string) []pipelineStage {
+	var stages []pipelineStage
+	var cur pipelineStage
+	var word strings.Builder
+	inWord := false
+	var quote rune
+	escaped := false
+
+	flushWord := func() {
+		if inWord {
+			cur.args = append(cur.args, word.String())
+			word.Reset()
+			inWord = false
+		}
+	}
+	endStage := func() {
+		flushWord()
+		if len(cur.args) > 0 && len(stages) < maxPipelineStages {
+			stages = append(stages, cur)
+		}
+		cur = pipelineStage{}
+	}
+
+	runes := []rune(script)
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case escaped:
+			word.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inWord = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				word.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inWord = true
+		case r == '&' && ((i > 0 && runes[i-1] == '>') || (i+1 < len(runes) && runes[i+1] == '>')):
+			// Part of a redirection such as 2>&1 or &>file.
+			word.WriteRune(r)
+			inWord = true
+		case r == '|' || r == '&':
+			if i+1 < len(runes) && runes[i+1] == r {
+				i++
+			}
+			endStage()
+		case r == ';' || r == '\n':
+			endStage()
+		case r == ' ' || r == '\t':
+			flushWord()
+		default:
+			word.WriteRune(r)
+			inWord = true
+		}
+	}
+	endStage()
+	return stages
+}
+
+// commandStages returns the stages of a shell-wrapped command action, or
+// nil when the action is not a shell script of more than one stage.
+func commandStages(action *model.Action) []pipelineStage {
+	if action.Tool != "command" {
+		return nil
+	}
+	name, _ := action.Params["name"].(string)
+	args, _ := action.Params["args"].([]string)
+	script, ok := shellScript(name, args)
+	if !ok {
+		return nil
+	}
+	stages := parsePipeline(script)
+	if len(stages) < 2 {
+		return nil
+	}
+	return stages
+}
+
+// buildStageActions maps a pipeline stage to the actions it performs: a
+// file_read per file operand of a reader, an http request per URL of curl
+// or wget, and a command action for anything else.
+func buildStageActions(stage pipelineStage, passthrough []string) []*model.Action {
+	args := stripCommandWrappers(stage.args)
+	if len(args) == 0 {
+		return nil
+	}
+	base := path.Base(args[0])
+	operands := args[1:]
+
+	var actions []*model.Action
+	switch {
+	case fileReadCommands[base]:
+		for _, op := range operands {
+			if !strings.HasPrefix(op, "-") && model.LooksLikePath(op) {
+				actions = append(actions, buildActionFromFileRead(op))
+			}
+		}
+	case httpCommands[base]:
+		method := httpMethod(base, operands)
+		for _, op := range operands {
+			if strings.Contains(op, "://") {
+				actions = append(actions, buildActionFromHTTPCommand(op, method))
+			}
+		}
+	case transferCommands[base] || deleteCommands[base]:
+		for _, op := range operandActions(base, operands) {
+			actions = append(actions, op.action)
+		}
+	}
+	if len(actions) == 0 {
+		actions = append(actions, buildActionFromCommand(args[0], operands, passthrough))
+	}
+	return actions
+}
+
+// stripCommandWrappers drops env assignments and privilege wrappers in
+// front of a command.
+func stripCommandWrappers(args []string) []string {
+	for len(args) > 0 && (args[0] == "sudo" || (strings.Contains(args[0], "=") && !strings.HasPrefix(args[0], "-"))) {
+		args = args[1:]
+	}
+	return args
+}
+
+// httpMethod infers the request method of a curl or wget invocation: an
+// explicit -X/--request/--method, otherwise POST when a body is sent.
+func httpMethod(base string, operands []string) string {
+	method := "GET"
+	for i, op := range operands {
+		switch {
+		case op == "-X" || op == "--request" || op == "--method":
+			if i+1 < len(operands) {
+				return strings.ToUpper(operands[i+1])
+			}
+		case strings.HasPrefix(op, "-X") && len(op) > 2:
+			return strings.ToUpper(op[2:])
+		case strings.HasPrefix(op, "--request=") || strings.HasPrefix(op, "--method="):
+			return strings.ToUpper(op[strings.Index(op, "=")+1:])
+		case base == "curl" && (op == "-d" || op == "-F" || op == "-T" || strings.HasPrefix(op, "--data") ||
+			op == "--form" || op == "--json" || op == "--upload-file"):
+			method = "POST"
+		case base == "wget" && (strings.HasPrefix(op, "--post-") || strings.HasPrefix(op, "--body-")):
+			method = "POST"
+		}
+	}
+	return method
+}
+
+// buildActionFromFileRead maps a file read by a pipeline stage or a
+// transfer source to a file_read action. Credential files are tagged secret.
+func buildActionFromFileRead(file string) *model.Action {
+	return buildActionFromFileOp(file, "file_read", "read")
+}
+
+// buildActionFromFileOp maps a file operand to an action with the given
+// tool and operation. Sensitive files are tagged as for file writes. Reads carry the
+// file's size when it can be stat'ed, for size threshold rules.
+func buildActionFromFileOp(file, tool, operation string) *model.Action {
+	var size int
+	if tool == "file_read" {
+		size, _ = model.FileReadVolume(file, nil)
+	}
+	resource, rawResource := model.DecodeResource(file)
+	sensitivity := model.SensLow
+	var tags []string
+	lower := strings.ToLower(resource)
+	for _, p := range SensitiveFilePatterns {
+		if strings.Contains(lower, p) {
+			sensitivity = model.SensHigh
+			tags = []string{"sensitive_file"}
+			break
+		}
+	}
+
+	return &model.Action{
+		Tool:      tool,
+		Resource:  resource,
+		Operation: operation,
+		Params:    map[string]any{"path": file},
+		RawMeta: map[string]any{
+			"sensitivity": string(sensitivity),
+			"tags":        toAnySlice(tags),
+			"bytes":       size,
+			"rows":        0,
+			"egress":      string(model.EgressInternal),
+			"destination": "",
+		},
+		RawResource: rawResource,
+	}
+}
+
+// buildActionFromHTTPCommand maps a request sent by curl or wget to an
+// http action.
+func buildActionFromHTTPCommand(rawURL, method string) *model.Action {
+	resource, rawResource := model.DecodeResource(rawURL)
+	host := resource
+	if i := strings.Index(host, "://"); i >= 0 {
+		host = host[i+3:]
+	}
+	if i := strings.IndexAny(host, "/?#"); i >= 0 {
+		host = host[:i]
+	}
+
+	return &model.Action{
+		Tool:      "http",
+		Resource:  resource,
+		Operation: method,
+		Params:    map[string]any{"url": rawURL, "method": method},
+		RawMeta: map[string]any{
+			"sensitivity": string(model.SensMedium),
+			"tags":        []any{"network"},
+			"bytes":       0,
+			"rows":        0,
+			"egress":      string(model.EgressExternal),
+			"destination": host,
+		},
+		RawResource: rawResource,
+	}
+}
+
+// evaluateStages evaluates each stage of a shell pipeline and returns the
+// most restrictive of result and the stage results. Stages are evaluated
+// in order against one copy of the trace state, so a credential read
+// followed by an upload escalates like the same two actions would; the
+// command itself is only accounted once. Callers must hold g.mu.
+func (g *Guard) evaluateStages(action *model.Action, result model.PolicyResult) model.PolicyResult {
+	stages := commandStages(action)
+	if len(stages) == 0 {
+		return result
+	}
+	state := g.tracer.State.Clone()
+	for i, stage := range stages {
+		for _, stageAction := range buildStageActions(stage, g.cfg.EnvPassthrough) {
+			stageResult := policy.Evaluate(stageAction, state, g.cfg.Purpose, g.cfg.AgentID, g.dl, g.policyCfg)
+			if stageResult.Decision.MoreRestrictive(result.Decision) {
+				stageResult.Reason = fmt.Sprintf("pipeline stage %d (%s): %s", i+1, stageAction.Resource, stageResult.Reason)
+				result = stageResult
+			}
+		}
+	}
+	return result
+}
@@ -0,0 +1,56 @@
+package cmdguard
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ppiankov/chainwatch/internal/model"
+)
+
+func TestFileReadSizeThreshold(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	dir := t.TempDir()
+	policyYAML := `rules:
+  - purpose: "*"
+    resource_pattern: "*"
+    decision: require_approval
+    approval_key: bulk_read
+    min_bytes: 104857600
+`
+	policyPath := filepath.Join(dir, "policy.yaml")
+	if err := os.WriteFile(policyPath, []byte(policyYAML), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	small := filepath.Join(dir, "app.conf")
+	if err := os.WriteFile(small, []byte("port = 8080\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	large := filepath.Join(dir, "dump.sql")
+	f, err := os.Create(large)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Sparse: 200MB on stat without writing the data.
+	if err := f.Truncate(200 << 20); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	g, err := NewGuard(Config{Purpose: "test", PolicyPath: policyPath, Actor: map[string]any{"test": true}})
+	if err != nil {
+		t.Fatalf("failed to create guard: %v", err)
+	}
+	defer g.Close()
+	ctx := context.Background()
+
+	if _, err := g.Run(ctx, "head", []string{"-c", "1", small}, nil); err != nil {
+		t.Fatalf("expected small read allowed, got %v", err)
+	}
+	_, err = g.Run(ctx, "head", []string{"-c", "1", large}, nil)
+	if blocked := requireBlocked(t, err); blocked.Decision != model.RequireApproval || blocked.ApprovalKey != "bulk_read" {
+		t.Fatalf("expected large read to require bulk_read approval, got %s %q", blocked.Decision, blocked.ApprovalKey)
+	}
+}
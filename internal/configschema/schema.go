@@ -0,0 +1,188 @@
+// Package configschema derives JSON Schemas from chainwatch config structs.
+//
+// Schemas are generated by reflection over the `yaml` struct tags, so any
+// field added to PolicyConfig or Profile appears in the emitted schema
+// without a separate edit. The output is intended for editor validation
+// and autocomplete of hand-written policy.yaml and profile YAML files.
+package configschema
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/jsonschema-go/jsonschema"
+
+	"github.com/ppiankov/chainwatch/internal/alert"
+	"github.com/ppiankov/chainwatch/internal/model"
+	"github.com/ppiankov/chainwatch/internal/policy"
+	"github.com/ppiankov/chainwatch/internal/profile"
+)
+
+// Draft is the JSON Schema dialect emitted by this package.
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+var (
+	durationType    = reflect.TypeOf(time.Duration(0))
+	sensitivityType = reflect.TypeOf(model.Sensitivity(""))
+	ruleAlertType   = reflect.TypeOf(alert.RuleAlert{})
+)
+
+// Policy returns the JSON Schema for policy.yaml (policy.PolicyConfig).
+func Policy() *jsonschema.Schema {
+	s := For(reflect.TypeOf(policy.PolicyConfig{}))
+	s.Schema = Draft
+	s.Title = "chainwatch policy configuration"
+	return s
+}
+
+// Profile returns the JSON Schema for profile YAML files (profile.Profile).
+func Profile() *jsonschema.Schema {
+	s := For(reflect.TypeOf(profile.Profile{}))
+	s.Schema = Draft
+	s.Title = "chainwatch safety profile"
+	return s
+}
+
+// ByName returns the schema for a config kind ("policy" or "profile").
+// Returns nil for unknown kinds.
+func ByName(name string) *jsonschema.Schema {
+	switch name {
+	case "policy":
+		return Policy()
+	case "profile":
+		return Profile()
+	default:
+		return nil
+	}
+}
+
+// Names returns the config kinds supported by ByName.
+func Names() []string {
+	return []string{"policy", "profile"}
+}
+
+// Marshal renders a schema as indented JSON.
+func Marshal(s *jsonschema.Schema) ([]byte, error) {
+	return json.MarshalIndent(s, "", "  ")
+}
+
+// For builds a schema for an arbitrary Go type using its yaml tags.
+// Structs are closed (additionalProperties: false) so typos in keys fail validation.
+func For(t reflect.Type) *jsonschema.Schema {
+	return build(t, make(map[reflect.Type]bool))
+}
+
+func build(t reflect.Type, visiting map[reflect.Type]bool) *jsonschema.Schema {
+	// Special-cased named types before kind dispatch.
+	switch t {
+	case durationType:
+		// yaml.v3 accepts "30m" strings or raw nanosecond integers.
+		return &jsonschema.Schema{
+			Types:       []string{"string", "integer"},
+			Description: "duration (e.g. 30s, 5m, 1h)",
+		}
+	case sensitivityType:
+		return &jsonschema.Schema{
+			Type: "string",
+			Enum: []any{string(model.SensLow), string(model.SensMedium), string(model.SensHigh)},
+		}
+	case ruleAlertType:
+		// Bare mode ("alert: force") or the full mapping.
+		modes := []any{alert.RuleAlertForce, alert.RuleAlertSuppress}
+		obj := buildStruct(t, visiting)
+		obj.Properties["mode"].Enum = modes
+		return &jsonschema.Schema{AnyOf: []*jsonschema.Schema{
+			{Type: "string", Enum: modes},
+			obj,
+		}}
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		return nullable(build(t.Elem(), visiting))
+	case reflect.String:
+		return &jsonschema.Schema{Type: "string"}
+	case reflect.Bool:
+		return &jsonschema.Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return &jsonschema.Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &jsonschema.Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		return nullable(&jsonschema.Schema{Type: "array", Items: build(t.Elem(), visiting)})
+	case reflect.Map:
+		return nullable(&jsonschema.Schema{Type: "object", AdditionalProperties: build(t.Elem(), visiting)})
+	case reflect.Struct:
+		if visiting[t] {
+			// Recursive type: stop descending, accept anything.
+			return &jsonschema.Schema{}
+		}
+		return buildStruct(t, visiting)
+	default:
+		// interface{} and anything else: no constraint.
+		return &jsonschema.Schema{}
+	}
+}
+
+// buildStruct builds a closed object schema from a struct's fields.
+func buildStruct(t reflect.Type, visiting map[reflect.Type]bool) *jsonschema.Schema {
+	visiting[t] = true
+	defer delete(visiting, t)
+
+	s := &jsonschema.Schema{
+		Type:                 "object",
+		Properties:           make(map[string]*jsonschema.Schema),
+		AdditionalProperties: &jsonschema.Schema{Not: &jsonschema.Schema{}},
+	}
+	addFields(s, t, visiting)
+	return s
+}
+
+// addFields adds struct fields as properties, flattening `yaml:",inline"` members.
+func addFields(s *jsonschema.Schema, t reflect.Type, visiting map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+		if name == "-" {
+			continue
+		}
+
+		ft := f.Type
+		if strings.Contains(opts, "inline") {
+			for ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				addFields(s, ft, visiting)
+				continue
+			}
+		}
+
+		if name == "" {
+			// yaml.v3 default key is the lowercased field name.
+			name = strings.ToLower(f.Name)
+		}
+		s.Properties[name] = build(ft, visiting)
+		s.PropertyOrder = append(s.PropertyOrder, name)
+	}
+}
+
+// nullable widens a schema to also accept null, matching YAML's
+// treatment of empty keys for pointers, slices, and maps.
+func nullable(s *jsonschema.Schema) *jsonschema.Schema {
+	switch {
+	case s.Type != "":
+		s.Types = []string{s.Type, "null"}
+		s.Type = ""
+	case len(s.Types) > 0:
+		s.Types = append(s.Types, "null")
+	}
+	return s
+}
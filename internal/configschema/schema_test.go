@@ -0,0 +1,143 @@
+package configschema
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/jsonschema-go/jsonschema"
+	"gopkg.in/yaml.v3"
+
+	"github.com/ppiankov/chainwatch/internal/policy"
+)
+
+// validateYAML round-trips the emitted schema through JSON (as an editor would
+// consume it) and validates a YAML document against it.
+func validateYAML(t *testing.T, s *jsonschema.Schema, doc string) error {
+	t.Helper()
+
+	raw, err := Marshal(s)
+	if err != nil {
+		t.Fatalf("marshal schema: %v", err)
+	}
+	var parsed jsonschema.Schema
+	if err := json.Unmarshal(raw, &parsed); err != nil {
+		t.Fatalf("unmarshal schema: %v", err)
+	}
+	resolved, err := parsed.Resolve(nil)
+	if err != nil {
+		t.Fatalf("resolve schema: %v", err)
+	}
+
+	var v any
+	if err := yaml.Unmarshal([]byte(doc), &v); err != nil {
+		t.Fatalf("parse yaml: %v", err)
+	}
+	// Normalize YAML scalars to JSON types.
+	j, err := json.Marshal(v)
+	if err != nil {
+		t.Fatalf("yaml to json: %v", err)
+	}
+	var instance any
+	if err := json.Unmarshal(j, &instance); err != nil {
+		t.Fatalf("decode json: %v", err)
+	}
+	return resolved.Validate(instance)
+}
+
+func TestPolicySchemaAcceptsDefaultConfig(t *testing.T) {
+	if err := validateYAML(t, Policy(), policy.DefaultConfigYAML()); err != nil {
+		t.Errorf("default policy.yaml should validate: %v", err)
+	}
+}
+
+func TestPolicySchemaAcceptsFullConfig(t *testing.T) {
+	doc := `
+enforcement_mode: locked
+min_tier: 1
+rules:
+  - purpose: "*"
+    resource_pattern: "*secret*"
+    decision: deny
+    alert: suppress
+  - purpose: "*"
+    resource_pattern: "*salary*"
+    decision: allow
+    alert:
+      mode: force
+      channels: [telegram]
+alerts:
+  - channel: telegram
+    events: [deny]
+    telegram:
+      bot_token: x
+      chat_id: "1"
+agents:
+  bot:
+    purposes: [ops]
+    allow_resources: ["/tmp/*"]
+    max_sensitivity: medium
+budgets:
+  "*":
+    max_bytes: 1024
+    max_duration: 30m
+rate_limits:
+  bot:
+    command:
+      max_requests: 10
+      window: 1m
+`
+	if err := validateYAML(t, Policy(), doc); err != nil {
+		t.Errorf("full policy should validate: %v", err)
+	}
+}
+
+func TestPolicySchemaRejectsInvalid(t *testing.T) {
+	tests := []struct {
+		name string
+		doc  string
+	}{
+		{"unknown key", "enforcement_modee: guarded\n"},
+		{"wrong type", "min_tier: high\n"},
+		{"bad sensitivity", "agents:\n  bot:\n    max_sensitivity: extreme\n"},
+		{"unknown rule key", "rules:\n  - purpose: x\n    decsion: deny\n"},
+		{"unknown alert mode", "rules:\n  - purpose: x\n    alert: loud\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateYAML(t, Policy(), tt.doc); err == nil {
+				t.Error("expected validation error")
+			}
+		})
+	}
+}
+
+func TestProfileSchemaAcceptsBuiltins(t *testing.T) {
+	paths, err := filepath.Glob("../profile/profiles/*.yaml")
+	if err != nil || len(paths) == 0 {
+		t.Fatalf("no built-in profiles found: %v", err)
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := validateYAML(t, Profile(), string(data)); err != nil {
+			t.Errorf("built-in profile %s should validate: %v", filepath.Base(path), err)
+		}
+	}
+}
+
+func TestProfileSchemaRejectsInvalid(t *testing.T) {
+	doc := "name: x\nexecution_boundaries:\n  urls: not-a-list\n"
+	if err := validateYAML(t, Profile(), doc); err == nil {
+		t.Error("expected validation error for non-list urls")
+	}
+}
+
+func TestByNameUnknown(t *testing.T) {
+	if ByName("denylist") != nil {
+		t.Error("expected nil for unknown schema name")
+	}
+}
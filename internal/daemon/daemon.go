@@ -11,7 +11,9 @@ import (
 	"syscall"
 	"time"
 
+	"github.com/ppiankov/chainwatch/internal/alert"
 	"github.com/ppiankov/chainwatch/internal/observe"
+	"github.com/ppiankov/chainwatch/internal/policy"
 	"github.com/ppiankov/chainwatch/internal/redact"
 	"github.com/ppiankov/chainwatch/internal/systemd"
 	"github.com/ppiankov/chainwatch/internal/wo"
@@ -33,12 +35,25 @@ type Config struct {
 	LLMRateLimit  int // requests per minute; 0 = unlimited
 	LLMFallbacks  []observe.LLMProvider
 	LLMPool       []observe.LLMProvider
+
+	// Policy is the chainwatch policy file. Investigation steps are
+	// enforced with it, and its alert channels receive pending-approval
+	// expiry reminders and expired notices. Empty uses the default
+	// ~/.chainwatch/policy.yaml.
+	Policy string
+	// ExpiryReminder is how long before a pending WO expires the reminder
+	// is sent (default 1h).
+	ExpiryReminder time.Duration
+	// ClassifyCacheTTL reuses the classification of identical evidence
+	// for this long instead of calling the LLM again (0 = disabled).
+	ClassifyCacheTTL time.Duration
 }
 
 // Daemon watches the inbox directory and processes jobs.
 type Daemon struct {
 	cfg       Config
 	processor *Processor
+	live      *policy.Live
 }
 
 // New creates a daemon with validated configuration.
@@ -54,6 +69,7 @@ func New(cfg Config) (*Daemon, error) {
 		Dirs:          cfg.Dirs,
 		Chainwatch:    cfg.Chainwatch,
 		AuditLog:      cfg.AuditLog,
+		Policy:        cfg.Policy,
 		APIURL:        cfg.APIURL,
 		APIKey:        cfg.APIKey,
 		Model:         cfg.Model,
@@ -62,11 +78,19 @@ func New(cfg Config) (*Daemon, error) {
 		LLMRateLimit:  cfg.LLMRateLimit,
 		LLMFallbacks:  cfg.LLMFallbacks,
 		LLMPool:       cfg.LLMPool,
+
+		ClassifyCacheTTL: cfg.ClassifyCacheTTL,
 	})
 
+	live, err := policy.NewLive(loadPolicy(cfg.Policy))
+	if err != nil {
+		return nil, err
+	}
+
 	return &Daemon{
 		cfg:       cfg,
 		processor: processor,
+		live:      live,
 	}, nil
 }
 
@@ -106,7 +130,8 @@ func (d *Daemon) Run(ctx context.Context) error {
 
 	// Start expiration sweeper in background.
 	gateway := NewGateway(d.cfg.Dirs.Outbox, d.cfg.Dirs.State, defaultTTL)
-	go d.runExpirationSweeper(ctx, gateway)
+	notifier := NewExpiryNotifier(gateway, func() *alert.Dispatcher { return d.live.Snapshot().Dispatcher }, d.cfg.ExpiryReminder)
+	go d.runExpirationSweeper(ctx, notifier)
 
 	// Start cache retry sweeper — retries cached observations when LLM becomes available.
 	go d.runCacheRetrySweeper(ctx)
@@ -130,8 +155,9 @@ func (d *Daemon) Run(ctx context.Context) error {
 // expirationInterval is how often the sweeper checks for expired WOs.
 const expirationInterval = 5 * time.Minute
 
-// runExpirationSweeper periodically checks for expired pending WOs.
-func (d *Daemon) runExpirationSweeper(ctx context.Context, gateway *Gateway) {
+// runExpirationSweeper periodically checks for expired pending WOs and
+// reminds operators about WOs close to expiry.
+func (d *Daemon) runExpirationSweeper(ctx context.Context, notifier *ExpiryNotifier) {
 	ticker := time.NewTicker(expirationInterval)
 	defer ticker.Stop()
 
@@ -140,7 +166,7 @@ func (d *Daemon) runExpirationSweeper(ctx context.Context, gateway *Gateway) {
 		case <-ctx.Done():
 			return
 		case <-ticker.C:
-			n, err := gateway.CheckExpired()
+			n, err := notifier.Sweep()
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "daemon: expiration sweep: %v\n", err)
 			} else if n > 0 {
@@ -19,6 +19,7 @@ func testDaemonConfig(t *testing.T) Config {
 			State:  filepath.Join(root, "state"),
 		},
 		Chainwatch:   "/nonexistent/chainwatch",
+		Policy:       filepath.Join(root, "policy.yaml"),
 		PollMode:     true,
 		PollInterval: 50 * time.Millisecond,
 	}
@@ -42,6 +43,58 @@ func TestNewDaemonValid(t *testing.T) {
 	}
 }
 
+func TestDaemonReloadPolicy(t *testing.T) {
+	cfg := testDaemonConfig(t)
+	writePolicy := func(body string) {
+		t.Helper()
+		if err := os.WriteFile(cfg.Policy, []byte(body), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	alertURL := func(d *Daemon) string {
+		alerts := d.live.Snapshot().Config.Alerts
+		if len(alerts) != 1 {
+			t.Fatalf("expected 1 alert channel, got %d", len(alerts))
+		}
+		return alerts[0].URL
+	}
+
+	writePolicy("alerts:\n  - url: http://old.example\n")
+	d, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if got := alertURL(d); got != "http://old.example" {
+		t.Fatalf("alert URL = %q, want the configured policy's", got)
+	}
+
+	writePolicy("alerts:\n  - url: http://new.example\n")
+	if err := d.ReloadPolicy(); err != nil {
+		t.Fatalf("ReloadPolicy: %v", err)
+	}
+	if got := alertURL(d); got != "http://new.example" {
+		t.Errorf("alert URL after reload = %q", got)
+	}
+
+	writePolicy("alerts: [unclosed\n")
+	if err := d.ReloadPolicy(); err == nil {
+		t.Fatal("expected reload error for invalid policy")
+	}
+	if got := alertURL(d); got != "http://new.example" {
+		t.Errorf("failed reload changed alert URL to %q", got)
+	}
+}
+
+func TestNewDaemonRejectsInvalidPolicy(t *testing.T) {
+	cfg := testDaemonConfig(t)
+	if err := os.WriteFile(cfg.Policy, []byte("alerts: [unclosed\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(cfg); err == nil {
+		t.Fatal("expected error for invalid policy")
+	}
+}
+
 func TestDaemonProcessesExistingFiles(t *testing.T) {
 	cfg := testDaemonConfig(t)
 	if err := EnsureDirs(cfg.Dirs); err != nil {
@@ -21,6 +21,9 @@ type Gateway struct {
 	stateDir string
 	ttl      time.Duration
 	mu       sync.Mutex
+
+	// now is the gateway clock; tests replace it to age pending WOs.
+	now func() time.Time
 }
 
 // PendingWO wraps a result with metadata for the approval UI.
@@ -41,6 +44,7 @@ func NewGateway(outbox, stateDir string, ttl time.Duration) *Gateway {
 		outbox:   outbox,
 		stateDir: stateDir,
 		ttl:      ttl,
+		now:      time.Now,
 	}
 }
 
@@ -76,23 +80,27 @@ func (g *Gateway) PendingWOs() ([]PendingWO, error) {
 			createdAt = info.ModTime()
 		}
 
-		pw := PendingWO{
-			ID:        r.ID,
-			CreatedAt: createdAt,
-			ExpiresAt: createdAt.Add(g.ttl),
-		}
+		pending = append(pending, pendingWO(r, createdAt, g.ttl))
+	}
+	return pending, nil
+}
 
-		// Extract target from WO if present.
-		if r.ProposedWO != nil {
-			pw.Target = JobTarget{
-				Host:  r.ProposedWO.Target.Host,
-				Scope: r.ProposedWO.Target.Scope,
-			}
-		}
+// pendingWO builds the approval UI view of a pending result.
+func pendingWO(r *Result, createdAt time.Time, ttl time.Duration) PendingWO {
+	pw := PendingWO{
+		ID:        r.ID,
+		CreatedAt: createdAt,
+		ExpiresAt: createdAt.Add(ttl),
+	}
 
-		pending = append(pending, pw)
+	// Extract target from WO if present.
+	if r.ProposedWO != nil {
+		pw.Target = JobTarget{
+			Host:  r.ProposedWO.Target.Host,
+			Scope: r.ProposedWO.Target.Scope,
+		}
 	}
-	return pending, nil
+	return pw
 }
 
 // Approve moves a pending WO from outbox to state/approved/.
@@ -119,7 +127,7 @@ func (g *Gateway) Approve(woID string) error {
 	if err != nil {
 		return err
 	}
-	if time.Since(info.ModTime()) > g.ttl {
+	if g.now().Sub(info.ModTime()) > g.ttl {
 		return fmt.Errorf("WO %q has expired", woID)
 	}
 
@@ -184,18 +192,25 @@ func (g *Gateway) Reject(woID, reason string) error {
 // CheckExpired scans pending WOs and moves expired ones to rejected.
 // Returns the number of WOs expired.
 func (g *Gateway) CheckExpired() (int, error) {
+	expired, err := g.ExpireLapsed()
+	return len(expired), err
+}
+
+// ExpireLapsed moves pending WOs past their TTL to rejected and returns
+// them, so callers can report each lapsed approval.
+func (g *Gateway) ExpireLapsed() ([]PendingWO, error) {
 	g.mu.Lock()
 	defer g.mu.Unlock()
 
 	entries, err := os.ReadDir(g.outbox)
 	if err != nil {
 		if os.IsNotExist(err) {
-			return 0, nil
+			return nil, nil
 		}
-		return 0, err
+		return nil, err
 	}
 
-	var expired int
+	var expired []PendingWO
 	for _, e := range entries {
 		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
 			continue
@@ -210,7 +225,7 @@ func (g *Gateway) CheckExpired() (int, error) {
 		if err != nil {
 			continue
 		}
-		if time.Since(info.ModTime()) <= g.ttl {
+		if g.now().Sub(info.ModTime()) <= g.ttl {
 			continue
 		}
 
@@ -228,7 +243,7 @@ func (g *Gateway) CheckExpired() (int, error) {
 			continue
 		}
 		_ = os.Remove(src)
-		expired++
+		expired = append(expired, pendingWO(r, info.ModTime(), g.ttl))
 	}
 	return expired, nil
 }
@@ -0,0 +1,111 @@
+package daemon
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/ppiankov/chainwatch/internal/alert"
+)
+
+// Alert event types sent by the ExpiryNotifier.
+const (
+	EventApprovalExpiring = "approval_expiring"
+	EventApprovalExpired  = "approval_expired"
+)
+
+// defaultReminderWindow is how long before expiry a pending WO reminder is sent.
+const defaultReminderWindow = time.Hour
+
+// ExpiryNotifier tells operators about pending WOs before they lapse:
+// one reminder when a WO enters the reminder window, and a final alert
+// when it expires unactioned.
+type ExpiryNotifier struct {
+	gateway *Gateway
+	alerts  func() *alert.Dispatcher
+	window  time.Duration
+
+	mu       sync.Mutex
+	reminded map[string]bool
+}
+
+// NewExpiryNotifier creates a notifier for gateway. alerts returns the
+// current dispatcher, so a policy reload changes where alerts go. A zero
+// window uses the default of one hour. A nil dispatcher still expires WOs
+// but sends nothing.
+func NewExpiryNotifier(gateway *Gateway, alerts func() *alert.Dispatcher, window time.Duration) *ExpiryNotifier {
+	if window == 0 {
+		window = defaultReminderWindow
+	}
+	return &ExpiryNotifier{
+		gateway:  gateway,
+		alerts:   alerts,
+		window:   window,
+		reminded: make(map[string]bool),
+	}
+}
+
+// Sweep expires lapsed WOs, alerting for each, then sends a reminder for
+// every pending WO that entered the reminder window since the last sweep.
+// Returns the number of WOs expired.
+func (n *ExpiryNotifier) Sweep() (int, error) {
+	expired, err := n.gateway.ExpireLapsed()
+	if err != nil {
+		return 0, err
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	for _, pw := range expired {
+		delete(n.reminded, pw.ID)
+		n.dispatch(pw, EventApprovalExpired,
+			fmt.Sprintf("pending approval for WO %s expired unactioned at %s", pw.ID, pw.ExpiresAt.UTC().Format(time.RFC3339)))
+	}
+
+	pending, err := n.gateway.PendingWOs()
+	if err != nil {
+		return len(expired), err
+	}
+
+	now := n.gateway.now()
+	live := make(map[string]bool, len(pending))
+	for _, pw := range pending {
+		live[pw.ID] = true
+		left := pw.ExpiresAt.Sub(now)
+		if n.reminded[pw.ID] || left > n.window {
+			continue
+		}
+		n.reminded[pw.ID] = true
+		n.dispatch(pw, EventApprovalExpiring,
+			fmt.Sprintf("pending approval for WO %s expires in %s (at %s)", pw.ID, left.Round(time.Minute), pw.ExpiresAt.UTC().Format(time.RFC3339)))
+	}
+
+	// Forget WOs approved or rejected since the last sweep.
+	for id := range n.reminded {
+		if !live[id] {
+			delete(n.reminded, id)
+		}
+	}
+	return len(expired), nil
+}
+
+func (n *ExpiryNotifier) dispatch(pw PendingWO, eventType, reason string) {
+	dispatcher := n.alerts()
+	if dispatcher == nil {
+		return
+	}
+	resource := pw.ID
+	if pw.Target.Host != "" {
+		resource = pw.ID + " (" + pw.Target.Host + ")"
+	}
+	dispatcher.Dispatch(alert.AlertEvent{
+		Timestamp: n.gateway.now().UTC().Format("2006-01-02T15:04:05.000Z"),
+		Tool:      "nullbot",
+		Resource:  resource,
+		Decision:  "require_approval",
+		Reason:    reason,
+		Tier:      2,
+		Type:      eventType,
+	})
+}
@@ -0,0 +1,137 @@
+package daemon
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ppiankov/chainwatch/internal/alert"
+)
+
+// alertSink starts a webhook that records delivered alert events.
+func alertSink(t *testing.T) (*alert.Dispatcher, <-chan alert.AlertEvent) {
+	t.Helper()
+	events := make(chan alert.AlertEvent, 16)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var ev alert.AlertEvent
+		if err := json.NewDecoder(r.Body).Decode(&ev); err == nil {
+			events <- ev
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	d := alert.NewDispatcher([]alert.AlertConfig{{
+		URL:    srv.URL,
+		Events: []string{EventApprovalExpiring, EventApprovalExpired},
+	}})
+	return d, events
+}
+
+func expectEvent(t *testing.T, events <-chan alert.AlertEvent, eventType string) alert.AlertEvent {
+	t.Helper()
+	select {
+	case ev := <-events:
+		if ev.Type != eventType {
+			t.Fatalf("event type = %q, want %q", ev.Type, eventType)
+		}
+		return ev
+	case <-time.After(2 * time.Second):
+		t.Fatalf("no %s alert delivered", eventType)
+	}
+	return alert.AlertEvent{}
+}
+
+func expectNoEvent(t *testing.T, events <-chan alert.AlertEvent) {
+	t.Helper()
+	select {
+	case ev := <-events:
+		t.Fatalf("unexpected %s alert: %s", ev.Type, ev.Reason)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestExpiryNotifierRemindsThenExpires(t *testing.T) {
+	root := t.TempDir()
+	cfg := DirConfig{
+		Inbox:  filepath.Join(root, "inbox"),
+		Outbox: filepath.Join(root, "outbox"),
+		State:  filepath.Join(root, "state"),
+	}
+	if err := EnsureDirs(cfg); err != nil {
+		t.Fatal(err)
+	}
+	g := NewGateway(cfg.Outbox, cfg.State, 2*time.Hour)
+	writePendingResult(t, cfg.Outbox, "wo-remind")
+
+	start := time.Now()
+	clock := start
+	g.now = func() time.Time { return clock }
+
+	d, events := alertSink(t)
+	n := NewExpiryNotifier(g, func() *alert.Dispatcher { return d }, 30*time.Minute)
+
+	// Outside the reminder window: nothing sent.
+	clock = start.Add(time.Hour)
+	if _, err := n.Sweep(); err != nil {
+		t.Fatal(err)
+	}
+	expectNoEvent(t, events)
+
+	// Past the reminder threshold: one reminder.
+	clock = start.Add(time.Hour + 45*time.Minute)
+	if _, err := n.Sweep(); err != nil {
+		t.Fatal(err)
+	}
+	ev := expectEvent(t, events, EventApprovalExpiring)
+	if ev.Resource != "wo-remind" || ev.Decision != "require_approval" {
+		t.Errorf("unexpected reminder: %+v", ev)
+	}
+
+	// A later sweep inside the window does not repeat it.
+	clock = start.Add(time.Hour + 50*time.Minute)
+	if _, err := n.Sweep(); err != nil {
+		t.Fatal(err)
+	}
+	expectNoEvent(t, events)
+
+	// Past expiry: the WO is rejected and a final alert sent.
+	clock = start.Add(2*time.Hour + time.Minute)
+	expired, err := n.Sweep()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expired != 1 {
+		t.Fatalf("expired = %d, want 1", expired)
+	}
+	expectEvent(t, events, EventApprovalExpired)
+
+	if pending, _ := g.PendingWOs(); len(pending) != 0 {
+		t.Errorf("expected no pending WOs after expiry, got %d", len(pending))
+	}
+}
+
+func TestExpiryNotifierSkipsActionedWO(t *testing.T) {
+	g, cfg := setupGateway(t)
+	writePendingResult(t, cfg.Outbox, "wo-approved")
+
+	start := time.Now()
+	clock := start
+	g.now = func() time.Time { return clock }
+
+	d, events := alertSink(t)
+	n := NewExpiryNotifier(g, func() *alert.Dispatcher { return d }, 10*time.Minute)
+
+	if err := g.Approve("wo-approved"); err != nil {
+		t.Fatal(err)
+	}
+
+	clock = start.Add(2 * time.Hour)
+	if _, err := n.Sweep(); err != nil {
+		t.Fatal(err)
+	}
+	expectNoEvent(t, events)
+}
@@ -18,6 +18,7 @@ type ProcessorConfig struct {
 	Dirs          DirConfig
 	Chainwatch    string
 	AuditLog      string
+	Policy        string
 	APIURL        string
 	APIKey        string
 	Model         string
@@ -26,6 +27,8 @@ type ProcessorConfig struct {
 	LLMRateLimit  int // requests per minute; 0 = unlimited
 	LLMFallbacks  []observe.LLMProvider
 	LLMPool       []observe.LLMProvider
+
+	ClassifyCacheTTL time.Duration // 0 = always call the LLM
 }
 
 // Processor handles job lifecycle transitions.
@@ -135,6 +138,7 @@ func (p *Processor) runInvestigation(job *Job, classify bool) (*Result, error) {
 		Type:       rbType,
 		Chainwatch: p.cfg.Chainwatch,
 		AuditLog:   p.cfg.AuditLog,
+		Policy:     p.cfg.Policy,
 		Params:     job.Params,
 	}
 
@@ -170,6 +174,10 @@ func (p *Processor) runInvestigation(job *Job, classify bool) (*Result, error) {
 				Pool:         p.cfg.LLMPool,
 				Sensitivity:  rb.Sensitivity,
 			}
+			if p.cfg.ClassifyCacheTTL > 0 {
+				classifyCfg.CachePath = observe.CacheDir(p.cfg.Dirs.State)
+				classifyCfg.CacheTTL = p.cfg.ClassifyCacheTTL
+			}
 
 			// Redact for cloud mode.
 			classifyEvidence := evidence
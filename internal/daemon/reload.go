@@ -0,0 +1,26 @@
+package daemon
+
+import (
+	"fmt"
+
+	"github.com/ppiankov/chainwatch/internal/denylist"
+	"github.com/ppiankov/chainwatch/internal/policy"
+	"github.com/ppiankov/chainwatch/internal/profile"
+)
+
+// loadPolicy returns the loader for the configured policy file.
+func loadPolicy(path string) policy.Loader {
+	return func() (*denylist.Denylist, *policy.PolicyConfig, string, error) {
+		return profile.LoadPolicy("", path, "", false)
+	}
+}
+
+// ReloadPolicy re-reads the policy file and swaps it in atomically, so
+// expiry alerts go to the new channels. On error the running config is
+// left unchanged.
+func (d *Daemon) ReloadPolicy() error {
+	if _, err := d.live.Reload(loadPolicy(d.cfg.Policy)); err != nil {
+		return fmt.Errorf("failed to reload policy: %w", err)
+	}
+	return nil
+}
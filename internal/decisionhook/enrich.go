@@ -0,0 +1,204 @@
+package decisionhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/ppiankov/chainwatch/internal/model"
+)
+
+// EnrichErrorLabel is set to "true" on an action whose enrichment failed
+// under FailOpen, so rules can still treat unenriched actions specially.
+const EnrichErrorLabel = "enrichment_error"
+
+// maxEnrichLabels bounds how many labels one enrichment may add.
+const maxEnrichLabels = 64
+
+// EnrichConfig configures the pre-evaluation enrichment webhook
+// (policy.yaml enrichment_hook).
+type EnrichConfig struct {
+	URL      string            `yaml:"url"`
+	Timeout  time.Duration     `yaml:"timeout"`           // per-request timeout (default 2s)
+	FailOpen bool              `yaml:"fail_open"`         // on error evaluate the action unenriched instead of denying
+	Headers  map[string]string `yaml:"headers,omitempty"` // extra request headers, e.g. Authorization
+}
+
+// EnrichRequest describes the action being enriched. It is the JSON body
+// POSTed to the enrichment webhook.
+type EnrichRequest struct {
+	TraceID   string            `json:"trace_id,omitempty"`
+	AgentID   string            `json:"agent_id,omitempty"`
+	Purpose   string            `json:"purpose,omitempty"`
+	Tool      string            `json:"tool"`
+	Resource  string            `json:"resource"`
+	Operation string            `json:"operation,omitempty"`
+	Labels    map[string]string `json:"labels,omitempty"`
+}
+
+// EnrichResponse is the enrichment webhook's JSON reply.
+type EnrichResponse struct {
+	Labels map[string]string `json:"labels"`
+}
+
+// Enricher supplies external context for an action before policy
+// evaluation, e.g. whether a destination is in the CMDB. The returned
+// labels are merged into the action and matched by rule label selectors.
+type Enricher interface {
+	Enrich(ctx context.Context, req EnrichRequest) (map[string]string, error)
+}
+
+// Enrichment runs an Enricher with a timeout. A nil *Enrichment is valid
+// and leaves actions unchanged.
+type Enrichment struct {
+	enricher Enricher
+	timeout  time.Duration
+	failOpen bool
+}
+
+// NewEnrichment creates a webhook-backed Enrichment. Returns nil when cfg
+// is nil or has no URL.
+func NewEnrichment(cfg *EnrichConfig) *Enrichment {
+	if cfg == nil || cfg.URL == "" {
+		return nil
+	}
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return NewEnrichmentWith(&webhookEnricher{
+		url:     cfg.URL,
+		headers: cfg.Headers,
+		client:  &http.Client{Timeout: timeout},
+	}, timeout, cfg.FailOpen)
+}
+
+// NewEnrichmentWith wraps a custom Enricher. Zero timeout means DefaultTimeout.
+func NewEnrichmentWith(e Enricher, timeout time.Duration, failOpen bool) *Enrichment {
+	if e == nil {
+		return nil
+	}
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Enrichment{enricher: e, timeout: timeout, failOpen: failOpen}
+}
+
+// Apply enriches action in place. Labels already on the action (operator
+// supplied) are never overwritten. If the enricher fails or exceeds the
+// timeout, Apply returns a deny result and false; with FailOpen the action
+// is instead tagged with EnrichErrorLabel and evaluated unenriched.
+func (e *Enrichment) Apply(ctx context.Context, action *model.Action, traceID, purpose, agentID string) (model.PolicyResult, bool) {
+	if e == nil {
+		return model.PolicyResult{}, true
+	}
+
+	labels, err := e.run(ctx, EnrichRequest{
+		TraceID:   traceID,
+		AgentID:   agentID,
+		Purpose:   purpose,
+		Tool:      action.Tool,
+		Resource:  action.Resource,
+		Operation: action.Operation,
+		Labels:    action.Labels,
+	})
+	if err != nil {
+		if e.failOpen {
+			setLabel(action, EnrichErrorLabel, "true")
+			return model.PolicyResult{}, true
+		}
+		return model.PolicyResult{
+			Decision: model.Deny,
+			Tier:     2,
+			Reason:   fmt.Sprintf("enrichment hook unavailable: %v", err),
+			PolicyID: "enrich.error",
+		}, false
+	}
+
+	added := 0
+	for k, v := range labels {
+		if k == "" || added >= maxEnrichLabels {
+			continue
+		}
+		if _, exists := action.Labels[k]; exists {
+			continue
+		}
+		setLabel(action, k, v)
+		added++
+	}
+	return model.PolicyResult{}, true
+}
+
+// run calls the enricher, abandoning it once the timeout expires even if
+// it ignores context cancellation.
+func (e *Enrichment) run(ctx context.Context, req EnrichRequest) (map[string]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, e.timeout)
+	defer cancel()
+
+	type outcome struct {
+		labels map[string]string
+		err    error
+	}
+	done := make(chan outcome, 1)
+	go func() {
+		labels, err := e.enricher.Enrich(ctx, req)
+		done <- outcome{labels, err}
+	}()
+
+	select {
+	case out := <-done:
+		return out.labels, out.err
+	case <-ctx.Done():
+		return nil, fmt.Errorf("enrichment timed out after %s", e.timeout)
+	}
+}
+
+func setLabel(action *model.Action, k, v string) {
+	if action.Labels == nil {
+		action.Labels = make(map[string]string)
+	}
+	action.Labels[k] = v
+}
+
+// webhookEnricher POSTs an EnrichRequest and reads labels from the reply.
+type webhookEnricher struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+func (w *webhookEnricher) Enrich(ctx context.Context, req EnrichRequest) (map[string]string, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, w.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	for k, v := range w.headers {
+		httpReq.Header.Set(k, v)
+	}
+
+	httpResp, err := w.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("webhook returned HTTP %d", httpResp.StatusCode)
+	}
+
+	var resp EnrichResponse
+	if err := json.NewDecoder(io.LimitReader(httpResp.Body, 64<<10)).Decode(&resp); err != nil {
+		return nil, fmt.Errorf("invalid webhook response: %w", err)
+	}
+	return resp.Labels, nil
+}
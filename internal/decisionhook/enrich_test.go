@@ -0,0 +1,81 @@
+package decisionhook
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/ppiankov/chainwatch/internal/model"
+)
+
+type enricherFunc func(ctx context.Context, req EnrichRequest) (map[string]string, error)
+
+func (f enricherFunc) Enrich(ctx context.Context, req EnrichRequest) (map[string]string, error) {
+	return f(ctx, req)
+}
+
+func TestEnrichmentMergesLabels(t *testing.T) {
+	e := NewEnrichmentWith(enricherFunc(func(_ context.Context, req EnrichRequest) (map[string]string, error) {
+		if req.Resource != "rm -rf /tmp/x" {
+			t.Errorf("unexpected resource %q", req.Resource)
+		}
+		return map[string]string{"cmdb_known": "false", "env": "staging"}, nil
+	}), 0, false)
+
+	action := &model.Action{Tool: "command", Resource: "rm -rf /tmp/x", Labels: map[string]string{"env": "prod"}}
+	if _, ok := e.Apply(context.Background(), action, "t-1", "test", ""); !ok {
+		t.Fatal("expected enrichment to succeed")
+	}
+	if action.Labels["cmdb_known"] != "false" {
+		t.Errorf("expected cmdb_known label, got %v", action.Labels)
+	}
+	if action.Labels["env"] != "prod" {
+		t.Errorf("operator label must not be overwritten, got %v", action.Labels)
+	}
+}
+
+func TestEnrichmentFailureModes(t *testing.T) {
+	slow := enricherFunc(func(context.Context, EnrichRequest) (map[string]string, error) {
+		time.Sleep(time.Second) // ignores cancellation
+		return nil, nil
+	})
+	failing := enricherFunc(func(context.Context, EnrichRequest) (map[string]string, error) {
+		return nil, errors.New("cmdb down")
+	})
+
+	for name, enricher := range map[string]Enricher{"timeout": slow, "error": failing} {
+		t.Run(name, func(t *testing.T) {
+			action := &model.Action{Tool: "command", Resource: "ls"}
+			result, ok := NewEnrichmentWith(enricher, 50*time.Millisecond, false).Apply(context.Background(), action, "", "", "")
+			if ok || result.Decision != model.Deny || result.PolicyID != "enrich.error" {
+				t.Errorf("expected fail-closed deny, got ok=%v %+v", ok, result)
+			}
+
+			action = &model.Action{Tool: "command", Resource: "ls"}
+			if _, ok := NewEnrichmentWith(enricher, 50*time.Millisecond, true).Apply(context.Background(), action, "", "", ""); !ok {
+				t.Fatal("expected fail-open to continue")
+			}
+			if action.Labels[EnrichErrorLabel] != "true" {
+				t.Errorf("expected %s label, got %v", EnrichErrorLabel, action.Labels)
+			}
+		})
+	}
+}
+
+func TestEnrichmentWebhookErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	t.Cleanup(srv.Close)
+
+	e := NewEnrichment(&EnrichConfig{URL: srv.URL})
+	if _, ok := e.Apply(context.Background(), testAction, "", "", ""); ok {
+		t.Error("expected HTTP error to fail closed")
+	}
+	if NewEnrichment(&EnrichConfig{}) != nil {
+		t.Error("expected nil Enrichment without URL")
+	}
+}
@@ -0,0 +1,246 @@
+// Package decisionhook consults external services around policy
+// evaluation: enrichment before it, authorization of borderline decisions
+// after it.
+package decisionhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ppiankov/chainwatch/internal/model"
+)
+
+// Defaults applied to zero-valued Config fields.
+const (
+	DefaultMinTier  = 2
+	DefaultTimeout  = 2 * time.Second
+	DefaultCacheTTL = 5 * time.Second
+)
+
+// maxCacheEntries bounds the decision cache; it is cleared when full.
+const maxCacheEntries = 4096
+
+// Config configures the external decision webhook (policy.yaml decision_hook).
+type Config struct {
+	URL      string            `yaml:"url"`
+	MinTier  int               `yaml:"min_tier"`          // consult for results at or above this tier (default 2)
+	Timeout  time.Duration     `yaml:"timeout"`           // per-request timeout (default 2s)
+	FailOpen bool              `yaml:"fail_open"`         // on webhook error keep the local decision instead of denying
+	CacheTTL time.Duration     `yaml:"cache_ttl"`         // identical requests reuse a decision this long (default 5s, negative disables)
+	Headers  map[string]string `yaml:"headers,omitempty"` // extra request headers, e.g. Authorization
+}
+
+// Request is the JSON body POSTed to the webhook.
+type Request struct {
+	TraceID   string `json:"trace_id,omitempty"`
+	AgentID   string `json:"agent_id,omitempty"`
+	Purpose   string `json:"purpose,omitempty"`
+	Tool      string `json:"tool"`
+	Resource  string `json:"resource"`
+	Operation string `json:"operation,omitempty"`
+	Tier      int    `json:"tier"`
+	Decision  string `json:"decision"` // local decision the webhook may override
+	Reason    string `json:"reason"`
+	PolicyID  string `json:"policy_id,omitempty"`
+
+	// RequireReason marks a require_reason rule: an allow for it must
+	// carry a reason, or the local decision stands.
+	RequireReason bool `json:"require_reason,omitempty"`
+}
+
+// Response is the webhook's JSON reply.
+type Response struct {
+	Decision string `json:"decision"` // allow | require_approval | deny
+	Reason   string `json:"reason,omitempty"`
+}
+
+// Hook calls the webhook and caches its answers. A nil *Hook is valid and
+// returns local decisions unchanged.
+type Hook struct {
+	cfg    Config
+	client *http.Client
+	now    func() time.Time
+
+	mu    sync.Mutex
+	cache map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	resp    Response
+	expires time.Time
+}
+
+// New creates a Hook. Returns nil when cfg is nil or has no URL.
+func New(cfg *Config) *Hook {
+	if cfg == nil || cfg.URL == "" {
+		return nil
+	}
+	c := *cfg
+	if c.MinTier <= 0 {
+		c.MinTier = DefaultMinTier
+	}
+	if c.Timeout <= 0 {
+		c.Timeout = DefaultTimeout
+	}
+	if c.CacheTTL == 0 {
+		c.CacheTTL = DefaultCacheTTL
+	}
+	return &Hook{
+		cfg:    c,
+		client: &http.Client{Timeout: c.Timeout},
+		now:    time.Now,
+		cache:  make(map[string]cacheEntry),
+	}
+}
+
+// Decide consults the webhook when the local result is allow or
+// require_approval at or above MinTier, and returns the webhook's decision.
+// A local deny is final and never sent. On webhook error the action is
+// denied, or with FailOpen the local result is kept and annotated.
+func (h *Hook) Decide(ctx context.Context, action *model.Action, traceID, purpose, agentID string, local model.PolicyResult) model.PolicyResult {
+	if h == nil || local.Tier < h.cfg.MinTier {
+		return local
+	}
+	if local.Decision != model.Allow && local.Decision != model.RequireApproval {
+		return local
+	}
+
+	req := Request{
+		TraceID:   traceID,
+		AgentID:   agentID,
+		Purpose:   purpose,
+		Tool:      action.Tool,
+		Resource:  action.Resource,
+		Operation: action.Operation,
+		Tier:      local.Tier,
+		Decision:  string(local.Decision),
+		Reason:    local.Reason,
+		PolicyID:  local.PolicyID,
+
+		RequireReason: local.RequireReason,
+	}
+
+	resp, err := h.lookup(ctx, req)
+	if err != nil {
+		if h.cfg.FailOpen {
+			local.Reason = fmt.Sprintf("%s [decision hook unavailable: %v]", local.Reason, err)
+			return local
+		}
+		return model.PolicyResult{
+			Decision: model.Deny,
+			Tier:     local.Tier,
+			Reason:   fmt.Sprintf("decision hook unavailable: %v", err),
+			PolicyID: "hook.error",
+		}
+	}
+
+	// Approving a require_reason rule needs a justification, here as from
+	// an operator; it lands in the audited reason.
+	if local.RequireReason && local.Decision == model.RequireApproval &&
+		resp.Decision == string(model.Allow) && strings.TrimSpace(resp.Reason) == "" {
+		local.Reason = fmt.Sprintf("%s [decision hook allow ignored: require_reason rule needs a reason]", local.Reason)
+		return local
+	}
+
+	result := model.PolicyResult{
+		Decision: model.Decision(resp.Decision),
+		Tier:     local.Tier,
+		Reason:   "decision hook: " + resp.Decision,
+		PolicyID: "hook." + resp.Decision,
+	}
+	if resp.Reason != "" {
+		result.Reason = "decision hook: " + resp.Reason
+	}
+	if result.Decision == model.RequireApproval {
+		result.ApprovalKey = local.ApprovalKey
+		if result.ApprovalKey == "" {
+			result.ApprovalKey = fmt.Sprintf("tier_%d_action", local.Tier)
+		}
+	}
+	return result
+}
+
+// lookup returns a cached response for an identical request or calls the
+// webhook. Errors are never cached.
+func (h *Hook) lookup(ctx context.Context, req Request) (Response, error) {
+	key := cacheKey(req)
+	now := h.now()
+
+	if h.cfg.CacheTTL > 0 {
+		h.mu.Lock()
+		e, ok := h.cache[key]
+		h.mu.Unlock()
+		if ok && now.Before(e.expires) {
+			return e.resp, nil
+		}
+	}
+
+	resp, err := h.call(ctx, req)
+	if err != nil {
+		return Response{}, err
+	}
+
+	if h.cfg.CacheTTL > 0 {
+		h.mu.Lock()
+		if len(h.cache) >= maxCacheEntries {
+			h.cache = make(map[string]cacheEntry)
+		}
+		h.cache[key] = cacheEntry{resp: resp, expires: now.Add(h.cfg.CacheTTL)}
+		h.mu.Unlock()
+	}
+	return resp, nil
+}
+
+func (h *Hook) call(ctx context.Context, req Request) (Response, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return Response{}, err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, h.cfg.Timeout)
+	defer cancel()
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, h.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return Response{}, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	for k, v := range h.cfg.Headers {
+		httpReq.Header.Set(k, v)
+	}
+
+	httpResp, err := h.client.Do(httpReq)
+	if err != nil {
+		return Response{}, err
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode/100 != 2 {
+		return Response{}, fmt.Errorf("webhook returned HTTP %d", httpResp.StatusCode)
+	}
+
+	var resp Response
+	if err := json.NewDecoder(io.LimitReader(httpResp.Body, 64<<10)).Decode(&resp); err != nil {
+		return Response{}, fmt.Errorf("invalid webhook response: %w", err)
+	}
+	switch resp.Decision {
+	case string(model.Allow), string(model.RequireApproval), string(model.Deny):
+		return resp, nil
+	default:
+		return Response{}, fmt.Errorf("invalid webhook decision %q", resp.Decision)
+	}
+}
+
+func cacheKey(req Request) string {
+	return strings.Join([]string{
+		req.AgentID, req.Purpose, req.Tool, req.Resource, req.Operation,
+		fmt.Sprint(req.Tier), req.Decision, req.PolicyID,
+	}, "\x00")
+}
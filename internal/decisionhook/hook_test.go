@@ -0,0 +1,208 @@
+package decisionhook
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ppiankov/chainwatch/internal/model"
+)
+
+func fakeService(t *testing.T, decision string, delay time.Duration, calls *atomic.Int32) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		var req Request
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Errorf("decode request: %v", err)
+		}
+		if delay > 0 {
+			select {
+			case <-time.After(delay):
+			case <-r.Context().Done():
+				return
+			}
+		}
+		_ = json.NewEncoder(w).Encode(Response{Decision: decision, Reason: "reviewed " + req.Resource})
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func localResult(decision model.Decision, tier int) model.PolicyResult {
+	return model.PolicyResult{Decision: decision, Tier: tier, Reason: "local", PolicyID: "tier.guarded"}
+}
+
+var testAction = &model.Action{Tool: "command", Resource: "rm -rf /tmp/x", Operation: "execute"}
+
+func TestDecideOverridesLocal(t *testing.T) {
+	tests := []struct {
+		name     string
+		remote   string
+		local    model.Decision
+		expected model.Decision
+	}{
+		{"allow to deny", "deny", model.Allow, model.Deny},
+		{"approval to allow", "allow", model.RequireApproval, model.Allow},
+		{"allow to approval", "require_approval", model.Allow, model.RequireApproval},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var calls atomic.Int32
+			srv := fakeService(t, tt.remote, 0, &calls)
+			h := New(&Config{URL: srv.URL})
+
+			result := h.Decide(context.Background(), testAction, "t-1", "test", "", localResult(tt.local, 2))
+			if result.Decision != tt.expected {
+				t.Fatalf("expected %s, got %s (%s)", tt.expected, result.Decision, result.Reason)
+			}
+			if result.PolicyID != "hook."+tt.remote {
+				t.Errorf("expected policy ID hook.%s, got %s", tt.remote, result.PolicyID)
+			}
+			if !strings.Contains(result.Reason, "reviewed rm -rf /tmp/x") {
+				t.Errorf("expected webhook reason, got %q", result.Reason)
+			}
+			if tt.expected == model.RequireApproval && result.ApprovalKey == "" {
+				t.Error("expected approval key for require_approval")
+			}
+		})
+	}
+}
+
+func TestDecideRequireReasonNeedsWebhookReason(t *testing.T) {
+	local := localResult(model.RequireApproval, 2)
+	local.ApprovalKey = "prod_deploy"
+	local.RequireReason = true
+
+	silent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req Request
+		json.NewDecoder(r.Body).Decode(&req)
+		if !req.RequireReason {
+			t.Error("expected require_reason sent to the webhook")
+		}
+		w.Write([]byte(`{"decision": "allow"}`))
+	}))
+	defer silent.Close()
+	result := New(&Config{URL: silent.URL}).Decide(context.Background(), testAction, "t-1", "test", "", local)
+	if result.Decision != model.RequireApproval || result.ApprovalKey != "prod_deploy" {
+		t.Errorf("expected allow without reason to keep the local decision, got %s (%s)", result.Decision, result.Reason)
+	}
+
+	var calls atomic.Int32
+	srv := fakeService(t, "allow", 0, &calls)
+	result = New(&Config{URL: srv.URL}).Decide(context.Background(), testAction, "t-1", "test", "", local)
+	if result.Decision != model.Allow || !strings.Contains(result.Reason, "reviewed rm -rf /tmp/x") {
+		t.Errorf("expected allow with the webhook's reason, got %s (%s)", result.Decision, result.Reason)
+	}
+}
+
+func TestDecideTimeoutFailsClosed(t *testing.T) {
+	var calls atomic.Int32
+	srv := fakeService(t, "allow", time.Second, &calls)
+	h := New(&Config{URL: srv.URL, Timeout: 50 * time.Millisecond})
+
+	result := h.Decide(context.Background(), testAction, "", "", "", localResult(model.Allow, 2))
+	if result.Decision != model.Deny {
+		t.Fatalf("expected deny on timeout, got %s", result.Decision)
+	}
+	if result.PolicyID != "hook.error" {
+		t.Errorf("expected policy ID hook.error, got %s", result.PolicyID)
+	}
+}
+
+func TestDecideTimeoutFailOpen(t *testing.T) {
+	var calls atomic.Int32
+	srv := fakeService(t, "deny", time.Second, &calls)
+	h := New(&Config{URL: srv.URL, Timeout: 50 * time.Millisecond, FailOpen: true})
+
+	result := h.Decide(context.Background(), testAction, "", "", "", localResult(model.RequireApproval, 2))
+	if result.Decision != model.RequireApproval {
+		t.Fatalf("expected local require_approval, got %s", result.Decision)
+	}
+	if !strings.Contains(result.Reason, "decision hook unavailable") {
+		t.Errorf("expected unavailable note in reason, got %q", result.Reason)
+	}
+}
+
+func TestDecideInvalidDecisionFailsClosed(t *testing.T) {
+	var calls atomic.Int32
+	srv := fakeService(t, "maybe", 0, &calls)
+	h := New(&Config{URL: srv.URL})
+
+	result := h.Decide(context.Background(), testAction, "", "", "", localResult(model.Allow, 3))
+	if result.Decision != model.Deny {
+		t.Fatalf("expected deny on invalid decision, got %s", result.Decision)
+	}
+}
+
+func TestDecideCachesResponses(t *testing.T) {
+	var calls atomic.Int32
+	srv := fakeService(t, "allow", 0, &calls)
+	h := New(&Config{URL: srv.URL})
+
+	for i := 0; i < 3; i++ {
+		h.Decide(context.Background(), testAction, "", "test", "", localResult(model.RequireApproval, 2))
+	}
+	if got := calls.Load(); got != 1 {
+		t.Fatalf("expected 1 webhook call, got %d", got)
+	}
+
+	other := &model.Action{Tool: "command", Resource: "rm -rf /tmp/y", Operation: "execute"}
+	h.Decide(context.Background(), other, "", "test", "", localResult(model.RequireApproval, 2))
+	if got := calls.Load(); got != 2 {
+		t.Fatalf("expected different resource to call webhook, got %d calls", got)
+	}
+}
+
+func TestDecideCacheExpires(t *testing.T) {
+	var calls atomic.Int32
+	srv := fakeService(t, "allow", 0, &calls)
+	h := New(&Config{URL: srv.URL, CacheTTL: time.Minute})
+	now := time.Now()
+	h.now = func() time.Time { return now }
+
+	h.Decide(context.Background(), testAction, "", "", "", localResult(model.Allow, 2))
+	now = now.Add(2 * time.Minute)
+	h.Decide(context.Background(), testAction, "", "", "", localResult(model.Allow, 2))
+	if got := calls.Load(); got != 2 {
+		t.Fatalf("expected expired entry to call webhook again, got %d calls", got)
+	}
+}
+
+func TestDecideSkipsLowTierAndDeny(t *testing.T) {
+	var calls atomic.Int32
+	srv := fakeService(t, "allow", 0, &calls)
+	h := New(&Config{URL: srv.URL, MinTier: 2})
+
+	low := h.Decide(context.Background(), testAction, "", "", "", localResult(model.Allow, 1))
+	if low.PolicyID != "tier.guarded" {
+		t.Errorf("expected tier 1 result unchanged, got %s", low.PolicyID)
+	}
+	denied := h.Decide(context.Background(), testAction, "", "", "", localResult(model.Deny, 3))
+	if denied.Decision != model.Deny {
+		t.Errorf("expected local deny to be final, got %s", denied.Decision)
+	}
+	if got := calls.Load(); got != 0 {
+		t.Fatalf("expected no webhook calls, got %d", got)
+	}
+}
+
+func TestNewDisabled(t *testing.T) {
+	if New(nil) != nil {
+		t.Error("expected nil hook for nil config")
+	}
+	if New(&Config{}) != nil {
+		t.Error("expected nil hook without URL")
+	}
+
+	var h *Hook
+	local := localResult(model.Allow, 3)
+	if got := h.Decide(context.Background(), testAction, "", "", "", local); got.PolicyID != local.PolicyID || got.Decision != local.Decision {
+		t.Errorf("expected nil hook to return local result, got %+v", got)
+	}
+}
@@ -33,7 +33,7 @@ func BenchmarkIsBlocked_LargeDenylist(b *testing.B) {
 	p := DefaultPatterns
 	// Add 1000 extra URL patterns
 	for i := 0; i < 1000; i++ {
-		p.URLs = append(p.URLs, fmt.Sprintf("https://blocked-%d.example.com", i))
+		p.URLs = append(p.URLs, Entry{Pattern: fmt.Sprintf("https://blocked-%d.example.com", i)})
 	}
 	dl := New(p)
 
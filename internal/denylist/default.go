@@ -3,7 +3,7 @@ package denylist
 // DefaultPatterns contains the hardcoded denylist patterns.
 // These are the irreversible boundaries that are always blocked.
 var DefaultPatterns = Patterns{
-	URLs: []string{
+	URLs: Entries(
 		"/checkout",
 		"/payment",
 		"stripe.com/v1/charges",
@@ -14,8 +14,8 @@ var DefaultPatterns = Patterns{
 		"/api/keys",
 		"/account/delete",
 		"/settings/security",
-	},
-	Files: []string{
+	),
+	Files: Entries(
 		"~/.ssh/id_rsa",
 		"~/.ssh/id_ed25519",
 		"~/.aws/credentials",
@@ -23,8 +23,8 @@ var DefaultPatterns = Patterns{
 		"**/.env.local",
 		"**/credentials.json",
 		"**/*.kdbx",
-	},
-	Commands: []string{
+	),
+	Commands: Entries(
 		"rm -rf /",
 		"rm -rf ~",
 		"dd if=/dev/zero",
@@ -51,5 +51,5 @@ var DefaultPatterns = Patterns{
 		"declare -p",
 		"export -p",
 		"compgen -v",
-	},
+	),
 }
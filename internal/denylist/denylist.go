@@ -1,43 +1,229 @@
 package denylist
 
 import (
+	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 )
 
-// Patterns holds the raw pattern strings organized by category.
+// Patterns holds the raw pattern entries organized by category.
 type Patterns struct {
-	URLs     []string `yaml:"urls"`
-	Files    []string `yaml:"files"`
-	Commands []string `yaml:"commands"`
+	URLs     []Entry `yaml:"urls"`
+	Files    []Entry `yaml:"files"`
+	Commands []Entry `yaml:"commands"`
+
+	// Warn lists patterns (from any category) that are logged and alerted
+	// on but not enforced. In YAML an entry is marked with action: warn:
+	//
+	//	commands:
+	//	  - "rm -rf"
+	//	  - pattern: "kubectl delete"
+	//	    action: warn
+	Warn []string `yaml:"warn,omitempty"`
+}
+
+// Entry actions.
+const (
+	ActionBlock = "block" // default: match denies the action
+	ActionWarn  = "warn"  // match allows the action but raises an alert
+)
+
+// Severity classifies a denylist match.
+type Severity int
+
+const (
+	SeverityNone  Severity = iota // no match
+	SeverityWarn                  // matched a warn entry only
+	SeverityBlock                 // matched a blocking entry
+)
+
+// Entry is a single pattern with its per-entry settings.
+type Entry struct {
+	Pattern string
+
+	// ExpiresAt is when the entry stops matching, for temporary blocks.
+	// Zero means it never expires. In YAML an entry sets expires_at:
+	//
+	//	urls:
+	//	  - pattern: "c2.example.net"
+	//	    expires_at: 2026-03-01T00:00:00Z
+	ExpiresAt time.Time
+
+	// Tools lists the tools the entry applies to. Empty means every tool.
+	// In YAML an entry sets tools:
+	//
+	//	urls:
+	//	  - pattern: "file://"
+	//	    tools: [browser]
+	Tools []string
+}
+
+// Entries wraps bare patterns as entries without per-entry settings.
+func Entries(patterns ...string) []Entry {
+	out := make([]Entry, len(patterns))
+	for i, p := range patterns {
+		out[i] = Entry{Pattern: p}
+	}
+	return out
+}
+
+// PatternsOf returns the pattern strings of entries.
+func PatternsOf(entries []Entry) []string {
+	out := make([]string, len(entries))
+	for i, e := range entries {
+		out[i] = e.Pattern
+	}
+	return out
+}
+
+// active reports whether e is unexpired at now.
+func (e Entry) active(now time.Time) bool {
+	return e.ExpiresAt.IsZero() || now.Before(e.ExpiresAt)
+}
+
+// scoped reports whether e applies to tool.
+func (e Entry) scoped(tool string) bool {
+	if len(e.Tools) == 0 {
+		return true
+	}
+	for _, t := range e.Tools {
+		if strings.EqualFold(t, tool) {
+			return true
+		}
+	}
+	return false
+}
+
+// MarshalYAML writes entries without settings as bare pattern strings.
+func (e Entry) MarshalYAML() (any, error) {
+	if e.ExpiresAt.IsZero() && len(e.Tools) == 0 {
+		return e.Pattern, nil
+	}
+	return struct {
+		Pattern   string    `yaml:"pattern"`
+		ExpiresAt time.Time `yaml:"expires_at,omitempty"`
+		Tools     []string  `yaml:"tools,omitempty"`
+	}{e.Pattern, e.ExpiresAt, e.Tools}, nil
+}
+
+// entry is a single list item: a bare pattern or {pattern, action, expires_at, tools}.
+type entry struct {
+	Entry
+	Action string
+}
+
+func (e *entry) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.ScalarNode {
+		e.Pattern = node.Value
+		return nil
+	}
+	var m struct {
+		Pattern   string    `yaml:"pattern"`
+		Action    string    `yaml:"action"`
+		ExpiresAt time.Time `yaml:"expires_at"`
+		Tools     []string  `yaml:"tools"`
+	}
+	if err := node.Decode(&m); err != nil {
+		return err
+	}
+	e.Pattern = m.Pattern
+	e.ExpiresAt = m.ExpiresAt
+	for _, t := range m.Tools {
+		if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+			e.Tools = append(e.Tools, t)
+		}
+	}
+	e.Action = strings.ToLower(strings.TrimSpace(m.Action))
+	if e.Pattern == "" {
+		return fmt.Errorf("line %d: denylist entry missing pattern", node.Line)
+	}
+	switch e.Action {
+	case "", ActionBlock, ActionWarn:
+		return nil
+	default:
+		return fmt.Errorf("line %d: unknown denylist action %q (valid: %s, %s)", node.Line, e.Action, ActionBlock, ActionWarn)
+	}
+}
+
+// UnmarshalYAML accepts both bare pattern strings and {pattern, action, expires_at, tools} entries.
+func (p *Patterns) UnmarshalYAML(node *yaml.Node) error {
+	var raw struct {
+		URLs     []entry  `yaml:"urls"`
+		Files    []entry  `yaml:"files"`
+		Commands []entry  `yaml:"commands"`
+		Warn     []string `yaml:"warn"`
+	}
+	if err := node.Decode(&raw); err != nil {
+		return err
+	}
+
+	*p = Patterns{Warn: raw.Warn}
+	collect := func(entries []entry) []Entry {
+		var out []Entry
+		for _, e := range entries {
+			out = append(out, e.Entry)
+			if e.Action == ActionWarn {
+				p.Warn = append(p.Warn, e.Pattern)
+			}
+		}
+		return out
+	}
+	for _, e := range raw.Files {
+		if err := ValidateFilePattern(e.Pattern); err != nil {
+			return err
+		}
+	}
+	p.URLs = collect(raw.URLs)
+	p.Files = collect(raw.Files)
+	p.Commands = collect(raw.Commands)
+	p.Warn = dedup(p.Warn)
+	if len(p.Warn) == 0 {
+		p.Warn = nil
+	}
+	return nil
 }
 
 // Denylist holds compiled patterns for fast matching.
 type Denylist struct {
-	urlPatterns     []*regexp.Regexp
-	filePatterns    []string // glob-style, matched via containment
-	commandPatterns []string // substring matching (case-insensitive)
-	raw             Patterns
+	urlPatterns    []*regexp.Regexp
+	urlEntries     []Entry          // parallel to urlPatterns; the entry as written
+	urlWarn        []bool           // parallel to urlPatterns
+	fileEntries    []Entry          // plain patterns match via containment
+	fileGlobs      []*regexp.Regexp // parallel to fileEntries; nil unless the pattern is a glob
+	commandEntries []Entry          // substring matching (case-insensitive)
+	warn           map[string]bool
+	raw            Patterns
+
+	// Provenance of runtime-added patterns (e.g. "clawbot:urls"), parallel
+	// to the pattern slices; empty for patterns from the denylist itself.
+	urlSources     []string
+	fileSources    []string
+	commandSources []string
 }
 
 // New creates a Denylist from raw patterns, compiling regexes.
 func New(p Patterns) *Denylist {
-	d := &Denylist{raw: p}
+	d := &Denylist{raw: p, warn: make(map[string]bool, len(p.Warn))}
+	for _, w := range p.Warn {
+		d.warn[w] = true
+	}
 
 	for _, u := range p.URLs {
-		re := patternToRegex(u)
-		if compiled, err := regexp.Compile("(?i)" + re); err == nil {
-			d.urlPatterns = append(d.urlPatterns, compiled)
-		}
+		d.addURLPattern(u, d.warn[u.Pattern], "")
 	}
 
-	d.filePatterns = p.Files
+	for _, f := range p.Files {
+		d.addFilePattern(f, "")
+	}
 
-	d.commandPatterns = p.Commands
+	d.commandEntries = p.Commands
+	d.commandSources = make([]string, len(p.Commands))
 
 	return d
 }
@@ -70,73 +256,131 @@ func Load(path string) (*Denylist, error) {
 		return nil, err
 	}
 
+	now := time.Now()
+	for _, list := range [][]Entry{p.URLs, p.Files, p.Commands} {
+		for _, e := range list {
+			if !e.active(now) {
+				fmt.Fprintf(warnOutput, "denylist: WARNING entry %q in %s expired at %s and is ignored\n",
+					e.Pattern, path, e.ExpiresAt.UTC().Format(time.RFC3339))
+			}
+		}
+	}
+
 	return New(p), nil
 }
 
+// warnOutput receives load-time warnings.
+var warnOutput io.Writer = os.Stderr
+
 // IsBlocked checks if a resource is blocked for the given tool type.
-// Returns (blocked, reason).
+// Warn entries do not block. Returns (blocked, reason).
 func (d *Denylist) IsBlocked(resource, tool string) (bool, string) {
+	sev, reason := d.Check(resource, tool)
+	if sev != SeverityBlock {
+		return false, ""
+	}
+	return true, reason
+}
+
+// Check matches a resource against the denylist and reports the most
+// severe match. A blocking entry always wins over a warn entry; among warn
+// entries the first match supplies the reason.
+func (d *Denylist) Check(resource, tool string) (Severity, string) {
+	sev, reason, _ := d.Match(resource, tool)
+	return sev, reason
+}
+
+// Match is Check plus the provenance of the blocking pattern: the source
+// passed to AddPatternFrom (e.g. "clawbot:urls"), or empty for patterns
+// loaded from the denylist itself and for warn matches.
+func (d *Denylist) Match(resource, tool string) (Severity, string, string) {
 	lowerResource := strings.ToLower(resource)
 	lowerTool := strings.ToLower(tool)
+	warnReason := ""
+	now := time.Now()
+
+	// hit records a match and reports whether it is blocking.
+	hit := func(kind, pattern string, warn bool) bool {
+		if !warn {
+			return true
+		}
+		if warnReason == "" {
+			warnReason = kind + " pattern matched (warn): " + pattern
+		}
+		return false
+	}
 
 	// URL patterns — checked for browser/HTTP tools and URL-like resources
 	if isBrowserTool(lowerTool) || isURL(lowerResource) {
-		for _, re := range d.urlPatterns {
-			if re.MatchString(lowerResource) {
-				return true, "URL pattern blocked: " + re.String()
+		for i, re := range d.urlPatterns {
+			if d.urlEntries[i].active(now) && d.urlEntries[i].scoped(lowerTool) && re.MatchString(lowerResource) && hit("URL", re.String(), d.urlWarn[i]) {
+				return SeverityBlock, "URL pattern blocked: " + re.String(), d.urlSources[i]
 			}
 		}
 	}
 
 	// File patterns — checked for file operations
 	if isFileTool(lowerTool) || (!isBrowserTool(lowerTool) && !isCommandTool(lowerTool)) {
-		for _, pattern := range d.filePatterns {
-			if matchFilePattern(lowerResource, strings.ToLower(pattern)) {
-				return true, "file pattern blocked: " + pattern
+		for i, e := range d.fileEntries {
+			if e.active(now) && e.scoped(lowerTool) && d.matchFile(i, lowerResource) && hit("file", e.Pattern, d.warn[e.Pattern]) {
+				return SeverityBlock, "file pattern blocked: " + e.Pattern, d.fileSources[i]
 			}
 		}
 	}
 
 	// Command patterns — checked for shell/command tools
 	if isCommandTool(lowerTool) {
-		for _, pattern := range d.commandPatterns {
-			if strings.Contains(lowerResource, strings.ToLower(pattern)) {
-				return true, "command pattern blocked: " + pattern
+		for i, e := range d.commandEntries {
+			if e.active(now) && e.scoped(lowerTool) && strings.Contains(lowerResource, strings.ToLower(e.Pattern)) && hit("command", e.Pattern, d.warn[e.Pattern]) {
+				return SeverityBlock, "command pattern blocked: " + e.Pattern, d.commandSources[i]
 			}
 		}
 		// Structural pipe-to-shell detection
 		if isPipeToShell(lowerResource) {
-			return true, "pipe-to-shell execution detected"
+			return SeverityBlock, "pipe-to-shell execution detected", ""
 		}
 	}
 
-	return false, ""
+	if warnReason != "" {
+		return SeverityWarn, warnReason, ""
+	}
+	return SeverityNone, "", ""
 }
 
 // AddPattern adds a pattern to the denylist at runtime.
 func (d *Denylist) AddPattern(category, pattern string) {
+	d.AddPatternFrom(category, pattern, "")
+}
+
+// AddPatternFrom adds a pattern at runtime and records where it came from
+// (e.g. "clawbot:urls") so blocks can report their provenance.
+func (d *Denylist) AddPatternFrom(category, pattern, source string) {
+	e := Entry{Pattern: pattern}
 	switch category {
 	case "urls":
-		d.raw.URLs = append(d.raw.URLs, pattern)
-		re := patternToRegex(pattern)
-		if compiled, err := regexp.Compile("(?i)" + re); err == nil {
-			d.urlPatterns = append(d.urlPatterns, compiled)
-		}
+		d.raw.URLs = append(d.raw.URLs, e)
+		d.addURLPattern(e, false, source)
 	case "files":
-		d.raw.Files = append(d.raw.Files, pattern)
-		d.filePatterns = append(d.filePatterns, pattern)
+		d.raw.Files = append(d.raw.Files, e)
+		d.addFilePattern(e, source)
 	case "commands":
-		d.raw.Commands = append(d.raw.Commands, pattern)
-		d.commandPatterns = append(d.commandPatterns, pattern)
+		d.raw.Commands = append(d.raw.Commands, e)
+		d.commandEntries = append(d.commandEntries, e)
+		d.commandSources = append(d.commandSources, source)
 	}
 }
 
+// WarnPatterns returns the patterns that alert without blocking.
+func (d *Denylist) WarnPatterns() []string {
+	return append([]string(nil), d.raw.Warn...)
+}
+
 // ToMap returns the raw patterns as a map for serialization.
 func (d *Denylist) ToMap() map[string]any {
 	return map[string]any{
-		"urls":     d.raw.URLs,
-		"files":    d.raw.Files,
-		"commands": d.raw.Commands,
+		"urls":     PatternsOf(d.raw.URLs),
+		"files":    PatternsOf(d.raw.Files),
+		"commands": PatternsOf(d.raw.Commands),
 	}
 }
 
@@ -149,6 +393,37 @@ func patternToRegex(pattern string) string {
 	return escaped
 }
 
+// addURLPattern compiles and appends a URL pattern; invalid patterns are skipped.
+func (d *Denylist) addURLPattern(e Entry, warn bool, source string) {
+	compiled, err := regexp.Compile("(?i)" + patternToRegex(e.Pattern))
+	if err != nil {
+		return
+	}
+	d.urlPatterns = append(d.urlPatterns, compiled)
+	d.urlEntries = append(d.urlEntries, e)
+	d.urlWarn = append(d.urlWarn, warn)
+	d.urlSources = append(d.urlSources, source)
+}
+
+// addFilePattern appends a file pattern, compiling it when it is a glob.
+// Loaders reject invalid globs (ValidateFilePattern); one added at runtime
+// falls back to containment rather than being dropped.
+func (d *Denylist) addFilePattern(e Entry, source string) {
+	re, _ := compileFileGlob(strings.ToLower(e.Pattern))
+	d.fileEntries = append(d.fileEntries, e)
+	d.fileSources = append(d.fileSources, source)
+	d.fileGlobs = append(d.fileGlobs, re)
+}
+
+// matchFile reports whether lowerResource matches file pattern i.
+func (d *Denylist) matchFile(i int, lowerResource string) bool {
+	if re := d.fileGlobs[i]; re != nil {
+		return re.MatchString(lowerResource)
+	}
+	return matchFilePattern(lowerResource, strings.ToLower(d.fileEntries[i].Pattern))
+}
+
+// matchFilePattern matches a plain (non-glob) file pattern by containment.
 func matchFilePattern(resource, pattern string) bool {
 	// Expand ~ in pattern for exact match
 	expanded := pattern
@@ -164,18 +439,107 @@ func matchFilePattern(resource, pattern string) bool {
 		}
 	}
 
-	// Glob-style: ** matches anything
-	if strings.Contains(expanded, "**") {
-		// Convert to simple substring: strip ** prefix
-		suffix := strings.ReplaceAll(expanded, "**/", "")
-		suffix = strings.ReplaceAll(suffix, "**", "")
-		return strings.Contains(resource, suffix)
-	}
-
 	// Direct containment
 	return strings.Contains(resource, expanded)
 }
 
+// ValidateFilePattern reports whether a file pattern compiles.
+func ValidateFilePattern(pattern string) error {
+	_, err := compileFileGlob(strings.ToLower(pattern))
+	return err
+}
+
+// compileFileGlob compiles a file glob to a regex, or returns nil when the
+// pattern has no glob syntax and is matched by containment.
+//
+//	**  matches across directory separators ("**/" also matches no directory)
+//	*   matches within a single path segment
+//	?   matches one character other than '/'
+//	[…] matches a character class; [!…] negates it
+//
+// A relative pattern (including "~/…") matches the trailing segments of a
+// path, so "**/x" and "*/x" match "/a/b/x". An absolute pattern must match
+// from the start of the path. Either way the match ends at the end of a
+// segment: "**/.env" matches "/p/.env" but not "/p/.envrc". Whitespace also
+// delimits paths, so patterns match paths embedded in command lines.
+func compileFileGlob(pattern string) (*regexp.Regexp, error) {
+	if !strings.ContainsAny(pattern, "*?[") {
+		return nil, nil
+	}
+
+	var b strings.Builder
+	rest := pattern
+	switch {
+	case strings.HasPrefix(rest, "/"):
+		b.WriteString(`(?:^|.*\s)`)
+	default:
+		rest = strings.TrimPrefix(rest, "~/")
+		b.WriteString(`(?:^|.*[/\s])`)
+	}
+
+	for i := 0; i < len(rest); i++ {
+		switch c := rest[i]; c {
+		case '*':
+			if i+1 < len(rest) && rest[i+1] == '*' {
+				i++
+				if i+1 < len(rest) && rest[i+1] == '/' {
+					i++
+					b.WriteString(`(?:.*/)?`)
+				} else {
+					b.WriteString(`.*`)
+				}
+			} else {
+				b.WriteString(`[^/]*`)
+			}
+		case '?':
+			b.WriteString(`[^/]`)
+		case '[':
+			end := classEnd(rest, i)
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := rest[i+1 : end]
+			b.WriteByte('[')
+			if strings.HasPrefix(class, "!") {
+				b.WriteByte('^')
+				class = class[1:]
+			}
+			b.WriteString(strings.ReplaceAll(class, `\`, `\\`))
+			b.WriteByte(']')
+			i = end
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString(`(?:[/\s].*)?$`)
+
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid file pattern %q: %w", pattern, err)
+	}
+	return re, nil
+}
+
+// classEnd returns the index of the ']' closing the class opened at
+// pattern[start], or -1. A ']' first in the class (after an optional '!')
+// is literal.
+func classEnd(pattern string, start int) int {
+	i := start + 1
+	if i < len(pattern) && pattern[i] == '!' {
+		i++
+	}
+	if i < len(pattern) && pattern[i] == ']' {
+		i++
+	}
+	for ; i < len(pattern); i++ {
+		if pattern[i] == ']' {
+			return i
+		}
+	}
+	return -1
+}
+
 func isBrowserTool(tool string) bool {
 	return strings.Contains(tool, "browser") || strings.Contains(tool, "http") || strings.Contains(tool, "web")
 }
@@ -1,9 +1,12 @@
 package denylist
 
 import (
+	"bytes"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestURLPatternBlocked(t *testing.T) {
@@ -63,6 +66,76 @@ func TestEnvFileBlocked(t *testing.T) {
 	}
 }
 
+func TestFileGlobPatterns(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		resource string
+		want     bool
+	}{
+		// ** crosses directory separators
+		{"**/secret.txt", "/a/b/c/secret.txt", true},
+		{"**/secret.txt", "/secret.txt", true},
+		{"**/secret.txt", "secret.txt", true},
+		{"**/secret.txt", "/a/b/mysecret.txt", false},
+		{"**/secret.txt", "/a/b/secret.txt.bak", false},
+		{"**/.env", "/project/.env", true},
+		{"**/.env", "/project/.envrc", false},
+		{"**/.env.*", "/project/.env.local", true},
+		{"**/.env.*", "/project/.env", false},
+		{"**/*.kdbx", "/home/u/vault/passwords.kdbx", true},
+		{"**/*.kdbx", "/home/u/vault/passwords.kdbx.txt", false},
+		{"**/credentials*", "/home/u/.aws/credentials", true},
+		{"**/credentials*", "/app/config/credentials.json", true},
+		{"**/credentials*", "/app/credentials/readme.md", true}, // directory match covers contents
+
+		// * stays within one segment
+		{"*/x", "/a/b/x", true},
+		{"*/x", "x", false},
+		{"a/*/x", "/r/a/b/x", true},
+		{"a/*/x", "/r/a/b/c/x", false},
+		{"/etc/*.conf", "/etc/app.conf", true},
+		{"/etc/*.conf", "/etc/app/sub.conf", false},
+		{"/etc/*.conf", "/opt/etc/app.conf", false},
+
+		// a/**/x spans zero or more directories
+		{"a/**/x", "/r/a/x", true},
+		{"a/**/x", "/r/a/b/c/x", true},
+		{"a/**/x", "/r/ab/x", false},
+		{"a/**/x", "/r/a/b/y", false},
+
+		// ? and character classes
+		{"**/key?.pem", "/k/key1.pem", true},
+		{"**/key?.pem", "/k/key12.pem", false},
+		{"**/key?.pem", "/k/key/.pem", false},
+		{"**/id_[re]*", "/h/.ssh/id_rsa", true},
+		{"**/id_[re]*", "/h/.ssh/id_ed25519", true},
+		{"**/id_[re]*", "/h/.ssh/id_dsa", false},
+		{"**/[!.]env", "/p/xenv", true},
+		{"**/[!.]env", "/p/.env", false},
+
+		// ~/ globs match under any home
+		{"~/.ssh/*", "/home/user/.ssh/id_rsa", true},
+		{"~/.ssh/*", "/home/user/.ssh/keys/id_rsa", true}, // directory match covers contents
+		{"~/.ssh/*", "/home/user/.sshx/id_rsa", false},
+
+		// Paths embedded in commands
+		{"**/.env", "cat /project/.env", true},
+		{"**/.env", "cat .env && echo ok", true},
+
+		// Plain patterns keep containment
+		{"~/.aws/credentials", "/home/u/.aws/credentials", true},
+		{"secrets.yaml", "/k8s/prod/secrets.yaml.bak", true},
+	}
+
+	for _, tt := range tests {
+		dl := New(Patterns{Files: Entries(tt.pattern)})
+		got, _ := dl.IsBlocked(tt.resource, "file_read")
+		if got != tt.want {
+			t.Errorf("pattern %q, resource %q: blocked=%v, want %v", tt.pattern, tt.resource, got, tt.want)
+		}
+	}
+}
+
 func TestSafeFileAllowed(t *testing.T) {
 	dl := NewDefault()
 
@@ -241,3 +314,166 @@ func TestToMap(t *testing.T) {
 		t.Error("expected commands in ToMap output")
 	}
 }
+
+func TestLoadWarnEntries(t *testing.T) {
+	tmpDir := t.TempDir()
+	yamlPath := filepath.Join(tmpDir, "denylist.yaml")
+	content := `commands:
+  - "rm -rf"
+  - pattern: "kubectl delete"
+    action: warn
+urls:
+  - pattern: "staging.example.com/**"
+    action: warn
+`
+	if err := os.WriteFile(yamlPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dl, err := Load(yamlPath)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if sev, reason := dl.Check("kubectl delete pod api-0", "command"); sev != SeverityWarn || reason == "" {
+		t.Errorf("expected warn match for kubectl delete, got %v %q", sev, reason)
+	}
+	if blocked, _ := dl.IsBlocked("kubectl delete pod api-0", "command"); blocked {
+		t.Error("warn entry must not block")
+	}
+	if sev, _ := dl.Check("https://staging.example.com/admin", "browser"); sev != SeverityWarn {
+		t.Errorf("expected warn match for URL entry, got %v", sev)
+	}
+	if sev, _ := dl.Check("rm -rf /tmp/x", "command"); sev != SeverityBlock {
+		t.Errorf("expected block for plain entry, got %v", sev)
+	}
+	// A blocking match wins over a warn match on the same resource.
+	if sev, _ := dl.Check("kubectl delete ns x && rm -rf /", "command"); sev != SeverityBlock {
+		t.Errorf("expected block to win over warn, got %v", sev)
+	}
+}
+
+func TestLoadRejectsInvalidFileGlob(t *testing.T) {
+	yamlPath := filepath.Join(t.TempDir(), "denylist.yaml")
+	if err := os.WriteFile(yamlPath, []byte("files:\n  - \"**/[z-a].key\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(yamlPath); err == nil || !strings.Contains(err.Error(), "[z-a]") {
+		t.Errorf("expected error naming the invalid glob, got %v", err)
+	}
+}
+
+func TestLoadRejectsUnknownAction(t *testing.T) {
+	yamlPath := filepath.Join(t.TempDir(), "denylist.yaml")
+	content := "commands:\n  - pattern: \"kubectl delete\"\n    action: maybe\n"
+	if err := os.WriteFile(yamlPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Load(yamlPath); err == nil {
+		t.Error("expected error for unknown action")
+	}
+}
+
+func TestLoadExpiringEntries(t *testing.T) {
+	yamlPath := filepath.Join(t.TempDir(), "denylist.yaml")
+	future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	past := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	content := "urls:\n" +
+		"  - pattern: \"c2-active.example.net\"\n    expires_at: " + future + "\n" +
+		"  - pattern: \"c2-old.example.net\"\n    expires_at: " + past + "\n" +
+		"commands:\n" +
+		"  - pattern: \"beacon-old\"\n    expires_at: " + past + "\n"
+	if err := os.WriteFile(yamlPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var warnings bytes.Buffer
+	warnOutput = &warnings
+	defer func() { warnOutput = os.Stderr }()
+
+	dl, err := Load(yamlPath)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if blocked, _ := dl.IsBlocked("https://c2-active.example.net/beacon", "browser"); !blocked {
+		t.Error("expected unexpired entry to block")
+	}
+	if blocked, _ := dl.IsBlocked("https://c2-old.example.net/beacon", "browser"); blocked {
+		t.Error("expected expired URL entry to be ignored")
+	}
+	if blocked, _ := dl.IsBlocked("beacon-old --start", "command"); blocked {
+		t.Error("expected expired command entry to be ignored")
+	}
+
+	out := warnings.String()
+	if !strings.Contains(out, "c2-old.example.net") || !strings.Contains(out, "beacon-old") {
+		t.Errorf("expected load-time warning for expired entries, got %q", out)
+	}
+	if strings.Contains(out, "c2-active.example.net") {
+		t.Errorf("unexpired entry must not warn, got %q", out)
+	}
+}
+
+func TestExpiryIsPerEntry(t *testing.T) {
+	past := time.Now().Add(-time.Hour)
+	dl := New(Patterns{
+		URLs:     []Entry{{Pattern: "beacon", ExpiresAt: past}},
+		Commands: Entries("beacon"),
+	})
+
+	if blocked, _ := dl.IsBlocked("https://beacon.example.net/", "browser"); blocked {
+		t.Error("expected expired URL entry to be ignored")
+	}
+	if blocked, _ := dl.IsBlocked("beacon --start", "command"); !blocked {
+		t.Error("expected unexpired command entry with the same pattern to block")
+	}
+}
+
+func TestToolScopeIsPerEntry(t *testing.T) {
+	dl := New(Patterns{
+		Files:    []Entry{{Pattern: "deploy.key", Tools: []string{"file_write"}}},
+		Commands: Entries("deploy.key"),
+	})
+
+	if blocked, _ := dl.IsBlocked("/srv/deploy.key", "file_read"); blocked {
+		t.Error("expected file entry scoped to file_write to allow file_read")
+	}
+	if blocked, _ := dl.IsBlocked("cat deploy.key", "command"); !blocked {
+		t.Error("expected unscoped command entry with the same pattern to block")
+	}
+}
+
+func TestLoadToolScopedEntries(t *testing.T) {
+	yamlPath := filepath.Join(t.TempDir(), "denylist.yaml")
+	content := "urls:\n" +
+		"  - pattern: \"pastebin.com\"\n    tools: [browser]\n" +
+		"files:\n" +
+		"  - pattern: \"~/.kube/config\"\n    tools: [File_Write]\n" +
+		"commands:\n" +
+		"  - \"rm -rf\"\n"
+	if err := os.WriteFile(yamlPath, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	dl, err := Load(yamlPath)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+
+	if blocked, _ := dl.IsBlocked("https://pastebin.com/raw/x", "browser"); !blocked {
+		t.Error("expected scoped URL entry to block browser")
+	}
+	if blocked, reason := dl.IsBlocked("https://pastebin.com/raw/x", "http_proxy"); blocked {
+		t.Errorf("expected scoped URL entry to allow http_proxy, got %q", reason)
+	}
+	if blocked, _ := dl.IsBlocked("~/.kube/config", "file_write"); !blocked {
+		t.Error("expected scoped file entry to block file_write (tool names are case-insensitive)")
+	}
+	if blocked, reason := dl.IsBlocked("~/.kube/config", "file_read"); blocked {
+		t.Errorf("expected scoped file entry to allow file_read, got %q", reason)
+	}
+	if blocked, _ := dl.IsBlocked("rm -rf /tmp/x", "command"); !blocked {
+		t.Error("expected unscoped entry to apply to every tool")
+	}
+}
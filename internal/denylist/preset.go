@@ -66,12 +66,55 @@ func ListPresets() []string {
 // Merge combines two Patterns, deduplicating entries.
 func Merge(base, overlay Patterns) Patterns {
 	return Patterns{
-		URLs:     dedup(append(base.URLs, overlay.URLs...)),
-		Files:    dedup(append(base.Files, overlay.Files...)),
-		Commands: dedup(append(base.Commands, overlay.Commands...)),
+		URLs:     mergeEntries(base.URLs, overlay.URLs),
+		Files:    mergeEntries(base.Files, overlay.Files),
+		Commands: mergeEntries(base.Commands, overlay.Commands),
+		Warn:     mergeWarn(base, overlay),
 	}
 }
 
+// mergeEntries unions two entry lists by pattern, preserving first-seen
+// order. An overlay entry replaces the settings of a base entry with the
+// same pattern, so an overlay can extend or clear its expiry and widen or
+// narrow its tool scope.
+func mergeEntries(base, overlay []Entry) []Entry {
+	out := make([]Entry, 0, len(base)+len(overlay))
+	index := make(map[string]int, len(base)+len(overlay))
+	for _, e := range append(append([]Entry(nil), base...), overlay...) {
+		if i, ok := index[e.Pattern]; ok {
+			out[i] = e
+			continue
+		}
+		index[e.Pattern] = len(out)
+		out = append(out, e)
+	}
+	return out
+}
+
+// mergeWarn unions warn patterns, dropping any the overlay lists as a
+// blocking entry so an overlay can promote a staged pattern to enforced.
+func mergeWarn(base, overlay Patterns) []string {
+	overlayWarn := make(map[string]bool, len(overlay.Warn))
+	for _, w := range overlay.Warn {
+		overlayWarn[w] = true
+	}
+	enforced := make(map[string]bool)
+	for _, list := range [][]Entry{overlay.URLs, overlay.Files, overlay.Commands} {
+		for _, e := range list {
+			if !overlayWarn[e.Pattern] {
+				enforced[e.Pattern] = true
+			}
+		}
+	}
+	var out []string
+	for _, w := range dedup(append(base.Warn, overlay.Warn...)) {
+		if !enforced[w] {
+			out = append(out, w)
+		}
+	}
+	return out
+}
+
 // dedup removes duplicate strings while preserving order.
 func dedup(items []string) []string {
 	seen := make(map[string]bool, len(items))
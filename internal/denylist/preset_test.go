@@ -3,6 +3,7 @@ package denylist
 import (
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestLoadPreset_SupplyChain(t *testing.T) {
@@ -26,7 +27,7 @@ func TestLoadPreset_SupplyChain(t *testing.T) {
 	for _, want := range wantURLs {
 		found := false
 		for _, u := range p.URLs {
-			if strings.Contains(u, want) {
+			if strings.Contains(u.Pattern, want) {
 				found = true
 				break
 			}
@@ -40,7 +41,7 @@ func TestLoadPreset_SupplyChain(t *testing.T) {
 	for _, want := range wantCommands {
 		found := false
 		for _, c := range p.Commands {
-			if strings.Contains(c, want) {
+			if strings.Contains(c.Pattern, want) {
 				found = true
 				break
 			}
@@ -54,7 +55,7 @@ func TestLoadPreset_SupplyChain(t *testing.T) {
 	for _, want := range wantFiles {
 		found := false
 		for _, f := range p.Files {
-			if strings.Contains(f, want) {
+			if strings.Contains(f.Pattern, want) {
 				found = true
 				break
 			}
@@ -94,14 +95,14 @@ func TestListPresets(t *testing.T) {
 
 func TestMerge_CombinesPatterns(t *testing.T) {
 	base := Patterns{
-		URLs:     []string{"a.com", "b.com"},
-		Files:    []string{"~/.ssh/id_rsa"},
-		Commands: []string{"rm -rf /"},
+		URLs:     Entries("a.com", "b.com"),
+		Files:    Entries("~/.ssh/id_rsa"),
+		Commands: Entries("rm -rf /"),
 	}
 	overlay := Patterns{
-		URLs:     []string{"c.com", "a.com"}, // a.com is duplicate
-		Files:    []string{"**/.env"},
-		Commands: []string{"npm publish", "rm -rf /"}, // rm -rf / is duplicate
+		URLs:     Entries("c.com", "a.com"), // a.com is duplicate
+		Files:    Entries("**/.env"),
+		Commands: Entries("npm publish", "rm -rf /"), // rm -rf / is duplicate
 	}
 
 	merged := Merge(base, overlay)
@@ -124,9 +125,9 @@ func TestMerge_CombinesPatterns(t *testing.T) {
 
 func TestMerge_EmptyOverlay(t *testing.T) {
 	base := Patterns{
-		URLs:     []string{"a.com"},
-		Files:    []string{"~/.ssh/id_rsa"},
-		Commands: []string{"rm -rf /"},
+		URLs:     Entries("a.com"),
+		Files:    Entries("~/.ssh/id_rsa"),
+		Commands: Entries("rm -rf /"),
 	}
 	empty := Patterns{}
 
@@ -140,8 +141,8 @@ func TestMerge_EmptyOverlay(t *testing.T) {
 func TestMerge_EmptyBase(t *testing.T) {
 	empty := Patterns{}
 	overlay := Patterns{
-		URLs:     []string{"a.com"},
-		Commands: []string{"npm publish"},
+		URLs:     Entries("a.com"),
+		Commands: Entries("npm publish"),
 	}
 
 	merged := Merge(empty, overlay)
@@ -152,8 +153,8 @@ func TestMerge_EmptyBase(t *testing.T) {
 }
 
 func TestMerge_PreservesOrder(t *testing.T) {
-	base := Patterns{URLs: []string{"first", "second"}}
-	overlay := Patterns{URLs: []string{"third", "first"}} // first is dup
+	base := Patterns{URLs: Entries("first", "second")}
+	overlay := Patterns{URLs: Entries("third", "first")} // first is dup
 
 	merged := Merge(base, overlay)
 	want := []string{"first", "second", "third"}
@@ -161,12 +162,27 @@ func TestMerge_PreservesOrder(t *testing.T) {
 		t.Fatalf("URLs count: got %d, want %d", len(merged.URLs), len(want))
 	}
 	for i, got := range merged.URLs {
-		if got != want[i] {
-			t.Errorf("URLs[%d]: got %q, want %q", i, got, want[i])
+		if got.Pattern != want[i] {
+			t.Errorf("URLs[%d]: got %q, want %q", i, got.Pattern, want[i])
 		}
 	}
 }
 
+func TestMerge_PreservesExpiry(t *testing.T) {
+	exp := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	base := Patterns{URLs: []Entry{{Pattern: "c2.example.net", ExpiresAt: exp}}}
+
+	merged := Merge(base, Patterns{URLs: Entries("other.example.net")})
+	if got := merged.URLs[0].ExpiresAt; !got.Equal(exp) {
+		t.Errorf("expected base expiry kept, got %v", got)
+	}
+
+	merged = Merge(base, Patterns{URLs: Entries("c2.example.net")})
+	if len(merged.URLs) != 1 || !merged.URLs[0].ExpiresAt.IsZero() {
+		t.Errorf("expected overlay entry to replace the expiry, got %+v", merged.URLs)
+	}
+}
+
 func TestSupplyChainPreset_BlocksRealAttacks(t *testing.T) {
 	preset, err := LoadPreset("supply-chain")
 	if err != nil {
@@ -209,3 +225,32 @@ func TestSupplyChainPreset_BlocksRealAttacks(t *testing.T) {
 		}
 	}
 }
+
+func TestMergeOverlayPromotesWarnEntry(t *testing.T) {
+	base := Patterns{Commands: Entries("kubectl delete"), Warn: []string{"kubectl delete"}}
+	overlay := Patterns{Commands: Entries("kubectl delete")}
+
+	merged := Merge(base, overlay)
+	if len(merged.Warn) != 0 {
+		t.Errorf("expected overlay block entry to drop warn, got %v", merged.Warn)
+	}
+	if kept := Merge(base, Patterns{}); len(kept.Warn) != 1 {
+		t.Errorf("expected warn entry preserved without overlay, got %v", kept.Warn)
+	}
+}
+
+func TestMerge_ToolScopes(t *testing.T) {
+	base := Patterns{URLs: []Entry{
+		{Pattern: "pastebin.com", Tools: []string{"browser"}},
+		{Pattern: "transfer.sh", Tools: []string{"browser"}},
+	}}
+	overlay := Patterns{URLs: Entries("transfer.sh")}
+
+	merged := Merge(base, overlay)
+	if got := merged.URLs[0].Tools; len(got) != 1 || got[0] != "browser" {
+		t.Errorf("expected base scope kept, got %v", got)
+	}
+	if got := merged.URLs[1].Tools; len(got) != 0 {
+		t.Errorf("expected unscoped overlay entry to widen the pattern to all tools, got %v", got)
+	}
+}
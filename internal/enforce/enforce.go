@@ -25,7 +25,8 @@ func (e *EnforcementError) Error() string {
 // Returns the (possibly modified) data, or an error if blocked.
 func Enforce(result model.PolicyResult, data any) (any, error) {
 	switch result.Decision {
-	case model.Deny:
+	case model.Deny, model.Quarantine:
+		// Enforce has no way to contain effects, so quarantine blocks.
 		return nil, &EnforcementError{
 			Decision: result.Decision,
 			Reason:   result.Reason,
@@ -42,6 +42,20 @@ func TestDenyRaisesError(t *testing.T) {
 	}
 }
 
+func TestQuarantineRaisesError(t *testing.T) {
+	result := model.PolicyResult{Decision: model.Quarantine, Reason: "untrusted write"}
+
+	data, err := Enforce(result, "payload")
+
+	var enfErr *EnforcementError
+	if !errors.As(err, &enfErr) {
+		t.Fatalf("expected EnforcementError for Quarantine, got %T", err)
+	}
+	if enfErr.Decision != model.Quarantine || data != nil {
+		t.Errorf("expected quarantine to block without data, got %s %v", enfErr.Decision, data)
+	}
+}
+
 func TestRequireApprovalRaisesError(t *testing.T) {
 	result := model.PolicyResult{
 		Decision:    model.RequireApproval,
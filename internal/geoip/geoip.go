@@ -0,0 +1,195 @@
+// Package geoip classifies egress destinations by country and autonomous
+// system using local MaxMind DB files (GeoLite2/GeoIP2 Country, City, ASN),
+// so policy rules can match on where traffic is going.
+package geoip
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/oschwald/maxminddb-golang"
+
+	"github.com/ppiankov/chainwatch/internal/model"
+)
+
+// Label keys set on actions whose destination was classified. Rules match
+// them with label selectors, e.g. labels: {dest_asn: "64500"}.
+const (
+	LabelCountry = "dest_country"
+	LabelASN     = "dest_asn"
+)
+
+// resolveTimeout bounds the DNS lookup for a hostname destination.
+const resolveTimeout = 2 * time.Second
+
+// Info is what is known about an IP address.
+type Info struct {
+	Country string // ISO 3166-1 alpha-2, e.g. "DE"
+	ASN     uint   // autonomous system number
+	ASOrg   string // autonomous system organization
+}
+
+// Lookup classifies an IP address. ok is false when nothing is known.
+type Lookup interface {
+	Lookup(addr netip.Addr) (info Info, ok bool)
+}
+
+// DB answers lookups from one or more MMDB files. MaxMind ships country
+// and ASN data separately; fields found in later files fill gaps left by
+// earlier ones.
+type DB struct {
+	dbs []*maxminddb.Reader
+}
+
+// record holds the fields read from any of the supported databases.
+type record struct {
+	Country           isoCode `maxminddb:"country"`
+	RegisteredCountry isoCode `maxminddb:"registered_country"`
+	ASN               uint    `maxminddb:"autonomous_system_number"`
+	ASOrg             string  `maxminddb:"autonomous_system_organization"`
+}
+
+type isoCode struct {
+	ISOCode string `maxminddb:"iso_code"`
+}
+
+// Open loads the MMDB files at paths. It returns nil when paths is empty,
+// which disables classification.
+func Open(paths []string) (*DB, error) {
+	var db DB
+	for _, p := range paths {
+		if strings.TrimSpace(p) == "" {
+			continue
+		}
+		buf, err := os.ReadFile(p)
+		if err != nil {
+			return nil, fmt.Errorf("read geoip db: %w", err)
+		}
+		m, err := maxminddb.FromBytes(buf)
+		if err != nil {
+			return nil, fmt.Errorf("%s: geoip db: %w", p, err)
+		}
+		db.dbs = append(db.dbs, m)
+	}
+	if len(db.dbs) == 0 {
+		return nil, nil
+	}
+	return &db, nil
+}
+
+// Lookup implements Lookup. Corrupt records, and IPv6 addresses in an
+// IPv4-only database, are treated as unknown.
+func (db *DB) Lookup(addr netip.Addr) (Info, bool) {
+	var info Info
+	ip := net.IP(addr.Unmap().AsSlice())
+	for _, m := range db.dbs {
+		var rec record
+		if err := m.Lookup(ip, &rec); err != nil {
+			continue
+		}
+		if info.Country == "" {
+			// The registered country covers anycast and satellite ranges.
+			info.Country = rec.Country.ISOCode
+			if info.Country == "" {
+				info.Country = rec.RegisteredCountry.ISOCode
+			}
+		}
+		if info.ASN == 0 && rec.ASN != 0 {
+			info.ASN = rec.ASN
+			info.ASOrg = rec.ASOrg
+		}
+	}
+	return info, info.Country != "" || info.ASN != 0
+}
+
+// Tagger classifies action destinations. A nil Tagger is a no-op, so
+// callers need not check whether a database is configured.
+type Tagger struct {
+	lookup  Lookup
+	resolve func(ctx context.Context, host string) ([]netip.Addr, error)
+}
+
+// NewTagger returns a Tagger backed by lookup, or nil if lookup is nil.
+func NewTagger(lookup Lookup) *Tagger {
+	if lookup == nil {
+		return nil
+	}
+	if db, ok := lookup.(*DB); ok && db == nil {
+		return nil
+	}
+	return &Tagger{lookup: lookup, resolve: resolveHost}
+}
+
+// Tag classifies host, an IP literal or hostname (resolved via DNS) with
+// an optional port, and
+// records the result on the action: dest_country, dest_asn, and
+// dest_as_org in RawMeta, and dest_country and dest_asn as labels.
+// Operator-supplied labels are never overwritten. Unknown or unresolvable
+// destinations leave the action unchanged.
+func (t *Tagger) Tag(ctx context.Context, action *model.Action, host string) {
+	if t == nil || host == "" {
+		return
+	}
+	info, ok := t.classify(ctx, host)
+	if !ok {
+		return
+	}
+
+	if action.RawMeta == nil {
+		action.RawMeta = map[string]any{}
+	}
+	if info.Country != "" {
+		action.RawMeta["dest_country"] = info.Country
+		setLabel(action, LabelCountry, info.Country)
+	}
+	if info.ASN != 0 {
+		action.RawMeta["dest_asn"] = int(info.ASN)
+		setLabel(action, LabelASN, strconv.FormatUint(uint64(info.ASN), 10))
+		if info.ASOrg != "" {
+			action.RawMeta["dest_as_org"] = info.ASOrg
+		}
+	}
+}
+
+func (t *Tagger) classify(ctx context.Context, host string) (Info, bool) {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.Trim(host, "[]")
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return t.lookup.Lookup(addr)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, resolveTimeout)
+	defer cancel()
+	addrs, err := t.resolve(ctx, host)
+	if err != nil {
+		return Info{}, false
+	}
+	for _, addr := range addrs {
+		if info, ok := t.lookup.Lookup(addr); ok {
+			return info, true
+		}
+	}
+	return Info{}, false
+}
+
+func resolveHost(ctx context.Context, host string) ([]netip.Addr, error) {
+	return net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+}
+
+func setLabel(action *model.Action, k, v string) {
+	if _, exists := action.Labels[k]; exists {
+		return
+	}
+	if action.Labels == nil {
+		action.Labels = make(map[string]string)
+	}
+	action.Labels[k] = v
+}
@@ -0,0 +1,289 @@
+package geoip
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/ppiankov/chainwatch/internal/denylist"
+	"github.com/ppiankov/chainwatch/internal/model"
+	"github.com/ppiankov/chainwatch/internal/policy"
+)
+
+// stubLookup answers from a fixed table.
+type stubLookup map[netip.Addr]Info
+
+func (s stubLookup) Lookup(addr netip.Addr) (Info, bool) {
+	info, ok := s[addr]
+	return info, ok
+}
+
+var (
+	badHost  = netip.MustParseAddr("203.0.113.7")
+	goodHost = netip.MustParseAddr("198.51.100.9")
+)
+
+func testTagger() *Tagger {
+	t := NewTagger(stubLookup{
+		badHost:  {Country: "ZZ", ASN: 64500, ASOrg: "Bulletproof Hosting Ltd"},
+		goodHost: {Country: "DE", ASN: 64501, ASOrg: "Example Cloud"},
+	})
+	t.resolve = func(ctx context.Context, host string) ([]netip.Addr, error) {
+		if host == "exfil.example.net" {
+			return []netip.Addr{badHost}, nil
+		}
+		return nil, errors.New("no such host")
+	}
+	return t
+}
+
+func httpAction(host string) *model.Action {
+	return &model.Action{
+		Tool:      "http_proxy",
+		Resource:  "https://" + host + "/upload",
+		Operation: "post",
+		RawMeta:   map[string]any{"egress": "external", "destination": host},
+	}
+}
+
+func TestTagIPLiteral(t *testing.T) {
+	action := httpAction("203.0.113.7")
+	testTagger().Tag(context.Background(), action, "203.0.113.7:443")
+
+	if action.RawMeta["dest_country"] != "ZZ" || action.RawMeta["dest_asn"] != 64500 || action.RawMeta["dest_as_org"] != "Bulletproof Hosting Ltd" {
+		t.Errorf("unexpected RawMeta: %v", action.RawMeta)
+	}
+	if action.Labels[LabelCountry] != "ZZ" || action.Labels[LabelASN] != "64500" {
+		t.Errorf("unexpected labels: %v", action.Labels)
+	}
+
+	// Tags survive normalization during evaluation.
+	action.NormalizeMeta()
+	if meta := action.NormalizedMeta(); meta.DestCountry != "ZZ" || meta.DestASN != 64500 {
+		t.Errorf("tags lost on normalize: %+v", meta)
+	}
+}
+
+func TestTagResolvesHostname(t *testing.T) {
+	action := httpAction("exfil.example.net")
+	testTagger().Tag(context.Background(), action, "exfil.example.net")
+	if action.Labels[LabelASN] != "64500" {
+		t.Errorf("expected resolved destination tagged, got %v", action.Labels)
+	}
+}
+
+func TestTagUnknownLeavesActionUnchanged(t *testing.T) {
+	for _, host := range []string{"192.0.2.1", "unresolvable.invalid"} {
+		action := httpAction(host)
+		testTagger().Tag(context.Background(), action, host)
+		if action.Labels != nil || action.RawMeta["dest_country"] != nil {
+			t.Errorf("%s: expected no tags, got labels %v meta %v", host, action.Labels, action.RawMeta)
+		}
+	}
+}
+
+func TestTagKeepsOperatorLabels(t *testing.T) {
+	action := httpAction("203.0.113.7")
+	action.Labels = map[string]string{LabelCountry: "override"}
+	testTagger().Tag(context.Background(), action, "203.0.113.7")
+	if action.Labels[LabelCountry] != "override" {
+		t.Errorf("operator label overwritten: %v", action.Labels)
+	}
+}
+
+func TestNilTaggerIsNoop(t *testing.T) {
+	db, err := Open(nil)
+	if err != nil || db != nil {
+		t.Fatalf("expected nil DB without paths, got %v, %v", db, err)
+	}
+	tagger := NewTagger(db)
+	action := httpAction("203.0.113.7")
+	tagger.Tag(context.Background(), action, "203.0.113.7")
+	if action.Labels != nil {
+		t.Errorf("expected nil tagger to leave action alone, got %v", action.Labels)
+	}
+}
+
+func TestRuleMatchesHighRiskASN(t *testing.T) {
+	cfg := policy.DefaultConfig()
+	cfg.Rules = append([]policy.Rule{{
+		Purpose:         "*",
+		ResourcePattern: "*",
+		Labels:          map[string]string{LabelASN: "64500"},
+		Decision:        "deny",
+		Reason:          "egress to high-risk ASN",
+	}}, cfg.Rules...)
+
+	evaluate := func(host string) model.PolicyResult {
+		action := httpAction(host)
+		testTagger().Tag(context.Background(), action, host)
+		return policy.Evaluate(action, model.NewTraceState("t-geo"), "general", "", denylist.NewDefault(), cfg)
+	}
+
+	if r := evaluate("203.0.113.7"); r.Decision != model.Deny || r.Reason != "egress to high-risk ASN" {
+		t.Errorf("expected deny for high-risk ASN, got %s: %s", r.Decision, r.Reason)
+	}
+	if r := evaluate("198.51.100.9"); r.Reason == "egress to high-risk ASN" {
+		t.Errorf("rule should not match other ASNs, got %s: %s", r.Decision, r.Reason)
+	}
+}
+
+func TestOpenMMDB(t *testing.T) {
+	dir := t.TempDir()
+	countryPath := filepath.Join(dir, "country.mmdb")
+	asnPath := filepath.Join(dir, "asn.mmdb")
+	writeMMDB(t, countryPath, []mmdbNetwork{
+		{netip.MustParsePrefix("203.0.113.0/24"), map[string]any{"country": map[string]any{"iso_code": "ZZ"}}},
+		{netip.MustParsePrefix("198.51.100.0/25"), map[string]any{"registered_country": map[string]any{"iso_code": "DE"}}},
+	})
+	writeMMDB(t, asnPath, []mmdbNetwork{
+		{netip.MustParsePrefix("203.0.113.0/25"), map[string]any{
+			"autonomous_system_number":       uint32(64500),
+			"autonomous_system_organization": "Bulletproof Hosting Ltd",
+		}},
+	})
+
+	db, err := Open([]string{countryPath, asnPath})
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	tests := []struct {
+		ip   string
+		want Info
+		ok   bool
+	}{
+		{"203.0.113.7", Info{Country: "ZZ", ASN: 64500, ASOrg: "Bulletproof Hosting Ltd"}, true},
+		{"203.0.113.200", Info{Country: "ZZ"}, true},
+		{"198.51.100.9", Info{Country: "DE"}, true},
+		{"198.51.100.200", Info{}, false},
+		{"192.0.2.1", Info{}, false},
+		{"2001:db8::1", Info{}, false},
+	}
+	for _, tt := range tests {
+		got, ok := db.Lookup(netip.MustParseAddr(tt.ip))
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("Lookup(%s) = %+v, %v; want %+v, %v", tt.ip, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestOpenRejectsNonMMDB(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "bogus.mmdb")
+	if err := os.WriteFile(path, []byte("not a database"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open([]string{path}); err == nil {
+		t.Fatal("expected error for file without metadata")
+	}
+}
+
+// --- minimal MMDB writer (IPv4 tree, 24-bit records) ---
+
+// metadataMarker precedes the metadata map at the end of an MMDB file.
+var metadataMarker = []byte("\xab\xcd\xefMaxMind.com")
+
+// dataSectionSeparator is the run of zero bytes between the search tree
+// and the data section.
+const dataSectionSeparator = 16
+
+// MMDB data section type numbers used by encodeValue.
+const (
+	typeString = 2
+	typeUint16 = 5
+	typeUint32 = 6
+	typeMap    = 7
+)
+
+type mmdbNetwork struct {
+	prefix netip.Prefix
+	record map[string]any
+}
+
+func writeMMDB(t *testing.T, path string, networks []mmdbNetwork) {
+	t.Helper()
+
+	const empty = -1
+	nodes := [][2]int{{empty, empty}}
+	var data bytes.Buffer
+	leaf := map[[2]int]int{} // (node, bit) -> data offset
+	for _, n := range networks {
+		ip := n.prefix.Addr().As4()
+		node := 0
+		for i := 0; i < n.prefix.Bits(); i++ {
+			bit := int(ip[i/8]>>(7-i%8)) & 1
+			if i == n.prefix.Bits()-1 {
+				leaf[[2]int{node, bit}] = data.Len()
+				encodeValue(&data, n.record)
+				break
+			}
+			if nodes[node][bit] == empty {
+				nodes = append(nodes, [2]int{empty, empty})
+				nodes[node][bit] = len(nodes) - 1
+			}
+			node = nodes[node][bit]
+		}
+	}
+
+	count := len(nodes)
+	var out bytes.Buffer
+	for i, n := range nodes {
+		for bit, rec := range n {
+			v := count // not found
+			if off, ok := leaf[[2]int{i, bit}]; ok {
+				v = count + dataSectionSeparator + off
+			} else if rec != empty {
+				v = rec
+			}
+			out.Write([]byte{byte(v >> 16), byte(v >> 8), byte(v)})
+		}
+	}
+	out.Write(make([]byte, dataSectionSeparator))
+	out.Write(data.Bytes())
+	out.Write(metadataMarker)
+	encodeValue(&out, map[string]any{
+		"binary_format_major_version": uint16(2),
+		"binary_format_minor_version": uint16(0),
+		"node_count":                  uint32(count),
+		"record_size":                 uint16(24),
+		"ip_version":                  uint16(4),
+		"database_type":               "Test",
+	})
+	if err := os.WriteFile(path, out.Bytes(), 0o600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func encodeValue(b *bytes.Buffer, v any) {
+	switch x := v.(type) {
+	case string:
+		if len(x) < 29 {
+			b.WriteByte(typeString<<5 | byte(len(x)))
+		} else {
+			b.Write([]byte{typeString<<5 | 29, byte(len(x) - 29)})
+		}
+		b.WriteString(x)
+	case uint16:
+		b.WriteByte(typeUint16<<5 | 2)
+		b.Write([]byte{byte(x >> 8), byte(x)})
+	case uint32:
+		b.WriteByte(typeUint32<<5 | 4)
+		b.Write([]byte{byte(x >> 24), byte(x >> 16), byte(x >> 8), byte(x)})
+	case map[string]any:
+		b.WriteByte(typeMap<<5 | byte(len(x)))
+		keys := make([]string, 0, len(x))
+		for k := range x {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			encodeValue(b, k)
+			encodeValue(b, x[k])
+		}
+	}
+}
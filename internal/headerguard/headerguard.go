@@ -0,0 +1,118 @@
+// Package headerguard sanitizes HTTP headers passing through the proxies.
+//
+// Both the forward proxy and the interceptor otherwise copy request and
+// response headers verbatim. A Guard strips configured headers in each
+// direction, caps the total size of a request's headers, and exposes
+// header values for secret and canary scanning.
+package headerguard
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Guard applies header sanitization. A nil Guard leaves headers untouched.
+type Guard struct {
+	stripRequest  []string // canonical header names
+	stripResponse []string
+	maxBytes      int
+	scanValues    bool
+}
+
+// New builds a Guard. Header names are matched case-insensitively.
+// maxBytes of zero disables the size cap. Returns nil when nothing is
+// configured.
+func New(stripRequest, stripResponse []string, maxBytes int, scanValues bool) (*Guard, error) {
+	if maxBytes < 0 {
+		return nil, fmt.Errorf("max header bytes must not be negative, got %d", maxBytes)
+	}
+	if len(stripRequest) == 0 && len(stripResponse) == 0 && maxBytes == 0 && !scanValues {
+		return nil, nil
+	}
+	req, err := canonicalNames(stripRequest)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := canonicalNames(stripResponse)
+	if err != nil {
+		return nil, err
+	}
+	return &Guard{stripRequest: req, stripResponse: resp, maxBytes: maxBytes, scanValues: scanValues}, nil
+}
+
+func canonicalNames(names []string) ([]string, error) {
+	out := make([]string, 0, len(names))
+	for _, n := range names {
+		n = strings.TrimSpace(n)
+		if n == "" {
+			return nil, fmt.Errorf("empty header name in strip list")
+		}
+		out = append(out, http.CanonicalHeaderKey(n))
+	}
+	return out, nil
+}
+
+// Size returns the wire size of h: "Name: value\r\n" per value.
+func Size(h http.Header) int {
+	n := 0
+	for k, vv := range h {
+		for _, v := range vv {
+			n += len(k) + len(v) + 4
+		}
+	}
+	return n
+}
+
+// CheckRequest returns an error when h exceeds the configured size cap.
+func (g *Guard) CheckRequest(h http.Header) error {
+	if g == nil || g.maxBytes == 0 {
+		return nil
+	}
+	if size := Size(h); size > g.maxBytes {
+		return fmt.Errorf("request headers total %d bytes, limit is %d", size, g.maxBytes)
+	}
+	return nil
+}
+
+// StripRequest removes the request denylist from h before forwarding.
+func (g *Guard) StripRequest(h http.Header) {
+	if g == nil {
+		return
+	}
+	for _, k := range g.stripRequest {
+		h.Del(k)
+	}
+}
+
+// StripResponse removes the response denylist from h before returning.
+func (g *Guard) StripResponse(h http.Header) {
+	if g == nil {
+		return
+	}
+	for _, k := range g.stripResponse {
+		h.Del(k)
+	}
+}
+
+// ScanText joins every header value of h, in name order, for content
+// scanning. Returns "" unless value scanning is enabled.
+func (g *Guard) ScanText(h http.Header) string {
+	if g == nil || !g.scanValues {
+		return ""
+	}
+	keys := make([]string, 0, len(h))
+	for k := range h {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		for _, v := range h[k] {
+			b.WriteString(v)
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
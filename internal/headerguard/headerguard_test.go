@@ -0,0 +1,72 @@
+package headerguard
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestNewReturnsNilWhenUnconfigured(t *testing.T) {
+	g, err := New(nil, nil, 0, false)
+	if err != nil || g != nil {
+		t.Fatalf("expected nil guard, got %v, %v", g, err)
+	}
+	h := http.Header{"X-Secret": {"v"}}
+	g.StripRequest(h)
+	if err := g.CheckRequest(h); err != nil || h.Get("X-Secret") != "v" || g.ScanText(h) != "" {
+		t.Error("nil guard must leave headers untouched")
+	}
+}
+
+func TestNewRejectsInvalidConfig(t *testing.T) {
+	if _, err := New(nil, nil, -1, false); err == nil {
+		t.Error("expected error for negative size cap")
+	}
+	if _, err := New([]string{" "}, nil, 0, false); err == nil {
+		t.Error("expected error for empty header name")
+	}
+}
+
+func TestStripIsCaseInsensitive(t *testing.T) {
+	g, err := New([]string{"x-internal-token"}, []string{"SERVER"}, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := http.Header{}
+	req.Set("X-Internal-Token", "t")
+	req.Set("Accept", "*/*")
+	g.StripRequest(req)
+	if req.Get("X-Internal-Token") != "" || req.Get("Accept") == "" {
+		t.Errorf("unexpected request headers after strip: %v", req)
+	}
+	resp := http.Header{}
+	resp.Set("Server", "nginx")
+	g.StripResponse(resp)
+	if resp.Get("Server") != "" {
+		t.Errorf("response header not stripped: %v", resp)
+	}
+}
+
+func TestCheckRequestSizeCap(t *testing.T) {
+	g, err := New(nil, nil, 64, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := g.CheckRequest(http.Header{"Accept": {"*/*"}}); err != nil {
+		t.Errorf("small headers rejected: %v", err)
+	}
+	if err := g.CheckRequest(http.Header{"X-Padding": {strings.Repeat("a", 64)}}); err == nil {
+		t.Error("expected oversized headers to be rejected")
+	}
+}
+
+func TestScanTextOrdered(t *testing.T) {
+	g, err := New(nil, nil, 0, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := g.ScanText(http.Header{"X-B": {"two"}, "X-A": {"one"}})
+	if got != "one\ntwo\n" {
+		t.Errorf("ScanText = %q", got)
+	}
+}
@@ -0,0 +1,50 @@
+package identity
+
+import (
+	"net/http"
+	"strings"
+	"unicode"
+)
+
+// DefaultAgentHeader is the conventional request header carrying an agent ID.
+const DefaultAgentHeader = "X-Agent-ID"
+
+// maxHeaderAgentID bounds agent IDs taken from request headers.
+const maxHeaderAgentID = 128
+
+// FromHeader resolves the agent identity for an incoming request.
+// An explicitly configured defaultID always wins: a request cannot claim
+// a different identity than the operator assigned. Otherwise, if header
+// is set and the request carries a valid value, that value becomes the
+// agent ID and is merged into a copy of defaultActor as "agent_id".
+// Values that are too long or contain control characters are ignored.
+func FromHeader(r *http.Request, header, defaultID string, defaultActor map[string]any) (string, map[string]any) {
+	if header == "" || defaultID != "" || r == nil {
+		return defaultID, defaultActor
+	}
+	id := strings.TrimSpace(r.Header.Get(header))
+	if !ValidAgentID(id) {
+		return defaultID, defaultActor
+	}
+
+	actor := make(map[string]any, len(defaultActor)+1)
+	for k, v := range defaultActor {
+		actor[k] = v
+	}
+	actor["agent_id"] = id
+	return id, actor
+}
+
+// ValidAgentID reports whether an externally supplied agent ID is usable:
+// non-empty, bounded in length, and free of control characters.
+func ValidAgentID(id string) bool {
+	if id == "" || len(id) > maxHeaderAgentID {
+		return false
+	}
+	for _, r := range id {
+		if unicode.IsControl(r) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,52 @@
+package identity
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFromHeaderUsesHeaderValue(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("X-Agent-ID", " agent-alpha ")
+	defaultActor := map[string]any{"proxy": "chainwatch"}
+
+	id, actor := FromHeader(r, DefaultAgentHeader, "", defaultActor)
+	if id != "agent-alpha" {
+		t.Errorf("expected agent-alpha, got %q", id)
+	}
+	if actor["agent_id"] != "agent-alpha" || actor["proxy"] != "chainwatch" {
+		t.Errorf("expected merged actor, got %v", actor)
+	}
+	if _, mutated := defaultActor["agent_id"]; mutated {
+		t.Error("default actor must not be mutated")
+	}
+}
+
+func TestFromHeaderFallsBackToDefaults(t *testing.T) {
+	defaultActor := map[string]any{"proxy": "chainwatch"}
+	tests := []struct {
+		name   string
+		header string
+		value  string
+	}{
+		{"missing header", DefaultAgentHeader, ""},
+		{"header disabled", "", "agent-alpha"},
+		{"control characters", DefaultAgentHeader, "agent\x01alpha"},
+		{"too long", DefaultAgentHeader, strings.Repeat("a", maxHeaderAgentID+1)},
+		{"configured agent wins", DefaultAgentHeader, "agent-alpha"},
+	}
+	for _, tt := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		if tt.value != "" {
+			r.Header[DefaultAgentHeader] = []string{tt.value}
+		}
+		id, actor := FromHeader(r, tt.header, "default", defaultActor)
+		if id != "default" {
+			t.Errorf("%s: expected default agent, got %q", tt.name, id)
+		}
+		if _, ok := actor["agent_id"]; ok {
+			t.Errorf("%s: expected default actor, got %v", tt.name, actor)
+		}
+	}
+}
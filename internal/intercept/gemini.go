@@ -0,0 +1,297 @@
+package intercept
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/ppiankov/chainwatch/internal/model"
+)
+
+// isGeminiBody reports whether body has the generateContent shape:
+// candidates[].content.parts[]. Function calls are parts carrying a
+// functionCall object.
+func isGeminiBody(body map[string]any) bool {
+	candidates, ok := body["candidates"].([]any)
+	if !ok {
+		return false
+	}
+	for _, c := range candidates {
+		if _, ok := geminiParts(c); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// geminiParts returns candidate.content.parts.
+func geminiParts(candidate any) ([]any, bool) {
+	cand, ok := candidate.(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	content, ok := cand["content"].(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	parts, ok := content["parts"].([]any)
+	return parts, ok
+}
+
+// extractGemini extracts functionCall parts from every candidate.
+// Gemini: candidates[].content.parts[].functionCall.{name, args, id}
+// Index counts parts across candidates in order, so RewriteGemini can
+// find each call again.
+func extractGemini(body map[string]any) []ToolCall {
+	candidates, _ := body["candidates"].([]any)
+	var calls []ToolCall
+	index := 0
+	for _, c := range candidates {
+		parts, _ := geminiParts(c)
+		for _, p := range parts {
+			i := index
+			index++
+			part, ok := p.(map[string]any)
+			if !ok {
+				continue
+			}
+			raw, ok := part["functionCall"]
+			if !ok {
+				continue
+			}
+			tc := ToolCall{Index: i, Format: FormatGemini}
+			fn, ok := raw.(map[string]any)
+			if !ok {
+				tc.ParseError = fmt.Sprintf("malformed functionCall: %T, not an object", raw)
+				calls = append(calls, tc)
+				continue
+			}
+			tc.ID, _ = fn["id"].(string)
+			tc.Name, _ = fn["name"].(string)
+			switch args := fn["args"].(type) {
+			case map[string]any:
+				tc.Arguments = args
+			case nil:
+			default:
+				tc.ParseError = fmt.Sprintf("malformed functionCall args: %T, not an object", args)
+			}
+			calls = append(calls, tc)
+		}
+	}
+	return calls
+}
+
+// RewriteGemini replaces each blocked functionCall part with a text part
+// carrying the block explanation. Returns whether anything changed.
+func RewriteGemini(body map[string]any, results []EvalResult) bool {
+	blocked := make(map[int]EvalResult)
+	for _, er := range results {
+		if !isAllowed(er.Result) {
+			blocked[er.Call.Index] = er
+		}
+	}
+	if len(blocked) == 0 {
+		return false
+	}
+
+	candidates, _ := body["candidates"].([]any)
+	changed := false
+	index := 0
+	for _, c := range candidates {
+		parts, _ := geminiParts(c)
+		for j := range parts {
+			if er, ok := blocked[index]; ok {
+				parts[j] = map[string]any{"text": blockMessage(er.Call, er.Result)}
+				changed = true
+			}
+			index++
+		}
+	}
+	return changed
+}
+
+// isGeminiStream reports whether r is a Gemini streamGenerateContent call.
+func isGeminiStream(r *http.Request) bool {
+	return strings.HasSuffix(r.URL.Path, ":streamGenerateContent")
+}
+
+// filterGeminiChunk evaluates the function calls in one Gemini response
+// chunk and returns it re-encoded with blocked calls rewritten, or nil if
+// the chunk is unchanged.
+func (s *Server) filterGeminiChunk(chunk map[string]any, who agentIdentity) []byte {
+	toolCalls := extractGemini(chunk)
+	if len(toolCalls) == 0 {
+		return nil
+	}
+	results := make([]EvalResult, 0, len(toolCalls))
+	for _, tc := range toolCalls {
+		results = append(results, EvalResult{Call: tc, Result: s.evaluateToolCall(tc, who)})
+	}
+	if !RewriteGemini(chunk, results) {
+		return nil
+	}
+	out, _ := json.Marshal(chunk)
+	return out
+}
+
+// handleGeminiStreaming processes a streamGenerateContent response sent
+// as a JSON array whose elements arrive one at a time, separated by
+// newlines. Each element is a complete response chunk; function calls in
+// it are evaluated before the element is forwarded. Elements that are not
+// chunk objects cannot be evaluated and fall under the unknown-format
+// policy.
+func (s *Server) handleGeminiStreaming(w http.ResponseWriter, r *http.Request, resp *http.Response, who agentIdentity) {
+	if resp.StatusCode != http.StatusOK {
+		// Upstream errors are a single JSON object, not a stream.
+		s.handleNonStreaming(w, resp, who)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		flusher = noopFlusher{}
+	}
+	copyHeaders(w, resp)
+	w.Header().Del("Content-Length")
+	w.WriteHeader(resp.StatusCode)
+	fmt.Fprint(w, "[")
+
+	dec := json.NewDecoder(io.LimitReader(resp.Body, 100<<20))
+	tok, err := dec.Token()
+	if err != nil || tok != json.Delim('[') {
+		if err == nil {
+			err = fmt.Errorf("expected JSON array, got %v", tok)
+		}
+		s.abortGeminiStream(w, flusher, r, who, err, false)
+		return
+	}
+	flusher.Flush()
+
+	var logged bool
+	for n := 0; dec.More(); n++ {
+		var raw json.RawMessage
+		if err := dec.Decode(&raw); err != nil {
+			s.abortGeminiStream(w, flusher, r, who, err, n > 0)
+			return
+		}
+		if len(raw) > s.sseLineLimit() {
+			s.abortGeminiStream(w, flusher, r, who, fmt.Errorf("chunk exceeds %d bytes", s.sseLineLimit()), n > 0)
+			return
+		}
+		element := []byte(raw)
+		var chunk map[string]any
+		if json.Unmarshal(raw, &chunk) != nil {
+			if !s.checkUnknownGeminiElement(r.URL.Path, who, &logged) {
+				endGeminiStream(w, flusher, unknownFormatError(), n > 0)
+				return
+			}
+		} else if out := s.filterGeminiChunk(chunk, who); out != nil {
+			element = out
+		}
+		if n > 0 {
+			fmt.Fprint(w, ",\r\n")
+		}
+		w.Write(element)
+		flusher.Flush()
+	}
+	if _, err := dec.Token(); err != nil && !errors.Is(err, io.EOF) {
+		s.abortGeminiStream(w, flusher, r, who, err, true)
+		return
+	}
+	fmt.Fprint(w, "]")
+	flusher.Flush()
+}
+
+// handleGeminiSSE processes a streamGenerateContent response requested
+// with alt=sse: one response chunk per data line. Data lines that are not
+// chunk objects fall under the unknown-format policy.
+func (s *Server) handleGeminiSSE(w http.ResponseWriter, flusher http.Flusher, r *http.Request, resp *http.Response, who agentIdentity) {
+	scanner := s.newSSEScanner(resp.Body)
+	var logged bool
+	for scanner.Scan() {
+		line := scanner.Text()
+		if data, ok := strings.CutPrefix(line, "data: "); ok {
+			var chunk map[string]any
+			if json.Unmarshal([]byte(data), &chunk) != nil {
+				if !s.checkUnknownGeminiElement(r.URL.Path, who, &logged) {
+					fmt.Fprintf(w, "event: error\ndata: %s\n\n", unknownFormatError())
+					flusher.Flush()
+					return
+				}
+			} else if out := s.filterGeminiChunk(chunk, who); out != nil {
+				line = "data: " + string(out)
+			}
+		}
+		fmt.Fprintf(w, "%s\n", line)
+		if line == "" {
+			flusher.Flush()
+		}
+	}
+	flusher.Flush()
+	if err := scanner.Err(); err != nil {
+		s.abortStream(w, flusher, r, who, err)
+	}
+}
+
+// noopFlusher stands in when the ResponseWriter cannot flush; the stream
+// is still filtered element by element, just delivered at the end.
+type noopFlusher struct{}
+
+func (noopFlusher) Flush() {}
+
+// checkUnknownGeminiElement applies the unknown-format policy to a stream
+// element that is not a response chunk object, so nothing in it can be
+// evaluated. Returns false when the stream must be cut before the element
+// is forwarded.
+func (s *Server) checkUnknownGeminiElement(path string, who agentIdentity, logged *bool) bool {
+	switch s.unknownFormatPolicy() {
+	case UnknownFormatBlock:
+		s.recordUnknownFormat(path, model.Deny, who)
+		return false
+	case UnknownFormatLog:
+		if !*logged {
+			*logged = true
+			s.recordUnknownFormat(path, model.Allow, who)
+		}
+	}
+	return true
+}
+
+// unknownFormatError is the Gemini error object sent in place of an
+// element blocked by the unknown-format policy.
+func unknownFormatError() []byte {
+	payload, _ := json.Marshal(map[string]any{
+		"error": map[string]any{
+			"status":  "chainwatch_unknown_format",
+			"message": "[BLOCKED by chainwatch] " + unknownFormatReason,
+		},
+	})
+	return payload
+}
+
+// abortGeminiStream ends a JSON array stream that could not be read to
+// completion with an error element, and audits the failure. afterElement
+// reports whether an element was already written.
+func (s *Server) abortGeminiStream(w http.ResponseWriter, flusher http.Flusher, r *http.Request, who agentIdentity, err error, afterElement bool) {
+	reason := "stream read failed: " + err.Error()
+	payload, _ := json.Marshal(map[string]any{
+		"error": map[string]any{
+			"status":  "chainwatch_stream_error",
+			"message": "[BLOCKED by chainwatch] " + reason,
+		},
+	})
+	endGeminiStream(w, flusher, payload, afterElement)
+	s.recordStreamError(r, who, reason)
+}
+
+// endGeminiStream writes payload as the final element of a JSON array
+// stream and closes the array.
+func endGeminiStream(w http.ResponseWriter, flusher http.Flusher, payload []byte, afterElement bool) {
+	if afterElement {
+		fmt.Fprint(w, ",\r\n")
+	}
+	fmt.Fprintf(w, "%s]", payload)
+	flusher.Flush()
+}
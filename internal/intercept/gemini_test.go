@@ -0,0 +1,197 @@
+package intercept
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ppiankov/chainwatch/internal/model"
+)
+
+// geminiResponse builds a generateContent response with one candidate.
+func geminiResponse(parts ...any) map[string]any {
+	return map[string]any{
+		"candidates": []any{
+			map[string]any{
+				"content":      map[string]any{"role": "model", "parts": parts},
+				"finishReason": "STOP",
+			},
+		},
+	}
+}
+
+func geminiCall(name string, args map[string]any) map[string]any {
+	return map[string]any{"functionCall": map[string]any{"name": name, "args": args}}
+}
+
+func TestExtractGeminiToolCalls(t *testing.T) {
+	body := geminiResponse(
+		map[string]any{"text": "Running it."},
+		geminiCall("run_command", map[string]any{"command": "ls"}),
+		map[string]any{"functionCall": "bogus"},
+	)
+	calls, format := ExtractToolCalls(body)
+	if format != FormatGemini {
+		t.Fatalf("expected FormatGemini, got %d", format)
+	}
+	if len(calls) != 2 {
+		t.Fatalf("expected 2 calls, got %d", len(calls))
+	}
+	if calls[0].Name != "run_command" || calls[0].Index != 1 || calls[0].Arguments["command"] != "ls" {
+		t.Errorf("unexpected call: %+v", calls[0])
+	}
+	if calls[1].ParseError == "" {
+		t.Error("malformed functionCall must carry a parse error")
+	}
+
+	if DetectFormat(geminiResponse(map[string]any{"text": "hi"})) != FormatGemini {
+		t.Error("text-only Gemini response should still be detected")
+	}
+}
+
+func TestRewriteGeminiBlocked(t *testing.T) {
+	body := geminiResponse(
+		geminiCall("read_file", map[string]any{"path": "README.md"}),
+		geminiCall("run_command", map[string]any{"command": "rm -rf /"}),
+	)
+	calls, _ := ExtractToolCalls(body)
+	results := []EvalResult{
+		{Call: calls[0], Result: model.PolicyResult{Decision: model.Allow}},
+		{Call: calls[1], Result: model.PolicyResult{Decision: model.Deny, Reason: "destructive"}},
+	}
+	out, changed := RewriteResponse(body, results, FormatGemini)
+	if !changed {
+		t.Fatal("expected rewrite")
+	}
+	var got map[string]any
+	json.Unmarshal(out, &got)
+	parts, _ := geminiParts(got["candidates"].([]any)[0])
+	if _, ok := parts[0].(map[string]any)["functionCall"]; !ok {
+		t.Error("allowed call should be kept")
+	}
+	text, _ := parts[1].(map[string]any)["text"].(string)
+	if !strings.Contains(text, "[BLOCKED by chainwatch]") || strings.Contains(string(out), "rm -rf") {
+		t.Errorf("blocked call not replaced: %s", out)
+	}
+}
+
+func TestGeminiFunctionCallBlocked(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(geminiResponse(geminiCall("run_command", map[string]any{"command": "rm -rf /"})))
+	}))
+	defer upstream.Close()
+
+	srv, port := newTestInterceptor(t, upstream.URL)
+	cancel := startTestInterceptor(t, srv)
+	defer cancel()
+
+	resp, err := interceptClient(port).Post(interceptURL(port, "/v1beta/models/gemini-2.0-flash:generateContent"), "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	out, _ := io.ReadAll(resp.Body)
+	if strings.Contains(string(out), "functionCall") || !strings.Contains(string(out), "[BLOCKED by chainwatch]") {
+		t.Errorf("expected functionCall replaced, got %s", out)
+	}
+}
+
+func TestGeminiStreamArrayBlocked(t *testing.T) {
+	first, _ := json.Marshal(geminiResponse(map[string]any{"text": "Cleaning up."}))
+	second, _ := json.Marshal(geminiResponse(geminiCall("run_command", map[string]any{"command": "rm -rf /"})))
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, "["+string(first)+"\n,\r\n"+string(second)+"\n]")
+	}))
+	defer upstream.Close()
+
+	srv, port := newTestInterceptor(t, upstream.URL)
+	cancel := startTestInterceptor(t, srv)
+	defer cancel()
+
+	resp, err := interceptClient(port).Post(interceptURL(port, "/v1beta/models/gemini-2.0-flash:streamGenerateContent"), "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	out, _ := io.ReadAll(resp.Body)
+
+	var chunks []map[string]any
+	if err := json.Unmarshal(out, &chunks); err != nil {
+		t.Fatalf("stream is not a valid JSON array: %v: %s", err, out)
+	}
+	if len(chunks) != 2 {
+		t.Fatalf("expected 2 chunks, got %d: %s", len(chunks), out)
+	}
+	if calls, _ := ExtractToolCalls(chunks[1]); len(calls) != 0 {
+		t.Errorf("blocked functionCall leaked: %s", out)
+	}
+	if !strings.Contains(string(out), "Cleaning up.") || !strings.Contains(string(out), "[BLOCKED by chainwatch]") {
+		t.Errorf("expected text chunk kept and block message added, got %s", out)
+	}
+}
+
+func TestGeminiSSEBlocked(t *testing.T) {
+	chunk, _ := json.Marshal(geminiResponse(geminiCall("run_command", map[string]any{"command": "rm -rf /"})))
+	upstream := sseStream([]string{"data: " + string(chunk) + "\n\n"})
+	defer upstream.Close()
+
+	srv, port := newTestInterceptor(t, upstream.URL)
+	cancel := startTestInterceptor(t, srv)
+	defer cancel()
+
+	resp, err := interceptClient(port).Post(interceptURL(port, "/v1beta/models/gemini-2.0-flash:streamGenerateContent?alt=sse"), "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	out, _ := io.ReadAll(resp.Body)
+	if strings.Contains(string(out), "functionCall") || !strings.Contains(string(out), "[BLOCKED by chainwatch]") {
+		t.Errorf("expected functionCall replaced, got %s", out)
+	}
+}
+
+func TestGeminiStreamNonObjectElementUnknownFormat(t *testing.T) {
+	call := geminiResponse(geminiCall("run_command", map[string]any{"command": "rm -rf /"}))
+	nested, _ := json.Marshal([]any{call})
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, "["+string(nested)+"\n]")
+	}))
+	defer upstream.Close()
+
+	stream := func(policy string) string {
+		t.Helper()
+		srv, port := newTestInterceptorWithConfig(t, Config{
+			Upstream:            upstream.URL,
+			Purpose:             "test",
+			UnknownFormatPolicy: policy,
+		})
+		cancel := startTestInterceptor(t, srv)
+		defer cancel()
+
+		resp, err := interceptClient(port).Post(interceptURL(port, "/v1beta/models/gemini-2.0-flash:streamGenerateContent"), "application/json", strings.NewReader("{}"))
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		defer resp.Body.Close()
+		out, _ := io.ReadAll(resp.Body)
+		var elements []any
+		if err := json.Unmarshal(out, &elements); err != nil {
+			t.Fatalf("stream is not a valid JSON array: %v: %s", err, out)
+		}
+		return string(out)
+	}
+
+	if out := stream(UnknownFormatPassthrough); !strings.Contains(out, "functionCall") {
+		t.Errorf("expected passthrough to forward the element, got %s", out)
+	}
+	out := stream(UnknownFormatBlock)
+	if strings.Contains(out, "functionCall") || !strings.Contains(out, "chainwatch_unknown_format") {
+		t.Errorf("expected block policy to replace the unverifiable element, got %s", out)
+	}
+}
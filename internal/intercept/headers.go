@@ -0,0 +1,66 @@
+package intercept
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ppiankov/chainwatch/internal/audit"
+	"github.com/ppiankov/chainwatch/internal/canary"
+	"github.com/ppiankov/chainwatch/internal/model"
+	"github.com/ppiankov/chainwatch/internal/policy"
+)
+
+// sanitizeRequestHeaders enforces the header size cap, strips the request
+// header denylist, and, when header scanning is on, blocks requests that
+// carry a registered canary in a header value. API keys legitimately ride
+// in headers to the upstream, so only canaries are blocked here. Returns
+// false if it wrote the response.
+func (s *Server) sanitizeRequestHeaders(w http.ResponseWriter, r *http.Request, who agentIdentity) bool {
+	if err := s.headers.CheckRequest(r.Header); err != nil {
+		http.Error(w, err.Error(), http.StatusRequestHeaderFieldsTooLarge)
+		return false
+	}
+	s.headers.StripRequest(r.Header)
+
+	tok, ok := canary.Find(who.enf.Config.Canaries, s.headers.ScanText(r.Header))
+	if !ok {
+		return true
+	}
+	action := &model.Action{
+		Tool:     "request_header",
+		Resource: s.upstream.String(),
+		RawMeta:  map[string]any{"egress": string(model.EgressExternal), "destination": s.upstream.Host},
+	}
+	result := policy.CanaryTriggered(action, tok)
+	if s.auditLog != nil {
+		s.mu.Lock()
+		traceID := s.tracer.State.TraceID
+		s.mu.Unlock()
+		s.auditLog.Record(audit.AuditEntry{
+			Timestamp:  time.Now().UTC().Format("2006-01-02T15:04:05.000Z"),
+			TraceID:    traceID,
+			AgentID:    who.id,
+			Action:     audit.AuditAction{Tool: action.Tool, Resource: action.Resource, Fingerprint: action.Fingerprint()},
+			Decision:   string(result.Decision),
+			Reason:     result.Reason,
+			Tier:       result.Tier,
+			PolicyHash: who.enf.Hash,
+		})
+	}
+	s.dispatchAlert(who.enf, action, result)
+
+	payload, _ := json.Marshal(map[string]any{
+		"type": "error",
+		"error": map[string]any{
+			"type":    "chainwatch_blocked",
+			"message": "[BLOCKED by chainwatch] " + result.Reason,
+		},
+	})
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", strconv.Itoa(len(payload)))
+	w.WriteHeader(http.StatusForbidden)
+	w.Write(payload)
+	return false
+}
@@ -0,0 +1,75 @@
+package intercept
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCanaryInRequestHeaderBlocked(t *testing.T) {
+	const canaryValue = "AKIACANARYTOKEN00001"
+	reached := false
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Upstream-Region", "eu-west-1")
+		io.WriteString(w, `{"object":"list","data":[]}`)
+	}))
+	defer upstream.Close()
+
+	policyPath := filepath.Join(t.TempDir(), "policy.yaml")
+	policyYAML := "canaries:\n  - name: fake-aws-key\n    value: " + canaryValue + "\n"
+	if err := os.WriteFile(policyPath, []byte(policyYAML), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	srv, port := newTestInterceptorWithConfig(t, Config{
+		Upstream:             upstream.URL,
+		Purpose:              "test",
+		PolicyPath:           policyPath,
+		AuditLogPath:         auditPath,
+		StripResponseHeaders: []string{"x-upstream-region"},
+		ScanHeaderValues:     true,
+	})
+	cancel := startTestInterceptor(t, srv)
+	defer cancel()
+	client := interceptClient(port)
+
+	resp, err := client.Get(interceptURL(port, "/v1/models"))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("X-Upstream-Region") != "" {
+		t.Fatalf("expected clean request forwarded with response header stripped, got %d %q", resp.StatusCode, resp.Header.Get("X-Upstream-Region"))
+	}
+
+	reached = false
+	req, _ := http.NewRequest(http.MethodGet, interceptURL(port, "/v1/models"), nil)
+	req.Header.Set("X-Debug", "creds="+canaryValue)
+	resp, err = client.Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusForbidden || !strings.Contains(string(body), "canary_triggered") {
+		t.Errorf("expected canary block, got %d %s", resp.StatusCode, body)
+	}
+	if reached {
+		t.Error("request carrying a canary header reached the upstream")
+	}
+
+	data, err := os.ReadFile(auditPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"tool":"request_header"`) || !strings.Contains(string(data), `"fingerprint":"sha256:`) {
+		t.Errorf("expected fingerprinted request_header audit entry, got:\n%s", data)
+	}
+}
@@ -0,0 +1,126 @@
+package intercept
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// jsonPath is a compiled JSONPath-style selector over decoded tool arguments.
+// Supported syntax is the subset needed to address a single value:
+//
+//	$.request.endpoint
+//	request.endpoint          (leading "$." optional)
+//	targets[0].url
+//	$['x-resource'].path
+type jsonPath []pathSegment
+
+// pathSegment is either an object key or an array index.
+type pathSegment struct {
+	key   string
+	index int
+	isIdx bool
+}
+
+// parseJSONPath compiles a path expression.
+func parseJSONPath(expr string) (jsonPath, error) {
+	p := strings.TrimSpace(expr)
+	p = strings.TrimPrefix(p, "$")
+	if p == "" {
+		return nil, fmt.Errorf("empty path %q", expr)
+	}
+
+	var segs jsonPath
+	for len(p) > 0 {
+		switch p[0] {
+		case '.':
+			p = p[1:]
+			end := strings.IndexAny(p, ".[")
+			if end == -1 {
+				end = len(p)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("empty key in path %q", expr)
+			}
+			segs = append(segs, pathSegment{key: p[:end]})
+			p = p[end:]
+
+		case '[':
+			end := strings.IndexByte(p, ']')
+			if end == -1 {
+				return nil, fmt.Errorf("unterminated [ in path %q", expr)
+			}
+			inner := p[1:end]
+			p = p[end+1:]
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				segs = append(segs, pathSegment{key: inner[1 : len(inner)-1]})
+				continue
+			}
+			idx, err := strconv.Atoi(inner)
+			if err != nil || idx < 0 {
+				return nil, fmt.Errorf("invalid index [%s] in path %q", inner, expr)
+			}
+			segs = append(segs, pathSegment{index: idx, isIdx: true})
+
+		default:
+			// Bare leading key without "$.".
+			if len(segs) > 0 {
+				return nil, fmt.Errorf("unexpected %q in path %q", p[0], expr)
+			}
+			p = "." + p
+		}
+	}
+	return segs, nil
+}
+
+// lookup walks args and returns the addressed value if it is a non-empty string.
+func (jp jsonPath) lookup(args map[string]any) (string, bool) {
+	var cur any = args
+	for _, seg := range jp {
+		if seg.isIdx {
+			arr, ok := cur.([]any)
+			if !ok || seg.index >= len(arr) {
+				return "", false
+			}
+			cur = arr[seg.index]
+			continue
+		}
+		obj, ok := cur.(map[string]any)
+		if !ok {
+			return "", false
+		}
+		if cur, ok = obj[seg.key]; !ok {
+			return "", false
+		}
+	}
+	s, ok := cur.(string)
+	return s, ok && s != ""
+}
+
+// resourcePaths maps tool names to the argument path holding their resource.
+type resourcePaths map[string]jsonPath
+
+// compileResourcePaths parses per-tool path expressions from Config.
+func compileResourcePaths(exprs map[string]string) (resourcePaths, error) {
+	if len(exprs) == 0 {
+		return nil, nil
+	}
+	paths := make(resourcePaths, len(exprs))
+	for tool, expr := range exprs {
+		jp, err := parseJSONPath(expr)
+		if err != nil {
+			return nil, fmt.Errorf("resource path for tool %q: %w", tool, err)
+		}
+		paths[tool] = jp
+	}
+	return paths, nil
+}
+
+// resolve returns the configured resource for a tool call, if any.
+func (rp resourcePaths) resolve(tc ToolCall) (string, bool) {
+	jp, ok := rp[tc.Name]
+	if !ok {
+		return "", false
+	}
+	return jp.lookup(tc.Arguments)
+}
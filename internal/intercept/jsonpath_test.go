@@ -0,0 +1,98 @@
+package intercept
+
+import "testing"
+
+func TestParseJSONPath(t *testing.T) {
+	args := map[string]any{
+		"request": map[string]any{
+			"endpoint": "https://internal.example/salary",
+			"targets": []any{
+				map[string]any{"url": "https://a.example"},
+				map[string]any{"url": "https://b.example"},
+			},
+		},
+		"x-resource": map[string]any{"path": "/etc/shadow"},
+		"count":      3,
+	}
+
+	tests := []struct {
+		expr string
+		want string
+		ok   bool
+	}{
+		{"$.request.endpoint", "https://internal.example/salary", true},
+		{"request.endpoint", "https://internal.example/salary", true},
+		{"$.request.targets[1].url", "https://b.example", true},
+		{"$['x-resource'].path", "/etc/shadow", true},
+		{`$["x-resource"]["path"]`, "/etc/shadow", true},
+		{"$.request.targets[5].url", "", false},
+		{"$.request.missing", "", false},
+		{"$.count", "", false},      // non-string
+		{"$.request", "", false},    // object
+		{"$.request[0]", "", false}, // index into object
+	}
+	for _, tt := range tests {
+		jp, err := parseJSONPath(tt.expr)
+		if err != nil {
+			t.Fatalf("parseJSONPath(%q): %v", tt.expr, err)
+		}
+		got, ok := jp.lookup(args)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("lookup(%q) = (%q, %v), want (%q, %v)", tt.expr, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestParseJSONPathInvalid(t *testing.T) {
+	for _, expr := range []string{"", "$", "$..a", "$.a[", "$.a[-1]", "$.a[x]"} {
+		if _, err := parseJSONPath(expr); err == nil {
+			t.Errorf("parseJSONPath(%q): expected error", expr)
+		}
+	}
+}
+
+func TestBuildActionNestedArgsDefaultHeuristic(t *testing.T) {
+	// Without configuration, nested args fall back to the tool name.
+	tc := ToolCall{Name: "fetch_record", Arguments: map[string]any{
+		"request": map[string]any{"endpoint": "https://hr.internal/salary"},
+	}}
+	action := buildActionFromToolCall(tc, nil)
+	if action.Resource != "fetch_record" {
+		t.Errorf("expected fallback resource=fetch_record, got %s", action.Resource)
+	}
+}
+
+func TestBuildActionConfiguredResourcePath(t *testing.T) {
+	paths, err := compileResourcePaths(map[string]string{"fetch_record": "$.request.endpoint"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tc := ToolCall{Name: "fetch_record", Arguments: map[string]any{
+		"note":    "quarterly review",
+		"request": map[string]any{"endpoint": "https://hr.internal/salary"},
+	}}
+	action := buildActionFromToolCall(tc, paths)
+	if action.Resource != "https://hr.internal/salary" {
+		t.Errorf("expected resource from configured path, got %s", action.Resource)
+	}
+
+	// Path misses fall back to the default heuristic.
+	tc.Arguments = map[string]any{"url": "https://example.com"}
+	action = buildActionFromToolCall(tc, paths)
+	if action.Resource != "https://example.com" {
+		t.Errorf("expected heuristic fallback, got %s", action.Resource)
+	}
+
+	// Other tools are unaffected.
+	other := ToolCall{Name: "run_command", Arguments: map[string]any{"command": "ls"}}
+	if got := buildActionFromToolCall(other, paths).Resource; got != "ls" {
+		t.Errorf("expected unrelated tool resource=ls, got %s", got)
+	}
+}
+
+func TestCompileResourcePathsInvalid(t *testing.T) {
+	if _, err := compileResourcePaths(map[string]string{"t": "$.a["}); err == nil {
+		t.Error("expected error for invalid path")
+	}
+}
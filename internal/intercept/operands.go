@@ -0,0 +1,177 @@
+package intercept
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/ppiankov/chainwatch/internal/cmdguard"
+	"github.com/ppiankov/chainwatch/internal/model"
+	"github.com/ppiankov/chainwatch/internal/policy"
+)
+
+// maxCommandOperands bounds how many operands of a single command are
+// evaluated, so a pathological argument list cannot stall the proxy.
+const maxCommandOperands = 32
+
+// shellSeparators start a new command within the same command line.
+var shellSeparators = map[string]bool{
+	"|": true, "||": true, "&&": true, ";": true, "&": true,
+}
+
+// commandOperand is a file or URL argument found in a command line.
+type commandOperand struct {
+	value string
+	tool  string // tool name fed to classifyTool: read_file, write_file, delete_file, http_request
+}
+
+// commandOperands extracts the file and URL operands of a command line.
+// "cp ~/.ssh/id_rsa /tmp/x" yields both paths, the source as a read and
+// the destination as a write; transfer and delete commands are split by
+// cmdguard.FileOperands, the same parser exec uses. For other commands,
+// flags, command names and bare words that do not look like paths are
+// skipped. Redirection targets are reported as writes.
+func commandOperands(command string) []commandOperand {
+	var ops []commandOperand
+	seen := make(map[string]bool)
+	add := func(value, tool string) {
+		if seen[value] || len(ops) >= maxCommandOperands {
+			return
+		}
+		seen[value] = true
+		ops = append(ops, commandOperand{value: value, tool: tool})
+	}
+
+	// Arguments are held until the command ends, when a transfer's
+	// destination is known.
+	base := ""
+	var args []string
+	flush := func() {
+		sources, destination, targets := cmdguard.FileOperands(base, args)
+		if len(sources) == 0 && destination == "" && len(targets) == 0 {
+			for _, tok := range args {
+				switch {
+				case strings.HasPrefix(tok, "-"):
+				case strings.Contains(tok, "://"):
+					add(tok, "http_request")
+				case model.LooksLikePath(tok):
+					add(tok, "read_file")
+				}
+			}
+		}
+		for _, src := range sources {
+			add(src, "read_file")
+		}
+		if destination != "" {
+			add(destination, "write_file")
+		}
+		for _, target := range targets {
+			add(target, "delete_file")
+		}
+		base, args = "", nil
+	}
+
+	expectCommand := true
+	redirect := false
+	for _, tok := range splitCommandLine(command) {
+		switch {
+		case shellSeparators[tok]:
+			flush()
+			expectCommand = true
+			continue
+		case tok == ">" || tok == ">>":
+			redirect = true
+			continue
+		case strings.HasPrefix(tok, ">"):
+			tok = strings.TrimLeft(tok, ">")
+			redirect = true
+		}
+
+		if redirect {
+			redirect = false
+			if tok != "" {
+				add(tok, "write_file")
+			}
+			continue
+		}
+		if expectCommand {
+			// Skip env assignments and privilege wrappers in front of the command.
+			if tok == "sudo" || (strings.Contains(tok, "=") && !strings.HasPrefix(tok, "-")) {
+				continue
+			}
+			base = path.Base(tok)
+			expectCommand = false
+			continue
+		}
+		args = append(args, tok)
+	}
+	flush()
+	return ops
+}
+
+// splitCommandLine tokenizes a command line on whitespace, honoring single
+// and double quotes and backslash escapes. It is not a full shell parser.
+func splitCommandLine(command string) []string {
+	var tokens []string
+	var cur strings.Builder
+	inToken := false
+	var quote rune
+	escaped := false
+
+	flush := func() {
+		if inToken {
+			tokens = append(tokens, cur.String())
+			cur.Reset()
+			inToken = false
+		}
+	}
+
+	for _, r := range command {
+		switch {
+		case escaped:
+			cur.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inToken = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inToken = true
+		case r == ' ' || r == '\t' || r == '\n':
+			flush()
+		default:
+			cur.WriteRune(r)
+			inToken = true
+		}
+	}
+	flush()
+	return tokens
+}
+
+// evaluateOperands evaluates each file/URL operand of a command action and
+// returns the most restrictive of result and the operand results. Operands
+// are evaluated against a copy of the trace state so the command is only
+// accounted once. Callers must hold s.mu.
+func (s *Server) evaluateOperands(action *model.Action, who agentIdentity, result model.PolicyResult) model.PolicyResult {
+	if action.Tool != "command" {
+		return result
+	}
+	for _, op := range commandOperands(action.Resource) {
+		opAction := buildActionFromToolCall(ToolCall{
+			Name:      op.tool,
+			Arguments: map[string]any{"resource": op.value},
+		}, nil)
+		opResult := policy.Evaluate(opAction, s.tracer.State.Clone(), s.cfg.Purpose, who.id, who.enf.Denylist, who.enf.Config)
+		if opResult.Decision.MoreRestrictive(result.Decision) {
+			opResult.Reason = fmt.Sprintf("operand %q: %s", op.value, opResult.Reason)
+			result = opResult
+		}
+	}
+	return result
+}
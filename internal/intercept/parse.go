@@ -2,6 +2,7 @@ package intercept
 
 import (
 	"encoding/json"
+	"fmt"
 	"strings"
 )
 
@@ -12,10 +13,12 @@ const (
 	FormatUnknown   LLMFormat = 0
 	FormatAnthropic LLMFormat = 1
 	FormatOpenAI    LLMFormat = 2
+	FormatRealtime  LLMFormat = 3 // OpenAI Realtime API WebSocket events
+	FormatGemini    LLMFormat = 4 // Google Gemini generateContent
 )
 
 // ToolCall is a normalized representation of a tool invocation
-// extracted from either Anthropic or OpenAI response format.
+// extracted from an Anthropic, OpenAI, or Gemini response.
 type ToolCall struct {
 	ID         string         // "toolu_123" or "call_123"
 	Name       string         // tool name: "run_command", "file_write", etc.
@@ -23,17 +26,26 @@ type ToolCall struct {
 	Index      int            // position in the content/tool_calls array
 	Format     LLMFormat
 	ParseError string // set if argument JSON could not be parsed
+
+	// Server marks an Anthropic server_tool_use block (web_search,
+	// web_fetch): the provider runs it and returns the result in a paired
+	// *_tool_result block in the same response.
+	Server bool
 }
 
 // DetectFormat examines a parsed JSON response body and determines
-// whether it uses Anthropic or OpenAI format.
+// whether it uses Anthropic, OpenAI, or Gemini format.
 func DetectFormat(body map[string]any) LLMFormat {
-	// Anthropic: has "content" array with objects having "type" field
+	// Anthropic: has "content" array with objects having "type" field.
+	// Any typed block counts, so beta shapes that lead with a new block
+	// variant are still recognized.
 	if content, ok := body["content"]; ok {
-		if arr, ok := content.([]any); ok && len(arr) > 0 {
-			if first, ok := arr[0].(map[string]any); ok {
-				if _, hasType := first["type"]; hasType {
-					return FormatAnthropic
+		if arr, ok := content.([]any); ok {
+			for _, item := range arr {
+				if block, ok := item.(map[string]any); ok {
+					if _, hasType := block["type"]; hasType {
+						return FormatAnthropic
+					}
 				}
 			}
 		}
@@ -50,6 +62,11 @@ func DetectFormat(body map[string]any) LLMFormat {
 		}
 	}
 
+	// Gemini: has "candidates" array with content.parts
+	if isGeminiBody(body) {
+		return FormatGemini
+	}
+
 	return FormatUnknown
 }
 
@@ -61,6 +78,9 @@ func DetectStreamingFormat(path string, headers map[string][]string) LLMFormat {
 	if strings.Contains(path, "/v1/chat/completions") {
 		return FormatOpenAI
 	}
+	if strings.HasSuffix(path, ":streamGenerateContent") {
+		return FormatGemini
+	}
 	if _, ok := headers["Anthropic-Version"]; ok {
 		return FormatAnthropic
 	}
@@ -78,6 +98,8 @@ func ExtractToolCalls(body map[string]any) ([]ToolCall, LLMFormat) {
 	case FormatOpenAI:
 		calls := extractOpenAI(body)
 		return calls, format
+	case FormatGemini:
+		return extractGemini(body), format
 	default:
 		return nil, FormatUnknown
 	}
@@ -85,6 +107,13 @@ func ExtractToolCalls(body map[string]any) ([]ToolCall, LLMFormat) {
 
 // extractAnthropic extracts tool_use blocks from Anthropic response format.
 // Anthropic content: [{"type": "tool_use", "id": "...", "name": "...", "input": {...}}]
+// server_tool_use blocks are extracted with Server set.
+//
+// Blocks of other types that still look executable (beta variants such as
+// server_tool_use, or anything carrying a name plus input/arguments) are
+// extracted best-effort. When such a block cannot be parsed, the call is
+// returned with ParseError set so the caller blocks it instead of letting
+// it through unenforced.
 func extractAnthropic(body map[string]any) []ToolCall {
 	content, ok := body["content"].([]any)
 	if !ok {
@@ -98,7 +127,7 @@ func extractAnthropic(body map[string]any) []ToolCall {
 			continue
 		}
 		blockType, _ := block["type"].(string)
-		if blockType != "tool_use" {
+		if blockType != "tool_use" && !looksLikeToolBlock(blockType, block) {
 			continue
 		}
 
@@ -112,14 +141,73 @@ func extractAnthropic(body map[string]any) []ToolCall {
 		if name, ok := block["name"].(string); ok {
 			tc.Name = name
 		}
-		if input, ok := block["input"].(map[string]any); ok {
-			tc.Arguments = input
+		tc.Server = blockType == "server_tool_use"
+		if blockType == "tool_use" {
+			if input, ok := block["input"].(map[string]any); ok {
+				tc.Arguments = input
+			}
+		} else {
+			tc.Arguments, tc.ParseError = parseToolBlockInput(blockType, block)
+			if tc.ParseError == "" && tc.Name == "" {
+				tc.ParseError = fmt.Sprintf("unrecognized tool block %q: missing name", blockType)
+			}
 		}
 		calls = append(calls, tc)
 	}
 	return calls
 }
 
+// nonExecutableBlockTypes are Anthropic content blocks that never invoke a tool.
+var nonExecutableBlockTypes = map[string]bool{
+	"text":              true,
+	"thinking":          true,
+	"redacted_thinking": true,
+	"image":             true,
+	"document":          true,
+}
+
+// looksLikeToolBlock reports whether an unrecognized content block has a
+// tool-call-like shape and must therefore be enforced.
+func looksLikeToolBlock(blockType string, block map[string]any) bool {
+	if nonExecutableBlockTypes[blockType] || strings.HasSuffix(blockType, "tool_result") {
+		return false
+	}
+	for _, suffix := range []string{"tool_use", "tool_call", "function_call"} {
+		if strings.HasSuffix(blockType, suffix) {
+			return true
+		}
+	}
+	_, hasName := block["name"]
+	_, hasInput := block["input"]
+	_, hasArgs := block["arguments"]
+	return hasName && (hasInput || hasArgs)
+}
+
+// parseToolBlockInput extracts arguments from an unrecognized tool block.
+// Accepts "input" or "arguments" as either an object or a JSON-encoded object.
+func parseToolBlockInput(blockType string, block map[string]any) (map[string]any, string) {
+	raw, ok := block["input"]
+	if !ok {
+		raw, ok = block["arguments"]
+	}
+	if !ok || raw == nil {
+		return nil, fmt.Sprintf("unrecognized tool block %q: missing input", blockType)
+	}
+
+	switch v := raw.(type) {
+	case map[string]any:
+		return v, ""
+	case string:
+		var args map[string]any
+		if err := json.Unmarshal([]byte(v), &args); err != nil {
+			return nil, fmt.Sprintf("unrecognized tool block %q: malformed input: %v", blockType, err)
+		}
+		return args, ""
+	default:
+		return nil, fmt.Sprintf("unrecognized tool block %q: input is %T, not an object", blockType, raw)
+	}
+}
+
 // extractOpenAI extracts function_call blocks from OpenAI response format.
 // OpenAI: choices[0].message.tool_calls[].function.{name, arguments}
 func extractOpenAI(body map[string]any) []ToolCall {
@@ -187,6 +275,7 @@ type streamingToolCall struct {
 	Index     int
 	Events    []string // buffered raw SSE lines
 	Truncated bool     // set if ArgJSON exceeded maxArgSize
+	Server    bool     // server_tool_use block
 }
 
 // NewStreamBuffer creates a StreamBuffer for the detected format.
@@ -213,6 +302,12 @@ func (sb *StreamBuffer) StartToolUse(index int, id, name string, rawEvent string
 	}
 }
 
+// StartServerToolUse begins buffering a new server_tool_use block.
+func (sb *StreamBuffer) StartServerToolUse(index int, id, name string, rawEvent string) {
+	sb.StartToolUse(index, id, name, rawEvent)
+	sb.calls[index].Server = true
+}
+
 // AppendDelta adds an input_json_delta chunk to the buffer.
 // Fragments beyond maxArgSize are discarded to prevent OOM.
 func (sb *StreamBuffer) AppendDelta(index int, jsonFragment string, rawEvent string) {
@@ -253,6 +348,7 @@ func (sb *StreamBuffer) Complete(index int, rawEvent string) (ToolCall, []string
 		Index:      tc.Index,
 		Format:     sb.Format,
 		ParseError: parseError,
+		Server:     tc.Server,
 	}
 
 	delete(sb.calls, index)
@@ -0,0 +1,80 @@
+package intercept
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/ppiankov/chainwatch/internal/tlsverify"
+)
+
+// errPinMismatch marks upstream TLS handshakes whose certificate chain
+// carries none of the configured SPKI pins.
+var errPinMismatch = errors.New("upstream certificate pin mismatch")
+
+// spkiPins is a set of SHA-256 SubjectPublicKeyInfo digests.
+type spkiPins map[[sha256.Size]byte]bool
+
+// parsePins decodes pins in the HPKP form "sha256/<base64>"; the
+// "sha256/" prefix is optional.
+func parsePins(pins []string) (spkiPins, error) {
+	if len(pins) == 0 {
+		return nil, nil
+	}
+	set := make(spkiPins, len(pins))
+	for _, p := range pins {
+		enc := strings.TrimPrefix(strings.TrimSpace(p), "sha256/")
+		raw, err := base64.StdEncoding.DecodeString(enc)
+		if err != nil || len(raw) != sha256.Size {
+			return nil, fmt.Errorf("invalid upstream cert pin %q: want sha256/<base64 SHA-256 SPKI digest>", p)
+		}
+		set[[sha256.Size]byte(raw)] = true
+	}
+	return set, nil
+}
+
+// spkiPin returns the "sha256/<base64>" pin for a certificate's public key.
+func spkiPin(cert *x509.Certificate) string {
+	sum := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	return "sha256/" + base64.StdEncoding.EncodeToString(sum[:])
+}
+
+// verifyConnection accepts the handshake if any certificate presented by
+// the peer matches a pin. It runs after normal chain verification, so pins
+// narrow trust rather than replace it.
+func (p spkiPins) verifyConnection(cs tls.ConnectionState) error {
+	for _, cert := range cs.PeerCertificates {
+		if p[sha256.Sum256(cert.RawSubjectPublicKeyInfo)] {
+			return nil
+		}
+	}
+	if len(cs.PeerCertificates) == 0 {
+		return fmt.Errorf("%w: no peer certificates", errPinMismatch)
+	}
+	return fmt.Errorf("%w: %s presented %s", errPinMismatch, cs.ServerName, spkiPin(cs.PeerCertificates[0]))
+}
+
+// tlsConfig returns the client TLS config for upstream connections,
+// enforcing pins when any are configured.
+func (p spkiPins) tlsConfig() *tls.Config {
+	cfg := &tls.Config{}
+	if len(p) > 0 {
+		cfg.VerifyConnection = p.verifyConnection
+	}
+	return cfg
+}
+
+// newUpstreamTransport builds the per-server transport used for upstream
+// requests, so pinning and verification bypass never leak into
+// http.DefaultTransport.
+func newUpstreamTransport(pins spkiPins, skip *tlsverify.SkipHosts) *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.TLSClientConfig = pins.tlsConfig()
+	skip.Apply(t)
+	return t
+}
@@ -0,0 +1,155 @@
+package intercept
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ppiankov/chainwatch/internal/alert"
+)
+
+// newPinnedInterceptor builds an interceptor for a TLS test upstream,
+// trusting the upstream's self-signed cert so only the pin decides.
+func newPinnedInterceptor(t *testing.T, upstream *httptest.Server, pins []string) (*Server, int) {
+	t.Helper()
+	srv, port := newTestInterceptor(t, upstream.URL)
+
+	parsed, err := parsePins(pins)
+	if err != nil {
+		t.Fatalf("parsePins: %v", err)
+	}
+	srv.pins = parsed
+	srv.transport = newUpstreamTransport(parsed, nil)
+	roots := x509.NewCertPool()
+	roots.AddCert(upstream.Certificate())
+	srv.transport.TLSClientConfig.RootCAs = roots
+	return srv, port
+}
+
+func TestUpstreamCertPinMatchPasses(t *testing.T) {
+	upstream := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(anthropicResponse([]any{map[string]any{"type": "text", "text": "ok"}}, "end_turn"))
+	}))
+	defer upstream.Close()
+
+	srv, port := newPinnedInterceptor(t, upstream, []string{spkiPin(upstream.Certificate())})
+	cancel := startTestInterceptor(t, srv)
+	defer cancel()
+
+	resp, err := interceptClient(port).Post(interceptURL(port, "/v1/messages"), "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 with matching pin, got %d", resp.StatusCode)
+	}
+}
+
+func TestUpstreamCertPinMismatchRejected(t *testing.T) {
+	upstreamHit := false
+	upstream := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		upstreamHit = true
+		w.Write([]byte("{}"))
+	}))
+	defer upstream.Close()
+
+	alerts := make(chan alert.AlertEvent, 1)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var ev alert.AlertEvent
+		json.NewDecoder(r.Body).Decode(&ev)
+		alerts <- ev
+	}))
+	defer hook.Close()
+
+	wrong := sha256.Sum256([]byte("not the upstream key"))
+	srv, port := newPinnedInterceptor(t, upstream, []string{"sha256/" + base64.StdEncoding.EncodeToString(wrong[:])})
+	srv.snapshot().Dispatcher = alert.NewDispatcher([]alert.AlertConfig{
+		{URL: hook.URL, Format: "generic", Events: []string{"upstream_pin_mismatch"}},
+	})
+	cancel := startTestInterceptor(t, srv)
+	defer cancel()
+
+	resp, err := interceptClient(port).Post(interceptURL(port, "/v1/messages"), "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusBadGateway {
+		t.Fatalf("expected 502 on pin mismatch, got %d", resp.StatusCode)
+	}
+	if upstreamHit {
+		t.Error("request must not reach an upstream that fails pinning")
+	}
+
+	select {
+	case ev := <-alerts:
+		if ev.Type != "upstream_pin_mismatch" {
+			t.Errorf("expected upstream_pin_mismatch alert, got %+v", ev)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected an alert on pin mismatch")
+	}
+}
+
+func TestParsePinsRejectsMalformed(t *testing.T) {
+	for _, pin := range []string{"sha256/not-base64!", "sha256/" + base64.StdEncoding.EncodeToString([]byte("short"))} {
+		if _, err := parsePins([]string{pin}); err == nil {
+			t.Errorf("parsePins(%q) should fail", pin)
+		}
+	}
+}
+
+func TestInsecureSkipVerifyHostsScoped(t *testing.T) {
+	upstream := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(anthropicResponse([]any{map[string]any{"type": "text", "text": "ok"}}, "end_turn"))
+	}))
+	defer upstream.Close()
+
+	tests := []struct {
+		hosts  []string
+		status int
+	}{
+		{[]string{"127.0.0.1"}, http.StatusOK},
+		{[]string{"vault.internal"}, http.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		port := ln.Addr().(*net.TCPAddr).Port
+		ln.Close()
+
+		srv, err := NewServer(Config{
+			Port:                    port,
+			Upstream:                upstream.URL,
+			Purpose:                 "test",
+			InsecureSkipVerifyHosts: tt.hosts,
+		})
+		if err != nil {
+			t.Fatalf("failed to create interceptor: %v", err)
+		}
+		cancel := startTestInterceptor(t, srv)
+
+		resp, err := interceptClient(port).Post(interceptURL(port, "/v1/messages"), "application/json", strings.NewReader("{}"))
+		if err != nil {
+			cancel()
+			t.Fatalf("request: %v", err)
+		}
+		resp.Body.Close()
+		cancel()
+		if resp.StatusCode != tt.status {
+			t.Errorf("skip hosts %v: expected %d, got %d", tt.hosts, tt.status, resp.StatusCode)
+		}
+	}
+}
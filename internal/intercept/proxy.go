@@ -2,13 +2,17 @@ package intercept
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
 	"net/url"
+	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
@@ -18,10 +22,13 @@ import (
 	"github.com/ppiankov/chainwatch/internal/approval"
 	"github.com/ppiankov/chainwatch/internal/audit"
 	"github.com/ppiankov/chainwatch/internal/breakglass"
-	"github.com/ppiankov/chainwatch/internal/denylist"
+	"github.com/ppiankov/chainwatch/internal/cmdguard"
+	"github.com/ppiankov/chainwatch/internal/geoip"
+	"github.com/ppiankov/chainwatch/internal/headerguard"
+	"github.com/ppiankov/chainwatch/internal/identity"
 	"github.com/ppiankov/chainwatch/internal/model"
 	"github.com/ppiankov/chainwatch/internal/policy"
-	"github.com/ppiankov/chainwatch/internal/profile"
+	"github.com/ppiankov/chainwatch/internal/tlsverify"
 	"github.com/ppiankov/chainwatch/internal/tracer"
 )
 
@@ -36,23 +43,123 @@ type Config struct {
 	AgentID      string
 	Actor        map[string]any
 	AuditLogPath string
+
+	// ResourcePaths maps tool names to a JSONPath-style expression
+	// (e.g. "$.request.endpoint") locating the resource in the tool's
+	// arguments. Consulted before the default key heuristic.
+	ResourcePaths map[string]string
+
+	// UpstreamCertPins are SHA-256 SPKI pins ("sha256/<base64>") for the
+	// upstream TLS certificate chain. When set, a handshake presenting no
+	// pinned key fails closed with 502 and an alert.
+	UpstreamCertPins []string
+
+	// InsecureSkipVerifyHosts lists upstream hostnames (e.g. internal
+	// services with self-signed certs) whose certificates are not
+	// verified. All other hosts stay verified; pins still apply.
+	InsecureSkipVerifyHosts []string
+
+	// AgentHeader names a request header (e.g. "X-Agent-ID") carrying the
+	// calling agent's identity. When AgentID is empty, a present header
+	// names the agent for that request and is merged into Actor; a
+	// configured AgentID is never overridden. Empty disables header
+	// attribution.
+	AgentHeader string
+
+	// ToolResults adds synthetic tool messages to OpenAI non-streaming
+	// rewrites (see RewriteOptions.ToolResults).
+	ToolResults bool
+
+	// MaxConcurrentStreams caps in-flight streaming responses, each of
+	// which holds a goroutine and a StreamBuffer. Excess streams get 503
+	// with Retry-After. Zero means unlimited.
+	MaxConcurrentStreams int
+
+	// MaxSSELineSize caps a single SSE line read from a streaming
+	// response. A longer line ends the stream with an error event and
+	// discards buffered tool calls. Zero means DefaultMaxSSELineSize.
+	MaxSSELineSize int
+
+	// RequirePolicyFile makes a missing or empty policy path a startup
+	// error instead of falling back to the default policy.
+	RequirePolicyFile bool
+
+	// ShadowUpstream, when set, mirrors each request to a second upstream
+	// (e.g. a candidate model) whose response is never returned to the
+	// client. Its tool calls are evaluated and audited alongside the
+	// primary's for comparison. WebSocket traffic is not mirrored.
+	ShadowUpstream string
+
+	// ShadowHeaders are set on mirrored requests, replacing the client's
+	// value (e.g. the shadow provider's Authorization header). The client's
+	// credential headers (Authorization, x-api-key, ...) are never mirrored;
+	// the shadow only receives credentials listed here.
+	ShadowHeaders map[string]string
+
+	// DisableTools is a coarse kill switch: request bodies are rewritten
+	// to tool_choice "none" so the model cannot emit tool calls at all,
+	// and WebSocket sessions are refused. Per-call enforcement on
+	// responses still applies.
+	DisableTools bool
+
+	// DisableToolsAgents limits DisableTools to these agent IDs. Empty
+	// means every agent.
+	DisableToolsAgents []string
+
+	// GeoIPDBs are MaxMind DB files (country and/or ASN) used to tag tool
+	// call destinations with dest_country and dest_asn for rules to match.
+	// Empty disables the lookup.
+	GeoIPDBs []string
+
+	// UnknownFormatPolicy decides what happens to a response that is
+	// neither Anthropic nor OpenAI shaped but carries tool-call-like
+	// content: UnknownFormatPassthrough (default), UnknownFormatLog, or
+	// UnknownFormatBlock to fail closed.
+	UnknownFormatPolicy string
+
+	// TracePath, when set, receives the session's trace as JSONL (one line
+	// per recorded action), appended on Close.
+	TracePath string
+
+	// StripRequestHeaders are removed from requests before they reach the
+	// upstream and StripResponseHeaders from responses before they reach
+	// the agent. Names match case-insensitively.
+	StripRequestHeaders  []string
+	StripResponseHeaders []string
+
+	// MaxHeaderBytes caps the total size of a request's headers; larger
+	// requests are rejected with 431. Zero disables the cap.
+	MaxHeaderBytes int
+
+	// ScanHeaderValues blocks requests whose header values carry a
+	// registered canary.
+	ScanHeaderValues bool
 }
 
 // Server is a reverse HTTP proxy that intercepts LLM responses
 // and evaluates chainwatch policy on tool_use/function_call blocks.
 type Server struct {
-	cfg        Config
-	upstream   *url.URL
-	dl         *denylist.Denylist
-	policyCfg  *policy.PolicyConfig
-	approvals  *approval.Store
-	bgStore    *breakglass.Store
-	dispatcher *alert.Dispatcher
-	tracer     *tracer.TraceAccumulator
-	auditLog   *audit.Log
-	policyHash string
-	mu         sync.Mutex
-	srv        *http.Server
+	cfg       Config
+	upstream  *url.URL
+	live      *policy.Live // denylist, policy, and hooks replaced by ReloadPolicy
+	approvals *approval.Store
+	bgStore   *breakglass.Store
+	tracer    *tracer.TraceAccumulator
+	auditLog  *audit.Log
+	geo       *geoip.Tagger
+	paths     resourcePaths
+	pins      spkiPins
+	skip      *tlsverify.SkipHosts
+	headers   *headerguard.Guard
+	transport *http.Transport
+	streams   chan struct{} // streaming semaphore; nil when unlimited
+	mu        sync.Mutex
+	srv       *http.Server
+
+	shadow          *url.URL // nil when shadowing is off
+	shadowTransport *http.Transport
+	shadowLog       []ShadowComparison // guarded by mu
+	shadowWG        sync.WaitGroup
 }
 
 // NewServer creates an interceptor proxy with loaded policy.
@@ -61,31 +168,23 @@ func NewServer(cfg Config) (*Server, error) {
 	if err != nil {
 		return nil, fmt.Errorf("invalid upstream URL: %w", err)
 	}
-
-	dl, err := denylist.Load(cfg.DenylistPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to load denylist: %w", err)
+	if err := validateUnknownFormatPolicy(cfg.UnknownFormatPolicy); err != nil {
+		return nil, err
 	}
 
-	policyCfg, policyHash, err := policy.LoadConfigWithHash(cfg.PolicyPath)
+	live, err := policy.NewLive(loadPolicy(cfg))
 	if err != nil {
-		return nil, fmt.Errorf("failed to load policy config: %w", err)
-	}
-
-	if cfg.ProfileName != "" {
-		prof, err := profile.Load(cfg.ProfileName)
-		if err != nil {
-			return nil, fmt.Errorf("failed to load profile %q: %w", cfg.ProfileName, err)
-		}
-		profile.ApplyToDenylist(prof, dl)
-		policyCfg = profile.ApplyToPolicy(prof, policyCfg)
+		return nil, err
 	}
+	policyCfg := live.Snapshot().Config
 
-	approvalStore, err := approval.NewStore(approval.DefaultDir())
+	approvalStore, err := approval.Open(approval.DefaultDir(), policyCfg.ApprovalStore)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create approval store: %w", err)
 	}
 	approvalStore.Cleanup()
+	approvalStore.SetThrottle(policyCfg.ApprovalThrottle)
+	approvalStore.SetReasonRequired(policyCfg.ReasonRequiredKeys())
 
 	if cfg.Actor == nil {
 		cfg.Actor = map[string]any{"interceptor": "chainwatch"}
@@ -96,25 +195,66 @@ func NewServer(cfg Config) (*Server, error) {
 
 	var auditLog *audit.Log
 	if cfg.AuditLogPath != "" {
-		auditLog, err = audit.Open(cfg.AuditLogPath)
+		auditLog, err = audit.OpenWithSinks(cfg.AuditLogPath, policyCfg.AuditSinks)
 		if err != nil {
 			return nil, fmt.Errorf("failed to open audit log: %w", err)
 		}
 	}
 
+	paths, err := compileResourcePaths(cfg.ResourcePaths)
+	if err != nil {
+		return nil, err
+	}
+
+	pins, err := parsePins(cfg.UpstreamCertPins)
+	if err != nil {
+		return nil, err
+	}
+
+	skip, err := tlsverify.ParseSkipHosts(cfg.InsecureSkipVerifyHosts)
+	if err != nil {
+		return nil, err
+	}
+
+	headers, err := headerguard.New(cfg.StripRequestHeaders, cfg.StripResponseHeaders, cfg.MaxHeaderBytes, cfg.ScanHeaderValues)
+	if err != nil {
+		return nil, err
+	}
+
+	shadow, err := parseShadowUpstream(cfg.ShadowUpstream)
+	if err != nil {
+		return nil, err
+	}
+
+	geoDB, err := geoip.Open(cfg.GeoIPDBs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load geoip database: %w", err)
+	}
+
 	bgStore, _ := breakglass.NewStore(breakglass.DefaultDir())
 
 	s := &Server{
-		cfg:        cfg,
-		upstream:   upstream,
-		dl:         dl,
-		policyCfg:  policyCfg,
-		approvals:  approvalStore,
-		bgStore:    bgStore,
-		dispatcher: alert.NewDispatcher(policyCfg.Alerts),
-		tracer:     tracer.NewAccumulator(tracer.NewTraceID()),
-		auditLog:   auditLog,
-		policyHash: policyHash,
+		cfg:       cfg,
+		upstream:  upstream,
+		live:      live,
+		approvals: approvalStore,
+		bgStore:   bgStore,
+		tracer:    tracer.NewAccumulator(tracer.NewTraceID()),
+		auditLog:  auditLog,
+		geo:       geoip.NewTagger(geoDB),
+		paths:     paths,
+		pins:      pins,
+		skip:      skip,
+		headers:   headers,
+		transport: newUpstreamTransport(pins, skip),
+	}
+	if cfg.MaxConcurrentStreams > 0 {
+		s.streams = make(chan struct{}, cfg.MaxConcurrentStreams)
+	}
+	if shadow != nil {
+		// Upstream pins describe the primary only.
+		s.shadow = shadow
+		s.shadowTransport = newUpstreamTransport(nil, skip)
 	}
 
 	s.srv = &http.Server{
@@ -146,23 +286,76 @@ func (s *Server) Start(ctx context.Context) error {
 	return err
 }
 
-// Close closes the audit log if configured.
+// Close waits for in-flight shadow requests, then appends the trace to
+// TracePath and closes the audit log, if configured.
 func (s *Server) Close() error {
+	s.shadowWG.Wait()
+	var traceErr error
+	if s.cfg.TracePath != "" {
+		s.mu.Lock()
+		traceErr = s.tracer.AppendJSONL(s.cfg.TracePath)
+		s.mu.Unlock()
+	}
 	if s.auditLog != nil {
-		return s.auditLog.Close()
+		if err := s.auditLog.Close(); err != nil {
+			return err
+		}
 	}
-	return nil
+	return traceErr
 }
 
 // TraceSummary exports the accumulated trace for debugging/audit.
 func (s *Server) TraceSummary() map[string]any {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	return s.tracer.ToJSON()
+	summary := s.tracer.ToJSON()
+	if s.shadow != nil {
+		summary["shadow"] = append([]ShadowComparison(nil), s.shadowLog...)
+	}
+	return summary
 }
 
 // ServeHTTP forwards requests to upstream and intercepts responses.
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Realtime APIs upgrade to WebSocket — relay frames with enforcement.
+	if isWebSocketUpgrade(r) {
+		s.handleWebSocket(w, r)
+		return
+	}
+
+	who := s.identify(r)
+	if !s.sanitizeRequestHeaders(w, r, who) {
+		return
+	}
+
+	if s.toolsDisabled(who) {
+		if status, err := disableToolsInRequest(r); err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+	}
+
+	// A request that asks for a stream takes its slot before the upstream
+	// call, so a flood of them is turned away without reaching upstream.
+	streamSlot := false
+	if streamRequested(r) {
+		if !s.acquireStream() {
+			s.rejectStream(w, r, who)
+			return
+		}
+		defer s.releaseStream()
+		streamSlot = true
+	}
+
+	if s.shadow != nil {
+		if body, ok := bufferShadowBody(r); ok {
+			done := make(chan struct{})
+			defer close(done)
+			who.proposed = &proposals{}
+			s.startShadow(r, body, who, done)
+		}
+	}
+
 	// Build outbound request to upstream
 	outURL := *s.upstream
 	outURL.Path = r.URL.Path
@@ -181,27 +374,219 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 	outReq.Header.Set("Host", s.upstream.Host)
-	outReq.ContentLength = r.ContentLength
+	setOutboundBody(outReq, r)
 
-	resp, err := http.DefaultTransport.RoundTrip(outReq)
+	resp, err := s.transport.RoundTrip(outReq)
 	if err != nil {
+		if errors.Is(err, errPinMismatch) {
+			s.reportPinMismatch(err)
+			http.Error(w, "upstream certificate pin mismatch", http.StatusBadGateway)
+			return
+		}
 		http.Error(w, fmt.Sprintf("upstream error: %v", err), http.StatusBadGateway)
 		return
 	}
 	defer resp.Body.Close()
+	s.headers.StripResponse(resp.Header)
 
 	// Route to streaming or non-streaming handler
 	contentType := resp.Header.Get("Content-Type")
-	if strings.Contains(contentType, "text/event-stream") {
-		s.handleStreaming(w, r, resp)
+	if strings.Contains(contentType, "text/event-stream") || isGeminiStream(r) {
+		if !streamSlot {
+			if !s.acquireStream() {
+				s.rejectStream(w, r, who)
+				return
+			}
+			defer s.releaseStream()
+		}
+		if !strings.Contains(contentType, "text/event-stream") {
+			s.handleGeminiStreaming(w, r, resp, who)
+			return
+		}
+		s.handleStreaming(w, r, resp, who)
 		return
 	}
 
-	s.handleNonStreaming(w, resp)
+	s.handleNonStreaming(w, resp, who)
+}
+
+// setOutboundBody carries the client's body framing over to the upstream
+// request. Chunked and streamed (often compressed) bodies arrive with
+// ContentLength -1; they are forwarded chunked rather than with a forced
+// length, and Content-Encoding passes through with the other headers so
+// the upstream decodes the body itself.
+func setOutboundBody(outReq, r *http.Request) {
+	outReq.Header.Del("Content-Length")
+	outReq.Header.Del("Transfer-Encoding")
+	switch {
+	case r.ContentLength > 0:
+		outReq.ContentLength = r.ContentLength
+	case r.ContentLength == 0 || r.Body == nil || r.Body == http.NoBody:
+		outReq.ContentLength = 0
+		outReq.Body = http.NoBody
+	default:
+		outReq.ContentLength = -1
+		outReq.TransferEncoding = []string{"chunked"}
+	}
+}
+
+// streamRetryAfter is the Retry-After hint, in seconds, sent with a 503
+// when MaxConcurrentStreams is reached.
+const streamRetryAfter = "1"
+
+// acquireStream takes a streaming slot without blocking.
+func (s *Server) acquireStream() bool {
+	if s.streams == nil {
+		return true
+	}
+	select {
+	case s.streams <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *Server) releaseStream() {
+	if s.streams != nil {
+		<-s.streams
+	}
+}
+
+// rejectStream answers a stream over MaxConcurrentStreams with a 503.
+func (s *Server) rejectStream(w http.ResponseWriter, r *http.Request, who agentIdentity) {
+	s.reportStreamLimit(r, who)
+	w.Header().Set("Retry-After", streamRetryAfter)
+	http.Error(w, "too many concurrent streams", http.StatusServiceUnavailable)
+}
+
+// maxStreamPeekBody bounds how much of a request body is read to find its
+// "stream" field. Larger or compressed bodies are not inspected; their
+// stream slot is taken when the response turns out to be a stream.
+const maxStreamPeekBody = 1 << 20
+
+// streamRequested reports whether r asks for a streaming response: a
+// Gemini streamGenerateContent call, an event-stream Accept header, or a
+// JSON body with "stream": true. The body is restored for forwarding.
+func streamRequested(r *http.Request) bool {
+	if isGeminiStream(r) || strings.Contains(r.Header.Get("Accept"), "text/event-stream") {
+		return true
+	}
+	if r.Body == nil || r.Body == http.NoBody || r.Header.Get("Content-Encoding") != "" {
+		return false
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxStreamPeekBody+1))
+	rest := r.Body
+	r.Body = readCloser{io.MultiReader(bytes.NewReader(body), rest), rest}
+	if err != nil || len(body) > maxStreamPeekBody {
+		return false
+	}
+	var req struct {
+		Stream bool `json:"stream"`
+	}
+	return json.Unmarshal(body, &req) == nil && req.Stream
+}
+
+// DefaultMaxSSELineSize is the SSE line limit used when
+// Config.MaxSSELineSize is zero. It leaves room for a full maxArgSize
+// tool call arriving in one event, with JSON escaping.
+const DefaultMaxSSELineSize = 4 << 20 // 4MB
+
+func (s *Server) sseLineLimit() int {
+	if s.cfg.MaxSSELineSize > 0 {
+		return s.cfg.MaxSSELineSize
+	}
+	return DefaultMaxSSELineSize
+}
+
+// newSSEScanner returns a line scanner over an SSE body that accepts
+// lines up to the configured size instead of bufio's 64KB default.
+func (s *Server) newSSEScanner(body io.Reader) *bufio.Scanner {
+	limit := s.sseLineLimit()
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, min(64*1024, limit)), limit)
+	return scanner
+}
+
+// abortStream ends a streaming response whose upstream could not be read
+// to completion. Buffered tool calls are never released: a tool call cut
+// off mid-stream cannot be evaluated, so the client gets an error event
+// instead and the failure is audited.
+func (s *Server) abortStream(w http.ResponseWriter, flusher http.Flusher, r *http.Request, who agentIdentity, err error) {
+	reason := "stream read failed: " + err.Error()
+	if errors.Is(err, bufio.ErrTooLong) {
+		reason = fmt.Sprintf("SSE line exceeds %d bytes", s.sseLineLimit())
+	}
+
+	payload, _ := json.Marshal(map[string]any{
+		"type": "error",
+		"error": map[string]any{
+			"type":    "chainwatch_stream_error",
+			"message": "[BLOCKED by chainwatch] " + reason,
+		},
+	})
+	fmt.Fprintf(w, "event: error\ndata: %s\n\n", payload)
+	flusher.Flush()
+	s.recordStreamError(r, who, reason)
+}
+
+// recordStreamError audits a stream that could not be read to completion.
+func (s *Server) recordStreamError(r *http.Request, who agentIdentity, reason string) {
+	now := time.Now().UTC().Format("2006-01-02T15:04:05.000Z")
+	s.mu.Lock()
+	traceID := s.tracer.State.TraceID
+	s.mu.Unlock()
+	if s.auditLog != nil {
+		s.auditLog.Record(audit.AuditEntry{
+			Timestamp:  now,
+			TraceID:    traceID,
+			AgentID:    who.id,
+			Action:     audit.AuditAction{Tool: "stream", Resource: r.URL.Path},
+			Decision:   string(model.Deny),
+			Reason:     reason,
+			PolicyHash: who.enf.Hash,
+			Type:       "stream_error",
+		})
+	}
+}
+
+// reportStreamLimit audits and alerts on a streaming response rejected
+// because MaxConcurrentStreams was reached.
+func (s *Server) reportStreamLimit(r *http.Request, who agentIdentity) {
+	now := time.Now().UTC().Format("2006-01-02T15:04:05.000Z")
+	reason := fmt.Sprintf("max concurrent streams (%d) reached", s.cfg.MaxConcurrentStreams)
+	s.mu.Lock()
+	traceID := s.tracer.State.TraceID
+	s.mu.Unlock()
+
+	if s.auditLog != nil {
+		s.auditLog.Record(audit.AuditEntry{
+			Timestamp:  now,
+			TraceID:    traceID,
+			AgentID:    who.id,
+			Action:     audit.AuditAction{Tool: "stream", Resource: r.URL.Path},
+			Decision:   string(model.Deny),
+			Reason:     reason,
+			PolicyHash: who.enf.Hash,
+			Type:       "stream_limit_exceeded",
+		})
+	}
+	if who.enf.Dispatcher != nil {
+		who.enf.Dispatcher.Dispatch(alert.AlertEvent{
+			Timestamp:  now,
+			TraceID:    traceID,
+			Tool:       "stream",
+			Resource:   r.URL.Path,
+			Decision:   string(model.Deny),
+			Reason:     reason,
+			PolicyHash: who.enf.Hash,
+			Type:       "stream_limit_exceeded",
+		})
+	}
 }
 
 // handleNonStreaming reads the full response, extracts tool calls, evaluates, rewrites.
-func (s *Server) handleNonStreaming(w http.ResponseWriter, resp *http.Response) {
+func (s *Server) handleNonStreaming(w http.ResponseWriter, resp *http.Response, who agentIdentity) {
 	body, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20)) // 10MB limit
 	if err != nil {
 		http.Error(w, fmt.Sprintf("failed to read upstream response: %v", err), http.StatusBadGateway)
@@ -218,6 +603,9 @@ func (s *Server) handleNonStreaming(w http.ResponseWriter, resp *http.Response)
 	}
 
 	calls, format := ExtractToolCalls(bodyMap)
+	if format == FormatUnknown && s.handleUnknownFormat(w, resp, bodyMap, who) {
+		return
+	}
 	if len(calls) == 0 {
 		// No tool calls — passthrough unchanged
 		copyHeaders(w, resp)
@@ -229,12 +617,12 @@ func (s *Server) handleNonStreaming(w http.ResponseWriter, resp *http.Response)
 	// Evaluate each tool call
 	var results []EvalResult
 	for _, call := range calls {
-		result := s.evaluateToolCall(call)
+		result := s.evaluateToolCall(call, who)
 		results = append(results, EvalResult{Call: call, Result: result})
 	}
 
 	// Rewrite blocked calls
-	modified, changed := RewriteResponse(bodyMap, results, format)
+	modified, changed := RewriteResponseWithOptions(bodyMap, results, format, RewriteOptions{ToolResults: s.cfg.ToolResults})
 	if !changed {
 		copyHeaders(w, resp)
 		w.WriteHeader(resp.StatusCode)
@@ -250,11 +638,11 @@ func (s *Server) handleNonStreaming(w http.ResponseWriter, resp *http.Response)
 }
 
 // handleStreaming processes SSE streaming responses, buffering tool_use blocks.
-func (s *Server) handleStreaming(w http.ResponseWriter, r *http.Request, resp *http.Response) {
+func (s *Server) handleStreaming(w http.ResponseWriter, r *http.Request, resp *http.Response, who agentIdentity) {
 	flusher, ok := w.(http.Flusher)
 	if !ok {
 		// Fallback: read entire stream and handle as non-streaming
-		s.handleNonStreaming(w, resp)
+		s.handleNonStreaming(w, resp, who)
 		return
 	}
 
@@ -265,21 +653,27 @@ func (s *Server) handleStreaming(w http.ResponseWriter, r *http.Request, resp *h
 	format := DetectStreamingFormat(r.URL.Path, r.Header)
 	switch format {
 	case FormatOpenAI:
-		s.handleOpenAIStreaming(w, flusher, resp)
+		s.handleOpenAIStreaming(w, flusher, r, resp, who)
+		return
+	case FormatGemini:
+		s.handleGeminiSSE(w, flusher, r, resp, who)
 		return
 	case FormatAnthropic:
 		// handled below
 	default:
-		// Unknown format — pass through unchanged
-		io.Copy(w, resp.Body)
-		flusher.Flush()
+		s.handleUnknownStreaming(w, flusher, r, resp, who)
 		return
 	}
 
 	buf := NewStreamBuffer(format)
-	scanner := bufio.NewScanner(resp.Body)
+	scanner := s.newSSEScanner(resp.Body)
 	var currentIndex int = -1
 	var buffering bool
+	var toolCalls, blockedCalls int
+	// Result blocks of blocked server tool calls are replaced, and their
+	// upstream events dropped, by index.
+	blockedServer := make(map[string]ToolCall)
+	withheld := make(map[int]bool)
 
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -320,14 +714,26 @@ func (s *Server) handleStreaming(w http.ResponseWriter, r *http.Request, resp *h
 			case "content_block_start":
 				idx := intFromAny(event["index"])
 				if cb, ok := event["content_block"].(map[string]any); ok {
-					if cbType, _ := cb["type"].(string); cbType == "tool_use" {
+					if cbType, _ := cb["type"].(string); cbType == "tool_use" || cbType == "server_tool_use" {
 						name, _ := cb["name"].(string)
 						id, _ := cb["id"].(string)
-						buf.StartToolUse(idx, id, name, line)
+						if cbType == "server_tool_use" {
+							buf.StartServerToolUse(idx, id, name, line)
+						} else {
+							buf.StartToolUse(idx, id, name, line)
+						}
 						currentIndex = idx
 						buffering = true
 						continue
 					}
+					if tc, ok := pairedServerCall(cb, blockedServer); ok {
+						withheld[idx] = true
+						for _, rep := range RewriteAnthropicResultSSE(idx, tc) {
+							fmt.Fprintf(w, "%s\n", rep)
+							flusher.Flush()
+						}
+						continue
+					}
 				}
 				// Non-tool block — pass through
 				fmt.Fprintf(w, "%s\n", line)
@@ -335,6 +741,9 @@ func (s *Server) handleStreaming(w http.ResponseWriter, r *http.Request, resp *h
 
 			case "content_block_delta":
 				idx := intFromAny(event["index"])
+				if withheld[idx] {
+					continue
+				}
 				if buf.IsBuffering(idx) {
 					if delta, ok := event["delta"].(map[string]any); ok {
 						if deltaType, _ := delta["type"].(string); deltaType == "input_json_delta" {
@@ -350,9 +759,14 @@ func (s *Server) handleStreaming(w http.ResponseWriter, r *http.Request, resp *h
 
 			case "content_block_stop":
 				idx := intFromAny(event["index"])
+				if withheld[idx] {
+					delete(withheld, idx)
+					continue
+				}
 				if tc, bufferedEvents, ok := buf.Complete(idx, line); ok {
 					// Evaluate the complete tool call
-					result := s.evaluateToolCall(tc)
+					result := s.evaluateToolCall(tc, who)
+					toolCalls++
 
 					if result.Decision == model.Allow || result.Decision == model.AllowWithRedaction {
 						// Allowed — emit original buffered events
@@ -362,6 +776,10 @@ func (s *Server) handleStreaming(w http.ResponseWriter, r *http.Request, resp *h
 						}
 					} else {
 						// Blocked — emit replacement text block
+						blockedCalls++
+						if tc.Server && tc.ID != "" {
+							blockedServer[tc.ID] = tc
+						}
 						replacements := RewriteAnthropicSSE(idx, tc, result)
 						for _, rep := range replacements {
 							fmt.Fprintf(w, "%s\n", rep)
@@ -380,6 +798,12 @@ func (s *Server) handleStreaming(w http.ResponseWriter, r *http.Request, resp *h
 				flusher.Flush()
 
 			default:
+				// Every tool call was blocked — the turn ends without tool use.
+				if eventType == "message_delta" && toolCalls > 0 && blockedCalls == toolCalls {
+					if rewritten, ok := RewriteAnthropicMessageDelta(event); ok {
+						line = rewritten
+					}
+				}
 				// message_start, message_delta, message_stop, ping — pass through
 				if !buffering {
 					fmt.Fprintf(w, "%s\n", line)
@@ -406,19 +830,84 @@ func (s *Server) handleStreaming(w http.ResponseWriter, r *http.Request, resp *h
 			}
 		}
 	}
+	if err := scanner.Err(); err != nil {
+		s.abortStream(w, flusher, r, who, err)
+	}
 }
 
 // handleOpenAIStreaming processes OpenAI-format SSE streams (including xAI).
 // Tool calls are identified by delta.tool_calls[i].index and accumulated
 // until finish_reason="tool_calls" is received.
-func (s *Server) handleOpenAIStreaming(w http.ResponseWriter, flusher http.Flusher, resp *http.Response) {
+func (s *Server) handleOpenAIStreaming(w http.ResponseWriter, flusher http.Flusher, r *http.Request, resp *http.Response, who agentIdentity) {
 	buf := NewStreamBuffer(FormatOpenAI)
-	scanner := bufio.NewScanner(resp.Body)
+	scanner := s.newSSEScanner(resp.Body)
 
 	// Track which tool call indices are actively buffering
 	activeTools := make(map[int]bool)
 	var pendingEvents []string
 
+	// flushToolCalls evaluates every buffered tool call and emits either the
+	// original events or a block message, then any events stashed while
+	// buffering. finishLine is the upstream finish chunk to forward; it is
+	// empty when the stream ended ([DONE] or EOF) without one, or when the
+	// finish_reason arrived in the same chunk as the tool call.
+	flushToolCalls := func(finishLine string) {
+		indices := make([]int, 0, len(activeTools))
+		for idx := range activeTools {
+			indices = append(indices, idx)
+		}
+		sort.Ints(indices)
+
+		allBlocked := true
+		var anyBlocked bool
+
+		for _, idx := range indices {
+			tc, bufferedEvents, ok := buf.Complete(idx, "")
+			if !ok {
+				continue
+			}
+
+			result := s.evaluateToolCall(tc, who)
+
+			if result.Decision == model.Allow || result.Decision == model.AllowWithRedaction {
+				allBlocked = false
+				// Emit original buffered events
+				for _, ev := range bufferedEvents {
+					if ev != "" {
+						fmt.Fprintf(w, "%s\n\n", ev)
+						flusher.Flush()
+					}
+				}
+			} else {
+				anyBlocked = true
+				// Emit block message as content chunk
+				rep := RewriteOpenAISSE(tc, result)
+				fmt.Fprintf(w, "%s\n", rep)
+				flusher.Flush()
+			}
+		}
+
+		for _, ev := range pendingEvents {
+			fmt.Fprintf(w, "%s\n\n", ev)
+			flusher.Flush()
+		}
+
+		// Emit finish chunk
+		if allBlocked && anyBlocked {
+			// All blocked — emit stop finish so clients don't wait for tool results
+			fin := RewriteOpenAISSEFinish()
+			fmt.Fprintf(w, "%s\n", fin)
+			flusher.Flush()
+		} else if finishLine != "" {
+			// Some or none blocked — emit original finish
+			fmt.Fprintf(w, "%s\n", finishLine)
+			flusher.Flush()
+		}
+
+		activeTools = make(map[int]bool)
+		pendingEvents = nil
+	}
+
 	for scanner.Scan() {
 		line := scanner.Text()
 
@@ -441,6 +930,11 @@ func (s *Server) handleOpenAIStreaming(w http.ResponseWriter, flusher http.Flush
 		dataStr := strings.TrimPrefix(line, "data: ")
 
 		if dataStr == "[DONE]" {
+			// Some providers never send a tool_calls finish chunk —
+			// evaluate whatever is still buffered before ending the stream.
+			if len(activeTools) > 0 {
+				flushToolCalls("")
+			}
 			fmt.Fprintf(w, "%s\n", line)
 			flusher.Flush()
 			continue
@@ -498,54 +992,18 @@ func (s *Server) handleOpenAIStreaming(w http.ResponseWriter, flusher http.Flush
 					}
 				}
 			}
+			// Finish reason in the same chunk: this line is already
+			// among the buffered events, so don't forward it again.
+			if finishReason != "" {
+				flushToolCalls("")
+			}
 			continue
 		}
 
-		// finish_reason="tool_calls" — evaluate all buffered tool calls
-		if finishReason == "tool_calls" {
-			allBlocked := true
-			var anyBlocked bool
-
-			for idx := range activeTools {
-				tc, bufferedEvents, ok := buf.Complete(idx, "")
-				if !ok {
-					continue
-				}
-
-				result := s.evaluateToolCall(tc)
-
-				if result.Decision == model.Allow || result.Decision == model.AllowWithRedaction {
-					allBlocked = false
-					// Emit original buffered events
-					for _, ev := range bufferedEvents {
-						if ev != "" {
-							fmt.Fprintf(w, "%s\n\n", ev)
-							flusher.Flush()
-						}
-					}
-				} else {
-					anyBlocked = true
-					// Emit block message as content chunk
-					rep := RewriteOpenAISSE(tc, result)
-					fmt.Fprintf(w, "%s\n", rep)
-					flusher.Flush()
-				}
-			}
-
-			// Emit finish chunk
-			if allBlocked && anyBlocked {
-				// All blocked — emit stop finish
-				fin := RewriteOpenAISSEFinish()
-				fmt.Fprintf(w, "%s\n", fin)
-				flusher.Flush()
-			} else {
-				// Some or none blocked — emit original finish
-				fmt.Fprintf(w, "%s\n", line)
-				flusher.Flush()
-			}
-
-			activeTools = make(map[int]bool)
-			pendingEvents = nil
+		// Any finish_reason ends the choice — evaluate all buffered tool calls.
+		// Providers may send "stop" (or another reason) even with tool calls present.
+		if finishReason != "" && len(activeTools) > 0 {
+			flushToolCalls(line)
 			continue
 		}
 
@@ -559,45 +1017,144 @@ func (s *Server) handleOpenAIStreaming(w http.ResponseWriter, flusher http.Flush
 		}
 	}
 
-	// Flush any remaining pending events
-	for _, ev := range pendingEvents {
-		fmt.Fprintf(w, "%s\n\n", ev)
-		flusher.Flush()
+	if err := scanner.Err(); err != nil {
+		s.abortStream(w, flusher, r, who, err)
+		return
+	}
+
+	// Stream ended without [DONE] — evaluate anything still buffered.
+	if len(activeTools) > 0 {
+		flushToolCalls("")
 	}
 }
 
-// evaluateToolCall builds a model.Action from a ToolCall and evaluates policy.
-func (s *Server) evaluateToolCall(tc ToolCall) model.PolicyResult {
-	action := buildActionFromToolCall(tc)
+// agentIdentity is the agent attribution for a single proxied request.
+type agentIdentity struct {
+	id    string
+	actor map[string]any
+
+	enf      *enforcement // policy snapshot taken when the request arrived
+	proposed *proposals   // primary's tool calls, when shadowing
+}
 
+// identify resolves the request's agent from Config.AgentHeader,
+// falling back to the configured AgentID and Actor. The header is
+// removed so it is never forwarded upstream.
+func (s *Server) identify(r *http.Request) agentIdentity {
+	id, actor := identity.FromHeader(r, s.cfg.AgentHeader, s.cfg.AgentID, s.cfg.Actor)
+	if s.cfg.AgentHeader != "" {
+		r.Header.Del(s.cfg.AgentHeader)
+	}
+	return agentIdentity{id: id, actor: actor, enf: s.snapshot()}
+}
+
+// argumentBytes is the encoded size of a tool call's arguments.
+func argumentBytes(tc ToolCall) int {
+	if tc.Arguments == nil {
+		return 0
+	}
+	data, err := json.Marshal(tc.Arguments)
+	if err != nil {
+		return 0
+	}
+	return len(data)
+}
+
+// evaluate enriches the action, runs local policy over it and its command
+// operands, then the external decision hook if configured. A panic in any
+// step denies the action.
+func (s *Server) evaluate(action *model.Action, who agentIdentity) (result model.PolicyResult) {
+	defer policy.RecoverEvaluation(&result)
+	enf := who.enf
+	// Enrichment calls out over HTTP, so it runs before the trace lock is
+	// taken.
+	traceID := s.traceID()
+	if denied, ok := enf.Enrich.Apply(context.Background(), action, traceID, s.cfg.Purpose, who.id); !ok {
+		return denied
+	}
+	func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		result = policy.Evaluate(action, s.tracer.State, s.cfg.Purpose, who.id, enf.Denylist, enf.Config)
+		result = s.evaluateOperands(action, who, result)
+	}()
+	// The decision hook also calls out, so it runs after the lock is released.
+	return enf.Hook.Decide(context.Background(), action, traceID, s.cfg.Purpose, who.id, result)
+}
+
+// traceID returns the current trace's ID.
+func (s *Server) traceID() string {
 	s.mu.Lock()
-	result := policy.Evaluate(action, s.tracer.State, s.cfg.Purpose, s.cfg.AgentID, s.dl, s.policyCfg)
-	s.tracer.RecordAction(s.cfg.Actor, s.cfg.Purpose, action, map[string]any{
+	defer s.mu.Unlock()
+	return s.tracer.State.TraceID
+}
+
+// recordToolCall appends the decision for tc to the trace.
+func (s *Server) recordToolCall(tc ToolCall, action *model.Action, who agentIdentity, result model.PolicyResult) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tracer.RecordAction(who.actor, s.cfg.Purpose, action, map[string]any{
 		"result":       string(result.Decision),
 		"reason":       result.Reason,
 		"policy_id":    result.PolicyID,
 		"approval_key": result.ApprovalKey,
+		"tier":         result.Tier,
 		"tool_call_id": tc.ID,
 		"tool_name":    tc.Name,
 		"source":       "intercept",
+
+		tracer.PayloadBytesKey: argumentBytes(tc),
 	}, "")
-	s.mu.Unlock()
+}
+
+// evaluateToolCall builds a model.Action from a ToolCall and evaluates policy.
+func (s *Server) evaluateToolCall(tc ToolCall, who agentIdentity) model.PolicyResult {
+	who.enf = s.policyFor(who)
+	enf := who.enf
+	action := buildActionFromToolCall(tc, s.paths)
+	malformed := malformedToolCall(tc, s.paths)
+	if dest, _ := action.RawMeta["destination"].(string); dest != "" {
+		s.geo.Tag(context.Background(), action, dest)
+	}
+
+	result := s.evaluate(action, who)
+	if tc.ParseError != "" {
+		// Fail closed: a call we could not parse cannot be enforced.
+		result = model.PolicyResult{
+			Decision: model.Deny,
+			Reason:   "unparseable tool call: " + tc.ParseError,
+			PolicyID: "intercept.parse_error",
+			Tier:     result.Tier,
+		}
+	} else if malformed != "" {
+		// Fail closed: the resource fell back to the tool name, which no
+		// denylist pattern would match.
+		result = model.PolicyResult{
+			Decision: model.Deny,
+			Reason:   "malformed_tool_call: " + malformed,
+			PolicyID: "intercept.malformed_tool_call",
+			Tier:     result.Tier,
+		}
+	}
+	s.recordToolCall(tc, action, who, result)
+	who.proposed.add(action, tc.Name, result)
 
 	if s.auditLog != nil {
 		s.auditLog.Record(audit.AuditEntry{
 			Timestamp:  time.Now().UTC().Format("2006-01-02T15:04:05.000Z"),
 			TraceID:    s.tracer.State.TraceID,
-			Action:     audit.AuditAction{Tool: action.Tool, Resource: action.Resource},
+			AgentID:    who.id,
+			Action:     audit.AuditAction{Tool: action.Tool, Resource: action.Resource, RawResource: action.RawResource, Labels: action.Labels, Fingerprint: action.Fingerprint()},
 			Decision:   string(result.Decision),
 			Reason:     result.Reason,
 			Tier:       result.Tier,
-			PolicyHash: s.policyHash,
+			PolicyHash: enf.Hash,
 		})
 	}
-	s.dispatchAlert(action, result)
+	s.dispatchAlert(enf, action, result)
 
 	// Break-glass override (CW-23.2)
-	if result.Tier >= 2 && s.bgStore != nil {
+	if result.Tier >= 2 && s.bgStore != nil && tc.ParseError == "" && malformed == "" {
 		if token := breakglass.CheckAndConsume(s.bgStore, result.Tier, action); token != nil {
 			originalDecision := result.Decision
 			result.Decision = model.Allow
@@ -608,11 +1165,12 @@ func (s *Server) evaluateToolCall(tc ToolCall) model.PolicyResult {
 				s.auditLog.Record(audit.AuditEntry{
 					Timestamp:        time.Now().UTC().Format("2006-01-02T15:04:05.000Z"),
 					TraceID:          s.tracer.State.TraceID,
-					Action:           audit.AuditAction{Tool: action.Tool, Resource: action.Resource},
+					AgentID:          who.id,
+					Action:           audit.AuditAction{Tool: action.Tool, Resource: action.Resource, RawResource: action.RawResource, Labels: action.Labels, Fingerprint: action.Fingerprint()},
 					Decision:         "allow",
 					Reason:           result.Reason,
 					Tier:             result.Tier,
-					PolicyHash:       s.policyHash,
+					PolicyHash:       enf.Hash,
 					Type:             "break_glass_used",
 					TokenID:          token.ID,
 					OriginalDecision: string(originalDecision),
@@ -620,32 +1178,57 @@ func (s *Server) evaluateToolCall(tc ToolCall) model.PolicyResult {
 					ExpiresAt:        token.ExpiresAt.Format(time.RFC3339),
 				})
 			}
-			s.dispatchBreakGlass(action, result)
+			s.dispatchBreakGlass(enf, action, result)
 		}
 	}
 
 	// Handle approval flow
 	if result.Decision == model.RequireApproval && result.ApprovalKey != "" {
-		status, _ := s.approvals.Check(result.ApprovalKey)
+		traceID := s.traceID()
+		status, _ := s.approvals.CheckTrace(result.ApprovalKey, traceID)
 		if status == approval.StatusApproved {
 			s.approvals.Consume(result.ApprovalKey)
+			if err := s.approvals.OpenGrace(result, traceID, who.id); err != nil {
+				fmt.Fprintf(os.Stderr, "approval grace not opened: %v\n", err)
+			}
 			return model.PolicyResult{
 				Decision: model.Allow,
 				Reason:   "approved via approval flow",
 				PolicyID: result.PolicyID,
 			}
 		}
+		if status == approval.StatusThrottled {
+			result = s.approvals.ThrottleDeny(result)
+			s.dispatchAlert(enf, action, result)
+			return result
+		}
+		if graced, ok := s.approvals.Grace(result, traceID, who.id); ok {
+			if s.auditLog != nil {
+				s.auditLog.Record(audit.AuditEntry{
+					Timestamp:  time.Now().UTC().Format("2006-01-02T15:04:05.000Z"),
+					TraceID:    traceID,
+					AgentID:    who.id,
+					Action:     audit.AuditAction{Tool: action.Tool, Resource: action.Resource, RawResource: action.RawResource, Labels: action.Labels, Fingerprint: action.Fingerprint()},
+					Decision:   string(graced.Decision),
+					Reason:     graced.Reason,
+					Tier:       graced.Tier,
+					PolicyHash: enf.Hash,
+					Type:       "approval_grace",
+				})
+			}
+			return graced
+		}
 		if status != approval.StatusPending && status != approval.StatusDenied {
-			s.approvals.Request(result.ApprovalKey, result.Reason, result.PolicyID, action.Resource, s.cfg.AgentID)
+			s.approvals.Request(result.ApprovalKey, result.Reason, result.PolicyID, action.Resource, who.id)
 		}
 	}
 
 	return result
 }
 
-func (s *Server) dispatchAlert(action *model.Action, result model.PolicyResult) {
-	if s.dispatcher != nil {
-		s.dispatcher.Dispatch(alert.AlertEvent{
+func (s *Server) dispatchAlert(enf *enforcement, action *model.Action, result model.PolicyResult) {
+	if enf.Dispatcher != nil {
+		enf.Dispatcher.Dispatch(alert.AlertEvent{
 			Timestamp:  time.Now().UTC().Format("2006-01-02T15:04:05.000Z"),
 			TraceID:    s.tracer.State.TraceID,
 			Tool:       action.Tool,
@@ -653,14 +1236,51 @@ func (s *Server) dispatchAlert(action *model.Action, result model.PolicyResult)
 			Decision:   string(result.Decision),
 			Reason:     result.Reason,
 			Tier:       result.Tier,
-			PolicyHash: s.policyHash,
+			PolicyHash: enf.Hash,
+			Rule:       alert.RuleAlert{Mode: result.AlertMode, Channels: result.AlertChannels},
 		})
 	}
 }
 
-func (s *Server) dispatchBreakGlass(action *model.Action, result model.PolicyResult) {
-	if s.dispatcher != nil {
-		s.dispatcher.Dispatch(alert.AlertEvent{
+// reportPinMismatch audits and alerts on an upstream certificate that
+// matched none of the configured pins.
+func (s *Server) reportPinMismatch(err error) {
+	enf := s.snapshot()
+	now := time.Now().UTC().Format("2006-01-02T15:04:05.000Z")
+	s.mu.Lock()
+	traceID := s.tracer.State.TraceID
+	s.mu.Unlock()
+
+	if s.auditLog != nil {
+		s.auditLog.Record(audit.AuditEntry{
+			Timestamp:  now,
+			TraceID:    traceID,
+			Action:     audit.AuditAction{Tool: "upstream_tls", Resource: s.upstream.Host},
+			Decision:   string(model.Deny),
+			Reason:     err.Error(),
+			PolicyHash: enf.Hash,
+			Type:       "upstream_pin_mismatch",
+		})
+	}
+	if enf.Dispatcher != nil {
+		enf.Dispatcher.Dispatch(alert.AlertEvent{
+			Timestamp:  now,
+			TraceID:    traceID,
+			Tool:       "upstream_tls",
+			Resource:   s.upstream.Host,
+			Decision:   string(model.Deny),
+			Reason:     err.Error(),
+			Tier:       policy.TierCritical,
+			PolicyHash: enf.Hash,
+			Type:       "upstream_pin_mismatch",
+			Rule:       alert.RuleAlert{Mode: alert.RuleAlertForce},
+		})
+	}
+}
+
+func (s *Server) dispatchBreakGlass(enf *enforcement, action *model.Action, result model.PolicyResult) {
+	if enf.Dispatcher != nil {
+		enf.Dispatcher.Dispatch(alert.AlertEvent{
 			Timestamp:  time.Now().UTC().Format("2006-01-02T15:04:05.000Z"),
 			TraceID:    s.tracer.State.TraceID,
 			Tool:       action.Tool,
@@ -668,30 +1288,59 @@ func (s *Server) dispatchBreakGlass(action *model.Action, result model.PolicyRes
 			Decision:   string(result.Decision),
 			Reason:     result.Reason,
 			Tier:       result.Tier,
-			PolicyHash: s.policyHash,
+			PolicyHash: enf.Hash,
 			Type:       "break_glass_used",
 		})
 	}
 }
 
 // buildActionFromToolCall maps a parsed ToolCall to a model.Action.
-func buildActionFromToolCall(tc ToolCall) *model.Action {
+// A configured resource path for the tool name takes precedence over extractResource.
+func buildActionFromToolCall(tc ToolCall, paths resourcePaths) *model.Action {
 	tool, operation := classifyTool(tc.Name)
-	resource := extractResource(tc.Arguments, tool)
+	server, isServer := serverTools[tc.Name]
+	if tc.Server && isServer {
+		tool, operation = server.tool, server.operation
+	}
+	resource, ok := paths.resolve(tc)
+	if !ok && tc.Server && isServer {
+		resource, _ = tc.Arguments[server.resourceKey].(string)
+	}
+	if !ok && resource == "" {
+		resource = extractResource(tc.Arguments, tool)
+	}
 	if resource == "" {
 		resource = tc.Name
 	}
+	var rawResource string
+	if tool != "command" {
+		resource, rawResource = model.DecodeResource(resource)
+	}
 
 	sensitivity, tags := classifyToolSensitivity(tool, resource)
 	egress := inferEgress(tool, resource)
 
 	// If tool is HTTP and args have a method, use it as operation
 	if tool == "http" {
-		if method, ok := tc.Arguments["method"].(string); ok {
-			operation = strings.ToLower(method)
+		if method, ok := tc.Arguments["method"].(string); ok && strings.TrimSpace(method) != "" {
+			operation = model.NormalizeHTTPMethod(method)
 		}
 	}
 
+	destination := extractDestination(resource)
+	if destination == "" && operation == "search" {
+		destination = searchSiteDomain(resource)
+	}
+
+	var labels map[string]string
+	if tool == "command" {
+		labels = cmdguard.CredentialReferenceLabels(resource)
+	}
+	var bytes, rows int
+	if tool == "file_read" {
+		bytes, rows = model.FileReadVolume(resource, tc.Arguments)
+	}
+
 	return &model.Action{
 		Tool:      tool,
 		Resource:  resource,
@@ -700,12 +1349,42 @@ func buildActionFromToolCall(tc ToolCall) *model.Action {
 		RawMeta: map[string]any{
 			"sensitivity": string(sensitivity),
 			"tags":        toAnySlice(tags),
-			"bytes":       0,
-			"rows":        0,
+			"bytes":       bytes,
+			"rows":        rows,
 			"egress":      string(egress),
-			"destination": extractDestination(resource),
+			"destination": destination,
 		},
+		Labels:      labels,
+		RawResource: rawResource,
+	}
+}
+
+// serverTool maps an Anthropic server tool to a chainwatch tool category,
+// operation, and the input field holding its resource.
+type serverTool struct {
+	tool        string
+	operation   string
+	resourceKey string
+}
+
+// serverTools lists the server tools evaluated as structured actions. A
+// web search is matched against URL denylist patterns by its query, so
+// "site:" operators and domains in the query are caught. Other server
+// tools fall back to name-based classification.
+var serverTools = map[string]serverTool{
+	"web_search": {tool: "browser", operation: "search", resourceKey: "query"},
+	"web_fetch":  {tool: "http", operation: "get", resourceKey: "url"},
+}
+
+// searchSiteDomain returns the domain of a "site:" operator in a search
+// query, or "".
+func searchSiteDomain(query string) string {
+	for _, field := range strings.Fields(query) {
+		if domain, ok := strings.CutPrefix(strings.ToLower(field), "site:"); ok && domain != "" {
+			return domain
+		}
 	}
+	return ""
 }
 
 // classifyTool maps a tool name to chainwatch tool category and operation.
@@ -742,6 +1421,29 @@ func classifyTool(name string) (string, string) {
 	return name, "execute"
 }
 
+// malformedToolCall returns why a call is too malformed to enforce, or ""
+// when it is fine. A command tool whose command argument is missing, null,
+// or blank has no resource to match against.
+func malformedToolCall(tc ToolCall, paths resourcePaths) string {
+	tool, _ := classifyTool(tc.Name)
+	if tool != "command" {
+		return ""
+	}
+	if resource, ok := paths.resolve(tc); ok && strings.TrimSpace(resource) != "" {
+		return ""
+	}
+	if v, present := tc.Arguments["command"]; present {
+		if cmd, _ := v.(string); strings.TrimSpace(cmd) == "" {
+			return "command tool call with empty or null command argument"
+		}
+		return ""
+	}
+	if strings.TrimSpace(extractResource(tc.Arguments, tool)) == "" {
+		return "command tool call with no command argument"
+	}
+	return ""
+}
+
 // extractResource tries to extract the resource string from tool arguments.
 func extractResource(args map[string]any, tool string) string {
 	keys := []string{"command", "url", "path", "file_path", "filename", "resource"}
@@ -773,22 +1475,30 @@ func classifyToolSensitivity(tool, resource string) (model.Sensitivity, []string
 				return model.SensHigh, []string{"destructive"}
 			}
 		}
+		if len(cmdguard.SensitiveEnvRefs(resource)) > 0 {
+			return model.SensHigh, []string{cmdguard.TagCredentialReference}
+		}
 		credential := []string{"sudo", "passwd", "ssh-keygen"}
 		for _, p := range credential {
 			if strings.Contains(lower, p) {
 				return model.SensHigh, []string{"credential"}
 			}
 		}
+		if strings.Contains(lower, "git push") {
+			return model.SensMedium, []string{"vcs_write"}
+		}
 	}
 
 	// File sensitivity
 	if tool == "file_read" || tool == "file_write" || tool == "file_delete" {
-		sensitive := []string{".ssh/", ".aws/", ".env", "credentials", "secret", "password", "salary"}
-		for _, p := range sensitive {
+		for _, p := range cmdguard.SensitiveFilePatterns {
 			if strings.Contains(lower, p) {
 				return model.SensHigh, []string{"sensitive_file"}
 			}
 		}
+		if strings.HasPrefix(lower, "/var/log/") {
+			return model.SensMedium, []string{"system_log"}
+		}
 	}
 
 	// HTTP sensitivity
@@ -799,6 +1509,15 @@ func classifyToolSensitivity(tool, resource string) (model.Sensitivity, []string
 				return model.SensHigh, []string{"payment"}
 			}
 		}
+		if strings.Contains(lower, "git-receive-pack") {
+			return model.SensMedium, []string{"vcs_write"}
+		}
+		paymentAdjacent := []string{"/invoice", "/pricing", "/subscription", "/cart", "/receipt"}
+		for _, p := range paymentAdjacent {
+			if strings.Contains(lower, p) {
+				return model.SensMedium, []string{"payment_adjacent"}
+			}
+		}
 	}
 
 	return model.SensLow, nil
@@ -1,6 +1,7 @@
 package intercept
 
 import (
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -8,16 +9,31 @@ import (
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/ppiankov/chainwatch/internal/audit"
+	"github.com/ppiankov/chainwatch/internal/denylist"
 	"github.com/ppiankov/chainwatch/internal/model"
+	"github.com/ppiankov/chainwatch/internal/tracer"
 )
 
 // --- Test helpers ---
 
 func newTestInterceptor(t *testing.T, upstreamURL string) (*Server, int) {
+	t.Helper()
+	return newTestInterceptorWithConfig(t, Config{
+		Upstream: upstreamURL,
+		Purpose:  "test",
+		Actor:    map[string]any{"test": true},
+	})
+}
+
+// newTestInterceptorWithConfig creates an interceptor from cfg on a free port.
+func newTestInterceptorWithConfig(t *testing.T, cfg Config) (*Server, int) {
 	t.Helper()
 	ln, err := net.Listen("tcp", "127.0.0.1:0")
 	if err != nil {
@@ -26,12 +42,7 @@ func newTestInterceptor(t *testing.T, upstreamURL string) (*Server, int) {
 	port := ln.Addr().(*net.TCPAddr).Port
 	ln.Close()
 
-	cfg := Config{
-		Port:     port,
-		Upstream: upstreamURL,
-		Purpose:  "test",
-		Actor:    map[string]any{"test": true},
-	}
+	cfg.Port = port
 	srv, err := NewServer(cfg)
 	if err != nil {
 		t.Fatalf("failed to create interceptor: %v", err)
@@ -162,6 +173,73 @@ func TestExtractAnthropicToolCalls(t *testing.T) {
 	}
 }
 
+func TestExtractAnthropicBetaToolBlocks(t *testing.T) {
+	body := map[string]any{
+		"content": []any{
+			map[string]any{"type": "thinking", "thinking": "planning"},
+			map[string]any{
+				"type":  "server_tool_use",
+				"id":    "srvtoolu_1",
+				"name":  "run_command",
+				"input": `{"command": "echo hello"}`,
+			},
+			map[string]any{
+				"type":  "mcp_tool_use",
+				"id":    "mcptoolu_1",
+				"name":  "run_command",
+				"input": "{not json",
+			},
+			map[string]any{"type": "web_search_tool_result", "tool_use_id": "srvtoolu_1"},
+		},
+	}
+	calls, format := ExtractToolCalls(body)
+	if format != FormatAnthropic {
+		t.Fatalf("expected Anthropic format, got %d", format)
+	}
+	if len(calls) != 2 {
+		t.Fatalf("expected 2 tool calls, got %d", len(calls))
+	}
+	if calls[0].ParseError != "" {
+		t.Errorf("expected best-effort parse, got error %s", calls[0].ParseError)
+	}
+	if calls[0].Arguments["command"] != "echo hello" {
+		t.Errorf("expected command argument, got %v", calls[0].Arguments)
+	}
+	if calls[1].Index != 2 {
+		t.Errorf("expected index 2, got %d", calls[1].Index)
+	}
+	if !strings.Contains(calls[1].ParseError, "mcp_tool_use") {
+		t.Errorf("expected parse error naming block type, got %q", calls[1].ParseError)
+	}
+}
+
+func TestExtractAnthropicServerToolUse(t *testing.T) {
+	body := map[string]any{
+		"content": []any{
+			map[string]any{"type": "server_tool_use", "id": "srvtoolu_1", "name": "web_search", "input": map[string]any{"query": "site:evil.example.com keys"}},
+			map[string]any{"type": "web_search_tool_result", "tool_use_id": "srvtoolu_1", "content": []any{}},
+		},
+	}
+	calls, _ := ExtractToolCalls(body)
+	if len(calls) != 1 {
+		t.Fatalf("expected only the server_tool_use block extracted, got %+v", calls)
+	}
+	if !calls[0].Server || calls[0].ParseError != "" {
+		t.Errorf("expected a parsed server call, got %+v", calls[0])
+	}
+
+	action := buildActionFromToolCall(calls[0], nil)
+	if action.Tool != "browser" || action.Operation != "search" {
+		t.Errorf("expected browser/search action, got %s/%s", action.Tool, action.Operation)
+	}
+	if action.Resource != "site:evil.example.com keys" {
+		t.Errorf("expected query as resource, got %q", action.Resource)
+	}
+	if dest := action.RawMeta["destination"]; dest != "evil.example.com" {
+		t.Errorf("expected site: domain as destination, got %v", dest)
+	}
+}
+
 func TestExtractOpenAIToolCalls(t *testing.T) {
 	body := map[string]any{
 		"choices": []any{
@@ -200,7 +278,7 @@ func TestExtractOpenAIToolCalls(t *testing.T) {
 
 func TestBuildActionFromCommandTool(t *testing.T) {
 	tc := ToolCall{Name: "run_command", Arguments: map[string]any{"command": "rm -rf /"}}
-	action := buildActionFromToolCall(tc)
+	action := buildActionFromToolCall(tc, nil)
 	if action.Tool != "command" {
 		t.Errorf("expected tool=command, got %s", action.Tool)
 	}
@@ -212,12 +290,75 @@ func TestBuildActionFromCommandTool(t *testing.T) {
 	}
 }
 
+func TestMalformedToolCall(t *testing.T) {
+	tests := []struct {
+		name string
+		args map[string]any
+		want bool
+	}{
+		{"run_command", map[string]any{"command": "ls"}, false},
+		{"run_command", map[string]any{"command": ""}, true},
+		{"run_command", map[string]any{"command": "   "}, true},
+		{"run_command", map[string]any{"command": nil}, true},
+		{"run_command", map[string]any{"command": nil, "description": "cleanup"}, true},
+		{"run_command", map[string]any{}, true},
+		{"run_command", nil, true},
+		{"run_command", map[string]any{"cmd": "ls"}, false},
+		{"read_file", map[string]any{}, false},
+	}
+	for _, tt := range tests {
+		got := malformedToolCall(ToolCall{Name: tt.name, Arguments: tt.args}, nil) != ""
+		if got != tt.want {
+			t.Errorf("malformedToolCall(%s, %v) = %v, want %v", tt.name, tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestCommandToolWithEmptyCommandBlocked(t *testing.T) {
+	for name, input := range map[string]string{
+		"empty": `{"command": ""}`,
+		"null":  `{"command": null}`,
+	} {
+		t.Run(name, func(t *testing.T) {
+			var args map[string]any
+			json.Unmarshal([]byte(input), &args)
+			upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.Write(anthropicResponse([]any{
+					map[string]any{"type": "tool_use", "id": "t1", "name": "run_command", "input": args},
+				}, "tool_use"))
+			}))
+			defer upstream.Close()
+
+			srv, port := newTestInterceptor(t, upstream.URL)
+			cancel := startTestInterceptor(t, srv)
+			defer cancel()
+
+			resp, err := interceptClient(port).Post(interceptURL(port, "/v1/messages"), "application/json", strings.NewReader("{}"))
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			defer resp.Body.Close()
+
+			var body map[string]any
+			json.NewDecoder(resp.Body).Decode(&body)
+			block := body["content"].([]any)[0].(map[string]any)
+			if block["type"] != "text" {
+				t.Fatalf("expected tool call to be blocked, got %s block", block["type"])
+			}
+			if text, _ := block["text"].(string); !strings.Contains(text, "malformed_tool_call") {
+				t.Errorf("expected malformed_tool_call in block message, got %s", text)
+			}
+		})
+	}
+}
+
 func TestBuildActionFromHTTPTool(t *testing.T) {
 	tc := ToolCall{Name: "http_request", Arguments: map[string]any{
 		"url":    "https://stripe.com/v1/charges",
 		"method": "POST",
 	}}
-	action := buildActionFromToolCall(tc)
+	action := buildActionFromToolCall(tc, nil)
 	if action.Tool != "http" {
 		t.Errorf("expected tool=http, got %s", action.Tool)
 	}
@@ -229,12 +370,63 @@ func TestBuildActionFromHTTPTool(t *testing.T) {
 	}
 }
 
+func TestBuildActionNormalizesHTTPMethod(t *testing.T) {
+	for method, want := range map[string]string{"PATCH": "patch", " head ": "head", "Options": "options", "": "get"} {
+		tc := ToolCall{Name: "http_request", Arguments: map[string]any{
+			"url":    "https://api.example.com/v1/orders/1",
+			"method": method,
+		}}
+		if got := buildActionFromToolCall(tc, nil).Operation; got != want {
+			t.Errorf("method %q: operation = %q, want %q", method, got, want)
+		}
+	}
+}
+
+func TestBuildActionDecodesPercentEncodedTraversal(t *testing.T) {
+	raw := "/workspace/..%2f..%2fhome%2fuser%2f.ssh%2fid_rsa"
+	tc := ToolCall{Name: "read_file", Arguments: map[string]any{"path": raw}}
+	action := buildActionFromToolCall(tc, nil)
+
+	if action.Resource != "/workspace/../../home/user/.ssh/id_rsa" {
+		t.Errorf("expected decoded resource, got %s", action.Resource)
+	}
+	if action.RawResource != raw {
+		t.Errorf("expected raw resource %q preserved, got %q", raw, action.RawResource)
+	}
+	if blocked, _ := denylist.NewDefault().IsBlocked(action.Resource, action.Tool); !blocked {
+		t.Error("expected percent-encoded ssh key path to be denylisted")
+	}
+}
+
+func TestBuildActionDecodesHexEncodedPath(t *testing.T) {
+	tc := ToolCall{Name: "read_file", Arguments: map[string]any{"path": `\x2fhome\x2fuser\x2f.aws\x2fcredentials`}}
+	action := buildActionFromToolCall(tc, nil)
+
+	if action.Resource != "/home/user/.aws/credentials" {
+		t.Errorf("expected decoded resource, got %s", action.Resource)
+	}
+	if meta := action.NormalizedMeta(); meta.Sensitivity != model.SensHigh {
+		t.Errorf("expected high sensitivity for hex-encoded credentials path, got %s", meta.Sensitivity)
+	}
+	if blocked, _ := denylist.NewDefault().IsBlocked(action.Resource, action.Tool); !blocked {
+		t.Error("expected hex-encoded credentials path to be denylisted")
+	}
+}
+
+func TestBuildActionKeepsCommandEncoding(t *testing.T) {
+	tc := ToolCall{Name: "run_command", Arguments: map[string]any{"command": "date +%Y%m%d"}}
+	action := buildActionFromToolCall(tc, nil)
+	if action.Resource != "date +%Y%m%d" || action.RawResource != "" {
+		t.Errorf("expected command left as is, got %q (raw %q)", action.Resource, action.RawResource)
+	}
+}
+
 func TestBuildActionFromFileTool(t *testing.T) {
 	tc := ToolCall{Name: "file_write", Arguments: map[string]any{
 		"path":    "~/.ssh/id_rsa",
 		"content": "secret key",
 	}}
-	action := buildActionFromToolCall(tc)
+	action := buildActionFromToolCall(tc, nil)
 	if action.Tool != "file_write" {
 		t.Errorf("expected tool=file_write, got %s", action.Tool)
 	}
@@ -243,9 +435,27 @@ func TestBuildActionFromFileTool(t *testing.T) {
 	}
 }
 
+func TestBuildActionFileReadVolume(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "notes.txt")
+	if err := os.WriteFile(path, []byte("hello"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	action := buildActionFromToolCall(ToolCall{Name: "read_file", Arguments: map[string]any{"path": path}}, nil)
+	if meta := action.NormalizedMeta(); meta.Bytes != 5 {
+		t.Errorf("expected stat size 5, got %d", meta.Bytes)
+	}
+
+	declared := buildActionFromToolCall(ToolCall{Name: "read_file", Arguments: map[string]any{
+		"path": "/remote/dump.sql", "size": float64(3 << 30), "rows": float64(50000),
+	}}, nil)
+	if meta := declared.NormalizedMeta(); meta.Bytes != 3<<30 || meta.Rows != 50000 {
+		t.Errorf("expected declared volume, got bytes=%d rows=%d", meta.Bytes, meta.Rows)
+	}
+}
+
 func TestBuildActionFromUnknownTool(t *testing.T) {
 	tc := ToolCall{Name: "custom_tool", Arguments: map[string]any{"data": "test"}}
-	action := buildActionFromToolCall(tc)
+	action := buildActionFromToolCall(tc, nil)
 	if action.Tool != "custom_tool" {
 		t.Errorf("expected tool=custom_tool, got %s", action.Tool)
 	}
@@ -253,6 +463,30 @@ func TestBuildActionFromUnknownTool(t *testing.T) {
 
 // --- Rewrite tests ---
 
+func TestClassifyToolSensitivityMedium(t *testing.T) {
+	tests := []struct {
+		tool, resource string
+		want           model.Sensitivity
+		tag            string
+	}{
+		{"file_read", "/var/log/syslog", model.SensMedium, "system_log"},
+		{"command", "git push origin feature/x", model.SensMedium, "vcs_write"},
+		{"http", "https://github.com/acme/app.git/git-receive-pack", model.SensMedium, "vcs_write"},
+		{"http", "https://shop.example.com/pricing", model.SensMedium, "payment_adjacent"},
+		{"http", "https://shop.example.com/checkout", model.SensHigh, "payment"},
+		{"file_read", "/data/report.csv", model.SensLow, ""},
+	}
+	for _, tt := range tests {
+		got, tags := classifyToolSensitivity(tt.tool, tt.resource)
+		if got != tt.want {
+			t.Errorf("classifyToolSensitivity(%q, %q) = %s, want %s", tt.tool, tt.resource, got, tt.want)
+		}
+		if tt.tag != "" && (len(tags) == 0 || tags[0] != tt.tag) {
+			t.Errorf("classifyToolSensitivity(%q, %q) tags = %v, want %s", tt.tool, tt.resource, tags, tt.tag)
+		}
+	}
+}
+
 func TestRewriteAnthropicBlocked(t *testing.T) {
 	body := map[string]any{
 		"content": []any{
@@ -286,6 +520,32 @@ func TestRewriteAnthropicBlocked(t *testing.T) {
 	}
 }
 
+func TestRewriteBlockMessageIncludesSuggestion(t *testing.T) {
+	body := map[string]any{
+		"content": []any{
+			map[string]any{"type": "tool_use", "id": "t1", "name": "bash", "input": map[string]any{"command": "rm app.log"}},
+		},
+		"stop_reason": "tool_use",
+	}
+	result := makeResult("deny", "log deletion blocked", "rule.rm")
+	result.Suggestion = "truncate -s0 app.log"
+	results := []EvalResult{{
+		Call:   ToolCall{Name: "bash", Index: 0, Format: FormatAnthropic},
+		Result: result,
+	}}
+	out, changed := RewriteResponse(body, results, FormatAnthropic)
+	if !changed {
+		t.Fatal("expected response to be changed")
+	}
+	var parsed map[string]any
+	json.Unmarshal(out, &parsed)
+
+	text := parsed["content"].([]any)[0].(map[string]any)["text"].(string)
+	if !strings.Contains(text, "Suggested alternative: truncate -s0 app.log") {
+		t.Errorf("expected suggestion in block message, got %s", text)
+	}
+}
+
 func TestRewriteAnthropicPartialBlock(t *testing.T) {
 	body := map[string]any{
 		"content": []any{
@@ -404,6 +664,58 @@ func TestRewriteOpenAIPartialBlock(t *testing.T) {
 	}
 }
 
+func TestRewriteOpenAIToolResults(t *testing.T) {
+	body := map[string]any{
+		"choices": []any{
+			map[string]any{
+				"message": map[string]any{
+					"content": nil,
+					"tool_calls": []any{
+						map[string]any{"id": "call_ok", "type": "function", "function": map[string]any{"name": "echo", "arguments": "{}"}},
+						map[string]any{"id": "call_rm", "type": "function", "function": map[string]any{"name": "rm", "arguments": "{}"}},
+					},
+				},
+				"finish_reason": "tool_calls",
+			},
+		},
+	}
+	results := []EvalResult{
+		{Call: ToolCall{ID: "call_ok", Name: "echo", Index: 0}, Result: makeResult("allow", "ok", "")},
+		{Call: ToolCall{ID: "call_rm", Name: "rm", Index: 1}, Result: makeResult("deny", "blocked", "denylist.block")},
+	}
+	out, changed := RewriteResponseWithOptions(body, results, FormatOpenAI, RewriteOptions{ToolResults: true})
+	if !changed {
+		t.Fatal("expected changed")
+	}
+	var parsed map[string]any
+	json.Unmarshal(out, &parsed)
+
+	choice := parsed["choices"].([]any)[0].(map[string]any)
+	msg := choice["message"].(map[string]any)
+	calls, _ := msg["tool_calls"].([]any)
+	if len(calls) != 1 || calls[0].(map[string]any)["id"] != "call_ok" {
+		t.Errorf("expected only the allowed call left in tool_calls, got %v", msg["tool_calls"])
+	}
+	if content, _ := msg["content"].(string); !strings.Contains(content, "[BLOCKED by chainwatch]") {
+		t.Errorf("expected block reason in assistant content, got %v", msg["content"])
+	}
+
+	toolMsgs, ok := choice["tool_messages"].([]any)
+	if !ok || len(toolMsgs) != 1 {
+		t.Fatalf("expected 1 synthetic tool message, got %v", choice["tool_messages"])
+	}
+	tm := toolMsgs[0].(map[string]any)
+	if tm["role"] != "tool" {
+		t.Errorf("expected role=tool, got %v", tm["role"])
+	}
+	if tm["tool_call_id"] != "call_rm" {
+		t.Errorf("expected tool_call_id=call_rm, got %v", tm["tool_call_id"])
+	}
+	if content, _ := tm["content"].(string); !strings.Contains(content, "[BLOCKED by chainwatch]") {
+		t.Errorf("expected block reason in content, got %v", tm["content"])
+	}
+}
+
 // --- End-to-end non-streaming tests ---
 
 func TestAnthropicToolUseBlocked(t *testing.T) {
@@ -455,6 +767,66 @@ func TestAnthropicToolUseBlocked(t *testing.T) {
 	}
 }
 
+func TestAnthropicServerWebSearchDenylistedBlocked(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		body := anthropicResponse([]any{
+			map[string]any{
+				"type":  "server_tool_use",
+				"id":    "srvtoolu_1",
+				"name":  "web_search",
+				"input": map[string]any{"query": "site:evil.example.com leaked keys"},
+			},
+			map[string]any{
+				"type":        "web_search_tool_result",
+				"tool_use_id": "srvtoolu_1",
+				"content": []any{
+					map[string]any{"type": "web_search_result", "url": "https://evil.example.com/dump", "title": "dump"},
+				},
+			},
+			map[string]any{"type": "text", "text": "Here is what I found"},
+		}, "end_turn")
+		w.Write(body)
+	}))
+	defer upstream.Close()
+
+	dlPath := filepath.Join(t.TempDir(), "denylist.yaml")
+	if err := os.WriteFile(dlPath, []byte("urls:\n  - evil.example.com\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	srv, port := newTestInterceptorWithConfig(t, Config{
+		Upstream:     upstream.URL,
+		DenylistPath: dlPath,
+		Purpose:      "test",
+	})
+	cancel := startTestInterceptor(t, srv)
+	defer cancel()
+
+	resp, err := interceptClient(port).Post(interceptURL(port, "/v1/messages"), "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	raw, _ := io.ReadAll(resp.Body)
+
+	var body map[string]any
+	if err := json.Unmarshal(raw, &body); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	content := body["content"].([]any)
+	call := content[0].(map[string]any)
+	if call["type"] != "text" || !strings.Contains(call["text"].(string), "[BLOCKED by chainwatch] Tool 'web_search'") {
+		t.Errorf("expected server_tool_use replaced with block text, got %v", call)
+	}
+	result := content[1].(map[string]any)
+	if result["type"] != "text" || !strings.Contains(result["text"].(string), "withheld") {
+		t.Errorf("expected paired search result withheld, got %v", result)
+	}
+	if strings.Contains(string(raw), "evil.example.com/dump") {
+		t.Errorf("denylisted search result leaked to the client: %s", raw)
+	}
+}
+
 func TestAnthropicToolUseAllowed(t *testing.T) {
 	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -494,6 +866,49 @@ func TestAnthropicToolUseAllowed(t *testing.T) {
 	}
 }
 
+func TestAnthropicMalformedToolBlockFailsClosed(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		body := anthropicResponse([]any{
+			map[string]any{
+				"type":      "tool_call_v2",
+				"id":        "toolu_1",
+				"name":      "run_command",
+				"arguments": []any{"echo", "hello"},
+			},
+		}, "tool_use")
+		w.Write(body)
+	}))
+	defer upstream.Close()
+
+	srv, port := newTestInterceptor(t, upstream.URL)
+	cancel := startTestInterceptor(t, srv)
+	defer cancel()
+
+	client := interceptClient(port)
+	resp, err := client.Post(interceptURL(port, "/v1/messages"), "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var body map[string]any
+	json.NewDecoder(resp.Body).Decode(&body)
+
+	content := body["content"].([]any)
+	block := content[0].(map[string]any)
+	if block["type"] != "text" {
+		t.Fatalf("expected malformed tool block to be replaced with text, got %s", block["type"])
+	}
+	text, _ := block["text"].(string)
+	if !strings.Contains(text, "intercept.parse_error") {
+		t.Errorf("expected parse error policy in block message, got %s", text)
+	}
+	if body["stop_reason"] != "end_turn" {
+		t.Errorf("expected stop_reason=end_turn, got %v", body["stop_reason"])
+	}
+}
+
 func TestAnthropicMultipleToolCalls(t *testing.T) {
 	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -739,6 +1154,76 @@ func TestRequestHeadersForwarded(t *testing.T) {
 	}
 }
 
+func TestChunkedCompressedRequestForwarded(t *testing.T) {
+	payload := `{"model":"m","messages":[{"role":"user","content":"` + strings.Repeat("hello ", 500) + `"}]}`
+
+	type received struct {
+		encoding      string
+		contentLength int64
+		chunked       bool
+		body          string
+		err           error
+	}
+	got := make(chan received, 1)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := received{
+			encoding:      r.Header.Get("Content-Encoding"),
+			contentLength: r.ContentLength,
+			chunked:       len(r.TransferEncoding) > 0 && r.TransferEncoding[0] == "chunked",
+		}
+		zr, err := gzip.NewReader(r.Body)
+		if err == nil {
+			var data []byte
+			data, err = io.ReadAll(zr)
+			rec.body = string(data)
+		}
+		rec.err = err
+		got <- rec
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"content":[{"type":"text","text":"ok"}]}`))
+	}))
+	defer upstream.Close()
+
+	srv, port := newTestInterceptor(t, upstream.URL)
+	cancel := startTestInterceptor(t, srv)
+	defer cancel()
+
+	// io.Pipe has no known length, so the client sends the body chunked
+	pr, pw := io.Pipe()
+	go func() {
+		zw := gzip.NewWriter(pw)
+		zw.Write([]byte(payload))
+		zw.Close()
+		pw.Close()
+	}()
+
+	req, _ := http.NewRequest("POST", interceptURL(port, "/v1/messages"), pr)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Content-Encoding", "gzip")
+	resp, err := interceptClient(port).Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+
+	rec := <-got
+	if rec.err != nil {
+		t.Fatalf("upstream could not decode body: %v", rec.err)
+	}
+	if rec.encoding != "gzip" {
+		t.Errorf("expected Content-Encoding gzip forwarded, got %q", rec.encoding)
+	}
+	if rec.contentLength != -1 || !rec.chunked {
+		t.Errorf("expected chunked upstream body, got length=%d chunked=%v", rec.contentLength, rec.chunked)
+	}
+	if rec.body != payload {
+		t.Errorf("upstream body mismatch: got %d bytes, want %d", len(rec.body), len(payload))
+	}
+}
+
 func TestTraceRecordsInterceptedCalls(t *testing.T) {
 	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Content-Type", "application/json")
@@ -767,6 +1252,42 @@ func TestTraceRecordsInterceptedCalls(t *testing.T) {
 	}
 }
 
+func TestTraceSummaryStats(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(anthropicResponse([]any{
+			map[string]any{"type": "tool_use", "id": "t1", "name": "run_command", "input": map[string]any{"command": "ls"}},
+			map[string]any{"type": "tool_use", "id": "t2", "name": "run_command", "input": map[string]any{"command": "rm -rf /"}},
+		}, "tool_use"))
+	}))
+	defer upstream.Close()
+
+	srv, port := newTestInterceptor(t, upstream.URL)
+	cancel := startTestInterceptor(t, srv)
+	defer cancel()
+
+	resp, err := interceptClient(port).Post(interceptURL(port, "/v1/messages"), "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+
+	st, ok := srv.TraceSummary()["stats"].(tracer.Stats)
+	if !ok {
+		t.Fatal("expected stats in trace summary")
+	}
+	if st.ToolCalls != 2 || st.Blocked != 1 || st.Decisions["deny"] != 1 {
+		t.Errorf("unexpected stats: %+v", st)
+	}
+	cmd := st.Tools["command"]
+	if cmd == nil || cmd.Calls != 2 || cmd.Blocked != 1 {
+		t.Errorf("unexpected command stats: %+v", cmd)
+	}
+	if want := len(`{"command":"ls"}`); st.BytesForwarded != want {
+		t.Errorf("bytes forwarded = %d, want %d (allowed call arguments only)", st.BytesForwarded, want)
+	}
+}
+
 // --- Helpers ---
 
 func makeResult(decision, reason, policyID string) model.PolicyResult {
@@ -776,3 +1297,173 @@ func makeResult(decision, reason, policyID string) model.PolicyResult {
 		PolicyID: policyID,
 	}
 }
+
+func TestAgentHeaderAttributesInterceptedCalls(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(anthropicResponse([]any{
+			map[string]any{"type": "tool_use", "id": "t1", "name": "run_command", "input": map[string]any{"command": "ls"}},
+		}, "tool_use"))
+	}))
+	defer upstream.Close()
+
+	srv, port := newTestInterceptor(t, upstream.URL)
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	auditLog, err := audit.Open(auditPath)
+	if err != nil {
+		t.Fatalf("open audit log: %v", err)
+	}
+	srv.auditLog = auditLog
+	srv.cfg.AgentHeader = "X-Agent-ID"
+	cancel := startTestInterceptor(t, srv)
+	defer cancel()
+
+	client := interceptClient(port)
+	for _, agent := range []string{"agent-alpha", ""} {
+		req, _ := http.NewRequest(http.MethodPost, interceptURL(port, "/v1/messages"), strings.NewReader("{}"))
+		if agent != "" {
+			req.Header.Set("X-Agent-ID", agent)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+	}
+	srv.Close()
+
+	data, err := os.ReadFile(auditPath)
+	if err != nil {
+		t.Fatalf("read audit log: %v", err)
+	}
+	var agents []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var entry audit.AuditEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("parse audit line: %v", err)
+		}
+		agents = append(agents, entry.AgentID)
+	}
+	if len(agents) != 2 || agents[0] != "agent-alpha" || agents[1] != "" {
+		t.Errorf("expected audit agents [agent-alpha \"\"], got %q", agents)
+	}
+}
+
+func TestReloadPolicyKeepsConfigWhenRequiredFileMissing(t *testing.T) {
+	policyPath := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(policyPath, []byte("enforcement_mode: locked\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	srv, _ := newTestInterceptor(t, "http://127.0.0.1:1")
+	srv.cfg.PolicyPath = policyPath
+	srv.cfg.RequirePolicyFile = true
+	if err := srv.ReloadPolicy(); err != nil {
+		t.Fatalf("ReloadPolicy: %v", err)
+	}
+	before := srv.snapshot()
+
+	if err := os.Remove(policyPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.ReloadPolicy(); err == nil {
+		t.Fatal("expected reload to fail once the required policy file is gone")
+	}
+
+	after := srv.snapshot()
+	if after.Hash != before.Hash || after.Config.EnforcementMode != "locked" {
+		t.Errorf("expected locked policy %s to stay active, got %s mode %q",
+			before.Hash, after.Hash, after.Config.EnforcementMode)
+	}
+}
+
+func TestConfirmIrreversibleRejected(t *testing.T) {
+	policyPath := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(policyPath, []byte("confirm_irreversible:\n  enabled: true\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := NewServer(Config{Port: 0, Upstream: "http://127.0.0.1:1", PolicyPath: policyPath}); err == nil || !strings.Contains(err.Error(), "confirm_irreversible") {
+		t.Fatalf("expected NewServer to reject confirm_irreversible, got %v", err)
+	}
+
+	srv, _ := newTestInterceptor(t, "http://127.0.0.1:1")
+	before := srv.snapshot()
+	srv.cfg.PolicyPath = policyPath
+	if err := srv.ReloadPolicy(); err == nil || !strings.Contains(err.Error(), "confirm_irreversible") {
+		t.Fatalf("expected reload to reject confirm_irreversible, got %v", err)
+	}
+	if after := srv.snapshot(); after.Hash != before.Hash {
+		t.Errorf("expected running policy %s to stay active, got %s", before.Hash, after.Hash)
+	}
+}
+
+func TestReloadPolicyAppliesDenylist(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(anthropicResponse([]any{
+			map[string]any{"type": "tool_use", "id": "t1", "name": "run_command", "input": map[string]any{"command": "echo reload-marker"}},
+		}, "tool_use"))
+	}))
+	defer upstream.Close()
+
+	denylistPath := filepath.Join(t.TempDir(), "denylist.yaml")
+	if err := os.WriteFile(denylistPath, []byte("commands:\n  - \"unrelated-marker\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	srv, port := newTestInterceptor(t, upstream.URL)
+	srv.cfg.DenylistPath = denylistPath
+	if err := srv.ReloadPolicy(); err != nil {
+		t.Fatalf("ReloadPolicy: %v", err)
+	}
+	cancel := startTestInterceptor(t, srv)
+	defer cancel()
+
+	blockType := func() any {
+		t.Helper()
+		resp, err := interceptClient(port).Post(interceptURL(port, "/v1/messages"), "application/json", strings.NewReader("{}"))
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		defer resp.Body.Close()
+		var body map[string]any
+		json.NewDecoder(resp.Body).Decode(&body)
+		return body["content"].([]any)[0].(map[string]any)["type"]
+	}
+
+	if got := blockType(); got != "tool_use" {
+		t.Fatalf("expected tool call allowed before reload, got %v block", got)
+	}
+
+	if err := os.WriteFile(denylistPath, []byte("commands:\n  - \"reload-marker\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.ReloadPolicy(); err != nil {
+		t.Fatalf("ReloadPolicy: %v", err)
+	}
+
+	if got := blockType(); got != "text" {
+		t.Errorf("expected tool call blocked after reload, got %v block", got)
+	}
+	if st := srv.TraceSummary()["stats"].(tracer.Stats); st.ToolCalls != 2 {
+		t.Errorf("expected trace to survive reload with 2 calls, got %d", st.ToolCalls)
+	}
+}
+
+func TestEvaluatePanicDeniesAndReleasesLock(t *testing.T) {
+	srv, _ := newTestInterceptor(t, "http://127.0.0.1:1")
+	state := srv.tracer.State
+	srv.tracer.State = nil // every evaluation step dereferences the trace state
+	action := &model.Action{Tool: "command", Resource: "ls", Operation: "execute"}
+	result := srv.evaluate(action, agentIdentity{enf: srv.snapshot()})
+	srv.tracer.State = state
+
+	if result.Decision != model.Deny || result.PolicyID != "evaluation.panic" {
+		t.Fatalf("expected evaluation.panic deny, got %s (%s)", result.Decision, result.PolicyID)
+	}
+	if !srv.mu.TryLock() {
+		t.Fatal("panic left the trace lock held")
+	}
+	srv.mu.Unlock()
+}
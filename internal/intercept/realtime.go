@@ -0,0 +1,154 @@
+package intercept
+
+import (
+	"encoding/json"
+)
+
+// ExtractRealtimeToolCalls extracts function calls from an OpenAI Realtime
+// server event. Handled events:
+//
+//	response.function_call_arguments.done — {call_id, name, arguments}
+//	response.output_item.done             — item{type: function_call, ...}
+//	response.done                         — response.output[] function_call items
+//
+// Returns nil for any other event.
+func ExtractRealtimeToolCalls(event map[string]any) []ToolCall {
+	eventType, _ := event["type"].(string)
+
+	switch eventType {
+	case "response.function_call_arguments.done":
+		return []ToolCall{realtimeCall(event, 0)}
+
+	case "response.output_item.done":
+		item, ok := event["item"].(map[string]any)
+		if !ok || !isRealtimeFunctionCall(item) {
+			return nil
+		}
+		return []ToolCall{realtimeCall(item, 0)}
+
+	case "response.done":
+		resp, ok := event["response"].(map[string]any)
+		if !ok {
+			return nil
+		}
+		output, _ := resp["output"].([]any)
+		var calls []ToolCall
+		for i, o := range output {
+			item, ok := o.(map[string]any)
+			if !ok || !isRealtimeFunctionCall(item) {
+				continue
+			}
+			calls = append(calls, realtimeCall(item, i))
+		}
+		return calls
+	}
+
+	return nil
+}
+
+func isRealtimeFunctionCall(item map[string]any) bool {
+	t, _ := item["type"].(string)
+	return t == "function_call"
+}
+
+// realtimeCall builds a ToolCall from an object carrying call_id/name/arguments.
+func realtimeCall(obj map[string]any, index int) ToolCall {
+	tc := ToolCall{Index: index, Format: FormatRealtime}
+	tc.ID, _ = obj["call_id"].(string)
+	tc.Name, _ = obj["name"].(string)
+	if argsStr, ok := obj["arguments"].(string); ok && argsStr != "" {
+		var args map[string]any
+		if err := json.Unmarshal([]byte(argsStr), &args); err != nil {
+			tc.ParseError = "malformed tool arguments: " + err.Error()
+		} else {
+			tc.Arguments = args
+		}
+	}
+	return tc
+}
+
+// RewriteRealtimeEvent replaces blocked function calls in a Realtime event.
+// Blocked argument-done events become error events; blocked function_call
+// items become assistant message items carrying the block explanation.
+// Returns the re-encoded event and whether anything changed.
+func RewriteRealtimeEvent(event map[string]any, results []EvalResult) ([]byte, bool) {
+	blocked := make(map[int]EvalResult)
+	for _, er := range results {
+		if !isAllowed(er.Result) {
+			blocked[er.Call.Index] = er
+		}
+	}
+	if len(blocked) == 0 {
+		out, _ := json.Marshal(event)
+		return out, false
+	}
+
+	eventType, _ := event["type"].(string)
+	changed := false
+
+	switch eventType {
+	case "response.function_call_arguments.done":
+		er := blocked[0]
+		replacement := map[string]any{
+			"type": "error",
+			"error": map[string]any{
+				"type":    "invalid_request_error",
+				"code":    "chainwatch_blocked",
+				"message": blockMessage(er.Call, er.Result),
+				"call_id": er.Call.ID,
+			},
+		}
+		if id, ok := event["event_id"]; ok {
+			replacement["event_id"] = id
+		}
+		event = replacement
+		changed = true
+
+	case "response.output_item.done":
+		if item, ok := event["item"].(map[string]any); ok {
+			event["item"] = realtimeBlockItem(item, blocked[0])
+			changed = true
+		}
+
+	case "response.done":
+		if resp, ok := event["response"].(map[string]any); ok {
+			if output, ok := resp["output"].([]any); ok {
+				for i, o := range output {
+					er, isBlocked := blocked[i]
+					item, ok := o.(map[string]any)
+					if !isBlocked || !ok {
+						continue
+					}
+					output[i] = realtimeBlockItem(item, er)
+					changed = true
+				}
+				resp["output"] = output
+			}
+		}
+	}
+
+	out, _ := json.Marshal(event)
+	return out, changed
+}
+
+// realtimeBlockItem converts a blocked function_call item into an assistant text message.
+func realtimeBlockItem(item map[string]any, er EvalResult) map[string]any {
+	replacement := map[string]any{
+		"type":   "message",
+		"role":   "assistant",
+		"status": "completed",
+		"content": []any{
+			map[string]any{
+				"type": "text",
+				"text": blockMessage(er.Call, er.Result),
+			},
+		},
+	}
+	if id, ok := item["id"]; ok {
+		replacement["id"] = id
+	}
+	if obj, ok := item["object"]; ok {
+		replacement["object"] = obj
+	}
+	return replacement
+}
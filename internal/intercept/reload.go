@@ -0,0 +1,67 @@
+package intercept
+
+import (
+	"fmt"
+
+	"github.com/ppiankov/chainwatch/internal/denylist"
+	"github.com/ppiankov/chainwatch/internal/policy"
+	"github.com/ppiankov/chainwatch/internal/profile"
+)
+
+// enforcement is a snapshot of the reloadable policy state. A request
+// takes one when it arrives and keeps it to the end, so a reload never
+// mixes old and new config within a response.
+type enforcement = policy.Enforcement
+
+// snapshot returns the current policy state.
+func (s *Server) snapshot() *enforcement {
+	return s.live.Snapshot()
+}
+
+// policyFor returns the request's policy snapshot, or the current policy
+// when the request has none.
+func (s *Server) policyFor(who agentIdentity) *enforcement {
+	if who.enf != nil {
+		return who.enf
+	}
+	return s.snapshot()
+}
+
+// loadPolicy returns the loader for the configured denylist, policy, and
+// profile. It refuses confirm_irreversible, which intercept cannot honor.
+func loadPolicy(cfg Config) policy.Loader {
+	return func() (*denylist.Denylist, *policy.PolicyConfig, string, error) {
+		dl, policyCfg, policyHash, err := profile.LoadPolicy(cfg.DenylistPath, cfg.PolicyPath, cfg.ProfileName, cfg.RequirePolicyFile)
+		if err != nil {
+			return nil, nil, "", err
+		}
+		if err := rejectConfirmIrreversible(policyCfg); err != nil {
+			return nil, nil, "", err
+		}
+		return dl, policyCfg, policyHash, nil
+	}
+}
+
+// rejectConfirmIrreversible refuses confirm_irreversible: tool calls come
+// from the model, so an approved call has no retry that could present a
+// confirm token, and every such call would be blocked.
+func rejectConfirmIrreversible(cfg *policy.PolicyConfig) error {
+	if cfg.ConfirmIrreversible != nil && cfg.ConfirmIrreversible.Enabled {
+		return fmt.Errorf("confirm_irreversible is not supported by chainwatch intercept: tool calls cannot carry a confirm token")
+	}
+	return nil
+}
+
+// ReloadPolicy re-reads the denylist, policy, and profile from their
+// configured paths and swaps them in atomically. In-flight requests finish
+// on the config they started with; trace state, approvals, and open
+// streams are kept. On error the running config is left unchanged.
+func (s *Server) ReloadPolicy() error {
+	enf, err := s.live.Reload(loadPolicy(s.cfg))
+	if err != nil {
+		return fmt.Errorf("failed to reload policy: %w", err)
+	}
+	s.approvals.SetThrottle(enf.Config.ApprovalThrottle)
+	s.approvals.SetReasonRequired(enf.Config.ReasonRequiredKeys())
+	return nil
+}
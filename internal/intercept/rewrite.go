@@ -14,16 +14,37 @@ type EvalResult struct {
 	Result model.PolicyResult
 }
 
+// RewriteOptions tunes how blocked tool calls are rewritten.
+type RewriteOptions struct {
+	// ToolResults additionally attaches a synthetic role "tool" message per
+	// blocked OpenAI tool call, with the matching tool_call_id and the block
+	// reason as content, under choices[0].tool_messages, for agent
+	// frameworks that append these messages to history. Blocked calls are
+	// still removed from the assistant message either way.
+	ToolResults bool
+}
+
 // RewriteResponse applies evaluation results to the response body.
 // For each blocked tool call, replaces it with a text explanation.
 // Returns the modified JSON bytes and whether any changes were made.
 func RewriteResponse(body map[string]any, results []EvalResult, format LLMFormat) ([]byte, bool) {
+	return RewriteResponseWithOptions(body, results, format, RewriteOptions{})
+}
+
+// RewriteResponseWithOptions is RewriteResponse with explicit options.
+func RewriteResponseWithOptions(body map[string]any, results []EvalResult, format LLMFormat, opts RewriteOptions) ([]byte, bool) {
 	var changed bool
 	switch format {
 	case FormatAnthropic:
 		changed = rewriteAnthropic(body, results)
 	case FormatOpenAI:
-		changed = rewriteOpenAI(body, results)
+		if opts.ToolResults {
+			changed = attachOpenAIToolResults(body, results)
+		} else {
+			changed = rewriteOpenAI(body, results)
+		}
+	case FormatGemini:
+		changed = RewriteGemini(body, results)
 	}
 
 	if !changed {
@@ -36,6 +57,8 @@ func RewriteResponse(body map[string]any, results []EvalResult, format LLMFormat
 }
 
 // rewriteAnthropic replaces blocked tool_use content blocks with text blocks.
+// The result block paired with a blocked server_tool_use is withheld too,
+// since the provider already ran the tool.
 func rewriteAnthropic(body map[string]any, results []EvalResult) bool {
 	content, ok := body["content"].([]any)
 	if !ok {
@@ -44,6 +67,7 @@ func rewriteAnthropic(body map[string]any, results []EvalResult) bool {
 
 	changed := false
 	allBlocked := true
+	blockedServer := make(map[string]ToolCall)
 
 	for _, er := range results {
 		if er.Result.Decision == model.Allow || er.Result.Decision == model.AllowWithRedaction {
@@ -59,6 +83,19 @@ func rewriteAnthropic(body map[string]any, results []EvalResult) bool {
 			}
 			changed = true
 		}
+		if er.Call.Server && er.Call.ID != "" {
+			blockedServer[er.Call.ID] = er.Call
+		}
+	}
+
+	for i, item := range content {
+		if tc, ok := pairedServerCall(item, blockedServer); ok {
+			content[i] = map[string]any{
+				"type": "text",
+				"text": withheldResultMessage(tc),
+			}
+			changed = true
+		}
 	}
 
 	if changed {
@@ -74,6 +111,30 @@ func rewriteAnthropic(body map[string]any, results []EvalResult) bool {
 	return changed
 }
 
+// pairedServerCall reports whether a content block is the *_tool_result of
+// one of the blocked server tool calls, keyed by tool_use_id.
+func pairedServerCall(item any, blocked map[string]ToolCall) (ToolCall, bool) {
+	if len(blocked) == 0 {
+		return ToolCall{}, false
+	}
+	block, ok := item.(map[string]any)
+	if !ok {
+		return ToolCall{}, false
+	}
+	blockType, _ := block["type"].(string)
+	if !strings.HasSuffix(blockType, "tool_result") {
+		return ToolCall{}, false
+	}
+	id, _ := block["tool_use_id"].(string)
+	tc, ok := blocked[id]
+	return tc, ok
+}
+
+// withheldResultMessage replaces the result of a blocked server tool call.
+func withheldResultMessage(tc ToolCall) string {
+	return fmt.Sprintf("[BLOCKED by chainwatch] Result of server tool '%s' withheld: the call was denied by policy", tc.Name)
+}
+
 // rewriteOpenAI removes blocked tool_call entries from choices[0].message.tool_calls.
 func rewriteOpenAI(body map[string]any, results []EvalResult) bool {
 	choices, ok := body["choices"].([]any)
@@ -137,6 +198,35 @@ func rewriteOpenAI(body map[string]any, results []EvalResult) bool {
 	return true
 }
 
+// attachOpenAIToolResults removes blocked calls as rewriteOpenAI does, then
+// adds a synthetic tool message for each of them, so an agent's tool loop
+// can pair the block with the call it issued. The blocked calls never
+// reach the client in executable form.
+func attachOpenAIToolResults(body map[string]any, results []EvalResult) bool {
+	if !rewriteOpenAI(body, results) {
+		return false
+	}
+	choices := body["choices"].([]any)
+	choice := choices[0].(map[string]any)
+
+	var toolMessages []any
+	for _, er := range results {
+		if er.Result.Decision == model.Allow || er.Result.Decision == model.AllowWithRedaction {
+			continue
+		}
+		toolMessages = append(toolMessages, map[string]any{
+			"role":         "tool",
+			"tool_call_id": er.Call.ID,
+			"content":      blockMessage(er.Call, er.Result),
+		})
+	}
+
+	choice["tool_messages"] = toolMessages
+	choices[0] = choice
+	body["choices"] = choices
+	return true
+}
+
 // blockMessage formats the human-readable block explanation.
 func blockMessage(tc ToolCall, result model.PolicyResult) string {
 	msg := fmt.Sprintf("[BLOCKED by chainwatch] Tool '%s' denied: %s", tc.Name, result.Reason)
@@ -146,6 +236,9 @@ func blockMessage(tc ToolCall, result model.PolicyResult) string {
 	if result.ApprovalKey != "" {
 		msg += fmt.Sprintf(" (approval_key=%s)", result.ApprovalKey)
 	}
+	if result.Suggestion != "" {
+		msg += fmt.Sprintf(". Suggested alternative: %s", result.Suggestion)
+	}
 	return msg
 }
 
@@ -193,11 +286,43 @@ func RewriteOpenAISSEFinish() string {
 	return "data: " + string(data) + "\n"
 }
 
+// RewriteAnthropicMessageDelta flips the stop_reason of a streamed
+// message_delta event from tool_use to end_turn, as rewriteAnthropic does
+// for non-streaming responses when every tool call was blocked. The rest
+// of the event, including usage, is kept. Returns the replacement data
+// line and whether the event was changed.
+func RewriteAnthropicMessageDelta(event map[string]any) (string, bool) {
+	delta, ok := event["delta"].(map[string]any)
+	if !ok {
+		return "", false
+	}
+	if sr, _ := delta["stop_reason"].(string); sr != "tool_use" {
+		return "", false
+	}
+	delta["stop_reason"] = "end_turn"
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return "", false
+	}
+	return "data: " + string(data), true
+}
+
 // RewriteAnthropicSSE generates SSE events that replace a blocked tool_use block
 // with a text content block in streaming format.
 func RewriteAnthropicSSE(index int, tc ToolCall, result model.PolicyResult) []string {
-	msg := blockMessage(tc, result)
+	return anthropicTextBlockSSE(index, blockMessage(tc, result))
+}
+
+// RewriteAnthropicResultSSE generates SSE events that replace the result
+// block of a blocked server tool call with a text content block.
+func RewriteAnthropicResultSSE(index int, tc ToolCall) []string {
+	return anthropicTextBlockSSE(index, withheldResultMessage(tc))
+}
 
+// anthropicTextBlockSSE renders a complete text content block at index as
+// start, delta, and stop SSE events.
+func anthropicTextBlockSSE(index int, msg string) []string {
 	startData, _ := json.Marshal(map[string]any{
 		"type":  "content_block_start",
 		"index": index,
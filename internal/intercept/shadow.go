@@ -0,0 +1,305 @@
+package intercept
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/ppiankov/chainwatch/internal/audit"
+	"github.com/ppiankov/chainwatch/internal/model"
+	"github.com/ppiankov/chainwatch/internal/policy"
+)
+
+const (
+	// shadowTimeout bounds one shadow request, independent of the client.
+	shadowTimeout = 2 * time.Minute
+	// maxShadowBody is the largest request body mirrored to the shadow;
+	// larger requests go to the primary only.
+	maxShadowBody = 10 << 20
+	// maxShadowRecords bounds the comparisons kept in the trace summary.
+	maxShadowRecords = 100
+)
+
+// ProposedCall is a tool call an upstream proposed and chainwatch's
+// verdict on it.
+type ProposedCall struct {
+	Name     string `json:"name"`
+	Resource string `json:"resource"`
+	Decision string `json:"decision"`
+	Blocked  bool   `json:"blocked"`
+}
+
+// ShadowComparison records the tool calls the primary and shadow
+// upstreams proposed for one request.
+type ShadowComparison struct {
+	Timestamp string         `json:"ts"`
+	AgentID   string         `json:"agent_id,omitempty"`
+	Path      string         `json:"path"`
+	Primary   []ProposedCall `json:"primary"`
+	Shadow    []ProposedCall `json:"shadow"`
+	Match     bool           `json:"match"`
+	Error     string         `json:"error,omitempty"`
+}
+
+// proposals collects the primary's evaluated tool calls for one request.
+type proposals struct {
+	mu    sync.Mutex
+	calls []ProposedCall
+}
+
+func (p *proposals) add(action *model.Action, name string, result model.PolicyResult) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.calls = append(p.calls, proposedCall(action, name, result))
+}
+
+func (p *proposals) list() []ProposedCall {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]ProposedCall(nil), p.calls...)
+}
+
+func proposedCall(action *model.Action, name string, result model.PolicyResult) ProposedCall {
+	return ProposedCall{
+		Name:     name,
+		Resource: action.Resource,
+		Decision: string(result.Decision),
+		Blocked:  !isAllowed(result),
+	}
+}
+
+// bufferShadowBody reads the request body so it can be sent to both
+// upstreams, restoring r.Body for the primary. Bodies over maxShadowBody
+// are not mirrored: ok is false and r.Body still yields the full body.
+func bufferShadowBody(r *http.Request) ([]byte, bool) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return nil, true
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxShadowBody+1))
+	rest := r.Body
+	r.Body = readCloser{io.MultiReader(bytes.NewReader(body), rest), rest}
+	if err != nil || len(body) > maxShadowBody {
+		return nil, false
+	}
+	return body, true
+}
+
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// shadowCredentialHeaders are never copied from the client to the shadow
+// upstream: the primary's credentials must not leak to a second provider.
+// Credentials for the shadow come only from Config.ShadowHeaders.
+var shadowCredentialHeaders = []string{
+	"Authorization",
+	"Proxy-Authorization",
+	"X-Api-Key",
+	"Api-Key",
+	"Cookie",
+}
+
+// shadowRequest mirrors the client's request to the shadow upstream. The
+// request context is detached from the client so a slow or failing
+// shadow never affects the primary response. Streaming is turned off in
+// the mirrored body so the shadow's tool calls arrive as one JSON reply.
+func (s *Server) shadowRequest(r *http.Request, body []byte) (*http.Request, context.CancelFunc, error) {
+	outURL := *s.shadow
+	outURL.Path = r.URL.Path
+	outURL.RawQuery = r.URL.RawQuery
+
+	if r.Header.Get("Content-Encoding") == "" {
+		body = disableStreaming(body)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shadowTimeout)
+	req, err := http.NewRequestWithContext(ctx, r.Method, outURL.String(), bytes.NewReader(body))
+	if err != nil {
+		cancel()
+		return nil, nil, err
+	}
+	for k, vv := range r.Header {
+		for _, v := range vv {
+			req.Header.Add(k, v)
+		}
+	}
+	req.Header.Del("Content-Length")
+	req.Header.Del("Transfer-Encoding")
+	req.Header.Del("Accept-Encoding")
+	for _, h := range shadowCredentialHeaders {
+		req.Header.Del(h)
+	}
+	req.Host = s.shadow.Host
+	for k, v := range s.cfg.ShadowHeaders {
+		req.Header.Set(k, v)
+	}
+	return req, cancel, nil
+}
+
+// disableStreaming sets "stream": false in a JSON request body. Non-JSON
+// bodies are returned unchanged.
+func disableStreaming(body []byte) []byte {
+	var m map[string]any
+	if err := json.Unmarshal(body, &m); err != nil {
+		return body
+	}
+	if stream, _ := m["stream"].(bool); !stream {
+		return body
+	}
+	m["stream"] = false
+	out, err := json.Marshal(m)
+	if err != nil {
+		return body
+	}
+	return out
+}
+
+// startShadow sends the mirrored request in the background. Once the
+// primary has been handled (primaryDone closed), the shadow's tool calls
+// are evaluated and compared with the primary's.
+func (s *Server) startShadow(r *http.Request, body []byte, who agentIdentity, primaryDone <-chan struct{}) {
+	req, cancel, err := s.shadowRequest(r, body)
+	path := r.URL.Path
+
+	s.shadowWG.Add(1)
+	go func() {
+		defer s.shadowWG.Done()
+
+		var calls []ProposedCall
+		if err == nil {
+			defer cancel()
+			calls, err = s.runShadow(req, who)
+		}
+		<-primaryDone
+
+		cmp := ShadowComparison{
+			Timestamp: time.Now().UTC().Format("2006-01-02T15:04:05.000Z"),
+			AgentID:   who.id,
+			Path:      path,
+			Primary:   who.proposed.list(),
+			Shadow:    calls,
+		}
+		if err != nil {
+			cmp.Error = err.Error()
+		} else {
+			cmp.Match = sameCalls(cmp.Primary, cmp.Shadow)
+		}
+		s.recordShadow(cmp)
+	}()
+}
+
+// runShadow performs the shadow request and returns the tool calls it
+// proposed, each with the decision chainwatch would have made. The verdict
+// comes from policy and denylist on a copy of the trace state; decision
+// hooks and enrichment are not called and nothing is recorded in the
+// trace.
+func (s *Server) runShadow(req *http.Request, who agentIdentity) ([]ProposedCall, error) {
+	resp, err := s.shadowTransport.RoundTrip(req)
+	if err != nil {
+		return nil, fmt.Errorf("shadow upstream: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 10<<20))
+	if err != nil {
+		return nil, fmt.Errorf("shadow upstream: %w", err)
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("shadow upstream returned HTTP %d", resp.StatusCode)
+	}
+	var bodyMap map[string]any
+	if err := json.Unmarshal(body, &bodyMap); err != nil {
+		return nil, fmt.Errorf("shadow upstream returned a non-JSON response")
+	}
+
+	toolCalls, _ := ExtractToolCalls(bodyMap)
+	enf := s.policyFor(who)
+
+	s.mu.Lock()
+	state := s.tracer.State.Clone()
+	traceID := s.tracer.State.TraceID
+	s.mu.Unlock()
+
+	calls := make([]ProposedCall, 0, len(toolCalls))
+	for _, tc := range toolCalls {
+		action := buildActionFromToolCall(tc, s.paths)
+		result := policy.Evaluate(action, state, s.cfg.Purpose, who.id, enf.Denylist, enf.Config)
+		if tc.ParseError != "" {
+			result = model.PolicyResult{Decision: model.Deny, Reason: "unparseable tool call: " + tc.ParseError, PolicyID: "intercept.parse_error"}
+		} else if malformed := malformedToolCall(tc, s.paths); malformed != "" {
+			result = model.PolicyResult{Decision: model.Deny, Reason: "malformed_tool_call: " + malformed, PolicyID: "intercept.malformed_tool_call"}
+		}
+		calls = append(calls, proposedCall(action, tc.Name, result))
+
+		if s.auditLog != nil {
+			s.auditLog.Record(audit.AuditEntry{
+				Timestamp:  time.Now().UTC().Format("2006-01-02T15:04:05.000Z"),
+				TraceID:    traceID,
+				AgentID:    who.id,
+				Action:     audit.AuditAction{Tool: action.Tool, Resource: action.Resource, RawResource: action.RawResource, Labels: action.Labels, Fingerprint: action.Fingerprint()},
+				Decision:   string(result.Decision),
+				Reason:     "shadow upstream proposal: " + result.Reason,
+				Tier:       result.Tier,
+				PolicyHash: enf.Hash,
+				Type:       "shadow_tool_call",
+			})
+		}
+	}
+	return calls, nil
+}
+
+// sameCalls reports whether both upstreams proposed the same tool names
+// and resources, ignoring order.
+func sameCalls(a, b []ProposedCall) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	key := func(calls []ProposedCall) []string {
+		out := make([]string, len(calls))
+		for i, c := range calls {
+			out[i] = c.Name + "\x00" + c.Resource
+		}
+		sort.Strings(out)
+		return out
+	}
+	ka, kb := key(a), key(b)
+	for i := range ka {
+		if ka[i] != kb[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *Server) recordShadow(cmp ShadowComparison) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.shadowLog = append(s.shadowLog, cmp)
+	if len(s.shadowLog) > maxShadowRecords {
+		s.shadowLog = s.shadowLog[len(s.shadowLog)-maxShadowRecords:]
+	}
+}
+
+// parseShadowUpstream validates Config.ShadowUpstream. Empty disables
+// shadowing.
+func parseShadowUpstream(raw string) (*url.URL, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, fmt.Errorf("invalid shadow upstream URL %q", raw)
+	}
+	return u, nil
+}
@@ -0,0 +1,206 @@
+package intercept
+
+import (
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ppiankov/chainwatch/internal/audit"
+	"github.com/ppiankov/chainwatch/internal/tracer"
+)
+
+func newShadowInterceptor(t *testing.T, upstreamURL, shadowURL string) (*Server, int) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to find free port: %v", err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	srv, err := NewServer(Config{
+		Port:           port,
+		Upstream:       upstreamURL,
+		Purpose:        "test",
+		Actor:          map[string]any{"test": true},
+		ShadowUpstream: shadowURL,
+		ShadowHeaders:  map[string]string{"Authorization": "Bearer shadow-key"},
+	})
+	if err != nil {
+		t.Fatalf("failed to create interceptor: %v", err)
+	}
+	return srv, port
+}
+
+func TestShadowUpstreamRecordsProposals(t *testing.T) {
+	primaryBody := anthropicResponse([]any{
+		map[string]any{"type": "tool_use", "id": "t1", "name": "run_command", "input": map[string]any{"command": "ls"}},
+	}, "tool_use")
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(primaryBody)
+	}))
+	defer upstream.Close()
+
+	shadowReqs := make(chan map[string]any, 1)
+	var shadowAuth, shadowAPIKey string
+	shadow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		shadowAuth = r.Header.Get("Authorization")
+		shadowAPIKey = r.Header.Get("X-Api-Key")
+		var req map[string]any
+		json.NewDecoder(r.Body).Decode(&req)
+		shadowReqs <- req
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(anthropicResponse([]any{
+			map[string]any{"type": "tool_use", "id": "s1", "name": "run_command", "input": map[string]any{"command": "rm -rf /"}},
+		}, "tool_use"))
+	}))
+	defer shadow.Close()
+
+	srv, port := newShadowInterceptor(t, upstream.URL, shadow.URL)
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	auditLog, err := audit.Open(auditPath)
+	if err != nil {
+		t.Fatalf("open audit log: %v", err)
+	}
+	srv.auditLog = auditLog
+	cancel := startTestInterceptor(t, srv)
+	defer cancel()
+
+	req, _ := http.NewRequest(http.MethodPost, interceptURL(port, "/v1/messages"), strings.NewReader(`{"model":"m","stream":true}`))
+	req.Header.Set("Authorization", "Bearer primary-key")
+	req.Header.Set("X-Api-Key", "primary-api-key")
+	resp, err := interceptClient(port).Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	got, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if string(got) != string(primaryBody) {
+		t.Errorf("client should see only the primary response, got %s", got)
+	}
+	srv.Close()
+
+	mirrored := <-shadowReqs
+	if mirrored["stream"] != false || mirrored["model"] != "m" {
+		t.Errorf("expected mirrored body with streaming off, got %v", mirrored)
+	}
+	if shadowAuth != "Bearer shadow-key" {
+		t.Errorf("expected shadow Authorization override, got %q", shadowAuth)
+	}
+	if shadowAPIKey != "" {
+		t.Errorf("primary x-api-key leaked to shadow: %q", shadowAPIKey)
+	}
+
+	summary := srv.TraceSummary()
+	cmps, _ := summary["shadow"].([]ShadowComparison)
+	if len(cmps) != 1 {
+		t.Fatalf("expected 1 shadow comparison, got %d", len(cmps))
+	}
+	cmp := cmps[0]
+	if cmp.Match || cmp.Error != "" {
+		t.Errorf("expected a mismatch without error, got %+v", cmp)
+	}
+	if len(cmp.Primary) != 1 || cmp.Primary[0].Resource != "ls" || cmp.Primary[0].Blocked {
+		t.Errorf("unexpected primary proposals: %+v", cmp.Primary)
+	}
+	if len(cmp.Shadow) != 1 || cmp.Shadow[0].Resource != "rm -rf /" || cmp.Shadow[0].Decision != "deny" {
+		t.Errorf("unexpected shadow proposals: %+v", cmp.Shadow)
+	}
+
+	// Shadow calls are audited but never enter the trace.
+	if st := summary["stats"].(tracer.Stats); st.ToolCalls != 1 {
+		t.Errorf("expected only the primary call in the trace, got %d", st.ToolCalls)
+	}
+	data, err := os.ReadFile(auditPath)
+	if err != nil {
+		t.Fatalf("read audit log: %v", err)
+	}
+	var shadowEntries int
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var entry audit.AuditEntry
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("parse audit line: %v", err)
+		}
+		if entry.Type == "shadow_tool_call" {
+			shadowEntries++
+			if entry.Action.Resource != "rm -rf /" || entry.Decision != "deny" {
+				t.Errorf("unexpected shadow audit entry: %+v", entry)
+			}
+		}
+	}
+	if shadowEntries != 1 {
+		t.Errorf("expected 1 shadow audit entry, got %d", shadowEntries)
+	}
+}
+
+func TestShadowUpstreamFailureDoesNotAffectClient(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write(anthropicResponse([]any{
+			map[string]any{"type": "text", "text": "hello"},
+		}, "end_turn"))
+	}))
+	defer upstream.Close()
+
+	shadow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "overloaded", http.StatusServiceUnavailable)
+	}))
+	defer shadow.Close()
+
+	srv, port := newShadowInterceptor(t, upstream.URL, shadow.URL)
+	cancel := startTestInterceptor(t, srv)
+	defer cancel()
+
+	resp, err := interceptClient(port).Post(interceptURL(port, "/v1/messages"), "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("expected 200 from primary, got %d", resp.StatusCode)
+	}
+	srv.Close()
+
+	cmps, _ := srv.TraceSummary()["shadow"].([]ShadowComparison)
+	if len(cmps) != 1 || !strings.Contains(cmps[0].Error, "503") {
+		t.Errorf("expected a recorded shadow error, got %+v", cmps)
+	}
+}
+
+func TestNewServerRejectsInvalidShadowUpstream(t *testing.T) {
+	if _, err := NewServer(Config{Upstream: "http://127.0.0.1:1", ShadowUpstream: "not a url"}); err == nil {
+		t.Error("expected error for invalid shadow upstream")
+	}
+}
+
+func TestShadowRequestStripsClientCredentials(t *testing.T) {
+	srv, _ := newShadowInterceptor(t, "http://127.0.0.1:1", "http://127.0.0.1:2")
+	srv.cfg.ShadowHeaders = nil
+
+	r := httptest.NewRequest(http.MethodPost, "/v1/messages", nil)
+	r.Header.Set("Authorization", "Bearer primary-key")
+	r.Header.Set("X-Api-Key", "primary-api-key")
+	r.Header.Set("Cookie", "session=1")
+	r.Header.Set("Anthropic-Version", "2023-06-01")
+	req, cancel, err := srv.shadowRequest(r, []byte(`{}`))
+	if err != nil {
+		t.Fatalf("shadowRequest: %v", err)
+	}
+	defer cancel()
+
+	for _, h := range []string{"Authorization", "X-Api-Key", "Cookie"} {
+		if v := req.Header.Get(h); v != "" {
+			t.Errorf("%s mirrored to shadow: %q", h, v)
+		}
+	}
+	if req.Header.Get("Anthropic-Version") != "2023-06-01" {
+		t.Error("expected non-credential headers to be mirrored")
+	}
+}
@@ -0,0 +1,82 @@
+package intercept
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+)
+
+// maxKillSwitchBody caps the request body buffered for the tool kill
+// switch. Larger bodies are refused rather than forwarded unrewritten.
+const maxKillSwitchBody = 10 << 20 // 10MB
+
+// toolsDisabled reports whether the tool kill switch covers an agent.
+func (s *Server) toolsDisabled(who agentIdentity) bool {
+	if !s.cfg.DisableTools {
+		return false
+	}
+	return len(s.cfg.DisableToolsAgents) == 0 || slices.Contains(s.cfg.DisableToolsAgents, who.id)
+}
+
+// disableToolsInRequest rewrites r's body so the model cannot emit tool
+// calls. It returns an HTTP status and error when the body cannot be
+// rewritten; the request must then be refused, not forwarded.
+func disableToolsInRequest(r *http.Request) (int, error) {
+	if r.Body == nil || r.Body == http.NoBody {
+		return 0, nil
+	}
+	if r.Header.Get("Content-Encoding") != "" {
+		return http.StatusUnsupportedMediaType, fmt.Errorf("tools disabled: compressed request bodies cannot be rewritten")
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxKillSwitchBody+1))
+	_ = r.Body.Close()
+	if err != nil {
+		return http.StatusBadRequest, fmt.Errorf("tools disabled: read request: %w", err)
+	}
+	if len(body) > maxKillSwitchBody {
+		return http.StatusRequestEntityTooLarge, fmt.Errorf("tools disabled: request body exceeds %d bytes", maxKillSwitchBody)
+	}
+
+	body = disableToolChoice(body, DetectStreamingFormat(r.URL.Path, r.Header))
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	r.ContentLength = int64(len(body))
+	return 0, nil
+}
+
+// disableToolChoice forces tool_choice to "none" in a JSON request body
+// that declares tools: {"type": "none"} for Anthropic, "none" otherwise
+// (OpenAI chat and responses). Legacy OpenAI functions get
+// function_call "none". Bodies without tools, or that are not JSON, are
+// returned unchanged — there is nothing for the model to call.
+func disableToolChoice(body []byte, format LLMFormat) []byte {
+	var m map[string]any
+	if err := json.Unmarshal(body, &m); err != nil {
+		return body
+	}
+
+	changed := false
+	if tools, _ := m["tools"].([]any); len(tools) > 0 {
+		if format == FormatAnthropic {
+			m["tool_choice"] = map[string]any{"type": "none"}
+		} else {
+			m["tool_choice"] = "none"
+		}
+		changed = true
+	}
+	if functions, _ := m["functions"].([]any); len(functions) > 0 {
+		m["function_call"] = "none"
+		changed = true
+	}
+	if !changed {
+		return body
+	}
+
+	out, err := json.Marshal(m)
+	if err != nil {
+		return body
+	}
+	return out
+}
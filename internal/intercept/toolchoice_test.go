@@ -0,0 +1,129 @@
+package intercept
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// forwardedBody starts an interceptor with the tool kill switch on and
+// returns the JSON body the upstream received for a request.
+func forwardedBody(t *testing.T, agents []string, path, agent, body string) map[string]any {
+	t.Helper()
+	got := make(chan []byte, 1)
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		data, _ := io.ReadAll(r.Body)
+		got <- data
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"content":[{"type":"text","text":"ok"}]}`))
+	}))
+	defer upstream.Close()
+
+	srv, port := newTestInterceptor(t, upstream.URL)
+	srv.cfg.DisableTools = true
+	srv.cfg.DisableToolsAgents = agents
+	srv.cfg.AgentHeader = "X-Agent-ID"
+	cancel := startTestInterceptor(t, srv)
+	defer cancel()
+
+	req, _ := http.NewRequest("POST", interceptURL(port, path), strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if agent != "" {
+		req.Header.Set("X-Agent-ID", agent)
+	}
+	resp, err := interceptClient(port).Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+
+	var m map[string]any
+	if err := json.Unmarshal(<-got, &m); err != nil {
+		t.Fatalf("upstream body is not JSON: %v", err)
+	}
+	return m
+}
+
+func TestKillSwitchAnthropicToolChoiceNone(t *testing.T) {
+	m := forwardedBody(t, nil, "/v1/messages", "",
+		`{"model":"claude","tools":[{"name":"run_command"}],"tool_choice":{"type":"any"},"messages":[]}`)
+
+	choice, _ := m["tool_choice"].(map[string]any)
+	if choice["type"] != "none" {
+		t.Errorf("expected tool_choice {type: none}, got %v", m["tool_choice"])
+	}
+	if m["model"] != "claude" {
+		t.Errorf("expected other fields preserved, got %v", m)
+	}
+}
+
+func TestKillSwitchOpenAIToolChoiceNone(t *testing.T) {
+	m := forwardedBody(t, nil, "/v1/chat/completions", "",
+		`{"model":"gpt-4","tools":[{"type":"function","function":{"name":"run_command"}}],"tool_choice":"required"}`)
+
+	if m["tool_choice"] != "none" {
+		t.Errorf("expected tool_choice none, got %v", m["tool_choice"])
+	}
+}
+
+func TestKillSwitchScopedToAgents(t *testing.T) {
+	body := `{"model":"gpt-4","tools":[{"type":"function","function":{"name":"run_command"}}],"tool_choice":"auto"}`
+
+	if m := forwardedBody(t, []string{"incident-bot"}, "/v1/chat/completions", "other-bot", body); m["tool_choice"] != "auto" {
+		t.Errorf("expected other agents untouched, got tool_choice %v", m["tool_choice"])
+	}
+	if m := forwardedBody(t, []string{"incident-bot"}, "/v1/chat/completions", "incident-bot", body); m["tool_choice"] != "none" {
+		t.Errorf("expected listed agent disabled, got tool_choice %v", m["tool_choice"])
+	}
+}
+
+func TestDisableToolChoice(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		format LLMFormat
+		want   string
+	}{
+		{"no tools unchanged", `{"model":"m","messages":[]}`, FormatOpenAI, `{"model":"m","messages":[]}`},
+		{"empty tools unchanged", `{"tools":[]}`, FormatAnthropic, `{"tools":[]}`},
+		{"not json unchanged", `not json`, FormatOpenAI, `not json`},
+		{"legacy functions", `{"functions":[{"name":"f"}]}`, FormatOpenAI, `{"function_call":"none","functions":[{"name":"f"}]}`},
+		{"unknown format uses openai form", `{"tools":[{"type":"function"}]}`, FormatUnknown, `{"tool_choice":"none","tools":[{"type":"function"}]}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(disableToolChoice([]byte(tt.body), tt.format)); got != tt.want {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKillSwitchRefusesCompressedBody(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("compressed body should not reach upstream")
+	}))
+	defer upstream.Close()
+
+	srv, port := newTestInterceptor(t, upstream.URL)
+	srv.cfg.DisableTools = true
+	cancel := startTestInterceptor(t, srv)
+	defer cancel()
+
+	req, _ := http.NewRequest("POST", interceptURL(port, "/v1/messages"), strings.NewReader("\x1f\x8b..."))
+	req.Header.Set("Content-Encoding", "gzip")
+	resp, err := interceptClient(port).Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnsupportedMediaType {
+		t.Errorf("expected 415, got %d", resp.StatusCode)
+	}
+}
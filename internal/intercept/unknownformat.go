@@ -0,0 +1,242 @@
+package intercept
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ppiankov/chainwatch/internal/audit"
+	"github.com/ppiankov/chainwatch/internal/model"
+)
+
+// Unknown-format policies (Config.UnknownFormatPolicy) for responses that
+// are neither Anthropic nor OpenAI shaped but carry tool-call-like content
+// the interceptor cannot verify.
+const (
+	UnknownFormatPassthrough = "passthrough" // forward unchanged (default)
+	UnknownFormatLog         = "log"         // forward and audit
+	UnknownFormatBlock       = "block"       // replace with an error and audit
+)
+
+// unknownFormatReason is the audit and error reason for unverifiable responses.
+const unknownFormatReason = "response format not recognized; tool-call-like content cannot be verified"
+
+// maxToolScanDepth bounds recursion into unrecognized response bodies.
+const maxToolScanDepth = 32
+
+// validateUnknownFormatPolicy checks Config.UnknownFormatPolicy.
+func validateUnknownFormatPolicy(p string) error {
+	switch p {
+	case "", UnknownFormatPassthrough, UnknownFormatLog, UnknownFormatBlock:
+		return nil
+	}
+	return fmt.Errorf("invalid unknown format policy %q (want passthrough, log, or block)", p)
+}
+
+// unknownFormatPolicy returns the configured policy, defaulting to passthrough.
+func (s *Server) unknownFormatPolicy() string {
+	if s.cfg.UnknownFormatPolicy == "" {
+		return UnknownFormatPassthrough
+	}
+	return s.cfg.UnknownFormatPolicy
+}
+
+// looksLikeToolCalls reports whether an unrecognized JSON value contains a
+// tool-call-like structure anywhere: a tool_calls/function_call key, a
+// block whose type ends in tool_use, tool_call, or function_call, or an
+// object carrying a name plus input/arguments.
+func looksLikeToolCalls(v any) bool {
+	return scanToolCalls(v, 0)
+}
+
+func scanToolCalls(v any, depth int) bool {
+	if depth > maxToolScanDepth {
+		return false
+	}
+	switch t := v.(type) {
+	case map[string]any:
+		if _, ok := t["tool_calls"]; ok {
+			return true
+		}
+		if _, ok := t["function_call"]; ok {
+			return true
+		}
+		if blockType, _ := t["type"].(string); blockType != "" && looksLikeToolBlock(blockType, t) {
+			return true
+		}
+		for _, child := range t {
+			if scanToolCalls(child, depth+1) {
+				return true
+			}
+		}
+	case []any:
+		for _, child := range t {
+			if scanToolCalls(child, depth+1) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// handleUnknownFormat applies the unknown-format policy to a JSON body
+// with tool-call-like content. Returns true if it wrote the response.
+func (s *Server) handleUnknownFormat(w http.ResponseWriter, resp *http.Response, bodyMap map[string]any, who agentIdentity) bool {
+	policy := s.unknownFormatPolicy()
+	if policy == UnknownFormatPassthrough || !looksLikeToolCalls(bodyMap) {
+		return false
+	}
+
+	path := ""
+	if resp.Request != nil {
+		path = resp.Request.URL.Path
+	}
+	decision := model.Allow
+	if policy == UnknownFormatBlock {
+		decision = model.Deny
+	}
+	s.recordUnknownFormat(path, decision, who)
+	if policy != UnknownFormatBlock {
+		return false
+	}
+
+	payload, _ := json.Marshal(map[string]any{
+		"type": "error",
+		"error": map[string]any{
+			"type":    "chainwatch_unknown_format",
+			"message": "[BLOCKED by chainwatch] " + unknownFormatReason,
+		},
+	})
+	copyHeaders(w, resp)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Length", strconv.Itoa(len(payload)))
+	w.WriteHeader(http.StatusBadGateway)
+	w.Write(payload)
+	return true
+}
+
+// checkUnknownStreamLine applies the unknown-format policy to one SSE line
+// of a stream in an unrecognized format. Returns false when the stream
+// must be cut before the line is forwarded.
+func (s *Server) checkUnknownStreamLine(line, path string, who agentIdentity, logged *bool) bool {
+	policy := s.unknownFormatPolicy()
+	if policy == UnknownFormatPassthrough || !strings.HasPrefix(line, "data: ") {
+		return true
+	}
+	var event any
+	if err := json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &event); err != nil || !looksLikeToolCalls(event) {
+		return true
+	}
+	if policy == UnknownFormatBlock {
+		s.recordUnknownFormat(path, model.Deny, who)
+		return false
+	}
+	if !*logged {
+		*logged = true
+		s.recordUnknownFormat(path, model.Allow, who)
+	}
+	return true
+}
+
+// maxUnknownStreamBuffer bounds how much of an unrecognized stream the
+// block policy holds back before deciding.
+const maxUnknownStreamBuffer = 10 << 20 // 10MB
+
+// handleUnknownStreaming forwards a stream in an unrecognized format. Under
+// the log policy each data line is checked for tool-call-like content as
+// it passes; block holds the stream back until the decision is made.
+func (s *Server) handleUnknownStreaming(w http.ResponseWriter, flusher http.Flusher, r *http.Request, resp *http.Response, who agentIdentity) {
+	switch s.unknownFormatPolicy() {
+	case UnknownFormatPassthrough:
+		io.Copy(w, resp.Body)
+		flusher.Flush()
+		return
+	case UnknownFormatBlock:
+		s.blockUnknownStream(w, flusher, r, resp, who)
+		return
+	}
+
+	scanner := s.newSSEScanner(resp.Body)
+	var logged bool
+	for scanner.Scan() {
+		line := scanner.Text()
+		s.checkUnknownStreamLine(line, r.URL.Path, who, &logged)
+		fmt.Fprintf(w, "%s\n", line)
+		if line == "" {
+			flusher.Flush()
+		}
+	}
+	flusher.Flush()
+	if err := scanner.Err(); err != nil {
+		s.abortStream(w, flusher, r, who, err)
+	}
+}
+
+// blockUnknownStream reads an unrecognized stream to the end before
+// forwarding any of it, so no event reaches the client before a
+// tool-call-like line is found. A stream with one, or larger than
+// maxUnknownStreamBuffer, is replaced by an error event.
+func (s *Server) blockUnknownStream(w http.ResponseWriter, flusher http.Flusher, r *http.Request, resp *http.Response, who agentIdentity) {
+	scanner := s.newSSEScanner(resp.Body)
+	var held bytes.Buffer
+	var logged bool
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !s.checkUnknownStreamLine(line, r.URL.Path, who, &logged) {
+			writeUnknownFormatEvent(w, flusher, unknownFormatReason)
+			return
+		}
+		if held.Len()+len(line)+1 > maxUnknownStreamBuffer {
+			s.recordUnknownFormat(r.URL.Path, model.Deny, who)
+			writeUnknownFormatEvent(w, flusher, fmt.Sprintf("unrecognized stream exceeds %d bytes and cannot be verified", maxUnknownStreamBuffer))
+			return
+		}
+		held.WriteString(line)
+		held.WriteByte('\n')
+	}
+	if err := scanner.Err(); err != nil {
+		s.abortStream(w, flusher, r, who, err)
+		return
+	}
+	w.Write(held.Bytes())
+	flusher.Flush()
+}
+
+// writeUnknownFormatEvent ends a stream with a chainwatch_unknown_format
+// error event.
+func writeUnknownFormatEvent(w http.ResponseWriter, flusher http.Flusher, reason string) {
+	payload, _ := json.Marshal(map[string]any{
+		"type": "error",
+		"error": map[string]any{
+			"type":    "chainwatch_unknown_format",
+			"message": "[BLOCKED by chainwatch] " + reason,
+		},
+	})
+	fmt.Fprintf(w, "event: error\ndata: %s\n\n", payload)
+	flusher.Flush()
+}
+
+// recordUnknownFormat audits an unverifiable response.
+func (s *Server) recordUnknownFormat(path string, decision model.Decision, who agentIdentity) {
+	if s.auditLog == nil {
+		return
+	}
+	s.mu.Lock()
+	traceID := s.tracer.State.TraceID
+	s.mu.Unlock()
+	s.auditLog.Record(audit.AuditEntry{
+		Timestamp:  time.Now().UTC().Format("2006-01-02T15:04:05.000Z"),
+		TraceID:    traceID,
+		AgentID:    who.id,
+		Action:     audit.AuditAction{Tool: "response", Resource: path},
+		Decision:   string(decision),
+		Reason:     unknownFormatReason,
+		PolicyHash: who.enf.Hash,
+		Type:       "unknown_format",
+	})
+}
@@ -0,0 +1,120 @@
+package intercept
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// responsesAPIBody is an OpenAI Responses API shaped body: neither
+// Anthropic content nor chat-completion choices, but it carries a call.
+const responsesAPIBody = `{"id":"resp_1","output":[{"type":"function_call","call_id":"call_1","name":"run_command","arguments":"{\"command\":\"rm -rf /\"}"}]}`
+
+func unknownFormatUpstream(body string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, body)
+	}))
+}
+
+func postUnknownFormat(t *testing.T, policy, body string) (int, string) {
+	t.Helper()
+	upstream := unknownFormatUpstream(body)
+	defer upstream.Close()
+
+	srv, port := newTestInterceptorWithConfig(t, Config{
+		Upstream:            upstream.URL,
+		Purpose:             "test",
+		UnknownFormatPolicy: policy,
+	})
+	cancel := startTestInterceptor(t, srv)
+	defer cancel()
+
+	resp, err := interceptClient(port).Post(interceptURL(port, "/v1/responses"), "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	out, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(out)
+}
+
+func TestUnknownFormatToolCallsBlockedWhenStrict(t *testing.T) {
+	status, body := postUnknownFormat(t, UnknownFormatBlock, responsesAPIBody)
+	if status != http.StatusBadGateway {
+		t.Errorf("expected 502, got %d", status)
+	}
+	if !strings.Contains(body, "chainwatch_unknown_format") {
+		t.Errorf("expected unknown format error, got %s", body)
+	}
+	if strings.Contains(body, "rm -rf") {
+		t.Errorf("unverified tool call leaked: %s", body)
+	}
+}
+
+func TestUnknownFormatPassthroughByDefault(t *testing.T) {
+	status, body := postUnknownFormat(t, "", responsesAPIBody)
+	if status != http.StatusOK || body != responsesAPIBody {
+		t.Errorf("expected body passed through unchanged, got %d %s", status, body)
+	}
+}
+
+func TestUnknownFormatWithoutToolCallsPassesWhenStrict(t *testing.T) {
+	models := `{"object":"list","data":[{"id":"model-a","object":"model"}]}`
+	status, body := postUnknownFormat(t, UnknownFormatBlock, models)
+	if status != http.StatusOK || body != models {
+		t.Errorf("expected non-tool body passed through, got %d %s", status, body)
+	}
+}
+
+func TestNewServerRejectsInvalidUnknownFormatPolicy(t *testing.T) {
+	if _, err := NewServer(Config{Upstream: "http://127.0.0.1:1", UnknownFormatPolicy: "drop"}); err == nil {
+		t.Fatal("expected error for invalid unknown format policy")
+	}
+}
+
+func postUnknownStream(t *testing.T, policy, stream string) string {
+	t.Helper()
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		io.WriteString(w, stream)
+	}))
+	defer upstream.Close()
+
+	srv, port := newTestInterceptorWithConfig(t, Config{
+		Upstream:            upstream.URL,
+		Purpose:             "test",
+		UnknownFormatPolicy: policy,
+	})
+	cancel := startTestInterceptor(t, srv)
+	defer cancel()
+
+	resp, err := interceptClient(port).Post(interceptURL(port, "/v1/responses"), "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	out, _ := io.ReadAll(resp.Body)
+	return string(out)
+}
+
+func TestUnknownFormatStreamHeldBackWhenStrict(t *testing.T) {
+	stream := "data: {\"type\":\"response.output_text.delta\",\"delta\":\"held-marker\"}\n\n" +
+		"data: " + responsesAPIBody + "\n\n"
+	body := postUnknownStream(t, UnknownFormatBlock, stream)
+	if !strings.Contains(body, "chainwatch_unknown_format") {
+		t.Errorf("expected unknown format error event, got %s", body)
+	}
+	if strings.Contains(body, "held-marker") || strings.Contains(body, "rm -rf") {
+		t.Errorf("events before the block decision reached the client: %s", body)
+	}
+}
+
+func TestUnknownFormatStreamWithoutToolCallsPassesWhenStrict(t *testing.T) {
+	stream := "data: {\"type\":\"response.output_text.delta\",\"delta\":\"hello\"}\n\n"
+	if body := postUnknownStream(t, UnknownFormatBlock, stream); body != stream {
+		t.Errorf("expected stream passed through unchanged, got %q", body)
+	}
+}
@@ -0,0 +1,470 @@
+package intercept
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/ppiankov/chainwatch/internal/model"
+)
+
+// WebSocket opcodes (RFC 6455 §5.2).
+const (
+	wsOpContinuation = 0x0
+	wsOpText         = 0x1
+	wsOpBinary       = 0x2
+	wsOpClose        = 0x8
+	wsOpPing         = 0x9
+	wsOpPong         = 0xA
+)
+
+// maxWSMessageSize caps an assembled upstream message, matching the
+// non-streaming body limit. Larger messages close the connection (fail-closed).
+const maxWSMessageSize = 10 << 20 // 10MB
+
+// wsCloseMessageTooBig is the RFC 6455 close code for oversized messages.
+const wsCloseMessageTooBig = 1009
+
+// wsFrame is a single WebSocket frame.
+type wsFrame struct {
+	fin     bool
+	rsv     byte
+	opcode  byte
+	masked  bool
+	mask    [4]byte
+	payload []byte
+}
+
+func (f *wsFrame) isControl() bool {
+	return f.opcode&0x8 != 0
+}
+
+// isWebSocketUpgrade reports whether the request asks for a WebSocket upgrade.
+func isWebSocketUpgrade(r *http.Request) bool {
+	return headerContainsToken(r.Header, "Connection", "upgrade") &&
+		headerContainsToken(r.Header, "Upgrade", "websocket")
+}
+
+func headerContainsToken(h http.Header, name, token string) bool {
+	for _, v := range h.Values(name) {
+		for _, part := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), token) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// readWSFrame reads one frame, unmasking the payload if masked.
+// Payloads larger than limit return an error before allocation.
+func readWSFrame(r *bufio.Reader, limit int64) (*wsFrame, error) {
+	var hdr [2]byte
+	if _, err := io.ReadFull(r, hdr[:]); err != nil {
+		return nil, err
+	}
+
+	f := &wsFrame{
+		fin:    hdr[0]&0x80 != 0,
+		rsv:    hdr[0] & 0x70,
+		opcode: hdr[0] & 0x0F,
+		masked: hdr[1]&0x80 != 0,
+	}
+
+	length := uint64(hdr[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(r, ext[:]); err != nil {
+			return nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+
+	if length > uint64(limit) {
+		return nil, errWSMessageTooBig
+	}
+
+	if f.masked {
+		if _, err := io.ReadFull(r, f.mask[:]); err != nil {
+			return nil, err
+		}
+	}
+
+	f.payload = make([]byte, length)
+	if _, err := io.ReadFull(r, f.payload); err != nil {
+		return nil, err
+	}
+	if f.masked {
+		for i := range f.payload {
+			f.payload[i] ^= f.mask[i%4]
+		}
+	}
+	return f, nil
+}
+
+var errWSMessageTooBig = errors.New("websocket message exceeds size limit")
+
+// writeWSFrame writes a frame, masking the payload if f.masked is set.
+func writeWSFrame(w io.Writer, f *wsFrame) error {
+	var hdr [14]byte
+	hdr[0] = f.rsv | f.opcode
+	if f.fin {
+		hdr[0] |= 0x80
+	}
+
+	n := 2
+	length := len(f.payload)
+	switch {
+	case length < 126:
+		hdr[1] = byte(length)
+	case length <= 0xFFFF:
+		hdr[1] = 126
+		binary.BigEndian.PutUint16(hdr[2:], uint16(length))
+		n += 2
+	default:
+		hdr[1] = 127
+		binary.BigEndian.PutUint64(hdr[2:], uint64(length))
+		n += 8
+	}
+
+	payload := f.payload
+	if f.masked {
+		hdr[1] |= 0x80
+		copy(hdr[n:], f.mask[:])
+		n += 4
+		payload = make([]byte, length)
+		for i := range f.payload {
+			payload[i] = f.payload[i] ^ f.mask[i%4]
+		}
+	}
+
+	if _, err := w.Write(hdr[:n]); err != nil {
+		return err
+	}
+	_, err := w.Write(payload)
+	return err
+}
+
+// wsCloseFrame builds a close frame with a status code and reason.
+func wsCloseFrame(code uint16, reason string) *wsFrame {
+	payload := make([]byte, 2+len(reason))
+	binary.BigEndian.PutUint16(payload, code)
+	copy(payload[2:], reason)
+	return &wsFrame{fin: true, opcode: wsOpClose, payload: payload}
+}
+
+// dialUpstreamWS opens a raw connection to the upstream host for a WebSocket
+// upgrade. https/wss upstreams use TLS.
+func (s *Server) dialUpstreamWS() (net.Conn, error) {
+	host := s.upstream.Host
+	secure := s.upstream.Scheme == "https" || s.upstream.Scheme == "wss"
+	if s.upstream.Port() == "" {
+		if secure {
+			host = net.JoinHostPort(s.upstream.Hostname(), "443")
+		} else {
+			host = net.JoinHostPort(s.upstream.Hostname(), "80")
+		}
+	}
+	if secure {
+		tlsCfg := s.transport.TLSClientConfig.Clone()
+		tlsCfg.ServerName = s.upstream.Hostname()
+		return tls.Dial("tcp", host, s.skip.Config(tlsCfg, s.upstream.Hostname()))
+	}
+	return net.Dial("tcp", host)
+}
+
+// handleWebSocket proxies a WebSocket upgrade to upstream, relays client
+// frames unchanged, and inspects upstream text messages for tool calls
+// (OpenAI Realtime events). Blocked calls are rewritten before the client
+// sees them, analogous to the SSE rewrite.
+func (s *Server) handleWebSocket(w http.ResponseWriter, r *http.Request) {
+	hj, ok := w.(http.Hijacker)
+	if !ok {
+		http.Error(w, "websocket not supported", http.StatusInternalServerError)
+		return
+	}
+
+	who := s.identify(r)
+	if s.toolsDisabled(who) {
+		// Realtime sessions declare tools in frames, not the upgrade request.
+		http.Error(w, "tools disabled: websocket sessions are refused", http.StatusForbidden)
+		return
+	}
+
+	upConn, err := s.dialUpstreamWS()
+	if err != nil {
+		if errors.Is(err, errPinMismatch) {
+			s.reportPinMismatch(err)
+			http.Error(w, "upstream certificate pin mismatch", http.StatusBadGateway)
+			return
+		}
+		http.Error(w, fmt.Sprintf("upstream error: %v", err), http.StatusBadGateway)
+		return
+	}
+
+	outReq := r.Clone(r.Context())
+	outReq.URL.Scheme = ""
+	outReq.URL.Host = ""
+	outReq.RequestURI = ""
+	outReq.Host = s.upstream.Host
+	// Compressed frames cannot be inspected — negotiate uncompressed only.
+	outReq.Header.Del("Sec-WebSocket-Extensions")
+
+	if err := outReq.Write(upConn); err != nil {
+		upConn.Close()
+		http.Error(w, fmt.Sprintf("upstream error: %v", err), http.StatusBadGateway)
+		return
+	}
+
+	upReader := bufio.NewReader(upConn)
+	resp, err := http.ReadResponse(upReader, outReq)
+	if err != nil {
+		upConn.Close()
+		http.Error(w, fmt.Sprintf("upstream error: %v", err), http.StatusBadGateway)
+		return
+	}
+
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		// Upstream refused the upgrade — relay its response as plain HTTP.
+		defer upConn.Close()
+		defer resp.Body.Close()
+		copyHeaders(w, resp)
+		w.WriteHeader(resp.StatusCode)
+		io.Copy(w, resp.Body)
+		return
+	}
+
+	clientConn, clientBuf, err := hj.Hijack()
+	if err != nil {
+		upConn.Close()
+		return
+	}
+
+	// Relay the 101 handshake verbatim (minus any extension upstream echoed).
+	resp.Header.Del("Sec-WebSocket-Extensions")
+	fmt.Fprintf(clientBuf, "HTTP/1.1 101 Switching Protocols\r\n")
+	resp.Header.Write(clientBuf)
+	fmt.Fprintf(clientBuf, "\r\n")
+	if err := clientBuf.Flush(); err != nil {
+		clientConn.Close()
+		upConn.Close()
+		return
+	}
+
+	var once sync.Once
+	closeBoth := func() {
+		once.Do(func() {
+			clientConn.Close()
+			upConn.Close()
+		})
+	}
+
+	// Client → upstream: raw passthrough (requests carry no tool calls to enforce).
+	go func() {
+		defer closeBoth()
+		if n := clientBuf.Reader.Buffered(); n > 0 {
+			buffered, _ := clientBuf.Reader.Peek(n)
+			if _, err := upConn.Write(buffered); err != nil {
+				return
+			}
+			clientBuf.Reader.Discard(n)
+		}
+		io.Copy(upConn, clientConn)
+	}()
+
+	// Upstream → client: frame-aware relay with tool call inspection.
+	defer closeBoth()
+	s.relayUpstreamWS(upReader, clientConn, who)
+}
+
+// relayUpstreamWS reads upstream frames, assembles text messages,
+// and forwards them to the client after realtime tool call enforcement.
+func (s *Server) relayUpstreamWS(up *bufio.Reader, client io.Writer, who agentIdentity) {
+	session := newRealtimeSession(who)
+
+	var msgOpcode byte
+	var msg []byte
+	var assembling bool
+
+	for {
+		f, err := readWSFrame(up, maxWSMessageSize)
+		if err != nil {
+			if errors.Is(err, errWSMessageTooBig) {
+				writeWSFrame(client, wsCloseFrame(wsCloseMessageTooBig, "chainwatch: message too large to inspect"))
+			}
+			return
+		}
+
+		// Control frames may interleave with fragmented messages — forward immediately.
+		if f.isControl() {
+			f.masked = false
+			if err := writeWSFrame(client, f); err != nil {
+				return
+			}
+			if f.opcode == wsOpClose {
+				return
+			}
+			continue
+		}
+
+		// Binary messages (audio) are forwarded frame-by-frame without inspection.
+		if f.opcode == wsOpBinary || (f.opcode == wsOpContinuation && msgOpcode == wsOpBinary) {
+			msgOpcode = wsOpBinary
+			f.masked = false
+			if err := writeWSFrame(client, f); err != nil {
+				return
+			}
+			if f.fin {
+				msgOpcode = 0
+			}
+			continue
+		}
+
+		if f.opcode == wsOpText {
+			msgOpcode = wsOpText
+			msg = msg[:0]
+			assembling = true
+		} else if !assembling {
+			// Stray continuation — protocol error upstream; drop it.
+			continue
+		}
+
+		if len(msg)+len(f.payload) > maxWSMessageSize {
+			writeWSFrame(client, wsCloseFrame(wsCloseMessageTooBig, "chainwatch: message too large to inspect"))
+			return
+		}
+		msg = append(msg, f.payload...)
+		if !f.fin {
+			continue
+		}
+
+		assembling = false
+		msgOpcode = 0
+		for _, out := range session.process(s, msg) {
+			if err := writeWSFrame(client, &wsFrame{fin: true, opcode: wsOpText, payload: out}); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// realtimeSession tracks per-connection tool call state so each call_id is
+// evaluated once even though it appears in several Realtime events.
+type realtimeSession struct {
+	who       agentIdentity
+	names     map[string]string // call_id → function name (from output_item.added)
+	decisions map[string]EvalResult
+	deltas    map[string][][]byte // call_id → withheld argument delta messages
+}
+
+func newRealtimeSession(who agentIdentity) *realtimeSession {
+	return &realtimeSession{
+		who:       who,
+		names:     make(map[string]string),
+		decisions: make(map[string]EvalResult),
+		deltas:    make(map[string][][]byte),
+	}
+}
+
+// maxWithheldDeltas bounds buffered argument deltas per call.
+const maxWithheldDeltas = 4096
+
+// process applies enforcement to one upstream text message and returns
+// the messages to forward to the client, in order.
+func (rs *realtimeSession) process(s *Server, msg []byte) [][]byte {
+	var event map[string]any
+	if err := json.Unmarshal(msg, &event); err != nil {
+		return [][]byte{msg}
+	}
+
+	eventType, _ := event["type"].(string)
+
+	// Remember function names announced before arguments complete.
+	if eventType == "response.output_item.added" {
+		if item, ok := event["item"].(map[string]any); ok {
+			if t, _ := item["type"].(string); t == "function_call" {
+				callID, _ := item["call_id"].(string)
+				name, _ := item["name"].(string)
+				if callID != "" && name != "" {
+					rs.names[callID] = name
+				}
+			}
+		}
+		return [][]byte{msg}
+	}
+
+	// Withhold argument deltas until the call is evaluated, as the SSE path
+	// buffers input_json_delta events.
+	if eventType == "response.function_call_arguments.delta" {
+		callID, _ := event["call_id"].(string)
+		if er, ok := rs.decisions[callID]; ok {
+			if isAllowed(er.Result) {
+				return [][]byte{msg}
+			}
+			return nil
+		}
+		if len(rs.deltas[callID]) < maxWithheldDeltas {
+			rs.deltas[callID] = append(rs.deltas[callID], msg)
+		}
+		return nil
+	}
+
+	calls := ExtractRealtimeToolCalls(event)
+	if len(calls) == 0 {
+		return [][]byte{msg}
+	}
+
+	var out [][]byte
+	results := make([]EvalResult, 0, len(calls))
+	for _, call := range calls {
+		if call.Name == "" {
+			call.Name = rs.names[call.ID]
+		}
+		er, seen := rs.decisions[call.ID]
+		if !seen || call.ID == "" {
+			// Sessions outlive reloads: each call uses the current policy.
+			who := rs.who
+			who.enf = s.snapshot()
+			er = EvalResult{Call: call, Result: s.evaluateToolCall(call, who)}
+			if call.ID != "" {
+				rs.decisions[call.ID] = er
+			}
+		}
+		er.Call.Index = call.Index
+		results = append(results, er)
+
+		// Release or discard withheld deltas now that the decision is known.
+		if pending := rs.deltas[call.ID]; len(pending) > 0 {
+			if isAllowed(er.Result) {
+				out = append(out, pending...)
+			}
+			delete(rs.deltas, call.ID)
+		}
+	}
+
+	rewritten, changed := RewriteRealtimeEvent(event, results)
+	if !changed {
+		return append(out, msg)
+	}
+	return append(out, rewritten)
+}
+
+// isAllowed reports whether a decision lets the tool call through unchanged.
+func isAllowed(result model.PolicyResult) bool {
+	return result.Decision == model.Allow || result.Decision == model.AllowWithRedaction
+}
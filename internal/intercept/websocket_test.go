@@ -0,0 +1,233 @@
+package intercept
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const wsGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+func wsAccept(key string) string {
+	h := sha1.Sum([]byte(key + wsGUID))
+	return base64.StdEncoding.EncodeToString(h[:])
+}
+
+func realtimeJSON(v map[string]any) []byte {
+	out, _ := json.Marshal(v)
+	return out
+}
+
+// fakeRealtimeUpstream accepts a WebSocket upgrade, echoes the first client
+// text message, then emits the given server frames and closes.
+func fakeRealtimeUpstream(t *testing.T, frames []*wsFrame) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isWebSocketUpgrade(r) {
+			http.Error(w, "expected upgrade", http.StatusBadRequest)
+			return
+		}
+		if r.Header.Get("Sec-WebSocket-Extensions") != "" {
+			http.Error(w, "extensions must be stripped", http.StatusBadRequest)
+			return
+		}
+		conn, rw, err := w.(http.Hijacker).Hijack()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n",
+			wsAccept(r.Header.Get("Sec-WebSocket-Key")))
+		rw.Flush()
+
+		// Client → upstream relay check.
+		in, err := readWSFrame(rw.Reader, maxWSMessageSize)
+		if err != nil {
+			return
+		}
+		writeWSFrame(conn, &wsFrame{fin: true, opcode: wsOpText, payload: append([]byte("echo:"), in.payload...)})
+
+		for _, f := range frames {
+			if err := writeWSFrame(conn, f); err != nil {
+				return
+			}
+		}
+		writeWSFrame(conn, wsCloseFrame(1000, "done"))
+	}))
+}
+
+// dialThroughInterceptor performs the client side of the upgrade via the interceptor.
+func dialThroughInterceptor(t *testing.T, port int) (net.Conn, *bufio.Reader) {
+	t.Helper()
+	conn, err := net.DialTimeout("tcp", fmt.Sprintf("127.0.0.1:%d", port), 2*time.Second)
+	if err != nil {
+		t.Fatalf("dial interceptor: %v", err)
+	}
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+
+	key := base64.StdEncoding.EncodeToString([]byte("chainwatch-test!"))
+	fmt.Fprintf(conn, "GET /v1/realtime?model=gpt-4o-realtime HTTP/1.1\r\nHost: 127.0.0.1\r\n"+
+		"Upgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Version: 13\r\n"+
+		"Sec-WebSocket-Key: %s\r\nSec-WebSocket-Extensions: permessage-deflate\r\n\r\n", key)
+
+	br := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(br, nil)
+	if err != nil {
+		t.Fatalf("read handshake: %v", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		t.Fatalf("expected 101, got %d", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Sec-WebSocket-Accept"); got != wsAccept(key) {
+		t.Fatalf("accept key mismatch: %q", got)
+	}
+	return conn, br
+}
+
+func readAllTextMessages(t *testing.T, br *bufio.Reader) []string {
+	t.Helper()
+	var msgs []string
+	for {
+		f, err := readWSFrame(br, maxWSMessageSize)
+		if err != nil {
+			t.Fatalf("read frame: %v", err)
+		}
+		if f.opcode == wsOpClose {
+			return msgs
+		}
+		if f.opcode == wsOpText {
+			msgs = append(msgs, string(f.payload))
+		}
+	}
+}
+
+func TestWebSocketRealtimeFunctionCallBlocked(t *testing.T) {
+	dangerousArgs := `{"command":"rm -rf /"}`
+	safeArgs := `{"command":"ls /tmp"}`
+
+	text := func(v map[string]any) *wsFrame {
+		return &wsFrame{fin: true, opcode: wsOpText, payload: realtimeJSON(v)}
+	}
+	fragmented := realtimeJSON(map[string]any{"type": "response.text.delta", "delta": "checking files"})
+
+	upstream := fakeRealtimeUpstream(t, []*wsFrame{
+		text(map[string]any{"type": "session.created"}),
+		// Text message split across two frames.
+		{fin: false, opcode: wsOpText, payload: fragmented[:10]},
+		{fin: true, opcode: wsOpContinuation, payload: fragmented[10:]},
+		text(map[string]any{"type": "response.output_item.added", "item": map[string]any{
+			"id": "item_1", "type": "function_call", "call_id": "call_bad", "name": "run_command", "arguments": "",
+		}}),
+		text(map[string]any{"type": "response.function_call_arguments.delta", "call_id": "call_bad", "delta": dangerousArgs}),
+		text(map[string]any{"type": "response.function_call_arguments.done", "call_id": "call_bad", "arguments": dangerousArgs}),
+		text(map[string]any{"type": "response.function_call_arguments.delta", "call_id": "call_ok", "delta": safeArgs}),
+		text(map[string]any{"type": "response.function_call_arguments.done", "call_id": "call_ok", "name": "run_command", "arguments": safeArgs}),
+		text(map[string]any{"type": "response.done", "response": map[string]any{"output": []any{
+			map[string]any{"id": "item_1", "type": "function_call", "call_id": "call_bad", "name": "run_command", "arguments": dangerousArgs},
+			map[string]any{"id": "item_2", "type": "function_call", "call_id": "call_ok", "name": "run_command", "arguments": safeArgs},
+		}}}),
+	})
+	defer upstream.Close()
+
+	srv, port := newTestInterceptor(t, upstream.URL)
+	cancel := startTestInterceptor(t, srv)
+	defer cancel()
+
+	conn, br := dialThroughInterceptor(t, port)
+	defer conn.Close()
+
+	// Client frames must be masked.
+	if err := writeWSFrame(conn, &wsFrame{fin: true, opcode: wsOpText, masked: true, mask: [4]byte{1, 2, 3, 4}, payload: []byte("hello")}); err != nil {
+		t.Fatalf("write client frame: %v", err)
+	}
+
+	msgs := readAllTextMessages(t, br)
+	joined := strings.Join(msgs, "\n")
+
+	if len(msgs) == 0 || msgs[0] != "echo:hello" {
+		t.Fatalf("expected client frame relayed upstream, got %v", msgs)
+	}
+	if !strings.Contains(joined, string(fragmented)) {
+		t.Error("fragmented text message should be reassembled and forwarded")
+	}
+	// The block reason quotes the denylist pattern; only the raw arguments must not leak.
+	if strings.Contains(joined, `\"command\":\"rm -rf /\"`) {
+		t.Errorf("dangerous arguments leaked to client:\n%s", joined)
+	}
+	if !strings.Contains(joined, "[BLOCKED by chainwatch]") {
+		t.Errorf("expected block message in rewritten events:\n%s", joined)
+	}
+	if !strings.Contains(joined, "chainwatch_blocked") {
+		t.Error("expected arguments.done rewritten to chainwatch_blocked error event")
+	}
+	if !strings.Contains(joined, "ls /tmp") {
+		t.Error("allowed function call should pass through")
+	}
+
+	// The blocked call is evaluated once despite appearing in three events.
+	var evaluated int
+	for _, ev := range srv.tracer.Events {
+		if ev.Action["resource"] == "rm -rf /" {
+			evaluated++
+		}
+	}
+	if evaluated != 1 {
+		t.Errorf("expected blocked call evaluated once, got %d", evaluated)
+	}
+}
+
+func TestWebSocketUpgradeRefusedPassthrough(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+	}))
+	defer upstream.Close()
+
+	srv, port := newTestInterceptor(t, upstream.URL)
+	cancel := startTestInterceptor(t, srv)
+	defer cancel()
+
+	req, _ := http.NewRequest("GET", interceptURL(port, "/v1/realtime"), nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	resp, err := interceptClient(port).Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected upstream 401 relayed, got %d", resp.StatusCode)
+	}
+}
+
+func TestWSFrameRoundTrip(t *testing.T) {
+	for _, size := range []int{0, 125, 126, 65535, 65536} {
+		payload := []byte(strings.Repeat("x", size))
+		var buf strings.Builder
+		writeWSFrame(&buf, &wsFrame{fin: true, opcode: wsOpBinary, masked: true, mask: [4]byte{9, 8, 7, 6}, payload: payload})
+
+		f, err := readWSFrame(bufio.NewReader(strings.NewReader(buf.String())), maxWSMessageSize)
+		if err != nil {
+			t.Fatalf("size %d: %v", size, err)
+		}
+		if !f.fin || f.opcode != wsOpBinary || string(f.payload) != string(payload) {
+			t.Errorf("size %d: round trip mismatch", size)
+		}
+	}
+}
+
+func TestWSFrameTooLarge(t *testing.T) {
+	var buf strings.Builder
+	writeWSFrame(&buf, &wsFrame{fin: true, opcode: wsOpText, payload: make([]byte, 200)})
+	if _, err := readWSFrame(bufio.NewReader(strings.NewReader(buf.String())), 100); err != errWSMessageTooBig {
+		t.Fatalf("expected errWSMessageTooBig, got %v", err)
+	}
+}
@@ -0,0 +1,70 @@
+package learn
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FormatYAML renders the suggestions as a denylist.yaml fragment, one
+// commented entry per suggestion, ready to review and merge.
+func FormatYAML(r Report) string {
+	var b strings.Builder
+	b.WriteString("# Suggested denylist entries from observed agent behavior.\n")
+	b.WriteString("# Review each entry before adopting it; remove any the agent legitimately needs.\n")
+	if len(r.Suggestions) == 0 {
+		b.WriteString("# No risky attempts observed.\n")
+		return b.String()
+	}
+	for _, category := range []string{"commands", "files", "urls"} {
+		first := true
+		for _, s := range r.Suggestions {
+			if s.Category != category {
+				continue
+			}
+			if first {
+				fmt.Fprintf(&b, "%s:\n", category)
+				first = false
+			}
+			fmt.Fprintf(&b, "  # %s, %d attempt(s), e.g. %s\n", s.Reason, s.Count, oneLine(strings.Join(s.Examples, "; ")))
+			fmt.Fprintf(&b, "  - %s\n", strconv.Quote(s.Pattern))
+		}
+	}
+	return b.String()
+}
+
+// oneLine keeps a resource from breaking out of a YAML comment.
+func oneLine(s string) string {
+	return strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+}
+
+// FormatText renders a human-readable summary of the session and the
+// suggestions.
+func FormatText(r Report) string {
+	var b strings.Builder
+	total := 0
+	for _, a := range r.Attempts {
+		total += a.Count
+	}
+	fmt.Fprintf(&b, "Observed %d distinct (tool, resource) pairs across %d attempts.\n", len(r.Attempts), total)
+	if len(r.Suggestions) == 0 {
+		b.WriteString("No risky attempts to block.\n")
+		return b.String()
+	}
+	fmt.Fprintf(&b, "\nSuggested blocks (%d):\n", len(r.Suggestions))
+	for _, s := range r.Suggestions {
+		fmt.Fprintf(&b, "  %-8s %-40s %s (%d attempt(s))\n", s.Category, s.Pattern, s.Reason, s.Count)
+	}
+	b.WriteString("\nRun with --format yaml to get a denylist fragment.\n")
+	return b.String()
+}
+
+// FormatJSON renders the report as indented JSON.
+func FormatJSON(r Report) (string, error) {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshal learn report: %w", err)
+	}
+	return string(data), nil
+}
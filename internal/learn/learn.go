@@ -0,0 +1,236 @@
+// Package learn bootstraps a denylist from observed agent behavior. An
+// agent runs under an advisory policy with an audit log; learn collects
+// the distinct (tool, resource) pairs it attempted and proposes blocks for
+// the risky ones, for an operator to review and adopt.
+package learn
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/ppiankov/chainwatch/internal/audit"
+	"github.com/ppiankov/chainwatch/internal/denylist"
+)
+
+// Attempt is one distinct (tool, resource) pair seen in a session.
+type Attempt struct {
+	Tool      string   `json:"tool"`
+	Resource  string   `json:"resource"`
+	Count     int      `json:"count"`
+	MaxTier   int      `json:"max_tier"`
+	Decisions []string `json:"decisions"`
+}
+
+// Suggestion is a proposed denylist entry.
+type Suggestion struct {
+	Category string   `json:"category"` // urls, files, or commands
+	Pattern  string   `json:"pattern"`
+	Reason   string   `json:"reason"`
+	Count    int      `json:"count"`    // attempts the pattern would have blocked
+	Examples []string `json:"examples"` // resources it matched, up to maxExamples
+}
+
+// Report is the outcome of a learning session.
+type Report struct {
+	Attempts    []Attempt    `json:"attempts"`
+	Suggestions []Suggestion `json:"suggestions"`
+}
+
+// maxExamples bounds the resources listed per suggestion.
+const maxExamples = 3
+
+// destructivePatterns mark commands whose effects cannot be undone.
+var destructivePatterns = []string{
+	"rm -rf", "rm -fr", "dd if=", "mkfs", "shred ", "chmod -r 777",
+	"> /dev/sd", "git push --force", "git push -f", "git reset --hard",
+	"drop table", "drop database", "truncate table", "terraform destroy",
+	"kubectl delete",
+}
+
+// privilegePatterns mark commands that gain or change credentials.
+var privilegePatterns = []string{"sudo ", "su -", "passwd", "chpasswd", "ssh-keygen"}
+
+// sensitiveFiles mark paths that hold credentials or personal data.
+var sensitiveFiles = []string{
+	".ssh/", ".aws/", ".config/gcloud/", ".kube/config", ".env", ".netrc",
+	"credentials", "secret", "password", "id_rsa", "id_ed25519",
+	"/etc/shadow", "/etc/passwd", "/etc/sudoers", "salary", "payroll",
+}
+
+// riskyTier is the tier from which an otherwise unclassified attempt is
+// proposed for blocking.
+const riskyTier = 3
+
+// ReadAttempts collects the distinct (tool, resource) pairs of policy
+// decisions in the audit log at path. A non-empty traceID limits the
+// session to that trace. Approval, break-glass and other event entries
+// are skipped.
+func ReadAttempts(path, traceID string) ([]Attempt, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open audit log: %w", err)
+	}
+	defer f.Close()
+
+	byKey := make(map[[2]string]*Attempt)
+	var order [][2]string
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		var entry audit.AuditEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue // skip malformed lines
+		}
+		if entry.Type != "" || entry.Action.Resource == "" {
+			continue
+		}
+		if traceID != "" && entry.TraceID != traceID {
+			continue
+		}
+
+		key := [2]string{entry.Action.Tool, entry.Action.Resource}
+		a := byKey[key]
+		if a == nil {
+			a = &Attempt{Tool: key[0], Resource: key[1]}
+			byKey[key] = a
+			order = append(order, key)
+		}
+		a.Count++
+		a.MaxTier = max(a.MaxTier, entry.Tier)
+		if !slices.Contains(a.Decisions, entry.Decision) {
+			a.Decisions = append(a.Decisions, entry.Decision)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read audit log: %w", err)
+	}
+
+	attempts := make([]Attempt, len(order))
+	for i, key := range order {
+		attempts[i] = *byKey[key]
+	}
+	return attempts, nil
+}
+
+// Suggest proposes denylist entries for the risky attempts: destructive
+// or privilege-changing commands, sensitive files, requests to external
+// hosts, and anything policy placed at tier 3 or above. Attempts the
+// current denylist dl already blocks are skipped; dl may be nil.
+func Suggest(attempts []Attempt, dl *denylist.Denylist) Report {
+	report := Report{Attempts: attempts}
+	byPattern := make(map[[2]string]*Suggestion)
+	var order [][2]string
+
+	for _, a := range attempts {
+		if dl != nil {
+			if blocked, _ := dl.IsBlocked(a.Resource, a.Tool); blocked {
+				continue
+			}
+		}
+		category, pattern, reason, ok := classify(a)
+		if !ok {
+			continue
+		}
+		key := [2]string{category, pattern}
+		s := byPattern[key]
+		if s == nil {
+			s = &Suggestion{Category: category, Pattern: pattern, Reason: reason}
+			byPattern[key] = s
+			order = append(order, key)
+		}
+		s.Count += a.Count
+		if len(s.Examples) < maxExamples && !slices.Contains(s.Examples, a.Resource) {
+			s.Examples = append(s.Examples, a.Resource)
+		}
+	}
+
+	sort.SliceStable(order, func(i, j int) bool {
+		return categoryRank(order[i][0]) < categoryRank(order[j][0])
+	})
+	for _, key := range order {
+		report.Suggestions = append(report.Suggestions, *byPattern[key])
+	}
+	return report
+}
+
+// classify returns the denylist entry proposed for a risky attempt.
+func classify(a Attempt) (category, pattern, reason string, ok bool) {
+	lower := strings.ToLower(a.Resource)
+	tool := strings.ToLower(a.Tool)
+
+	switch {
+	case isCommandTool(tool):
+		for _, p := range destructivePatterns {
+			if strings.Contains(lower, p) {
+				return "commands", strings.TrimSpace(p), "destructive command", true
+			}
+		}
+		for _, p := range privilegePatterns {
+			if strings.Contains(lower, p) {
+				return "commands", strings.TrimSpace(p), "privilege or credential change", true
+			}
+		}
+		if a.MaxTier >= riskyTier {
+			return "commands", a.Resource, fmt.Sprintf("evaluated at tier %d", a.MaxTier), true
+		}
+	case isURL(lower):
+		host := externalHost(a.Resource)
+		if host != "" {
+			return "urls", host, "external egress", true
+		}
+		if a.MaxTier >= riskyTier {
+			return "urls", a.Resource, fmt.Sprintf("evaluated at tier %d", a.MaxTier), true
+		}
+	default:
+		for _, p := range sensitiveFiles {
+			if strings.Contains(lower, p) {
+				return "files", a.Resource, "sensitive file", true
+			}
+		}
+		if a.MaxTier >= riskyTier {
+			return "files", a.Resource, fmt.Sprintf("evaluated at tier %d", a.MaxTier), true
+		}
+	}
+	return "", "", "", false
+}
+
+// externalHost returns the host of an http(s) URL, or "" when it is
+// local or cannot be parsed.
+func externalHost(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+	host := strings.ToLower(u.Hostname())
+	switch {
+	case host == "", host == "localhost", strings.HasSuffix(host, ".localhost"),
+		strings.HasPrefix(host, "127."), host == "::1":
+		return ""
+	}
+	return host
+}
+
+func isCommandTool(tool string) bool {
+	return strings.Contains(tool, "shell") || strings.Contains(tool, "command") || strings.Contains(tool, "exec")
+}
+
+func isURL(resource string) bool {
+	return strings.HasPrefix(resource, "http://") || strings.HasPrefix(resource, "https://")
+}
+
+func categoryRank(category string) int {
+	switch category {
+	case "commands":
+		return 0
+	case "files":
+		return 1
+	default:
+		return 2
+	}
+}
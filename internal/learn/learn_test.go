@@ -0,0 +1,128 @@
+package learn
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/ppiankov/chainwatch/internal/audit"
+	"github.com/ppiankov/chainwatch/internal/denylist"
+)
+
+func writeSession(t *testing.T, entries []audit.AuditEntry) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	log, err := audit.Open(path)
+	if err != nil {
+		t.Fatalf("open audit log: %v", err)
+	}
+	defer log.Close()
+	for _, e := range entries {
+		if e.TraceID == "" {
+			e.TraceID = "learn-session"
+		}
+		if err := log.Record(e); err != nil {
+			t.Fatalf("record: %v", err)
+		}
+	}
+	return path
+}
+
+func decision(tool, resource string, tier int) audit.AuditEntry {
+	return audit.AuditEntry{
+		Action:   audit.AuditAction{Tool: tool, Resource: resource},
+		Decision: "allow",
+		Reason:   "advisory",
+		Tier:     tier,
+	}
+}
+
+func TestDestructiveCommandSuggestedBlock(t *testing.T) {
+	path := writeSession(t, []audit.AuditEntry{
+		decision("command", "ls -la", 0),
+		decision("command", "rm -rf build/cache", 1),
+		decision("command", "rm -rf build/cache", 1),
+		decision("command", "git status", 0),
+	})
+
+	attempts, err := ReadAttempts(path, "")
+	if err != nil {
+		t.Fatalf("ReadAttempts: %v", err)
+	}
+	if len(attempts) != 3 {
+		t.Fatalf("expected 3 distinct attempts, got %d", len(attempts))
+	}
+
+	report := Suggest(attempts, denylist.NewDefault())
+	if len(report.Suggestions) != 1 {
+		t.Fatalf("expected one suggestion, got %+v", report.Suggestions)
+	}
+	s := report.Suggestions[0]
+	if s.Category != "commands" || s.Pattern != "rm -rf" || s.Count != 2 {
+		t.Errorf("unexpected suggestion %+v", s)
+	}
+
+	// The YAML fragment is a loadable denylist that blocks the command.
+	var p denylist.Patterns
+	if err := yaml.Unmarshal([]byte(FormatYAML(report)), &p); err != nil {
+		t.Fatalf("suggested YAML does not parse: %v\n%s", err, FormatYAML(report))
+	}
+	if blocked, _ := denylist.New(p).IsBlocked("rm -rf build/cache", "command"); !blocked {
+		t.Error("expected suggested denylist to block the destructive command")
+	}
+}
+
+func TestSuggestRiskyCategories(t *testing.T) {
+	path := writeSession(t, []audit.AuditEntry{
+		decision("file_read", "/home/dev/.kube/config", 1),
+		decision("file_read", "/srv/app/README.md", 0),
+		decision("http", "https://paste.example.net/upload", 2),
+		decision("http", "http://localhost:8080/health", 0),
+		decision("command", "make deploy", 3),
+		{TraceID: "other", Action: audit.AuditAction{Tool: "command", Resource: "sudo reboot"}, Decision: "allow"},
+		{Action: audit.AuditAction{Tool: "approval", Resource: "key"}, Decision: "approved", Type: "approval_approved"},
+	})
+
+	attempts, err := ReadAttempts(path, "learn-session")
+	if err != nil {
+		t.Fatalf("ReadAttempts: %v", err)
+	}
+	report := Suggest(attempts, nil)
+
+	got := make(map[string]string)
+	for _, s := range report.Suggestions {
+		got[s.Category+" "+s.Pattern] = s.Reason
+	}
+	want := map[string]string{
+		"commands make deploy":         "evaluated at tier 3",
+		"files /home/dev/.kube/config": "sensitive file",
+		"urls paste.example.net":       "external egress",
+	}
+	for k, reason := range want {
+		if got[k] != reason {
+			t.Errorf("suggestion %q: reason %q, want %q (all: %v)", k, got[k], reason, got)
+		}
+	}
+	if len(got) != len(want) {
+		t.Errorf("unexpected suggestions: %v", got)
+	}
+}
+
+func TestSuggestSkipsAlreadyDenylisted(t *testing.T) {
+	path := writeSession(t, []audit.AuditEntry{
+		decision("command", "rm -rf /", 3),
+	})
+	attempts, err := ReadAttempts(path, "")
+	if err != nil {
+		t.Fatalf("ReadAttempts: %v", err)
+	}
+	report := Suggest(attempts, denylist.NewDefault())
+	if len(report.Suggestions) != 0 {
+		t.Errorf("expected no suggestion for an already denylisted command, got %+v", report.Suggestions)
+	}
+	if !strings.Contains(FormatYAML(report), "No risky attempts") {
+		t.Errorf("expected empty report note, got %q", FormatYAML(report))
+	}
+}
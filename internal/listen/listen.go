@@ -0,0 +1,113 @@
+// Package listen opens server listeners from address strings, supporting
+// Unix domain sockets for single-host deployments.
+package listen
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SocketMode is the permission set on Unix socket files: owner only.
+// Agents connecting to the socket must run as the same user (or root).
+const SocketMode os.FileMode = 0o600
+
+const unixScheme = "unix://"
+
+// SocketPath returns the filesystem path of a "unix:///path/to.sock"
+// address, and whether addr is a Unix socket address at all.
+func SocketPath(addr string) (string, bool) {
+	path, ok := strings.CutPrefix(addr, unixScheme)
+	return path, ok
+}
+
+// Listen opens a listener for addr. "unix:///run/chainwatch.sock" listens
+// on a Unix domain socket created with SocketMode; anything else is a TCP
+// address such as ":50051" or "127.0.0.1:50051". A stale socket file left
+// by a previous run is replaced, but a socket with a live server behind it
+// is an error. The socket file is removed when the listener is closed.
+//
+// The socket is bound inside a private directory, restricted there, and
+// then renamed into place, so it is never reachable with the looser
+// permissions the umask would give it.
+func Listen(addr string) (net.Listener, error) {
+	path, ok := SocketPath(addr)
+	if !ok {
+		lis, err := net.Listen("tcp", addr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+		}
+		return lis, nil
+	}
+
+	if path == "" {
+		return nil, fmt.Errorf("invalid unix socket address %q: missing path", addr)
+	}
+	if err := removeStale(path); err != nil {
+		return nil, err
+	}
+	dir, err := os.MkdirTemp(filepath.Dir(path), ".cw-sock-")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create socket directory for %s: %w", path, err)
+	}
+	defer os.RemoveAll(dir)
+
+	tmp := filepath.Join(dir, "s")
+	lis, err := net.Listen("unix", tmp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	ul := lis.(*net.UnixListener)
+	ul.SetUnlinkOnClose(false)
+	if err := os.Chmod(tmp, SocketMode); err != nil {
+		ul.Close()
+		return nil, fmt.Errorf("failed to set permissions on %s: %w", path, err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		ul.Close()
+		return nil, fmt.Errorf("failed to move socket to %s: %w", path, err)
+	}
+	return &unixListener{UnixListener: ul, path: path}, nil
+}
+
+// unixListener removes the socket file at its final path on Close; the
+// net package would only unlink the name the socket was bound to.
+type unixListener struct {
+	*net.UnixListener
+	path string
+	once sync.Once
+}
+
+func (l *unixListener) Close() error {
+	err := l.UnixListener.Close()
+	l.once.Do(func() { os.Remove(l.path) })
+	return err
+}
+
+// removeStale deletes a socket file nobody is listening on. Regular files
+// are never removed.
+func removeStale(path string) error {
+	info, err := os.Lstat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	if info.Mode()&os.ModeSocket == 0 {
+		return fmt.Errorf("refusing to replace %s: not a socket", path)
+	}
+	if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+		conn.Close()
+		return fmt.Errorf("socket %s is in use by another process", path)
+	}
+	if err := os.Remove(path); err != nil {
+		return fmt.Errorf("failed to remove stale socket %s: %w", path, err)
+	}
+	return nil
+}
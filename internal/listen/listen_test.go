@@ -0,0 +1,117 @@
+package listen
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestListenTCP(t *testing.T) {
+	lis, err := Listen("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	defer lis.Close()
+	if lis.Addr().Network() != "tcp" {
+		t.Errorf("expected tcp listener, got %s", lis.Addr().Network())
+	}
+}
+
+func TestListenUnixReplacesStaleSocket(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "cw.sock")
+
+	// Leave a stale socket file behind, as after a crash.
+	stale, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	lis, err := Listen("unix://" + sock)
+	if err != nil {
+		t.Fatalf("Listen over stale socket: %v", err)
+	}
+	info, err := os.Stat(sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != SocketMode {
+		t.Errorf("expected mode %o, got %o", SocketMode, info.Mode().Perm())
+	}
+
+	lis.Close()
+	if _, err := os.Stat(sock); !os.IsNotExist(err) {
+		t.Errorf("expected socket removed on close, got %v", err)
+	}
+}
+
+func TestListenUnixRefusesLiveSocket(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "cw.sock")
+	lis, err := Listen("unix://" + sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer lis.Close()
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	if _, err := Listen("unix://" + sock); err == nil || !strings.Contains(err.Error(), "in use") {
+		t.Errorf("expected in-use error, got %v", err)
+	}
+}
+
+func TestListenUnixRefusesRegularFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "not-a-socket")
+	if err := os.WriteFile(path, []byte("data"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Listen("unix://" + path); err == nil {
+		t.Error("expected error for a regular file")
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("regular file must be left in place: %v", err)
+	}
+}
+
+func TestSocketPath(t *testing.T) {
+	if path, ok := SocketPath("unix:///run/chainwatch.sock"); !ok || path != "/run/chainwatch.sock" {
+		t.Errorf("SocketPath = %q, %v", path, ok)
+	}
+	if _, ok := SocketPath(":50051"); ok {
+		t.Error("expected TCP address not to be a socket path")
+	}
+}
+
+func TestListenUnixLeavesOnlySocket(t *testing.T) {
+	dir := t.TempDir()
+	sock := filepath.Join(dir, "cw.sock")
+	lis, err := Listen("unix://" + sock)
+	if err != nil {
+		t.Fatalf("Listen: %v", err)
+	}
+	defer lis.Close()
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name() != "cw.sock" {
+		t.Errorf("expected only the socket in %s, got %v", dir, entries)
+	}
+
+	conn, err := net.Dial("unix", sock)
+	if err != nil {
+		t.Fatalf("dial renamed socket: %v", err)
+	}
+	conn.Close()
+}
@@ -58,10 +58,83 @@ func ProcessEmail(cfg Config, raw []byte) error {
 		return fmt.Errorf("rate limit: %w", err)
 	}
 
-	// Generate job ID.
+	job, err := newJob(email)
+	if err != nil {
+		return err
+	}
+
+	// Write atomically to inbox.
+	data, err := json.MarshalIndent(job, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshal: %w", err)
+	}
+
+	filename := job.ID + ".json"
+	tmpPath := filepath.Join(cfg.InboxDir, filename+".tmp")
+	finalPath := filepath.Join(cfg.InboxDir, filename)
+
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("write temp: %w", err)
+	}
+	return os.Rename(tmpPath, finalPath)
+}
+
+// defaultRunbook is the runbook the daemon falls back to for jobs without
+// one, which includes every maildrop job.
+const defaultRunbook = "linux"
+
+// Validation is the outcome of a dry run of ProcessEmail.
+type Validation struct {
+	Allowed bool     `json:"allowed"`
+	Sender  string   `json:"sender,omitempty"`
+	Reasons []string `json:"reasons,omitempty"` // why the email would be rejected
+	Job     *jobJSON `json:"job,omitempty"`     // the job that would be written
+	Runbook string   `json:"runbook,omitempty"` // runbook the daemon would route the job to
+}
+
+// Validate runs the ProcessEmail checks without side effects: the email
+// is parsed, the sender checked against the allowlist, and the rate limit
+// evaluated without consuming an attempt. Every failing check is reported.
+// Nothing is written to the inbox.
+func Validate(cfg Config, raw []byte) *Validation {
+	v := &Validation{}
+
+	email, err := ParseEmail(raw)
+	if err != nil {
+		v.Reasons = append(v.Reasons, fmt.Sprintf("parse: %v", err))
+		return v
+	}
+	v.Sender = email.From
+
+	al, err := LoadAllowlist(cfg.AllowlistFile)
+	if err != nil {
+		v.Reasons = append(v.Reasons, fmt.Sprintf("allowlist: %v", err))
+	} else if !al.IsAllowed(email.From) {
+		v.Reasons = append(v.Reasons, fmt.Sprintf("sender %s not in allowlist", email.From))
+	}
+
+	rl := NewRateLimiter(cfg.RateLimitDir, cfg.RateLimit, cfg.RateWindow)
+	if err := rl.Peek(email.From); err != nil {
+		v.Reasons = append(v.Reasons, fmt.Sprintf("rate limit: %v", err))
+	}
+
+	job, err := newJob(email)
+	if err != nil {
+		v.Reasons = append(v.Reasons, err.Error())
+	} else {
+		v.Job = job
+		v.Runbook = defaultRunbook
+	}
+
+	v.Allowed = len(v.Reasons) == 0
+	return v
+}
+
+// newJob builds the inbox job for an accepted email.
+func newJob(email *Email) (*jobJSON, error) {
 	id, err := generateJobID()
 	if err != nil {
-		return fmt.Errorf("generate ID: %w", err)
+		return nil, fmt.Errorf("generate ID: %w", err)
 	}
 
 	// Use subject as brief; fall back to body if subject is empty.
@@ -74,7 +147,7 @@ func ProcessEmail(cfg Config, raw []byte) error {
 		brief = brief[:500]
 	}
 
-	job := jobJSON{
+	return &jobJSON{
 		ID:   id,
 		Type: "investigate", // Always forced to investigate.
 		Target: jobTarget{
@@ -83,22 +156,7 @@ func ProcessEmail(cfg Config, raw []byte) error {
 		Brief:     brief,
 		Source:    "maildrop",
 		CreatedAt: time.Now().UTC(),
-	}
-
-	// Write atomically to inbox.
-	data, err := json.MarshalIndent(job, "", "  ")
-	if err != nil {
-		return fmt.Errorf("marshal: %w", err)
-	}
-
-	filename := id + ".json"
-	tmpPath := filepath.Join(cfg.InboxDir, filename+".tmp")
-	finalPath := filepath.Join(cfg.InboxDir, filename)
-
-	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
-		return fmt.Errorf("write temp: %w", err)
-	}
-	return os.Rename(tmpPath, finalPath)
+	}, nil
 }
 
 // generateJobID creates a random job ID like "mail-a1b2c3d4e5f6".
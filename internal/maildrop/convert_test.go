@@ -131,3 +131,52 @@ func TestProcessEmailForcedInvestigateType(t *testing.T) {
 		t.Errorf("maildrop jobs must always be type=investigate, got %v", job["type"])
 	}
 }
+
+func TestValidateAllowedDryRun(t *testing.T) {
+	cfg, inbox := setupConvertTest(t)
+	cfg.RateLimit = 1
+	raw := []byte("From: admin@example.com\r\nSubject: Check web server\r\n\r\nThe site is slow.")
+
+	// Repeated dry runs must not consume the rate limit.
+	for i := 0; i < 3; i++ {
+		v := Validate(cfg, raw)
+		if !v.Allowed {
+			t.Fatalf("dry run %d: expected allowed, got reasons %v", i, v.Reasons)
+		}
+		if v.Job == nil || v.Job.Type != "investigate" || v.Job.Brief != "Check web server" {
+			t.Errorf("unexpected job: %+v", v.Job)
+		}
+		if v.Runbook != "linux" {
+			t.Errorf("runbook = %q, want linux", v.Runbook)
+		}
+	}
+
+	entries, _ := os.ReadDir(inbox)
+	if len(entries) != 0 {
+		t.Errorf("dry run wrote %d files to inbox", len(entries))
+	}
+	if err := ProcessEmail(cfg, raw); err != nil {
+		t.Errorf("rate limit consumed by dry run: %v", err)
+	}
+}
+
+func TestValidateRejectedSender(t *testing.T) {
+	cfg, inbox := setupConvertTest(t)
+	raw := []byte("From: attacker@evil.com\r\nSubject: rm -rf\r\n\r\nDo it.")
+
+	v := Validate(cfg, raw)
+	if v.Allowed {
+		t.Fatal("expected non-allowlisted sender to be rejected")
+	}
+	if v.Sender != "attacker@evil.com" {
+		t.Errorf("sender = %q", v.Sender)
+	}
+	if len(v.Reasons) != 1 || v.Reasons[0] != "sender attacker@evil.com not in allowlist" {
+		t.Errorf("unexpected reasons: %v", v.Reasons)
+	}
+
+	entries, _ := os.ReadDir(inbox)
+	if len(entries) != 0 {
+		t.Errorf("dry run wrote %d files to inbox", len(entries))
+	}
+}
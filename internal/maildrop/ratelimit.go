@@ -52,8 +52,27 @@ func (r *RateLimiter) Check(sender string) error {
 
 	path := r.statePath(sender)
 	state := r.loadState(path)
+	recent, err := r.evaluate(state, sender)
+	if err != nil {
+		return err
+	}
+
+	// Record this attempt.
+	recent = append(recent, time.Now().UTC())
+	state.Timestamps = recent
+
+	return r.saveState(path, state)
+}
+
+// Peek reports what Check would return without recording an attempt.
+func (r *RateLimiter) Peek(sender string) error {
+	_, err := r.evaluate(r.loadState(r.statePath(sender)), sender)
+	return err
+}
 
-	// Prune timestamps outside the window.
+// evaluate prunes timestamps outside the window and returns the recent
+// ones, or an error if the sender is already at the limit.
+func (r *RateLimiter) evaluate(state *rateState, sender string) ([]time.Time, error) {
 	cutoff := time.Now().Add(-r.window)
 	var recent []time.Time
 	for _, ts := range state.Timestamps {
@@ -63,15 +82,10 @@ func (r *RateLimiter) Check(sender string) error {
 	}
 
 	if len(recent) >= r.limit {
-		return fmt.Errorf("rate limit exceeded: %d jobs in the last %s for %s",
+		return nil, fmt.Errorf("rate limit exceeded: %d jobs in the last %s for %s",
 			len(recent), r.window, sender)
 	}
-
-	// Record this attempt.
-	recent = append(recent, time.Now().UTC())
-	state.Timestamps = recent
-
-	return r.saveState(path, state)
+	return recent, nil
 }
 
 // statePath returns the state file path for a sender (hashed to avoid FS issues).
@@ -6,10 +6,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"maps"
 	"net/http"
 	"net/url"
+	"os"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
 
@@ -19,6 +22,8 @@ import (
 	"github.com/ppiankov/chainwatch/internal/cmdguard"
 	"github.com/ppiankov/chainwatch/internal/model"
 	"github.com/ppiankov/chainwatch/internal/policy"
+	"github.com/ppiankov/chainwatch/internal/redact"
+	"github.com/ppiankov/chainwatch/internal/zone"
 )
 
 // --- Input/Output types ---
@@ -27,6 +32,8 @@ import (
 type ExecInput struct {
 	Command string   `json:"command" jsonschema:"command to execute"`
 	Args    []string `json:"args,omitempty" jsonschema:"command arguments"`
+
+	ConfirmToken string `json:"confirm_token,omitempty" jsonschema:"confirm token from a previous blocked response; required to retry an approved irreversible command"`
 }
 
 // ExecOutput contains the result of command execution or block details.
@@ -38,14 +45,26 @@ type ExecOutput struct {
 	Decision    string `json:"decision,omitempty"`
 	Reason      string `json:"reason,omitempty"`
 	ApprovalKey string `json:"approval_key,omitempty"`
+	Suggestion  string `json:"suggestion,omitempty"`
+	Guidance    string `json:"guidance,omitempty"`
+
+	ConfirmToken string `json:"confirm_token,omitempty"`
+
+	StdoutTruncated bool `json:"stdout_truncated,omitempty"`
+	StderrTruncated bool `json:"stderr_truncated,omitempty"`
 }
 
+// truncatedMarker is appended to exec output cut at the response cap.
+const truncatedMarker = "\n[truncated]"
+
 // HTTPInput defines parameters for the chainwatch_http tool.
 type HTTPInput struct {
 	Method  string            `json:"method" jsonschema:"HTTP method (GET/POST/PUT/DELETE)"`
 	URL     string            `json:"url" jsonschema:"request URL"`
 	Headers map[string]string `json:"headers,omitempty" jsonschema:"request headers"`
 	Body    string            `json:"body,omitempty" jsonschema:"request body"`
+
+	ConfirmToken string `json:"confirm_token,omitempty" jsonschema:"confirm token from a previous blocked response; required to retry an approved irreversible request"`
 }
 
 // HTTPOutput contains the HTTP response or block details.
@@ -57,6 +76,33 @@ type HTTPOutput struct {
 	Decision    string            `json:"decision,omitempty"`
 	Reason      string            `json:"reason,omitempty"`
 	ApprovalKey string            `json:"approval_key,omitempty"`
+	Suggestion  string            `json:"suggestion,omitempty"`
+	Guidance    string            `json:"guidance,omitempty"`
+
+	ConfirmToken string `json:"confirm_token,omitempty"`
+}
+
+// WriteInput defines parameters for the chainwatch_write tool.
+type WriteInput struct {
+	Path    string `json:"path" jsonschema:"file path to write"`
+	Content string `json:"content" jsonschema:"file content"`
+
+	ConfirmToken string `json:"confirm_token,omitempty" jsonschema:"confirm token from a previous blocked response; required to retry an approved irreversible write"`
+}
+
+// WriteOutput contains the write result or block details.
+type WriteOutput struct {
+	Path         string `json:"path,omitempty"`
+	IntendedPath string `json:"intended_path,omitempty"`
+	Bytes        int    `json:"bytes,omitempty"`
+	Quarantined  bool   `json:"quarantined,omitempty"`
+	Blocked      bool   `json:"blocked,omitempty"`
+	Decision     string `json:"decision,omitempty"`
+	Reason       string `json:"reason,omitempty"`
+	ApprovalKey  string `json:"approval_key,omitempty"`
+	Suggestion   string `json:"suggestion,omitempty"`
+	Guidance     string `json:"guidance,omitempty"`
+	ConfirmToken string `json:"confirm_token,omitempty"`
 }
 
 // CheckInput defines parameters for the chainwatch_check tool.
@@ -64,6 +110,11 @@ type CheckInput struct {
 	Tool      string `json:"tool" jsonschema:"tool type (command/http_proxy/file_read)"`
 	Resource  string `json:"resource" jsonschema:"resource being accessed"`
 	Operation string `json:"operation,omitempty" jsonschema:"operation type (execute/read/write/GET/POST)"`
+
+	Labels map[string]string `json:"labels,omitempty" jsonschema:"operator context labels (tenant, env, ticket) matched by policy rule selectors"`
+
+	Bytes int `json:"bytes,omitempty" jsonschema:"declared size of a file_read in bytes; the file is stat'ed when omitted"`
+	Rows  int `json:"rows,omitempty" jsonschema:"declared row count of a file_read"`
 }
 
 // CheckOutput contains the policy decision.
@@ -72,12 +123,15 @@ type CheckOutput struct {
 	Reason      string `json:"reason"`
 	PolicyID    string `json:"policy_id,omitempty"`
 	ApprovalKey string `json:"approval_key,omitempty"`
+	Suggestion  string `json:"suggestion,omitempty"`
 }
 
 // ApproveInput defines parameters for the chainwatch_approve tool.
 type ApproveInput struct {
 	Key      string `json:"key" jsonschema:"approval key from a blocked action"`
 	Duration string `json:"duration,omitempty" jsonschema:"approval duration (e.g. 5m), omit for one-time approval"`
+	Trace    string `json:"trace,omitempty" jsonschema:"trace ID; approves every matching action in that trace instead of one"`
+	Reason   string `json:"reason,omitempty" jsonschema:"justification for the approval; required for keys of require_reason rules"`
 }
 
 // ApproveOutput confirms the approval.
@@ -85,6 +139,7 @@ type ApproveOutput struct {
 	Key      string `json:"key"`
 	Status   string `json:"status"`
 	Duration string `json:"duration,omitempty"`
+	Trace    string `json:"trace,omitempty"`
 }
 
 // PendingInput is empty — no parameters needed.
@@ -104,55 +159,140 @@ type PendingItem struct {
 	CreatedAt string `json:"created_at"`
 }
 
+// BreakGlassListInput is empty — no parameters needed.
+type BreakGlassListInput struct{}
+
+// BreakGlassListOutput lists all break-glass tokens.
+type BreakGlassListOutput struct {
+	Tokens []BreakGlassItem `json:"tokens"`
+}
+
+// BreakGlassItem describes a single break-glass token.
+type BreakGlassItem struct {
+	ID        string `json:"id"`
+	Status    string `json:"status"`
+	Reason    string `json:"reason"`
+	CreatedAt string `json:"created_at"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+// BreakGlassRevokeInput defines parameters for the chainwatch_breakglass_revoke tool.
+type BreakGlassRevokeInput struct {
+	ID string `json:"id" jsonschema:"break-glass token ID to revoke"`
+}
+
+// BreakGlassRevokeOutput confirms the revocation.
+type BreakGlassRevokeOutput struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+}
+
 // --- Handlers ---
 
 func (s *Server) handleExec(ctx context.Context, req *mcpsdk.CallToolRequest, input ExecInput) (*mcpsdk.CallToolResult, ExecOutput, error) {
-	result, err := s.guard.Run(ctx, input.Command, input.Args, nil)
+	result, err := s.guard.Run(cmdguard.WithConfirmToken(ctx, input.ConfirmToken), input.Command, input.Args, nil)
 	if err != nil {
 		var blocked *cmdguard.BlockedError
 		if errors.As(err, &blocked) {
 			out := ExecOutput{
-				Blocked:     true,
-				Decision:    string(blocked.Decision),
-				Reason:      blocked.Reason,
-				ApprovalKey: blocked.ApprovalKey,
+				Blocked:      true,
+				Decision:     string(blocked.Decision),
+				Reason:       blocked.Reason,
+				ApprovalKey:  blocked.ApprovalKey,
+				Suggestion:   blocked.Suggestion,
+				Guidance:     s.blockedGuidance(blocked),
+				ConfirmToken: blocked.ConfirmToken,
 			}
 			return &mcpsdk.CallToolResult{IsError: true}, out, nil
 		}
 		return nil, ExecOutput{}, err
 	}
 
+	stdout, stdoutCut := truncateOutput(result.Stdout, s.maxOutputBytes)
+	stderr, stderrCut := truncateOutput(result.Stderr, s.maxOutputBytes)
 	return nil, ExecOutput{
-		Stdout:   result.Stdout,
-		Stderr:   result.Stderr,
-		ExitCode: result.ExitCode,
+		Stdout:          stdout,
+		Stderr:          stderr,
+		ExitCode:        result.ExitCode,
+		StdoutTruncated: stdoutCut || result.StdoutTruncated,
+		StderrTruncated: stderrCut || result.StderrTruncated,
 	}, nil
 }
 
-func (s *Server) handleHTTP(ctx context.Context, req *mcpsdk.CallToolRequest, input HTTPInput) (*mcpsdk.CallToolResult, HTTPOutput, error) {
-	if input.Method == "" {
-		input.Method = "GET"
+func (s *Server) handleWrite(ctx context.Context, req *mcpsdk.CallToolRequest, input WriteInput) (*mcpsdk.CallToolResult, WriteOutput, error) {
+	result, err := s.guard.WriteFileConfirmed(input.Path, []byte(input.Content), input.ConfirmToken)
+	if err != nil {
+		var blocked *cmdguard.BlockedError
+		if errors.As(err, &blocked) {
+			out := WriteOutput{
+				Blocked:      true,
+				Decision:     string(blocked.Decision),
+				Reason:       blocked.Reason,
+				ApprovalKey:  blocked.ApprovalKey,
+				Suggestion:   blocked.Suggestion,
+				Guidance:     s.blockedGuidance(blocked),
+				ConfirmToken: blocked.ConfirmToken,
+			}
+			return &mcpsdk.CallToolResult{IsError: true}, out, nil
+		}
+		return nil, WriteOutput{}, err
 	}
 
-	// Build action for policy evaluation
-	action := buildHTTPAction(input)
+	return nil, WriteOutput{
+		Path:         result.Path,
+		IntendedPath: result.IntendedPath,
+		Bytes:        result.Bytes,
+		Quarantined:  result.Quarantined,
+		Decision:     string(result.Decision),
+	}, nil
+}
+
+// evaluate runs local policy, then the external decision hook if
+// configured. A panic in either step denies the action.
+func (s *Server) evaluate(ctx context.Context, action *model.Action) (result model.PolicyResult) {
+	defer policy.RecoverEvaluation(&result)
+	var traceID string
+	func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		traceID = s.tracer.State.TraceID
+		result = policy.Evaluate(action, s.tracer.State, s.purpose, s.agentID, s.dl, s.policyCfg)
+	}()
+	// The decision hook calls out over HTTP, so it runs after the trace
+	// lock is released.
+	return s.hook.Decide(ctx, action, traceID, s.purpose, s.agentID, result)
+}
 
+// recordDecision appends the decision made for an MCP tool to the trace.
+func (s *Server) recordDecision(tool string, action *model.Action, result model.PolicyResult) {
 	s.mu.Lock()
-	result := policy.Evaluate(action, s.tracer.State, s.purpose, s.agentID, s.dl, s.policyCfg)
+	defer s.mu.Unlock()
 	s.tracer.RecordAction(
-		map[string]any{"mcp": "chainwatch_http"},
+		map[string]any{"mcp": tool},
 		s.purpose, action,
 		map[string]any{
 			"result":       string(result.Decision),
 			"reason":       result.Reason,
 			"policy_id":    result.PolicyID,
 			"approval_key": result.ApprovalKey,
+			"tier":         result.Tier,
 		}, "",
 	)
-	s.mu.Unlock()
+}
+
+func (s *Server) handleHTTP(ctx context.Context, req *mcpsdk.CallToolRequest, input HTTPInput) (*mcpsdk.CallToolResult, HTTPOutput, error) {
+	if input.Method == "" {
+		input.Method = "GET"
+	}
+
+	// Build action for policy evaluation
+	action := buildHTTPAction(input)
+
+	result := s.evaluate(ctx, action)
+	s.recordDecision("chainwatch_http", action, result)
 
 	s.recordAudit(action, string(result.Decision), result.Reason, result.Tier)
-	s.dispatchAlert(action, string(result.Decision), result.Reason, result.Tier)
+	s.dispatchAlert(action, result)
 
 	// Break-glass override (CW-23.2)
 	if result.Tier >= 2 && s.bgStore != nil {
@@ -166,7 +306,7 @@ func (s *Server) handleHTTP(ctx context.Context, req *mcpsdk.CallToolRequest, in
 				s.auditLog.Record(audit.AuditEntry{
 					Timestamp:        time.Now().UTC().Format("2006-01-02T15:04:05.000Z"),
 					TraceID:          s.tracer.State.TraceID,
-					Action:           audit.AuditAction{Tool: action.Tool, Resource: action.Resource},
+					Action:           audit.AuditAction{Tool: action.Tool, Resource: action.Resource, RawResource: action.RawResource, Labels: action.Labels, Fingerprint: action.Fingerprint()},
 					Decision:         "allow",
 					Reason:           result.Reason,
 					Tier:             result.Tier,
@@ -182,21 +322,66 @@ func (s *Server) handleHTTP(ctx context.Context, req *mcpsdk.CallToolRequest, in
 		}
 	}
 
-	// Check decision
-	if result.Decision == model.Deny {
+	// Check decision (HTTP effects cannot be quarantined — fail closed)
+	if result.Decision == model.Deny || result.Decision == model.Quarantine {
 		out := HTTPOutput{
 			Blocked:     true,
 			Decision:    string(result.Decision),
 			Reason:      result.Reason,
 			ApprovalKey: result.ApprovalKey,
+			Suggestion:  result.Suggestion,
+			Guidance:    s.policyCfg.DenialGuidance.Render(result),
 		}
 		return &mcpsdk.CallToolResult{IsError: true}, out, nil
 	}
 
 	if result.Decision == model.RequireApproval && result.ApprovalKey != "" {
-		status, _ := s.approvals.Check(result.ApprovalKey)
-		if status == approval.StatusApproved {
+		status, _ := s.approvals.CheckTrace(result.ApprovalKey, s.tracer.State.TraceID)
+		if status == approval.StatusApproved && s.policyCfg.ConfirmRequired(result, s.tracer.State) {
+			result = s.approvals.ConfirmApproved(result, action, input.ConfirmToken, s.policyCfg.ConfirmTTL())
+			if result.Decision != model.Allow {
+				out := HTTPOutput{
+					Blocked:      true,
+					Decision:     string(result.Decision),
+					Reason:       result.Reason,
+					ApprovalKey:  result.ApprovalKey,
+					ConfirmToken: result.ConfirmToken,
+				}
+				return &mcpsdk.CallToolResult{IsError: true}, out, nil
+			}
+			// confirmed: fall through to execute
+		} else if status == approval.StatusApproved {
 			s.approvals.Consume(result.ApprovalKey)
+			if err := s.approvals.OpenGrace(result, s.tracer.State.TraceID, s.agentID); err != nil {
+				fmt.Fprintf(os.Stderr, "approval grace not opened: %v\n", err)
+			}
+			// fall through to execute
+		} else if status == approval.StatusThrottled {
+			result = s.approvals.ThrottleDeny(result)
+			s.dispatchAlert(action, result)
+			out := HTTPOutput{
+				Blocked:     true,
+				Decision:    string(result.Decision),
+				Reason:      result.Reason,
+				ApprovalKey: result.ApprovalKey,
+				Guidance:    s.policyCfg.DenialGuidance.Render(result),
+			}
+			return &mcpsdk.CallToolResult{IsError: true}, out, nil
+		} else if graced, ok := s.approvals.Grace(result, s.tracer.State.TraceID, s.agentID); ok && !s.policyCfg.ConfirmRequired(result, s.tracer.State) {
+			result = graced
+			if s.auditLog != nil {
+				s.auditLog.Record(audit.AuditEntry{
+					Timestamp:  time.Now().UTC().Format("2006-01-02T15:04:05.000Z"),
+					TraceID:    s.tracer.State.TraceID,
+					AgentID:    s.agentID,
+					Action:     audit.AuditAction{Tool: action.Tool, Resource: action.Resource, RawResource: action.RawResource, Labels: action.Labels, Fingerprint: action.Fingerprint()},
+					Decision:   string(result.Decision),
+					Reason:     result.Reason,
+					Tier:       result.Tier,
+					PolicyHash: s.policyHash,
+					Type:       "approval_grace",
+				})
+			}
 			// fall through to execute
 		} else {
 			if status != approval.StatusPending && status != approval.StatusDenied {
@@ -207,6 +392,7 @@ func (s *Server) handleHTTP(ctx context.Context, req *mcpsdk.CallToolRequest, in
 				Decision:    string(result.Decision),
 				Reason:      result.Reason,
 				ApprovalKey: result.ApprovalKey,
+				Guidance:    s.policyCfg.DenialGuidance.Render(result),
 			}
 			return &mcpsdk.CallToolResult{IsError: true}, out, nil
 		}
@@ -215,6 +401,7 @@ func (s *Server) handleHTTP(ctx context.Context, req *mcpsdk.CallToolRequest, in
 			Blocked:  true,
 			Decision: string(result.Decision),
 			Reason:   result.Reason,
+			Guidance: s.policyCfg.DenialGuidance.Render(result),
 		}
 		return &mcpsdk.CallToolResult{IsError: true}, out, nil
 	}
@@ -261,21 +448,10 @@ func (s *Server) handleHTTP(ctx context.Context, req *mcpsdk.CallToolRequest, in
 }
 
 func (s *Server) handleCheck(ctx context.Context, req *mcpsdk.CallToolRequest, input CheckInput) (*mcpsdk.CallToolResult, CheckOutput, error) {
-	action := buildCheckAction(input)
+	action := buildCheckAction(input, s.envPassthrough)
 
-	s.mu.Lock()
-	result := policy.Evaluate(action, s.tracer.State, s.purpose, s.agentID, s.dl, s.policyCfg)
-	s.tracer.RecordAction(
-		map[string]any{"mcp": "chainwatch_check"},
-		s.purpose, action,
-		map[string]any{
-			"result":       string(result.Decision),
-			"reason":       result.Reason,
-			"policy_id":    result.PolicyID,
-			"approval_key": result.ApprovalKey,
-		}, "",
-	)
-	s.mu.Unlock()
+	result := s.evaluate(ctx, action)
+	s.recordDecision("chainwatch_check", action, result)
 
 	s.recordAudit(action, string(result.Decision), result.Reason, result.Tier)
 
@@ -284,6 +460,7 @@ func (s *Server) handleCheck(ctx context.Context, req *mcpsdk.CallToolRequest, i
 		Reason:      result.Reason,
 		PolicyID:    result.PolicyID,
 		ApprovalKey: result.ApprovalKey,
+		Suggestion:  result.Suggestion,
 	}, nil
 }
 
@@ -297,13 +474,21 @@ func (s *Server) handleApprove(ctx context.Context, req *mcpsdk.CallToolRequest,
 		}
 	}
 
-	if err := s.approvals.Approve(input.Key, duration, s.agentID); err != nil {
+	err := s.approvals.ApproveWith(input.Key, approval.ApproveOptions{
+		Duration:      duration,
+		ApprovedBy:    s.agentID,
+		TraceID:       input.Trace,
+		Justification: input.Reason,
+	})
+	if err != nil {
 		return nil, ApproveOutput{}, err
 	}
+	s.recordApprovalAudit(input.Key, strings.TrimSpace(input.Reason))
 
 	out := ApproveOutput{
 		Key:    input.Key,
 		Status: "approved",
+		Trace:  input.Trace,
 	}
 	if duration > 0 {
 		out.Duration = duration.String()
@@ -331,21 +516,76 @@ func (s *Server) handlePending(ctx context.Context, req *mcpsdk.CallToolRequest,
 	return nil, PendingOutput{Approvals: items}, nil
 }
 
+func (s *Server) handleBreakGlassList(ctx context.Context, req *mcpsdk.CallToolRequest, input BreakGlassListInput) (*mcpsdk.CallToolResult, BreakGlassListOutput, error) {
+	if s.bgStore == nil {
+		return nil, BreakGlassListOutput{}, fmt.Errorf("break-glass store unavailable")
+	}
+	tokens, err := s.bgStore.List()
+	if err != nil {
+		return nil, BreakGlassListOutput{}, err
+	}
+
+	items := make([]BreakGlassItem, len(tokens))
+	for i, t := range tokens {
+		items[i] = BreakGlassItem{
+			ID:        t.ID,
+			Status:    t.Status(),
+			Reason:    t.Reason,
+			CreatedAt: t.CreatedAt.Format(time.RFC3339),
+			ExpiresAt: t.ExpiresAt.Format(time.RFC3339),
+		}
+	}
+
+	return nil, BreakGlassListOutput{Tokens: items}, nil
+}
+
+func (s *Server) handleBreakGlassRevoke(ctx context.Context, req *mcpsdk.CallToolRequest, input BreakGlassRevokeInput) (*mcpsdk.CallToolResult, BreakGlassRevokeOutput, error) {
+	if s.bgStore == nil {
+		return nil, BreakGlassRevokeOutput{}, fmt.Errorf("break-glass store unavailable")
+	}
+	if err := s.bgStore.Revoke(input.ID); err != nil {
+		return nil, BreakGlassRevokeOutput{}, err
+	}
+
+	if s.auditLog != nil {
+		s.auditLog.Record(audit.AuditEntry{
+			Timestamp:  time.Now().UTC().Format("2006-01-02T15:04:05.000Z"),
+			TraceID:    s.tracer.State.TraceID,
+			AgentID:    s.agentID,
+			Action:     audit.AuditAction{Tool: "breakglass", Resource: input.ID},
+			Decision:   "revoked",
+			Reason:     "break-glass token revoked via MCP",
+			PolicyHash: s.policyHash,
+			Type:       "break_glass_revoked",
+			TokenID:    input.ID,
+		})
+	}
+
+	return nil, BreakGlassRevokeOutput{ID: input.ID, Status: "revoked"}, nil
+}
+
 // --- Action builders ---
 
 func buildHTTPAction(input HTTPInput) *model.Action {
-	method := strings.ToLower(input.Method)
-	sensitivity, tags := classifyURLSensitivity(input.URL)
+	method := model.NormalizeHTTPMethod(input.Method)
+	resource, rawResource := model.DecodeResource(input.URL)
+	sensitivity, tags := classifyURLSensitivity(resource)
+	if bodySens, bodyTags := classifyBodySensitivity(input.Body); len(bodyTags) > 0 {
+		if model.SensRank[bodySens] > model.SensRank[sensitivity] {
+			sensitivity = bodySens
+		}
+		tags = append(tags, bodyTags...)
+	}
 
 	egress := model.EgressExternal
-	lower := strings.ToLower(input.URL)
+	lower := strings.ToLower(resource)
 	if strings.Contains(lower, "localhost") || strings.Contains(lower, "127.0.0.1") {
 		egress = model.EgressInternal
 	}
 
 	return &model.Action{
 		Tool:      "http_proxy",
-		Resource:  input.URL,
+		Resource:  resource,
 		Operation: method,
 		Params: map[string]any{
 			"method": input.Method,
@@ -357,12 +597,14 @@ func buildHTTPAction(input HTTPInput) *model.Action {
 			"bytes":       len(input.Body),
 			"rows":        0,
 			"egress":      string(egress),
-			"destination": extractHost(input.URL),
+			"destination": extractHost(resource),
 		},
+		Payload:     input.Body,
+		RawResource: rawResource,
 	}
 }
 
-func buildCheckAction(input CheckInput) *model.Action {
+func buildCheckAction(input CheckInput, passthrough []string) *model.Action {
 	tool := input.Tool
 	if tool == "" {
 		tool = "command"
@@ -372,14 +614,25 @@ func buildCheckAction(input CheckInput) *model.Action {
 		op = "execute"
 	}
 
+	resource, rawResource := input.Resource, ""
+	if tool != "command" {
+		resource, rawResource = model.DecodeResource(resource)
+	}
+
 	sensitivity := model.SensLow
 	var tags []string
 
+	labels := input.Labels
 	switch tool {
 	case "command":
-		sensitivity, tags = classifyCommandSensitivity(input.Resource)
+		sensitivity, tags = classifyCommandSensitivity(resource, passthrough)
+		if refs := cmdguard.CredentialReferenceLabels(resource, passthrough...); refs != nil {
+			// Caller-supplied labels win; the input map is not modified.
+			labels = refs
+			maps.Copy(labels, input.Labels)
+		}
 	case "http_proxy":
-		sensitivity, tags = classifyURLSensitivity(input.Resource)
+		sensitivity, tags = classifyURLSensitivity(resource)
 	}
 
 	egress := model.EgressInternal
@@ -387,24 +640,56 @@ func buildCheckAction(input CheckInput) *model.Action {
 		egress = model.EgressExternal
 	}
 
+	var bytes, rows int
+	if tool == "file_read" {
+		bytes, rows = model.FileReadVolume(resource, map[string]any{"bytes": input.Bytes, "rows": input.Rows})
+	}
+
 	return &model.Action{
 		Tool:      tool,
-		Resource:  input.Resource,
+		Resource:  resource,
 		Operation: op,
 		Params:    map[string]any{"resource": input.Resource},
 		RawMeta: map[string]any{
 			"sensitivity": string(sensitivity),
 			"tags":        toAnySlice(tags),
-			"bytes":       0,
-			"rows":        0,
+			"bytes":       bytes,
+			"rows":        rows,
 			"egress":      string(egress),
 			"destination": "",
 		},
+		Labels:      labels,
+		RawResource: rawResource,
 	}
 }
 
 // --- Helpers ---
 
+// blockedGuidance renders the policy's denial guidance for a command or
+// write blocked by the guard.
+func (s *Server) blockedGuidance(blocked *cmdguard.BlockedError) string {
+	return s.policyCfg.DenialGuidance.Render(model.PolicyResult{
+		Decision:    blocked.Decision,
+		Reason:      blocked.Reason,
+		PolicyID:    blocked.PolicyID,
+		ApprovalKey: blocked.ApprovalKey,
+		Suggestion:  blocked.Suggestion,
+	})
+}
+
+// truncateOutput cuts s to at most limit bytes on a UTF-8 boundary and
+// appends truncatedMarker. A limit of zero or less disables truncation.
+func truncateOutput(s string, limit int) (string, bool) {
+	if limit <= 0 || len(s) <= limit {
+		return s, false
+	}
+	cut := limit
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + truncatedMarker, true
+}
+
 func classifyURLSensitivity(url string) (model.Sensitivity, []string) {
 	lower := strings.ToLower(url)
 	payment := []string{"/checkout", "/payment", "/billing", "stripe.com", "paypal.com"}
@@ -425,10 +710,51 @@ func classifyURLSensitivity(url string) (model.Sensitivity, []string) {
 			return model.SensHigh, []string{"sensitive"}
 		}
 	}
+	if strings.Contains(lower, "git-receive-pack") {
+		return model.SensMedium, []string{"vcs_write"}
+	}
+	return model.SensLow, nil
+}
+
+// classifyBodySensitivity inspects an outgoing request body. Secrets are
+// found with the same scanners cmdguard uses on command output, including
+// base64-encoded secrets, plus password=/token= style pairs; email
+// addresses count as PII. The "secret"
+// and "pii" tags place the action in the credential-adjacent and
+// sensitive-data zones, so sending them escalates the tier.
+func classifyBodySensitivity(body string) (model.Sensitivity, []string) {
+	if body == "" {
+		return model.SensLow, nil
+	}
+	var secret, pii bool
+	if len(cmdguard.DetectSecrets(body)) > 0 {
+		secret = true
+	}
+	// Secrets base64-encoded to slip past the plain-text scan.
+	if _, n := redact.ScanBase64(body); n > 0 {
+		secret = true
+	}
+	for _, m := range redact.Scan(body) {
+		switch m.Type {
+		case redact.PatternCred:
+			secret = true
+		case redact.PatternEmail:
+			pii = true
+		}
+	}
+
+	switch {
+	case secret && pii:
+		return model.SensHigh, []string{zone.TagSecret, zone.TagPII}
+	case secret:
+		return model.SensHigh, []string{zone.TagSecret}
+	case pii:
+		return model.SensMedium, []string{zone.TagPII}
+	}
 	return model.SensLow, nil
 }
 
-func classifyCommandSensitivity(cmd string) (model.Sensitivity, []string) {
+func classifyCommandSensitivity(cmd string, passthrough []string) (model.Sensitivity, []string) {
 	lower := strings.ToLower(cmd)
 	destructive := []string{"rm -rf", "dd if=", "mkfs", "chmod -r 777"}
 	for _, p := range destructive {
@@ -436,12 +762,21 @@ func classifyCommandSensitivity(cmd string) (model.Sensitivity, []string) {
 			return model.SensHigh, []string{"destructive"}
 		}
 	}
+	if len(cmdguard.SensitiveEnvRefs(cmd, passthrough...)) > 0 {
+		return model.SensHigh, []string{cmdguard.TagCredentialReference}
+	}
 	credential := []string{"sudo", "passwd", "ssh-keygen"}
 	for _, p := range credential {
 		if strings.Contains(lower, p) {
 			return model.SensHigh, []string{"credential"}
 		}
 	}
+	if strings.Contains(lower, "git push") {
+		return model.SensMedium, []string{"vcs_write"}
+	}
+	if strings.Contains(lower, "/var/log/") {
+		return model.SensMedium, []string{"system_log"}
+	}
 	return model.SensLow, nil
 }
 
@@ -3,6 +3,7 @@ package mcp
 import (
 	"context"
 	"fmt"
+	"net"
 	"sync"
 
 	mcpsdk "github.com/modelcontextprotocol/go-sdk/mcp"
@@ -14,7 +15,9 @@ import (
 	"github.com/ppiankov/chainwatch/internal/audit"
 	"github.com/ppiankov/chainwatch/internal/breakglass"
 	"github.com/ppiankov/chainwatch/internal/cmdguard"
+	"github.com/ppiankov/chainwatch/internal/decisionhook"
 	"github.com/ppiankov/chainwatch/internal/denylist"
+	"github.com/ppiankov/chainwatch/internal/listen"
 	"github.com/ppiankov/chainwatch/internal/model"
 	"github.com/ppiankov/chainwatch/internal/policy"
 	"github.com/ppiankov/chainwatch/internal/profile"
@@ -29,6 +32,24 @@ type Config struct {
 	Purpose      string
 	AgentID      string
 	AuditLogPath string
+
+	// DecisionCacheTTL enables caching of repeated identical exec decisions.
+	DecisionCacheTTL time.Duration
+
+	// QuarantineDir receives chainwatch_write writes with a quarantine decision.
+	QuarantineDir string
+
+	// MaxOutputBytes caps stdout and stderr in chainwatch_exec responses,
+	// independent of the guard's capture limit. Zero means no cap.
+	MaxOutputBytes int
+
+	// Listen serves MCP sessions on "unix:///path.sock" or a host:port
+	// instead of stdio, one session per connection. Empty means stdio.
+	Listen string
+
+	// EnvPassthrough names env vars kept for chainwatch_exec commands even
+	// when a sensitive prefix would strip them.
+	EnvPassthrough []string
 }
 
 // Server wraps the MCP SDK server with chainwatch policy enforcement.
@@ -43,9 +64,14 @@ type Server struct {
 	tracer     *tracer.TraceAccumulator
 	auditLog   *audit.Log
 	policyHash string
+	hook       *decisionhook.Hook
 	purpose    string
 	agentID    string
 	mu         sync.Mutex
+
+	maxOutputBytes int
+	listen         string
+	envPassthrough []string
 }
 
 // New creates an MCP server with loaded policy, denylist, and tools.
@@ -70,11 +96,13 @@ func New(cfg Config) (*Server, error) {
 		policyCfg = profile.ApplyToPolicy(prof, policyCfg)
 	}
 
-	approvalStore, err := approval.NewStore(approval.DefaultDir())
+	approvalStore, err := approval.Open(approval.DefaultDir(), policyCfg.ApprovalStore)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create approval store: %w", err)
 	}
 	approvalStore.Cleanup()
+	approvalStore.SetThrottle(policyCfg.ApprovalThrottle)
+	approvalStore.SetReasonRequired(policyCfg.ReasonRequiredKeys())
 
 	// Create cmdguard for exec tool
 	guardCfg := cmdguard.Config{
@@ -85,6 +113,10 @@ func New(cfg Config) (*Server, error) {
 		AgentID:      cfg.AgentID,
 		Actor:        map[string]any{"mcp": "chainwatch"},
 		AuditLogPath: cfg.AuditLogPath,
+
+		DecisionCacheTTL: cfg.DecisionCacheTTL,
+		QuarantineDir:    cfg.QuarantineDir,
+		EnvPassthrough:   cfg.EnvPassthrough,
 	}
 	guard, err := cmdguard.NewGuard(guardCfg)
 	if err != nil {
@@ -98,7 +130,7 @@ func New(cfg Config) (*Server, error) {
 
 	var auditLog *audit.Log
 	if cfg.AuditLogPath != "" {
-		auditLog, err = audit.Open(cfg.AuditLogPath)
+		auditLog, err = audit.OpenWithSinks(cfg.AuditLogPath, policyCfg.AuditSinks)
 		if err != nil {
 			return nil, fmt.Errorf("failed to open audit log: %w", err)
 		}
@@ -116,8 +148,13 @@ func New(cfg Config) (*Server, error) {
 		tracer:     tracer.NewAccumulator(tracer.NewTraceID()),
 		auditLog:   auditLog,
 		policyHash: policyHash,
+		hook:       decisionhook.New(policyCfg.DecisionHook),
 		purpose:    purpose,
 		agentID:    cfg.AgentID,
+
+		maxOutputBytes: cfg.MaxOutputBytes,
+		listen:         cfg.Listen,
+		envPassthrough: cfg.EnvPassthrough,
 	}
 
 	s.mcpServer = mcpsdk.NewServer(
@@ -132,9 +169,51 @@ func New(cfg Config) (*Server, error) {
 	return s, nil
 }
 
-// Run starts the MCP server on stdio transport. Blocks until ctx is cancelled.
+// Run starts the MCP server on stdio transport, or on Config.Listen when
+// set. Blocks until ctx is cancelled.
 func (s *Server) Run(ctx context.Context) error {
-	return s.mcpServer.Run(ctx, &mcpsdk.StdioTransport{})
+	if s.listen == "" {
+		return s.mcpServer.Run(ctx, &mcpsdk.StdioTransport{})
+	}
+	lis, err := listen.Listen(s.listen)
+	if err != nil {
+		return err
+	}
+	return s.ServeOn(ctx, lis)
+}
+
+// ServeOn accepts connections on lis, each carrying one MCP session of
+// newline-delimited JSON-RPC as on stdio. Sessions share the server's
+// trace. Blocks until ctx is cancelled, then closes lis (removing a Unix
+// socket file) and every open session.
+func (s *Server) ServeOn(ctx context.Context, lis net.Listener) error {
+	stop := context.AfterFunc(ctx, func() { lis.Close() })
+	defer stop()
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	for {
+		conn, err := lis.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			lis.Close()
+			return err
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ss, err := s.mcpServer.Connect(ctx, &mcpsdk.IOTransport{Reader: conn, Writer: conn}, nil)
+			if err != nil {
+				conn.Close()
+				return
+			}
+			closeOnCancel := context.AfterFunc(ctx, func() { ss.Close() })
+			defer closeOnCancel()
+			ss.Wait()
+		}()
+	}
 }
 
 // Close closes the audit log if configured.
@@ -152,17 +231,18 @@ func (s *Server) TraceSummary() map[string]any {
 	return s.tracer.ToJSON()
 }
 
-func (s *Server) dispatchAlert(action *model.Action, decision, reason string, tier int) {
+func (s *Server) dispatchAlert(action *model.Action, result model.PolicyResult) {
 	if s.dispatcher != nil {
 		s.dispatcher.Dispatch(alert.AlertEvent{
 			Timestamp:  time.Now().UTC().Format("2006-01-02T15:04:05.000Z"),
 			TraceID:    s.tracer.State.TraceID,
 			Tool:       action.Tool,
 			Resource:   action.Resource,
-			Decision:   decision,
-			Reason:     reason,
-			Tier:       tier,
+			Decision:   string(result.Decision),
+			Reason:     result.Reason,
+			Tier:       result.Tier,
 			PolicyHash: s.policyHash,
+			Rule:       alert.RuleAlert{Mode: result.AlertMode, Channels: result.AlertChannels},
 		})
 	}
 }
@@ -188,7 +268,7 @@ func (s *Server) recordAudit(action *model.Action, decision, reason string, tier
 		s.auditLog.Record(audit.AuditEntry{
 			Timestamp:  time.Now().UTC().Format("2006-01-02T15:04:05.000Z"),
 			TraceID:    s.tracer.State.TraceID,
-			Action:     audit.AuditAction{Tool: action.Tool, Resource: action.Resource},
+			Action:     audit.AuditAction{Tool: action.Tool, Resource: action.Resource, RawResource: action.RawResource, Labels: action.Labels, Fingerprint: action.Fingerprint()},
 			Decision:   decision,
 			Reason:     reason,
 			Tier:       tier,
@@ -197,6 +277,23 @@ func (s *Server) recordAudit(action *model.Action, decision, reason string, tier
 	}
 }
 
+// recordApprovalAudit records an approval granted through chainwatch_approve.
+func (s *Server) recordApprovalAudit(key, justification string) {
+	if s.auditLog != nil {
+		s.auditLog.Record(audit.AuditEntry{
+			Timestamp:     time.Now().UTC().Format("2006-01-02T15:04:05.000Z"),
+			TraceID:       s.tracer.State.TraceID,
+			AgentID:       s.agentID,
+			Action:        audit.AuditAction{Tool: "approval", Resource: key},
+			Decision:      "approved",
+			Reason:        "approved via MCP",
+			PolicyHash:    s.policyHash,
+			Type:          "approval_approved",
+			Justification: justification,
+		})
+	}
+}
+
 // registerTools adds all chainwatch tools to the MCP server.
 func (s *Server) registerTools() {
 	mcpsdk.AddTool(s.mcpServer, &mcpsdk.Tool{
@@ -209,6 +306,11 @@ func (s *Server) registerTools() {
 		Description: "Make an HTTP request through chainwatch policy enforcement. Blocked requests return an error with the reason.",
 	}, s.handleHTTP)
 
+	mcpsdk.AddTool(s.mcpServer, &mcpsdk.Tool{
+		Name:        "chainwatch_write",
+		Description: "Write a file through chainwatch policy enforcement. Quarantined writes are redirected to the quarantine directory; blocked writes return an error with the reason.",
+	}, s.handleWrite)
+
 	mcpsdk.AddTool(s.mcpServer, &mcpsdk.Tool{
 		Name:        "chainwatch_check",
 		Description: "Check if an action would be allowed by chainwatch policy without executing it (dry-run).",
@@ -223,4 +325,14 @@ func (s *Server) registerTools() {
 		Name:        "chainwatch_pending",
 		Description: "List all pending approval requests.",
 	}, s.handlePending)
+
+	mcpsdk.AddTool(s.mcpServer, &mcpsdk.Tool{
+		Name:        "chainwatch_breakglass_list",
+		Description: "List break-glass emergency override tokens and their status (active, used, revoked, expired).",
+	}, s.handleBreakGlassList)
+
+	mcpsdk.AddTool(s.mcpServer, &mcpsdk.Tool{
+		Name:        "chainwatch_breakglass_revoke",
+		Description: "Revoke a break-glass token so it can no longer override a denial. The revocation is recorded in the audit log.",
+	}, s.handleBreakGlassRevoke)
 }
@@ -0,0 +1,28 @@
+package model
+
+import "os"
+
+// FileReadVolume estimates the bytes and rows of a file read. When path is
+// a locally accessible regular file its stat size wins, so a tool call
+// cannot understate the size to slip under a min_bytes rule; a size
+// declared in args ("bytes"/"size") is only a fallback. Rows come from
+// args ("rows"/"lines"). Unknown values are zero.
+func FileReadVolume(path string, args map[string]any) (bytes, rows int) {
+	rows = firstInt(args, "rows", "lines")
+	if path != "" {
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			return int(info.Size()), rows
+		}
+	}
+	return firstInt(args, "bytes", "size"), rows
+}
+
+// firstInt returns the first positive integer among args[keys].
+func firstInt(args map[string]any, keys ...string) int {
+	for _, k := range keys {
+		if n := toInt(args[k]); n > 0 {
+			return n
+		}
+	}
+	return 0
+}
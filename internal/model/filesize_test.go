@@ -0,0 +1,32 @@
+package model
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileReadVolumeStatWinsOverDeclaredSize(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dump.sql")
+	if err := os.WriteFile(path, make([]byte, 4096), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	bytes, rows := FileReadVolume(path, map[string]any{"bytes": 1, "rows": 10})
+	if bytes != 4096 {
+		t.Errorf("expected stat size 4096, got %d", bytes)
+	}
+	if rows != 10 {
+		t.Errorf("expected declared rows 10, got %d", rows)
+	}
+}
+
+func TestFileReadVolumeFallsBackToDeclaredSize(t *testing.T) {
+	missing := filepath.Join(t.TempDir(), "remote.csv")
+	if bytes, _ := FileReadVolume(missing, map[string]any{"size": 2048}); bytes != 2048 {
+		t.Errorf("expected declared size 2048, got %d", bytes)
+	}
+	if bytes, _ := FileReadVolume("", nil); bytes != 0 {
+		t.Errorf("expected 0 for unknown size, got %d", bytes)
+	}
+}
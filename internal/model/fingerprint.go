@@ -0,0 +1,34 @@
+package model
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sort"
+)
+
+// Fingerprint returns a stable "sha256:<hex>" digest identifying the action
+// by tool, resource, operation and the normalized metadata that drives
+// classification (sensitivity, egress, destination, tags, rows). Params,
+// labels and payload are excluded, so the same operation fingerprints the
+// same on every instance and can be correlated with external logs.
+func (a *Action) Fingerprint() string {
+	meta := a.NormalizedMeta()
+	tags := append([]string(nil), meta.Tags...)
+	sort.Strings(tags)
+
+	// A JSON array keeps field boundaries unambiguous and its encoding is
+	// deterministic for these types.
+	canonical, _ := json.Marshal([]any{
+		a.Tool,
+		a.Resource,
+		a.Operation,
+		meta.Sensitivity,
+		meta.Egress,
+		meta.Destination,
+		tags,
+		meta.Rows,
+	})
+	sum := sha256.Sum256(canonical)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
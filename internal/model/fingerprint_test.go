@@ -0,0 +1,59 @@
+package model
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestFingerprintStableForEquivalentActions(t *testing.T) {
+	a := &Action{
+		Tool:      "http_request",
+		Resource:  "https://api.example.com/v1",
+		Operation: "post",
+		Params:    map[string]any{"body": "first"},
+		RawMeta:   map[string]any{"sensitivity": "high", "tags": []any{"pii", "credential"}, "egress": "external"},
+		Labels:    map[string]string{"tenant": "a"},
+	}
+	b := &Action{
+		Tool:      "http_request",
+		Resource:  "https://api.example.com/v1",
+		Operation: "post",
+		Params:    map[string]any{"body": "second"},
+		RawMeta:   map[string]any{"sensitivity": "high", "tags": []any{"credential", "pii"}, "egress": "external"},
+		Labels:    map[string]string{"tenant": "b"},
+	}
+	b.NormalizeMeta()
+
+	fp := a.Fingerprint()
+	if !strings.HasPrefix(fp, "sha256:") || len(fp) != len("sha256:")+64 {
+		t.Fatalf("unexpected fingerprint format %q", fp)
+	}
+	if fp != b.Fingerprint() {
+		t.Errorf("equivalent actions should share a fingerprint: %s vs %s", fp, b.Fingerprint())
+	}
+	if fp != a.Fingerprint() {
+		t.Error("fingerprint must be deterministic")
+	}
+}
+
+func TestFingerprintDiffersForDifferentActions(t *testing.T) {
+	base := func() *Action {
+		return &Action{Tool: "command", Resource: "ls /tmp", Operation: "execute"}
+	}
+	fp := base().Fingerprint()
+
+	variants := map[string]*Action{
+		"tool":        {Tool: "file_read", Resource: "ls /tmp", Operation: "execute"},
+		"resource":    {Tool: "command", Resource: "ls /var", Operation: "execute"},
+		"operation":   {Tool: "command", Resource: "ls /tmp", Operation: "read"},
+		"sensitivity": {Tool: "command", Resource: "ls /tmp", Operation: "execute", RawMeta: map[string]any{"sensitivity": "high"}},
+		"egress":      {Tool: "command", Resource: "ls /tmp", Operation: "execute", RawMeta: map[string]any{"egress": "external"}},
+		// Field boundaries must not be ambiguous.
+		"boundary": {Tool: "command", Resource: "ls /tmpexecute", Operation: ""},
+	}
+	for name, v := range variants {
+		if v.Fingerprint() == fp {
+			t.Errorf("%s: differing action shares fingerprint %s", name, fp)
+		}
+	}
+}
@@ -0,0 +1,29 @@
+package model
+
+import "strings"
+
+// NormalizeHTTPMethod returns the canonical operation for an HTTP method:
+// trimmed and lowercased, e.g. " PATCH " → "patch".
+func NormalizeHTTPMethod(method string) string {
+	return strings.ToLower(strings.TrimSpace(method))
+}
+
+// IsSafeHTTPMethod reports whether op is a read-only HTTP method
+// (GET, HEAD, OPTIONS) per RFC 9110 §9.2.1.
+func IsSafeHTTPMethod(op string) bool {
+	switch NormalizeHTTPMethod(op) {
+	case "get", "head", "options":
+		return true
+	}
+	return false
+}
+
+// IsMutatingHTTPMethod reports whether op is an HTTP method that changes
+// server state (POST, PUT, PATCH, DELETE).
+func IsMutatingHTTPMethod(op string) bool {
+	switch NormalizeHTTPMethod(op) {
+	case "post", "put", "patch", "delete":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package model
+
+import (
+	"path"
+	"strings"
+)
+
+// LooksLikePath reports whether a command argument is plausibly a file
+// path: it contains a slash or tilde, starts with a dot, or has an
+// extension.
+func LooksLikePath(tok string) bool {
+	if strings.ContainsAny(tok, "/~") || strings.HasPrefix(tok, ".") {
+		return true
+	}
+	return path.Ext(tok) != ""
+}
+
+// maxDecodeRounds bounds how many layers of encoding DecodeResource peels
+// off, e.g. %252f → %2f → /.
+const maxDecodeRounds = 3
+
+// DecodeResource undoes percent-encoding (%2e%2e%2f) and \xHH hex escapes
+// (\x2fetc\x2fpasswd) in a file path or URL, so sensitivity and denylist
+// patterns match what the resource actually names. Nested encodings are
+// decoded up to maxDecodeRounds deep; malformed escapes are left as is.
+// raw is the input when decoding changed it, otherwise "".
+func DecodeResource(s string) (decoded, raw string) {
+	decoded = s
+	for range maxDecodeRounds {
+		next := decodeEscapes(decoded)
+		if next == decoded {
+			break
+		}
+		decoded = next
+	}
+	if decoded == s {
+		return s, ""
+	}
+	return decoded, s
+}
+
+// decodeEscapes decodes one layer of %HH and \xHH escapes.
+func decodeEscapes(s string) string {
+	if !strings.Contains(s, "%") && !strings.Contains(s, `\x`) && !strings.Contains(s, `\X`) {
+		return s
+	}
+	var b strings.Builder
+	b.Grow(len(s))
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '%' && i+2 < len(s) && isHex(s[i+1]) && isHex(s[i+2]):
+			b.WriteByte(unhex(s[i+1])<<4 | unhex(s[i+2]))
+			i += 2
+		case s[i] == '\\' && i+3 < len(s) && (s[i+1] == 'x' || s[i+1] == 'X') && isHex(s[i+2]) && isHex(s[i+3]):
+			b.WriteByte(unhex(s[i+2])<<4 | unhex(s[i+3]))
+			i += 3
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String()
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	default:
+		return c - 'A' + 10
+	}
+}
@@ -0,0 +1,44 @@
+package model
+
+import "testing"
+
+func TestDecodeResource(t *testing.T) {
+	tests := []struct {
+		in, want string
+		changed  bool
+	}{
+		{"/etc/hosts", "/etc/hosts", false},
+		{"..%2f..%2fetc%2fpasswd", "../../etc/passwd", true},
+		{"%2e%2e%2F%2E%2E%2fetc", "../../etc", true},
+		{`\x2fetc\x2fpasswd`, "/etc/passwd", true},
+		{"%252e%252e%252fetc", "../etc", true},
+		{"https://example.com/a%20b", "https://example.com/a b", true},
+		{"100%", "100%", false},
+		{"%zz/%4", "%zz/%4", false},
+		{`C:\x`, `C:\x`, false},
+	}
+	for _, tt := range tests {
+		got, raw := DecodeResource(tt.in)
+		if got != tt.want {
+			t.Errorf("DecodeResource(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+		if (raw != "") != tt.changed || (tt.changed && raw != tt.in) {
+			t.Errorf("DecodeResource(%q) raw = %q, changed=%v", tt.in, raw, tt.changed)
+		}
+	}
+}
+
+func TestLooksLikePath(t *testing.T) {
+	for tok, want := range map[string]bool{
+		"/etc/passwd": true,
+		"~/notes":     true,
+		".env":        true,
+		"report.csv":  true,
+		"status":      false,
+		"-la":         false,
+	} {
+		if got := LooksLikePath(tok); got != want {
+			t.Errorf("LooksLikePath(%q) = %v, want %v", tok, got, want)
+		}
+	}
+}
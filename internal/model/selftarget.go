@@ -13,6 +13,12 @@ var selfTargetPatterns = []string{
 	".groq-key",
 }
 
+// SelfTargetPatterns returns the resource substrings that make an action
+// self-targeting, for documentation and export.
+func SelfTargetPatterns() []string {
+	return append([]string(nil), selfTargetPatterns...)
+}
+
 // IsSelfTargeting returns true if the action targets chainwatch itself.
 // Fail-closed: broad matching is intentionally conservative for safety.
 func IsSelfTargeting(action *Action) bool {
@@ -35,8 +35,37 @@ const (
 	AllowWithRedaction Decision = "allow_with_redaction"
 	RequireApproval    Decision = "require_approval"
 	RewriteOutput      Decision = "rewrite_output"
+
+	// Quarantine lets the action run with its effects contained (e.g. file
+	// writes redirected to a quarantine directory). Enforcement points that
+	// cannot contain an action must treat it as Deny.
+	Quarantine Decision = "quarantine"
 )
 
+// decisionRank orders decisions by how much they restrict execution.
+var decisionRank = map[Decision]int{
+	Allow:              0,
+	AllowWithRedaction: 1,
+	RewriteOutput:      2,
+	RequireApproval:    3,
+	Quarantine:         4,
+	Deny:               5,
+}
+
+// Rank orders d by how much it restricts execution, from Allow (0) to
+// Deny. Unknown decisions rank as Deny (fail-closed).
+func (d Decision) Rank() int {
+	if r, ok := decisionRank[d]; ok {
+		return r
+	}
+	return decisionRank[Deny]
+}
+
+// MoreRestrictive reports whether d restricts execution more than other.
+func (d Decision) MoreRestrictive(other Decision) bool {
+	return d.Rank() > other.Rank()
+}
+
 // ResultMeta is standardized metadata describing what a tool call returned.
 type ResultMeta struct {
 	Sensitivity Sensitivity     `json:"sensitivity"`
@@ -45,6 +74,11 @@ type ResultMeta struct {
 	Bytes       int             `json:"bytes"`
 	Egress      EgressDirection `json:"egress"`
 	Destination string          `json:"destination"`
+
+	// Destination classification from a GeoIP/ASN database, when configured.
+	DestCountry string `json:"dest_country,omitempty"`
+	DestASN     int    `json:"dest_asn,omitempty"`
+	DestASOrg   string `json:"dest_as_org,omitempty"`
 }
 
 // DefaultResultMeta returns a ResultMeta with safe defaults.
@@ -91,13 +125,16 @@ func ResultMetaFromMap(m map[string]any) ResultMeta {
 	if d, ok := m["destination"].(string); ok {
 		rm.Destination = d
 	}
+	rm.DestCountry, _ = m["dest_country"].(string)
+	rm.DestASN = toInt(m["dest_asn"])
+	rm.DestASOrg, _ = m["dest_as_org"].(string)
 
 	return rm
 }
 
 // ToMap converts ResultMeta to a map for serialization.
 func (rm ResultMeta) ToMap() map[string]any {
-	return map[string]any{
+	m := map[string]any{
 		"sensitivity": string(rm.Sensitivity),
 		"tags":        rm.Tags,
 		"rows":        rm.Rows,
@@ -105,6 +142,16 @@ func (rm ResultMeta) ToMap() map[string]any {
 		"egress":      string(rm.Egress),
 		"destination": rm.Destination,
 	}
+	if rm.DestCountry != "" {
+		m["dest_country"] = rm.DestCountry
+	}
+	if rm.DestASN != 0 {
+		m["dest_asn"] = rm.DestASN
+	}
+	if rm.DestASOrg != "" {
+		m["dest_as_org"] = rm.DestASOrg
+	}
+	return m
 }
 
 func toInt(v any) int {
@@ -128,6 +175,18 @@ type Action struct {
 	Params     map[string]any `json:"params"`
 	RawMeta    map[string]any `json:"result_meta"`
 	normalized *ResultMeta
+
+	// Labels is operator-supplied context (tenant, environment, ticket ID)
+	// recorded in audit and trace and matched by rule label selectors.
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// RawResource is the resource as received when it was percent- or
+	// hex-encoded; Resource holds the decoded form policy matches against.
+	RawResource string `json:"raw_resource,omitempty"`
+
+	// Payload is the outbound request body, when the enforcement point sees
+	// one. It is scanned for canary tokens and never recorded.
+	Payload string `json:"-"`
 }
 
 // NormalizedMeta returns the normalized ResultMeta, computing it if needed.
@@ -170,6 +229,52 @@ type TraceState struct {
 	// v0.5.0: rate limiting
 	ToolCallCounts       map[string]int `json:"tool_call_counts,omitempty"`
 	RateLimitWindowStart time.Time      `json:"rate_limit_window_start"`
+
+	// Per-trace action budget (policy max_actions_per_trace)
+	ActionCount int `json:"action_count"`
+}
+
+// Trace reset scopes.
+const (
+	ResetScopeAll         = "all"          // zones, seen sources, volume and counters
+	ResetScopeActionCount = "action_count" // only the max_actions_per_trace counter
+)
+
+// ResetActionCount clears the per-trace action budget counter and returns
+// the count before the reset.
+func (s *TraceState) ResetActionCount() int {
+	prev := s.ActionCount
+	s.ActionCount = 0
+	return prev
+}
+
+// Reset clears the accumulated risk of the trace — zones, seen sources,
+// volume, egress, tags, rate-limit counters and action count — while keeping
+// its trace, agent and session identity. Returns the state before the reset.
+func (s *TraceState) Reset() TraceState {
+	prev := *s
+	fresh := NewTraceState(s.TraceID)
+	fresh.AgentID = s.AgentID
+	fresh.SessionID = s.SessionID
+	*s = *fresh
+	return prev
+}
+
+// Clone returns a deep copy of the state, for speculative evaluation that
+// must not accumulate into the trace.
+func (s *TraceState) Clone() *TraceState {
+	c := *s
+	c.SeenSources = append([]string(nil), s.SeenSources...)
+	c.Tags = append([]string(nil), s.Tags...)
+	c.ZonesEntered = make(map[Zone]bool, len(s.ZonesEntered))
+	for z, v := range s.ZonesEntered {
+		c.ZonesEntered[z] = v
+	}
+	c.ToolCallCounts = make(map[string]int, len(s.ToolCallCounts))
+	for k, v := range s.ToolCallCounts {
+		c.ToolCallCounts[k] = v
+	}
+	return &c
 }
 
 // NewTraceState creates a TraceState with safe defaults.
@@ -215,4 +320,34 @@ type PolicyResult struct {
 	ApprovalKey   string         `json:"approval_key,omitempty"`
 	OutputRewrite string         `json:"output_rewrite,omitempty"`
 	PolicyID      string         `json:"policy_id,omitempty"`
+	AlertMode     string         `json:"alert_mode,omitempty"`     // per-rule alert override: force, suppress
+	AlertChannels []string       `json:"alert_channels,omitempty"` // per-rule alert channel restriction
+
+	// Suggestion is a safer alternative from the deny rule that matched,
+	// returned to the agent with the block.
+	Suggestion string `json:"suggestion,omitempty"`
+
+	// ApprovalGrace is the matched rule's approval grace window; see
+	// approval.Store.OpenGrace.
+	ApprovalGrace time.Duration `json:"approval_grace,omitempty"`
+
+	// RequireReason is set when the matched rule is require_reason: an
+	// approval of it, by an operator or the decision hook, must carry a
+	// justification.
+	RequireReason bool `json:"require_reason,omitempty"`
+
+	// ConfirmToken is issued when an approved irreversible action needs a
+	// second step; the retry of the identical action must present it.
+	ConfirmToken string `json:"confirm_token,omitempty"`
+
+	// Observed lists observe-mode rules that matched but were not enforced.
+	Observed []Observation `json:"observed,omitempty"`
+}
+
+// Observation records the decision an observe-mode rule would have made.
+type Observation struct {
+	PolicyID string   `json:"policy_id"`
+	Decision Decision `json:"decision"`
+	Reason   string   `json:"reason"`
+	Enforced bool     `json:"enforced"` // always false; explicit for log consumers
 }
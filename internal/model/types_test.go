@@ -56,6 +56,26 @@ func TestEscalateLevelMonotonic(t *testing.T) {
 	}
 }
 
+func TestResetClearsAccumulatedState(t *testing.T) {
+	state := NewTraceState("test")
+	state.AgentID = "agent-1"
+	state.EscalateLevel(Commitment)
+	state.ZonesEntered[ZoneCredentialAdjacent] = true
+	state.SeenSources = append(state.SeenSources, "/home/u/.ssh/id_rsa")
+	state.ActionCount = 7
+
+	prev := state.Reset()
+	if prev.Zone != Commitment || prev.ActionCount != 7 {
+		t.Errorf("expected previous zone Commitment with 7 actions, got %v/%d", prev.Zone, prev.ActionCount)
+	}
+	if state.Zone != Safe || len(state.ZonesEntered) != 0 || len(state.SeenSources) != 0 || state.ActionCount != 0 {
+		t.Errorf("expected cleared state, got %+v", state)
+	}
+	if state.TraceID != "test" || state.AgentID != "agent-1" {
+		t.Errorf("expected identity preserved, got trace=%s agent=%s", state.TraceID, state.AgentID)
+	}
+}
+
 func TestResultMetaFromMapDefensive(t *testing.T) {
 	// nil map → safe defaults
 	rm := ResultMetaFromMap(nil)
@@ -107,6 +127,18 @@ func TestHasSource(t *testing.T) {
 	}
 }
 
+func TestDecisionMoreRestrictive(t *testing.T) {
+	order := []Decision{Allow, AllowWithRedaction, RewriteOutput, RequireApproval, Quarantine, Deny}
+	for i := 1; i < len(order); i++ {
+		if !order[i].MoreRestrictive(order[i-1]) || order[i-1].MoreRestrictive(order[i]) {
+			t.Errorf("expected %s to be more restrictive than %s", order[i], order[i-1])
+		}
+	}
+	if Decision("bogus").Rank() != Deny.Rank() {
+		t.Error("expected unknown decision to rank as deny")
+	}
+}
+
 func TestBoundaryZoneString(t *testing.T) {
 	tests := []struct {
 		zone BoundaryZone
@@ -188,7 +188,7 @@ func (m *Monitor) scan() {
 					m.auditLog.Record(audit.AuditEntry{
 						Timestamp:        time.Now().UTC().Format("2006-01-02T15:04:05.000Z"),
 						TraceID:          m.tracer.State.TraceID,
-						Action:           audit.AuditAction{Tool: "syscall", Resource: proc.Command},
+						Action:           audit.AuditAction{Tool: "syscall", Resource: proc.Command, Fingerprint: action.Fingerprint()},
 						Decision:         "allow",
 						Reason:           reason,
 						Tier:             3,
@@ -275,6 +275,7 @@ func (m *Monitor) recordAction(proc ProcessInfo, rule Rule, decision, reason str
 	m.tracer.RecordAction(m.cfg.Actor, "root_monitor", action, map[string]any{
 		"result": decision,
 		"reason": reason,
+		"tier":   tier,
 	}, "")
 	m.mu.Unlock()
 
@@ -282,7 +283,7 @@ func (m *Monitor) recordAction(proc ProcessInfo, rule Rule, decision, reason str
 		m.auditLog.Record(audit.AuditEntry{
 			Timestamp: time.Now().UTC().Format("2006-01-02T15:04:05.000Z"),
 			TraceID:   m.tracer.State.TraceID,
-			Action:    audit.AuditAction{Tool: action.Tool, Resource: action.Resource},
+			Action:    audit.AuditAction{Tool: action.Tool, Resource: action.Resource, RawResource: action.RawResource, Labels: action.Labels, Fingerprint: action.Fingerprint()},
 			Decision:  decision,
 			Reason:    reason,
 			Tier:      tier,
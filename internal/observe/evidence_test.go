@@ -0,0 +1,105 @@
+package observe
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// listing returns n lines of file-listing style output.
+func listing(prefix string, n int) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "-rw-r--r-- 1 www www 4096 %s-%05d.php\n", prefix, i)
+	}
+	return b.String()
+}
+
+func TestCollectEvidenceCapsStepOutput(t *testing.T) {
+	out := listing("file", 5000)
+	result := &RunResult{Steps: []StepResult{
+		{Command: "ls -laR /var/www", Purpose: "list files", Output: out},
+	}}
+
+	evidence := CollectEvidenceWithLimits(result, EvidenceLimits{MaxStepBytes: 4096})
+
+	if len(evidence) > 4096+200 {
+		t.Fatalf("evidence not capped: %d bytes", len(evidence))
+	}
+	if !strings.Contains(evidence, "[truncated ") {
+		t.Error("expected truncation marker")
+	}
+	if !strings.Contains(evidence, "file-00000.php") {
+		t.Error("expected head of output kept")
+	}
+	if !strings.Contains(evidence, "file-04999.php") {
+		t.Error("expected tail of output kept")
+	}
+	// Cuts fall on line boundaries: every kept listing line is whole.
+	for _, line := range strings.Split(evidence, "\n") {
+		if strings.Contains(line, "file-") && !strings.HasPrefix(line, "-rw-r--r--") {
+			t.Errorf("partial line kept: %q", line)
+		}
+	}
+}
+
+func TestCollectEvidenceTotalCapPrefersAnomalies(t *testing.T) {
+	result := &RunResult{Steps: []StepResult{
+		{Command: "ls -laR /var/www", Purpose: "list files", Output: listing("file", 3000)},
+		{Command: "tail /var/log/nginx/error.log", Purpose: "recent errors", Output: "2026/01/02 [error] upstream timed out\n" + listing("err", 100)},
+		{Command: "du -a /tmp", Purpose: "temp usage", Output: listing("tmp", 3000)},
+	}}
+
+	evidence := CollectEvidenceWithLimits(result, EvidenceLimits{MaxStepBytes: -1, MaxTotalBytes: 16 << 10})
+
+	if len(evidence) > 16<<10 {
+		t.Fatalf("evidence exceeds total cap: %d bytes", len(evidence))
+	}
+	if !strings.Contains(evidence, "upstream timed out") || !strings.Contains(evidence, "err-00099.php") {
+		t.Error("anomalous step should be kept whole")
+	}
+	for _, header := range []string{"=== list files ===", "=== recent errors ===", "=== temp usage ==="} {
+		if !strings.Contains(evidence, header) {
+			t.Errorf("missing step header %q", header)
+		}
+	}
+	if strings.Count(evidence, "[truncated ") < 1 {
+		t.Error("expected truncation markers on the verbose steps")
+	}
+}
+
+func TestCollectEvidenceUnderCapUnchanged(t *testing.T) {
+	result := &RunResult{Steps: []StepResult{
+		{Command: "uname -a", Purpose: "identify system", Output: "Linux prod 5.15.0"},
+	}}
+	want := "=== identify system ===\n$ uname -a\nLinux prod 5.15.0\n\n"
+	if got := CollectEvidence(result); got != want {
+		t.Errorf("evidence = %q, want %q", got, want)
+	}
+}
+
+func TestTruncatedEvidenceStillRedacted(t *testing.T) {
+	config := listing("cfg", 2000) + "<password>hunter2</password>\n<host>db1.internal</host>\n"
+	result := &RunResult{Steps: []StepResult{
+		{Command: "cat /etc/clickhouse-server/users.xml", Purpose: "read config", Output: config},
+	}}
+
+	evidence := CollectEvidenceWithLimits(result, EvidenceLimits{MaxStepBytes: 2048})
+	if !strings.Contains(evidence, "[truncated ") {
+		t.Fatal("expected evidence to be truncated")
+	}
+
+	redacted, n := RedactEvidence(evidence, DefaultRedactRules())
+	if n == 0 {
+		t.Error("expected redaction of the kept tail")
+	}
+	if strings.Contains(redacted, "hunter2") || strings.Contains(redacted, "db1.internal") {
+		t.Errorf("secret survived redaction of truncated evidence:\n%s", redacted)
+	}
+}
+
+func TestTruncateMiddleZeroLimit(t *testing.T) {
+	if got := truncateMiddle("abcdef", 0); got != "[truncated 6 bytes]" {
+		t.Errorf("got %q", got)
+	}
+}
@@ -0,0 +1,106 @@
+package observe
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ppiankov/chainwatch/internal/wo"
+)
+
+// EventNewCriticalFinding is the alert event type for a critical
+// observation that first appears during `nullbot observe --follow`.
+const EventNewCriticalFinding = "new_critical_finding"
+
+// FollowConfig drives repeated observe cycles.
+type FollowConfig struct {
+	Scope    string        // scope used to hash observations for the diff
+	Interval time.Duration // wait between the end of one cycle and the next
+	Cycles   int           // stop after this many cycles; zero runs until ctx is done
+
+	// Cycle runs one investigation and returns its observations. It must
+	// go through Run or RunMulti so every cycle keeps the hard-locked
+	// inspect-only profile.
+	Cycle func(ctx context.Context) ([]wo.Observation, error)
+
+	// Report receives the observations that are new since the previous
+	// successful cycle, or the error of a failed cycle. Optional.
+	Report func(cycle int, fresh []wo.Observation, err error)
+
+	// Alert is called for each new critical observation. Optional.
+	Alert func(obs wo.Observation)
+}
+
+// DiffObservations returns the observations in cur whose finding hash is
+// absent from prev. Observations that cannot be hashed are treated as new.
+func DiffObservations(scope string, prev, cur []wo.Observation) []wo.Observation {
+	seen := make(map[string]bool, len(prev))
+	for _, obs := range prev {
+		if hash, err := ComputeObservationHash(scope, obs); err == nil {
+			seen[hash] = true
+		}
+	}
+	var fresh []wo.Observation
+	for _, obs := range cur {
+		hash, err := ComputeObservationHash(scope, obs)
+		if err == nil && seen[hash] {
+			continue
+		}
+		if err == nil {
+			seen[hash] = true
+		}
+		fresh = append(fresh, obs)
+	}
+	return fresh
+}
+
+// Follow runs cfg.Cycle every cfg.Interval and reports only observations
+// not present in the previous successful cycle. The first cycle reports
+// everything it finds. A failed cycle is reported and keeps the previous
+// baseline. Returns nil when ctx is cancelled or cfg.Cycles is reached.
+func Follow(ctx context.Context, cfg FollowConfig) error {
+	if cfg.Cycle == nil {
+		return fmt.Errorf("follow: cycle function is required")
+	}
+	if cfg.Interval <= 0 {
+		return fmt.Errorf("follow: interval must be positive, got %s", cfg.Interval)
+	}
+
+	var prev []wo.Observation
+	for cycle := 1; cfg.Cycles <= 0 || cycle <= cfg.Cycles; cycle++ {
+		if cycle > 1 {
+			timer := time.NewTimer(cfg.Interval)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil
+			case <-timer.C:
+			}
+		}
+
+		cur, err := cfg.Cycle(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			if cfg.Report != nil {
+				cfg.Report(cycle, nil, err)
+			}
+			continue
+		}
+
+		fresh := DiffObservations(cfg.Scope, prev, cur)
+		prev = cur
+		if cfg.Report != nil {
+			cfg.Report(cycle, fresh, nil)
+		}
+		if cfg.Alert != nil {
+			for _, obs := range fresh {
+				if obs.Severity == wo.SeverityCritical {
+					cfg.Alert(obs)
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package observe
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ppiankov/chainwatch/internal/wo"
+)
+
+func TestFollowReportsOnlyNewObservations(t *testing.T) {
+	dir := t.TempDir()
+	argsLog := filepath.Join(dir, "args")
+	chainwatch := filepath.Join(dir, "chainwatch")
+	script := "#!/bin/sh\necho \"$@\" >> " + argsLog + "\nexit 0\n"
+	if err := os.WriteFile(chainwatch, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	known := wo.Observation{Type: wo.CronAnomaly, Severity: wo.SeverityCritical, Detail: "curl | sh in root crontab"}
+	added := wo.Observation{Type: wo.UnknownFile, Severity: wo.SeverityCritical, Detail: "/var/www/site/shell.php"}
+	cycles := [][]wo.Observation{
+		{known},
+		{known, added},
+	}
+
+	rb := &Runbook{Type: "test", Steps: []Step{{Command: "ls {{SCOPE}}", Purpose: "list"}}}
+	run := 0
+	reported := map[int][]wo.Observation{}
+	alerted := map[int][]wo.Observation{}
+	err := Follow(context.Background(), FollowConfig{
+		Scope:    "/var/www/site",
+		Interval: time.Millisecond,
+		Cycles:   2,
+		Cycle: func(ctx context.Context) ([]wo.Observation, error) {
+			if _, err := Run(RunnerConfig{
+				Scope:      "/var/www/site",
+				Chainwatch: chainwatch,
+				AuditLog:   filepath.Join(dir, "audit.jsonl"),
+			}, rb); err != nil {
+				return nil, err
+			}
+			obs := cycles[run]
+			run++
+			return obs, nil
+		},
+		Report: func(cycle int, fresh []wo.Observation, err error) {
+			if err != nil {
+				t.Errorf("cycle %d: unexpected error: %v", cycle, err)
+			}
+			reported[cycle] = fresh
+		},
+		Alert: func(obs wo.Observation) {
+			alerted[run] = append(alerted[run], obs)
+		},
+	})
+	if err != nil {
+		t.Fatalf("Follow: %v", err)
+	}
+
+	if len(reported[1]) != 1 || reported[1][0].Detail != known.Detail {
+		t.Errorf("cycle 1 should report the baseline finding, got %+v", reported[1])
+	}
+	if len(reported[2]) != 1 || reported[2][0].Detail != added.Detail {
+		t.Errorf("cycle 2 should report only the new finding, got %+v", reported[2])
+	}
+	if len(alerted[2]) != 1 || alerted[2][0].Detail != added.Detail {
+		t.Errorf("cycle 2 should alert only on the new critical finding, got %+v", alerted[2])
+	}
+
+	data, err := os.ReadFile(argsLog)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected one chainwatch invocation per cycle, got %d: %q", len(lines), lines)
+	}
+	for i, line := range lines {
+		if !strings.HasPrefix(line, "exec --profile clawbot ") {
+			t.Errorf("cycle %d not run under the clawbot profile: %q", i+1, line)
+		}
+	}
+}
+
+func TestFollowRequiresPositiveInterval(t *testing.T) {
+	err := Follow(context.Background(), FollowConfig{
+		Cycle: func(ctx context.Context) ([]wo.Observation, error) { return nil, nil },
+	})
+	if err == nil {
+		t.Fatal("expected error for zero interval")
+	}
+}
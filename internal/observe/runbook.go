@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"sort"
 	"strings"
 
@@ -22,9 +23,28 @@ type Runbook struct {
 
 // Step defines a single investigation command with its purpose.
 type Step struct {
-	Command string `yaml:"command"`
-	Purpose string `yaml:"purpose"`
-	Cluster bool   `yaml:"cluster,omitempty"` // true: run only when cluster mode is enabled
+	Name    string         `yaml:"name,omitempty"` // optional: referenced by later steps' when
+	Command string         `yaml:"command"`
+	Purpose string         `yaml:"purpose"`
+	Cluster bool           `yaml:"cluster,omitempty"` // true: run only when cluster mode is enabled
+	When    *StepCondition `yaml:"when,omitempty"`    // optional: run only if the condition holds
+}
+
+// StepCondition gates a step on the result of an earlier named step.
+// The referenced step must have run, not been blocked, and produced
+// non-empty output. If Matches is set, the output must also match it.
+//
+//   - name: quick_scan
+//     command: "grep -rl 'eval(' {{SCOPE}} | head -20"
+//     purpose: "find files using eval"
+//   - command: "grep -rn 'base64_decode' {{SCOPE}} | head -50"
+//     purpose: "deep scan for obfuscated payloads"
+//     when:
+//     step: quick_scan
+//     matches: "\\.php$"
+type StepCondition struct {
+	Step    string `yaml:"step"`
+	Matches string `yaml:"matches,omitempty"` // regex; empty means any non-empty output
 }
 
 // destructivePrefixes are command prefixes that runbook steps must not start with.
@@ -48,6 +68,7 @@ func ValidateRunbook(rb *Runbook) error {
 	if len(rb.Steps) == 0 {
 		return fmt.Errorf("runbook must have at least one step")
 	}
+	named := make(map[string]bool)
 	for i, step := range rb.Steps {
 		if step.Command == "" {
 			return fmt.Errorf("step %d has empty command", i)
@@ -58,6 +79,36 @@ func ValidateRunbook(rb *Runbook) error {
 		if err := checkDestructive(step); err != nil {
 			return fmt.Errorf("step %d: %w", i, err)
 		}
+		if err := checkCondition(step, named); err != nil {
+			return fmt.Errorf("step %d: %w", i, err)
+		}
+		if step.Name != "" {
+			if named[step.Name] {
+				return fmt.Errorf("step %d: duplicate step name %q", i, step.Name)
+			}
+			named[step.Name] = true
+		}
+	}
+	return nil
+}
+
+// checkCondition verifies a when clause references an earlier named step
+// and carries a valid regex. Forward references are rejected so runbooks
+// stay linear.
+func checkCondition(step Step, earlier map[string]bool) error {
+	if step.When == nil {
+		return nil
+	}
+	if step.When.Step == "" {
+		return fmt.Errorf("when.step is required")
+	}
+	if !earlier[step.When.Step] {
+		return fmt.Errorf("when.step %q does not name an earlier step", step.When.Step)
+	}
+	if step.When.Matches != "" {
+		if _, err := regexp.Compile(step.When.Matches); err != nil {
+			return fmt.Errorf("when.matches: %w", err)
+		}
 	}
 	return nil
 }
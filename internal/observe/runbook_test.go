@@ -528,3 +528,55 @@ steps:
 		t.Errorf("steps = %d, want 1", len(rb.Steps))
 	}
 }
+
+func TestParseRunbookWhenCondition(t *testing.T) {
+	yaml := `name: conditional
+type: conditional
+steps:
+  - name: quick_scan
+    command: "grep -rl 'eval(' {{SCOPE}}"
+    purpose: "quick scan"
+  - command: "grep -rn base64_decode {{SCOPE}}"
+    purpose: "deep scan"
+    when:
+      step: quick_scan
+      matches: "\\.php$"
+`
+	rb, err := ParseRunbook([]byte(yaml))
+	if err != nil {
+		t.Fatalf("ParseRunbook failed: %v", err)
+	}
+	when := rb.Steps[1].When
+	if when == nil || when.Step != "quick_scan" || when.Matches != `\.php$` {
+		t.Fatalf("unexpected when clause: %+v", when)
+	}
+}
+
+func TestValidateRunbookRejectsBadCondition(t *testing.T) {
+	tests := []struct {
+		name  string
+		steps []Step
+	}{
+		{"unknown step", []Step{
+			{Command: "ls", Purpose: "list", When: &StepCondition{Step: "missing"}},
+		}},
+		{"forward reference", []Step{
+			{Command: "ls", Purpose: "list", When: &StepCondition{Step: "later"}},
+			{Name: "later", Command: "id", Purpose: "id"},
+		}},
+		{"bad regex", []Step{
+			{Name: "a", Command: "ls", Purpose: "list"},
+			{Command: "id", Purpose: "id", When: &StepCondition{Step: "a", Matches: "("}},
+		}},
+		{"duplicate name", []Step{
+			{Name: "a", Command: "ls", Purpose: "list"},
+			{Name: "a", Command: "id", Purpose: "id"},
+		}},
+	}
+	for _, tt := range tests {
+		rb := Runbook{Name: "test", Type: "test", Steps: tt.steps}
+		if err := ValidateRunbook(&rb); err == nil {
+			t.Errorf("ValidateRunbook(%s) should fail", tt.name)
+		}
+	}
+}
@@ -8,6 +8,7 @@ package observe
 import (
 	"fmt"
 	"os/exec"
+	"regexp"
 	"strings"
 	"time"
 
@@ -32,24 +33,35 @@ type RunnerConfig struct {
 	Port        int               // optional ClickHouse port (for runbook templating)
 	Chainwatch  string            // path to chainwatch binary
 	AuditLog    string            // path to audit log
+	Policy      string            // chainwatch policy path; empty uses chainwatch's default
 	Params      map[string]string // optional query parameters (e.g., QUERY, DATE)
+
+	// Target runs every step on a remote host ("user@host") over SSH. The
+	// ssh invocation itself is still routed through chainwatch exec, so the
+	// remote command line is policy-checked locally before it leaves.
+	Target string
+	SSH    string // ssh client binary (default "ssh")
 }
 
 // StepResult captures the output of a single investigation command.
 type StepResult struct {
-	Command  string        `json:"command"`
-	Purpose  string        `json:"purpose"`
-	Output   string        `json:"output"`
-	ExitCode int           `json:"exit_code"`
-	Blocked  bool          `json:"blocked"`
-	Cluster  string        `json:"cluster,omitempty"`
-	Host     string        `json:"host,omitempty"`
-	Duration time.Duration `json:"duration_ms"`
+	Name       string        `json:"name,omitempty"`
+	Command    string        `json:"command"`
+	Purpose    string        `json:"purpose"`
+	Output     string        `json:"output"`
+	ExitCode   int           `json:"exit_code"`
+	Blocked    bool          `json:"blocked"`
+	Skipped    bool          `json:"skipped,omitempty"`     // when condition not met; command never ran
+	SkipReason string        `json:"skip_reason,omitempty"` // why the step was skipped
+	Cluster    string        `json:"cluster,omitempty"`
+	Host       string        `json:"host,omitempty"`
+	Duration   time.Duration `json:"duration_ms"`
 }
 
 // RunResult is the full output of an investigation.
 type RunResult struct {
 	Scope   string       `json:"scope"`
+	Target  string       `json:"target,omitempty"`
 	Type    string       `json:"type"`
 	Steps   []StepResult `json:"steps"`
 	StartAt time.Time    `json:"start_at"`
@@ -66,9 +78,13 @@ func Run(cfg RunnerConfig, rb *Runbook) (*RunResult, error) {
 	if cfg.AuditLog == "" {
 		cfg.AuditLog = "/tmp/nullbot-observe.jsonl"
 	}
+	if err := ValidateTarget(cfg.Target); err != nil {
+		return nil, err
+	}
 
 	result := &RunResult{
 		Scope:   cfg.Scope,
+		Target:  cfg.Target,
 		Type:    rb.Type,
 		StartAt: time.Now().UTC(),
 	}
@@ -96,18 +112,49 @@ func Run(cfg RunnerConfig, rb *Runbook) (*RunResult, error) {
 		params["CONFIG_PATH"] = cfg.ConfigPath
 	}
 
+	named := make(map[string]StepResult)
+	unreachable := ""
 	for _, step := range rb.Steps {
 		if step.Cluster && !cfg.Cluster {
 			continue
 		}
 
-		// Expand placeholders in commands.
+		// Expand placeholders in commands. With a target, {{SCOPE}} names a
+		// path on the remote host.
 		cmd := strings.ReplaceAll(step.Command, "{{SCOPE}}", cfg.Scope)
 		for k, v := range params {
 			cmd = strings.ReplaceAll(cmd, "{{"+k+"}}", v)
 		}
 
-		sr := execStep(cfg, cmd, step.Purpose)
+		var sr StepResult
+		if unreachable != "" {
+			sr = StepResult{
+				Command:    cmd,
+				Purpose:    step.Purpose,
+				Skipped:    true,
+				SkipReason: unreachable,
+				Cluster:    cfg.ClusterName,
+				Host:       cfg.Host,
+			}
+		} else if ok, reason := conditionMet(step.When, named); ok {
+			sr = execStep(cfg, cmd, step.Purpose)
+			if cfg.Target != "" && !sr.Blocked && sr.ExitCode == sshConnectFailure {
+				unreachable = fmt.Sprintf("ssh connection to %s failed", cfg.Target)
+			}
+		} else {
+			sr = StepResult{
+				Command:    cmd,
+				Purpose:    step.Purpose,
+				Skipped:    true,
+				SkipReason: reason,
+				Cluster:    cfg.ClusterName,
+				Host:       cfg.Host,
+			}
+		}
+		sr.Name = step.Name
+		if step.Name != "" {
+			named[step.Name] = sr
+		}
 		result.Steps = append(result.Steps, sr)
 	}
 
@@ -128,6 +175,7 @@ func RunMulti(cfg RunnerConfig, types []string) (*RunResult, error) {
 
 	result := &RunResult{
 		Scope:   cfg.Scope,
+		Target:  cfg.Target,
 		Type:    strings.Join(types, "+"),
 		StartAt: time.Now().UTC(),
 	}
@@ -136,6 +184,8 @@ func RunMulti(cfg RunnerConfig, types []string) (*RunResult, error) {
 		rb := GetRunbook(rbType)
 
 		partial, err := Run(RunnerConfig{
+			Target:      cfg.Target,
+			SSH:         cfg.SSH,
 			Scope:       cfg.Scope,
 			Type:        rbType,
 			Cluster:     cfg.Cluster,
@@ -166,12 +216,73 @@ func RunMulti(cfg RunnerConfig, types []string) (*RunResult, error) {
 	return result, nil
 }
 
-// execStep runs a single command through chainwatch exec.
+// conditionMet evaluates a step's when clause against earlier named results.
+// A nil condition always holds. Otherwise the referenced step must have run,
+// not been blocked, and produced non-empty output matching when.matches.
+func conditionMet(when *StepCondition, named map[string]StepResult) (bool, string) {
+	if when == nil {
+		return true, ""
+	}
+	prev, ok := named[when.Step]
+	switch {
+	case !ok:
+		return false, fmt.Sprintf("step %q did not run", when.Step)
+	case prev.Skipped:
+		return false, fmt.Sprintf("step %q was skipped", when.Step)
+	case prev.Blocked:
+		return false, fmt.Sprintf("step %q was blocked", when.Step)
+	case prev.Output == "":
+		return false, fmt.Sprintf("step %q produced no output", when.Step)
+	}
+	if when.Matches != "" {
+		re, err := regexp.Compile(when.Matches)
+		if err != nil {
+			return false, fmt.Sprintf("invalid when.matches: %v", err)
+		}
+		if !re.MatchString(prev.Output) {
+			return false, fmt.Sprintf("step %q output did not match %q", when.Step, when.Matches)
+		}
+	}
+	return true, ""
+}
+
+// sshConnectFailure is the exit status ssh reports when it cannot reach
+// or authenticate to the remote host.
+const sshConnectFailure = 255
+
+// ValidateTarget checks that a remote target has the form [user@]host.
+// Empty means local. Leading dashes and whitespace are rejected so the
+// target can never be parsed as an ssh option.
+func ValidateTarget(target string) error {
+	if target == "" {
+		return nil
+	}
+	host := target
+	if i := strings.LastIndex(target, "@"); i >= 0 {
+		host = target[i+1:]
+	}
+	if host == "" || strings.HasPrefix(target, "-") || strings.ContainsAny(target, " \t\n'\"`$;|&") {
+		return fmt.Errorf("invalid target %q: want user@host", target)
+	}
+	return nil
+}
+
+// execStep runs a single command through chainwatch exec. With a target,
+// chainwatch exec wraps the ssh invocation and the command runs under the
+// remote shell.
 func execStep(cfg RunnerConfig, command, purpose string) StepResult {
 	start := time.Now()
 
-	args := []string{"exec", "--profile", inspectProfile, "--audit-log", cfg.AuditLog, "--"}
-	args = append(args, "sh", "-c", command)
+	args := []string{"exec", "--profile", inspectProfile, "--audit-log", cfg.AuditLog}
+	if cfg.Policy != "" {
+		args = append(args, "--policy", cfg.Policy)
+	}
+	args = append(args, "--")
+	if cfg.Target != "" {
+		args = append(args, sshArgs(cfg, command)...)
+	} else {
+		args = append(args, "sh", "-c", command)
+	}
 
 	cmd := exec.Command(cfg.Chainwatch, args...)
 	out, err := cmd.CombinedOutput()
@@ -200,17 +311,34 @@ func execStep(cfg RunnerConfig, command, purpose string) StepResult {
 	return sr
 }
 
+// sshArgs builds a non-interactive ssh invocation that runs command on the
+// target through "sh -c". The command is single-quoted so the remote shell
+// receives it verbatim.
+func sshArgs(cfg RunnerConfig, command string) []string {
+	ssh := cfg.SSH
+	if ssh == "" {
+		ssh = "ssh"
+	}
+	return []string{
+		ssh,
+		"-o", "BatchMode=yes",
+		"-o", "ConnectTimeout=10",
+		cfg.Target,
+		"sh -c " + shellQuote(command),
+	}
+}
+
+// shellQuote wraps s in single quotes for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
 // CollectEvidence concatenates all non-blocked step outputs into a single
-// evidence string suitable for LLM classification.
+// evidence string suitable for LLM classification. Skipped steps are listed
+// explicitly so their absence is not mistaken for an empty result. Output
+// is capped at the default EvidenceLimits.
 func CollectEvidence(result *RunResult) string {
-	var b strings.Builder
-	for _, sr := range result.Steps {
-		if sr.Blocked || sr.Output == "" {
-			continue
-		}
-		b.WriteString(fmt.Sprintf("=== %s ===\n$ %s\n%s\n\n", sr.Purpose, sr.Command, sr.Output))
-	}
-	return b.String()
+	return CollectEvidenceWithLimits(result, EvidenceLimits{})
 }
 
 // ToObservations is a placeholder that converts raw classified output
@@ -1,6 +1,9 @@
 package observe
 
 import (
+	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -283,3 +286,228 @@ func TestManualObservation(t *testing.T) {
 		t.Errorf("detail mismatch: %s", obs.Detail)
 	}
 }
+
+// fakeChainwatch writes a stub binary that runs the command after "--"
+// directly, standing in for `chainwatch exec`.
+func fakeChainwatch(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "chainwatch")
+	script := "#!/bin/sh\nwhile [ \"$1\" != \"--\" ]; do shift; done\nshift\nexec \"$@\"\n"
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatalf("write stub: %v", err)
+	}
+	return path
+}
+
+func TestRunConditionalSteps(t *testing.T) {
+	rb := &Runbook{
+		Name: "conditional",
+		Type: "test",
+		Steps: []Step{
+			{Name: "quick", Command: "echo found.php", Purpose: "quick scan"},
+			{Name: "empty", Command: "true", Purpose: "empty scan"},
+			{Command: "echo deep", Purpose: "deep scan", When: &StepCondition{Step: "quick", Matches: `\.php$`}},
+			{Command: "echo never", Purpose: "follow-up", When: &StepCondition{Step: "empty"}},
+		},
+	}
+
+	result, err := Run(RunnerConfig{
+		Scope:      "/tmp/test",
+		Chainwatch: fakeChainwatch(t),
+		AuditLog:   filepath.Join(t.TempDir(), "audit.jsonl"),
+	}, rb)
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if len(result.Steps) != 4 {
+		t.Fatalf("expected 4 step results (including skipped), got %d", len(result.Steps))
+	}
+
+	deep := result.Steps[2]
+	if deep.Skipped || deep.Output != "deep" {
+		t.Errorf("expected deep scan to run, got skipped=%v output=%q", deep.Skipped, deep.Output)
+	}
+
+	follow := result.Steps[3]
+	if !follow.Skipped {
+		t.Fatalf("expected follow-up to be skipped, got output %q", follow.Output)
+	}
+	if !strings.Contains(follow.SkipReason, "no output") {
+		t.Errorf("unexpected skip reason %q", follow.SkipReason)
+	}
+
+	evidence := CollectEvidence(result)
+	if !strings.Contains(evidence, "=== follow-up ===\n(skipped: ") {
+		t.Errorf("evidence should record the skipped step, got:\n%s", evidence)
+	}
+	if strings.Contains(evidence, "never") {
+		t.Error("skipped step command must not appear to have produced output")
+	}
+}
+
+// fakeSSH writes a mock ssh transport that records the target and runs
+// the remote command string locally. An unreachable transport exits 255
+// like ssh does on connection failure.
+func fakeSSH(t *testing.T, unreachable bool) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	path := filepath.Join(dir, "ssh")
+	logPath := filepath.Join(dir, "targets")
+	script := "#!/bin/sh\nwhile [ \"$1\" = \"-o\" ]; do shift 2; done\necho \"$1\" >> " + logPath + "\nshift\n"
+	if unreachable {
+		script += "echo 'ssh: connect to host: Connection refused' >&2\nexit 255\n"
+	} else {
+		script += "exec sh -c \"$1\"\n"
+	}
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatalf("write ssh stub: %v", err)
+	}
+	return path, logPath
+}
+
+func TestRunRemoteTargetOverSSH(t *testing.T) {
+	ssh, targets := fakeSSH(t, false)
+	rb := &Runbook{
+		Name: "remote",
+		Type: "test",
+		Steps: []Step{
+			{Command: "echo scope={{SCOPE}}", Purpose: "expand scope"},
+			{Command: "echo 'it'\"'\"'s quoted'", Purpose: "quoting survives"},
+		},
+	}
+
+	result, err := Run(RunnerConfig{
+		Scope:      "/srv/app",
+		Target:     "ops@web-1",
+		SSH:        ssh,
+		Chainwatch: fakeChainwatch(t),
+		AuditLog:   filepath.Join(t.TempDir(), "audit.jsonl"),
+	}, rb)
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if result.Target != "ops@web-1" {
+		t.Errorf("expected target recorded, got %q", result.Target)
+	}
+	if got := result.Steps[0].Output; got != "scope=/srv/app" {
+		t.Errorf("expected remote scope expansion, got %q", got)
+	}
+	if got := result.Steps[1].Output; got != "it's quoted" {
+		t.Errorf("expected quoting preserved, got %q", got)
+	}
+
+	data, err := os.ReadFile(targets)
+	if err != nil {
+		t.Fatalf("read targets: %v", err)
+	}
+	if strings.TrimSpace(string(data)) != "ops@web-1\nops@web-1" {
+		t.Errorf("expected every step sent to ops@web-1, got %q", data)
+	}
+}
+
+func TestRunRemoteStaysInspectOnly(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds the chainwatch binary")
+	}
+	dir := t.TempDir()
+	chainwatch := filepath.Join(dir, "chainwatch")
+	build := exec.Command("go", "build", "-o", chainwatch, "github.com/ppiankov/chainwatch/cmd/chainwatch")
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("build chainwatch: %v\n%s", err, out)
+	}
+	// Keep the user's ~/.chainwatch policy out of the run.
+	t.Setenv("HOME", dir)
+
+	ssh, targets := fakeSSH(t, false)
+	result, err := Run(RunnerConfig{
+		Scope:      "/srv/app",
+		Target:     "ops@web-1",
+		SSH:        ssh,
+		Chainwatch: chainwatch,
+		AuditLog:   filepath.Join(dir, "audit.jsonl"),
+	}, &Runbook{Type: "test", Steps: []Step{
+		{Command: "rm -rf {{SCOPE}}", Purpose: "destructive"},
+		{Command: "uname -s", Purpose: "identify"},
+	}})
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if !result.Steps[0].Blocked {
+		t.Errorf("expected remote destructive command to be blocked locally, got %+v", result.Steps[0])
+	}
+	if result.Steps[1].Blocked || result.Steps[1].ExitCode != 0 {
+		t.Errorf("expected read-only remote command to run, got %+v", result.Steps[1])
+	}
+
+	// Only the read-only step reached ssh; the mutation was never dispatched.
+	data, err := os.ReadFile(targets)
+	if err != nil {
+		t.Fatalf("read targets: %v", err)
+	}
+	if got := strings.TrimSpace(string(data)); got != "ops@web-1" {
+		t.Errorf("expected exactly one ssh dispatch, got %q", got)
+	}
+}
+
+func TestRunRemoteConnectionFailure(t *testing.T) {
+	ssh, _ := fakeSSH(t, true)
+	rb := &Runbook{Type: "test", Steps: []Step{
+		{Command: "uname -a", Purpose: "identify"},
+		{Command: "uptime", Purpose: "load"},
+	}}
+
+	result, err := Run(RunnerConfig{
+		Scope:      "/",
+		Target:     "ops@down-host",
+		SSH:        ssh,
+		Chainwatch: fakeChainwatch(t),
+		AuditLog:   filepath.Join(t.TempDir(), "audit.jsonl"),
+	}, rb)
+	if err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+	if result.Steps[0].ExitCode != 255 || !strings.Contains(result.Steps[0].Output, "Connection refused") {
+		t.Errorf("expected first step to report ssh failure, got %+v", result.Steps[0])
+	}
+	second := result.Steps[1]
+	if !second.Skipped || !strings.Contains(second.SkipReason, "ssh connection to ops@down-host failed") {
+		t.Errorf("expected remaining steps skipped after connection failure, got %+v", second)
+	}
+}
+
+func TestValidateTarget(t *testing.T) {
+	for _, ok := range []string{"", "host", "ops@web-1", "ops@10.0.0.5"} {
+		if err := ValidateTarget(ok); err != nil {
+			t.Errorf("ValidateTarget(%q): unexpected error %v", ok, err)
+		}
+	}
+	for _, bad := range []string{"-oProxyCommand=x", "ops@", "ops@host;id", "a b"} {
+		if err := ValidateTarget(bad); err == nil {
+			t.Errorf("ValidateTarget(%q): expected error", bad)
+		}
+	}
+}
+
+func TestRunPassesPolicyPath(t *testing.T) {
+	dir := t.TempDir()
+	argsLog := filepath.Join(dir, "args")
+	chainwatch := filepath.Join(dir, "chainwatch")
+	if err := os.WriteFile(chainwatch, []byte("#!/bin/sh\necho \"$@\" >> "+argsLog+"\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	policyPath := filepath.Join(dir, "policy.yaml")
+	if _, err := Run(RunnerConfig{
+		Scope:      "/srv/app",
+		Chainwatch: chainwatch,
+		AuditLog:   filepath.Join(dir, "audit.jsonl"),
+		Policy:     policyPath,
+	}, &Runbook{Type: "test", Steps: []Step{{Command: "ls {{SCOPE}}", Purpose: "inventory"}}}); err != nil {
+		t.Fatalf("Run returned error: %v", err)
+	}
+
+	data, _ := os.ReadFile(argsLog)
+	if !strings.Contains(string(data), "--policy "+policyPath+" -- ") {
+		t.Errorf("expected chainwatch exec to get --policy %s, got %q", policyPath, data)
+	}
+}
@@ -1,7 +1,9 @@
 package policy
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/ppiankov/chainwatch/internal/budget"
 	"github.com/ppiankov/chainwatch/internal/model"
@@ -115,3 +117,58 @@ func TestNewSessionResetsBudget(t *testing.T) {
 		t.Errorf("expected non-Deny for fresh session, got %s (%s)", result2.Decision, result2.Reason)
 	}
 }
+
+func TestTraceBudgetDeniesActionAfterMax(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxActionsPerTrace = 3
+	state := model.NewTraceState("trace-budget")
+
+	for i := 1; i <= 3; i++ {
+		result := Evaluate(lsAction(), state, "general", "", nil, cfg)
+		if result.Decision != model.Allow {
+			t.Fatalf("action %d: expected allow, got %s (%s)", i, result.Decision, result.Reason)
+		}
+	}
+
+	result := Evaluate(lsAction(), state, "general", "", nil, cfg)
+	if result.Decision != model.Deny {
+		t.Fatalf("expected deny on 4th action, got %s", result.Decision)
+	}
+	if !strings.HasPrefix(result.Reason, "trace_budget_exhausted") {
+		t.Errorf("expected trace_budget_exhausted reason, got %q", result.Reason)
+	}
+	if result.PolicyID != "trace.budget_exhausted" {
+		t.Errorf("expected trace.budget_exhausted, got %s", result.PolicyID)
+	}
+}
+
+func TestTraceBudgetResetReenablesEvaluation(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.MaxActionsPerTrace = 1
+	state := model.NewTraceState("trace-budget-reset")
+
+	Evaluate(lsAction(), state, "general", "", nil, cfg)
+	if result := Evaluate(lsAction(), state, "general", "", nil, cfg); result.Decision != model.Deny {
+		t.Fatalf("expected deny once budget exhausted, got %s", result.Decision)
+	}
+
+	if prev := state.ResetActionCount(); prev != 1 {
+		t.Errorf("expected previous count 1, got %d", prev)
+	}
+
+	if result := Evaluate(lsAction(), state, "general", "", nil, cfg); result.Decision != model.Allow {
+		t.Errorf("expected allow after reset, got %s (%s)", result.Decision, result.Reason)
+	}
+}
+
+func TestTraceBudgetBypassesDecisionCache(t *testing.T) {
+	cache := NewDecisionCache(time.Minute)
+	cfg := DefaultConfig()
+	cfg.MaxActionsPerTrace = 1
+	state := model.NewTraceState("trace-budget-cache")
+
+	cache.Evaluate(lsAction(), state, "general", "", nil, cfg)
+	if result := cache.Evaluate(lsAction(), state, "general", "", nil, cfg); result.Decision != model.Deny {
+		t.Errorf("expected deny (cache must not mask trace budget), got %s", result.Decision)
+	}
+}
@@ -0,0 +1,200 @@
+package policy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ppiankov/chainwatch/internal/denylist"
+	"github.com/ppiankov/chainwatch/internal/model"
+)
+
+// maxCacheEntriesPerTrace bounds memory for long-running traces.
+// When exceeded, expired entries are swept; if still full, the trace cache resets.
+const maxCacheEntriesPerTrace = 1024
+
+// DecisionCache short-circuits repeated evaluation of identical actions
+// within a trace. Only allow and deny decisions are cached.
+//
+// Safety properties:
+//   - Entries are scoped to a trace ID and expire after the TTL.
+//   - Any change to trace state that affects tiering (zone level, zones entered,
+//     max sensitivity, egress) invalidates every entry for that trace, so a
+//     cached allow can never mask an escalated tier.
+//   - Swapping the policy config or denylist (e.g. on reload) invalidates the trace.
+//   - Configs with rate limits, budgets, or a trace action budget bypass the cache entirely, since
+//     those depend on per-call counters that must advance on every evaluation.
+//   - Actions carrying byte volume bypass the cache, since accumulated volume
+//     drives high_volume zone detection.
+//   - Configs with canary tokens bypass the cache, since params and payload
+//     are scanned but not part of the cache key.
+//
+// A nil *DecisionCache is valid and evaluates every call.
+type DecisionCache struct {
+	ttl    time.Duration
+	now    func() time.Time
+	mu     sync.Mutex
+	traces map[string]*traceCache
+	hits   int
+	misses int
+}
+
+type traceCache struct {
+	stateKey string
+	cfg      *PolicyConfig
+	dl       *denylist.Denylist
+	entries  map[string]cacheEntry
+}
+
+type cacheEntry struct {
+	result  model.PolicyResult
+	expires time.Time
+}
+
+// NewDecisionCache creates a cache with the given TTL.
+// Returns nil if ttl <= 0 (caching disabled).
+func NewDecisionCache(ttl time.Duration) *DecisionCache {
+	if ttl <= 0 {
+		return nil
+	}
+	return &DecisionCache{
+		ttl:    ttl,
+		now:    time.Now,
+		traces: make(map[string]*traceCache),
+	}
+}
+
+// Evaluate returns a cached decision for an identical action when trace state
+// is unchanged, otherwise delegates to Evaluate and caches allow/deny results.
+// Callers must serialize access to state, as with Evaluate.
+func (c *DecisionCache) Evaluate(action *model.Action, state *model.TraceState, purpose string, agentID string, dl *denylist.Denylist, cfg *PolicyConfig) model.PolicyResult {
+	if c == nil || !cacheable(action, cfg) {
+		return Evaluate(action, state, purpose, agentID, dl, cfg)
+	}
+
+	key := actionCacheKey(action, purpose, agentID)
+	now := c.now()
+
+	c.mu.Lock()
+	tc := c.traces[state.TraceID]
+	if tc == nil || tc.stateKey != stateCacheKey(state) || tc.cfg != cfg || tc.dl != dl {
+		tc = nil
+		delete(c.traces, state.TraceID)
+	}
+	if tc != nil {
+		if e, ok := tc.entries[key]; ok && now.Before(e.expires) {
+			c.hits++
+			c.mu.Unlock()
+			action.NormalizeMeta()
+			if agentID != "" {
+				state.AgentID = agentID
+			}
+			return e.result
+		}
+	}
+	c.misses++
+	c.mu.Unlock()
+
+	result := Evaluate(action, state, purpose, agentID, dl, cfg)
+	if result.Decision != model.Allow && result.Decision != model.Deny {
+		return result
+	}
+
+	// Key entries on post-evaluation state: the first evaluation of an
+	// action may itself escalate zones, and the cached result reflects that.
+	stateKey := stateCacheKey(state)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	tc = c.traces[state.TraceID]
+	if tc == nil || tc.stateKey != stateKey || tc.cfg != cfg || tc.dl != dl {
+		tc = &traceCache{
+			stateKey: stateKey,
+			cfg:      cfg,
+			dl:       dl,
+			entries:  make(map[string]cacheEntry),
+		}
+		c.traces[state.TraceID] = tc
+	}
+	if len(tc.entries) >= maxCacheEntriesPerTrace {
+		tc.sweep(now)
+		if len(tc.entries) >= maxCacheEntriesPerTrace {
+			tc.entries = make(map[string]cacheEntry)
+		}
+	}
+	tc.entries[key] = cacheEntry{result: result, expires: now.Add(c.ttl)}
+	return result
+}
+
+// Invalidate drops all cached decisions for a trace.
+func (c *DecisionCache) Invalidate(traceID string) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.traces, traceID)
+}
+
+// Stats returns the number of cache hits and misses since creation.
+func (c *DecisionCache) Stats() (hits, misses int) {
+	if c == nil {
+		return 0, 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.hits, c.misses
+}
+
+func (tc *traceCache) sweep(now time.Time) {
+	for k, e := range tc.entries {
+		if !now.Before(e.expires) {
+			delete(tc.entries, k)
+		}
+	}
+}
+
+// cacheable reports whether an evaluation is free of per-call counters.
+func cacheable(action *model.Action, cfg *PolicyConfig) bool {
+	if cfg == nil {
+		return false
+	}
+	if len(cfg.RateLimits) > 0 || len(cfg.Budgets) > 0 || cfg.MaxActionsPerTrace > 0 {
+		return false
+	}
+	if len(cfg.Canaries) > 0 {
+		return false
+	}
+	return action.NormalizedMeta().Bytes == 0
+}
+
+// actionCacheKey identifies an action by the fields that influence
+// evaluation: its fingerprint plus the evaluation context.
+func actionCacheKey(action *model.Action, purpose, agentID string) string {
+	labels := make([]string, 0, len(action.Labels))
+	for k, v := range action.Labels {
+		labels = append(labels, k+"="+v)
+	}
+	sort.Strings(labels)
+	return strings.Join([]string{
+		action.Fingerprint(),
+		purpose,
+		agentID,
+		strings.Join(labels, ","),
+	}, "\x00")
+}
+
+// stateCacheKey fingerprints the trace state that feeds tier classification.
+func stateCacheKey(state *model.TraceState) string {
+	zones := make([]string, 0, len(state.ZonesEntered))
+	for z, entered := range state.ZonesEntered {
+		if entered {
+			zones = append(zones, string(z))
+		}
+	}
+	sort.Strings(zones)
+	return fmt.Sprintf("%d|%s|%s|%s", state.Zone, strings.Join(zones, ","),
+		state.MaxSensitivity, state.Egress)
+}
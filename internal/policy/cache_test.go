@@ -0,0 +1,116 @@
+package policy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ppiankov/chainwatch/internal/model"
+	"github.com/ppiankov/chainwatch/internal/ratelimit"
+)
+
+func lsAction() *model.Action {
+	return &model.Action{Tool: "command", Resource: "ls /tmp", Operation: "execute"}
+}
+
+func TestDecisionCacheReusesWhenStateUnchanged(t *testing.T) {
+	cache := NewDecisionCache(time.Minute)
+	cfg := DefaultConfig()
+	state := model.NewTraceState("trace-cache")
+
+	first := cache.Evaluate(lsAction(), state, "general", "", nil, cfg)
+	second := cache.Evaluate(lsAction(), state, "general", "", nil, cfg)
+
+	if first.Decision != model.Allow || second.Decision != model.Allow {
+		t.Fatalf("expected allow/allow, got %s/%s", first.Decision, second.Decision)
+	}
+	if hits, misses := cache.Stats(); hits != 1 || misses != 1 {
+		t.Errorf("expected 1 hit 1 miss, got %d hits %d misses", hits, misses)
+	}
+}
+
+func TestDecisionCacheInvalidatedOnZoneEscalation(t *testing.T) {
+	cache := NewDecisionCache(time.Minute)
+	cfg := DefaultConfig()
+	state := model.NewTraceState("trace-escalate")
+
+	before := cache.Evaluate(lsAction(), state, "general", "", nil, cfg)
+	if before.Decision != model.Allow {
+		t.Fatalf("expected allow before escalation, got %s", before.Decision)
+	}
+
+	// Checkout enters commercial_commit → irreversible.
+	checkout := &model.Action{Tool: "browser", Resource: "https://shop.example.com/checkout", Operation: "navigate"}
+	cache.Evaluate(checkout, state, "general", "", nil, cfg)
+	if state.Zone != model.Irreversible {
+		t.Fatalf("expected irreversible zone, got %s", state.Zone)
+	}
+
+	after := cache.Evaluate(lsAction(), state, "general", "", nil, cfg)
+	if after.Decision != model.Deny {
+		t.Errorf("expected deny after escalation (cached allow must not mask tier), got %s", after.Decision)
+	}
+	if after.Tier != TierCritical {
+		t.Errorf("expected tier 3 after escalation, got %d", after.Tier)
+	}
+	if hits, _ := cache.Stats(); hits != 0 {
+		t.Errorf("expected no cache hits across escalation, got %d", hits)
+	}
+}
+
+func TestDecisionCacheExpires(t *testing.T) {
+	cache := NewDecisionCache(time.Second)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+	cfg := DefaultConfig()
+	state := model.NewTraceState("trace-ttl")
+
+	cache.Evaluate(lsAction(), state, "general", "", nil, cfg)
+	now = now.Add(2 * time.Second)
+	cache.Evaluate(lsAction(), state, "general", "", nil, cfg)
+
+	if hits, misses := cache.Stats(); hits != 0 || misses != 2 {
+		t.Errorf("expected expired entry to miss, got %d hits %d misses", hits, misses)
+	}
+}
+
+func TestDecisionCacheScopedPerTrace(t *testing.T) {
+	cache := NewDecisionCache(time.Minute)
+	cfg := DefaultConfig()
+
+	cache.Evaluate(lsAction(), model.NewTraceState("a"), "general", "", nil, cfg)
+	cache.Evaluate(lsAction(), model.NewTraceState("b"), "general", "", nil, cfg)
+
+	if hits, _ := cache.Stats(); hits != 0 {
+		t.Errorf("expected no hits across traces, got %d", hits)
+	}
+}
+
+func TestDecisionCacheBypassedWithRateLimits(t *testing.T) {
+	cache := NewDecisionCache(time.Minute)
+	cfg := DefaultConfig()
+	cfg.RateLimits = map[string]ratelimit.RateLimitConfig{
+		"*": {"command": {MaxRequests: 1, Window: time.Minute}},
+	}
+	state := model.NewTraceState("trace-rl")
+
+	first := cache.Evaluate(lsAction(), state, "general", "", nil, cfg)
+	second := cache.Evaluate(lsAction(), state, "general", "", nil, cfg)
+
+	if first.Decision != model.Allow {
+		t.Fatalf("expected first call allowed, got %s", first.Decision)
+	}
+	if second.Decision != model.Deny {
+		t.Errorf("expected rate limit deny on second call, got %s", second.Decision)
+	}
+}
+
+func TestNilDecisionCacheEvaluates(t *testing.T) {
+	var cache *DecisionCache
+	result := cache.Evaluate(lsAction(), model.NewTraceState("nil"), "general", "", nil, nil)
+	if result.Decision != model.Allow {
+		t.Errorf("expected allow from nil cache, got %s", result.Decision)
+	}
+	if NewDecisionCache(0) != nil {
+		t.Error("expected nil cache for zero TTL")
+	}
+}
@@ -1,17 +1,23 @@
 package policy
 
 import (
+	"bytes"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v3"
 
 	"github.com/ppiankov/chainwatch/internal/alert"
+	"github.com/ppiankov/chainwatch/internal/approval"
+	"github.com/ppiankov/chainwatch/internal/audit"
 	"github.com/ppiankov/chainwatch/internal/budget"
+	"github.com/ppiankov/chainwatch/internal/canary"
+	"github.com/ppiankov/chainwatch/internal/decisionhook"
 	"github.com/ppiankov/chainwatch/internal/identity"
 	"github.com/ppiankov/chainwatch/internal/model"
 	"github.com/ppiankov/chainwatch/internal/ratelimit"
@@ -44,13 +50,50 @@ func (sw SensitivityWeights) WeightFor(s model.Sensitivity) int {
 	}
 }
 
-// Rule is a purpose-bound policy rule evaluated in order (first match wins).
+// Rule is a purpose-bound policy rule. Rules are evaluated in list order
+// (profile rules first, then policy.yaml) and the first matching rule
+// decides, unless it sets Continue.
 type Rule struct {
 	Purpose         string `yaml:"purpose"`
 	ResourcePattern string `yaml:"resource_pattern"`
 	Decision        string `yaml:"decision"`
 	Reason          string `yaml:"reason"`
 	ApprovalKey     string `yaml:"approval_key"`
+
+	// Mode is "enforce" (default) or "observe". An observe rule records its
+	// would-be decision on the result without applying it, so new rules can
+	// be rolled out gradually while the rest of the policy enforces.
+	Mode string `yaml:"mode,omitempty"`
+
+	// Labels restricts the rule to actions carrying every listed label.
+	// A value of "*" matches any value for that key.
+	Labels map[string]string `yaml:"labels,omitempty"`
+
+	// Alert overrides alert routing when this rule matches (force, suppress, channels).
+	Alert alert.RuleAlert `yaml:"alert,omitempty"`
+
+	// Suggest is a safer alternative offered to the agent when this deny
+	// rule blocks an action, e.g. "truncate -s0 <file>" for rm of logs.
+	Suggest string `yaml:"suggest,omitempty"`
+
+	// Continue lets evaluation fall through to later rules after a match,
+	// for layered rules: the strictest decision among the continuing rules
+	// and the rule that finally stops evaluation is applied.
+	Continue bool `yaml:"continue,omitempty"`
+
+	// RequireReason makes approvals of this rule's approval_key carry a
+	// non-empty justification, recorded in the audit log.
+	RequireReason bool `yaml:"require_reason,omitempty"`
+
+	// ApprovalGrace opens a window when an approval of this rule is used:
+	// for that long, later actions matching the same rule are allowed
+	// without a new prompt. Only valid on require_approval rules.
+	ApprovalGrace time.Duration `yaml:"approval_grace,omitempty"`
+
+	// MinBytes and MinRows restrict the rule to actions at least this
+	// large, e.g. file reads over 100MB. Zero means no threshold.
+	MinBytes int64 `yaml:"min_bytes,omitempty"`
+	MinRows  int   `yaml:"min_rows,omitempty"`
 }
 
 // PolicyConfig holds all configurable policy parameters.
@@ -64,6 +107,32 @@ type PolicyConfig struct {
 	Agents             map[string]*identity.AgentConfig     `yaml:"agents,omitempty"`
 	Budgets            map[string]*budget.BudgetConfig      `yaml:"budgets,omitempty"`
 	RateLimits         map[string]ratelimit.RateLimitConfig `yaml:"rate_limits,omitempty"`
+	MaxActionsPerTrace int                                  `yaml:"max_actions_per_trace,omitempty"` // 0 = unlimited
+	ZoneDecisions      map[string]string                    `yaml:"zone_decisions,omitempty"`        // zone name → allow | require_approval | deny
+	DecisionHook       *decisionhook.Config                 `yaml:"decision_hook,omitempty"`         // external decision webhook for borderline tiers
+	AuditSinks         []audit.SinkConfig                   `yaml:"audit_sinks,omitempty"`           // SIEM-formatted copies of the audit log (cef, ecs, json)
+	ApprovalThrottle   approval.Throttle                    `yaml:"approval_throttle,omitempty"`     // auto-deny keys approved too often (anti-fatigue)
+	ApprovalStore      approval.BackendConfig               `yaml:"approval_store,omitempty"`        // where approvals live: file (default) or redis shared by replicas
+	AllowedPurposes    []string                             `yaml:"allowed_purposes,omitempty"`      // when set, actions declaring any other purpose are denied
+	ProtectedPaths     []string                             `yaml:"protected_paths,omitempty"`       // writes there are promoted to tier 2
+
+	ProtectDefaultPaths bool `yaml:"default_protected_paths,omitempty"` // also protect DefaultProtectedPaths
+
+	Canaries       []canary.Token             `yaml:"canaries,omitempty"`        // planted fake credentials; transmitting one externally is blocked
+	EnrichmentHook *decisionhook.EnrichConfig `yaml:"enrichment_hook,omitempty"` // external labels added to actions before evaluation
+	DenialGuidance *DenialGuidance            `yaml:"denial_guidance,omitempty"` // how-to-proceed message in block responses
+
+	ConfirmIrreversible *ConfirmConfig      `yaml:"confirm_irreversible,omitempty"` // approved irreversible actions also need an action-bound confirm token
+	OutputEgress        *OutputEgressConfig `yaml:"output_egress,omitempty"`        // re-check command output for egress that happened at runtime
+}
+
+// OutputEgressConfig enables a post-execution check of command output for
+// signs of exfiltration that pre-execution evaluation cannot see, such as
+// the URLs a command contacted and the bytes it uploaded. A hit is audited
+// and alerted at the critical tier; the command has already run.
+type OutputEgressConfig struct {
+	Enabled  bool  `yaml:"enabled"`
+	MinBytes int64 `yaml:"min_bytes,omitempty"` // bytes sent to external hosts that trigger; 0 flags any external upload
 }
 
 // DefaultConfig returns the built-in policy config matching previous hardcoded values.
@@ -95,12 +164,9 @@ func DefaultConfig() *PolicyConfig {
 // Empty path falls back to ~/.chainwatch/policy.yaml.
 // Missing file returns defaults. Invalid YAML returns an error.
 func LoadConfig(path string) (*PolicyConfig, error) {
-	if path == "" {
-		home, err := os.UserHomeDir()
-		if err != nil {
-			return DefaultConfig(), nil
-		}
-		path = filepath.Join(home, ".chainwatch", "policy.yaml")
+	path, err := resolveConfigPath(path)
+	if err != nil {
+		return DefaultConfig(), nil
 	}
 
 	data, err := os.ReadFile(path)
@@ -116,21 +182,66 @@ func LoadConfig(path string) (*PolicyConfig, error) {
 	if err := yaml.Unmarshal(data, cfg); err != nil {
 		return nil, fmt.Errorf("failed to parse policy config: %w", err)
 	}
+	if err := cfg.ValidateZoneDecisions(); err != nil {
+		return nil, fmt.Errorf("invalid policy config: %w", err)
+	}
+	if err := cfg.ValidateRuleModes(); err != nil {
+		return nil, fmt.Errorf("invalid policy config: %w", err)
+	}
+	if err := canary.Validate(cfg.Canaries); err != nil {
+		return nil, fmt.Errorf("invalid policy config: %w", err)
+	}
+	if err := cfg.DenialGuidance.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid policy config: %w", err)
+	}
 
 	return cfg, nil
 }
 
+// resolveConfigPath returns path, or ~/.chainwatch/policy.yaml when path
+// is empty.
+func resolveConfigPath(path string) (string, error) {
+	if path != "" {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".chainwatch", "policy.yaml"), nil
+}
+
+// RequireConfigFile returns an error unless path names an existing,
+// non-empty policy file. An empty path means the default
+// ~/.chainwatch/policy.yaml, as in LoadConfig. Strict deployments check it
+// before loading so a missing policy fails startup instead of silently
+// falling back to defaults.
+func RequireConfigFile(path string) error {
+	path, err := resolveConfigPath(path)
+	if err != nil {
+		return fmt.Errorf("policy file required: no policy path given and %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("policy file required: %s does not exist", path)
+		}
+		return fmt.Errorf("failed to read policy config: %w", err)
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return fmt.Errorf("policy file required: %s is empty", path)
+	}
+	return nil
+}
+
 // LoadConfigWithHash loads policy configuration and returns its SHA-256 hash.
 // The hash is computed over the raw YAML bytes on disk.
 // When no file exists (defaults used), the hash is the SHA-256 of empty input.
 func LoadConfigWithHash(path string) (*PolicyConfig, string, error) {
-	if path == "" {
-		home, err := os.UserHomeDir()
-		if err != nil {
-			h := sha256.Sum256(nil)
-			return DefaultConfig(), "sha256:" + hex.EncodeToString(h[:]), nil
-		}
-		path = filepath.Join(home, ".chainwatch", "policy.yaml")
+	path, err := resolveConfigPath(path)
+	if err != nil {
+		h := sha256.Sum256(nil)
+		return DefaultConfig(), "sha256:" + hex.EncodeToString(h[:]), nil
 	}
 
 	data, err := os.ReadFile(path)
@@ -149,6 +260,15 @@ func LoadConfigWithHash(path string) (*PolicyConfig, string, error) {
 	if err := yaml.Unmarshal(data, cfg); err != nil {
 		return nil, "", fmt.Errorf("failed to parse policy config: %w", err)
 	}
+	if err := cfg.ValidateZoneDecisions(); err != nil {
+		return nil, "", fmt.Errorf("invalid policy config: %w", err)
+	}
+	if err := cfg.ValidateRuleModes(); err != nil {
+		return nil, "", fmt.Errorf("invalid policy config: %w", err)
+	}
+	if err := cfg.DenialGuidance.Validate(); err != nil {
+		return nil, "", fmt.Errorf("invalid policy config: %w", err)
+	}
 
 	return cfg, hash, nil
 }
@@ -194,6 +314,72 @@ func matchRule(rule Rule, purpose, resource string) bool {
 	return lowerResource == lowerPattern
 }
 
+// Rule modes.
+const (
+	RuleModeEnforce = "enforce"
+	RuleModeObserve = "observe"
+)
+
+// ValidateRuleModes checks that every rule mode is empty, enforce, or
+// observe, that only deny rules carry a suggestion, that only
+// require_approval rules carry an approval grace window, and that size
+// thresholds are not negative.
+func (c *PolicyConfig) ValidateRuleModes() error {
+	for i, rule := range c.Rules {
+		switch rule.Mode {
+		case "", RuleModeEnforce, RuleModeObserve:
+		default:
+			return fmt.Errorf("rules[%d]: invalid mode %q (want enforce or observe)", i, rule.Mode)
+		}
+		if rule.Suggest != "" && rule.Decision != "deny" {
+			return fmt.Errorf("rules[%d]: suggest is only supported on deny rules, got decision %q", i, rule.Decision)
+		}
+		if rule.ApprovalGrace < 0 {
+			return fmt.Errorf("rules[%d]: approval_grace must not be negative, got %s", i, rule.ApprovalGrace)
+		}
+		if rule.ApprovalGrace > 0 && rule.Decision != "require_approval" {
+			return fmt.Errorf("rules[%d]: approval_grace is only supported on require_approval rules, got decision %q", i, rule.Decision)
+		}
+		if rule.MinBytes < 0 || rule.MinRows < 0 {
+			return fmt.Errorf("rules[%d]: min_bytes and min_rows must not be negative", i)
+		}
+	}
+	return nil
+}
+
+// ReasonRequiredKeys returns the approval keys of require_reason rules.
+func (c *PolicyConfig) ReasonRequiredKeys() []string {
+	var keys []string
+	for _, rule := range c.Rules {
+		if rule.RequireReason && rule.ApprovalKey != "" {
+			keys = append(keys, rule.ApprovalKey)
+		}
+	}
+	return keys
+}
+
+// matchVolume reports whether an action meets the rule's size thresholds.
+func matchVolume(rule Rule, action *model.Action) bool {
+	if rule.MinBytes == 0 && rule.MinRows == 0 {
+		return true
+	}
+	meta := action.NormalizedMeta()
+	return int64(meta.Bytes) >= rule.MinBytes && meta.Rows >= rule.MinRows
+}
+
+// matchLabels reports whether labels satisfy every key/value in the
+// selector. Values match exactly; "*" requires only that the key is present.
+// An empty selector matches all actions.
+func matchLabels(selector, labels map[string]string) bool {
+	for k, want := range selector {
+		got, ok := labels[k]
+		if !ok || (want != "*" && got != want) {
+			return false
+		}
+	}
+	return true
+}
+
 // parseDecision maps a string to a Decision enum. Fail-closed: unknown → Deny.
 func parseDecision(s string) model.Decision {
 	switch s {
@@ -207,13 +393,15 @@ func parseDecision(s string) model.Decision {
 		return model.RequireApproval
 	case "rewrite_output":
 		return model.RewriteOutput
+	case "quarantine":
+		return model.Quarantine
 	default:
 		return model.Deny
 	}
 }
 
-// rulePolicyID generates a policy ID from a rule.
-func rulePolicyID(rule Rule) string {
+// RulePolicyID generates the policy ID reported when a rule matches.
+func RulePolicyID(rule Rule) string {
 	pattern := rule.ResourcePattern
 	pattern = strings.Trim(pattern, "*")
 	pattern = strings.Trim(pattern, ".")
@@ -241,6 +429,14 @@ func DefaultConfigYAML() string {
 # locked:   tier 2-3 denied, tier 1 requires approval, tier 0 allowed (regulated)
 enforcement_mode: guarded
 
+# Per-zone decision overrides (optional). Replace the tier decision for
+# actions whose tier comes from the trace's zone (not self-targeting or
+# min_tier promotions). Zones: safe, sensitive, commitment, irreversible.
+# Decisions: allow | require_approval | deny. Not allowed in advisory mode;
+# in locked mode overrides may only tighten.
+# zone_decisions:
+#   commitment: deny
+
 # Risk score thresholds for decision boundaries (legacy, kept for reference).
 # risk <= allow_max -> allow
 # allow_max < risk < approval_min -> allow_with_redaction
@@ -256,13 +452,34 @@ sensitivity_weights:
   medium: 3
   high: 6
 
-# Purpose-bound rules evaluated in order. First match wins.
+# Purpose-bound rules evaluated in order. First match wins: the first
+# matching rule decides and later rules are skipped, unless it sets
+# continue: true. Profile rules come before the rules below.
 # Fields:
 #   purpose: exact match or "*" for any purpose
 #   resource_pattern: glob pattern (*salary* = contains "salary")
-#   decision: allow | deny | allow_with_redaction | require_approval
+#   decision: allow | deny | allow_with_redaction | require_approval | quarantine
+#     (quarantine redirects file writes to the guard's quarantine dir; other tools are denied)
 #   reason: human-readable reason (optional, auto-generated if omitted)
 #   approval_key: key for approval workflow (required if decision is require_approval)
+#   suggest: safer alternative returned to the agent with the block (deny
+#     rules only), e.g. "truncate -s0 <file>" instead of deleting logs
+#   approval_grace: duration (require_approval rules only), e.g. 10m — once
+#     an approval of this rule is used, later actions matching the same rule
+#     are allowed without a new prompt for that long (audited as approval_grace)
+#   mode: enforce (default) | observe — observe logs the would-be decision
+#     without applying it, for canary rollout of new rules
+#   labels: label selector (optional), e.g. {env: prod, ticket: "*"}; every
+#     label must be present on the action ("*" = any value)
+#   min_bytes / min_rows: size thresholds (optional) — the rule only matches
+#     actions at least this large, e.g. min_bytes: 104857600 for file reads
+#     over 100MB (read sizes come from stat or the tool call's declared size)
+#   alert: per-rule alert override (optional):
+#     force    — alert on every match, even if no channel lists this decision
+#     suppress — never alert on matches of this rule
+#     or a mapping: {mode: force, channels: [telegram]} to restrict channels
+#   continue: true (optional) — keep evaluating later rules after a match;
+#     the strictest decision among the matches applies (layered rules)
 rules:
   - purpose: SOC_efficiency
     resource_pattern: "*salary*"
@@ -278,6 +495,12 @@ rules:
 #     url: https://hooks.slack.com/services/XXX
 #     format: slack
 #     events: [deny, require_approval, break_glass_used]
+#     retry:                      # exponential backoff; defaults shown
+#       max_attempts: 3
+#       initial_backoff: 1s
+#       max_backoff: 30s
+#     spool_dir: /var/lib/chainwatch/alert-spool  # keep failed alerts, redeliver on recovery
+#     spool_max: 1000             # spooled alerts kept per channel; oldest evicted when full
 #   - channel: telegram
 #     events: [deny, break_glass_used]
 #     telegram:
@@ -294,6 +517,82 @@ rules:
 #       from: chainwatch@example.com
 #       to: [ops@example.com]
 
+# External decision hook — consult an authorization service for borderline
+# actions (allow/require_approval at or above min_tier). Local denies are final.
+# An allow for a require_reason rule must carry a reason, like an operator's.
+# decision_hook:
+#   url: https://authz.internal/chainwatch
+#   min_tier: 2
+#   timeout: 2s
+#   cache_ttl: 5s
+#   fail_open: false  # default: deny when the service is unavailable
+
+# Enrichment hook — ask an external service for context labels (CMDB
+# membership, on-call status) before evaluation. Returned labels are matched
+# by rule label selectors; labels already set by the operator win.
+# enrichment_hook:
+#   url: https://cmdb.internal/chainwatch/enrich
+#   timeout: 2s
+#   fail_open: false  # default: deny when the service is unavailable;
+#                     # true evaluates unenriched with enrichment_error=true
+
+# Audit sinks — copy every audit entry to SIEM-friendly files. The
+# hash-chained JSONL log (--audit-log) stays the canonical record.
+# Formats: cef (Common Event Format), ecs (Elastic Common Schema), json.
+# audit_sinks:
+#   - format: cef
+#     path: /var/log/chainwatch/audit.cef
+#   - format: ecs
+#     path: /var/log/chainwatch/audit.ecs.json
+
+# Approval throttle — guards against approval fatigue. Once an approval key
+# has been granted max_approvals times within window, further matching
+# actions are denied outright (no new prompt) and a forced alert flags the
+# unusually frequent pattern. Resets as the window slides past old grants.
+# approval_throttle:
+#   max_approvals: 5
+#   window: 1h
+
+# Approval store — where pending and granted approvals live. The default
+# file store keeps them in ~/.chainwatch/pending on this host. Replicas
+# behind a load balancer should share a redis store so an approval granted
+# on one replica is honored (and consumed once) on all of them.
+# approval_store:
+#   type: redis
+#   addr: redis.internal:6379
+#   password_env: CHAINWATCH_REDIS_PASSWORD
+#   db: 0
+#   prefix: "chainwatch:approval:"
+#   tls: true
+
+# Output egress — after a command runs, inspect its stdout/stderr for signs
+# it sent data out (curl -v request lines, upload byte counts, wget
+# connections). Policy runs before execution, so this only detects: a hit
+# records a tier 3 output_egress audit entry and a forced alert.
+# output_egress:
+#   enabled: true
+#   min_bytes: 1024  # 0 (default) flags any upload to an external host
+
+# Protected paths — writing, moving onto or deleting a file here is
+# promoted to at least tier 2 (guarded), because the change persists beyond
+# the session. default_protected_paths adds the built-in list (/etc/,
+# /boot/, system bin dirs, systemd units, cron spools,
+# ~/.ssh/authorized_keys and shell startup files). A trailing "/" protects
+# everything below; "~/" is the home directory of the user running
+# chainwatch.
+# default_protected_paths: true
+# protected_paths:
+#   - /opt/app/releases/
+#   - ~/.gitconfig
+
+# Canary tokens — fake credentials planted to detect exfiltration.
+# Reading a canary is not flagged; any action that transmits a registered
+# value (raw, URL-escaped or base64) to an external destination is denied
+# at tier 3 as canary_triggered and force-alerted.
+# canaries:
+#   - name: fake-aws-key
+#     value: AKIAEXAMPLECANARY0001
+
 # Agent identity — scope enforcement per registered agent.
 # When agent_id is passed to Evaluate, the agent must be registered here.
 # Unknown agents are denied (fail-closed).
@@ -342,5 +641,10 @@ rules:
 #     command:
 #       max_requests: 20
 #       window: 1m
+
+# Trace action budget — total evaluations allowed per trace.
+# Once exhausted every further action is denied (trace_budget_exhausted)
+# until the trace is reset: chainwatch budget reset-trace <trace-id>.
+# max_actions_per_trace: 500
 `
 }
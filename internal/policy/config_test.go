@@ -3,7 +3,9 @@ package policy
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/ppiankov/chainwatch/internal/model"
 	"gopkg.in/yaml.v3"
@@ -51,6 +53,47 @@ func TestLoadConfigMissingFile(t *testing.T) {
 	}
 }
 
+func TestRequireConfigFile(t *testing.T) {
+	dir := t.TempDir()
+	empty := filepath.Join(dir, "empty.yaml")
+	if err := os.WriteFile(empty, []byte("  \n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	valid := filepath.Join(dir, "policy.yaml")
+	if err := os.WriteFile(valid, []byte("enforcement_mode: locked\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{"/nonexistent/path/policy.yaml", empty} {
+		if err := RequireConfigFile(path); err == nil {
+			t.Errorf("RequireConfigFile(%q): expected error", path)
+		}
+	}
+	if err := RequireConfigFile(valid); err != nil {
+		t.Errorf("RequireConfigFile(valid): %v", err)
+	}
+}
+
+func TestRequireConfigFileDefaultPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+
+	if err := RequireConfigFile(""); err == nil {
+		t.Error("expected error when ~/.chainwatch/policy.yaml is missing")
+	}
+
+	dir := filepath.Join(home, ".chainwatch")
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "policy.yaml"), []byte("enforcement_mode: locked\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := RequireConfigFile(""); err != nil {
+		t.Errorf("expected default policy file to satisfy the requirement, got %v", err)
+	}
+}
+
 func TestLoadConfigEmptyPath(t *testing.T) {
 	cfg, err := LoadConfig("")
 	if err != nil {
@@ -216,6 +259,222 @@ func TestMatchRuleNoMatch(t *testing.T) {
 	}
 }
 
+func TestMatchLabels(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector map[string]string
+		labels   map[string]string
+		expected bool
+	}{
+		{"empty selector", nil, nil, true},
+		{"exact match", map[string]string{"env": "prod"}, map[string]string{"env": "prod", "tenant": "acme"}, true},
+		{"value mismatch", map[string]string{"env": "prod"}, map[string]string{"env": "staging"}, false},
+		{"missing label", map[string]string{"env": "prod"}, nil, false},
+		{"wildcard present", map[string]string{"ticket": "*"}, map[string]string{"ticket": "OPS-12"}, true},
+		{"wildcard missing", map[string]string{"ticket": "*"}, map[string]string{"env": "prod"}, false},
+		{"all required", map[string]string{"env": "prod", "tenant": "acme"}, map[string]string{"env": "prod"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := matchLabels(tt.selector, tt.labels); got != tt.expected {
+				t.Errorf("matchLabels(%v, %v) = %v, want %v", tt.selector, tt.labels, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEvaluateRuleLabelSelector(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Rules = []Rule{{
+		Purpose:         "*",
+		ResourcePattern: "*report*",
+		Decision:        "deny",
+		Reason:          "prod reports are frozen",
+		Labels:          map[string]string{"env": "prod"},
+	}}
+
+	newAction := func(labels map[string]string) *model.Action {
+		return &model.Action{
+			Tool:      "file_read",
+			Resource:  "/data/report.csv",
+			Operation: "read",
+			RawMeta:   map[string]any{"sensitivity": "low", "egress": "internal"},
+			Labels:    labels,
+		}
+	}
+
+	labeled := Evaluate(newAction(map[string]string{"env": "prod"}), model.NewTraceState("t1"), "general", "", nil, cfg)
+	if labeled.Decision != model.Deny || labeled.Reason != "prod reports are frozen" {
+		t.Errorf("expected labeled action denied by rule, got %s (%s)", labeled.Decision, labeled.Reason)
+	}
+
+	for _, labels := range []map[string]string{nil, {"env": "staging"}} {
+		result := Evaluate(newAction(labels), model.NewTraceState("t2"), "general", "", nil, cfg)
+		if result.Decision == model.Deny {
+			t.Errorf("expected rule to skip action with labels %v, got %s (%s)", labels, result.Decision, result.Reason)
+		}
+	}
+}
+
+func TestEvaluateRuleSizeThreshold(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Rules = []Rule{{
+		Purpose:         "*",
+		ResourcePattern: "*",
+		Decision:        "require_approval",
+		ApprovalKey:     "bulk_read",
+		MinBytes:        100 << 20,
+	}}
+
+	newAction := func(bytes int) *model.Action {
+		return &model.Action{
+			Tool:      "file_read",
+			Resource:  "/data/export.csv",
+			Operation: "read",
+			RawMeta:   map[string]any{"sensitivity": "low", "egress": "internal", "bytes": bytes},
+		}
+	}
+
+	large := Evaluate(newAction(2<<30), model.NewTraceState("t1"), "general", "", nil, cfg)
+	if large.Decision != model.RequireApproval || large.ApprovalKey != "bulk_read" {
+		t.Errorf("expected large read to require approval, got %s (%s)", large.Decision, large.Reason)
+	}
+	small := Evaluate(newAction(4096), model.NewTraceState("t2"), "general", "", nil, cfg)
+	if small.Decision == model.RequireApproval {
+		t.Errorf("expected small read to skip the threshold rule, got %s (%s)", small.Decision, small.Reason)
+	}
+
+	cfg.Rules[0].MinBytes = -1
+	if err := cfg.ValidateRuleModes(); err == nil {
+		t.Error("expected negative min_bytes to be rejected")
+	}
+}
+
+func TestEvaluateObserveRule(t *testing.T) {
+	newAction := func() *model.Action {
+		return &model.Action{
+			Tool:      "file_read",
+			Resource:  "/data/report.csv",
+			Operation: "read",
+			RawMeta:   map[string]any{"sensitivity": "low", "egress": "internal"},
+		}
+	}
+	cfg := DefaultConfig()
+	cfg.Rules = []Rule{{
+		Purpose:         "*",
+		ResourcePattern: "*report*",
+		Decision:        "deny",
+		Reason:          "reports are frozen",
+		Mode:            RuleModeObserve,
+	}}
+
+	observed := Evaluate(newAction(), model.NewTraceState("t1"), "general", "", nil, cfg)
+	if observed.Decision != model.Allow {
+		t.Fatalf("expected observe rule to allow, got %s (%s)", observed.Decision, observed.Reason)
+	}
+	if len(observed.Observed) != 1 {
+		t.Fatalf("expected 1 observation, got %+v", observed.Observed)
+	}
+	o := observed.Observed[0]
+	if o.Decision != model.Deny || o.Enforced || o.Reason != "reports are frozen" {
+		t.Errorf("unexpected observation: %+v", o)
+	}
+	if !strings.Contains(observed.Reason, "[observe: purpose.*.report would deny]") {
+		t.Errorf("expected observation in reason, got %q", observed.Reason)
+	}
+
+	cfg.Rules[0].Mode = RuleModeEnforce
+	enforced := Evaluate(newAction(), model.NewTraceState("t2"), "general", "", nil, cfg)
+	if enforced.Decision != model.Deny {
+		t.Errorf("expected enforced rule to deny, got %s", enforced.Decision)
+	}
+	if len(enforced.Observed) != 0 {
+		t.Errorf("expected no observations when enforced, got %+v", enforced.Observed)
+	}
+}
+
+func TestEvaluateObserveRuleFallsThroughToNextRule(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Rules = []Rule{
+		{Purpose: "*", ResourcePattern: "*report*", Decision: "deny", Mode: RuleModeObserve},
+		{Purpose: "*", ResourcePattern: "*.csv", Decision: "require_approval", ApprovalKey: "csv"},
+	}
+	action := &model.Action{
+		Tool:      "file_read",
+		Resource:  "/data/report.csv",
+		Operation: "read",
+		RawMeta:   map[string]any{"sensitivity": "low", "egress": "internal"},
+	}
+
+	result := Evaluate(action, model.NewTraceState("t"), "general", "", nil, cfg)
+	if result.Decision != model.RequireApproval || result.ApprovalKey != "csv" {
+		t.Errorf("expected next enforcing rule to apply, got %s (%s)", result.Decision, result.Reason)
+	}
+	if len(result.Observed) != 1 {
+		t.Errorf("expected observation carried on enforced result, got %+v", result.Observed)
+	}
+}
+
+func TestLoadConfigInvalidRuleMode(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	data := "rules:\n  - purpose: \"*\"\n    resource_pattern: \"*x*\"\n    decision: deny\n    mode: shadow\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(path); err == nil || !strings.Contains(err.Error(), "invalid mode") {
+		t.Errorf("expected invalid mode error, got %v", err)
+	}
+}
+
+func TestLoadConfigSuggestRequiresDeny(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	data := "rules:\n  - purpose: \"*\"\n    resource_pattern: \"*x*\"\n    decision: require_approval\n    approval_key: x\n    suggest: \"use y\"\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(path); err == nil || !strings.Contains(err.Error(), "only supported on deny rules") {
+		t.Errorf("expected suggest-on-deny error, got %v", err)
+	}
+}
+
+func TestLoadConfigApprovalGrace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	data := "rules:\n  - purpose: \"*\"\n    resource_pattern: \"*deploy*\"\n    decision: require_approval\n    approval_key: deploy\n    approval_grace: 10m\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := cfg.Rules[len(cfg.Rules)-1].ApprovalGrace; got != 10*time.Minute {
+		t.Errorf("expected approval_grace 10m, got %s", got)
+	}
+
+	data = "rules:\n  - purpose: \"*\"\n    resource_pattern: \"*deploy*\"\n    decision: deny\n    approval_grace: 10m\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(path); err == nil || !strings.Contains(err.Error(), "only supported on require_approval rules") {
+		t.Errorf("expected approval_grace-on-require_approval error, got %v", err)
+	}
+}
+
+func TestLoadConfigApprovalThrottle(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	data := "approval_throttle:\n  max_approvals: 5\n  window: 1h\n"
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := LoadConfig(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.ApprovalThrottle.MaxApprovals != 5 || cfg.ApprovalThrottle.Window != time.Hour {
+		t.Errorf("unexpected approval throttle: %+v", cfg.ApprovalThrottle)
+	}
+}
+
 func TestParseDecision(t *testing.T) {
 	tests := []struct {
 		input string
@@ -226,6 +485,7 @@ func TestParseDecision(t *testing.T) {
 		{"allow_with_redaction", model.AllowWithRedaction},
 		{"require_approval", model.RequireApproval},
 		{"rewrite_output", model.RewriteOutput},
+		{"quarantine", model.Quarantine},
 		{"unknown", model.Deny}, // fail-closed
 		{"", model.Deny},
 	}
@@ -239,7 +499,7 @@ func TestParseDecision(t *testing.T) {
 
 func TestRulePolicyID(t *testing.T) {
 	rule := Rule{Purpose: "SOC_efficiency", ResourcePattern: "*salary*"}
-	id := rulePolicyID(rule)
+	id := RulePolicyID(rule)
 	if id != "purpose.SOC_efficiency.salary" {
 		t.Errorf("expected purpose.SOC_efficiency.salary, got %s", id)
 	}
@@ -247,7 +507,7 @@ func TestRulePolicyID(t *testing.T) {
 
 func TestRulePolicyIDWildcard(t *testing.T) {
 	rule := Rule{Purpose: "general", ResourcePattern: "*"}
-	id := rulePolicyID(rule)
+	id := RulePolicyID(rule)
 	if id != "purpose.general.all" {
 		t.Errorf("expected purpose.general.all, got %s", id)
 	}
@@ -358,3 +618,71 @@ func TestCustomThresholdsChangeDecision(t *testing.T) {
 		t.Errorf("expected Allow with high AllowMax threshold, got %s (%s)", result.Decision, result.Reason)
 	}
 }
+
+func TestEvaluateRulesFirstMatchWins(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.Rules = []Rule{
+		{Purpose: "*", ResourcePattern: "*report*", Decision: "allow", Reason: "reports are fine"},
+		{Purpose: "*", ResourcePattern: "*.csv", Decision: "deny", Reason: "no csv"},
+	}
+	action := &model.Action{
+		Tool:      "file_read",
+		Resource:  "/data/report.csv",
+		Operation: "read",
+		RawMeta:   map[string]any{"sensitivity": "low", "egress": "internal"},
+	}
+
+	result := Evaluate(action, model.NewTraceState("t1"), "general", "", nil, cfg)
+	if result.Decision != model.Allow || result.Reason != "reports are fine" {
+		t.Errorf("expected first matching rule to decide, got %s (%s)", result.Decision, result.Reason)
+	}
+
+	// Reordering changes the outcome: the earlier rule always decides.
+	cfg.Rules[0], cfg.Rules[1] = cfg.Rules[1], cfg.Rules[0]
+	result = Evaluate(action, model.NewTraceState("t2"), "general", "", nil, cfg)
+	if result.Decision != model.Deny || result.Reason != "no csv" {
+		t.Errorf("expected reordered first rule to decide, got %s (%s)", result.Decision, result.Reason)
+	}
+}
+
+func TestEvaluateContinueRuleLayers(t *testing.T) {
+	newAction := func(resource string) *model.Action {
+		return &model.Action{
+			Tool:      "file_read",
+			Resource:  resource,
+			Operation: "read",
+			RawMeta:   map[string]any{"sensitivity": "low", "egress": "internal"},
+		}
+	}
+	cfg := DefaultConfig()
+	cfg.Rules = []Rule{
+		{Purpose: "*", ResourcePattern: "*prod*", Decision: "require_approval", ApprovalKey: "prod", Continue: true},
+		{Purpose: "*", ResourcePattern: "*secrets*", Decision: "deny", Reason: "secrets are off limits"},
+		{Purpose: "*", ResourcePattern: "*readme*", Decision: "allow"},
+	}
+
+	// The later, stricter rule also applies after the continue rule.
+	secret := Evaluate(newAction("/prod/secrets.env"), model.NewTraceState("t1"), "general", "", nil, cfg)
+	if secret.Decision != model.Deny || secret.Reason != "secrets are off limits" {
+		t.Errorf("expected later deny rule to apply, got %s (%s)", secret.Decision, secret.Reason)
+	}
+
+	// A later, looser rule cannot relax the continue rule's decision.
+	readme := Evaluate(newAction("/prod/readme.md"), model.NewTraceState("t2"), "general", "", nil, cfg)
+	if readme.Decision != model.RequireApproval || readme.ApprovalKey != "prod" {
+		t.Errorf("expected continue rule to hold, got %s (%s)", readme.Decision, readme.Reason)
+	}
+
+	// With no later match the continue rule still decides.
+	other := Evaluate(newAction("/prod/app.log"), model.NewTraceState("t3"), "general", "", nil, cfg)
+	if other.Decision != model.RequireApproval || other.ApprovalKey != "prod" {
+		t.Errorf("expected continue rule alone to decide, got %s (%s)", other.Decision, other.Reason)
+	}
+
+	// Without continue, the first rule stops evaluation.
+	cfg.Rules[0].Continue = false
+	stopped := Evaluate(newAction("/prod/secrets.env"), model.NewTraceState("t4"), "general", "", nil, cfg)
+	if stopped.Decision != model.RequireApproval {
+		t.Errorf("expected first rule to stop evaluation, got %s (%s)", stopped.Decision, stopped.Reason)
+	}
+}
@@ -0,0 +1,41 @@
+package policy
+
+import (
+	"time"
+
+	"github.com/ppiankov/chainwatch/internal/model"
+)
+
+// DefaultConfirmTTL is how long a confirm token stays valid when
+// confirm_irreversible.ttl is unset.
+const DefaultConfirmTTL = 5 * time.Minute
+
+// ConfirmConfig adds a second step to approved irreversible actions: the
+// first retry after approval returns a short-lived confirm token bound to
+// the exact action, and only a retry presenting that token proceeds.
+type ConfirmConfig struct {
+	Enabled bool          `yaml:"enabled"`
+	TTL     time.Duration `yaml:"ttl,omitempty"` // token lifetime; default 5m
+}
+
+// ConfirmRequired reports whether an approved require_approval result
+// must also present a confirm token: confirm_irreversible is enabled and
+// the action is at the irreversible tier or its trace has reached the
+// irreversible zone.
+func (c *PolicyConfig) ConfirmRequired(result model.PolicyResult, state *model.TraceState) bool {
+	if c == nil || c.ConfirmIrreversible == nil || !c.ConfirmIrreversible.Enabled {
+		return false
+	}
+	if result.Tier >= TierCritical {
+		return true
+	}
+	return state != nil && state.Zone >= model.Irreversible
+}
+
+// ConfirmTTL returns the configured confirm token lifetime.
+func (c *PolicyConfig) ConfirmTTL() time.Duration {
+	if c == nil || c.ConfirmIrreversible == nil || c.ConfirmIrreversible.TTL <= 0 {
+		return DefaultConfirmTTL
+	}
+	return c.ConfirmIrreversible.TTL
+}
@@ -0,0 +1,43 @@
+package policy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ppiankov/chainwatch/internal/model"
+)
+
+func TestConfirmRequired(t *testing.T) {
+	on := &PolicyConfig{ConfirmIrreversible: &ConfirmConfig{Enabled: true}}
+	irreversible := model.NewTraceState("t1")
+	irreversible.EscalateLevel(model.Irreversible)
+
+	tests := []struct {
+		name   string
+		cfg    *PolicyConfig
+		tier   int
+		state  *model.TraceState
+		expect bool
+	}{
+		{"disabled", DefaultConfig(), TierCritical, irreversible, false},
+		{"critical tier", on, TierCritical, model.NewTraceState("t2"), true},
+		{"irreversible zone", on, TierGuarded, irreversible, true},
+		{"guarded tier", on, TierGuarded, model.NewTraceState("t3"), false},
+	}
+	for _, tt := range tests {
+		got := tt.cfg.ConfirmRequired(model.PolicyResult{Decision: model.RequireApproval, Tier: tt.tier}, tt.state)
+		if got != tt.expect {
+			t.Errorf("%s: ConfirmRequired = %v, want %v", tt.name, got, tt.expect)
+		}
+	}
+}
+
+func TestConfirmTTL(t *testing.T) {
+	if got := DefaultConfig().ConfirmTTL(); got != DefaultConfirmTTL {
+		t.Errorf("default TTL = %s, want %s", got, DefaultConfirmTTL)
+	}
+	cfg := &PolicyConfig{ConfirmIrreversible: &ConfirmConfig{Enabled: true, TTL: time.Minute}}
+	if got := cfg.ConfirmTTL(); got != time.Minute {
+		t.Errorf("TTL = %s, want 1m", got)
+	}
+}
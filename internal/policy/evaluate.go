@@ -5,7 +5,9 @@ import (
 	"strings"
 	"time"
 
+	"github.com/ppiankov/chainwatch/internal/alert"
 	"github.com/ppiankov/chainwatch/internal/budget"
+	"github.com/ppiankov/chainwatch/internal/canary"
 	"github.com/ppiankov/chainwatch/internal/denylist"
 	"github.com/ppiankov/chainwatch/internal/identity"
 	"github.com/ppiankov/chainwatch/internal/model"
@@ -15,21 +17,51 @@ import (
 
 // Evaluate evaluates a single action in the context of the current trace state.
 //
+// A panic during evaluation, e.g. on a malformed action, is recovered and
+// fails closed with an evaluation_panic deny instead of crashing the caller.
+//
+// Purposes outside allowed_purposes are denied before any step below.
+//
 // Evaluation order (must not be changed):
 //
+//	0.25. Trace budget — max evaluated actions per trace (max_actions_per_trace)
 //	0.5. Rate limiting — per-agent per-tool-category caps (before any state mutation)
-//	1. Denylist check — hard block, tier 3
+//	1. Denylist check — hard block, tier 3 (warn entries annotate + force alert)
+//	1.5. Canary tokens — registered canary sent to an external destination, tier 3
 //	2. Zone escalation — update state
-//	3. Tier classification — zones + self-targeting + known-safe + min_tier
+//	3. Tier classification — zones + self-targeting + protected paths + known-safe + min_tier
 //	   3.5. Agent enforcement — scope, purpose, sensitivity, per-agent rules (only if agentID != "")
 //	   3.75. Budget enforcement — per-agent session resource caps (only if budgets configured)
-//	4. Purpose-bound rules — explicit overrides (first match wins)
-//	5. Tier enforcement — mode + tier → decision
-func Evaluate(action *model.Action, state *model.TraceState, purpose string, agentID string, dl *denylist.Denylist, cfg *PolicyConfig) model.PolicyResult {
+//	4. Purpose-bound rules — explicit overrides (first match wins; observe rules only annotate)
+//	5. Tier enforcement — zone_decisions override, else mode + tier → decision
+func Evaluate(action *model.Action, state *model.TraceState, purpose string, agentID string, dl *denylist.Denylist, cfg *PolicyConfig) (result model.PolicyResult) {
+	// Registered first so it runs after every other deferred annotation
+	// and its deny is the final word.
+	defer RecoverEvaluation(&result)
 	if cfg == nil {
 		cfg = DefaultConfig()
 	}
 
+	// Step 0: Purpose allowlist (usually set by a profile's allowed_purposes).
+	// Checked first so a made-up purpose cannot steer purpose-bound rules.
+	if len(cfg.AllowedPurposes) > 0 && !purposeAllowed(purpose, cfg.AllowedPurposes) {
+		return PurposeNotAllowed(purpose, cfg.AllowedPurposes)
+	}
+
+	// Step 0.25: Trace budget (counts every evaluation until reset)
+	if cfg.MaxActionsPerTrace > 0 {
+		if state.ActionCount >= cfg.MaxActionsPerTrace {
+			return model.PolicyResult{
+				Decision: model.Deny,
+				Tier:     TierCritical,
+				Reason: fmt.Sprintf("trace_budget_exhausted: %d/%d actions evaluated in trace",
+					state.ActionCount, cfg.MaxActionsPerTrace),
+				PolicyID: "trace.budget_exhausted",
+			}
+		}
+		state.ActionCount++
+	}
+
 	// Step 0.5: Rate limiting (per-agent per-tool-category, before any state mutation)
 	if len(cfg.RateLimits) > 0 {
 		effectiveAgent := agentID
@@ -43,20 +75,27 @@ func Evaluate(action *model.Action, state *model.TraceState, purpose string, age
 		}
 	}
 
-	// Step 1: Denylist check (hard block, highest priority, always tier 3)
+	// Step 1: Denylist check (hard block, highest priority, always tier 3).
+	// Warn entries do not block: evaluation continues and the final result
+	// is annotated and force-alerted.
 	if dl != nil {
-		if blocked, reason := dl.IsBlocked(action.Resource, action.Tool); blocked {
-			return model.PolicyResult{
-				Decision: model.Deny,
-				Tier:     TierCritical,
-				Reason:   fmt.Sprintf("denylisted: %s", reason),
-				PolicyID: "denylist.block",
-			}
+		switch sev, reason, source := dl.Match(action.Resource, action.Tool); sev {
+		case denylist.SeverityBlock:
+			return DenylistBlock(reason, source)
+		case denylist.SeverityWarn:
+			defer func() { result = withDenylistWarning(result, reason) }()
 		}
 	}
 
 	action.NormalizeMeta()
 
+	// Step 1.5: Canary tokens (exfiltration detection; local reads are not flagged)
+	if len(cfg.Canaries) > 0 && action.NormalizedMeta().Egress == model.EgressExternal {
+		if tok, ok := findCanary(action, cfg.Canaries); ok {
+			return CanaryTriggered(action, tok)
+		}
+	}
+
 	// Step 2: Zone escalation
 	newZones := zone.DetectZones(action, state)
 	for z := range newZones {
@@ -67,10 +106,19 @@ func Evaluate(action *model.Action, state *model.TraceState, purpose string, age
 
 	// Step 3: Tier classification
 	tier := ClassifyTier(state.Zone)
+	zoneTier := true // tier reflects the zone, so zone_decisions may apply
 
 	// Self-targeting override (Law 3: self-preservation is structural)
 	if model.IsSelfTargeting(action) {
 		tier = TierCritical
+		zoneTier = false
+	}
+
+	// Protected paths: writes there persist beyond the session
+	protected := protectedWrite(action, cfg)
+	if protected != "" && tier < TierGuarded {
+		tier = TierGuarded
+		zoneTier = false
 	}
 
 	// Known-safe vs unknown: if no zone signal, distinguish safe from unknown
@@ -86,6 +134,7 @@ func Evaluate(action *model.Action, state *model.TraceState, purpose string, age
 	// Profile min_tier promotion (baked into cfg by profile.ApplyToPolicy)
 	if cfg.MinTier > tier {
 		tier = cfg.MinTier
+		zoneTier = false
 	}
 
 	// Step 3.5: Agent enforcement (only if agentID is provided)
@@ -108,38 +157,82 @@ func Evaluate(action *model.Action, state *model.TraceState, purpose string, age
 		}
 	}
 
-	// Step 4: Purpose-bound rules (explicit overrides, first match wins)
+	// Step 4: Purpose-bound rules (explicit overrides, first match wins).
+	// Observe-mode rules record their would-be decision and fall through.
+	// Continue rules fall through too, but their decision still applies if
+	// it is stricter than the rule that stops evaluation.
+	var observed []model.Observation
+	var layered *model.PolicyResult
+	defer func() {
+		if len(observed) > 0 {
+			result = withObservations(result, observed)
+		}
+	}()
 	for _, rule := range cfg.Rules {
-		if matchRule(rule, purpose, action.Resource) {
+		if matchRule(rule, purpose, action.Resource) && matchLabels(rule.Labels, action.Labels) && matchVolume(rule, action) {
 			decision := parseDecision(rule.Decision)
 			reason := rule.Reason
 			if reason == "" {
 				reason = fmt.Sprintf("%s purpose: %s requires %s",
 					rule.Purpose, rule.ResourcePattern, rule.Decision)
 			}
-			return model.PolicyResult{
-				Decision:    decision,
-				Tier:        tier,
-				Reason:      reason,
-				ApprovalKey: rule.ApprovalKey,
-				PolicyID:    rulePolicyID(rule),
+			if rule.Mode == RuleModeObserve {
+				observed = append(observed, model.Observation{
+					PolicyID: RulePolicyID(rule),
+					Decision: decision,
+					Reason:   reason,
+				})
+				continue
+			}
+			matched := model.PolicyResult{
+				Decision:      decision,
+				Tier:          tier,
+				Reason:        reason,
+				ApprovalKey:   rule.ApprovalKey,
+				PolicyID:      RulePolicyID(rule),
+				AlertMode:     rule.Alert.Mode,
+				AlertChannels: rule.Alert.Channels,
+				Suggestion:    rule.Suggest,
+				ApprovalGrace: rule.ApprovalGrace,
+				RequireReason: rule.RequireReason,
+			}
+			if rule.Continue {
+				if layered == nil || matched.Decision.MoreRestrictive(layered.Decision) {
+					layered = &matched
+				}
+				continue
 			}
+			if layered != nil && layered.Decision.MoreRestrictive(matched.Decision) {
+				return *layered
+			}
+			return matched
 		}
 	}
+	if layered != nil {
+		return *layered
+	}
 
 	// Step 5: Tier enforcement
 	mode := cfg.EnforcementMode
 	if mode == "" {
 		mode = "guarded"
 	}
+	if zoneTier {
+		if result, handled := enforceByZone(state.Zone, tier, mode, cfg); handled {
+			return result
+		}
+	}
 	decision, policyID := EnforceByTier(mode, tier)
 
-	result := model.PolicyResult{
+	result = model.PolicyResult{
 		Decision: decision,
 		Tier:     tier,
 		Reason:   fmt.Sprintf("tier %d (%s) in %s mode", tier, TierLabel(tier), mode),
 		PolicyID: policyID,
 	}
+	if protected != "" {
+		result.Reason += fmt.Sprintf(": %s %s is under protected path %s", action.Operation, action.Resource, protected)
+	}
 
 	if decision == model.RequireApproval {
 		result.ApprovalKey = fmt.Sprintf("tier_%d_action", tier)
@@ -148,6 +241,25 @@ func Evaluate(action *model.Action, state *model.TraceState, purpose string, age
 	return result
 }
 
+// withDenylistWarning annotates a result produced despite a warn-only
+// denylist match and forces an alert, since visibility is the whole point
+// of staging an entry as warn.
+func withDenylistWarning(result model.PolicyResult, reason string) model.PolicyResult {
+	result.Reason = fmt.Sprintf("%s [denylist warn: %s]", result.Reason, reason)
+	result.AlertMode = alert.RuleAlertForce
+	return result
+}
+
+// withObservations attaches observe-mode rule matches to the enforced result
+// and notes them in the reason so trace and audit records carry them.
+func withObservations(result model.PolicyResult, observed []model.Observation) model.PolicyResult {
+	result.Observed = observed
+	for _, o := range observed {
+		result.Reason = fmt.Sprintf("%s [observe: %s would %s]", result.Reason, o.PolicyID, o.Decision)
+	}
+	return result
+}
+
 // evaluateAgent enforces agent identity constraints.
 // Returns (result, true) if the agent check produces a terminal decision.
 // Returns (zero, false) if the action should fall through to step 4/5.
@@ -240,3 +352,73 @@ func formatZones(zones map[model.Zone]bool) string {
 	}
 	return strings.Join(parts, ", ")
 }
+
+// findCanary looks for a registered canary in the action's resource,
+// outbound payload, and parameters.
+func findCanary(action *model.Action, tokens []canary.Token) (canary.Token, bool) {
+	if tok, ok := canary.Find(tokens, action.Resource); ok {
+		return tok, true
+	}
+	if tok, ok := canary.Find(tokens, action.Payload); ok {
+		return tok, true
+	}
+	return canary.FindParams(tokens, action.Params)
+}
+
+// CanaryTriggered builds the tier-3 deny result for a canary token leaving
+// the system. The alert is forced past channel event filters.
+func CanaryTriggered(action *model.Action, tok canary.Token) model.PolicyResult {
+	dest := action.NormalizedMeta().Destination
+	if dest == "" {
+		dest = action.Resource
+	}
+	return model.PolicyResult{
+		Decision:  model.Deny,
+		Tier:      TierCritical,
+		Reason:    fmt.Sprintf("canary_triggered: registered canary %q transmitted externally to %s", tok.Name, dest),
+		PolicyID:  "canary.triggered",
+		AlertMode: alert.RuleAlertForce,
+	}
+}
+
+// NoPurposeAllowed is the allowed_purposes entry left when stacked
+// profiles share no purpose. It never matches, so every purpose is denied.
+const NoPurposeAllowed = "-"
+
+// purposeAllowed reports whether purpose is in the allowlist (case-insensitive).
+func purposeAllowed(purpose string, allowed []string) bool {
+	for _, p := range allowed {
+		if p != NoPurposeAllowed && strings.EqualFold(p, purpose) {
+			return true
+		}
+	}
+	return false
+}
+
+// PurposeNotAllowed builds the tier-3 deny result for a purpose outside the
+// configured allowlist.
+func PurposeNotAllowed(purpose string, allowed []string) model.PolicyResult {
+	return model.PolicyResult{
+		Decision: model.Deny,
+		Tier:     TierCritical,
+		Reason:   fmt.Sprintf("purpose_not_allowed: purpose %q is not in allowed_purposes [%s]", purpose, strings.Join(allowed, ", ")),
+		PolicyID: "purpose.not_allowed",
+	}
+}
+
+// DenylistBlock builds the tier-3 deny result for a denylist match. When
+// the pattern came from a profile boundary, source (e.g. "clawbot:urls")
+// is appended to the reason and the policy ID.
+func DenylistBlock(reason, source string) model.PolicyResult {
+	result := model.PolicyResult{
+		Decision: model.Deny,
+		Tier:     TierCritical,
+		Reason:   fmt.Sprintf("denylisted: %s", reason),
+		PolicyID: "denylist.block",
+	}
+	if source != "" {
+		result.Reason += fmt.Sprintf(" (source: %s)", source)
+		result.PolicyID += "." + source
+	}
+	return result
+}
@@ -0,0 +1,100 @@
+package policy
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+
+	"github.com/ppiankov/chainwatch/internal/model"
+)
+
+// DefaultGuidanceTemplate tells an agent how to proceed after a block.
+const DefaultGuidanceTemplate = `{{if eq .Decision "require_approval"}}This action needs operator approval before it can run.` +
+	`{{if .ApprovalKey}} Ask an operator to run "chainwatch approve {{.ApprovalKey}}", then retry the same action.{{end}}` +
+	`{{else}}This action was blocked by chainwatch policy{{if .PolicyID}} ({{.PolicyID}}){{end}}.` +
+	` Do not retry it unchanged; {{if .Suggestion}}try instead: {{.Suggestion}}{{else}}choose another approach or ask an operator for help.{{end}}{{end}}` +
+	`{{if .Hint}} {{.Hint}}{{end}}`
+
+// DenialGuidance configures the "how to proceed" message added to block
+// responses returned to agents: proxy JSON bodies and MCP tool outputs.
+type DenialGuidance struct {
+	Template string            `yaml:"template,omitempty"` // text/template; empty uses DefaultGuidanceTemplate
+	Hints    map[string]string `yaml:"hints,omitempty"`    // policy ID, or "prefix.*", → remediation hint
+}
+
+// GuidanceData holds the variables available to a guidance template.
+type GuidanceData struct {
+	Decision    string
+	Reason      string
+	PolicyID    string
+	ApprovalKey string
+	Hint        string // operator-supplied remediation for the policy ID
+	Suggestion  string // safer alternative from the matched deny rule
+}
+
+// Validate checks that the template parses.
+func (g *DenialGuidance) Validate() error {
+	if g == nil || g.Template == "" {
+		return nil
+	}
+	if _, err := template.New("guidance").Parse(g.Template); err != nil {
+		return fmt.Errorf("denial_guidance.template: %w", err)
+	}
+	return nil
+}
+
+// Render returns the guidance for a blocking result. A nil receiver
+// renders the default template without hints. A template that fails to
+// execute falls back to the default.
+func (g *DenialGuidance) Render(result model.PolicyResult) string {
+	data := GuidanceData{
+		Decision:    string(result.Decision),
+		Reason:      result.Reason,
+		PolicyID:    result.PolicyID,
+		ApprovalKey: result.ApprovalKey,
+		Suggestion:  result.Suggestion,
+	}
+	text := DefaultGuidanceTemplate
+	if g != nil {
+		data.Hint = g.hint(result.PolicyID)
+		if g.Template != "" {
+			text = g.Template
+		}
+	}
+	if out, err := renderGuidance(text, data); err == nil {
+		return out
+	}
+	out, _ := renderGuidance(DefaultGuidanceTemplate, data)
+	return out
+}
+
+// hint returns the hint for policyID: an exact key first, then the
+// longest matching "prefix.*" key.
+func (g *DenialGuidance) hint(policyID string) string {
+	if policyID == "" {
+		return ""
+	}
+	if h, ok := g.Hints[policyID]; ok {
+		return h
+	}
+	best, bestLen := "", -1
+	for key, h := range g.Hints {
+		prefix, ok := strings.CutSuffix(key, "*")
+		if ok && strings.HasPrefix(policyID, prefix) && len(prefix) > bestLen {
+			best, bestLen = h, len(prefix)
+		}
+	}
+	return best
+}
+
+func renderGuidance(text string, data GuidanceData) (string, error) {
+	tmpl, err := template.New("guidance").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(b.String()), nil
+}
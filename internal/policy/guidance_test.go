@@ -0,0 +1,70 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/ppiankov/chainwatch/internal/model"
+)
+
+func TestGuidanceDefaultTemplate(t *testing.T) {
+	var g *DenialGuidance
+
+	approval := g.Render(model.PolicyResult{Decision: model.RequireApproval, ApprovalKey: "soc_salary_access"})
+	if !strings.Contains(approval, `chainwatch approve soc_salary_access`) {
+		t.Errorf("expected approval instructions, got %q", approval)
+	}
+
+	deny := g.Render(model.PolicyResult{Decision: model.Deny, PolicyID: "denylist.block"})
+	if !strings.Contains(deny, "(denylist.block)") || !strings.Contains(deny, "Do not retry") {
+		t.Errorf("unexpected deny guidance: %q", deny)
+	}
+}
+
+func TestGuidanceHints(t *testing.T) {
+	g := &DenialGuidance{
+		Template: "{{.Decision}}: {{.Hint}}",
+		Hints: map[string]string{
+			"ratelimit.*":        "wait a minute",
+			"ratelimit.global.*": "wait an hour",
+			"denylist.block":     "exact",
+		},
+	}
+	tests := []struct {
+		policyID string
+		want     string
+	}{
+		{"denylist.block", "deny: exact"},
+		{"ratelimit.global.http_proxy_exceeded", "deny: wait an hour"},
+		{"ratelimit.agent.command_exceeded", "deny: wait a minute"},
+		{"other", "deny:"},
+	}
+	for _, tt := range tests {
+		if got := g.Render(model.PolicyResult{Decision: model.Deny, PolicyID: tt.policyID}); got != tt.want {
+			t.Errorf("Render(%s) = %q, want %q", tt.policyID, got, tt.want)
+		}
+	}
+}
+
+func TestGuidanceBadTemplateFallsBack(t *testing.T) {
+	g := &DenialGuidance{Template: "{{.Missing.Field}}"}
+	got := g.Render(model.PolicyResult{Decision: model.Deny})
+	if !strings.Contains(got, "blocked by chainwatch policy") {
+		t.Errorf("expected default guidance on execute error, got %q", got)
+	}
+}
+
+func TestLoadConfigRejectsInvalidGuidanceTemplate(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(path, []byte("denial_guidance:\n  template: \"{{.Decision\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(path); err == nil {
+		t.Error("expected LoadConfig to reject an unparseable template")
+	}
+	if _, _, err := LoadConfigWithHash(path); err == nil {
+		t.Error("expected LoadConfigWithHash to reject an unparseable template")
+	}
+}
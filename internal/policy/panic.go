@@ -0,0 +1,29 @@
+package policy
+
+import (
+	"fmt"
+
+	"github.com/ppiankov/chainwatch/internal/model"
+)
+
+// RecoverEvaluation turns a panic in an evaluation step into the
+// EvaluationPanic deny stored in *result. Surfaces defer it around
+// enrichment, policy evaluation and the decision hook so a malformed
+// action fails closed instead of taking the process down.
+func RecoverEvaluation(result *model.PolicyResult) {
+	if r := recover(); r != nil {
+		*result = EvaluationPanic(r)
+	}
+}
+
+// EvaluationPanic builds the fail-closed result for an evaluation that
+// panicked. The panic value is kept in the reason so the audit entry
+// recorded for the deny shows what went wrong.
+func EvaluationPanic(recovered any) model.PolicyResult {
+	return model.PolicyResult{
+		Decision: model.Deny,
+		Tier:     TierCritical,
+		Reason:   fmt.Sprintf("evaluation_panic: %v", recovered),
+		PolicyID: "evaluation.panic",
+	}
+}
@@ -0,0 +1,86 @@
+package policy
+
+import (
+	"os"
+	"strings"
+
+	"github.com/ppiankov/chainwatch/internal/model"
+)
+
+// DefaultProtectedPaths are locations where a write or delete outlives the
+// session: scheduled jobs, services, shell startup files and system
+// binaries. Entries ending in "/" match everything below them; "~/"
+// entries match in the home directory of the user running chainwatch.
+// The list applies only when PolicyConfig.ProtectDefaultPaths is set, since
+// it puts every /etc write behind approval.
+var DefaultProtectedPaths = []string{
+	"/etc/",
+	"/boot/",
+	"/bin/",
+	"/sbin/",
+	"/usr/bin/",
+	"/usr/sbin/",
+	"/usr/local/bin/",
+	"/lib/systemd/",
+	"/usr/lib/systemd/",
+	"/var/spool/cron/",
+	"~/.ssh/authorized_keys",
+	"~/.bashrc",
+	"~/.bash_profile",
+	"~/.profile",
+	"~/.zshrc",
+	"~/.config/systemd/",
+	"~/.config/autostart/",
+}
+
+// protectedWrite returns the protected path entry a write, move or delete
+// action targets, or "" when it targets none.
+func protectedWrite(action *model.Action, cfg *PolicyConfig) string {
+	if !isFileMutation(action) {
+		return ""
+	}
+	lists := [][]string{cfg.ProtectedPaths}
+	if cfg.ProtectDefaultPaths {
+		lists = append(lists, DefaultProtectedPaths)
+	}
+	home, _ := os.UserHomeDir()
+	resource := strings.ToLower(action.Resource)
+	for _, list := range lists {
+		for _, p := range list {
+			if matchProtectedPath(resource, strings.ToLower(p), strings.ToLower(home)) {
+				return p
+			}
+		}
+	}
+	return ""
+}
+
+// isFileMutation reports whether an action writes, moves or deletes a file.
+func isFileMutation(action *model.Action) bool {
+	switch strings.ToLower(action.Operation) {
+	case "write", "delete", "move", "copy":
+		return true
+	}
+	tool := strings.ToLower(action.Tool)
+	return strings.Contains(tool, "write") || strings.Contains(tool, "delete")
+}
+
+// matchProtectedPath reports whether resource is pattern or, for a
+// pattern ending in "/", lies below it. A "~/" pattern is anchored to home;
+// a resource still written as "~/..." is taken to be in home too.
+func matchProtectedPath(resource, pattern, home string) bool {
+	if rest, ok := strings.CutPrefix(pattern, "~/"); ok {
+		if r, ok := strings.CutPrefix(resource, "~/"); ok {
+			resource = r
+		} else if r, ok := strings.CutPrefix(resource, strings.TrimSuffix(home, "/")+"/"); ok && home != "" {
+			resource = r
+		} else {
+			return false
+		}
+		pattern = rest
+	}
+	if strings.HasSuffix(pattern, "/") {
+		return strings.HasPrefix(resource, pattern)
+	}
+	return resource == pattern
+}
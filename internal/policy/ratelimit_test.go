@@ -88,7 +88,7 @@ func TestRateLimitFiresBeforeDenylist(t *testing.T) {
 
 	// Also set up a denylist that would block this resource
 	dl := denylist.New(denylist.Patterns{
-		Commands: []string{"/bin/ls"},
+		Commands: denylist.Entries("/bin/ls"),
 	})
 
 	action := &model.Action{
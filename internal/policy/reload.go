@@ -0,0 +1,77 @@
+package policy
+
+import (
+	"sync"
+
+	"github.com/ppiankov/chainwatch/internal/alert"
+	"github.com/ppiankov/chainwatch/internal/decisionhook"
+	"github.com/ppiankov/chainwatch/internal/denylist"
+)
+
+// Enforcement is a snapshot of an enforcement point's reloadable policy
+// state. A request takes one when it arrives and keeps it to the end, so a
+// reload never mixes old and new config within a request.
+type Enforcement struct {
+	Denylist   *denylist.Denylist
+	Config     *PolicyConfig
+	Hash       string
+	Dispatcher *alert.Dispatcher
+	Hook       *decisionhook.Hook
+	Enrich     *decisionhook.Enrichment
+}
+
+// Loader reads the denylist and policy config from their configured
+// sources, with any profile applied, and returns the policy hash.
+type Loader func() (*denylist.Denylist, *PolicyConfig, string, error)
+
+// Live holds the current Enforcement of a long-running enforcement point
+// and swaps it in atomically on reload.
+type Live struct {
+	mu  sync.RWMutex
+	cur *Enforcement
+}
+
+// NewLive loads the initial Enforcement with load.
+func NewLive(load Loader) (*Live, error) {
+	e, err := buildEnforcement(load)
+	if err != nil {
+		return nil, err
+	}
+	return &Live{cur: e}, nil
+}
+
+// Snapshot returns the current policy state.
+func (l *Live) Snapshot() *Enforcement {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.cur
+}
+
+// Reload loads a new Enforcement with load and swaps it in. In-flight
+// requests finish on the snapshot they took. On error the running config
+// is left unchanged.
+func (l *Live) Reload(load Loader) (*Enforcement, error) {
+	e, err := buildEnforcement(load)
+	if err != nil {
+		return nil, err
+	}
+	l.mu.Lock()
+	l.cur = e
+	l.mu.Unlock()
+	return e, nil
+}
+
+func buildEnforcement(load Loader) (*Enforcement, error) {
+	dl, cfg, hash, err := load()
+	if err != nil {
+		return nil, err
+	}
+	return &Enforcement{
+		Denylist:   dl,
+		Config:     cfg,
+		Hash:       hash,
+		Dispatcher: alert.NewDispatcher(cfg.Alerts),
+		Hook:       decisionhook.New(cfg.DecisionHook),
+		Enrich:     decisionhook.NewEnrichment(cfg.EnrichmentHook),
+	}, nil
+}
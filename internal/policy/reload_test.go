@@ -0,0 +1,48 @@
+package policy
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ppiankov/chainwatch/internal/denylist"
+)
+
+func TestLiveReloadKeepsConfigOnError(t *testing.T) {
+	load := func(mode string, err error) Loader {
+		return func() (*denylist.Denylist, *PolicyConfig, string, error) {
+			if err != nil {
+				return nil, nil, "", err
+			}
+			cfg := DefaultConfig()
+			cfg.EnforcementMode = mode
+			return denylist.NewDefault(), cfg, "hash-" + mode, nil
+		}
+	}
+
+	live, err := NewLive(load("guarded", nil))
+	if err != nil {
+		t.Fatalf("NewLive: %v", err)
+	}
+	before := live.Snapshot()
+	if before.Hash != "hash-guarded" || before.Config.EnforcementMode != "guarded" {
+		t.Fatalf("unexpected initial snapshot: %+v", before)
+	}
+
+	if _, err := live.Reload(load("", errors.New("bad policy"))); err == nil {
+		t.Fatal("expected reload error")
+	}
+	if live.Snapshot() != before {
+		t.Error("failed reload replaced the running config")
+	}
+
+	if _, err := live.Reload(load("locked", nil)); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if after := live.Snapshot(); after.Hash != "hash-locked" || after.Config.EnforcementMode != "locked" {
+		t.Errorf("expected locked config after reload, got %s %q", after.Hash, after.Config.EnforcementMode)
+	}
+	// A snapshot taken before the reload keeps the old config.
+	if before.Config.EnforcementMode != "guarded" {
+		t.Errorf("earlier snapshot changed to %q", before.Config.EnforcementMode)
+	}
+}
@@ -0,0 +1,26 @@
+package policy
+
+// EvaluationStep is one stage of Evaluate. ID matches the "Step N:" comment
+// that marks the stage in Evaluate.
+type EvaluationStep struct {
+	ID      string
+	Name    string
+	Summary string
+}
+
+// EvaluationSteps lists Evaluate's stages in evaluation order. Renderers of
+// the decision logic (policytree) walk this list, and a test keeps it in
+// step with Evaluate, so a new stage cannot go undocumented.
+var EvaluationSteps = []EvaluationStep{
+	{"0", "Purpose allowlist", "purposes outside allowed_purposes are denied"},
+	{"0.25", "Trace budget", "deny once a trace has evaluated max_actions_per_trace actions"},
+	{"0.5", "Rate limiting", "per-agent per-tool-category caps, before any state mutation"},
+	{"1", "Denylist", "hard block at tier 3; warn entries annotate and force an alert"},
+	{"1.5", "Canary tokens", "a registered canary sent to an external destination is denied at tier 3"},
+	{"2", "Zone escalation", "zones entered by the action accumulate and raise the trace's irreversibility level"},
+	{"3", "Tier classification", "zone level, self-targeting, protected paths, known-safe actions and min_tier set the tier"},
+	{"3.5", "Agent enforcement", "scope, purpose, sensitivity and per-agent rules, when an agent ID is supplied"},
+	{"3.75", "Budget enforcement", "per-agent session resource caps"},
+	{"4", "Purpose-bound rules", "explicit overrides; the first match wins, observe rules only annotate"},
+	{"5", "Tier enforcement", "zone_decisions override, else mode and tier decide"},
+}
@@ -0,0 +1,27 @@
+package policy
+
+import (
+	"os"
+	"regexp"
+	"testing"
+)
+
+func TestEvaluationStepsMatchEvaluate(t *testing.T) {
+	src, err := os.ReadFile("evaluate.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, m := range regexp.MustCompile(`(?m)^\t// Step ([0-9.]+):`).FindAllStringSubmatch(string(src), -1) {
+		ids = append(ids, m[1])
+	}
+
+	if len(ids) != len(EvaluationSteps) {
+		t.Fatalf("Evaluate has steps %v, EvaluationSteps has %d entries", ids, len(EvaluationSteps))
+	}
+	for i, id := range ids {
+		if EvaluationSteps[i].ID != id {
+			t.Errorf("step %d: Evaluate has %q, EvaluationSteps has %q", i, id, EvaluationSteps[i].ID)
+		}
+	}
+}
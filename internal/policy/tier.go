@@ -47,7 +47,7 @@ func IsKnownSafe(action *model.Action) bool {
 
 	op := strings.ToLower(action.Operation)
 	// Read-only file/HTTP operations on non-sensitive data
-	if op == "read" || op == "get" {
+	if op == "read" || model.IsSafeHTTPMethod(op) {
 		return true
 	}
 
@@ -64,6 +64,11 @@ func IsKnownSafe(action *model.Action) bool {
 	return false
 }
 
+// KnownSafeCommands returns the commands IsKnownSafe treats as tier 0.
+func KnownSafeCommands() []string {
+	return append([]string(nil), knownSafeCommands...)
+}
+
 var knownSafeCommands = []string{
 	"ls", "cat", "whoami", "pwd", "echo", "date", "hostname", "uname",
 	"wc", "head", "tail", "which", "env", "printenv", "id",
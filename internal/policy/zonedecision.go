@@ -0,0 +1,89 @@
+package policy
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ppiankov/chainwatch/internal/model"
+)
+
+// zoneNames maps zone_decisions keys to boundary zones.
+var zoneNames = map[string]model.BoundaryZone{
+	"safe":         model.Safe,
+	"sensitive":    model.Sensitive,
+	"commitment":   model.Commitment,
+	"irreversible": model.Irreversible,
+}
+
+// zoneDecisionRank orders the decisions a zone override may use.
+var zoneDecisionRank = map[string]int{
+	"allow":            0,
+	"require_approval": 1,
+	"deny":             2,
+}
+
+// ValidateZoneDecisions checks zone_decisions against the enforcement mode.
+// Keys must name a zone, values must be allow, require_approval, or deny.
+// Advisory mode never blocks, so overrides are rejected; locked mode only
+// accepts overrides at least as strict as its own default for the zone.
+func (c *PolicyConfig) ValidateZoneDecisions() error {
+	if len(c.ZoneDecisions) == 0 {
+		return nil
+	}
+
+	mode := c.EnforcementMode
+	if mode == "" {
+		mode = "guarded"
+	}
+	if mode == "advisory" {
+		return fmt.Errorf("zone_decisions: not allowed in advisory mode")
+	}
+
+	names := make([]string, 0, len(c.ZoneDecisions))
+	for name := range c.ZoneDecisions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		decision := c.ZoneDecisions[name]
+		z, ok := zoneNames[name]
+		if !ok {
+			return fmt.Errorf("zone_decisions: unknown zone %q (want safe, sensitive, commitment, or irreversible)", name)
+		}
+		rank, ok := zoneDecisionRank[decision]
+		if !ok {
+			return fmt.Errorf("zone_decisions.%s: invalid decision %q (want allow, require_approval, or deny)", name, decision)
+		}
+		if mode == "locked" {
+			def, _ := EnforceByTier(mode, ClassifyTier(z))
+			if rank < zoneDecisionRank[string(def)] {
+				return fmt.Errorf("zone_decisions.%s: %s is weaker than %s in locked mode", name, decision, def)
+			}
+		}
+	}
+	return nil
+}
+
+// enforceByZone applies a zone_decisions override for the trace's zone.
+// Returns (result, true) when an override exists.
+func enforceByZone(z model.BoundaryZone, tier int, mode string, cfg *PolicyConfig) (model.PolicyResult, bool) {
+	name := strings.ToLower(z.String())
+	d, ok := cfg.ZoneDecisions[name]
+	if !ok {
+		return model.PolicyResult{}, false
+	}
+
+	decision := parseDecision(d)
+	result := model.PolicyResult{
+		Decision: decision,
+		Tier:     tier,
+		Reason:   fmt.Sprintf("%s zone configured as %s in %s mode", name, d, mode),
+		PolicyID: fmt.Sprintf("zone.%s.%s", name, d),
+	}
+	if decision == model.RequireApproval {
+		result.ApprovalKey = fmt.Sprintf("tier_%d_action", tier)
+	}
+	return result, true
+}
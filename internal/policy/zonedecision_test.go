@@ -0,0 +1,109 @@
+package policy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ppiankov/chainwatch/internal/model"
+)
+
+// commitmentAction returns a trace already credential-adjacent and an
+// egress action that escalates it to the Commitment zone.
+func commitmentAction() (*model.Action, *model.TraceState) {
+	state := model.NewTraceState("test")
+	state.ZonesEntered[model.ZoneCredentialAdjacent] = true
+	return &model.Action{
+		Tool:      "http",
+		Resource:  "https://api.example.com/data",
+		Operation: "get",
+		RawMeta:   map[string]any{"sensitivity": "low"},
+	}, state
+}
+
+func TestCommitmentZoneDecisionOverride(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ZoneDecisions = map[string]string{"commitment": "deny"}
+
+	action, state := commitmentAction()
+	result := Evaluate(action, state, "general", "", nil, cfg)
+
+	if result.Decision != model.Deny {
+		t.Errorf("expected Deny for overridden COMMITMENT zone, got %s", result.Decision)
+	}
+	if result.Tier != TierGuarded {
+		t.Errorf("expected tier 2 (guarded), got %d", result.Tier)
+	}
+	if result.PolicyID != "zone.commitment.deny" {
+		t.Errorf("expected policy ID zone.commitment.deny, got %s", result.PolicyID)
+	}
+}
+
+func TestZoneDecisionOtherZonesUnchanged(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ZoneDecisions = map[string]string{"irreversible": "deny"}
+
+	action, state := commitmentAction()
+	result := Evaluate(action, state, "general", "", nil, cfg)
+
+	if result.Decision != model.RequireApproval {
+		t.Errorf("expected default RequireApproval for COMMITMENT zone, got %s", result.Decision)
+	}
+}
+
+func TestZoneDecisionDoesNotOverrideSelfTargeting(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.ZoneDecisions = map[string]string{"safe": "allow"}
+
+	action := &model.Action{
+		Tool:      "command",
+		Resource:  "chainwatch approve tier_3_action",
+		Operation: "execute",
+		RawMeta:   map[string]any{"sensitivity": "low"},
+	}
+	result := Evaluate(action, model.NewTraceState("test"), "general", "", nil, cfg)
+	if result.Decision != model.Deny {
+		t.Errorf("expected self-targeting to stay denied, got %s", result.Decision)
+	}
+}
+
+func TestValidateZoneDecisions(t *testing.T) {
+	tests := []struct {
+		name    string
+		mode    string
+		zones   map[string]string
+		wantErr bool
+	}{
+		{"guarded tighten", "guarded", map[string]string{"commitment": "deny"}, false},
+		{"guarded loosen", "guarded", map[string]string{"commitment": "allow"}, false},
+		{"locked tighten", "locked", map[string]string{"sensitive": "deny"}, false},
+		{"locked loosen", "locked", map[string]string{"commitment": "require_approval"}, true},
+		{"advisory", "advisory", map[string]string{"commitment": "deny"}, true},
+		{"unknown zone", "guarded", map[string]string{"commit": "deny"}, true},
+		{"unknown decision", "guarded", map[string]string{"commitment": "quarantine"}, true},
+		{"empty", "advisory", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &PolicyConfig{EnforcementMode: tt.mode, ZoneDecisions: tt.zones}
+			err := cfg.ValidateZoneDecisions()
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateZoneDecisions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadConfigRejectsInvalidZoneDecisions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policy.yaml")
+	yaml := "enforcement_mode: locked\nzone_decisions:\n  irreversible: allow\n"
+	if err := os.WriteFile(path, []byte(yaml), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig(path); err == nil {
+		t.Error("expected LoadConfig to reject loosening override in locked mode")
+	}
+	if _, _, err := LoadConfigWithHash(path); err == nil {
+		t.Error("expected LoadConfigWithHash to reject loosening override in locked mode")
+	}
+}
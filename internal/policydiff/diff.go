@@ -3,6 +3,7 @@ package policydiff
 import (
 	"fmt"
 	"sort"
+	"strings"
 
 	"github.com/ppiankov/chainwatch/internal/policy"
 )
@@ -75,6 +76,9 @@ func Diff(old, new *policy.PolicyConfig) *DiffResult {
 	diffMapKeys(r, "budgets", budgetKeys(old), budgetKeys(new))
 	diffMapKeys(r, "rate_limits", rateLimitKeys(old), rateLimitKeys(new))
 
+	// Zone decision overrides
+	diffZoneDecisions(r, old.ZoneDecisions, new.ZoneDecisions)
+
 	r.HasChanges = len(r.Changes) > 0 || len(r.RuleChanges) > 0
 	return r
 }
@@ -90,6 +94,30 @@ func diffInt(r *DiffResult, field string, old, new int, higherIsStricter bool) {
 	}
 }
 
+func diffZoneDecisions(r *DiffResult, old, new map[string]string) {
+	seen := make(map[string]bool)
+	var zones []string
+	for _, m := range []map[string]string{old, new} {
+		for z := range m {
+			if !seen[z] {
+				seen[z] = true
+				zones = append(zones, z)
+			}
+		}
+	}
+	sort.Strings(zones)
+
+	for _, z := range zones {
+		if old[z] != new[z] {
+			r.Changes = append(r.Changes, Change{
+				Field: "zone_decisions." + z,
+				Old:   old[z],
+				New:   new[z],
+			})
+		}
+	}
+}
+
 func intComment(old, new int, higherIsStricter bool) string {
 	if higherIsStricter {
 		if new > old {
@@ -105,13 +133,34 @@ func intComment(old, new int, higherIsStricter bool) string {
 }
 
 func ruleKey(r policy.Rule) string {
-	return r.Purpose + "|" + r.ResourcePattern
+	return r.Purpose + "|" + r.ResourcePattern + "|" + labelSelector(r.Labels)
 }
 
 func ruleLabel(r policy.Rule) string {
+	if len(r.Labels) > 0 {
+		return fmt.Sprintf("purpose=%s resource=%s labels=%s", r.Purpose, r.ResourcePattern, labelSelector(r.Labels))
+	}
 	return fmt.Sprintf("purpose=%s resource=%s", r.Purpose, r.ResourcePattern)
 }
 
+// ruleMode returns a rule's effective mode; empty means enforce.
+func ruleMode(r policy.Rule) string {
+	if r.Mode == "" {
+		return policy.RuleModeEnforce
+	}
+	return r.Mode
+}
+
+// labelSelector renders a rule's label selector as sorted key=value pairs.
+func labelSelector(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for k, v := range labels {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
 func diffRules(r *DiffResult, oldRules, newRules []policy.Rule) {
 	oldMap := make(map[string]policy.Rule)
 	for _, rule := range oldRules {
@@ -133,6 +182,24 @@ func diffRules(r *DiffResult, oldRules, newRules []policy.Rule) {
 					Rule: fmt.Sprintf("%s → %s (was: %s)", ruleLabel(rule), rule.Decision, oldRule.Decision),
 				})
 			}
+			if ruleMode(oldRule) != ruleMode(rule) {
+				r.RuleChanges = append(r.RuleChanges, RuleChange{
+					Type: "changed",
+					Rule: fmt.Sprintf("%s mode → %s (was: %s)", ruleLabel(rule), ruleMode(rule), ruleMode(oldRule)),
+				})
+			}
+			if oldRule.Continue != rule.Continue {
+				r.RuleChanges = append(r.RuleChanges, RuleChange{
+					Type: "changed",
+					Rule: fmt.Sprintf("%s continue → %t (was: %t)", ruleLabel(rule), rule.Continue, oldRule.Continue),
+				})
+			}
+			if oldRule.RequireReason != rule.RequireReason {
+				r.RuleChanges = append(r.RuleChanges, RuleChange{
+					Type: "changed",
+					Rule: fmt.Sprintf("%s require_reason → %t (was: %t)", ruleLabel(rule), rule.RequireReason, oldRule.RequireReason),
+				})
+			}
 		} else {
 			r.RuleChanges = append(r.RuleChanges, RuleChange{
 				Type: "added",
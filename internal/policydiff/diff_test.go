@@ -1,6 +1,7 @@
 package policydiff
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/ppiankov/chainwatch/internal/policy"
@@ -68,6 +69,20 @@ func TestChangedEnforcementMode(t *testing.T) {
 	}
 }
 
+func TestChangedZoneDecision(t *testing.T) {
+	a := policy.DefaultConfig()
+	b := policy.DefaultConfig()
+	b.ZoneDecisions = map[string]string{"commitment": "deny"}
+
+	r := Diff(a, b)
+	if !r.HasChanges {
+		t.Fatal("expected changes")
+	}
+	if len(r.Changes) != 1 || r.Changes[0].Field != "zone_decisions.commitment" || r.Changes[0].New != "deny" {
+		t.Errorf("expected zone_decisions.commitment → deny, got %+v", r.Changes)
+	}
+}
+
 func TestAddedRuleDetected(t *testing.T) {
 	a := policy.DefaultConfig()
 	b := policy.DefaultConfig()
@@ -136,6 +151,17 @@ func TestChangedRuleDecision(t *testing.T) {
 	}
 }
 
+func TestChangedRuleMode(t *testing.T) {
+	a := policy.DefaultConfig()
+	b := policy.DefaultConfig()
+	b.Rules[0].Mode = policy.RuleModeObserve
+
+	r := Diff(a, b)
+	if len(r.RuleChanges) != 1 || !strings.Contains(r.RuleChanges[0].Rule, "mode → observe (was: enforce)") {
+		t.Errorf("expected mode change, got %+v", r.RuleChanges)
+	}
+}
+
 func TestChangedSensitivityWeight(t *testing.T) {
 	a := policy.DefaultConfig()
 	b := policy.DefaultConfig()
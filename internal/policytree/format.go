@@ -0,0 +1,249 @@
+package policytree
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// FormatMarkdown renders the tree as a markdown document, one section per
+// evaluation step.
+func FormatMarkdown(t *Tree) string {
+	var b strings.Builder
+
+	b.WriteString("# Chainwatch policy decision tree\n\n")
+	if t.PolicyPath != "" {
+		fmt.Fprintf(&b, "- Policy: `%s`\n", t.PolicyPath)
+	}
+	if t.PolicyHash != "" {
+		fmt.Fprintf(&b, "- Policy hash: `%s`\n", t.PolicyHash)
+	}
+	if len(t.Profiles) > 0 {
+		fmt.Fprintf(&b, "- Profiles: %s\n", strings.Join(t.Profiles, ", "))
+	}
+	fmt.Fprintf(&b, "- Enforcement mode: **%s**\n", t.EnforcementMode)
+	b.WriteString("\nSteps run in order; the first step that decides ends evaluation.\n")
+
+	for _, step := range t.Steps {
+		fmt.Fprintf(&b, "\n## %s. %s\n\n", step.ID, step.Name)
+		if write, ok := stepSections[step.ID]; ok {
+			write(&b, t)
+		} else {
+			fmt.Fprintf(&b, "%s.\n", upperFirst(step.Summary))
+		}
+	}
+
+	b.WriteString("\n## Thresholds\n\n")
+	fmt.Fprintf(&b, "- allow_max: %d\n- approval_min: %d\n", t.Thresholds.AllowMax, t.Thresholds.ApprovalMin)
+
+	if t.Guidance != nil && len(t.Guidance.Hints) > 0 {
+		b.WriteString("\n## Denial guidance hints\n\n")
+		keys := make([]string, 0, len(t.Guidance.Hints))
+		for k := range t.Guidance.Hints {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(&b, "- `%s`: %s\n", k, t.Guidance.Hints[k])
+		}
+	}
+
+	return b.String()
+}
+
+// stepSections render the configuration each evaluation step reads, keyed
+// by policy.EvaluationSteps ID.
+var stepSections = map[string]func(*strings.Builder, *Tree){
+	"0": func(b *strings.Builder, t *Tree) {
+		if len(t.AllowedPurposes) == 0 {
+			b.WriteString("Not configured: any purpose is accepted.\n")
+			return
+		}
+		fmt.Fprintf(b, "Deny (tier 3, `purpose.not_allowed`) unless the purpose is one of: %s\n", codeList(t.AllowedPurposes))
+	},
+	"0.25": func(b *strings.Builder, t *Tree) {
+		if t.MaxActionsPerTrace == 0 {
+			b.WriteString("Not configured.\n")
+			return
+		}
+		fmt.Fprintf(b, "Deny (tier 3, `trace.budget_exhausted`) once a trace has evaluated %d actions.\n", t.MaxActionsPerTrace)
+	},
+	"0.5": func(b *strings.Builder, t *Tree) {
+		if len(t.RateLimits) == 0 {
+			b.WriteString("Not configured.\n")
+			return
+		}
+		b.WriteString("Deny once an agent exceeds its cap for a tool category. Agent `*` applies to actions without an agent ID.\n\n")
+		b.WriteString("| Agent | Category | Max requests | Window |\n|---|---|---|---|\n")
+		for _, l := range t.RateLimits {
+			fmt.Fprintf(b, "| %s | %s | %d | %s |\n", cell(l.Agent), cell(l.Category), l.MaxRequests, l.Window)
+		}
+	},
+	"1": func(b *strings.Builder, t *Tree) {
+		fmt.Fprintf(b, "Any match is **%s** at tier %d.\n\n", t.Denylist.Decision, t.Denylist.Tier)
+		writeList(b, "URLs", t.Denylist.URLs)
+		writeList(b, "Files", t.Denylist.Files)
+		writeList(b, "Commands", t.Denylist.Commands)
+		if len(t.Denylist.Warn) > 0 {
+			b.WriteString("\nThese patterns only alert; evaluation continues:\n\n")
+			writeList(b, "Warn", t.Denylist.Warn)
+		}
+	},
+	"1.5": func(b *strings.Builder, t *Tree) {
+		if t.Canaries == 0 {
+			b.WriteString("Not configured.\n")
+			return
+		}
+		fmt.Fprintf(b, "Deny (tier 3) when any of %d registered canary tokens leaves the system.\n", t.Canaries)
+	},
+	"2": func(b *strings.Builder, t *Tree) {
+		b.WriteString("An action enters a zone when its resource or tool contains a pattern. Zones accumulate for the trace.\n\n")
+		b.WriteString("| Zone | Patterns | Also entered by |\n|---|---|---|\n")
+		for _, z := range t.Zones {
+			fmt.Fprintf(b, "| %s | %s | %s |\n", z.Zone, codeList(z.Patterns), z.Condition)
+		}
+		b.WriteString("\nWhen every zone in a combination has been entered, the trace escalates. The highest level wins.\n\n")
+		b.WriteString("| Zones | Level | Tier | Decision | Policy ID |\n|---|---|---|---|---|\n")
+		for _, e := range t.Escalations {
+			fmt.Fprintf(b, "| %s | %s | %d | %s | `%s` |\n", strings.Join(e.Zones, " + "), e.Level, e.Tier, e.Decision, e.PolicyID)
+		}
+	},
+	"3": func(b *strings.Builder, t *Tree) {
+		b.WriteString("The trace's escalation level sets the tier, then:\n\n")
+		fmt.Fprintf(b, "- Actions whose resource contains any of these are tier %d (**%s** unless a rule matches): %s\n",
+			t.SelfTarget.Tier, t.SelfTarget.Decision, codeList(t.SelfTarget.Patterns))
+		if len(t.ProtectedPaths) > 0 {
+			fmt.Fprintf(b, "- Writes, moves and deletes under these paths are at least tier 2: %s\n", codeList(t.ProtectedPaths))
+		}
+		fmt.Fprintf(b, "- With no zone signal, only known-safe actions stay tier 0: low-sensitivity reads and these commands: %s. Others are tier 1.\n",
+			codeList(t.KnownSafeCommands))
+		if t.MinTier > 0 {
+			fmt.Fprintf(b, "- Every action is at least tier %d (`min_tier`).\n", t.MinTier)
+		}
+	},
+	"3.5": func(b *strings.Builder, t *Tree) {
+		b.WriteString("Applies only when the action carries an agent ID. ")
+		if len(t.Agents) == 0 {
+			b.WriteString("No agents are registered, so every action with an agent ID is denied.\n")
+			return
+		}
+		fmt.Fprintf(b, "Registered agents: %s. Unknown agents, unauthorized purposes, out-of-scope resources, and sensitivity above the agent cap are denied.\n",
+			strings.Join(t.Agents, ", "))
+	},
+	"3.75": func(b *strings.Builder, t *Tree) {
+		if len(t.Budgets) == 0 {
+			b.WriteString("Not configured.\n")
+			return
+		}
+		b.WriteString("Deny once an agent's session exceeds a cap. Agent `*` applies to actions without an agent ID.\n\n")
+		b.WriteString("| Agent | Max bytes | Max rows | Max duration |\n|---|---|---|---|\n")
+		for _, bg := range t.Budgets {
+			fmt.Fprintf(b, "| %s | %d | %d | %s |\n", cell(bg.Agent), bg.MaxBytes, bg.MaxRows, bg.MaxDuration)
+		}
+	},
+	"4": func(b *strings.Builder, t *Tree) {
+		if len(t.Rules) == 0 {
+			b.WriteString("No rules configured.\n")
+			return
+		}
+		b.WriteString("Evaluated in order; the first matching rule decides unless it continues.\n\n")
+		b.WriteString("| # | Purpose | Resource | Labels | Volume | Decision | Approval key | Mode | Policy ID |\n|---|---|---|---|---|---|---|---|---|\n")
+		for _, r := range t.Rules {
+			mode := r.Mode
+			if r.Continue {
+				mode += ", continue"
+			}
+			if r.RequireReason {
+				mode += ", reason required"
+			}
+			fmt.Fprintf(b, "| %d | %s | `%s` | %s | %s | %s | %s | %s | `%s` |\n",
+				r.Order, cell(r.Purpose), cell(r.ResourcePattern), cell(formatLabels(r.Labels)), formatVolume(r), r.Decision, cell(r.ApprovalKey), mode, cell(r.PolicyID))
+		}
+	},
+	"5": func(b *strings.Builder, t *Tree) {
+		fmt.Fprintf(b, "Decision by tier in %s mode when no rule matched.\n\n", t.EnforcementMode)
+		b.WriteString("| Tier | Label | Decision | Policy ID |\n|---|---|---|---|\n")
+		for _, tier := range t.Tiers {
+			fmt.Fprintf(b, "| %d | %s | %s | `%s` |\n", tier.Tier, tier.Label, tier.Decision, tier.PolicyID)
+		}
+		if len(t.ZoneDecisions) > 0 {
+			b.WriteString("\nZone overrides (`zone_decisions`) for zone-derived tiers:\n\n")
+			names := make([]string, 0, len(t.ZoneDecisions))
+			for name := range t.ZoneDecisions {
+				names = append(names, name)
+			}
+			sort.Strings(names)
+			for _, name := range names {
+				fmt.Fprintf(b, "- %s: %s\n", name, t.ZoneDecisions[name])
+			}
+		}
+	},
+}
+
+// FormatJSON renders the tree as indented JSON.
+func FormatJSON(t *Tree) (string, error) {
+	data, err := json.MarshalIndent(t, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshal decision tree: %w", err)
+	}
+	return string(data), nil
+}
+
+func writeList(b *strings.Builder, title string, items []string) {
+	if len(items) == 0 {
+		fmt.Fprintf(b, "- %s: none\n", title)
+		return
+	}
+	fmt.Fprintf(b, "- %s:\n", title)
+	for _, item := range items {
+		fmt.Fprintf(b, "  - `%s`\n", item)
+	}
+}
+
+// codeList renders items as inline code, safe for a table cell.
+func codeList(items []string) string {
+	quoted := make([]string, len(items))
+	for i, item := range items {
+		quoted[i] = "`" + cell(item) + "`"
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// cell escapes pipes so a value does not split a markdown table cell.
+func cell(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
+
+// formatVolume renders a rule's min_bytes/min_rows thresholds.
+func formatVolume(r RuleNode) string {
+	var parts []string
+	if r.MinBytes > 0 {
+		parts = append(parts, fmt.Sprintf("≥%d bytes", r.MinBytes))
+	}
+	if r.MinRows > 0 {
+		parts = append(parts, fmt.Sprintf("≥%d rows", r.MinRows))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// upperFirst capitalizes the first letter of s.
+func upperFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+func formatLabels(labels map[string]string) string {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, len(keys))
+	for i, k := range keys {
+		parts[i] = k + "=" + labels[k]
+	}
+	return strings.Join(parts, ", ")
+}
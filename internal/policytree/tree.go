@@ -0,0 +1,327 @@
+// Package policytree renders the effective policy decision logic — every
+// condition under which an action is denied or held for approval — from a
+// loaded config, for compliance review.
+package policytree
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/ppiankov/chainwatch/internal/denylist"
+	"github.com/ppiankov/chainwatch/internal/model"
+	"github.com/ppiankov/chainwatch/internal/policy"
+	"github.com/ppiankov/chainwatch/internal/zone"
+)
+
+// Tree is the effective decision logic in evaluation order.
+type Tree struct {
+	PolicyPath      string   `json:"policy_path,omitempty"`
+	PolicyHash      string   `json:"policy_hash,omitempty"`
+	Profiles        []string `json:"profiles,omitempty"`
+	EnforcementMode string   `json:"enforcement_mode"`
+
+	// Steps are Evaluate's stages in order (policy.EvaluationSteps); the
+	// fields below hold the configuration each stage reads.
+	Steps []StepNode `json:"steps"`
+
+	AllowedPurposes    []string          `json:"allowed_purposes,omitempty"`
+	MaxActionsPerTrace int               `json:"max_actions_per_trace,omitempty"`
+	RateLimits         []RateLimitNode   `json:"rate_limits,omitempty"`
+	Denylist           DenylistNode      `json:"denylist"`
+	Canaries           int               `json:"canaries"`
+	Zones              []ZoneNode        `json:"zones"`
+	Escalations        []EscalateNode    `json:"escalations"`
+	SelfTarget         SelfTargetNode    `json:"self_target"`
+	ProtectedPaths     []string          `json:"protected_paths,omitempty"`
+	KnownSafeCommands  []string          `json:"known_safe_commands"`
+	MinTier            int               `json:"min_tier"`
+	Agents             []string          `json:"agents,omitempty"`
+	Budgets            []BudgetNode      `json:"budgets,omitempty"`
+	Rules              []RuleNode        `json:"rules"`
+	Tiers              []TierNode        `json:"tiers"`
+	Thresholds         ThresholdsNode    `json:"thresholds"`
+	Guidance           *GuidanceNode     `json:"denial_guidance,omitempty"`
+	ZoneDecisions      map[string]string `json:"zone_decisions,omitempty"`
+}
+
+// StepNode is one evaluation stage.
+type StepNode struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Summary string `json:"summary"`
+}
+
+// RateLimitNode is one agent's cap on a tool category.
+type RateLimitNode struct {
+	Agent       string `json:"agent"`
+	Category    string `json:"category"`
+	MaxRequests int    `json:"max_requests"`
+	Window      string `json:"window"`
+}
+
+// BudgetNode is one agent's session resource cap. Zero means no limit.
+type BudgetNode struct {
+	Agent       string `json:"agent"`
+	MaxBytes    int64  `json:"max_bytes,omitempty"`
+	MaxRows     int64  `json:"max_rows,omitempty"`
+	MaxDuration string `json:"max_duration,omitempty"`
+}
+
+// DenylistNode lists hard-block patterns. Any match is denied at tier 3,
+// except patterns listed in Warn, which only alert.
+type DenylistNode struct {
+	URLs     []string `json:"urls"`
+	Files    []string `json:"files"`
+	Commands []string `json:"commands"`
+	Warn     []string `json:"warn,omitempty"`
+	Decision string   `json:"decision"`
+	Tier     int      `json:"tier"`
+}
+
+// ZoneNode describes how an action enters a zone.
+type ZoneNode struct {
+	Zone      string   `json:"zone"`
+	Patterns  []string `json:"patterns,omitempty"`
+	Condition string   `json:"condition,omitempty"`
+}
+
+// EscalateNode is a zone combination that raises the trace's
+// irreversibility level, and the decision that level yields.
+type EscalateNode struct {
+	Zones    []string `json:"zones"`
+	Level    string   `json:"level"`
+	Tier     int      `json:"tier"`
+	Decision string   `json:"decision"`
+	PolicyID string   `json:"policy_id"`
+}
+
+// SelfTargetNode lists resource substrings that make an action target
+// chainwatch itself. Such actions are always tier 3.
+type SelfTargetNode struct {
+	Patterns []string `json:"patterns"`
+	Tier     int      `json:"tier"`
+	Decision string   `json:"decision"`
+}
+
+// RuleNode is one purpose-bound rule with its conditions and decision.
+type RuleNode struct {
+	Order           int               `json:"order"`
+	PolicyID        string            `json:"policy_id"`
+	Purpose         string            `json:"purpose"`
+	ResourcePattern string            `json:"resource_pattern"`
+	Labels          map[string]string `json:"labels,omitempty"`
+	Decision        string            `json:"decision"`
+	MinBytes        int64             `json:"min_bytes,omitempty"`
+	MinRows         int               `json:"min_rows,omitempty"`
+	ApprovalKey     string            `json:"approval_key,omitempty"`
+	Reason          string            `json:"reason,omitempty"`
+	Mode            string            `json:"mode"`
+	Continue        bool              `json:"continue,omitempty"`
+	RequireReason   bool              `json:"require_reason,omitempty"`
+}
+
+// TierNode is the mode's decision for a tier when no rule matched and no
+// zone_decisions override applies.
+type TierNode struct {
+	Tier     int    `json:"tier"`
+	Label    string `json:"label"`
+	Decision string `json:"decision"`
+	PolicyID string `json:"policy_id"`
+}
+
+// ThresholdsNode holds the legacy risk score boundaries.
+type ThresholdsNode struct {
+	AllowMax    int `json:"allow_max"`
+	ApprovalMin int `json:"approval_min"`
+}
+
+// GuidanceNode is the configured denial guidance.
+type GuidanceNode struct {
+	Template string            `json:"template,omitempty"`
+	Hints    map[string]string `json:"hints,omitempty"`
+}
+
+// zoneOrder lists zones in the order they are documented.
+var zoneOrder = []model.Zone{
+	model.ZoneCommercialIntent,
+	model.ZoneCommercialCommitment,
+	model.ZoneCredentialAdjacent,
+	model.ZoneCredentialExposed,
+	model.ZoneSensitiveData,
+	model.ZoneEgressCapable,
+	model.ZoneEgressActive,
+	model.ZoneHighVolume,
+}
+
+// zoneConditions describes zones entered by operation, volume, or content
+// rather than by resource pattern alone.
+var zoneConditions = map[model.Zone]string{
+	model.ZoneCredentialAdjacent: fmt.Sprintf("action data tagged %q", zone.TagSecret),
+	model.ZoneCredentialExposed:  "read of a credential-adjacent resource",
+	model.ZoneSensitiveData:      fmt.Sprintf("action data tagged %q", zone.TagPII),
+	model.ZoneEgressActive:       "POST/PUT/PATCH/DELETE to an http(s) URL",
+	model.ZoneHighVolume:         fmt.Sprintf("trace volume exceeds %d bytes", zone.HighVolumeThreshold),
+}
+
+// Build derives the decision tree from a loaded config and denylist. Apply
+// profiles to both before calling so the tree reflects what is enforced.
+func Build(cfg *policy.PolicyConfig, dl *denylist.Denylist) *Tree {
+	if cfg == nil {
+		cfg = policy.DefaultConfig()
+	}
+	mode := cfg.EnforcementMode
+	if mode == "" {
+		mode = "guarded"
+	}
+
+	t := &Tree{
+		EnforcementMode:    mode,
+		AllowedPurposes:    cfg.AllowedPurposes,
+		MaxActionsPerTrace: cfg.MaxActionsPerTrace,
+		Denylist:           DenylistNode{Decision: string(model.Deny), Tier: policy.TierCritical},
+		Canaries:           len(cfg.Canaries),
+		SelfTarget: SelfTargetNode{
+			Patterns: model.SelfTargetPatterns(),
+			Tier:     policy.TierCritical,
+		},
+		ProtectedPaths:    cfg.ProtectedPaths,
+		KnownSafeCommands: policy.KnownSafeCommands(),
+		MinTier:           cfg.MinTier,
+		Thresholds: ThresholdsNode{
+			AllowMax:    cfg.Thresholds.AllowMax,
+			ApprovalMin: cfg.Thresholds.ApprovalMin,
+		},
+		ZoneDecisions: cfg.ZoneDecisions,
+	}
+
+	for _, s := range policy.EvaluationSteps {
+		t.Steps = append(t.Steps, StepNode(s))
+	}
+
+	if cfg.ProtectDefaultPaths {
+		t.ProtectedPaths = append(append([]string(nil), t.ProtectedPaths...), policy.DefaultProtectedPaths...)
+	}
+
+	for _, agent := range sortedKeys(cfg.RateLimits) {
+		limits := cfg.RateLimits[agent]
+		for _, category := range sortedKeys(limits) {
+			if l := limits[category]; l != nil && l.MaxRequests > 0 && l.Window > 0 {
+				t.RateLimits = append(t.RateLimits, RateLimitNode{
+					Agent:       agent,
+					Category:    category,
+					MaxRequests: l.MaxRequests,
+					Window:      l.Window.String(),
+				})
+			}
+		}
+	}
+
+	for _, agent := range sortedKeys(cfg.Budgets) {
+		b := cfg.Budgets[agent]
+		if b == nil || !b.HasLimits() {
+			continue
+		}
+		node := BudgetNode{Agent: agent, MaxBytes: b.MaxBytes, MaxRows: b.MaxRows}
+		if b.MaxDuration > 0 {
+			node.MaxDuration = b.MaxDuration.String()
+		}
+		t.Budgets = append(t.Budgets, node)
+	}
+
+	if dl != nil {
+		raw := dl.ToMap()
+		t.Denylist.URLs, _ = raw["urls"].([]string)
+		t.Denylist.Files, _ = raw["files"].([]string)
+		t.Denylist.Commands, _ = raw["commands"].([]string)
+		t.Denylist.Warn = dl.WarnPatterns()
+	}
+
+	for _, z := range zoneOrder {
+		t.Zones = append(t.Zones, ZoneNode{
+			Zone:      string(z),
+			Patterns:  zone.Patterns(z),
+			Condition: zoneConditions[z],
+		})
+	}
+
+	for _, rule := range zone.IrreversibilityRules {
+		tier := policy.ClassifyTier(rule.Level)
+		decision, policyID := tierDecision(mode, tier, cfg)
+		zones := make([]string, len(rule.Required))
+		for i, z := range rule.Required {
+			zones[i] = string(z)
+		}
+		t.Escalations = append(t.Escalations, EscalateNode{
+			Zones:    zones,
+			Level:    rule.Level.String(),
+			Tier:     tier,
+			Decision: decision,
+			PolicyID: policyID,
+		})
+	}
+
+	selfDecision, _ := policy.EnforceByTier(mode, policy.TierCritical)
+	t.SelfTarget.Decision = string(selfDecision)
+
+	t.Agents = sortedKeys(cfg.Agents)
+
+	for i, rule := range cfg.Rules {
+		ruleMode := rule.Mode
+		if ruleMode == "" {
+			ruleMode = policy.RuleModeEnforce
+		}
+		t.Rules = append(t.Rules, RuleNode{
+			Order:           i + 1,
+			PolicyID:        policy.RulePolicyID(rule),
+			Purpose:         rule.Purpose,
+			ResourcePattern: rule.ResourcePattern,
+			Labels:          rule.Labels,
+			MinBytes:        rule.MinBytes,
+			MinRows:         rule.MinRows,
+			Decision:        rule.Decision,
+			ApprovalKey:     rule.ApprovalKey,
+			Reason:          rule.Reason,
+			Mode:            ruleMode,
+			Continue:        rule.Continue,
+			RequireReason:   rule.RequireReason,
+		})
+	}
+
+	for tier := policy.TierSafe; tier <= policy.TierCritical; tier++ {
+		decision, policyID := policy.EnforceByTier(mode, tier)
+		t.Tiers = append(t.Tiers, TierNode{
+			Tier:     tier,
+			Label:    policy.TierLabel(tier),
+			Decision: string(decision),
+			PolicyID: policyID,
+		})
+	}
+
+	if g := cfg.DenialGuidance; g != nil {
+		t.Guidance = &GuidanceNode{Template: g.Template, Hints: g.Hints}
+	}
+
+	return t
+}
+
+// sortedKeys returns the keys of m in order.
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// tierDecision returns the decision for a zone-derived tier: the
+// zone_decisions override for the matching zone, else the mode default.
+func tierDecision(mode string, tier int, cfg *policy.PolicyConfig) (string, string) {
+	name := strings.ToLower(model.BoundaryZone(tier).String())
+	if d, ok := cfg.ZoneDecisions[name]; ok {
+		return d, fmt.Sprintf("zone.%s.%s", name, d)
+	}
+	decision, policyID := policy.EnforceByTier(mode, tier)
+	return string(decision), policyID
+}
@@ -0,0 +1,131 @@
+package policytree
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ppiankov/chainwatch/internal/budget"
+	"github.com/ppiankov/chainwatch/internal/denylist"
+	"github.com/ppiankov/chainwatch/internal/policy"
+	"github.com/ppiankov/chainwatch/internal/ratelimit"
+)
+
+func TestBuildIncludesRulesAndZoneDenies(t *testing.T) {
+	tree := Build(policy.DefaultConfig(), denylist.NewDefault())
+
+	var salary *RuleNode
+	for i := range tree.Rules {
+		if tree.Rules[i].ResourcePattern == "*salary*" {
+			salary = &tree.Rules[i]
+		}
+	}
+	if salary == nil {
+		t.Fatalf("expected salary rule in tree, got %+v", tree.Rules)
+	}
+	if salary.Decision != "require_approval" || salary.ApprovalKey != "soc_salary_access" || salary.PolicyID != "purpose.SOC_efficiency.salary" {
+		t.Errorf("unexpected salary rule: %+v", salary)
+	}
+
+	var irreversible int
+	for _, e := range tree.Escalations {
+		if e.Level == "IRREVERSIBLE" {
+			irreversible++
+			if e.Decision != "deny" || e.Tier != policy.TierCritical {
+				t.Errorf("expected irreversible escalation to deny at tier 3, got %+v", e)
+			}
+		}
+	}
+	if irreversible == 0 {
+		t.Fatal("expected irreversible escalations in tree")
+	}
+	if len(tree.Denylist.Commands) == 0 || tree.Denylist.Decision != "deny" {
+		t.Errorf("expected denylist commands to deny, got %+v", tree.Denylist)
+	}
+}
+
+func TestBuildReflectsZoneDecisionsAndMode(t *testing.T) {
+	cfg := policy.DefaultConfig()
+	cfg.ZoneDecisions = map[string]string{"commitment": "deny"}
+	tree := Build(cfg, nil)
+
+	for _, e := range tree.Escalations {
+		if e.Level == "COMMITMENT" && (e.Decision != "deny" || e.PolicyID != "zone.commitment.deny") {
+			t.Errorf("expected zone override on commitment escalation, got %+v", e)
+		}
+	}
+
+	cfg = policy.DefaultConfig()
+	cfg.EnforcementMode = "advisory"
+	for _, tier := range Build(cfg, nil).Tiers {
+		if tier.Decision != "allow" {
+			t.Errorf("expected advisory mode to allow tier %d, got %s", tier.Tier, tier.Decision)
+		}
+	}
+}
+
+func TestTreeCoversEveryEvaluationStep(t *testing.T) {
+	cfg := policy.DefaultConfig()
+	cfg.AllowedPurposes = []string{"SOC_efficiency"}
+	cfg.RateLimits = map[string]ratelimit.RateLimitConfig{
+		"*": {"command": {MaxRequests: 10, Window: time.Minute}},
+	}
+	cfg.Budgets = map[string]*budget.BudgetConfig{"*": {MaxBytes: 1 << 20}}
+	cfg.ProtectedPaths = []string{"/srv/app/"}
+	cfg.Rules = append(cfg.Rules, policy.Rule{Purpose: "*", ResourcePattern: "*.csv", Decision: "require_approval", MinRows: 1000})
+	dl := denylist.New(denylist.Patterns{Commands: denylist.Entries("kubectl delete"), Warn: []string{"kubectl delete"}})
+
+	md := FormatMarkdown(Build(cfg, dl))
+	for _, step := range policy.EvaluationSteps {
+		if _, ok := stepSections[step.ID]; !ok {
+			t.Errorf("no section renders step %s (%s)", step.ID, step.Name)
+		}
+		if heading := "## " + step.ID + ". " + step.Name; !strings.Contains(md, heading) {
+			t.Errorf("markdown missing %q", heading)
+		}
+	}
+	for _, want := range []string{
+		"unless the purpose is one of: `SOC_efficiency`",
+		"| * | command | 10 | 1m0s |",
+		"| * | 1048576 | 0 |  |",
+		"`/srv/app/`",
+		"- Warn:\n  - `kubectl delete`",
+		"≥1000 rows",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown missing %q:\n%s", want, md)
+		}
+	}
+}
+
+func TestFormatMarkdownAndJSON(t *testing.T) {
+	cfg := policy.DefaultConfig()
+	cfg.Rules = append(cfg.Rules, policy.Rule{Purpose: "*", ResourcePattern: "a|b", Decision: "deny", Continue: true})
+	tree := Build(cfg, denylist.NewDefault())
+
+	md := FormatMarkdown(tree)
+	for _, want := range []string{
+		"## 1. Denylist",
+		"| 1 | SOC_efficiency | `*salary*` |  |  | require_approval | soc_salary_access | enforce |",
+		"`a\\|b`",
+		"enforce, continue",
+		"| credential_exposed + egress_active | IRREVERSIBLE | 3 | deny |",
+	} {
+		if !strings.Contains(md, want) {
+			t.Errorf("markdown missing %q:\n%s", want, md)
+		}
+	}
+
+	out, err := FormatJSON(tree)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded Tree
+	if err := json.Unmarshal([]byte(out), &decoded); err != nil {
+		t.Fatalf("invalid JSON: %v", err)
+	}
+	if len(decoded.Rules) != 2 || decoded.Rules[0].ResourcePattern != "*salary*" {
+		t.Errorf("unexpected rules in JSON: %+v", decoded.Rules)
+	}
+}
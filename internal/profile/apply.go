@@ -1,6 +1,7 @@
 package profile
 
 import (
+	"fmt"
 	"regexp"
 	"strings"
 
@@ -9,28 +10,52 @@ import (
 )
 
 // ApplyToDenylist merges profile execution_boundaries into the denylist.
-// Uses existing denylist.AddPattern() — additive, no removal.
+// Additive, no removal. Each pattern is tagged with its source
+// ("<profile>:<category>", e.g. "clawbot:urls") so blocks report which
+// profile boundary fired.
 func ApplyToDenylist(p *Profile, dl *denylist.Denylist) {
 	for _, u := range p.ExecutionBoundaries.URLs {
-		dl.AddPattern("urls", u)
+		dl.AddPatternFrom("urls", u, p.sourceName()+":urls")
 	}
 	for _, f := range p.ExecutionBoundaries.Files {
-		dl.AddPattern("files", f)
+		dl.AddPatternFrom("files", f, p.sourceName()+":files")
 	}
 	for _, c := range p.ExecutionBoundaries.Commands {
-		dl.AddPattern("commands", c)
+		dl.AddPatternFrom("commands", c, p.sourceName()+":commands")
 	}
 }
 
-// ApplyToPolicy merges profile policy rules and MinTier into config.
-// Profile rules are prepended (higher priority in first-match-wins order).
-// MinTier can only promote (never demote).
-// Returns a new config — does not mutate the input.
+// modeStrictness ranks enforcement modes; an empty policy mode means guarded.
+var modeStrictness = map[string]int{
+	"advisory": 0,
+	"guarded":  1,
+	"locked":   2,
+}
+
+// stricterMode reports whether profile mode m tightens the current mode.
+func stricterMode(m, current string) bool {
+	if m == "" {
+		return false
+	}
+	if current == "" {
+		current = "guarded"
+	}
+	return modeStrictness[m] > modeStrictness[current]
+}
+
+// ApplyToPolicy merges profile policy rules, MinTier, enforcement mode, and
+// allowed purposes into config. Profile rules are prepended (higher
+// priority in first-match-wins order). MinTier, enforcement mode, and
+// allowed purposes can only tighten (never loosen): a purpose allowlist
+// already in config is intersected with the profile's. Returns a new
+// config — does not mutate the input.
 func ApplyToPolicy(p *Profile, cfg *policy.PolicyConfig) *policy.PolicyConfig {
 	hasMinTier := p.MinTier > cfg.MinTier
+	hasMode := stricterMode(p.EnforcementMode, cfg.EnforcementMode)
 	hasRules := p.Policy != nil && len(p.Policy.Rules) > 0
+	hasPurposes := len(p.AllowedPurposes) > 0
 
-	if !hasMinTier && !hasRules {
+	if !hasMinTier && !hasMode && !hasRules && !hasPurposes {
 		return cfg
 	}
 
@@ -39,6 +64,9 @@ func ApplyToPolicy(p *Profile, cfg *policy.PolicyConfig) *policy.PolicyConfig {
 	if hasMinTier {
 		merged.MinTier = p.MinTier
 	}
+	if hasMode {
+		merged.EnforcementMode = p.EnforcementMode
+	}
 
 	if hasRules {
 		merged.Rules = make([]policy.Rule, 0, len(p.Policy.Rules)+len(cfg.Rules))
@@ -46,9 +74,64 @@ func ApplyToPolicy(p *Profile, cfg *policy.PolicyConfig) *policy.PolicyConfig {
 		merged.Rules = append(merged.Rules, cfg.Rules...)
 	}
 
+	if hasPurposes {
+		merged.AllowedPurposes = intersectPurposes(cfg.AllowedPurposes, p.AllowedPurposes)
+	}
+
 	return &merged
 }
 
+// intersectPurposes narrows an existing purpose allowlist by a profile's.
+// An empty current list allows everything, so the profile's list is used
+// as is. The result is never empty: disjoint lists leave an allowlist no
+// purpose matches.
+func intersectPurposes(current, profile []string) []string {
+	if len(current) == 0 {
+		return append([]string(nil), profile...)
+	}
+	var out []string
+	for _, p := range profile {
+		for _, c := range current {
+			if strings.EqualFold(p, c) {
+				out = append(out, p)
+				break
+			}
+		}
+	}
+	if len(out) == 0 {
+		out = []string{policy.NoPurposeAllowed}
+	}
+	return out
+}
+
+// SplitNames parses a profile list such as "clawbot,vm-cloud" into names,
+// trimming whitespace and dropping empty entries.
+func SplitNames(spec string) []string {
+	var names []string
+	for _, n := range strings.Split(spec, ",") {
+		if n = strings.TrimSpace(n); n != "" {
+			names = append(names, n)
+		}
+	}
+	return names
+}
+
+// ApplyStack loads the named profiles and applies them in order, so
+// execution boundaries accumulate, the highest MinTier and strictest
+// enforcement mode win, and rules from later profiles take precedence.
+// Returns a new config — does not mutate the input config.
+func ApplyStack(names []string, dl *denylist.Denylist, cfg *policy.PolicyConfig) (*policy.PolicyConfig, error) {
+	for _, name := range names {
+		p, err := Load(name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load profile %q: %w", name, err)
+		}
+		ApplyToDenylist(p, dl)
+		cfg = ApplyToPolicy(p, cfg)
+	}
+	return cfg, nil
+}
+
 // MatchesAuthority checks instruction text against authority boundary patterns.
 // Returns (matched, reason). Fail-closed: invalid regex is treated as a match.
 func MatchesAuthority(p *Profile, instruction string) (bool, string) {
@@ -65,3 +148,30 @@ func MatchesAuthority(p *Profile, instruction string) (bool, string) {
 	}
 	return false, ""
 }
+
+// LoadPolicy reads the denylist and policy files and applies the profile
+// stack named by names (comma-separated) on top of them. With
+// requirePolicy, a missing or empty policy file is an error instead of
+// the default policy.
+func LoadPolicy(denylistPath, policyPath, names string, requirePolicy bool) (*denylist.Denylist, *policy.PolicyConfig, string, error) {
+	dl, err := denylist.Load(denylistPath)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("failed to load denylist: %w", err)
+	}
+
+	if requirePolicy {
+		if err := policy.RequireConfigFile(policyPath); err != nil {
+			return nil, nil, "", err
+		}
+	}
+	policyCfg, policyHash, err := policy.LoadConfigWithHash(policyPath)
+	if err != nil {
+		return nil, nil, "", fmt.Errorf("failed to load policy config: %w", err)
+	}
+
+	policyCfg, err = ApplyStack(SplitNames(names), dl, policyCfg)
+	if err != nil {
+		return nil, nil, "", err
+	}
+	return dl, policyCfg, policyHash, nil
+}
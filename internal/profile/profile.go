@@ -6,9 +6,11 @@ import (
 	"path/filepath"
 	"regexp"
 	"sort"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 
+	"github.com/ppiankov/chainwatch/internal/denylist"
 	"github.com/ppiankov/chainwatch/internal/policy"
 )
 
@@ -35,9 +37,22 @@ type Profile struct {
 	Name                string              `yaml:"name"`
 	Description         string              `yaml:"description"`
 	MinTier             int                 `yaml:"min_tier"`
+	EnforcementMode     string              `yaml:"enforcement_mode,omitempty"` // can only tighten: advisory < guarded < locked
+	AllowedPurposes     []string            `yaml:"allowed_purposes,omitempty"` // when set, other purposes are denied
 	AuthorityBoundaries []AuthorityPattern  `yaml:"authority_boundaries"`
 	ExecutionBoundaries ExecutionBoundaries `yaml:"execution_boundaries"`
 	Policy              *PolicyOverrides    `yaml:"policy,omitempty"`
+
+	// ID is the name the profile was loaded by (e.g. "clawbot"); set by Load.
+	ID string `yaml:"-"`
+}
+
+// sourceName identifies the profile in denylist provenance tags.
+func (p *Profile) sourceName() string {
+	if p.ID != "" {
+		return p.ID
+	}
+	return p.Name
 }
 
 // Load loads a profile by name. Checks built-in profiles first,
@@ -49,6 +64,7 @@ func Load(name string) (*Profile, error) {
 		if err := yaml.Unmarshal(data, &p); err != nil {
 			return nil, fmt.Errorf("failed to parse built-in profile %q: %w", name, err)
 		}
+		p.ID = name
 		return &p, nil
 	}
 
@@ -68,6 +84,10 @@ func Load(name string) (*Profile, error) {
 	if err := yaml.Unmarshal(data, &p); err != nil {
 		return nil, fmt.Errorf("failed to parse profile %q: %w", name, err)
 	}
+	if err := validateFileGlobs(&p); err != nil {
+		return nil, fmt.Errorf("profile %q: %w", name, err)
+	}
+	p.ID = name
 
 	return &p, nil
 }
@@ -111,6 +131,22 @@ func Validate(p *Profile) error {
 		return fmt.Errorf("profile name is required")
 	}
 
+	if p.EnforcementMode != "" {
+		if _, ok := modeStrictness[p.EnforcementMode]; !ok {
+			return fmt.Errorf("enforcement_mode %q: must be advisory, guarded, or locked", p.EnforcementMode)
+		}
+	}
+
+	for i, purpose := range p.AllowedPurposes {
+		if strings.TrimSpace(purpose) == "" || purpose == "*" {
+			return fmt.Errorf("allowed_purposes[%d]: must name a purpose, got %q", i, purpose)
+		}
+	}
+
+	if err := validateFileGlobs(p); err != nil {
+		return err
+	}
+
 	for i, ap := range p.AuthorityBoundaries {
 		if _, err := regexp.Compile("(?i)" + ap.Pattern); err != nil {
 			return fmt.Errorf("authority_boundaries[%d]: invalid regex %q: %w", i, ap.Pattern, err)
@@ -119,3 +155,14 @@ func Validate(p *Profile) error {
 
 	return nil
 }
+
+// validateFileGlobs rejects execution_boundaries file globs the denylist
+// cannot compile, so they fail at load instead of matching by containment.
+func validateFileGlobs(p *Profile) error {
+	for i, f := range p.ExecutionBoundaries.Files {
+		if err := denylist.ValidateFilePattern(f); err != nil {
+			return fmt.Errorf("execution_boundaries.files[%d]: %w", i, err)
+		}
+	}
+	return nil
+}
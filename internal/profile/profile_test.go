@@ -1,6 +1,7 @@
 package profile
 
 import (
+	"strings"
 	"testing"
 
 	"gopkg.in/yaml.v3"
@@ -287,6 +288,14 @@ func TestValidateProfileBadRegex(t *testing.T) {
 	}
 }
 
+func TestValidateProfileBadFileGlob(t *testing.T) {
+	p := &Profile{Name: "test"}
+	p.ExecutionBoundaries.Files = []string{"**/[z-a].key"}
+	if err := Validate(p); err == nil {
+		t.Error("expected error for invalid file glob")
+	}
+}
+
 func TestAllBuiltinProfilesLoad(t *testing.T) {
 	names := []string{
 		"clawbot",
@@ -758,3 +767,140 @@ func TestTerraformPlannerDeniesApplyInPolicy(t *testing.T) {
 		}
 	}
 }
+
+func TestApplyStackUnionsBoundariesAndTakesStrictest(t *testing.T) {
+	dl := denylist.NewDefault()
+	cfg := policy.DefaultConfig()
+
+	merged, err := ApplyStack(SplitNames("clawbot, terraform-planner"), dl, cfg)
+	if err != nil {
+		t.Fatalf("ApplyStack: %v", err)
+	}
+
+	// clawbot boundary
+	if blocked, _ := dl.IsBlocked("https://paypal.com/pay", "browser"); !blocked {
+		t.Error("expected clawbot URL boundary to apply")
+	}
+	// terraform-planner boundary
+	if blocked, _ := dl.IsBlocked("terraform import aws_s3_bucket.b b", "command"); !blocked {
+		t.Error("expected terraform-planner command boundary to apply")
+	}
+	if merged.MinTier != 2 {
+		t.Errorf("expected MinTier 2 from terraform-planner, got %d", merged.MinTier)
+	}
+	if cfg.MinTier != 0 {
+		t.Error("original config was mutated")
+	}
+}
+
+func TestApplyStackOrderDoesNotLowerMinTier(t *testing.T) {
+	merged, err := ApplyStack([]string{"vm-cloud", "clawbot"}, denylist.NewDefault(), policy.DefaultConfig())
+	if err != nil {
+		t.Fatalf("ApplyStack: %v", err)
+	}
+	if merged.MinTier != 2 {
+		t.Errorf("expected MinTier 2 to survive a later looser profile, got %d", merged.MinTier)
+	}
+}
+
+func TestApplyStackUnknownProfile(t *testing.T) {
+	if _, err := ApplyStack([]string{"clawbot", "no-such-profile"}, denylist.NewDefault(), policy.DefaultConfig()); err == nil {
+		t.Fatal("expected error for unknown profile in stack")
+	}
+}
+
+func TestApplyToPolicyEnforcementModeOnlyTightens(t *testing.T) {
+	cfg := policy.DefaultConfig()
+	cfg.EnforcementMode = "guarded"
+
+	if merged := ApplyToPolicy(&Profile{EnforcementMode: "advisory"}, cfg); merged.EnforcementMode != "guarded" {
+		t.Errorf("advisory profile loosened mode to %q", merged.EnforcementMode)
+	}
+	merged := ApplyToPolicy(&Profile{EnforcementMode: "locked"}, cfg)
+	if merged.EnforcementMode != "locked" {
+		t.Errorf("expected locked, got %q", merged.EnforcementMode)
+	}
+	if cfg.EnforcementMode != "guarded" {
+		t.Error("original config was mutated")
+	}
+}
+
+func TestSplitNames(t *testing.T) {
+	got := SplitNames(" clawbot ,, vm-cloud,")
+	if len(got) != 2 || got[0] != "clawbot" || got[1] != "vm-cloud" {
+		t.Errorf("SplitNames = %q", got)
+	}
+	if SplitNames("") != nil {
+		t.Error("expected nil for empty spec")
+	}
+}
+
+func TestApplyToDenylistReportsSource(t *testing.T) {
+	p, err := Load("clawbot")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dl := denylist.New(denylist.Patterns{})
+	ApplyToDenylist(p, dl)
+
+	action := &model.Action{Tool: "browser", Resource: "https://shop.example.com/checkout/cart", Operation: "navigate"}
+	result := policy.Evaluate(action, model.NewTraceState("t"), "general", "", dl, policy.DefaultConfig())
+	if result.Decision != model.Deny {
+		t.Fatalf("expected deny, got %s", result.Decision)
+	}
+	if result.PolicyID != "denylist.block.clawbot:urls" {
+		t.Errorf("expected policy_id denylist.block.clawbot:urls, got %s", result.PolicyID)
+	}
+	if !strings.Contains(result.Reason, "(source: clawbot:urls)") {
+		t.Errorf("expected source in reason, got %q", result.Reason)
+	}
+
+	// Patterns from the denylist itself carry no source
+	dl = denylist.NewDefault()
+	if sev, _, source := dl.Match("rm -rf /", "command"); sev != denylist.SeverityBlock || source != "" {
+		t.Errorf("expected sourceless block, got %v %q", sev, source)
+	}
+}
+
+func TestApplyToPolicyAllowedPurposes(t *testing.T) {
+	p := &Profile{Name: "soc", AllowedPurposes: []string{"SOC_efficiency"}}
+	merged := ApplyToPolicy(p, policy.DefaultConfig())
+	action := &model.Action{Tool: "file_read", Resource: "/data/report.txt", Operation: "read"}
+
+	res := policy.Evaluate(action, model.NewTraceState("t1"), "general", "", nil, merged)
+	if res.Decision != model.Deny || res.PolicyID != "purpose.not_allowed" {
+		t.Fatalf("expected purpose.not_allowed deny, got %s (%s)", res.Decision, res.PolicyID)
+	}
+	if !strings.Contains(res.Reason, "purpose_not_allowed") {
+		t.Errorf("expected purpose_not_allowed reason, got %q", res.Reason)
+	}
+
+	res = policy.Evaluate(action, model.NewTraceState("t2"), "soc_efficiency", "", nil, merged)
+	if res.PolicyID == "purpose.not_allowed" {
+		t.Errorf("listed purpose denied: %s", res.Reason)
+	}
+}
+
+func TestApplyToPolicyAllowedPurposesIntersect(t *testing.T) {
+	cfg := ApplyToPolicy(&Profile{AllowedPurposes: []string{"a", "b"}}, policy.DefaultConfig())
+	merged := ApplyToPolicy(&Profile{AllowedPurposes: []string{"B", "c"}}, cfg)
+	if len(merged.AllowedPurposes) != 1 || !strings.EqualFold(merged.AllowedPurposes[0], "b") {
+		t.Errorf("expected [b], got %q", merged.AllowedPurposes)
+	}
+
+	disjoint := ApplyToPolicy(&Profile{AllowedPurposes: []string{"c"}}, cfg)
+	action := &model.Action{Tool: "file_read", Resource: "/data/report.txt", Operation: "read"}
+	for _, purpose := range []string{"a", "c", policy.NoPurposeAllowed} {
+		res := policy.Evaluate(action, model.NewTraceState("t"), purpose, "", nil, disjoint)
+		if res.PolicyID != "purpose.not_allowed" {
+			t.Errorf("purpose %q: expected deny on disjoint lists, got %s (%s)", purpose, res.Decision, res.PolicyID)
+		}
+	}
+}
+
+func TestValidateRejectsWildcardPurpose(t *testing.T) {
+	p := &Profile{Name: "bad", AllowedPurposes: []string{"*"}}
+	if err := Validate(p); err == nil || !strings.Contains(err.Error(), "allowed_purposes") {
+		t.Errorf("expected allowed_purposes error, got %v", err)
+	}
+}
@@ -114,7 +114,7 @@ func GenerateSeccompJSON(p *Profile) ([]byte, error) {
 // deny rules. It always includes a conservative baseline deny set.
 func BuildSeccompProfile(p *Profile) (*SeccompProfile, error) {
 	patterns := make([]string, 0, len(denylist.DefaultPatterns.Commands))
-	patterns = append(patterns, denylist.DefaultPatterns.Commands...)
+	patterns = append(patterns, denylist.PatternsOf(denylist.DefaultPatterns.Commands)...)
 	if p != nil {
 		patterns = append(patterns, p.ExecutionBoundaries.Commands...)
 	}
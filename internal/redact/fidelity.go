@@ -0,0 +1,113 @@
+package redact
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// tokenRe matches token-shaped strings such as "<<PATH_1>>", "<<LITERAL_3>>",
+// or the hash form "<<PATH_h3fa9c2d1e0b47a51>>".
+var tokenRe = regexp.MustCompile(`<<[A-Z][A-Z0-9_]*_(?:\d+|h[0-9a-f]+)>>`)
+
+// FidelityOptions tunes VerifyFidelity.
+type FidelityOptions struct {
+	// Required lists tokens the response must use. Missing ones are
+	// reported and fail the check.
+	Required []string
+	// DenyLiterals are extra strings that must never appear, in addition
+	// to every sensitive value held by the token map.
+	DenyLiterals []string
+}
+
+// FidelityReport is the outcome of checking an LLM response against the
+// token map used to redact its prompt.
+type FidelityReport struct {
+	Preserved []string `json:"preserved"`         // known tokens present in the response
+	Missing   []string `json:"missing,omitempty"` // required tokens absent from the response
+	Unknown   []string `json:"unknown,omitempty"` // token-shaped strings with no mapping (invented by the LLM)
+	Leaks     []string `json:"leaks,omitempty"`   // literal sensitive values present in the response
+	Required  int      `json:"required"`
+}
+
+// Safe reports whether the response may be detokenized and acted on:
+// no literal leaks, no unmapped tokens, and every required token present.
+func (r FidelityReport) Safe() bool {
+	return len(r.Leaks) == 0 && len(r.Unknown) == 0 && len(r.Missing) == 0
+}
+
+// Fidelity returns the fraction of required tokens the response preserved.
+// With no required tokens it is 1.
+func (r FidelityReport) Fidelity() float64 {
+	if r.Required == 0 {
+		return 1
+	}
+	return float64(r.Required-len(r.Missing)) / float64(r.Required)
+}
+
+// Err returns nil when the report is Safe, otherwise an error describing
+// every failure. Leaked values are counted, never echoed.
+func (r FidelityReport) Err() error {
+	if r.Safe() {
+		return nil
+	}
+	var problems []string
+	if len(r.Leaks) > 0 {
+		problems = append(problems, fmt.Sprintf("%d literal sensitive value(s) leaked", len(r.Leaks)))
+	}
+	if len(r.Unknown) > 0 {
+		problems = append(problems, fmt.Sprintf("unknown tokens %s", strings.Join(r.Unknown, ", ")))
+	}
+	if len(r.Missing) > 0 {
+		problems = append(problems, fmt.Sprintf("missing required tokens %s", strings.Join(r.Missing, ", ")))
+	}
+	return fmt.Errorf("redaction fidelity check failed: %s", strings.Join(problems, "; "))
+}
+
+// VerifyFidelity checks an LLM response produced from a redacted prompt.
+// It reports which tokens survived, which required tokens are missing,
+// which tokens were invented, and which sensitive values appear literally.
+// Callers should fail closed on !Safe() before detokenizing or executing
+// anything the response proposes. tm may be nil when only DenyLiterals apply.
+func VerifyFidelity(response string, tm *TokenMap, opts FidelityOptions) FidelityReport {
+	report := FidelityReport{Required: len(opts.Required)}
+
+	seen := make(map[string]bool)
+	for _, tok := range tokenRe.FindAllString(response, -1) {
+		if seen[tok] {
+			continue
+		}
+		seen[tok] = true
+		if tm != nil {
+			if _, ok := tm.Resolve(tok); ok {
+				report.Preserved = append(report.Preserved, tok)
+				continue
+			}
+		}
+		report.Unknown = append(report.Unknown, tok)
+	}
+	sort.Strings(report.Preserved)
+	sort.Strings(report.Unknown)
+
+	for _, tok := range opts.Required {
+		if !seen[tok] {
+			report.Missing = append(report.Missing, tok)
+		}
+	}
+
+	var deny []string
+	if tm != nil {
+		deny = tm.Values()
+	}
+	deny = append(deny, opts.DenyLiterals...)
+	leaked := make(map[string]bool)
+	for _, val := range deny {
+		if val != "" && !leaked[val] && strings.Contains(response, val) {
+			leaked[val] = true
+			report.Leaks = append(report.Leaks, val)
+		}
+	}
+
+	return report
+}
@@ -0,0 +1,98 @@
+package redact
+
+import (
+	"strings"
+	"testing"
+)
+
+func fidelityMap(t *testing.T) (*TokenMap, string, string) {
+	t.Helper()
+	tm := NewTokenMap("fidelity-test")
+	path := tm.Token(PatternPath, "/var/www/site/wp-config.php")
+	ip := tm.Token(PatternIP, "10.0.0.5")
+	return tm, path, ip
+}
+
+func TestVerifyFidelityCleanResponse(t *testing.T) {
+	tm, path, ip := fidelityMap(t)
+	resp := `{"steps":[{"cmd":"sha256sum ` + path + `"},{"cmd":"ping -c1 ` + ip + `"}]}`
+
+	r := VerifyFidelity(resp, tm, FidelityOptions{Required: []string{path, ip}})
+	if !r.Safe() {
+		t.Fatalf("expected safe report, got %+v (%v)", r, r.Err())
+	}
+	if len(r.Preserved) != 2 || r.Fidelity() != 1 {
+		t.Errorf("expected both tokens preserved, got %+v", r)
+	}
+}
+
+func TestVerifyFidelityDetectsLiteralLeak(t *testing.T) {
+	tm, path, _ := fidelityMap(t)
+	resp := "cat /var/www/site/wp-config.php && ls " + path
+
+	r := VerifyFidelity(resp, tm, FidelityOptions{})
+	if r.Safe() {
+		t.Fatal("literal sensitive value must fail the check")
+	}
+	if len(r.Leaks) != 1 || r.Leaks[0] != "/var/www/site/wp-config.php" {
+		t.Errorf("leaks = %v", r.Leaks)
+	}
+	if err := r.Err(); err == nil || strings.Contains(err.Error(), "wp-config") {
+		t.Errorf("error must describe the leak without echoing it, got %v", err)
+	}
+}
+
+func TestVerifyFidelityDetectsUnknownToken(t *testing.T) {
+	tm, path, _ := fidelityMap(t)
+	resp := "cp " + path + " <<PATH_9>>"
+
+	r := VerifyFidelity(resp, tm, FidelityOptions{})
+	if r.Safe() {
+		t.Fatal("invented token must fail the check")
+	}
+	if len(r.Unknown) != 1 || r.Unknown[0] != "<<PATH_9>>" {
+		t.Errorf("unknown = %v", r.Unknown)
+	}
+	if len(r.Preserved) != 1 || r.Preserved[0] != path {
+		t.Errorf("preserved = %v", r.Preserved)
+	}
+}
+
+func TestVerifyFidelityMissingRequiredToken(t *testing.T) {
+	tm, path, ip := fidelityMap(t)
+
+	r := VerifyFidelity("ls "+path, tm, FidelityOptions{Required: []string{path, ip}})
+	if r.Safe() {
+		t.Fatal("missing required token must fail the check")
+	}
+	if len(r.Missing) != 1 || r.Missing[0] != ip {
+		t.Errorf("missing = %v", r.Missing)
+	}
+	if got := r.Fidelity(); got != 0.5 {
+		t.Errorf("fidelity = %v, want 0.5", got)
+	}
+}
+
+func TestVerifyFidelityDenyLiteralsWithoutMap(t *testing.T) {
+	r := VerifyFidelity("rm -rf /srv/prod/data", nil, FidelityOptions{DenyLiterals: []string{"/srv/prod"}})
+	if len(r.Leaks) != 1 || r.Safe() {
+		t.Fatalf("expected deny literal leak, got %+v", r)
+	}
+}
+
+func TestVerifyFidelityDuplicateTokensCountedOnce(t *testing.T) {
+	tm, path, _ := fidelityMap(t)
+	r := VerifyFidelity(path+" "+path+" <<IP_7>> <<IP_7>>", tm, FidelityOptions{})
+	if len(r.Preserved) != 1 || len(r.Unknown) != 1 {
+		t.Errorf("expected deduplicated tokens, got %+v", r)
+	}
+}
+
+func TestCheckLeaksMatchesVerifyFidelity(t *testing.T) {
+	tm, _, _ := fidelityMap(t)
+	resp := "ping 10.0.0.5"
+	leaks := CheckLeaks(resp, tm)
+	if len(leaks) != 1 || leaks[0] != "10.0.0.5" {
+		t.Errorf("CheckLeaks = %v", leaks)
+	}
+}
@@ -0,0 +1,292 @@
+package redact
+
+import (
+	"encoding/base64"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// secretPattern pairs a credential regex with the category reported in
+// redaction placeholders.
+type secretPattern struct {
+	category string
+	re       *regexp.Regexp
+}
+
+// secretPatterns match known API key and token formats in command output.
+// These detect actual credential values, not variable names.
+var secretPatterns = []secretPattern{
+	// Groq keys: gsk_...
+	{"groq_key", regexp.MustCompile(`gsk_[a-zA-Z0-9]{20,}`)},
+	// OpenAI keys: sk-...
+	{"openai_key", regexp.MustCompile(`sk-[a-zA-Z0-9]{20,}`)},
+	// Anthropic keys: sk-ant-...
+	{"anthropic_key", regexp.MustCompile(`sk-ant-[a-zA-Z0-9\-]{20,}`)},
+	// Generic long hex tokens (64+ chars) that look like API keys
+	{"hex_token", regexp.MustCompile(`\b[a-f0-9]{64,}\b`)},
+	// AWS access key IDs: AKIA...
+	{"aws_key", regexp.MustCompile(`AKIA[0-9A-Z]{16}`)},
+	// Bearer tokens
+	{"bearer_token", regexp.MustCompile(`(?i)bearer\s+[a-zA-Z0-9\-_.]{20,}`)},
+	// GitHub tokens: ghp_ (PAT), gho_ (OAuth), ghs_ (server), ghr_ (refresh)
+	{"github_token", regexp.MustCompile(`(?:ghp|gho|ghs|ghr)_[a-zA-Z0-9]{36,}`)},
+	// Slack tokens: xoxb-, xoxp-, xoxa-, xoxr-, xoxs-
+	{"slack_token", regexp.MustCompile(`xox[bpars]-[a-zA-Z0-9\-]{10,}`)},
+	// Private key headers (PEM format)
+	{"private_key", regexp.MustCompile(`-----BEGIN (?:RSA |EC |OPENSSH |DSA )?PRIVATE KEY-----`)},
+	// TLS certificate and CSR headers (PEM format)
+	{"certificate", regexp.MustCompile(`-----BEGIN CERTIFICATE(?:\s+REQUEST)?-----`)},
+	// Connection strings with credentials
+	{"connection_string", regexp.MustCompile(`(?:postgres|postgresql|mysql|mongodb|redis|amqp)://[^\s:]+:[^\s@]+@[^\s]+`)},
+	// URLs with embedded user:password credentials
+	{"url_credentials", regexp.MustCompile(`(?:https?|ftp)://[^\s:/@]+:[^\s@/]+@[^\s]+`)},
+}
+
+// DefaultRedactPlaceholder replaces matched secrets in output. The
+// {category} token expands to the detected secret type, e.g. aws_key.
+const DefaultRedactPlaceholder = "[REDACTED:{category}]"
+
+// Redactor scans command output for secrets and replaces each match with
+// Placeholder. A "{category}" token in Placeholder expands to the secret
+// category; a placeholder without it is used verbatim. Empty Placeholder
+// means DefaultRedactPlaceholder.
+type Redactor struct {
+	Placeholder string
+}
+
+// placeholder returns the replacement text for a secret category.
+func (r Redactor) placeholder(category string) string {
+	p := r.Placeholder
+	if p == "" {
+		p = DefaultRedactPlaceholder
+	}
+	return strings.ReplaceAll(p, "{category}", category)
+}
+
+// ScanOutput checks command output for leaked secrets and returns a
+// redacted copy using the default placeholder. The second return value
+// is the number of secrets found.
+func ScanOutput(output string) (string, int) {
+	return Redactor{}.ScanOutput(output)
+}
+
+// ScanOutput checks command output for leaked secrets and returns a
+// redacted copy. The second return value is the number of secrets found.
+func (r Redactor) ScanOutput(output string) (string, int) {
+	count := 0
+	result := output
+	for _, sp := range secretPatterns {
+		matches := sp.re.FindAllString(result, -1)
+		if len(matches) > 0 {
+			count += len(matches)
+			result = sp.re.ReplaceAllLiteralString(result, r.placeholder(sp.category))
+		}
+	}
+	return result, count
+}
+
+// base64Pattern matches candidate base64-encoded strings.
+// Minimum 16 chars to avoid false positives on short strings.
+// Requires valid base64 charset and proper padding.
+var base64Pattern = regexp.MustCompile(`[A-Za-z0-9+/]{16,}={0,2}`)
+
+// minDecodedLen is the minimum decoded length to consider for secret scanning.
+// Secrets under 8 bytes are unlikely to be real credentials.
+const minDecodedLen = 8
+
+// ScanBase64 finds candidate base64 strings, decodes them, and checks
+// the decoded content against secret patterns. Returns the output with
+// any base64-encoded secrets redacted using the default placeholder and
+// the count of secrets found.
+func ScanBase64(output string) (string, int) {
+	return Redactor{}.ScanBase64(output)
+}
+
+// ScanBase64 is ScanBase64 with the redactor's placeholder. Encoded
+// secrets are reported under the category of the decoded match.
+func (r Redactor) ScanBase64(output string) (string, int) {
+	count := 0
+	result := base64Pattern.ReplaceAllStringFunc(output, func(match string) string {
+		category, ok := decodedSecret(match)
+		if !ok {
+			return match
+		}
+		count++
+		return r.placeholder(category)
+	})
+	return result, count
+}
+
+// decodedSecret decodes a base64 candidate and reports the category of the
+// first secret pattern its decoded text matches.
+func decodedSecret(match string) (string, bool) {
+	decoded, err := base64.StdEncoding.DecodeString(match)
+	if err != nil {
+		// Try RawStdEncoding (no padding).
+		decoded, err = base64.RawStdEncoding.DecodeString(match)
+		if err != nil {
+			return "", false
+		}
+	}
+
+	if len(decoded) < minDecodedLen {
+		return "", false
+	}
+
+	// Check if decoded content is mostly printable text.
+	// Binary data (images, compressed) should not be scanned.
+	if !isPrintable(decoded) {
+		return "", false
+	}
+
+	decodedStr := string(decoded)
+	for _, sp := range secretPatterns {
+		if sp.re.MatchString(decodedStr) {
+			return sp.category, true
+		}
+	}
+	return "", false
+}
+
+// isPrintable returns true if at least 80% of bytes are printable ASCII
+// or common whitespace. This filters out binary data that happens to be
+// valid base64.
+func isPrintable(data []byte) bool {
+	if len(data) == 0 {
+		return false
+	}
+	printable := 0
+	for _, b := range data {
+		if (b >= 0x20 && b <= 0x7E) || b == '\n' || b == '\r' || b == '\t' {
+			printable++
+		}
+	}
+	return float64(printable)/float64(len(data)) >= 0.8
+}
+
+// EnvKeyValuePattern matches KEY=VALUE lines where KEY is a known
+// sensitive env var name. This catches output from `set`, `export -p`,
+// `declare -p`, and similar shell builtins.
+var envKeyValuePattern = regexp.MustCompile(
+	`(?im)^(?:declare -x |export )?` +
+		`(NULLBOT_\w*|GROQ_\w*|OPENAI_\w*|ANTHROPIC_\w*|AWS_\w*|GITHUB_TOKEN\w*|GH_TOKEN\w*|SLACK_TOKEN\w*|SLACK_BOT\w*|DATABASE_URL\w*|REDIS_URL\w*|API_KEY|API_SECRET|CHAINWATCH_\w*)` +
+		`[= ].*$`,
+)
+
+// pemBlockPattern matches complete PEM-encoded blocks (certificates, private
+// keys, CSRs). This catches the entire block including the base64 body, not
+// just the header line. Prevents TLS certs and keys from leaking to the LLM.
+var pemBlockPattern = regexp.MustCompile(
+	`(?s)-----BEGIN [A-Z][A-Z0-9 ]*-----\n[A-Za-z0-9+/=\n]+-----END [A-Z][A-Z0-9 ]*-----`,
+)
+
+// ScanOutputFull runs PEM block, secret pattern, base64, and env key=value
+// scanning with the default placeholder.
+func ScanOutputFull(output string) (string, int) {
+	return Redactor{}.ScanOutputFull(output)
+}
+
+// ScanOutputFull runs PEM block, secret pattern, base64, and env key=value scanning.
+// PEM blocks are scanned first so full cert/key blocks are redacted before
+// line-level patterns consume only the header line.
+func (r Redactor) ScanOutputFull(output string) (string, int) {
+	count := 0
+
+	// Redact full PEM blocks (certs, keys, CSRs) before line-level scanning.
+	result := output
+	pemMatches := pemBlockPattern.FindAllString(result, -1)
+	if len(pemMatches) > 0 {
+		count += len(pemMatches)
+		result = pemBlockPattern.ReplaceAllLiteralString(result, r.placeholder("pem_block"))
+	}
+
+	// Line-level secret patterns.
+	res, n := r.ScanOutput(result)
+	result = res
+	count += n
+
+	// Scan for base64-encoded secrets.
+	res, n = r.ScanBase64(result)
+	result = res
+	count += n
+
+	// Also redact env var lines with sensitive names
+	envMatches := envKeyValuePattern.FindAllString(result, -1)
+	if len(envMatches) > 0 {
+		count += len(envMatches)
+		result = envKeyValuePattern.ReplaceAllLiteralString(result, r.placeholder("env_secret"))
+	}
+
+	// Collapse consecutive redacted lines of the same category
+	categories := []string{"pem_block", "env_secret"}
+	for _, sp := range secretPatterns {
+		categories = append(categories, sp.category)
+	}
+	for _, category := range categories {
+		p := r.placeholder(category)
+		for strings.Contains(result, p+"\n"+p) {
+			result = strings.ReplaceAll(result, p+"\n"+p, p)
+		}
+	}
+
+	return result, count
+}
+
+// secretSpan is a secret found by DetectSecrets, by byte offsets in the
+// scanned text.
+type secretSpan struct {
+	start, end int
+	category   string
+}
+
+// DetectSecrets reports the categories of secrets ScanOutputFull would
+// redact from text, sorted and without duplicates. It classifies content
+// that is sent rather than shown, such as HTTP request bodies.
+//
+// The passes run in ScanOutputFull's order on the unmodified text. Where
+// ScanOutputFull would already have replaced a span, a later match is
+// kept only if it encloses that span whole, as a line-level pattern still
+// matches around a placeholder. Base64 runs never span a placeholder, so
+// they must not touch an earlier match at all.
+func DetectSecrets(text string) []string {
+	var spans []secretSpan
+	add := func(category string, start, end int, mayEnclose bool) {
+		for _, s := range spans {
+			if end <= s.start || start >= s.end {
+				continue
+			}
+			if !mayEnclose || start > s.start || end < s.end {
+				return
+			}
+		}
+		spans = append(spans, secretSpan{start, end, category})
+	}
+	addAll := func(category string, re *regexp.Regexp) {
+		for _, loc := range re.FindAllStringIndex(text, -1) {
+			add(category, loc[0], loc[1], true)
+		}
+	}
+
+	addAll("pem_block", pemBlockPattern)
+	for _, sp := range secretPatterns {
+		addAll(sp.category, sp.re)
+	}
+	for _, loc := range base64Pattern.FindAllStringIndex(text, -1) {
+		if category, ok := decodedSecret(text[loc[0]:loc[1]]); ok {
+			add(category, loc[0], loc[1], false)
+		}
+	}
+	addAll("env_secret", envKeyValuePattern)
+
+	seen := make(map[string]bool)
+	var categories []string
+	for _, s := range spans {
+		if !seen[s.category] {
+			seen[s.category] = true
+			categories = append(categories, s.category)
+		}
+	}
+	sort.Strings(categories)
+	return categories
+}
@@ -1,21 +1,44 @@
 package redact
 
 import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"sort"
+	"strconv"
 	"strings"
 	"time"
 )
 
+// TokenMode selects how a TokenMap names the tokens it allocates.
+type TokenMode string
+
+const (
+	// TokenReversible numbers tokens per map ("<<PATH_1>>"). The same value
+	// gets different tokens in different maps; only the map can reverse them.
+	TokenReversible TokenMode = "reversible"
+	// TokenHash derives tokens from a keyed HMAC of the value
+	// ("<<PATH_h3fa9c2d1e0b47a51>>"). The same value and key always yield
+	// the same token, so audit records can be correlated and distinct
+	// secrets counted across runs without storing any map.
+	TokenHash TokenMode = "hash"
+)
+
+// hashTokenLen is the number of hex digits of the HMAC kept in a hash token.
+const hashTokenLen = 16
+
 // TokenMap provides bidirectional mapping between sensitive values and tokens.
 // It is safe for sequential use within a single job. Not goroutine-safe.
 type TokenMap struct {
 	forward   map[string]string   // sensitive value → "<<TYPE_N>>"
 	reverse   map[string]string   // "<<TYPE_N>>" → sensitive value
 	counters  map[PatternType]int // next number per pattern type
-	JobID     string              `json:"job_id"`
-	CreatedAt time.Time           `json:"created_at"`
+	mode      TokenMode
+	hashKey   []byte
+	JobID     string    `json:"job_id"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 // NewTokenMap creates an empty token map for a job.
@@ -24,24 +47,60 @@ func NewTokenMap(jobID string) *TokenMap {
 		forward:   make(map[string]string),
 		reverse:   make(map[string]string),
 		counters:  make(map[PatternType]int),
+		mode:      TokenReversible,
 		JobID:     jobID,
 		CreatedAt: time.Now().UTC(),
 	}
 }
 
+// NewHashTokenMap creates an empty token map that allocates TokenHash
+// tokens keyed by key. The map still records values, so Detoken and
+// CheckLeaks work within the job; the key itself is never serialized.
+func NewHashTokenMap(jobID string, key []byte) *TokenMap {
+	tm := NewTokenMap(jobID)
+	tm.mode = TokenHash
+	tm.hashKey = append([]byte(nil), key...)
+	return tm
+}
+
+// Mode returns how the map names its tokens.
+func (tm *TokenMap) Mode() TokenMode {
+	if tm.mode == "" {
+		return TokenReversible
+	}
+	return tm.mode
+}
+
 // Token returns the token for a sensitive value. Idempotent: the same value
 // always returns the same token within a map.
 func (tm *TokenMap) Token(typ PatternType, value string) string {
 	if tok, ok := tm.forward[value]; ok {
 		return tok
 	}
-	tm.counters[typ]++
-	tok := fmt.Sprintf("<<%s_%d>>", typ, tm.counters[typ])
+	var tok string
+	if tm.mode == TokenHash {
+		tok = hashToken(tm.hashKey, typ, value)
+	} else {
+		tm.counters[typ]++
+		tok = fmt.Sprintf("<<%s_%d>>", typ, tm.counters[typ])
+	}
 	tm.forward[value] = tok
 	tm.reverse[tok] = value
 	return tok
 }
 
+// hashToken returns the TokenHash token for a value. The pattern type is
+// part of the MAC input, so one value found as different types does not
+// collide.
+func hashToken(key []byte, typ PatternType, value string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(typ))
+	mac.Write([]byte{0})
+	mac.Write([]byte(value))
+	sum := hex.EncodeToString(mac.Sum(nil))
+	return fmt.Sprintf("<<%s_h%s>>", typ, sum[:hashTokenLen])
+}
+
 // Resolve returns the original value for a token.
 func (tm *TokenMap) Resolve(token string) (string, bool) {
 	v, ok := tm.reverse[token]
@@ -140,6 +199,7 @@ func (tm *TokenMap) UnmarshalJSON(data []byte) error {
 }
 
 // parseToken extracts the type and number from a token string like "<<PATH_1>>".
+// Hash tokens carry no number and are rejected.
 func parseToken(tok string) (PatternType, int, bool) {
 	s := strings.TrimPrefix(tok, "<<")
 	s = strings.TrimSuffix(s, ">>")
@@ -148,8 +208,8 @@ func parseToken(tok string) (PatternType, int, bool) {
 		return "", 0, false
 	}
 	typ := s[:idx]
-	var num int
-	if _, err := fmt.Sscanf(s[idx+1:], "%d", &num); err != nil {
+	num, err := strconv.Atoi(s[idx+1:])
+	if err != nil {
 		return "", 0, false
 	}
 	return PatternType(typ), num, true
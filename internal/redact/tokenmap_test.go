@@ -2,6 +2,7 @@ package redact
 
 import (
 	"encoding/json"
+	"strings"
 	"testing"
 )
 
@@ -159,3 +160,55 @@ func containsSubstr(s, sub string) bool {
 	}
 	return false
 }
+
+func TestHashTokenStableAcrossMaps(t *testing.T) {
+	key := []byte("audit-correlation-key")
+	a := NewHashTokenMap("job-a", key)
+	b := NewHashTokenMap("job-b", key)
+
+	// Allocate in a different order in each map: hash tokens do not depend
+	// on allocation order.
+	a.Token(PatternIP, "10.0.0.1")
+	tokA := a.Token(PatternPath, "/etc/shadow")
+	tokB := b.Token(PatternPath, "/etc/shadow")
+
+	if tokA != tokB {
+		t.Errorf("expected same token across maps, got %s and %s", tokA, tokB)
+	}
+	if !strings.HasPrefix(tokA, "<<PATH_h") || strings.Contains(tokA, "shadow") {
+		t.Errorf("unexpected hash token format: %s", tokA)
+	}
+	if a.Mode() != TokenHash {
+		t.Errorf("expected hash mode, got %s", a.Mode())
+	}
+	if val, ok := a.Resolve(tokA); !ok || val != "/etc/shadow" {
+		t.Errorf("expected token to resolve within its map, got %q ok=%v", val, ok)
+	}
+
+	other := NewHashTokenMap("job-c", []byte("another-key"))
+	if tok := other.Token(PatternPath, "/etc/shadow"); tok == tokA {
+		t.Errorf("expected a different key to yield a different token, got %s", tok)
+	}
+	if tok := a.Token(PatternPath, "/etc/passwd"); tok == tokA {
+		t.Errorf("expected distinct values to yield distinct tokens, got %s", tok)
+	}
+}
+
+func TestHashTokenRedactAndCheckLeaks(t *testing.T) {
+	tm := NewHashTokenMap("job", []byte("k"))
+	redacted := Redact("cat /var/www/site/wp-config.php", tm)
+	if strings.Contains(redacted, "/var/www") {
+		t.Fatalf("expected path redacted, got %q", redacted)
+	}
+
+	report := VerifyFidelity(redacted, tm, FidelityOptions{})
+	if !report.Safe() || len(report.Preserved) != 1 {
+		t.Errorf("expected hash token preserved, got %+v", report)
+	}
+	if leaks := CheckLeaks("rm /var/www/site/wp-config.php", tm); len(leaks) != 1 {
+		t.Errorf("expected 1 leak, got %v", leaks)
+	}
+	if got := Detoken(redacted, tm); got != "cat /var/www/site/wp-config.php" {
+		t.Errorf("expected detoken to restore text, got %q", got)
+	}
+}
@@ -22,6 +22,8 @@ import (
 	"strings"
 	"testing"
 	"time"
+
+	"github.com/ppiankov/chainwatch/internal/redact"
 )
 
 const (
@@ -225,21 +227,14 @@ func TestRedactionFidelity(t *testing.T) {
 				allCmds += s.Cmd + " "
 			}
 
-			r.tokenTotal = len(tc.wantTokens)
-			for _, tok := range tc.wantTokens {
-				if strings.Contains(allCmds, tok) {
-					r.tokenHits++
-				} else {
-					r.tokensMissing = append(r.tokensMissing, tok)
-				}
-			}
-
-			// Check for literal leaks.
-			for _, deny := range tc.denyLiteral {
-				if strings.Contains(allCmds, deny) {
-					r.leaks = append(r.leaks, deny)
-				}
-			}
+			report := redact.VerifyFidelity(allCmds, nil, redact.FidelityOptions{
+				Required:     tc.wantTokens,
+				DenyLiterals: tc.denyLiteral,
+			})
+			r.tokenTotal = report.Required
+			r.tokenHits = report.Required - len(report.Missing)
+			r.tokensMissing = report.Missing
+			r.leaks = report.Leaks
 
 			t.Logf("Tokens: %d/%d used correctly", r.tokenHits, r.tokenTotal)
 			if len(r.tokensMissing) > 0 {
@@ -45,8 +45,7 @@ func Run(s *Scenario, cfg *policy.PolicyConfig, dl *denylist.Denylist) *RunResul
 		}
 
 		evalResult := policy.Evaluate(action, state, c.Purpose, c.Agent, evalDL, evalCfg)
-		actual := string(evalResult.Decision)
-		expected := strings.ToLower(c.Expect)
+		expected, actual := describe(c, evalResult)
 
 		cr := CaseResult{
 			Index:    i + 1,
@@ -70,6 +69,23 @@ func Run(s *Scenario, cfg *policy.PolicyConfig, dl *denylist.Denylist) *RunResul
 	return result
 }
 
+// describe renders the expected and actual outcome of a case. Tier and
+// approval key are included only when the case asserts them, so a plain
+// decision case compares just the decision.
+func describe(c Case, r model.PolicyResult) (expected, actual string) {
+	expected = strings.ToLower(c.Expect)
+	actual = string(r.Decision)
+	if c.ExpectTier != nil {
+		expected += fmt.Sprintf(" tier=%d", *c.ExpectTier)
+		actual += fmt.Sprintf(" tier=%d", r.Tier)
+	}
+	if c.ExpectApprovalKey != "" {
+		expected += " approval_key=" + c.ExpectApprovalKey
+		actual += " approval_key=" + r.ApprovalKey
+	}
+	return expected, actual
+}
+
 // LoadAndRun loads a scenario YAML file, loads policy and denylist, and runs.
 func LoadAndRun(path, policyPath, denylistPath string) (*RunResult, error) {
 	data, err := os.ReadFile(path)
@@ -216,3 +216,44 @@ cases:
 		t.Errorf("expected 2 total passed across scenarios, got %d", totalPassed)
 	}
 }
+
+func TestTierAndApprovalKeyAssertions(t *testing.T) {
+	dir := t.TempDir()
+	writeScenario(t, dir, "cases.yaml", `
+name: "policy regression"
+cases:
+  - action: {tool: file_read, resource: /hr/salary.csv}
+    purpose: SOC_efficiency
+    expect: require_approval
+    expect_approval_key: soc_salary_access
+  - action: {tool: command, resource: "rm -rf /"}
+    expect: deny
+    expect_tier: 3
+  - action: {tool: file_read, resource: /hr/salary.csv}
+    purpose: SOC_efficiency
+    expect: require_approval
+    expect_approval_key: wrong_key
+  - action: {tool: command, resource: "rm -rf /"}
+    expect: allow
+`)
+
+	result, err := LoadAndRun(filepath.Join(dir, "cases.yaml"), filepath.Join(dir, "missing-policy.yaml"), filepath.Join(dir, "missing-denylist.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Passed != 2 || result.Failed != 2 {
+		t.Fatalf("expected 2 passed and 2 failed, got %d/%d", result.Passed, result.Failed)
+	}
+	if !result.Cases[0].Passed || !result.Cases[1].Passed {
+		t.Errorf("expected matching cases to pass: %+v", result.Cases[:2])
+	}
+
+	keyCase := result.Cases[2]
+	if keyCase.Passed || keyCase.Actual != "require_approval approval_key=soc_salary_access" {
+		t.Errorf("expected approval key mismatch, got %+v", keyCase)
+	}
+	decisionCase := result.Cases[3]
+	if decisionCase.Passed || decisionCase.Expected != "allow" || decisionCase.Actual != "deny" {
+		t.Errorf("expected decision mismatch, got %+v", decisionCase)
+	}
+}
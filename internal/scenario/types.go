@@ -13,6 +13,10 @@ type Case struct {
 	Expect  string         `yaml:"expect"`
 	Purpose string         `yaml:"purpose,omitempty"`
 	Agent   string         `yaml:"agent,omitempty"`
+
+	// Optional assertions beyond the decision; unset fields are not checked.
+	ExpectTier        *int   `yaml:"expect_tier,omitempty"`
+	ExpectApprovalKey string `yaml:"expect_approval_key,omitempty"`
 }
 
 // Scenario is a named collection of policy test cases.
@@ -4,20 +4,29 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/fsnotify/fsnotify"
 )
 
+// Reloadable is implemented by servers that can re-read their policy and
+// denylist in place (the gRPC server, proxy, and interceptor).
+type Reloadable interface {
+	ReloadPolicy() error
+}
+
 // Reloader watches policy and denylist files for changes and triggers hot-reload.
+// SIGHUP triggers an immediate reload.
 type Reloader struct {
 	watcher *fsnotify.Watcher
-	server  *Server
+	server  Reloadable
 	paths   []string
 }
 
 // NewReloader creates a file watcher for the given paths.
-func NewReloader(server *Server, paths []string) (*Reloader, error) {
+func NewReloader(server Reloadable, paths []string) (*Reloader, error) {
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create file watcher: %w", err)
@@ -45,10 +54,14 @@ func NewReloader(server *Server, paths []string) (*Reloader, error) {
 	}, nil
 }
 
-// Run watches for file changes and reloads policy. Blocks until ctx is cancelled.
+// Run watches for file changes and SIGHUP and reloads policy. Blocks until ctx is cancelled.
 func (r *Reloader) Run(ctx context.Context) error {
 	defer r.watcher.Close()
 
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
 	// Debounce: wait 500ms after last write before reloading
 	var debounce *time.Timer
 
@@ -68,15 +81,12 @@ func (r *Reloader) Run(ctx context.Context) error {
 				if debounce != nil {
 					debounce.Stop()
 				}
-				debounce = time.AfterFunc(500*time.Millisecond, func() {
-					if err := r.server.ReloadPolicy(); err != nil {
-						fmt.Fprintf(os.Stderr, "hot-reload failed: %v\n", err)
-					} else {
-						fmt.Fprintf(os.Stderr, "hot-reload: policy reloaded\n")
-					}
-				})
+				debounce = time.AfterFunc(500*time.Millisecond, r.reload)
 			}
 
+		case <-hup:
+			r.reload()
+
 		case err, ok := <-r.watcher.Errors:
 			if !ok {
 				return nil
@@ -85,3 +95,11 @@ func (r *Reloader) Run(ctx context.Context) error {
 		}
 	}
 }
+
+func (r *Reloader) reload() {
+	if err := r.server.ReloadPolicy(); err != nil {
+		fmt.Fprintf(os.Stderr, "hot-reload failed: %v\n", err)
+	} else {
+		fmt.Fprintf(os.Stderr, "hot-reload: policy reloaded\n")
+	}
+}
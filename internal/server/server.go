@@ -4,16 +4,23 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"os"
+	"strings"
 	"sync"
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
 
 	pb "github.com/ppiankov/chainwatch/api/proto/chainwatch/v1"
 	"github.com/ppiankov/chainwatch/internal/alert"
 	"github.com/ppiankov/chainwatch/internal/approval"
 	"github.com/ppiankov/chainwatch/internal/audit"
+	"github.com/ppiankov/chainwatch/internal/decisionhook"
 	"github.com/ppiankov/chainwatch/internal/denylist"
+	"github.com/ppiankov/chainwatch/internal/identity"
+	"github.com/ppiankov/chainwatch/internal/listen"
 	"github.com/ppiankov/chainwatch/internal/model"
 	"github.com/ppiankov/chainwatch/internal/policy"
 	"github.com/ppiankov/chainwatch/internal/profile"
@@ -26,10 +33,15 @@ type Config struct {
 	PolicyPath   string
 	DenylistPath string
 	ProfileName  string
-	AuditLogPath string
+	AuditLogPath string // optional: hash-chained log of every decision, approval, and trace reset
 	ApprovalDir  string // optional: override default approval store directory
+	Listen       string // optional: "unix:///path.sock" or host:port; overrides Port
 }
 
+// actorMetadataKey is the gRPC metadata key callers use to identify
+// themselves, mirroring the HTTP agent header.
+var actorMetadataKey = strings.ToLower(identity.DefaultAgentHeader)
+
 // sessionTTL is how long idle sessions are kept before eviction.
 const sessionTTL = 1 * time.Hour
 
@@ -37,7 +49,9 @@ const sessionTTL = 1 * time.Hour
 const sessionEvictInterval = 5 * time.Minute
 
 // sessionEntry wraps a TraceAccumulator with creation time for TTL eviction.
+// mu guards ta: concurrent Evaluate calls may share a trace.
 type sessionEntry struct {
+	mu        sync.Mutex
 	ta        *tracer.TraceAccumulator
 	createdAt time.Time
 }
@@ -47,9 +61,11 @@ type Server struct {
 	pb.UnimplementedChainwatchServiceServer
 
 	mu         sync.RWMutex
+	reloadMu   sync.Mutex // serializes ReloadPolicy and SetProfile
 	policyCfg  *policy.PolicyConfig
 	dl         *denylist.Denylist
 	policyHash string
+	hook       *decisionhook.Hook
 	approvals  *approval.Store
 	dispatcher *alert.Dispatcher
 	auditLog   *audit.Log
@@ -62,38 +78,26 @@ type Server struct {
 
 // New creates a gRPC server with loaded policy, denylist, and approval store.
 func New(cfg Config) (*Server, error) {
-	dl, err := denylist.Load(cfg.DenylistPath)
+	dl, policyCfg, policyHash, err := profile.LoadPolicy(cfg.DenylistPath, cfg.PolicyPath, cfg.ProfileName, false)
 	if err != nil {
-		return nil, fmt.Errorf("failed to load denylist: %w", err)
-	}
-
-	policyCfg, policyHash, err := policy.LoadConfigWithHash(cfg.PolicyPath)
-	if err != nil {
-		return nil, fmt.Errorf("failed to load policy config: %w", err)
-	}
-
-	if cfg.ProfileName != "" {
-		prof, err := profile.Load(cfg.ProfileName)
-		if err != nil {
-			return nil, fmt.Errorf("failed to load profile %q: %w", cfg.ProfileName, err)
-		}
-		profile.ApplyToDenylist(prof, dl)
-		policyCfg = profile.ApplyToPolicy(prof, policyCfg)
+		return nil, err
 	}
 
 	approvalDir := cfg.ApprovalDir
 	if approvalDir == "" {
 		approvalDir = approval.DefaultDir()
 	}
-	approvalStore, err := approval.NewStore(approvalDir)
+	approvalStore, err := approval.Open(approvalDir, policyCfg.ApprovalStore)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create approval store: %w", err)
 	}
 	approvalStore.Cleanup()
+	approvalStore.SetThrottle(policyCfg.ApprovalThrottle)
+	approvalStore.SetReasonRequired(policyCfg.ReasonRequiredKeys())
 
 	var auditLog *audit.Log
 	if cfg.AuditLogPath != "" {
-		auditLog, err = audit.Open(cfg.AuditLogPath)
+		auditLog, err = audit.OpenWithSinks(cfg.AuditLogPath, policyCfg.AuditSinks)
 		if err != nil {
 			return nil, fmt.Errorf("failed to open audit log: %w", err)
 		}
@@ -103,6 +107,7 @@ func New(cfg Config) (*Server, error) {
 		policyCfg:  policyCfg,
 		dl:         dl,
 		policyHash: policyHash,
+		hook:       decisionhook.New(policyCfg.DecisionHook),
 		approvals:  approvalStore,
 		dispatcher: alert.NewDispatcher(policyCfg.Alerts),
 		auditLog:   auditLog,
@@ -117,11 +122,16 @@ func New(cfg Config) (*Server, error) {
 	return s, nil
 }
 
-// Serve starts the gRPC server on the configured port. Blocks until stopped.
+// Serve starts the gRPC server on the configured address (Listen, else
+// Port). Blocks until stopped; a Unix socket file is removed on stop.
 func (s *Server) Serve() error {
-	lis, err := net.Listen("tcp", fmt.Sprintf(":%d", s.cfg.Port))
+	addr := s.cfg.Listen
+	if addr == "" {
+		addr = fmt.Sprintf(":%d", s.cfg.Port)
+	}
+	lis, err := listen.Listen(addr)
 	if err != nil {
-		return fmt.Errorf("failed to listen on port %d: %w", s.cfg.Port, err)
+		return err
 	}
 	return s.grpcServer.Serve(lis)
 }
@@ -146,7 +156,9 @@ func (s *Server) Close() error {
 }
 
 // Evaluate implements the Evaluate RPC.
-func (s *Server) Evaluate(ctx context.Context, req *pb.EvalRequest) (*pb.EvalResponse, error) {
+func (s *Server) Evaluate(ctx context.Context, req *pb.EvalRequest) (resp *pb.EvalResponse, err error) {
+	defer s.recoverEvaluate(ctx, req, &resp, &err)
+
 	if req.Action == nil {
 		return &pb.EvalResponse{
 			Decision: "deny",
@@ -164,42 +176,184 @@ func (s *Server) Evaluate(ctx context.Context, req *pb.EvalRequest) (*pb.EvalRes
 	if traceID == "" {
 		traceID = tracer.NewTraceID()
 	}
-	ta := s.getOrCreateSession(traceID)
+	if req.DryRun {
+		return s.evaluateDryRun(ctx, req, action, purpose, traceID), nil
+	}
+	entry := s.getOrCreateSession(traceID)
 
 	s.mu.RLock()
 	policyCfg := s.policyCfg
 	dl := s.dl
 	policyHash := s.policyHash
+	hook := s.hook
 	s.mu.RUnlock()
 
-	result := policy.Evaluate(action, ta.State, purpose, req.AgentId, dl, policyCfg)
-
-	ta.RecordAction(
-		map[string]any{"grpc": "chainwatch.v1.Evaluate"},
-		purpose, action,
-		map[string]any{
-			"result":       string(result.Decision),
-			"reason":       result.Reason,
-			"policy_id":    result.PolicyID,
-			"approval_key": result.ApprovalKey,
-		}, "",
-	)
-
-	s.recordAudit(action, string(result.Decision), result.Reason, result.Tier, policyHash, traceID)
-	s.dispatchAlert(action, string(result.Decision), result.Reason, result.Tier, policyHash, traceID)
+	var result model.PolicyResult
+	func() {
+		entry.mu.Lock()
+		defer entry.mu.Unlock()
+		result = policy.Evaluate(action, entry.ta.State, purpose, req.AgentId, dl, policyCfg)
+	}()
+	// The decision hook calls out over HTTP, so it runs outside the session lock.
+	result = hook.Decide(ctx, action, traceID, purpose, req.AgentId, result)
+
+	var needConfirm bool
+	func() {
+		entry.mu.Lock()
+		defer entry.mu.Unlock()
+		entry.ta.RecordAction(
+			map[string]any{"grpc": "chainwatch.v1.Evaluate"},
+			purpose, action,
+			map[string]any{
+				"result":       string(result.Decision),
+				"reason":       result.Reason,
+				"policy_id":    result.PolicyID,
+				"approval_key": result.ApprovalKey,
+				"tier":         result.Tier,
+			}, "",
+		)
+		needConfirm = policyCfg.ConfirmRequired(result, entry.ta.State)
+	}()
+
+	s.dispatchAlert(action, result, policyHash, traceID)
+
+	agentID := req.AgentId
+	if agentID == "" {
+		agentID = actorFromContext(ctx)
+	}
 
 	// Handle require_approval: create pending request if needed
+	auditType := ""
 	if result.Decision == model.RequireApproval && result.ApprovalKey != "" {
-		status, _ := s.approvals.Check(result.ApprovalKey)
-		if status == approval.StatusApproved {
+		status, _ := s.approvals.CheckTrace(result.ApprovalKey, traceID)
+		if status == approval.StatusApproved && needConfirm {
+			result = s.approvals.ConfirmApproved(result, action, req.ConfirmToken, policyCfg.ConfirmTTL())
+		} else if status == approval.StatusApproved {
 			s.approvals.Consume(result.ApprovalKey)
+			if err := s.approvals.OpenGrace(result, traceID, agentID); err != nil {
+				fmt.Fprintf(os.Stderr, "approval grace not opened: %v\n", err)
+			}
 			result.Decision = model.Allow
 			result.Reason = "approved: " + result.Reason
+		} else if status == approval.StatusThrottled {
+			result = s.approvals.ThrottleDeny(result)
+			s.dispatchAlert(action, result, policyHash, traceID)
+		} else if graced, ok := s.approvals.Grace(result, traceID, agentID); ok && !needConfirm {
+			result = graced
+			auditType = "approval_grace"
 		} else if status != approval.StatusPending && status != approval.StatusDenied {
 			s.approvals.Request(result.ApprovalKey, result.Reason, result.PolicyID, action.Resource, "")
 		}
 	}
 
+	s.recordAudit(audit.AuditEntry{
+		TraceID:    traceID,
+		AgentID:    agentID,
+		Action:     audit.AuditAction{Tool: action.Tool, Resource: action.Resource, RawResource: action.RawResource, Labels: action.Labels, Fingerprint: action.Fingerprint()},
+		Decision:   string(result.Decision),
+		Reason:     result.Reason,
+		Tier:       result.Tier,
+		PolicyHash: policyHash,
+		Type:       auditType,
+	})
+
+	return &pb.EvalResponse{
+		Decision:     string(result.Decision),
+		Reason:       result.Reason,
+		Tier:         int32(result.Tier),
+		PolicyId:     result.PolicyID,
+		ApprovalKey:  result.ApprovalKey,
+		TraceId:      traceID,
+		Fingerprint:  action.Fingerprint(),
+		ConfirmToken: result.ConfirmToken,
+	}, nil
+}
+
+// recoverEvaluate is deferred by Evaluate. gRPC does not recover handler
+// panics, so a panic outside policy evaluation (action conversion, decision
+// hook, approval handling) would take the server down. It is turned into an
+// audited evaluation_panic deny instead.
+func (s *Server) recoverEvaluate(ctx context.Context, req *pb.EvalRequest, resp **pb.EvalResponse, err *error) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	result := policy.EvaluationPanic(r)
+
+	s.mu.RLock()
+	policyHash := s.policyHash
+	s.mu.RUnlock()
+	agentID := req.AgentId
+	if agentID == "" {
+		agentID = actorFromContext(ctx)
+	}
+	var action audit.AuditAction
+	if req.Action != nil {
+		a := protoToAction(req.Action)
+		action = audit.AuditAction{Tool: a.Tool, Resource: a.Resource, RawResource: a.RawResource, Labels: a.Labels, Fingerprint: a.Fingerprint()}
+	}
+	if !req.DryRun {
+		s.recordAudit(audit.AuditEntry{
+			TraceID:    req.TraceId,
+			AgentID:    agentID,
+			Action:     action,
+			Decision:   string(result.Decision),
+			Reason:     result.Reason,
+			Tier:       result.Tier,
+			PolicyHash: policyHash,
+		})
+	}
+
+	*resp = &pb.EvalResponse{
+		Decision:    string(result.Decision),
+		Reason:      result.Reason,
+		Tier:        int32(result.Tier),
+		PolicyId:    result.PolicyID,
+		TraceId:     req.TraceId,
+		Fingerprint: action.Fingerprint,
+	}
+	*err = nil
+}
+
+// evaluateDryRun answers "would this be allowed?" for a dry_run request.
+// The action is evaluated against a copy of the trace state, so zones and
+// counters of the real trace are left as they were. No session is created
+// and nothing is recorded, alerted, requested or consumed. The decision
+// hook is not consulted: it may act on what it is asked. Approvals are
+// read as they stand.
+func (s *Server) evaluateDryRun(ctx context.Context, req *pb.EvalRequest, action *model.Action, purpose, traceID string) *pb.EvalResponse {
+	state := model.NewTraceState(traceID)
+	if v, ok := s.sessions.Load(traceID); ok {
+		entry := v.(*sessionEntry)
+		entry.mu.Lock()
+		state = entry.ta.State.Clone()
+		entry.mu.Unlock()
+	}
+
+	s.mu.RLock()
+	policyCfg := s.policyCfg
+	dl := s.dl
+	s.mu.RUnlock()
+
+	agentID := req.AgentId
+	if agentID == "" {
+		agentID = actorFromContext(ctx)
+	}
+
+	result := policy.Evaluate(action, state, purpose, req.AgentId, dl, policyCfg)
+	if result.Decision == model.RequireApproval && result.ApprovalKey != "" {
+		status, _ := s.approvals.PeekTrace(result.ApprovalKey, traceID)
+		needConfirm := policyCfg.ConfirmRequired(result, state)
+		if status == approval.StatusApproved && !needConfirm {
+			result.Decision = model.Allow
+			result.Reason = "approved: " + result.Reason
+		} else if status == approval.StatusThrottled {
+			result = s.approvals.ThrottleDeny(result)
+		} else if graced, ok := s.approvals.Grace(result, traceID, agentID); ok && !needConfirm {
+			result = graced
+		}
+	}
+
 	return &pb.EvalResponse{
 		Decision:    string(result.Decision),
 		Reason:      result.Reason,
@@ -207,7 +361,8 @@ func (s *Server) Evaluate(ctx context.Context, req *pb.EvalRequest) (*pb.EvalRes
 		PolicyId:    result.PolicyID,
 		ApprovalKey: result.ApprovalKey,
 		TraceId:     traceID,
-	}, nil
+		Fingerprint: action.Fingerprint(),
+	}
 }
 
 // Approve implements the Approve RPC.
@@ -221,10 +376,22 @@ func (s *Server) Approve(ctx context.Context, req *pb.ApproveRequest) (*pb.Appro
 		}
 	}
 
-	if err := s.approvals.Approve(req.Key, duration, ""); err != nil {
+	actor := actorFromContext(ctx)
+	err := s.approvals.ApproveWith(req.Key, approval.ApproveOptions{
+		Duration:      duration,
+		ApprovedBy:    actor,
+		Justification: req.Reason,
+	})
+	if err != nil {
 		return nil, err
 	}
 
+	reason := "approved via gRPC"
+	if duration > 0 {
+		reason = fmt.Sprintf("approved via gRPC for %s", duration)
+	}
+	s.recordApprovalAudit(actor, req.Key, "approved", reason, strings.TrimSpace(req.Reason))
+
 	return &pb.ApproveResponse{
 		Key:    req.Key,
 		Status: "approved",
@@ -236,6 +403,7 @@ func (s *Server) Deny(ctx context.Context, req *pb.DenyRequest) (*pb.DenyRespons
 	if err := s.approvals.Deny(req.Key); err != nil {
 		return nil, err
 	}
+	s.recordApprovalAudit(actorFromContext(ctx), req.Key, "denied", "denied via gRPC", "")
 
 	return &pb.DenyResponse{
 		Key:    req.Key,
@@ -264,48 +432,178 @@ func (s *Server) ListPending(ctx context.Context, req *pb.ListPendingRequest) (*
 	return &pb.ListPendingResponse{Approvals: approvals}, nil
 }
 
-// ReloadPolicy atomically swaps policy and denylist config.
-// Called by the hot-reloader on file change.
-func (s *Server) ReloadPolicy() error {
-	dl, err := denylist.Load(s.cfg.DenylistPath)
+// ResetTrace implements the ResetTrace RPC.
+// With the default scope it clears the trace's accumulated state (zones,
+// seen sources, action count) so an escalated or budget-exhausted trace
+// evaluates from the base tier. The action_count scope clears only the
+// max_actions_per_trace counter and keeps the trace escalated. The reset
+// is audited with the caller's identity.
+func (s *Server) ResetTrace(ctx context.Context, req *pb.ResetTraceRequest) (*pb.ResetTraceResponse, error) {
+	if req.TraceId == "" {
+		return nil, fmt.Errorf("trace_id is required")
+	}
+	switch req.Scope {
+	case "", model.ResetScopeAll, model.ResetScopeActionCount:
+	default:
+		return nil, fmt.Errorf("invalid reset scope %q (want all or action_count)", req.Scope)
+	}
+
+	v, ok := s.sessions.Load(req.TraceId)
+	if !ok {
+		return &pb.ResetTraceResponse{TraceId: req.TraceId}, nil
+	}
+
+	entry := v.(*sessionEntry)
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	var prevCount int
+	var reason string
+	if req.Scope == model.ResetScopeActionCount {
+		prevCount = entry.ta.State.ResetActionCount()
+		reason = fmt.Sprintf("trace action count reset via gRPC (%d actions)", prevCount)
+	} else {
+		prev := entry.ta.State.Reset()
+		prevCount = prev.ActionCount
+		reason = fmt.Sprintf("trace state reset via gRPC (was zone %s, %d zones entered, %d actions)",
+			prev.Zone, len(prev.ZonesEntered), prev.ActionCount)
+	}
+
+	s.mu.RLock()
+	policyHash := s.policyHash
+	s.mu.RUnlock()
+	s.recordAudit(audit.AuditEntry{
+		TraceID:    req.TraceId,
+		AgentID:    actorFromContext(ctx),
+		Action:     audit.AuditAction{Tool: "trace", Resource: req.TraceId},
+		Decision:   "reset",
+		Reason:     reason,
+		PolicyHash: policyHash,
+		Type:       "trace_reset",
+	})
+
+	return &pb.ResetTraceResponse{
+		TraceId:             req.TraceId,
+		Found:               true,
+		PreviousActionCount: int32(prevCount),
+	}, nil
+}
+
+// ReloadProfile implements the ReloadProfile RPC.
+// Switches the running server to the named profile (or re-applies the
+// current one when the name is empty). The switch is audited with the
+// caller's identity.
+func (s *Server) ReloadProfile(ctx context.Context, req *pb.ReloadProfileRequest) (*pb.ReloadProfileResponse, error) {
+	name := strings.TrimSpace(req.Profile)
+	if name == "" {
+		s.mu.RLock()
+		name = s.cfg.ProfileName
+		s.mu.RUnlock()
+	}
+
+	prev, err := s.SetProfile(name, actorFromContext(ctx))
 	if err != nil {
-		return fmt.Errorf("failed to reload denylist: %w", err)
+		return nil, err
 	}
 
-	policyCfg, policyHash, err := policy.LoadConfigWithHash(s.cfg.PolicyPath)
+	s.mu.RLock()
+	policyHash := s.policyHash
+	s.mu.RUnlock()
+	return &pb.ReloadProfileResponse{
+		Profile:         name,
+		PreviousProfile: prev,
+		PolicyHash:      policyHash,
+	}, nil
+}
+
+// SetProfile re-reads policy and denylist, applies the named profile (a
+// comma-separated stack, or none when empty) and swaps the result into the
+// running server. actor identifies who triggered the switch in the audit
+// log. Returns the previously active profile. On error the running config
+// is left unchanged.
+func (s *Server) SetProfile(name, actor string) (string, error) {
+	name = strings.Join(profile.SplitNames(name), ",")
+
+	// Serialize switches so a concurrent hot-reload cannot re-apply the
+	// old profile between loading and swapping.
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	dl, policyCfg, policyHash, err := profile.LoadPolicy(s.cfg.DenylistPath, s.cfg.PolicyPath, name, false)
 	if err != nil {
-		return fmt.Errorf("failed to reload policy config: %w", err)
+		return "", err
 	}
 
-	if s.cfg.ProfileName != "" {
-		prof, err := profile.Load(s.cfg.ProfileName)
-		if err != nil {
-			return fmt.Errorf("failed to reload profile %q: %w", s.cfg.ProfileName, err)
-		}
-		profile.ApplyToDenylist(prof, dl)
-		policyCfg = profile.ApplyToPolicy(prof, policyCfg)
+	s.mu.Lock()
+	prev := s.cfg.ProfileName
+	s.cfg.ProfileName = name
+	s.swapConfig(dl, policyCfg, policyHash)
+	s.mu.Unlock()
+	s.approvals.SetThrottle(policyCfg.ApprovalThrottle)
+	s.approvals.SetReasonRequired(policyCfg.ReasonRequiredKeys())
+
+	s.recordAudit(audit.AuditEntry{
+		AgentID:    actor,
+		Action:     audit.AuditAction{Tool: "profile", Resource: name},
+		Decision:   "switched",
+		Reason:     fmt.Sprintf("profile switched from %s to %s", profileLabel(prev), profileLabel(name)),
+		PolicyHash: policyHash,
+		Type:       "profile_switch",
+	})
+	return prev, nil
+}
+
+// profileLabel names a profile stack for messages.
+func profileLabel(name string) string {
+	if name == "" {
+		return "(none)"
+	}
+	return name
+}
+
+// ReloadPolicy atomically swaps policy and denylist config.
+// Called by the hot-reloader on file change.
+func (s *Server) ReloadPolicy() error {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	s.mu.RLock()
+	name := s.cfg.ProfileName
+	s.mu.RUnlock()
+
+	dl, policyCfg, policyHash, err := profile.LoadPolicy(s.cfg.DenylistPath, s.cfg.PolicyPath, name, false)
+	if err != nil {
+		return err
 	}
 
 	s.mu.Lock()
+	s.swapConfig(dl, policyCfg, policyHash)
+	s.mu.Unlock()
+	s.approvals.SetThrottle(policyCfg.ApprovalThrottle)
+	s.approvals.SetReasonRequired(policyCfg.ReasonRequiredKeys())
+
+	return nil
+}
+
+// swapConfig installs a freshly loaded config. Callers must hold s.mu.
+func (s *Server) swapConfig(dl *denylist.Denylist, policyCfg *policy.PolicyConfig, policyHash string) {
 	s.policyCfg = policyCfg
 	s.dl = dl
 	s.policyHash = policyHash
 	s.dispatcher = alert.NewDispatcher(policyCfg.Alerts)
-	s.mu.Unlock()
-
-	return nil
+	s.hook = decisionhook.New(policyCfg.DecisionHook)
 }
 
-func (s *Server) getOrCreateSession(traceID string) *tracer.TraceAccumulator {
+func (s *Server) getOrCreateSession(traceID string) *sessionEntry {
 	if v, ok := s.sessions.Load(traceID); ok {
-		return v.(*sessionEntry).ta
+		return v.(*sessionEntry)
 	}
 	entry := &sessionEntry{
 		ta:        tracer.NewAccumulator(traceID),
 		createdAt: time.Now(),
 	}
 	actual, _ := s.sessions.LoadOrStore(traceID, entry)
-	return actual.(*sessionEntry).ta
+	return actual.(*sessionEntry)
 }
 
 // evictSessions periodically removes sessions older than sessionTTL.
@@ -331,21 +629,57 @@ func (s *Server) evictSessions() {
 	}
 }
 
-func (s *Server) recordAudit(action *model.Action, decision, reason string, tier int, policyHash, traceID string) {
-	if s.auditLog != nil {
-		s.auditLog.Record(audit.AuditEntry{
-			Timestamp:  time.Now().UTC().Format("2006-01-02T15:04:05.000Z"),
-			TraceID:    traceID,
-			Action:     audit.AuditAction{Tool: action.Tool, Resource: action.Resource},
-			Decision:   decision,
-			Reason:     reason,
-			Tier:       tier,
-			PolicyHash: policyHash,
-		})
+// recordAudit appends an entry to the audit log, if one is configured.
+func (s *Server) recordAudit(entry audit.AuditEntry) {
+	if s.auditLog == nil {
+		return
+	}
+	if entry.Timestamp == "" {
+		entry.Timestamp = time.Now().UTC().Format("2006-01-02T15:04:05.000Z")
+	}
+	s.auditLog.Record(entry)
+}
+
+// recordApprovalAudit records an operator approval decision. The approval
+// store does not track the originating trace, so the key stands in as the
+// resource and the trace ID is left empty.
+func (s *Server) recordApprovalAudit(actor, key, decision, reason, justification string) {
+	s.mu.RLock()
+	policyHash := s.policyHash
+	s.mu.RUnlock()
+	s.recordAudit(audit.AuditEntry{
+		AgentID:    actor,
+		Action:     audit.AuditAction{Tool: "approval", Resource: key},
+		Decision:   decision,
+		Reason:     reason,
+		PolicyHash: policyHash,
+		Type:       "approval_" + decision,
+
+		Justification: justification,
+	})
+}
+
+// actorFromContext identifies the caller of an RPC: the x-agent-id
+// metadata value when present and valid, otherwise the peer address
+// (the network name for Unix socket peers, which have no address).
+func actorFromContext(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, v := range md.Get(actorMetadataKey) {
+			if id := strings.TrimSpace(v); identity.ValidAgentID(id) {
+				return id
+			}
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		if addr := p.Addr.String(); addr != "" {
+			return "grpc:" + addr
+		}
+		return "grpc:" + p.Addr.Network()
 	}
+	return ""
 }
 
-func (s *Server) dispatchAlert(action *model.Action, decision, reason string, tier int, policyHash, traceID string) {
+func (s *Server) dispatchAlert(action *model.Action, result model.PolicyResult, policyHash, traceID string) {
 	s.mu.RLock()
 	d := s.dispatcher
 	s.mu.RUnlock()
@@ -355,10 +689,11 @@ func (s *Server) dispatchAlert(action *model.Action, decision, reason string, ti
 			TraceID:    traceID,
 			Tool:       action.Tool,
 			Resource:   action.Resource,
-			Decision:   decision,
-			Reason:     reason,
-			Tier:       tier,
+			Decision:   string(result.Decision),
+			Reason:     result.Reason,
+			Tier:       result.Tier,
 			PolicyHash: policyHash,
+			Rule:       alert.RuleAlert{Mode: result.AlertMode, Channels: result.AlertChannels},
 		})
 	}
 }
@@ -373,11 +708,18 @@ func protoToAction(pb *pb.Action) *model.Action {
 	for k, v := range pb.Meta {
 		rawMeta[k] = v
 	}
+	// Encoded paths and URLs are decoded like on the other surfaces, so
+	// %2e%2e%2f cannot slip past path rules. Commands are left verbatim.
+	resource, rawResource := pb.Resource, ""
+	if pb.Tool != "command" {
+		resource, rawResource = model.DecodeResource(resource)
+	}
 	return &model.Action{
-		Tool:      pb.Tool,
-		Resource:  pb.Resource,
-		Operation: pb.Operation,
-		Params:    params,
-		RawMeta:   rawMeta,
+		Tool:        pb.Tool,
+		Resource:    resource,
+		RawResource: rawResource,
+		Operation:   pb.Operation,
+		Params:      params,
+		RawMeta:     rawMeta,
 	}
 }
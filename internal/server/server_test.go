@@ -1,29 +1,45 @@
 package server
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
+	"io"
 	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
 
 	pb "github.com/ppiankov/chainwatch/api/proto/chainwatch/v1"
+	"github.com/ppiankov/chainwatch/internal/audit"
+	"github.com/ppiankov/chainwatch/internal/client"
+	"github.com/ppiankov/chainwatch/internal/model"
+	"github.com/ppiankov/chainwatch/internal/tracer"
 )
 
 // testServer spins up an in-process gRPC server on a random port and returns a client.
 func testServer(t *testing.T, policyPath, denylistPath string) (pb.ChainwatchServiceClient, func()) {
 	t.Helper()
-
-	cfg := Config{
+	return testServerWithConfig(t, Config{
 		PolicyPath:   policyPath,
 		DenylistPath: denylistPath,
 		ApprovalDir:  filepath.Join(t.TempDir(), "approvals"),
-	}
+	})
+}
+
+// testServerWithConfig is testServer with full control over the server Config.
+func testServerWithConfig(t *testing.T, cfg Config) (pb.ChainwatchServiceClient, func()) {
+	t.Helper()
 
 	srv, err := New(cfg)
 	if err != nil {
@@ -85,6 +101,10 @@ func TestEvaluateAllowsLowRisk(t *testing.T) {
 	if resp.TraceId == "" {
 		t.Error("expected trace_id to be set")
 	}
+	want := (&model.Action{Tool: "command", Resource: "ls", Operation: "execute"}).Fingerprint()
+	if resp.Fingerprint != want {
+		t.Errorf("expected fingerprint %s, got %q", want, resp.Fingerprint)
+	}
 }
 
 func TestEvaluateDeniesDestructive(t *testing.T) {
@@ -114,6 +134,38 @@ commands:
 	}
 }
 
+func TestEvaluateRecordsTierInTrace(t *testing.T) {
+	srv, err := New(Config{ApprovalDir: filepath.Join(t.TempDir(), "approvals")})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer srv.Close()
+
+	resp, err := srv.Evaluate(context.Background(), &pb.EvalRequest{
+		Action:  &pb.Action{Tool: "command", Resource: "rm -rf /", Operation: "execute"},
+		TraceId: "test-trace-tier",
+	})
+	if err != nil {
+		t.Fatalf("Evaluate: %v", err)
+	}
+
+	v, ok := srv.sessions.Load("test-trace-tier")
+	if !ok {
+		t.Fatal("expected a session for the trace")
+	}
+	var buf bytes.Buffer
+	if err := v.(*sessionEntry).ta.ExportJSONL(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var line tracer.ExportedAction
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("parse trace line %q: %v", buf.String(), err)
+	}
+	if resp.Tier != 3 || line.Tier != int(resp.Tier) {
+		t.Errorf("expected trace tier %d, got %d", resp.Tier, line.Tier)
+	}
+}
+
 func TestEvaluateDenylistBlock(t *testing.T) {
 	denylistPath := writeTempFile(t, "denylist.yaml", `
 urls:
@@ -138,6 +190,30 @@ urls:
 	}
 }
 
+func TestEvaluateDecodesEncodedResource(t *testing.T) {
+	denylistPath := writeTempFile(t, "denylist.yaml", `
+urls:
+  - "evil.com"
+`)
+	client, cleanup := testServer(t, "", denylistPath)
+	defer cleanup()
+
+	resp, err := client.Evaluate(context.Background(), &pb.EvalRequest{
+		Action: &pb.Action{
+			Tool:      "http_proxy",
+			Resource:  "https://%65vil%2ecom/exfil",
+			Operation: "get",
+		},
+	})
+	if err != nil {
+		t.Fatalf("Evaluate: %v", err)
+	}
+
+	if resp.Decision != "deny" {
+		t.Errorf("expected deny for percent-encoded denylisted URL, got %s: %s", resp.Decision, resp.Reason)
+	}
+}
+
 func TestEvaluateRequiresApproval(t *testing.T) {
 	policyPath := writeTempFile(t, "policy.yaml", `
 enforcement_mode: guarded
@@ -499,3 +575,717 @@ func TestReloaderCreation(t *testing.T) {
 
 	cancel()
 }
+
+func TestResetTraceReenablesEvaluation(t *testing.T) {
+	policyPath := writeTempFile(t, "policy.yaml", `
+enforcement_mode: guarded
+max_actions_per_trace: 1
+`)
+	client, cleanup := testServer(t, policyPath, "")
+	defer cleanup()
+
+	traceID := "test-trace-budget"
+	req := &pb.EvalRequest{
+		Action:  &pb.Action{Tool: "command", Resource: "ls", Operation: "execute"},
+		TraceId: traceID,
+	}
+
+	if resp, err := client.Evaluate(context.Background(), req); err != nil || resp.Decision != "allow" {
+		t.Fatalf("first Evaluate: %v %v", resp, err)
+	}
+	resp, err := client.Evaluate(context.Background(), req)
+	if err != nil {
+		t.Fatalf("second Evaluate: %v", err)
+	}
+	if resp.Decision != "deny" || resp.PolicyId != "trace.budget_exhausted" {
+		t.Fatalf("expected trace budget deny, got %s (%s)", resp.Decision, resp.PolicyId)
+	}
+
+	reset, err := client.ResetTrace(context.Background(), &pb.ResetTraceRequest{TraceId: traceID})
+	if err != nil {
+		t.Fatalf("ResetTrace: %v", err)
+	}
+	if !reset.Found || reset.PreviousActionCount != 1 {
+		t.Errorf("expected found with previous count 1, got %v", reset)
+	}
+
+	resp, err = client.Evaluate(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Evaluate after reset: %v", err)
+	}
+	if resp.Decision != "allow" {
+		t.Errorf("expected allow after reset, got %s (%s)", resp.Decision, resp.Reason)
+	}
+}
+
+func TestEvaluateDryRunDoesNotMutateTrace(t *testing.T) {
+	policyPath := writeTempFile(t, "policy.yaml", "enforcement_mode: guarded\n")
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	client, cleanup := testServerWithConfig(t, Config{
+		PolicyPath:   policyPath,
+		ApprovalDir:  filepath.Join(t.TempDir(), "approvals"),
+		AuditLogPath: auditPath,
+	})
+	defer cleanup()
+
+	traceID := "test-trace-dry-run"
+	eval := func(a *pb.Action, dryRun bool) *pb.EvalResponse {
+		t.Helper()
+		resp, err := client.Evaluate(context.Background(), &pb.EvalRequest{Action: a, TraceId: traceID, DryRun: dryRun})
+		if err != nil {
+			t.Fatalf("Evaluate %s: %v", a.Resource, err)
+		}
+		return resp
+	}
+	sensitive := &pb.Action{Tool: "file_read", Resource: "/data/hr/employees.csv", Operation: "read"}
+	egress := &pb.Action{Tool: "http", Resource: "https://example.com/status", Operation: "get"}
+	benign := &pb.Action{Tool: "command", Resource: "ls", Operation: "execute"}
+
+	// Dry runs of the zone-entering actions leave the real trace at base tier.
+	eval(sensitive, true)
+	if resp := eval(egress, true); resp.TraceId != traceID {
+		t.Errorf("expected dry run to echo trace %q, got %q", traceID, resp.TraceId)
+	}
+	if resp := eval(benign, false); resp.Tier != 0 || resp.Decision != "allow" {
+		t.Fatalf("expected dry runs to leave trace at base tier, got tier %d %s (%s)", resp.Tier, resp.Decision, resp.Reason)
+	}
+
+	// The same actions evaluated for real escalate the trace, and a dry
+	// run then sees the escalated state.
+	eval(sensitive, false)
+	eval(egress, false)
+	real := eval(benign, false)
+	if real.Tier == 0 {
+		t.Fatalf("expected real evaluations to escalate the trace, got tier 0 (%s)", real.Reason)
+	}
+	if dry := eval(benign, true); dry.Tier != real.Tier {
+		t.Errorf("expected dry run on escalated trace to report tier %d, got %d", real.Tier, dry.Tier)
+	}
+
+	if n := len(readAuditEntries(t, auditPath)); n != 4 {
+		t.Errorf("expected only the 4 real evaluations audited, got %d entries", n)
+	}
+}
+
+func TestEvaluateDryRunSkipsHookAndKeepsApproval(t *testing.T) {
+	var hookCalls atomic.Int32
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hookCalls.Add(1)
+		w.Write([]byte(`{"decision": "require_approval"}`))
+	}))
+	defer hook.Close()
+
+	policyPath := writeTempFile(t, "policy.yaml", `
+enforcement_mode: guarded
+decision_hook:
+  url: `+hook.URL+`
+  min_tier: 1
+  cache_ttl: -1s
+rules:
+  - purpose: "*"
+    resource_pattern: "*salary*"
+    decision: require_approval
+    reason: "salary data requires approval"
+    approval_key: salary_access
+`)
+	client, cleanup := testServer(t, policyPath, "")
+	defer cleanup()
+
+	action := &pb.Action{Tool: "http_proxy", Resource: "https://internal.corp/api/salary", Operation: "get"}
+	eval := func(dryRun bool) *pb.EvalResponse {
+		t.Helper()
+		resp, err := client.Evaluate(context.Background(), &pb.EvalRequest{Action: action, TraceId: "t-dry", DryRun: dryRun})
+		if err != nil {
+			t.Fatalf("Evaluate: %v", err)
+		}
+		return resp
+	}
+
+	if resp := eval(true); resp.Decision != "require_approval" {
+		t.Fatalf("expected dry run to require approval, got %s", resp.Decision)
+	}
+	if _, err := client.Approve(context.Background(), &pb.ApproveRequest{Key: "salary_access"}); err == nil {
+		t.Fatal("expected approve to fail: a dry run must not create the request")
+	}
+	eval(false)
+	if _, err := client.Approve(context.Background(), &pb.ApproveRequest{Key: "salary_access"}); err != nil {
+		t.Fatalf("Approve: %v", err)
+	}
+	hookCalls.Store(0)
+
+	if resp := eval(true); resp.Decision != "allow" {
+		t.Errorf("expected dry run to see the approval, got %s: %s", resp.Decision, resp.Reason)
+	}
+	if n := hookCalls.Load(); n != 0 {
+		t.Errorf("expected dry run not to call the decision hook, got %d calls", n)
+	}
+	if resp := eval(false); resp.Decision != "allow" {
+		t.Errorf("expected one-time approval left for the real call, got %s: %s", resp.Decision, resp.Reason)
+	}
+	if hookCalls.Load() == 0 {
+		t.Error("expected the real evaluation to consult the decision hook")
+	}
+}
+
+func TestResetTraceClearsEscalation(t *testing.T) {
+	policyPath := writeTempFile(t, "policy.yaml", "enforcement_mode: guarded\n")
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	client, cleanup := testServerWithConfig(t, Config{
+		PolicyPath:   policyPath,
+		ApprovalDir:  filepath.Join(t.TempDir(), "approvals"),
+		AuditLogPath: auditPath,
+	})
+	defer cleanup()
+
+	traceID := "test-trace-escalated"
+	eval := func(a *pb.Action) *pb.EvalResponse {
+		t.Helper()
+		resp, err := client.Evaluate(context.Background(), &pb.EvalRequest{Action: a, TraceId: traceID})
+		if err != nil {
+			t.Fatalf("Evaluate %s: %v", a.Resource, err)
+		}
+		return resp
+	}
+	benign := &pb.Action{Tool: "command", Resource: "ls", Operation: "execute"}
+
+	eval(&pb.Action{Tool: "file_read", Resource: "/data/hr/employees.csv", Operation: "read"})
+	eval(&pb.Action{Tool: "http", Resource: "https://example.com/status", Operation: "get"})
+	if resp := eval(benign); resp.Tier == 0 {
+		t.Fatalf("expected escalated trace to raise tier of benign action, got tier 0 (%s)", resp.Reason)
+	}
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), actorMetadataKey, "oncall-alice")
+	reset, err := client.ResetTrace(ctx, &pb.ResetTraceRequest{TraceId: traceID})
+	if err != nil {
+		t.Fatalf("ResetTrace: %v", err)
+	}
+	if !reset.Found {
+		t.Errorf("expected trace to be found, got %v", reset)
+	}
+
+	if resp := eval(benign); resp.Tier != 0 || resp.Decision != "allow" {
+		t.Errorf("expected base tier allow after reset, got tier %d %s (%s)", resp.Tier, resp.Decision, resp.Reason)
+	}
+
+	var resetEntry *audit.AuditEntry
+	for _, e := range readAuditEntries(t, auditPath) {
+		if e.Type == "trace_reset" {
+			resetEntry = &e
+		}
+	}
+	if resetEntry == nil {
+		t.Fatal("expected trace_reset audit entry")
+	}
+	if resetEntry.AgentID != "oncall-alice" {
+		t.Errorf("expected operator oncall-alice in audit entry, got %q", resetEntry.AgentID)
+	}
+	if !strings.Contains(resetEntry.Reason, "SENSITIVE") {
+		t.Errorf("expected previous zone in audit reason, got %q", resetEntry.Reason)
+	}
+}
+
+func TestResetTraceActionCountKeepsEscalation(t *testing.T) {
+	policyPath := writeTempFile(t, "policy.yaml", "enforcement_mode: guarded\nmax_actions_per_trace: 10\n")
+	client, cleanup := testServerWithConfig(t, Config{
+		PolicyPath:  policyPath,
+		ApprovalDir: filepath.Join(t.TempDir(), "approvals"),
+	})
+	defer cleanup()
+
+	traceID := "test-trace-count-only"
+	eval := func(a *pb.Action) *pb.EvalResponse {
+		t.Helper()
+		resp, err := client.Evaluate(context.Background(), &pb.EvalRequest{Action: a, TraceId: traceID})
+		if err != nil {
+			t.Fatalf("Evaluate %s: %v", a.Resource, err)
+		}
+		return resp
+	}
+	benign := &pb.Action{Tool: "command", Resource: "ls", Operation: "execute"}
+
+	eval(&pb.Action{Tool: "file_read", Resource: "/data/hr/employees.csv", Operation: "read"})
+	eval(&pb.Action{Tool: "http", Resource: "https://example.com/status", Operation: "get"})
+	escalated := eval(benign)
+	if escalated.Tier == 0 {
+		t.Fatalf("expected escalated trace to raise tier of benign action, got tier 0 (%s)", escalated.Reason)
+	}
+
+	reset, err := client.ResetTrace(context.Background(), &pb.ResetTraceRequest{TraceId: traceID, Scope: "action_count"})
+	if err != nil {
+		t.Fatalf("ResetTrace: %v", err)
+	}
+	if reset.PreviousActionCount != 3 {
+		t.Errorf("expected previous action count 3, got %d", reset.PreviousActionCount)
+	}
+	if resp := eval(benign); resp.Tier != escalated.Tier {
+		t.Errorf("expected action_count reset to keep tier %d, got %d (%s)", escalated.Tier, resp.Tier, resp.Reason)
+	}
+
+	if _, err := client.ResetTrace(context.Background(), &pb.ResetTraceRequest{TraceId: traceID, Scope: "zones"}); err == nil {
+		t.Error("expected error for unknown reset scope")
+	}
+}
+
+func TestForcedRuleAlertDispatched(t *testing.T) {
+	alerts := make(chan string, 1)
+	webhook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		alerts <- string(body)
+	}))
+	defer webhook.Close()
+
+	policyPath := writeTempFile(t, "policy.yaml", `enforcement_mode: guarded
+rules:
+  - purpose: "*"
+    resource_pattern: "*payroll-export*"
+    decision: allow
+    alert: force
+alerts:
+  - url: `+webhook.URL+`
+    format: generic
+    events: [deny]
+`)
+	client, cleanup := testServer(t, policyPath, "")
+	defer cleanup()
+
+	resp, err := client.Evaluate(context.Background(), &pb.EvalRequest{
+		Action: &pb.Action{Tool: "command", Resource: "echo payroll-export", Operation: "execute"},
+	})
+	if err != nil {
+		t.Fatalf("Evaluate: %v", err)
+	}
+	if resp.Decision != "allow" {
+		t.Fatalf("expected allow, got %s (%s)", resp.Decision, resp.Reason)
+	}
+
+	select {
+	case body := <-alerts:
+		if !strings.Contains(body, "payroll-export") {
+			t.Errorf("expected alert for the forced rule, got %s", body)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected forced alert despite channel event filter")
+	}
+}
+
+func TestResetTraceUnknown(t *testing.T) {
+	client, cleanup := testServer(t, "", "")
+	defer cleanup()
+
+	reset, err := client.ResetTrace(context.Background(), &pb.ResetTraceRequest{TraceId: "no-such-trace"})
+	if err != nil {
+		t.Fatalf("ResetTrace: %v", err)
+	}
+	if reset.Found {
+		t.Error("expected found=false for unknown trace")
+	}
+}
+
+func TestReloadProfileBlocksNewlyBoundariedCommand(t *testing.T) {
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	client, cleanup := testServerWithConfig(t, Config{
+		ApprovalDir:  filepath.Join(t.TempDir(), "approvals"),
+		AuditLogPath: auditPath,
+	})
+	defer cleanup()
+
+	cmd := &pb.EvalRequest{Action: &pb.Action{Tool: "command", Resource: "systemctl restart nginx", Operation: "execute"}}
+	resp, err := client.Evaluate(context.Background(), cmd)
+	if err != nil {
+		t.Fatalf("Evaluate: %v", err)
+	}
+	if resp.Decision == "deny" {
+		t.Fatalf("expected systemctl to pass without a profile, got deny (%s)", resp.Reason)
+	}
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), actorMetadataKey, "oncall-alice")
+	switched, err := client.ReloadProfile(ctx, &pb.ReloadProfileRequest{Profile: "vm-cloud"})
+	if err != nil {
+		t.Fatalf("ReloadProfile: %v", err)
+	}
+	if switched.Profile != "vm-cloud" || switched.PreviousProfile != "" {
+		t.Errorf("unexpected switch response %v", switched)
+	}
+
+	resp, err = client.Evaluate(context.Background(), cmd)
+	if err != nil {
+		t.Fatalf("Evaluate: %v", err)
+	}
+	if resp.Decision != "deny" {
+		t.Errorf("expected deny after switching to vm-cloud, got %s (%s)", resp.Decision, resp.Reason)
+	}
+
+	var entry *audit.AuditEntry
+	for _, e := range readAuditEntries(t, auditPath) {
+		if e.Type == "profile_switch" {
+			entry = &e
+		}
+	}
+	if entry == nil {
+		t.Fatal("expected profile_switch audit entry")
+	}
+	if entry.AgentID != "oncall-alice" {
+		t.Errorf("expected operator oncall-alice in audit entry, got %q", entry.AgentID)
+	}
+	if !strings.Contains(entry.Reason, "vm-cloud") {
+		t.Errorf("expected new profile in audit reason, got %q", entry.Reason)
+	}
+}
+
+func TestReloadProfileUnknownKeepsConfig(t *testing.T) {
+	client, cleanup := testServerWithConfig(t, Config{
+		ProfileName: "vm-cloud",
+		ApprovalDir: filepath.Join(t.TempDir(), "approvals"),
+	})
+	defer cleanup()
+
+	if _, err := client.ReloadProfile(context.Background(), &pb.ReloadProfileRequest{Profile: "no-such-profile"}); err == nil {
+		t.Fatal("expected error for unknown profile")
+	}
+
+	resp, err := client.Evaluate(context.Background(), &pb.EvalRequest{
+		Action: &pb.Action{Tool: "command", Resource: "systemctl restart nginx", Operation: "execute"},
+	})
+	if err != nil {
+		t.Fatalf("Evaluate: %v", err)
+	}
+	if resp.Decision != "deny" {
+		t.Errorf("expected vm-cloud to stay active after failed switch, got %s (%s)", resp.Decision, resp.Reason)
+	}
+}
+
+func readAuditEntries(t *testing.T, path string) []audit.AuditEntry {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read audit log: %v", err)
+	}
+	var entries []audit.AuditEntry
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var e audit.AuditEntry
+		if err := json.Unmarshal([]byte(line), &e); err != nil {
+			t.Fatalf("parse audit line %q: %v", line, err)
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+func TestEvaluateAndApproveAreAudited(t *testing.T) {
+	policyPath := writeTempFile(t, "policy.yaml", `
+enforcement_mode: guarded
+rules:
+  - purpose: "*"
+    resource_pattern: "*salary*"
+    decision: require_approval
+    approval_key: salary_access
+`)
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	client, cleanup := testServerWithConfig(t, Config{
+		PolicyPath:   policyPath,
+		ApprovalDir:  filepath.Join(t.TempDir(), "approvals"),
+		AuditLogPath: auditPath,
+	})
+
+	agentCtx := metadata.AppendToOutgoingContext(context.Background(), "x-agent-id", "agent-a")
+	_, err := client.Evaluate(agentCtx, &pb.EvalRequest{
+		TraceId: "t-audit",
+		Action:  &pb.Action{Tool: "http_proxy", Resource: "https://internal.corp/api/salary", Operation: "get"},
+	})
+	if err != nil {
+		t.Fatalf("Evaluate: %v", err)
+	}
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-agent-id", "operator-1")
+	if _, err := client.Approve(ctx, &pb.ApproveRequest{Key: "salary_access"}); err != nil {
+		t.Fatalf("Approve: %v", err)
+	}
+	cleanup()
+
+	if r := audit.Verify(auditPath); !r.Valid {
+		t.Fatalf("audit chain invalid: %s", r.Error)
+	}
+	entries := readAuditEntries(t, auditPath)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 audit entries, got %d", len(entries))
+	}
+
+	eval := entries[0]
+	if eval.TraceID != "t-audit" || eval.AgentID != "agent-a" || eval.Decision != "require_approval" {
+		t.Errorf("unexpected Evaluate audit entry: %+v", eval)
+	}
+	if eval.PolicyHash == "" {
+		t.Error("Evaluate audit entry must carry the policy hash")
+	}
+
+	approve := entries[1]
+	if approve.Type != "approval_approved" || approve.Action.Resource != "salary_access" || approve.Decision != "approved" {
+		t.Errorf("unexpected Approve audit entry: %+v", approve)
+	}
+	if approve.AgentID != "operator-1" {
+		t.Errorf("Approve actor = %q, want operator-1 from metadata", approve.AgentID)
+	}
+}
+
+func TestApproveRequireReason(t *testing.T) {
+	policyPath := writeTempFile(t, "policy.yaml", `
+enforcement_mode: guarded
+rules:
+  - purpose: "*"
+    resource_pattern: "*salary*"
+    decision: require_approval
+    approval_key: salary_access
+    require_reason: true
+`)
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	client, cleanup := testServerWithConfig(t, Config{
+		PolicyPath:   policyPath,
+		ApprovalDir:  filepath.Join(t.TempDir(), "approvals"),
+		AuditLogPath: auditPath,
+	})
+
+	_, err := client.Evaluate(context.Background(), &pb.EvalRequest{
+		Action: &pb.Action{Tool: "http_proxy", Resource: "https://internal.corp/api/salary", Operation: "get"},
+	})
+	if err != nil {
+		t.Fatalf("Evaluate: %v", err)
+	}
+
+	_, err = client.Approve(context.Background(), &pb.ApproveRequest{Key: "salary_access"})
+	if err == nil || !strings.Contains(err.Error(), "requires a reason") {
+		t.Fatalf("expected approval without reason rejected, got %v", err)
+	}
+	_, err = client.Approve(context.Background(), &pb.ApproveRequest{Key: "salary_access", Reason: "payroll audit INC-7"})
+	if err != nil {
+		t.Fatalf("Approve with reason: %v", err)
+	}
+	cleanup()
+
+	entries := readAuditEntries(t, auditPath)
+	last := entries[len(entries)-1]
+	if last.Type != "approval_approved" || last.Justification != "payroll audit INC-7" {
+		t.Errorf("expected approval audited with justification, got %+v", last)
+	}
+	for _, e := range entries {
+		if e.Type == "approval_approved" && e.Justification == "" {
+			t.Errorf("rejected approval must not be audited as approved: %+v", e)
+		}
+	}
+}
+
+func TestDenyIsAudited(t *testing.T) {
+	policyPath := writeTempFile(t, "policy.yaml", `
+enforcement_mode: guarded
+rules:
+  - purpose: "*"
+    resource_pattern: "*salary*"
+    decision: require_approval
+    approval_key: salary_access
+`)
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	client, cleanup := testServerWithConfig(t, Config{
+		PolicyPath:   policyPath,
+		ApprovalDir:  filepath.Join(t.TempDir(), "approvals"),
+		AuditLogPath: auditPath,
+	})
+
+	client.Evaluate(context.Background(), &pb.EvalRequest{
+		Action: &pb.Action{Tool: "http_proxy", Resource: "https://internal.corp/api/salary", Operation: "get"},
+	})
+	if _, err := client.Deny(context.Background(), &pb.DenyRequest{Key: "salary_access"}); err != nil {
+		t.Fatalf("Deny: %v", err)
+	}
+	cleanup()
+
+	entries := readAuditEntries(t, auditPath)
+	last := entries[len(entries)-1]
+	if last.Type != "approval_denied" || last.Decision != "denied" {
+		t.Errorf("expected approval_denied entry, got %+v", last)
+	}
+	if !strings.HasPrefix(last.AgentID, "grpc:") {
+		t.Errorf("expected peer address as actor without metadata, got %q", last.AgentID)
+	}
+}
+
+func TestServeOnUnixSocket(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "cw.sock")
+	srv, err := New(Config{
+		ApprovalDir: filepath.Join(t.TempDir(), "approvals"),
+		Listen:      "unix://" + sock,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer srv.Close()
+
+	served := make(chan error, 1)
+	go func() { served <- srv.Serve() }()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if _, err := os.Stat(sock); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("socket not created in time")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	info, err := os.Stat(sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("expected socket mode 0600, got %o", perm)
+	}
+
+	c, err := client.New("unix://" + sock)
+	if err != nil {
+		t.Fatalf("client.New: %v", err)
+	}
+	result, err := c.Evaluate(&model.Action{Tool: "command", Resource: "rm -rf /", Operation: "execute"}, "general", "")
+	c.Close()
+	if err != nil {
+		t.Fatalf("Evaluate over unix socket: %v", err)
+	}
+	if result.Decision != model.Deny {
+		t.Errorf("expected deny for rm -rf /, got %s", result.Decision)
+	}
+
+	srv.GracefulStop()
+	if err := <-served; err != nil {
+		t.Errorf("Serve: %v", err)
+	}
+	if _, err := os.Stat(sock); !os.IsNotExist(err) {
+		t.Errorf("expected socket file removed on stop, got %v", err)
+	}
+}
+
+func TestConfirmTokenForIrreversibleApproval(t *testing.T) {
+	policyPath := writeTempFile(t, "policy.yaml", `
+enforcement_mode: guarded
+min_tier: 3
+confirm_irreversible:
+  enabled: true
+  ttl: 1m
+rules:
+  - purpose: "*"
+    resource_pattern: "*prod-db*"
+    decision: require_approval
+    reason: "dropping production data"
+    approval_key: drop_prod
+`)
+	client, cleanup := testServer(t, policyPath, "")
+	defer cleanup()
+
+	eval := func(resource, token string) *pb.EvalResponse {
+		t.Helper()
+		resp, err := client.Evaluate(context.Background(), &pb.EvalRequest{
+			Action:       &pb.Action{Tool: "command", Resource: resource, Operation: "execute"},
+			TraceId:      "t-confirm",
+			ConfirmToken: token,
+		})
+		if err != nil {
+			t.Fatalf("Evaluate: %v", err)
+		}
+		return resp
+	}
+
+	if resp := eval("drop prod-db", ""); resp.Decision != "require_approval" || resp.ConfirmToken != "" {
+		t.Fatalf("expected plain require_approval, got %s token=%q", resp.Decision, resp.ConfirmToken)
+	}
+	if _, err := client.Approve(context.Background(), &pb.ApproveRequest{Key: "drop_prod"}); err != nil {
+		t.Fatalf("Approve: %v", err)
+	}
+
+	// Approved, but the first retry only yields a confirm token.
+	first := eval("drop prod-db", "")
+	if first.Decision != "require_approval" || first.ConfirmToken == "" || first.PolicyId != "approval.confirm" {
+		t.Fatalf("expected confirm token, got %s (%s) token=%q", first.Decision, first.PolicyId, first.ConfirmToken)
+	}
+
+	// The token does not carry over to another resource under the same key.
+	if resp := eval("drop prod-db-replica", first.ConfirmToken); resp.Decision == "allow" {
+		t.Fatal("confirm token accepted for a different resource")
+	}
+
+	if resp := eval("drop prod-db", first.ConfirmToken); resp.Decision != "allow" {
+		t.Fatalf("expected allow with confirm token, got %s: %s", resp.Decision, resp.Reason)
+	}
+	if resp := eval("drop prod-db", first.ConfirmToken); resp.Decision == "allow" {
+		t.Fatal("confirm token reused after the approval was consumed")
+	}
+}
+
+func TestEvaluatePanicFailsClosedAndIsAudited(t *testing.T) {
+	policyPath := writeTempFile(t, "policy.yaml", "enforcement_mode: guarded\n")
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	srv, err := New(Config{
+		PolicyPath:   policyPath,
+		ApprovalDir:  filepath.Join(t.TempDir(), "approvals"),
+		AuditLogPath: auditPath,
+	})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	defer srv.Close()
+
+	// A session without an accumulator makes evaluation of its trace panic.
+	srv.sessions.Store("test-trace-panic", &sessionEntry{createdAt: time.Now()})
+
+	resp, err := srv.Evaluate(context.Background(), &pb.EvalRequest{
+		Action:  &pb.Action{Tool: "file_read", Resource: "/boom", Operation: "read"},
+		TraceId: "test-trace-panic",
+	})
+	if err != nil {
+		t.Fatalf("Evaluate: %v", err)
+	}
+	if resp.Decision != "deny" || resp.PolicyId != "evaluation.panic" {
+		t.Fatalf("expected evaluation.panic deny, got %s (%s)", resp.Decision, resp.Reason)
+	}
+
+	// The server keeps serving after the panic.
+	resp, err = srv.Evaluate(context.Background(), &pb.EvalRequest{
+		Action:  &pb.Action{Tool: "command", Resource: "ls", Operation: "execute"},
+		TraceId: "test-trace-healthy",
+	})
+	if err != nil || resp.Decision != "allow" {
+		t.Fatalf("expected allow after recovered panic, got %v %v", resp, err)
+	}
+
+	entries := readAuditEntries(t, auditPath)
+	if len(entries) != 2 {
+		t.Fatalf("expected 2 audit entries, got %d", len(entries))
+	}
+	if entries[0].Decision != "deny" || !strings.HasPrefix(entries[0].Reason, "evaluation_panic: runtime error") {
+		t.Errorf("expected panic recorded in audit log, got %s %q", entries[0].Decision, entries[0].Reason)
+	}
+}
+
+func TestRecoverEvaluateDeniesOutsidePolicy(t *testing.T) {
+	auditPath := filepath.Join(t.TempDir(), "audit.jsonl")
+	log, err := audit.Open(auditPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := &Server{auditLog: log}
+	req := &pb.EvalRequest{Action: &pb.Action{Tool: "http", Resource: "https://example.com"}, TraceId: "t1"}
+
+	resp, err := func() (resp *pb.EvalResponse, err error) {
+		defer s.recoverEvaluate(context.Background(), req, &resp, &err)
+		panic("hook exploded")
+	}()
+	log.Close()
+	if err != nil || resp.Decision != "deny" || resp.PolicyId != "evaluation.panic" {
+		t.Fatalf("expected evaluation.panic deny, got %v %v", resp, err)
+	}
+	entries := readAuditEntries(t, auditPath)
+	if len(entries) != 1 || entries[0].Action.Resource != "https://example.com" {
+		t.Fatalf("expected one audit entry for the request, got %+v", entries)
+	}
+	want := protoToAction(req.Action).Fingerprint()
+	if entries[0].Action.Fingerprint != want || resp.Fingerprint != want {
+		t.Errorf("expected fingerprint %s in audit and response, got %q and %q", want, entries[0].Action.Fingerprint, resp.Fingerprint)
+	}
+}
@@ -33,7 +33,7 @@ type SimResult struct {
 // isPermissive returns true for decisions that allow action execution.
 func isPermissive(decision string) bool {
 	switch decision {
-	case "allow", "allow_with_redaction", "rewrite_output":
+	case "allow", "allow_with_redaction", "rewrite_output", "quarantine":
 		return true
 	default:
 		return false
@@ -0,0 +1,111 @@
+// Package tlsverify scopes upstream TLS certificate verification bypass to
+// an explicit list of hosts.
+//
+// Internal services often present self-signed certificates. Rather than
+// disabling verification globally, operators list those hosts; every other
+// host keeps full chain and hostname verification.
+package tlsverify
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SkipHosts is a set of hostnames whose certificates are not verified.
+// A nil SkipHosts verifies every host.
+type SkipHosts struct {
+	hosts map[string]bool
+	log   io.Writer
+
+	mu     sync.Mutex
+	warned map[string]bool
+}
+
+// ParseSkipHosts normalizes hostnames (lowercased, port stripped). Wildcards
+// and CIDRs are rejected so the bypass cannot silently widen. Returns nil
+// for an empty list.
+func ParseSkipHosts(hosts []string) (*SkipHosts, error) {
+	if len(hosts) == 0 {
+		return nil, nil
+	}
+	s := &SkipHosts{hosts: make(map[string]bool, len(hosts)), log: os.Stderr, warned: make(map[string]bool)}
+	for _, h := range hosts {
+		name := strings.ToLower(strings.TrimSpace(h))
+		if host, _, err := net.SplitHostPort(name); err == nil {
+			name = host
+		}
+		name = strings.Trim(name, "[]")
+		if name == "" || strings.ContainsAny(name, "*/") {
+			return nil, fmt.Errorf("invalid insecure skip-verify host %q: want an exact hostname or IP", h)
+		}
+		s.hosts[name] = true
+	}
+	return s, nil
+}
+
+// Skips reports whether certificate verification is bypassed for host.
+func (s *SkipHosts) Skips(host string) bool {
+	return s != nil && s.hosts[strings.ToLower(strings.Trim(host, "[]"))]
+}
+
+// Config returns the client TLS config for connecting to host: base
+// unchanged when host is not listed, otherwise a copy with verification
+// disabled. Any VerifyConnection on base (e.g. SPKI pinning) still runs.
+func (s *SkipHosts) Config(base *tls.Config, host string) *tls.Config {
+	if !s.Skips(host) {
+		return base
+	}
+	s.warn(host)
+	cfg := base.Clone()
+	cfg.InsecureSkipVerify = true
+	return cfg
+}
+
+// Apply installs a TLS dialer on t that picks the per-host config from
+// t.TLSClientConfig, since crypto/tls verification is all-or-nothing per
+// config. HTTP/2 negotiation is kept. A nil SkipHosts leaves t unchanged.
+func (s *SkipHosts) Apply(t *http.Transport) {
+	if s == nil {
+		return
+	}
+	dialer := &tls.Dialer{NetDialer: &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}}
+	t.DialTLSContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, _, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		base := t.TLSClientConfig
+		if base == nil {
+			base = &tls.Config{}
+		}
+		base = base.Clone()
+		if base.ServerName == "" {
+			base.ServerName = host
+		}
+		if len(base.NextProtos) == 0 && t.ForceAttemptHTTP2 {
+			base.NextProtos = []string{"h2", "http/1.1"}
+		}
+		d := *dialer
+		d.Config = s.Config(base, host)
+		return d.DialContext(ctx, network, addr)
+	}
+}
+
+// warn logs the first skipped connection per host.
+func (s *SkipHosts) warn(host string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.warned[host] {
+		return
+	}
+	s.warned[host] = true
+	fmt.Fprintf(s.log, "WARNING: TLS certificate verification SKIPPED for upstream host %s (insecure skip-verify host list)\n", host)
+}
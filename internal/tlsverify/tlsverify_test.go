@@ -0,0 +1,71 @@
+package tlsverify
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func get(t *testing.T, skip *SkipHosts, roots *x509.CertPool, url string) error {
+	t.Helper()
+	tr := http.DefaultTransport.(*http.Transport).Clone()
+	tr.TLSClientConfig = &tls.Config{RootCAs: roots}
+	skip.Apply(tr)
+	defer tr.CloseIdleConnections()
+	resp, err := (&http.Client{Transport: tr}).Get(url)
+	if err == nil {
+		resp.Body.Close()
+	}
+	return err
+}
+
+func TestSkipHostsScopesBypass(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer srv.Close()
+
+	listed, err := ParseSkipHosts([]string{"127.0.0.1:8443"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var logs bytes.Buffer
+	listed.log = &logs
+	for i := 0; i < 2; i++ {
+		if err := get(t, listed, nil, srv.URL); err != nil {
+			t.Fatalf("listed host with self-signed cert should connect: %v", err)
+		}
+	}
+	if n := strings.Count(logs.String(), "WARNING"); n != 1 {
+		t.Errorf("expected one warning for the skipped host, got %d:\n%s", n, logs.String())
+	}
+
+	unlisted, _ := ParseSkipHosts([]string{"vault.internal"})
+	if err := get(t, unlisted, nil, srv.URL); err == nil {
+		t.Fatal("unlisted host with self-signed cert must be rejected")
+	}
+
+	// Unlisted hosts still pass normal verification against trusted roots.
+	roots := x509.NewCertPool()
+	roots.AddCert(srv.Certificate())
+	if err := get(t, unlisted, roots, srv.URL); err != nil {
+		t.Fatalf("trusted unlisted host should connect: %v", err)
+	}
+}
+
+func TestParseSkipHostsRejectsWildcards(t *testing.T) {
+	for _, h := range []string{"*.internal", "", "10.0.0.0/8"} {
+		if _, err := ParseSkipHosts([]string{h}); err == nil {
+			t.Errorf("ParseSkipHosts(%q) should fail", h)
+		}
+	}
+	s, err := ParseSkipHosts([]string{"Vault.Internal:8200"})
+	if err != nil || !s.Skips("vault.internal") {
+		t.Errorf("expected normalized host, got %v %v", s, err)
+	}
+	if s, _ := ParseSkipHosts(nil); s.Skips("anything") {
+		t.Error("nil SkipHosts must verify every host")
+	}
+}
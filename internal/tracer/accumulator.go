@@ -105,6 +105,17 @@ func (ta *TraceAccumulator) BuildEvent(
 		zonesStr = append(zonesStr, string(z))
 	}
 
+	actionMap := map[string]any{
+		"type":      "tool_call",
+		"tool":      action.Tool,
+		"resource":  action.Resource,
+		"operation": action.Operation,
+		"params":    action.Params,
+	}
+	if len(action.Labels) > 0 {
+		actionMap["labels"] = action.Labels
+	}
+
 	return Event{
 		Timestamp:    UTCNowISO(),
 		TraceID:      ta.State.TraceID,
@@ -114,13 +125,7 @@ func (ta *TraceAccumulator) BuildEvent(
 		SessionID:    ta.State.SessionID,
 		Actor:        actor,
 		Purpose:      purpose,
-		Action: map[string]any{
-			"type":      "tool_call",
-			"tool":      action.Tool,
-			"resource":  action.Resource,
-			"operation": action.Operation,
-			"params":    action.Params,
-		},
+		Action:       actionMap,
 		Data: map[string]any{
 			"classification":        string(meta.Sensitivity),
 			"tags":                  meta.Tags,
@@ -185,6 +190,7 @@ func (ta *TraceAccumulator) ToJSON() map[string]any {
 
 	return map[string]any{
 		"trace_state": stateMap,
+		"stats":       ta.Stats(),
 		"events":      ta.Events,
 	}
 }
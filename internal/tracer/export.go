@@ -0,0 +1,71 @@
+package tracer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+)
+
+// ExportedAction is one line of a JSONL trace export: a recorded action
+// with the actor, purpose, and decision that applied to it.
+type ExportedAction struct {
+	Timestamp string         `json:"ts"`
+	TraceID   string         `json:"trace_id"`
+	SpanID    string         `json:"span_id"`
+	AgentID   string         `json:"agent_id,omitempty"`
+	SessionID string         `json:"session_id,omitempty"`
+	Actor     map[string]any `json:"actor"`
+	Purpose   string         `json:"purpose"`
+	Action    map[string]any `json:"action"`
+	Decision  map[string]any `json:"decision"`
+	Tier      int            `json:"tier"`
+}
+
+// ExportJSONL writes one JSON line per recorded action, in record order.
+func (ta *TraceAccumulator) ExportJSONL(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	for _, ev := range ta.Events {
+		line := ExportedAction{
+			Timestamp: ev.Timestamp,
+			TraceID:   ev.TraceID,
+			SpanID:    ev.SpanID,
+			AgentID:   ev.AgentID,
+			SessionID: ev.SessionID,
+			Actor:     ev.Actor,
+			Purpose:   ev.Purpose,
+			Action:    ev.Action,
+			Decision:  ev.Decision,
+			Tier:      decisionTier(ev.Decision),
+		}
+		if err := enc.Encode(line); err != nil {
+			return fmt.Errorf("export trace: %w", err)
+		}
+	}
+	return nil
+}
+
+// AppendJSONL appends the trace export to the file at path, creating it
+// with owner-only permissions if needed.
+func (ta *TraceAccumulator) AppendJSONL(path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("open trace file: %w", err)
+	}
+	if err := ta.ExportJSONL(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// decisionTier reads the enforcement tier recorded in a decision map.
+func decisionTier(decision map[string]any) int {
+	switch t := decision["tier"].(type) {
+	case int:
+		return t
+	case float64:
+		return int(t)
+	}
+	return 0
+}
@@ -0,0 +1,86 @@
+package tracer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ppiankov/chainwatch/internal/model"
+)
+
+func TestExportJSONLRoundTrip(t *testing.T) {
+	acc := NewAccumulator("t-export")
+	actor := map[string]any{"cli": "chainwatch exec"}
+	acc.RecordAction(actor, "ops", &model.Action{Tool: "command", Resource: "ls /tmp", Operation: "execute"},
+		map[string]any{"result": "allow", "reason": "ok", "tier": 0}, "")
+	acc.RecordAction(actor, "ops", &model.Action{Tool: "command", Resource: "rm -rf /", Operation: "execute"},
+		map[string]any{"result": "deny", "reason": "destructive", "tier": 3}, "")
+
+	var buf bytes.Buffer
+	if err := acc.ExportJSONL(&buf); err != nil {
+		t.Fatalf("ExportJSONL: %v", err)
+	}
+
+	var lines []ExportedAction
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var line ExportedAction
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("line %d does not parse: %v", len(lines)+1, err)
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) != len(acc.Events) {
+		t.Fatalf("expected %d lines, got %d", len(acc.Events), len(lines))
+	}
+
+	for i, line := range lines {
+		ev := acc.Events[i]
+		if line.Timestamp != ev.Timestamp || line.TraceID != "t-export" || line.SpanID != ev.SpanID {
+			t.Errorf("line %d: identity mismatch: %+v", i, line)
+		}
+		if line.Purpose != "ops" || line.Actor["cli"] != "chainwatch exec" {
+			t.Errorf("line %d: actor/purpose mismatch: %+v", i, line)
+		}
+		if line.Action["resource"] != ev.Action["resource"] || line.Action["tool"] != "command" {
+			t.Errorf("line %d: action mismatch: %v", i, line.Action)
+		}
+		if line.Decision["result"] != ev.Decision["result"] || line.Decision["reason"] != ev.Decision["reason"] {
+			t.Errorf("line %d: decision mismatch: %v", i, line.Decision)
+		}
+	}
+	if lines[0].Tier != 0 || lines[1].Tier != 3 {
+		t.Errorf("expected tiers 0 and 3, got %d and %d", lines[0].Tier, lines[1].Tier)
+	}
+}
+
+func TestAppendJSONLAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trace.jsonl")
+	acc := NewAccumulator("t-append")
+	acc.RecordAction(nil, "ops", &model.Action{Tool: "command", Resource: "ls", Operation: "execute"},
+		map[string]any{"result": "allow"}, "")
+
+	for range 2 {
+		if err := acc.AppendJSONL(path); err != nil {
+			t.Fatalf("AppendJSONL: %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := bytes.Count(data, []byte("\n")); n != 2 {
+		t.Errorf("expected 2 lines after two appends, got %d", n)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if perm := info.Mode().Perm(); perm != 0o600 {
+		t.Errorf("expected 0600 permissions, got %o", perm)
+	}
+}
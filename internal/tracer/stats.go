@@ -0,0 +1,142 @@
+package tracer
+
+import (
+	"time"
+
+	"github.com/ppiankov/chainwatch/internal/model"
+)
+
+// PayloadBytesKey is the optional decision field carrying the size of the
+// payload an action forwarded (e.g. tool-call arguments in the
+// interceptor). Stats prefers it over the action's volume bytes.
+const PayloadBytesKey = "payload_bytes"
+
+// Stats aggregates the recorded events of a trace, answering "how risky
+// was this session" without re-walking the events.
+type Stats struct {
+	ToolCalls      int                   `json:"tool_calls"`
+	Blocked        int                   `json:"blocked"`
+	BytesForwarded int                   `json:"bytes_forwarded"`
+	CallsPerMinute float64               `json:"calls_per_minute"`
+	Decisions      map[string]int        `json:"decisions"`
+	Tools          map[string]*CallStats `json:"tools"`
+	Agents         map[string]*CallStats `json:"agents,omitempty"`
+}
+
+// CallStats is the per-tool or per-agent breakdown within Stats.
+type CallStats struct {
+	Calls          int `json:"calls"`
+	Blocked        int `json:"blocked"`
+	BytesForwarded int `json:"bytes_forwarded"`
+}
+
+// Stats computes aggregates over the recorded events. An event is blocked
+// when its decision result is deny, require_approval, or quarantine; only
+// unblocked events count towards bytes forwarded. The call rate spans the
+// first to the last event, with a floor of one minute.
+func (ta *TraceAccumulator) Stats() Stats {
+	st := Stats{
+		Decisions: make(map[string]int),
+		Tools:     make(map[string]*CallStats),
+	}
+
+	var first, last time.Time
+	for _, ev := range ta.Events {
+		st.ToolCalls++
+
+		result, _ := ev.Decision["result"].(string)
+		if result != "" {
+			st.Decisions[result]++
+		}
+		blocked := isBlocking(result)
+		bytes := 0
+		if blocked {
+			st.Blocked++
+		} else {
+			bytes = eventBytes(ev)
+		}
+		st.BytesForwarded += bytes
+
+		tool, _ := ev.Action["tool"].(string)
+		if tool == "" {
+			tool = "unknown"
+		}
+		st.Tools[tool] = st.Tools[tool].add(blocked, bytes)
+
+		if agent := eventAgent(ev); agent != "" {
+			if st.Agents == nil {
+				st.Agents = make(map[string]*CallStats)
+			}
+			st.Agents[agent] = st.Agents[agent].add(blocked, bytes)
+		}
+
+		if ts, err := time.Parse("2006-01-02T15:04:05.000Z", ev.Timestamp); err == nil {
+			if first.IsZero() || ts.Before(first) {
+				first = ts
+			}
+			if ts.After(last) {
+				last = ts
+			}
+		}
+	}
+
+	if st.ToolCalls > 0 {
+		minutes := max(last.Sub(first).Minutes(), 1)
+		st.CallsPerMinute = float64(st.ToolCalls) / minutes
+	}
+	return st
+}
+
+func (cs *CallStats) add(blocked bool, bytes int) *CallStats {
+	if cs == nil {
+		cs = &CallStats{}
+	}
+	cs.Calls++
+	if blocked {
+		cs.Blocked++
+	}
+	cs.BytesForwarded += bytes
+	return cs
+}
+
+func isBlocking(result string) bool {
+	switch model.Decision(result) {
+	case model.Deny, model.RequireApproval, model.Quarantine:
+		return true
+	}
+	return false
+}
+
+// eventBytes returns the decision's payload size when recorded, else the
+// action's volume bytes.
+func eventBytes(ev Event) int {
+	if n, ok := toInt(ev.Decision[PayloadBytesKey]); ok {
+		return n
+	}
+	if vol, ok := ev.Data["volume"].(map[string]any); ok {
+		n, _ := toInt(vol["bytes"])
+		return n
+	}
+	return 0
+}
+
+// eventAgent returns the actor's agent_id, set per request from an agent
+// header, else the trace's agent ID.
+func eventAgent(ev Event) string {
+	if id, ok := ev.Actor["agent_id"].(string); ok && id != "" {
+		return id
+	}
+	return ev.AgentID
+}
+
+func toInt(v any) (int, bool) {
+	switch n := v.(type) {
+	case int:
+		return n, true
+	case int64:
+		return int(n), true
+	case float64:
+		return int(n), true
+	}
+	return 0, false
+}
@@ -0,0 +1,71 @@
+package tracer
+
+import (
+	"testing"
+
+	"github.com/ppiankov/chainwatch/internal/model"
+)
+
+func TestStatsMixedSession(t *testing.T) {
+	acc := NewAccumulator("test-stats")
+
+	record := func(agent, tool, result string, bytes int) {
+		acc.RecordAction(
+			map[string]any{"agent_id": agent},
+			"test",
+			&model.Action{Tool: tool, Resource: "r", RawMeta: map[string]any{"bytes": bytes}},
+			map[string]any{"result": result},
+			"",
+		)
+	}
+	record("agent-a", "file_read", "allow", 100)
+	record("agent-a", "command", "deny", 50)
+	record("agent-b", "command", "allow", 30)
+	record("agent-b", "http_proxy", "require_approval", 70)
+	record("agent-b", "file_write", "quarantine", 10)
+
+	st := acc.Stats()
+	if st.ToolCalls != 5 || st.Blocked != 3 {
+		t.Errorf("calls=%d blocked=%d, want 5 and 3", st.ToolCalls, st.Blocked)
+	}
+	if st.BytesForwarded != 130 {
+		t.Errorf("bytes forwarded = %d, want 130 (blocked calls excluded)", st.BytesForwarded)
+	}
+	if st.Decisions["allow"] != 2 || st.Decisions["deny"] != 1 || st.Decisions["require_approval"] != 1 {
+		t.Errorf("unexpected decisions: %v", st.Decisions)
+	}
+	if cmd := st.Tools["command"]; cmd == nil || cmd.Calls != 2 || cmd.Blocked != 1 || cmd.BytesForwarded != 30 {
+		t.Errorf("unexpected command stats: %+v", cmd)
+	}
+	if a := st.Agents["agent-a"]; a == nil || a.Calls != 2 || a.Blocked != 1 || a.BytesForwarded != 100 {
+		t.Errorf("unexpected agent-a stats: %+v", a)
+	}
+	if b := st.Agents["agent-b"]; b == nil || b.Calls != 3 || b.Blocked != 2 {
+		t.Errorf("unexpected agent-b stats: %+v", b)
+	}
+	if st.CallsPerMinute != 5 {
+		t.Errorf("calls per minute = %v, want 5 within the one-minute floor", st.CallsPerMinute)
+	}
+
+	if _, ok := acc.ToJSON()["stats"].(Stats); !ok {
+		t.Error("expected stats in ToJSON snapshot")
+	}
+}
+
+func TestStatsPayloadBytesOverridesVolume(t *testing.T) {
+	acc := NewAccumulator("test-payload")
+	acc.RecordAction(nil, "test",
+		&model.Action{Tool: "command", Resource: "ls", RawMeta: map[string]any{"bytes": 0}},
+		map[string]any{"result": "allow", PayloadBytesKey: 42}, "")
+
+	if st := acc.Stats(); st.BytesForwarded != 42 {
+		t.Errorf("bytes forwarded = %d, want 42", st.BytesForwarded)
+	}
+}
+
+func TestStatsEmpty(t *testing.T) {
+	st := NewAccumulator("empty").Stats()
+	if st.ToolCalls != 0 || st.CallsPerMinute != 0 || len(st.Tools) != 0 || st.Agents != nil {
+		t.Errorf("unexpected stats for empty trace: %+v", st)
+	}
+}
@@ -9,6 +9,12 @@ import (
 // HighVolumeThreshold is the byte threshold for HIGH_VOLUME zone (10MB).
 const HighVolumeThreshold = 10_000_000
 
+// Content tags recognized by DetectZones.
+const (
+	TagSecret = "secret" // action data carries a credential
+	TagPII    = "pii"    // action data carries personal information
+)
+
 // zoneRule defines pattern-based detection for a single zone.
 type zoneRule struct {
 	URLPatterns     []string
@@ -47,6 +53,18 @@ var zoneDetectionRules = map[model.Zone]zoneRule{
 	},
 }
 
+// Patterns returns the resource and tool substrings that place an action in
+// zone z, for documentation and export. Zones entered by operation, volume,
+// or content tags rather than patterns return only their static patterns.
+func Patterns(z model.Zone) []string {
+	rule := zoneDetectionRules[z]
+	var patterns []string
+	patterns = append(patterns, rule.URLPatterns...)
+	patterns = append(patterns, rule.FilePatterns...)
+	patterns = append(patterns, rule.CommandPatterns...)
+	return patterns
+}
+
 // DetectZones examines an action and current state to determine which
 // zones the action touches. Returns a set of newly detected zones.
 func DetectZones(action *model.Action, state *model.TraceState) map[model.Zone]bool {
@@ -73,13 +91,32 @@ func DetectZones(action *model.Action, state *model.TraceState) map[model.Zone]b
 		}
 	}
 
-	// EGRESS_ACTIVE: POST/PUT to external URL
+	// EGRESS_ACTIVE: POST/PUT/PATCH/DELETE to external URL
 	if isWriteHTTPOperation(operation) && isExternalURL(resource) {
 		zones[model.ZoneEgressActive] = true
 	}
 
-	// HIGH_VOLUME: accumulated bytes exceed threshold
+	// A safe HTTP method (GET/HEAD/OPTIONS) only reads a commitment endpoint;
+	// it signals intent, not commitment. Mutating methods keep the commitment.
+	if zones[model.ZoneCommercialCommitment] && model.IsSafeHTTPMethod(operation) {
+		delete(zones, model.ZoneCommercialCommitment)
+		zones[model.ZoneCommercialIntent] = true
+	}
+
+	// Content tags set by classifiers that inspect outgoing data (e.g. an
+	// HTTP body carrying a secret or PII) place the action in the same
+	// zones as touching such a file.
 	meta := action.NormalizedMeta()
+	for _, tag := range meta.Tags {
+		switch tag {
+		case TagSecret:
+			zones[model.ZoneCredentialAdjacent] = true
+		case TagPII:
+			zones[model.ZoneSensitiveData] = true
+		}
+	}
+
+	// HIGH_VOLUME: accumulated bytes exceed threshold
 	totalBytes := state.VolumeBytes + meta.Bytes
 	if totalBytes > HighVolumeThreshold {
 		zones[model.ZoneHighVolume] = true
@@ -122,7 +159,7 @@ func isCredentialResource(resource string) bool {
 }
 
 func isWriteHTTPOperation(operation string) bool {
-	return operation == "post" || operation == "put" || operation == "delete"
+	return model.IsMutatingHTTPMethod(operation)
 }
 
 func isExternalURL(resource string) bool {
@@ -77,6 +77,41 @@ func TestDetectZonesCommercialCommitment(t *testing.T) {
 	}
 }
 
+func TestDetectZonesPatchToCommitmentIsMutation(t *testing.T) {
+	action := &model.Action{
+		Tool:      "http",
+		Resource:  "https://store.example.com/checkout/order/42",
+		Operation: "patch",
+	}
+	zones := DetectZones(action, model.NewTraceState("test"))
+
+	if !zones[model.ZoneCommercialCommitment] {
+		t.Error("expected PATCH to keep COMMERCIAL_COMMITMENT")
+	}
+	if !zones[model.ZoneEgressActive] {
+		t.Error("expected PATCH to external URL to be EGRESS_ACTIVE")
+	}
+}
+
+func TestDetectZonesHeadToCommitmentIsRead(t *testing.T) {
+	action := &model.Action{
+		Tool:      "http",
+		Resource:  "https://store.example.com/checkout/order/42",
+		Operation: "head",
+	}
+	zones := DetectZones(action, model.NewTraceState("test"))
+
+	if zones[model.ZoneCommercialCommitment] {
+		t.Error("HEAD must not enter COMMERCIAL_COMMITMENT")
+	}
+	if !zones[model.ZoneCommercialIntent] {
+		t.Error("expected HEAD on a commitment endpoint to register COMMERCIAL_INTENT")
+	}
+	if zones[model.ZoneEgressActive] {
+		t.Error("HEAD must not be EGRESS_ACTIVE")
+	}
+}
+
 func TestDetectZonesCommercialIntent(t *testing.T) {
 	action := &model.Action{
 		Tool:      "browser",
@@ -174,3 +209,24 @@ func TestDetectZonesSafeAction(t *testing.T) {
 		t.Errorf("expected no zones for safe action, got %v", zones)
 	}
 }
+
+func TestDetectZonesContentTags(t *testing.T) {
+	action := &model.Action{
+		Tool:      "http_proxy",
+		Resource:  "https://api.example.com/notes",
+		Operation: "post",
+		RawMeta:   map[string]any{"sensitivity": "high", "tags": []any{TagSecret, TagPII}},
+	}
+
+	zones := DetectZones(action, model.NewTraceState("test"))
+
+	if !zones[model.ZoneCredentialAdjacent] {
+		t.Error("expected CREDENTIAL_ADJACENT zone for secret tag")
+	}
+	if !zones[model.ZoneSensitiveData] {
+		t.Error("expected SENSITIVE_DATA zone for pii tag")
+	}
+	if zones[model.ZoneCredentialExposed] {
+		t.Error("sending a secret is not reading a credential file")
+	}
+}
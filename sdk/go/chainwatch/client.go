@@ -51,11 +51,13 @@ func New(opts ...Option) (*Client, error) {
 		policyCfg = profile.ApplyToPolicy(prof, policyCfg)
 	}
 
-	approvalStore, err := approval.NewStore(approval.DefaultDir())
+	approvalStore, err := approval.Open(approval.DefaultDir(), policyCfg.ApprovalStore)
 	if err != nil {
 		return nil, fmt.Errorf("chainwatch: failed to create approval store: %w", err)
 	}
 	approvalStore.Cleanup()
+	approvalStore.SetThrottle(policyCfg.ApprovalThrottle)
+	approvalStore.SetReasonRequired(policyCfg.ReasonRequiredKeys())
 
 	return &Client{
 		cfg:       cfg,
@@ -68,7 +70,7 @@ func New(opts ...Option) (*Client, error) {
 
 // Check evaluates policy for an action without executing anything.
 func (c *Client) Check(action Action) Result {
-	internal := toInternalAction(action)
+	internal := toInternalAction(action, c.cfg.labels)
 
 	c.mu.Lock()
 	pr := policy.Evaluate(internal, c.tracer.State, c.cfg.purpose, c.cfg.agentID, c.dl, c.policyCfg)
@@ -14,6 +14,10 @@
 //	    Operation: "read",
 //	})
 //
+// WithLabels and Action.Labels attach operator context (tenant, environment,
+// ticket ID) that is recorded in the trace and matched by policy rule label
+// selectors.
+//
 // The SDK links directly against internal packages for zero-subprocess
 // overhead. External users import github.com/ppiankov/chainwatch/sdk/go/chainwatch.
 package chainwatch
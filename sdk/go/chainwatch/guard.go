@@ -14,6 +14,7 @@ type ToolFunc func(ctx context.Context, action Action) (any, error)
 
 // Wrap returns a new ToolFunc that evaluates policy before calling fn.
 // If policy denies the action, returns a *BlockedError without calling fn.
+// The SDK cannot contain a tool's effects, so quarantine is treated as deny.
 func (c *Client) Wrap(fn ToolFunc, opts ...WrapOption) ToolFunc {
 	wcfg := wrapConfig{purpose: c.cfg.purpose, agentID: c.cfg.agentID}
 	for _, o := range opts {
@@ -21,7 +22,7 @@ func (c *Client) Wrap(fn ToolFunc, opts ...WrapOption) ToolFunc {
 	}
 
 	return func(ctx context.Context, action Action) (any, error) {
-		internal := toInternalAction(action)
+		internal := toInternalAction(action, c.cfg.labels)
 
 		c.mu.Lock()
 		result := policy.Evaluate(internal, c.tracer.State, wcfg.purpose, wcfg.agentID, c.dl, c.policyCfg)
@@ -31,10 +32,11 @@ func (c *Client) Wrap(fn ToolFunc, opts ...WrapOption) ToolFunc {
 			"policy_id":    result.PolicyID,
 			"approval_key": result.ApprovalKey,
 		}, "")
+		needConfirm := c.policyCfg.ConfirmRequired(result, c.tracer.State)
 		c.mu.Unlock()
 
 		switch result.Decision {
-		case model.Deny:
+		case model.Deny, model.Quarantine:
 			return nil, &BlockedError{
 				Action:      action,
 				Decision:    Decision(result.Decision),
@@ -45,12 +47,16 @@ func (c *Client) Wrap(fn ToolFunc, opts ...WrapOption) ToolFunc {
 
 		case model.RequireApproval:
 			if result.ApprovalKey != "" {
-				status, _ := c.approvals.Check(result.ApprovalKey)
-				if status == approval.StatusApproved {
+				status, _ := c.approvals.CheckTrace(result.ApprovalKey, c.tracer.State.TraceID)
+				if status == approval.StatusApproved && needConfirm {
+					result = approval.ConfirmUnsupported(result)
+				} else if status == approval.StatusApproved {
 					c.approvals.Consume(result.ApprovalKey)
 					return fn(ctx, action)
 				}
-				if status != approval.StatusPending && status != approval.StatusDenied {
+				if status == approval.StatusThrottled {
+					result = c.approvals.ThrottleDeny(result)
+				} else if status != approval.StatusPending && status != approval.StatusDenied {
 					c.approvals.Request(result.ApprovalKey, result.Reason, result.PolicyID, action.Resource, c.cfg.agentID)
 				}
 			}
@@ -3,10 +3,14 @@ package chainwatch
 import (
 	"context"
 	"fmt"
+	"os"
+	"path/filepath"
 	"reflect"
 	"sync"
 	"testing"
 	"time"
+
+	"github.com/ppiankov/chainwatch/internal/tracer"
 )
 
 func TestWrapBlocksDenied(t *testing.T) {
@@ -253,3 +257,68 @@ func TestWrapInnerNotCalledOnDeny(t *testing.T) {
 		t.Errorf("expected inner to not be called, was called %d times", callCount)
 	}
 }
+
+func TestWrapLabels(t *testing.T) {
+	policyPath := filepath.Join(t.TempDir(), "policy.yaml")
+	policyYAML := "rules:\n  - purpose: \"*\"\n    resource_pattern: \"*deploy*\"\n    decision: deny\n    labels:\n      env: prod\n"
+	if err := os.WriteFile(policyPath, []byte(policyYAML), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	c, err := New(WithPurpose("test"), WithPolicy(policyPath), WithLabels(map[string]string{"tenant": "acme"}))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	inner := func(ctx context.Context, a Action) (any, error) {
+		return "ok", nil
+	}
+	wrapped := c.Wrap(inner)
+
+	if _, err := wrapped(context.Background(), Action{Tool: "command", Resource: "echo deploy", Operation: "execute"}); err != nil {
+		t.Fatalf("expected unlabeled action allowed, got %v", err)
+	}
+
+	_, err = wrapped(context.Background(), Action{
+		Tool:      "command",
+		Resource:  "echo deploy",
+		Operation: "execute",
+		Labels:    map[string]string{"env": "prod"},
+	})
+	blocked := requireBlocked(t, err)
+	if blocked.Decision != Deny {
+		t.Errorf("expected deny for env=prod, got %s", blocked.Decision)
+	}
+
+	events := c.TraceSummary()["events"].([]tracer.Event)
+	labels, _ := events[len(events)-1].Action["labels"].(map[string]string)
+	if labels["tenant"] != "acme" || labels["env"] != "prod" {
+		t.Errorf("expected merged labels in trace, got %v", labels)
+	}
+}
+
+func TestWrapQuarantineBlocks(t *testing.T) {
+	policyPath := filepath.Join(t.TempDir(), "policy.yaml")
+	policyYAML := "rules:\n  - purpose: \"*\"\n    resource_pattern: \"*untrusted*\"\n    decision: quarantine\n"
+	if err := os.WriteFile(policyPath, []byte(policyYAML), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	c, err := New(WithPurpose("test"), WithPolicy(policyPath))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+
+	called := false
+	wrapped := c.Wrap(func(ctx context.Context, a Action) (any, error) {
+		called = true
+		return "ok", nil
+	})
+
+	_, err = wrapped(context.Background(), Action{Tool: "file_write", Resource: "/tmp/untrusted.sh", Operation: "write"})
+	blocked := requireBlocked(t, err)
+	if blocked.Decision != Quarantine {
+		t.Errorf("expected quarantine decision, got %s", blocked.Decision)
+	}
+	if called {
+		t.Error("quarantined tool must not run without containment")
+	}
+}
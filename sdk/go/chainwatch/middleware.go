@@ -15,7 +15,7 @@ func (c *Client) Middleware(next http.Handler) http.Handler {
 		action := actionFromRequest(r)
 		result := c.Check(action)
 
-		if result.Decision == Deny || result.Decision == RequireApproval {
+		if result.Decision == Deny || result.Decision == RequireApproval || result.Decision == Quarantine {
 			w.Header().Set("Content-Type", "application/json")
 			w.WriteHeader(http.StatusForbidden)
 			json.NewEncoder(w).Encode(map[string]any{
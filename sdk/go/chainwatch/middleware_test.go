@@ -4,6 +4,8 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -42,6 +44,28 @@ func TestMiddlewareBlocksDenied(t *testing.T) {
 	}
 }
 
+func TestMiddlewareBlocksQuarantined(t *testing.T) {
+	policyPath := filepath.Join(t.TempDir(), "policy.yaml")
+	policyYAML := "rules:\n  - purpose: \"*\"\n    resource_pattern: \"*untrusted*\"\n    decision: quarantine\n"
+	if err := os.WriteFile(policyPath, []byte(policyYAML), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	c, err := New(WithPurpose("test"), WithPolicy(policyPath))
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	handler := c.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Fatal("next handler should not be called")
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/untrusted/upload", nil))
+
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("expected 403 for quarantine, got %d", rec.Code)
+	}
+}
+
 func TestMiddlewareJSONBody(t *testing.T) {
 	c := newTestClient(t)
 	handler := c.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
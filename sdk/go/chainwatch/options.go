@@ -10,6 +10,7 @@ type clientConfig struct {
 	purpose      string
 	agentID      string
 	actor        map[string]any
+	labels       map[string]string
 }
 
 // WithProfile sets the safety profile (e.g., "clawbot").
@@ -42,6 +43,13 @@ func WithAgent(agentID string) Option {
 	return func(c *clientConfig) { c.agentID = agentID }
 }
 
+// WithLabels attaches operator-supplied labels (tenant, environment, ticket
+// ID) to every action. Labels are recorded in the trace and matched by
+// policy rules with a labels selector. Per-action labels take precedence.
+func WithLabels(labels map[string]string) Option {
+	return func(c *clientConfig) { c.labels = labels }
+}
+
 // WrapOption configures a single Wrap call.
 type WrapOption func(*wrapConfig)
 
@@ -14,6 +14,7 @@ const (
 	Deny               Decision = Decision(model.Deny)
 	AllowWithRedaction Decision = Decision(model.AllowWithRedaction)
 	RequireApproval    Decision = Decision(model.RequireApproval)
+	Quarantine         Decision = Decision(model.Quarantine)
 )
 
 // Action describes what a tool intends to do.
@@ -22,6 +23,9 @@ type Action struct {
 	Resource  string         // target: URL, file path, command string
 	Operation string         // operation type: "execute", "read", "write", "GET", "POST"
 	Meta      map[string]any // optional: sensitivity, tags, bytes, rows, egress, destination
+
+	// Labels is optional per-action context, merged over the client's WithLabels.
+	Labels map[string]string
 }
 
 // Result is a policy evaluation outcome.
@@ -38,7 +42,8 @@ func (r Result) Allowed() bool {
 	return r.Decision == Allow || r.Decision == AllowWithRedaction
 }
 
-// BlockedError is returned when policy denies or requires approval for an action.
+// BlockedError is returned when policy denies, quarantines, or requires
+// approval for an action.
 type BlockedError struct {
 	Action      Action
 	Decision    Decision
@@ -52,7 +57,8 @@ func (e *BlockedError) Error() string {
 }
 
 // toInternalAction maps an SDK Action to an internal model.Action.
-func toInternalAction(a Action) *model.Action {
+// Client labels are merged under the action's own labels.
+func toInternalAction(a Action, labels map[string]string) *model.Action {
 	rawMeta := a.Meta
 	if rawMeta == nil {
 		rawMeta = model.DefaultResultMeta().ToMap()
@@ -63,7 +69,23 @@ func toInternalAction(a Action) *model.Action {
 		Operation: a.Operation,
 		Params:    rawMeta,
 		RawMeta:   rawMeta,
+		Labels:    mergeLabels(labels, a.Labels),
+	}
+}
+
+// mergeLabels returns base overlaid with override. Returns nil when both are empty.
+func mergeLabels(base, override map[string]string) map[string]string {
+	if len(base) == 0 && len(override) == 0 {
+		return nil
+	}
+	merged := make(map[string]string, len(base)+len(override))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range override {
+		merged[k] = v
 	}
+	return merged
 }
 
 // toResult maps an internal PolicyResult to an SDK Result.